@@ -0,0 +1,1425 @@
+// Code generated by cmd/genclient. DO NOT EDIT.
+
+export interface AgingReport {
+  user_email: string;
+  as_of: string;
+  balances: BalanceAge[];
+  totals: AgingTotal[];
+}
+
+export interface AgingTotal {
+  bucket: string;
+  owed_to_me: number;
+  i_owe: number;
+}
+
+export interface ApplyDebtSimplificationRequest {
+  user_email: string;
+}
+
+export interface ArchiveEventRequest {
+  user_email: string;
+}
+
+export interface AuditLog {
+  id: number;
+  actor: string;
+  method: string;
+  route: string;
+  path: string;
+  payload_hash: string;
+  status: number;
+  latency_ms: number;
+  created_at: string;
+}
+
+export interface BalanceAge {
+  with_user_email: string;
+  with_user_name: string;
+  amount: number;
+  paid_amount: number;
+  oldest_source: LedgerSource;
+  oldest_at: string;
+  age_days: number;
+  bucket: string;
+}
+
+export interface BalanceDelta {
+  from_user_id: number;
+  to_user_id: number;
+  amount: number;
+}
+
+export interface BalanceDrift {
+  user1_id: number;
+  user2_id: number;
+  materialized: number;
+  ledger: number;
+}
+
+export interface BudgetStatus {
+  tag: string;
+  currency: string;
+  monthly_limit: number;
+  spent: number;
+  remaining: number;
+}
+
+export interface BudgetWarning {
+  user_email: string;
+  tag: string;
+  currency: string;
+  monthly_limit: number;
+  spent_after: number;
+}
+
+export interface ChannelPreferences {
+  email: boolean;
+  push: boolean;
+}
+
+export interface ClaimExpenseInviteRequest {
+  user_email: string;
+}
+
+export interface CommitImportRequest {
+  chunk_count: number;
+}
+
+export interface ComponentHealth {
+  name: string;
+  status: string;
+  latency_ms: number;
+  error?: string;
+  details?: Record<string, number>;
+}
+
+export interface Counterparty {
+  user_email: string;
+  user_name: string;
+  expense_count: number;
+  total_shared: number;
+  balance: number;
+  settlement_count: number;
+  average_settle_hours?: number | null;
+}
+
+export interface CreateEventRequest {
+  name: string;
+  created_by_email: string;
+  base_currency?: string;
+  unit?: string;
+}
+
+export interface CreateExpenseInviteRequest {
+  user_email: string;
+}
+
+export interface CreateExpenseRequest {
+  public_id?: string;
+  description: string;
+  tag: string;
+  total_amount: number;
+  tax_amount?: number;
+  tip_percentage?: number;
+  currency?: string;
+  refund_of?: string;
+  payee_party_id?: number | null;
+  location?: Location | null;
+  event_id?: number | null;
+  created_by_email: string;
+  split_method: string;
+  equal_splits?: EqualSplitRequest[];
+  percentage_splits?: PercentageSplitRequest[];
+  manual_splits?: ManualSplitRequest[];
+  days_splits?: DaysSplitRequest[];
+  weighted_splits?: WeightedSplitRequest[];
+  explain?: boolean;
+  payer_only?: boolean;
+  allow_zero_amounts?: boolean;
+  balance_strategy?: string;
+}
+
+export interface CreateGoalRequest {
+  user_email: string;
+  target_balance: number;
+  deadline: string;
+}
+
+export interface CreateImportRequest {
+  user_email: string;
+}
+
+export interface CreateLoanRequest {
+  lender_email: string;
+  borrower_email: string;
+  amount: number;
+  description: string;
+  due_date?: string;
+}
+
+export interface CreatePartyRequest {
+  name: string;
+}
+
+export interface CreateShareLinkRequest {
+  user_email: string;
+  expires_in?: string;
+}
+
+export interface CreateTagRuleRequest {
+  keyword: string;
+  tag: string;
+}
+
+export interface CreateUserRequest {
+  name: string;
+  email: string;
+  public_id?: string;
+}
+
+export interface CreatedExpenseInvite {
+  expense_id: number;
+  token: string;
+  url: string;
+  qr_code_url: string;
+  expires_at: string;
+}
+
+export interface CreatedShareLink {
+  id: number;
+  event_id: number;
+  created_by: number;
+  expires_at: string;
+  revoked_at?: string | null;
+  created_at: string;
+  token: string;
+  url: string;
+}
+
+export interface CurrencyConversion {
+  original_currency: string;
+  original_amount: number;
+  rate: number;
+}
+
+export interface DaysSplitRequest {
+  user_email: string;
+  join_date: string;
+  leave_date: string;
+  amount_paid?: number;
+}
+
+export interface DebtSimplification {
+  user_email: string;
+  transfers: SimplifiedTransfer[];
+  transfers_before: number;
+  blocked_by_dispute?: string[];
+}
+
+export interface DismissDisputeRequest {
+  user_email: string;
+}
+
+export interface DisputeExpenseRequest {
+  user_email: string;
+  reason: string;
+}
+
+export interface EqualSplitRequest {
+  user_email: string;
+  amount_paid?: number;
+}
+
+export interface Event {
+  id: number;
+  name: string;
+  created_by: number;
+  base_currency?: string;
+  unit?: string;
+  auto_settle: boolean;
+  archived_at?: string | null;
+  last_settle_up_at?: string | null;
+  created_at: string;
+}
+
+export interface EventExpense {
+  id: number;
+  description: string;
+  tag: string;
+  total_amount: number;
+  currency: string;
+  created_at: string;
+}
+
+export interface EventParticipant {
+  user_email: string;
+  user_name: string;
+  currency: string;
+  paid: number;
+  owed: number;
+  net: number;
+}
+
+export interface EventPreferences {
+  new_expense: boolean;
+  reminder: boolean;
+  digest: boolean;
+}
+
+export interface EventSummary {
+  id: number;
+  name: string;
+  created_by: number;
+  base_currency?: string;
+  unit?: string;
+  auto_settle: boolean;
+  archived_at?: string | null;
+  last_settle_up_at?: string | null;
+  created_at: string;
+  totals: EventTotal[];
+  participants: EventParticipant[];
+  settle_up: SettleUpTransfer[];
+}
+
+export interface EventTotal {
+  currency: string;
+  total_amount: number;
+  expense_count: number;
+}
+
+export interface Expense {
+  id: string;
+  description: string;
+  tag: string;
+  total_amount: number;
+  currency: string;
+  created_by: number;
+  status: string;
+  dispute_reason?: string;
+  refund_of?: string;
+  payee_party?: Party | null;
+  location?: Location | null;
+  event_id?: number | null;
+  balance_strategy?: string;
+  conversion?: CurrencyConversion | null;
+  adjustment?: ExpenseAdjustment | null;
+  created_at: string;
+  locked_at?: string | null;
+  settled_by?: number | null;
+  budget_warnings?: BudgetWarning[];
+  splits?: ExpenseSplit[];
+  balance_deltas?: BalanceDelta[];
+  undo_until?: string | null;
+  explanation?: SplitExplanation | null;
+  suggested_tag?: string;
+}
+
+export interface ExpenseAdjustment {
+  amount_paid: number;
+  amount_owed: number;
+}
+
+export interface ExpenseInvite {
+  expense_id: number;
+  description: string;
+  total_amount: number;
+  currency: string;
+  created_by: string;
+  event_name?: string;
+  expires_at: string;
+  shares: InviteShare[];
+}
+
+export interface ExpenseSplit {
+  user_id: number;
+  amount_paid: number;
+  amount_owed: number;
+}
+
+export interface FairnessCurrency {
+  currency: string;
+  total_paid: number;
+  mean_paid: number;
+  members: FairnessMember[];
+}
+
+export interface FairnessMember {
+  user_email: string;
+  user_name: string;
+  paid: number;
+  owed: number;
+  net: number;
+  deviation: number;
+  standing: string;
+}
+
+export interface FairnessReport {
+  event_id: number;
+  event_name: string;
+  currencies: FairnessCurrency[];
+}
+
+export interface Forecast {
+  user_email: string;
+  recurring: RecurringBill[];
+  months: ForecastMonth[];
+}
+
+export interface ForecastCategory {
+  tag: string;
+  recurring: number;
+  other: number;
+  amount: number;
+}
+
+export interface ForecastMonth {
+  month: string;
+  total: number;
+  categories: ForecastCategory[];
+}
+
+export interface Goal {
+  id: number;
+  user_id: number;
+  target_balance: number;
+  starting_balance: number;
+  deadline: string;
+  created_at: string;
+}
+
+export interface GoalNudge {
+  user_email: string;
+  user_name: string;
+  amount: number;
+}
+
+export interface GoalProgress {
+  id: number;
+  user_id: number;
+  target_balance: number;
+  starting_balance: number;
+  deadline: string;
+  created_at: string;
+  current_balance: number;
+  progress: number;
+  achieved: boolean;
+  days_left: number;
+  on_track: boolean;
+  nudges: GoalNudge[];
+}
+
+export interface HealthReport {
+  status: string;
+  components: ComponentHealth[];
+}
+
+export interface Heatmap {
+  user_email: string;
+  year: number;
+  max: number;
+  days: HeatmapDay[];
+}
+
+export interface HeatmapDay {
+  date: string;
+  amount: number;
+  expense_count: number;
+}
+
+export interface ImportSession {
+  id: number;
+  created_by: number;
+  status: string;
+  chunk_count: number;
+  received_chunks: number[];
+  rows_imported: number;
+  job_id?: number | null;
+  last_error?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface InviteClaim {
+  expense_id: number;
+  amount_owed?: number | null;
+  claimed_at?: string | null;
+  joined_event_id?: number | null;
+}
+
+export interface InviteShare {
+  name: string;
+  amount_owed: number;
+  claimed: boolean;
+}
+
+export interface Job {
+  id: number;
+  type: string;
+  payload: unknown;
+  status: string;
+  attempts: number;
+  max_attempts: number;
+  last_error?: string;
+  progress?: JobProgress | null;
+  run_at: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface JobProgress {
+  done: number;
+  total: number;
+}
+
+export interface LedgerLine {
+  id: number;
+  source: LedgerSource;
+  with_user_email: string;
+  with_user_name: string;
+  amount: number;
+  balance: number;
+  created_at: string;
+}
+
+export interface LedgerSource {
+  type: string;
+  id?: number;
+}
+
+export interface LedgerStatement {
+  user_email: string;
+  as_of: string;
+  entries: LedgerLine[];
+  balances: UserBalanceView[];
+  overall: number;
+}
+
+export interface Loan {
+  id: number;
+  lender_id: number;
+  borrower_id: number;
+  amount: number;
+  description: string;
+  due_date?: string | null;
+  reminded_at?: string | null;
+  created_at: string;
+}
+
+export interface LoanView {
+  id: number;
+  direction: string;
+  with_user_email: string;
+  with_user_name: string;
+  amount: number;
+  outstanding: number;
+  description: string;
+  due_date?: string;
+  overdue: boolean;
+  created_at: string;
+}
+
+export interface Location {
+  latitude: number;
+  longitude: number;
+  place_name?: string;
+}
+
+export interface ManualSplitRequest {
+  user_email: string;
+  amount_owed: number;
+  amount_paid?: number;
+}
+
+export interface NearbyExpense {
+  expense_id: string;
+  date: string;
+  tag: string;
+  description: string;
+  total_amount: number;
+  currency: string;
+  share: number;
+  status: string;
+  payee?: string;
+  created_by_name: string;
+  created_by_email: string;
+  is_payer: boolean;
+  running_balance?: number | null;
+  location: Location;
+  distance_meters: number;
+}
+
+export interface NextPayerSuggestion {
+  user_email: string;
+  user_name: string;
+  standings: PayerStanding[];
+}
+
+export interface NotificationPreferences {
+  channels: ChannelPreferences;
+  events: EventPreferences;
+  digest_frequency: string;
+}
+
+export interface OverallBalanceResponse {
+  overall_balance: number;
+}
+
+export interface Party {
+  id: number;
+  name: string;
+  created_at: string;
+}
+
+export interface PayerStanding {
+  user_email: string;
+  user_name: string;
+  paid: number;
+  owed: number;
+  ratio: number;
+}
+
+export interface PaymentCallbackRequest {
+  from_email: string;
+  to_email: string;
+  amount: number;
+  provider: string;
+  reference: string;
+}
+
+export interface PaymentHandles {
+  user_id: number;
+  upi_id?: string;
+  paypal_me?: string;
+  venmo?: string;
+}
+
+export interface PaymentLink {
+  provider: string;
+  url: string;
+  qr_code_url: string;
+}
+
+export interface PercentageSplitRequest {
+  user_email: string;
+  percentage: number;
+  amount_paid?: number;
+}
+
+export interface ProposeSettlementRequest {
+  payer_email: string;
+  payee_email: string;
+  amount: number;
+  on_behalf_of_email?: string;
+}
+
+export interface QueryStats {
+  name: string;
+  calls: number;
+  errors: number;
+  total_ms: number;
+  mean_ms: number;
+  max_ms: number;
+  rows_returned: number;
+  mean_rows_returned: number;
+  rows_examined?: number | null;
+  explain?: (Record<string, unknown>)[];
+  explained_at?: string | null;
+  explain_error?: string;
+}
+
+export interface QuotaUsage {
+  expenses_today: number;
+  expenses_per_day: number;
+  events: number;
+  max_events: number;
+  upload_bytes: number;
+  max_upload_bytes: number;
+}
+
+export interface RecurringBill {
+  description: string;
+  tag: string;
+  share: number;
+  months_seen: number;
+  last_date: string;
+}
+
+export interface ReviewCategory {
+  tag: string;
+  owed: number;
+  expense_count: number;
+}
+
+export interface ReviewCoSpender {
+  user_email: string;
+  user_name: string;
+  shared_expenses: number;
+}
+
+export interface ReviewExpense {
+  expense_id: number;
+  description: string;
+  tag: string;
+  total_amount: number;
+  currency: string;
+  date: string;
+}
+
+export interface ReviewMonth {
+  month: string;
+  owed: number;
+  expense_count: number;
+}
+
+export interface RevokeShareLinkRequest {
+  user_email: string;
+}
+
+export interface SetAutoSettleRequest {
+  user_email: string;
+  enabled: boolean;
+}
+
+export interface SetBaseCurrencyRequest {
+  user_email: string;
+  base_currency: string;
+}
+
+export interface SetDigestFrequencyRequest {
+  frequency: string;
+}
+
+export interface SetSplitWeightRequest {
+  split_weight: number;
+}
+
+export interface SetTagBudgetRequest {
+  user_email: string;
+  tag: string;
+  currency?: string;
+  monthly_limit: number;
+}
+
+export interface SettleUpTransfer {
+  from_email: string;
+  to_email: string;
+  currency: string;
+  amount: number;
+  payments?: PaymentLink[];
+}
+
+export interface Settlement {
+  id: number;
+  payer_id: number;
+  payee_id: number;
+  amount: number;
+  outstanding_amount?: number | null;
+  status: string;
+  via_user_id?: number | null;
+  via_outstanding_amount?: number | null;
+  payment_provider?: string;
+  payment_reference?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface SettlementPayment {
+  settlement_id: number;
+  provider: string;
+  payment_intent_id: string;
+  client_secret: string;
+  amount: number;
+  currency: string;
+  status: string;
+}
+
+export interface SettlementView {
+  id: number;
+  direction: string;
+  with_user_email: string;
+  with_user_name: string;
+  via_user_email?: string;
+  amount: number;
+  outstanding_amount?: number | null;
+  remaining_amount?: number | null;
+  via_outstanding_amount?: number | null;
+  via_remaining_amount?: number | null;
+  status: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface ShareExplanation {
+  user_email: string;
+  basis: number;
+  raw_amount: number;
+  rounded_down: number;
+  remainder: number;
+  tax_and_tip: number;
+  amount_owed: number;
+  amount_paid: number;
+}
+
+export interface ShareLink {
+  id: number;
+  event_id: number;
+  created_by: number;
+  expires_at: string;
+  revoked_at?: string | null;
+  created_at: string;
+}
+
+export interface SharedBalance {
+  name: string;
+  currency: string;
+  net: number;
+}
+
+export interface SharedLedger {
+  name: string;
+  archived: boolean;
+  expires_at: string;
+  totals: EventTotal[];
+  balances: SharedBalance[];
+  settle_up: SharedTransfer[];
+  expenses: EventExpense[];
+}
+
+export interface SharedTransfer {
+  from: string;
+  to: string;
+  currency: string;
+  amount: number;
+}
+
+export interface SimplifiedTransfer {
+  payer_email: string;
+  payee_email: string;
+  via_user_email?: string;
+  amount: number;
+}
+
+export interface SplitExplanation {
+  method: string;
+  currency: string;
+  minor_unit: number;
+  subtotal: number;
+  tax_and_tip: number;
+  shares: ShareExplanation[];
+  remainder_units: number;
+  remainder_to?: string;
+  steps: string[];
+  balance_deltas: BalanceDelta[];
+}
+
+export interface TagRule {
+  id: number;
+  keyword: string;
+  tag: string;
+  created_at: string;
+}
+
+export interface TagSuggestion {
+  tag: string;
+  source: string;
+  keyword?: string;
+  confidence: number;
+}
+
+export interface TagSuggestionResponse {
+  suggestion: TagSuggestion | null;
+}
+
+export interface TransitionSettlementRequest {
+  user_email: string;
+}
+
+export interface UnlockExpenseRequest {
+  user_email: string;
+}
+
+export interface UploadChunkRequest {
+  data: string;
+}
+
+export interface User {
+  id: string;
+  name: string;
+  email: string;
+  split_weight: number;
+}
+
+export interface UserBalanceView {
+  with_user_email: string;
+  with_user_name: string;
+  amount: number;
+  paid_amount?: number;
+  last_updated: string;
+}
+
+export interface UserExpenseView {
+  expense_id: string;
+  date: string;
+  tag: string;
+  description: string;
+  total_amount: number;
+  currency: string;
+  share: number;
+  status: string;
+  payee?: string;
+  created_by_name: string;
+  created_by_email: string;
+  is_payer: boolean;
+  running_balance?: number | null;
+}
+
+export interface WeightedSplitRequest {
+  user_email: string;
+  amount_paid?: number;
+}
+
+export interface YearInReview {
+  user_email: string;
+  year: number;
+  expense_count: number;
+  total_fronted: number;
+  total_owed: number;
+  biggest_expense?: ReviewExpense | null;
+  top_category?: ReviewCategory | null;
+  top_co_spender?: ReviewCoSpender | null;
+  months_ranked: ReviewMonth[];
+}
+
+export class ApiError extends Error {
+  // requestId identifies the request in the server's logs, when the API itself answered. code and
+  // field say why a request body was refused.
+  constructor(
+    public readonly status: number,
+    message: string,
+    public readonly requestId?: string,
+    public readonly code?: string,
+    public readonly field?: string,
+  ) {
+    super(message);
+  }
+}
+
+interface Envelope<T> {
+  data: T;
+  meta: { request_id?: string; api_version: string };
+  error: { message: string; code?: string; field?: string } | null;
+}
+
+export interface ClientOptions {
+  // Headers sent with every request, such as Authorization
+  headers?: Record<string, string>;
+  fetch?: typeof fetch;
+}
+
+export class SplitExpenseClient {
+  private readonly fetch: typeof fetch;
+
+  constructor(private readonly baseUrl: string, private readonly options: ClientOptions = {}) {
+    this.baseUrl = baseUrl.replace(/\/+$/, "");
+    this.fetch = options.fetch ?? fetch.bind(globalThis);
+  }
+
+  private async send(method: string, path: string, body?: unknown, query?: Record<string, string>): Promise<Response> {
+    const search = query ? "?" + new URLSearchParams(query).toString() : "";
+    const headers: Record<string, string> = { Accept: "application/json", ...this.options.headers };
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+    }
+    const response = await this.fetch(this.baseUrl + path + search, {
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    if (!response.ok) {
+      const text = (await response.text()).trim();
+      let envelope: Envelope<unknown> | undefined;
+      try {
+        envelope = JSON.parse(text);
+      } catch {
+        // Not from the API itself, e.g. a proxy in front of it
+      }
+      if (envelope?.error) {
+        const { message, code, field } = envelope.error;
+        throw new ApiError(response.status, message, envelope.meta?.request_id, code, field);
+      }
+      throw new ApiError(response.status, text);
+    }
+    return response;
+  }
+
+  private async json<T>(method: string, path: string, body?: unknown, query?: Record<string, string>): Promise<T> {
+    const response = await this.send(method, path, body, query);
+    return ((await response.json()) as Envelope<T>).data;
+  }
+
+  // GET /health
+  getHealth(query?: Record<string, string>): Promise<HealthReport> {
+    return this.json<HealthReport>("GET", `/health`, undefined, query);
+  }
+
+  // POST /users
+  postUsers(body: CreateUserRequest, query?: Record<string, string>): Promise<User> {
+    return this.json<User>("POST", `/users`, body, query);
+  }
+
+  // GET /users/{id}
+  getUsersById(id: string | number, query?: Record<string, string>): Promise<User> {
+    return this.json<User>("GET", `/users/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // PUT /users/{id}/split-weight
+  putUsersSplitWeight(id: string | number, body: SetSplitWeightRequest, query?: Record<string, string>): Promise<User> {
+    return this.json<User>("PUT", `/users/${encodeURIComponent(String(id))}/split-weight`, body, query);
+  }
+
+  // GET /users/by-email/{email}
+  getUsersByEmail(email: string, query?: Record<string, string>): Promise<User> {
+    return this.json<User>("GET", `/users/by-email/${encodeURIComponent(String(email))}`, undefined, query);
+  }
+
+  // PUT /users/{id}/digest-frequency
+  putUsersDigestFrequency(id: string | number, body: SetDigestFrequencyRequest, query?: Record<string, string>): Promise<SetDigestFrequencyRequest> {
+    return this.json<SetDigestFrequencyRequest>("PUT", `/users/${encodeURIComponent(String(id))}/digest-frequency`, body, query);
+  }
+
+  // GET /users/{id}/preferences
+  getUsersPreferences(id: string | number, query?: Record<string, string>): Promise<NotificationPreferences> {
+    return this.json<NotificationPreferences>("GET", `/users/${encodeURIComponent(String(id))}/preferences`, undefined, query);
+  }
+
+  // PUT /users/{id}/preferences
+  putUsersPreferences(id: string | number, body: NotificationPreferences, query?: Record<string, string>): Promise<NotificationPreferences> {
+    return this.json<NotificationPreferences>("PUT", `/users/${encodeURIComponent(String(id))}/preferences`, body, query);
+  }
+
+  // GET /users/{id}/payment-handles
+  getUsersPaymentHandles(id: string | number, query?: Record<string, string>): Promise<PaymentHandles> {
+    return this.json<PaymentHandles>("GET", `/users/${encodeURIComponent(String(id))}/payment-handles`, undefined, query);
+  }
+
+  // PUT /users/{id}/payment-handles
+  putUsersPaymentHandles(id: string | number, body: PaymentHandles, query?: Record<string, string>): Promise<PaymentHandles> {
+    return this.json<PaymentHandles>("PUT", `/users/${encodeURIComponent(String(id))}/payment-handles`, body, query);
+  }
+
+  // GET /users/{id}/quota
+  getUsersQuota(id: string | number, query?: Record<string, string>): Promise<QuotaUsage> {
+    return this.json<QuotaUsage>("GET", `/users/${encodeURIComponent(String(id))}/quota`, undefined, query);
+  }
+
+  // GET /notifications/unsubscribe
+  getNotificationsUnsubscribe(query?: Record<string, string>): Promise<Response> {
+    return this.send("GET", `/notifications/unsubscribe`, undefined, query);
+  }
+
+  // POST /notifications/unsubscribe
+  postNotificationsUnsubscribe(query?: Record<string, string>): Promise<Response> {
+    return this.send("POST", `/notifications/unsubscribe`, undefined, query);
+  }
+
+  // POST /expenses
+  postExpenses(body: CreateExpenseRequest, query?: Record<string, string>): Promise<Expense> {
+    return this.json<Expense>("POST", `/expenses`, body, query);
+  }
+
+  // GET /expenses/by-user/{email}
+  getExpensesByUser(email: string, query?: Record<string, string>): Promise<UserExpenseView[]> {
+    return this.json<UserExpenseView[]>("GET", `/expenses/by-user/${encodeURIComponent(String(email))}`, undefined, query);
+  }
+
+  // GET /expenses/by-user-id/{id}
+  getExpensesByUserId(id: string | number, query?: Record<string, string>): Promise<UserExpenseView[]> {
+    return this.json<UserExpenseView[]>("GET", `/expenses/by-user-id/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // GET /expenses/by-user/{email}/nearby
+  getExpensesByUserNearby(email: string, query?: Record<string, string>): Promise<NearbyExpense[]> {
+    return this.json<NearbyExpense[]>("GET", `/expenses/by-user/${encodeURIComponent(String(email))}/nearby`, undefined, query);
+  }
+
+  // GET /expenses/by-user-id/{id}/nearby
+  getExpensesByUserIdNearby(id: string | number, query?: Record<string, string>): Promise<NearbyExpense[]> {
+    return this.json<NearbyExpense[]>("GET", `/expenses/by-user-id/${encodeURIComponent(String(id))}/nearby`, undefined, query);
+  }
+
+  // POST /expenses/{id}/dispute
+  postExpensesDispute(id: string | number, body: DisputeExpenseRequest, query?: Record<string, string>): Promise<Expense> {
+    return this.json<Expense>("POST", `/expenses/${encodeURIComponent(String(id))}/dispute`, body, query);
+  }
+
+  // POST /expenses/{id}/dismiss-dispute
+  postExpensesDismissDispute(id: string | number, body: DismissDisputeRequest, query?: Record<string, string>): Promise<Expense> {
+    return this.json<Expense>("POST", `/expenses/${encodeURIComponent(String(id))}/dismiss-dispute`, body, query);
+  }
+
+  // DELETE /expenses/{id}
+  deleteExpensesById(id: string | number, query?: Record<string, string>): Promise<Response> {
+    return this.send("DELETE", `/expenses/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // POST /expenses/{id}/unlock
+  postExpensesUnlock(id: string | number, body: UnlockExpenseRequest, query?: Record<string, string>): Promise<Expense> {
+    return this.json<Expense>("POST", `/expenses/${encodeURIComponent(String(id))}/unlock`, body, query);
+  }
+
+  // POST /parties
+  postParties(body: CreatePartyRequest, query?: Record<string, string>): Promise<Party> {
+    return this.json<Party>("POST", `/parties`, body, query);
+  }
+
+  // GET /parties
+  getParties(query?: Record<string, string>): Promise<Party[]> {
+    return this.json<Party[]>("GET", `/parties`, undefined, query);
+  }
+
+  // GET /tags/suggest
+  getTagsSuggest(query?: Record<string, string>): Promise<TagSuggestionResponse> {
+    return this.json<TagSuggestionResponse>("GET", `/tags/suggest`, undefined, query);
+  }
+
+  // POST /tag-rules
+  postTagRules(body: CreateTagRuleRequest, query?: Record<string, string>): Promise<TagRule> {
+    return this.json<TagRule>("POST", `/tag-rules`, body, query);
+  }
+
+  // GET /tag-rules
+  getTagRules(query?: Record<string, string>): Promise<TagRule[]> {
+    return this.json<TagRule[]>("GET", `/tag-rules`, undefined, query);
+  }
+
+  // DELETE /tag-rules/{id}
+  deleteTagRulesById(id: string | number, query?: Record<string, string>): Promise<Response> {
+    return this.send("DELETE", `/tag-rules/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // POST /events
+  postEvents(body: CreateEventRequest, query?: Record<string, string>): Promise<Event> {
+    return this.json<Event>("POST", `/events`, body, query);
+  }
+
+  // GET /events
+  getEvents(query?: Record<string, string>): Promise<Event[]> {
+    return this.json<Event[]>("GET", `/events`, undefined, query);
+  }
+
+  // GET /events/{id}
+  getEventsById(id: string | number, query?: Record<string, string>): Promise<EventSummary> {
+    return this.json<EventSummary>("GET", `/events/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // POST /events/{id}/archive
+  postEventsArchive(id: string | number, body: ArchiveEventRequest, query?: Record<string, string>): Promise<Event> {
+    return this.json<Event>("POST", `/events/${encodeURIComponent(String(id))}/archive`, body, query);
+  }
+
+  // POST /events/{id}/unarchive
+  postEventsUnarchive(id: string | number, body: ArchiveEventRequest, query?: Record<string, string>): Promise<Event> {
+    return this.json<Event>("POST", `/events/${encodeURIComponent(String(id))}/unarchive`, body, query);
+  }
+
+  // PUT /events/{id}/base-currency
+  putEventsBaseCurrency(id: string | number, body: SetBaseCurrencyRequest, query?: Record<string, string>): Promise<Event> {
+    return this.json<Event>("PUT", `/events/${encodeURIComponent(String(id))}/base-currency`, body, query);
+  }
+
+  // PUT /events/{id}/auto-settle
+  putEventsAutoSettle(id: string | number, body: SetAutoSettleRequest, query?: Record<string, string>): Promise<Event> {
+    return this.json<Event>("PUT", `/events/${encodeURIComponent(String(id))}/auto-settle`, body, query);
+  }
+
+  // POST /events/{id}/share-links
+  postEventsShareLinks(id: string | number, body: CreateShareLinkRequest, query?: Record<string, string>): Promise<CreatedShareLink> {
+    return this.json<CreatedShareLink>("POST", `/events/${encodeURIComponent(String(id))}/share-links`, body, query);
+  }
+
+  // POST /share-links/{id}/revoke
+  postShareLinksRevoke(id: string | number, body: RevokeShareLinkRequest, query?: Record<string, string>): Promise<ShareLink> {
+    return this.json<ShareLink>("POST", `/share-links/${encodeURIComponent(String(id))}/revoke`, body, query);
+  }
+
+  // GET /share/{token}
+  getShareByToken(token: string, query?: Record<string, string>): Promise<SharedLedger> {
+    return this.json<SharedLedger>("GET", `/share/${encodeURIComponent(String(token))}`, undefined, query);
+  }
+
+  // POST /expenses/{id}/invites
+  postExpensesInvites(id: string | number, body: CreateExpenseInviteRequest, query?: Record<string, string>): Promise<CreatedExpenseInvite> {
+    return this.json<CreatedExpenseInvite>("POST", `/expenses/${encodeURIComponent(String(id))}/invites`, body, query);
+  }
+
+  // GET /invites/{token}
+  getInvitesByToken(token: string, query?: Record<string, string>): Promise<ExpenseInvite> {
+    return this.json<ExpenseInvite>("GET", `/invites/${encodeURIComponent(String(token))}`, undefined, query);
+  }
+
+  // GET /invites/{token}/qr.png
+  getInvitesQrPng(token: string, query?: Record<string, string>): Promise<Response> {
+    return this.send("GET", `/invites/${encodeURIComponent(String(token))}/qr.png`, undefined, query);
+  }
+
+  // POST /invites/{token}/claim
+  postInvitesClaim(token: string, body: ClaimExpenseInviteRequest, query?: Record<string, string>): Promise<InviteClaim> {
+    return this.json<InviteClaim>("POST", `/invites/${encodeURIComponent(String(token))}/claim`, body, query);
+  }
+
+  // GET /balances/by-user/{email}
+  getBalancesByUser(email: string, query?: Record<string, string>): Promise<UserBalanceView[]> {
+    return this.json<UserBalanceView[]>("GET", `/balances/by-user/${encodeURIComponent(String(email))}`, undefined, query);
+  }
+
+  // GET /balances/by-user-id/{id}
+  getBalancesByUserId(id: string | number, query?: Record<string, string>): Promise<UserBalanceView[]> {
+    return this.json<UserBalanceView[]>("GET", `/balances/by-user-id/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // GET /balances/overall/by-user/{email}
+  getBalancesOverallByUser(email: string, query?: Record<string, string>): Promise<OverallBalanceResponse> {
+    return this.json<OverallBalanceResponse>("GET", `/balances/overall/by-user/${encodeURIComponent(String(email))}`, undefined, query);
+  }
+
+  // GET /balances/overall/by-user-id/{id}
+  getBalancesOverallByUserId(id: string | number, query?: Record<string, string>): Promise<OverallBalanceResponse> {
+    return this.json<OverallBalanceResponse>("GET", `/balances/overall/by-user-id/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // GET /balances/owed-to-me/{email}
+  getBalancesOwedToMeByEmail(email: string, query?: Record<string, string>): Promise<UserBalanceView[]> {
+    return this.json<UserBalanceView[]>("GET", `/balances/owed-to-me/${encodeURIComponent(String(email))}`, undefined, query);
+  }
+
+  // GET /balances/i-owe/{email}
+  getBalancesIOweByEmail(email: string, query?: Record<string, string>): Promise<UserBalanceView[]> {
+    return this.json<UserBalanceView[]>("GET", `/balances/i-owe/${encodeURIComponent(String(email))}`, undefined, query);
+  }
+
+  // GET /balances/aging/{email}
+  getBalancesAgingByEmail(email: string, query?: Record<string, string>): Promise<AgingReport> {
+    return this.json<AgingReport>("GET", `/balances/aging/${encodeURIComponent(String(email))}`, undefined, query);
+  }
+
+  // GET /balances/aging/by-user-id/{id}
+  getBalancesAgingByUserId(id: string | number, query?: Record<string, string>): Promise<AgingReport> {
+    return this.json<AgingReport>("GET", `/balances/aging/by-user-id/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // GET /ledger/by-user/{email}
+  getLedgerByUser(email: string, query?: Record<string, string>): Promise<LedgerStatement> {
+    return this.json<LedgerStatement>("GET", `/ledger/by-user/${encodeURIComponent(String(email))}`, undefined, query);
+  }
+
+  // GET /ledger/by-user-id/{id}
+  getLedgerByUserId(id: string | number, query?: Record<string, string>): Promise<LedgerStatement> {
+    return this.json<LedgerStatement>("GET", `/ledger/by-user-id/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // POST /loans
+  postLoans(body: CreateLoanRequest, query?: Record<string, string>): Promise<Loan> {
+    return this.json<Loan>("POST", `/loans`, body, query);
+  }
+
+  // GET /loans/by-user/{email}
+  getLoansByUser(email: string, query?: Record<string, string>): Promise<LoanView[]> {
+    return this.json<LoanView[]>("GET", `/loans/by-user/${encodeURIComponent(String(email))}`, undefined, query);
+  }
+
+  // GET /loans/by-user-id/{id}
+  getLoansByUserId(id: string | number, query?: Record<string, string>): Promise<LoanView[]> {
+    return this.json<LoanView[]>("GET", `/loans/by-user-id/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // POST /settlements
+  postSettlements(body: ProposeSettlementRequest, query?: Record<string, string>): Promise<Settlement> {
+    return this.json<Settlement>("POST", `/settlements`, body, query);
+  }
+
+  // GET /payments/qr.png
+  getPaymentsQrPng(query?: Record<string, string>): Promise<Response> {
+    return this.send("GET", `/payments/qr.png`, undefined, query);
+  }
+
+  // POST /payments/callback
+  postPaymentsCallback(body: PaymentCallbackRequest, query?: Record<string, string>): Promise<Settlement> {
+    return this.json<Settlement>("POST", `/payments/callback`, body, query);
+  }
+
+  // POST /payments/stripe/webhook
+  postPaymentsStripeWebhook(query?: Record<string, string>): Promise<Response> {
+    return this.send("POST", `/payments/stripe/webhook`, undefined, query);
+  }
+
+  // GET /settlements/by-user/{email}
+  getSettlementsByUser(email: string, query?: Record<string, string>): Promise<SettlementView[]> {
+    return this.json<SettlementView[]>("GET", `/settlements/by-user/${encodeURIComponent(String(email))}`, undefined, query);
+  }
+
+  // GET /settlements/by-user-id/{id}
+  getSettlementsByUserId(id: string | number, query?: Record<string, string>): Promise<SettlementView[]> {
+    return this.json<SettlementView[]>("GET", `/settlements/by-user-id/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // GET /settlements/simplify/by-user/{email}
+  getSettlementsSimplifyByUser(email: string, query?: Record<string, string>): Promise<DebtSimplification> {
+    return this.json<DebtSimplification>("GET", `/settlements/simplify/by-user/${encodeURIComponent(String(email))}`, undefined, query);
+  }
+
+  // GET /settlements/simplify/by-user-id/{id}
+  getSettlementsSimplifyByUserId(id: string | number, query?: Record<string, string>): Promise<DebtSimplification> {
+    return this.json<DebtSimplification>("GET", `/settlements/simplify/by-user-id/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // POST /settlements/simplify
+  postSettlementsSimplify(body: ApplyDebtSimplificationRequest, query?: Record<string, string>): Promise<Settlement[]> {
+    return this.json<Settlement[]>("POST", `/settlements/simplify`, body, query);
+  }
+
+  // POST /settlements/{id}/send
+  postSettlementsSend(id: string | number, body: TransitionSettlementRequest, query?: Record<string, string>): Promise<Settlement> {
+    return this.json<Settlement>("POST", `/settlements/${encodeURIComponent(String(id))}/send`, body, query);
+  }
+
+  // POST /settlements/{id}/confirm
+  postSettlementsConfirm(id: string | number, body: TransitionSettlementRequest, query?: Record<string, string>): Promise<Settlement> {
+    return this.json<Settlement>("POST", `/settlements/${encodeURIComponent(String(id))}/confirm`, body, query);
+  }
+
+  // POST /settlements/{id}/dispute
+  postSettlementsDispute(id: string | number, body: TransitionSettlementRequest, query?: Record<string, string>): Promise<Settlement> {
+    return this.json<Settlement>("POST", `/settlements/${encodeURIComponent(String(id))}/dispute`, body, query);
+  }
+
+  // POST /settlements/{id}/pay
+  postSettlementsPay(id: string | number, query?: Record<string, string>): Promise<SettlementPayment> {
+    return this.json<SettlementPayment>("POST", `/settlements/${encodeURIComponent(String(id))}/pay`, undefined, query);
+  }
+
+  // PUT /budgets
+  putBudgets(body: SetTagBudgetRequest, query?: Record<string, string>): Promise<Response> {
+    return this.send("PUT", `/budgets`, body, query);
+  }
+
+  // GET /budgets/by-user/{email}
+  getBudgetsByUser(email: string, query?: Record<string, string>): Promise<BudgetStatus[]> {
+    return this.json<BudgetStatus[]>("GET", `/budgets/by-user/${encodeURIComponent(String(email))}`, undefined, query);
+  }
+
+  // GET /budgets/by-user-id/{id}
+  getBudgetsByUserId(id: string | number, query?: Record<string, string>): Promise<BudgetStatus[]> {
+    return this.json<BudgetStatus[]>("GET", `/budgets/by-user-id/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // POST /goals
+  postGoals(body: CreateGoalRequest, query?: Record<string, string>): Promise<Goal> {
+    return this.json<Goal>("POST", `/goals`, body, query);
+  }
+
+  // GET /goals/by-user/{email}
+  getGoalsByUser(email: string, query?: Record<string, string>): Promise<GoalProgress[]> {
+    return this.json<GoalProgress[]>("GET", `/goals/by-user/${encodeURIComponent(String(email))}`, undefined, query);
+  }
+
+  // GET /goals/by-user-id/{id}
+  getGoalsByUserId(id: string | number, query?: Record<string, string>): Promise<GoalProgress[]> {
+    return this.json<GoalProgress[]>("GET", `/goals/by-user-id/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // GET /analytics/next-payer
+  getAnalyticsNextPayer(query?: Record<string, string>): Promise<NextPayerSuggestion> {
+    return this.json<NextPayerSuggestion>("GET", `/analytics/next-payer`, undefined, query);
+  }
+
+  // GET /analytics/year-in-review/{email}
+  getAnalyticsYearInReviewByEmail(email: string, query?: Record<string, string>): Promise<YearInReview> {
+    return this.json<YearInReview>("GET", `/analytics/year-in-review/${encodeURIComponent(String(email))}`, undefined, query);
+  }
+
+  // GET /analytics/year-in-review/by-user-id/{id}
+  getAnalyticsYearInReviewByUserId(id: string | number, query?: Record<string, string>): Promise<YearInReview> {
+    return this.json<YearInReview>("GET", `/analytics/year-in-review/by-user-id/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // GET /analytics/heatmap/{email}
+  getAnalyticsHeatmapByEmail(email: string, query?: Record<string, string>): Promise<Heatmap> {
+    return this.json<Heatmap>("GET", `/analytics/heatmap/${encodeURIComponent(String(email))}`, undefined, query);
+  }
+
+  // GET /analytics/heatmap/by-user-id/{id}
+  getAnalyticsHeatmapByUserId(id: string | number, query?: Record<string, string>): Promise<Heatmap> {
+    return this.json<Heatmap>("GET", `/analytics/heatmap/by-user-id/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // GET /analytics/counterparties/{email}
+  getAnalyticsCounterpartiesByEmail(email: string, query?: Record<string, string>): Promise<Counterparty[]> {
+    return this.json<Counterparty[]>("GET", `/analytics/counterparties/${encodeURIComponent(String(email))}`, undefined, query);
+  }
+
+  // GET /analytics/counterparties/by-user-id/{id}
+  getAnalyticsCounterpartiesByUserId(id: string | number, query?: Record<string, string>): Promise<Counterparty[]> {
+    return this.json<Counterparty[]>("GET", `/analytics/counterparties/by-user-id/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // GET /analytics/forecast/{email}
+  getAnalyticsForecastByEmail(email: string, query?: Record<string, string>): Promise<Forecast> {
+    return this.json<Forecast>("GET", `/analytics/forecast/${encodeURIComponent(String(email))}`, undefined, query);
+  }
+
+  // GET /analytics/forecast/by-user-id/{id}
+  getAnalyticsForecastByUserId(id: string | number, query?: Record<string, string>): Promise<Forecast> {
+    return this.json<Forecast>("GET", `/analytics/forecast/by-user-id/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // GET /analytics/fairness/{id}
+  getAnalyticsFairnessById(id: string | number, query?: Record<string, string>): Promise<FairnessReport> {
+    return this.json<FairnessReport>("GET", `/analytics/fairness/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // GET /jobs/{id}
+  getJobsById(id: string | number, query?: Record<string, string>): Promise<Job> {
+    return this.json<Job>("GET", `/jobs/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // POST /imports
+  postImports(body: CreateImportRequest, query?: Record<string, string>): Promise<ImportSession> {
+    return this.json<ImportSession>("POST", `/imports`, body, query);
+  }
+
+  // GET /imports/{id}
+  getImportsById(id: string | number, query?: Record<string, string>): Promise<ImportSession> {
+    return this.json<ImportSession>("GET", `/imports/${encodeURIComponent(String(id))}`, undefined, query);
+  }
+
+  // PUT /imports/{id}/chunks/{seq}
+  putImportsChunksBySeq(id: string | number, seq: string, body: UploadChunkRequest, query?: Record<string, string>): Promise<ImportSession> {
+    return this.json<ImportSession>("PUT", `/imports/${encodeURIComponent(String(id))}/chunks/${encodeURIComponent(String(seq))}`, body, query);
+  }
+
+  // POST /imports/{id}/commit
+  postImportsCommit(id: string | number, body: CommitImportRequest, query?: Record<string, string>): Promise<ImportSession> {
+    return this.json<ImportSession>("POST", `/imports/${encodeURIComponent(String(id))}/commit`, body, query);
+  }
+
+  // GET /admin/audit
+  getAdminAudit(query?: Record<string, string>): Promise<AuditLog[]> {
+    return this.json<AuditLog[]>("GET", `/admin/audit`, undefined, query);
+  }
+
+  // GET /admin/queries
+  getAdminQueries(query?: Record<string, string>): Promise<QueryStats[]> {
+    return this.json<QueryStats[]>("GET", `/admin/queries`, undefined, query);
+  }
+
+  // GET /admin/ledger/check
+  getAdminLedgerCheck(query?: Record<string, string>): Promise<BalanceDrift[]> {
+    return this.json<BalanceDrift[]>("GET", `/admin/ledger/check`, undefined, query);
+  }
+
+  // POST /admin/ledger/rebuild
+  postAdminLedgerRebuild(query?: Record<string, string>): Promise<BalanceDrift[]> {
+    return this.json<BalanceDrift[]>("POST", `/admin/ledger/rebuild`, undefined, query);
+  }
+}
@@ -0,0 +1,58 @@
+// Command anonymize scrambles a copy of the database for use in staging: names, emails and free text
+// are replaced, amounts are perturbed and balances rebuilt to match. It rewrites the database in
+// place, so it only runs when -confirm names the configured database; never point it at production.
+// Run it from the directory holding config/, like the server.
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"log"
+
+	"github.com/aadithya-md/split-expense/internal/app"
+	"github.com/aadithya-md/split-expense/internal/config"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/go-sql-driver/mysql"
+)
+
+func main() {
+	confirm := flag.String("confirm", "", "name of the database to anonymize, which must be the configured one")
+	seed := flag.String("seed", "", "seed for the amount perturbation, to anonymize reproducibly (default random)")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Error loading configuration: %v", err)
+	}
+	if cfg.Storage.Backend == "memory" {
+		log.Fatal("STORAGE.BACKEND is memory: there is no database to anonymize.")
+	}
+	dsn, err := mysql.ParseDSN(cfg.SQLDb.ConnectionString)
+	if err != nil {
+		log.Fatalf("Error parsing SQL_DB.CONNECTION_STRING: %v", err)
+	}
+	if *confirm == "" || *confirm != dsn.DBName {
+		log.Fatalf("Refusing to anonymize: pass -confirm %s to rewrite that database in place.", dsn.DBName)
+	}
+	if *seed == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			log.Fatalf("Error generating seed: %v", err)
+		}
+		*seed = hex.EncodeToString(b)
+	}
+
+	a, err := app.New(cfg)
+	if err != nil {
+		log.Fatalf("Error initialising application: %v", err)
+	}
+	defer a.Close()
+
+	report, err := repository.Anonymize(a.DB, *seed)
+	if err != nil {
+		log.Fatalf("Anonymizing %s failed: %v", dsn.DBName, err)
+	}
+	log.Printf("Anonymized %s: %d users, %d expenses, %d loans and %d settlements; %d balances rebuilt.",
+		dsn.DBName, report.Users, report.Expenses, report.Loans, report.Settlements, report.BalancesRebuilt)
+}
@@ -0,0 +1,32 @@
+// Command genclient writes the TypeScript client for the API, generated from the router's routes.
+// Run it from the repository root after changing a route or a request or response type:
+//
+//	go run ./cmd/genclient -o clients/typescript/client.ts
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/aadithya-md/split-expense/internal/clientgen"
+	"github.com/aadithya-md/split-expense/internal/router"
+)
+
+func main() {
+	out := flag.String("o", "", "file to write the client to (default stdout)")
+	flag.Parse()
+
+	// Only the route table is needed, not services to serve it
+	client, err := clientgen.TypeScript(router.Routes(router.Services{}, router.Options{}))
+	if err != nil {
+		log.Fatalf("Error generating client: %v", err)
+	}
+	if *out == "" {
+		os.Stdout.Write(client)
+		return
+	}
+	if err := os.WriteFile(*out, client, 0o644); err != nil {
+		log.Fatalf("Error writing client: %v", err)
+	}
+}
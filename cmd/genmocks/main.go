@@ -0,0 +1,46 @@
+// Command genmocks writes the testify mocks the tests use under internal/mocks. Run it from the
+// repository root after changing one of the mocked interfaces:
+//
+//	go run ./cmd/genmocks
+//
+// With -check it writes nothing and fails if a mock is out of date instead, for CI.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/aadithya-md/split-expense/internal/mockgen"
+)
+
+func main() {
+	check := flag.Bool("check", false, "fail if a mock is out of date instead of writing it")
+	flag.Parse()
+
+	stale := false
+	for _, p := range mockgen.Generated {
+		src, err := mockgen.Generate(".", p.Spec)
+		if err != nil {
+			log.Fatalf("Error generating %s: %v", p.File, err)
+		}
+		if *check {
+			if committed, err := os.ReadFile(p.File); err != nil || !bytes.Equal(committed, src) {
+				log.Printf("%s is stale; run go run ./cmd/genmocks", p.File)
+				stale = true
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(p.File), 0o755); err != nil {
+			log.Fatalf("Error creating %s: %v", filepath.Dir(p.File), err)
+		}
+		if err := os.WriteFile(p.File, src, 0o644); err != nil {
+			log.Fatalf("Error writing %s: %v", p.File, err)
+		}
+	}
+	if stale {
+		os.Exit(1)
+	}
+}
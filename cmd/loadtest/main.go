@@ -0,0 +1,176 @@
+// Command loadtest drives a running server's HTTP API at a fixed request rate with a mix of
+// operations, and reports latency percentiles per operation so regressions, in CreateExpense above
+// all, show up as numbers. It registers its own users first, so point it at a disposable database.
+//
+//	go run ./cmd/loadtest -url http://localhost:8080 -scenario create-heavy -rps 100 -duration 2m
+//
+// Scenarios are create-heavy, read-heavy and soak, a long run at a modest rate with interim
+// reports to watch latency drift. -max-create-p99 makes the command fail when CreateExpense's p99
+// is over budget, for use in CI.
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	mrand "math/rand/v2"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:8080", "base URL of the server under test")
+	scenario := flag.String("scenario", "create-heavy", "mix of operations: "+strings.Join(scenarioNames(), ", "))
+	rps := flag.Int("rps", 0, "requests per second (default the scenario's)")
+	duration := flag.Duration("duration", 0, "how long to run (default the scenario's)")
+	userCount := flag.Int("users", 20, "users to create and spread requests across")
+	concurrency := flag.Int("concurrency", 100, "most requests in flight at once; requests due beyond it are dropped and counted")
+	interval := flag.Duration("interval", 10*time.Second, "how often to print interim results, 0 for none")
+	maxCreateP99 := flag.Duration("max-create-p99", 0, "fail if create_expense's p99 latency is over this, 0 for no limit")
+	flag.Parse()
+
+	p, ok := profiles[*scenario]
+	if !ok {
+		log.Fatalf("Unknown scenario %q: choose from %s.", *scenario, strings.Join(scenarioNames(), ", "))
+	}
+	if *rps > 0 {
+		p.rps = *rps
+	}
+	if *duration > 0 {
+		p.duration = *duration
+	}
+	if *userCount < 2 || *concurrency < 1 {
+		log.Fatal("-users must be at least 2 and -concurrency at least 1.")
+	}
+	url := strings.TrimRight(*baseURL, "/")
+
+	client := &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{MaxIdleConns: *concurrency, MaxIdleConnsPerHost: *concurrency},
+	}
+	users, err := createUsers(client, url, runID(), *userCount)
+	if err != nil {
+		log.Fatalf("Error creating users: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, p.duration)
+	defer cancel()
+
+	log.Printf("Running %s at %d rps for %s against %s.", *scenario, p.rps, p.duration, url)
+	rec := &recorder{}
+	start := time.Now()
+	run(ctx, client, url, users, p, *concurrency, *interval, rec)
+	elapsed := time.Since(start)
+
+	fmt.Printf("\n%s: %s, %d requests dropped at the concurrency limit\n", *scenario, elapsed.Round(time.Second), rec.dropped)
+	stats := summarise(rec.all)
+	writeStats(os.Stdout, stats, elapsed)
+
+	if *maxCreateP99 > 0 {
+		i := slices.IndexFunc(stats, func(st opStats) bool { return st.op == createExpense.name })
+		if i >= 0 && stats[i].p99 > *maxCreateP99 {
+			log.Fatalf("create_expense p99 %s is over the %s limit.", round(stats[i].p99), *maxCreateP99)
+		}
+	}
+}
+
+// run sends requests at p's rate until ctx is done, then waits for those in flight. The rate is
+// held regardless of how fast the server answers, so a slow server shows up as latency rather
+// than as fewer requests.
+func run(ctx context.Context, client *http.Client, url string, users []string, p profile, concurrency int, interval time.Duration, rec *recorder) {
+	rng := mrand.New(mrand.NewPCG(mrand.Uint64(), mrand.Uint64()))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	ticker := time.NewTicker(time.Second / time.Duration(p.rps))
+	defer ticker.Stop()
+	var report <-chan time.Time
+	if interval > 0 {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		report = t.C
+	}
+	last := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case now := <-report:
+			window := summarise(rec.takeWindow())
+			if len(window) > 0 {
+				all := window[len(window)-1]
+				log.Printf("%d requests (%.1f rps), %d failed, %d throttled, p50 %s, p99 %s", all.requests,
+					float64(all.requests)/now.Sub(last).Seconds(), all.failed, all.throttled, round(all.p50), round(all.p99))
+			}
+			last = now
+		case <-ticker.C:
+			op := p.pick(rng)
+			req, err := op.build(url, users, rng)
+			if err != nil {
+				log.Fatalf("Error building %s request: %v", op.name, err)
+			}
+			select {
+			case slots <- struct{}{}:
+			default:
+				rec.drop()
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-slots }()
+				rec.add(send(client, op.name, req))
+			}()
+		}
+	}
+}
+
+// send makes one request and times it to the end of the response body.
+func send(client *http.Client, op string, req *http.Request) sample {
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return sample{op: op, latency: time.Since(start), failed: true}
+	}
+	_, err = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	s := sample{op: op, latency: time.Since(start)}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		s.throttled = true
+	case err != nil || resp.StatusCode >= 300:
+		s.failed = true
+	}
+	return s
+}
+
+// runID tells this run's users apart from earlier runs'.
+func runID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("Error generating run ID: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
+
+func scenarioNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// profile is a named mix of operations with the rate and duration it runs at unless overridden.
+type profile struct {
+	rps      int
+	duration time.Duration
+	mix      []weighted
+}
+
+type weighted struct {
+	op     operation
+	weight int
+}
+
+// operation builds one request against the API for a randomly chosen set of users.
+type operation struct {
+	name  string
+	build func(baseURL string, users []string, rng *rand.Rand) (*http.Request, error)
+}
+
+var (
+	createExpense = operation{name: "create_expense", build: buildCreateExpense}
+	listExpenses  = operation{name: "list_expenses", build: userGet("/expenses/by-user/")}
+	balances      = operation{name: "balances", build: userGet("/balances/by-user/")}
+	overall       = operation{name: "overall_balance", build: userGet("/balances/overall/by-user/")}
+)
+
+// profiles are the scenarios -scenario chooses from. soak runs a realistic mix at a modest rate for
+// long enough to surface leaks, connection exhaustion and slow growth in latency.
+var profiles = map[string]profile{
+	"create-heavy": {rps: 50, duration: time.Minute, mix: []weighted{
+		{createExpense, 80}, {listExpenses, 10}, {balances, 5}, {overall, 5},
+	}},
+	"read-heavy": {rps: 200, duration: time.Minute, mix: []weighted{
+		{createExpense, 10}, {listExpenses, 40}, {balances, 30}, {overall, 20},
+	}},
+	"soak": {rps: 20, duration: time.Hour, mix: []weighted{
+		{createExpense, 30}, {listExpenses, 30}, {balances, 25}, {overall, 15},
+	}},
+}
+
+// pick chooses an operation from the mix in proportion to its weight.
+func (p profile) pick(rng *rand.Rand) operation {
+	total := 0
+	for _, w := range p.mix {
+		total += w.weight
+	}
+	n := rng.IntN(total)
+	for _, w := range p.mix {
+		if n < w.weight {
+			return w.op
+		}
+		n -= w.weight
+	}
+	return p.mix[len(p.mix)-1].op
+}
+
+// buildCreateExpense posts an equal split of a random amount between two to four users, the first
+// of whom paid it all.
+func buildCreateExpense(baseURL string, users []string, rng *rand.Rand) (*http.Request, error) {
+	n := min(2+rng.IntN(3), len(users))
+	amount := float64(100+rng.IntN(500000)) / 100
+
+	var splits []map[string]any
+	for i, idx := range rng.Perm(len(users))[:n] {
+		split := map[string]any{"user_email": users[idx]}
+		if i == 0 {
+			split["amount_paid"] = amount
+		}
+		splits = append(splits, split)
+	}
+	body, err := json.Marshal(map[string]any{
+		"description":      "Load test",
+		"tag":              "loadtest",
+		"total_amount":     amount,
+		"created_by_email": splits[0]["user_email"],
+		"split_method":     "equal",
+		"equal_splits":     splits,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, baseURL+"/expenses", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// userGet builds a GET of prefix followed by a random user's email.
+func userGet(prefix string) func(string, []string, *rand.Rand) (*http.Request, error) {
+	return func(baseURL string, users []string, rng *rand.Rand) (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, baseURL+prefix+url.PathEscape(users[rng.IntN(len(users))]), nil)
+	}
+}
+
+// createUsers registers count users for the run, with emails unique to runID so runs don't collide.
+func createUsers(client *http.Client, baseURL, runID string, count int) ([]string, error) {
+	users := make([]string, 0, count)
+	for i := range count {
+		email := fmt.Sprintf("loadtest-%s-%d@example.com", runID, i)
+		body, err := json.Marshal(map[string]string{"name": fmt.Sprintf("Load Test %d", i), "email": email})
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Post(baseURL+"/users", "application/json", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			return nil, fmt.Errorf("creating %s: %s", email, resp.Status)
+		}
+		users = append(users, email)
+	}
+	return users, nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// sample is the outcome of one request.
+type sample struct {
+	op        string
+	latency   time.Duration
+	failed    bool // Transport error or a status other than 2xx and 429
+	throttled bool // Refused with 429
+}
+
+// recorder collects samples from concurrent requests, for the whole run and for the window since
+// the last interim report.
+type recorder struct {
+	mu      sync.Mutex
+	all     []sample
+	window  []sample
+	dropped int
+}
+
+func (r *recorder) add(s sample) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.all = append(r.all, s)
+	r.window = append(r.window, s)
+}
+
+// drop counts a request not sent because too many were already in flight.
+func (r *recorder) drop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dropped++
+}
+
+// takeWindow returns the samples since it was last called.
+func (r *recorder) takeWindow() []sample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	window := r.window
+	r.window = nil
+	return window
+}
+
+// opStats summarises the samples of one operation.
+type opStats struct {
+	op                          string
+	requests, failed, throttled int
+	p50, p90, p99, max          time.Duration
+}
+
+// summarise groups samples by operation, sorted by name, with an "all" row last.
+func summarise(samples []sample) []opStats {
+	byOp := make(map[string][]sample)
+	for _, s := range samples {
+		byOp[s.op] = append(byOp[s.op], s)
+	}
+	ops := make([]string, 0, len(byOp))
+	for op := range byOp {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	stats := make([]opStats, 0, len(ops)+1)
+	for _, op := range ops {
+		stats = append(stats, summariseOp(op, byOp[op]))
+	}
+	if len(ops) > 1 {
+		stats = append(stats, summariseOp("all", samples))
+	}
+	return stats
+}
+
+func summariseOp(op string, samples []sample) opStats {
+	st := opStats{op: op, requests: len(samples)}
+	latencies := make([]time.Duration, 0, len(samples))
+	for _, s := range samples {
+		if s.failed {
+			st.failed++
+		}
+		if s.throttled {
+			st.throttled++
+		}
+		latencies = append(latencies, s.latency)
+	}
+	slices.Sort(latencies)
+	st.p50 = percentile(latencies, 50)
+	st.p90 = percentile(latencies, 90)
+	st.p99 = percentile(latencies, 99)
+	st.max = percentile(latencies, 100)
+	return st
+}
+
+// percentile is the nearest-rank p-th percentile of sorted latencies, zero when there are none.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
+
+func writeStats(w io.Writer, stats []opStats, elapsed time.Duration) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "operation\trequests\trps\tfailed\tthrottled\tp50\tp90\tp99\tmax\t")
+	for _, st := range stats {
+		fmt.Fprintf(tw, "%s\t%d\t%.1f\t%d\t%d\t%s\t%s\t%s\t%s\t\n", st.op, st.requests,
+			float64(st.requests)/elapsed.Seconds(), st.failed, st.throttled,
+			round(st.p50), round(st.p90), round(st.p99), round(st.max))
+	}
+	tw.Flush()
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
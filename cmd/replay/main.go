@@ -0,0 +1,39 @@
+// Command replay rebuilds the expense tables from the expense event store and reports what it
+// changed. Run it from the directory holding config/, like the server.
+package main
+
+import (
+	"log"
+
+	"github.com/aadithya-md/split-expense/internal/app"
+	"github.com/aadithya-md/split-expense/internal/config"
+)
+
+func main() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Error loading configuration: %v", err)
+	}
+	if cfg.Storage.Backend == "memory" {
+		log.Fatal("STORAGE.BACKEND is memory: there is no stored event log to replay.")
+	}
+	if !cfg.EventStore.Enabled {
+		log.Println("EVENT_STORE.ENABLED is off: replaying the events recorded while it was on.")
+	}
+
+	a, err := app.New(cfg)
+	if err != nil {
+		log.Fatalf("Error initialising application: %v", err)
+	}
+	defer a.Close()
+
+	report, err := a.ExpenseEventRepo.RebuildProjections()
+	if err != nil {
+		log.Fatalf("Rebuilding expense projections failed: %v", err)
+	}
+	log.Printf("Replayed %d events for %d expenses: %d restored, %d updated, %d deleted.",
+		report.Events, report.Expenses, report.Restored, report.Updated, report.Deleted)
+	if report.Restored+report.Deleted > 0 {
+		log.Println("Balances follow the ledger, not the expense tables; check them with GET /admin/ledger/check.")
+	}
+}
@@ -2,21 +2,14 @@ package main
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
+	"github.com/aadithya-md/split-expense/internal/app"
 	"github.com/aadithya-md/split-expense/internal/config"
-	"github.com/aadithya-md/split-expense/internal/repository"
-	"github.com/aadithya-md/split-expense/internal/router"
-	"github.com/aadithya-md/split-expense/internal/service"
-
-	_ "github.com/go-sql-driver/mysql"
 )
 
 func main() {
@@ -24,34 +17,33 @@ func main() {
 	if err != nil {
 		log.Fatalf("Error loading configuration: %v", err)
 	}
+	log.Printf("Effective configuration:\n%s", cfg.Summary())
 
-	db, err := sql.Open("mysql", cfg.SQLDb.ConnectionString)
+	a, err := app.New(cfg)
 	if err != nil {
-		log.Fatalf("Error opening database connection: %v", err)
+		log.Fatalf("Error initialising application: %v", err)
 	}
-	defer db.Close()
-
-	// Ping the database to verify the connection
-	if err = db.Ping(); err != nil {
-		log.Fatalf("Error connecting to the database: %v", err)
+	defer a.Close()
+	if cfg.Storage.Backend == "memory" {
+		log.Println("Storing data in memory; it is lost when the server stops.")
+	} else {
+		log.Println("Successfully connected to the MySQL database!")
 	}
-	log.Println("Successfully connected to the database!")
-
-	userRepo := repository.NewUserRepository(db)
-	userService := service.NewUserService(userRepo)
-
-	balanceRepo := repository.NewBalanceRepository(db)
-	expenseRepo := repository.NewExpenseRepository(db, balanceRepo)
-	expenseService := service.NewExpenseService(expenseRepo, userService, balanceRepo)
 
-	r := router.NewRouter(userService, expenseService)
+	srv := a.Server()
 
-	srv := &http.Server{
-		Addr:         fmt.Sprintf("%s:%s", cfg.HttpServer.Address, cfg.HttpServer.Port),
-		Handler:      r,
-		ReadTimeout:  cfg.HttpServer.ReadTimeout,
-		WriteTimeout: cfg.HttpServer.WriteTimeout,
-		IdleTimeout:  cfg.HttpServer.IdleTimeout,
+	// Background jobs, and the schedulers that queue digests, loan reminders and settle-ups, run until the server starts shutting down
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	jobsDone := make(chan struct{})
+	go func() {
+		a.JobService.Run(jobsCtx)
+		close(jobsDone)
+	}()
+	go a.DigestService.Run(jobsCtx, cfg.Notifications.DigestCheckInterval)
+	go a.LoanService.Run(jobsCtx, cfg.Notifications.ReminderCheckInterval)
+	if cfg.SettleUp.Enabled {
+		go a.SettleUpService.Run(jobsCtx, cfg.SettleUp.CheckInterval)
 	}
 
 	// Create a channel to listen for OS signals
@@ -67,12 +59,21 @@ func main() {
 
 	<-done // Block until an OS signal is received
 	log.Println("Server is shutting down...")
+	// Stop claiming new jobs straight away; the one in flight drains alongside the HTTP requests
+	stopJobs()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HttpServer.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatalf("Server shutdown failed: %v", err)
 	}
+
+	select {
+	case <-jobsDone:
+	case <-ctx.Done():
+		// The job's lease runs out and another runner picks it up again
+		log.Println("Gave up waiting for the running background job.")
+	}
 	log.Println("Server gracefully stopped.")
 }
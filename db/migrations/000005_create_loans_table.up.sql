@@ -0,0 +1,13 @@
+CREATE TABLE loans (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    lender_id INT NOT NULL,
+    borrower_id INT NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    description VARCHAR(255) DEFAULT '',
+    due_date DATE NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (lender_id) REFERENCES users(id),
+    FOREIGN KEY (borrower_id) REFERENCES users(id),
+    INDEX idx_loans_lender_id (lender_id),
+    INDEX idx_loans_borrower_id (borrower_id)
+);
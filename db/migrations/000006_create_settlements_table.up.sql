@@ -0,0 +1,13 @@
+CREATE TABLE settlements (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    payer_id INT NOT NULL,
+    payee_id INT NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    status ENUM('proposed', 'sent', 'confirmed', 'disputed') NOT NULL DEFAULT 'proposed',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (payer_id) REFERENCES users(id),
+    FOREIGN KEY (payee_id) REFERENCES users(id),
+    INDEX idx_settlements_payer_id (payer_id),
+    INDEX idx_settlements_payee_id (payee_id)
+);
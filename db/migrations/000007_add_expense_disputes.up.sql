@@ -0,0 +1,3 @@
+ALTER TABLE expenses
+    ADD COLUMN status ENUM('active', 'disputed') NOT NULL DEFAULT 'active',
+    ADD COLUMN dispute_reason VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE users
+    ADD COLUMN split_weight DECIMAL(10, 4) NOT NULL DEFAULT 1;
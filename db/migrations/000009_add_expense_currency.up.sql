@@ -0,0 +1,11 @@
+-- Amounts keep three decimal places so currencies such as KWD are stored exactly
+ALTER TABLE expenses
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'INR',
+    MODIFY total_amount DECIMAL(13, 3) NOT NULL;
+
+ALTER TABLE expense_splits
+    MODIFY amount_paid DECIMAL(13, 3) NOT NULL,
+    MODIFY amount_owed DECIMAL(13, 3) NOT NULL;
+
+ALTER TABLE balances
+    MODIFY balance DECIMAL(13, 3) NOT NULL;
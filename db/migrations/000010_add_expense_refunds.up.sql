@@ -0,0 +1,4 @@
+ALTER TABLE expenses
+    ADD COLUMN refund_of INT NULL,
+    ADD FOREIGN KEY (refund_of) REFERENCES expenses(id),
+    ADD INDEX idx_expenses_refund_of (refund_of);
@@ -0,0 +1,13 @@
+CREATE TABLE audit_logs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    method VARCHAR(10) NOT NULL,
+    route VARCHAR(255) NOT NULL,
+    path VARCHAR(2048) NOT NULL,
+    payload_hash CHAR(64) NOT NULL,
+    status INT NOT NULL,
+    latency_ms BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_audit_logs_actor_created_at (actor, created_at),
+    INDEX idx_audit_logs_created_at (created_at)
+);
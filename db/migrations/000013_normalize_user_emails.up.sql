@@ -0,0 +1,6 @@
+-- Emails are compared case-insensitively: store them lowercased so the unique key covers every
+-- spelling. Fails if two existing users differ only in case; merge them first.
+UPDATE users SET email = LOWER(TRIM(email));
+
+ALTER TABLE users
+    ADD CONSTRAINT chk_users_email_normalized CHECK (CAST(email AS BINARY) = CAST(LOWER(TRIM(email)) AS BINARY));
@@ -0,0 +1,12 @@
+CREATE TABLE tag_budgets (
+    user_id INT NOT NULL,
+    tag VARCHAR(255) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    monthly_limit DECIMAL(13, 3) NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, tag, currency),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- Month-to-date spend per tag is summed from the user's splits
+CREATE INDEX idx_expenses_tag_currency_created_at ON expenses (tag, currency, created_at);
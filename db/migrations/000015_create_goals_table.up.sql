@@ -0,0 +1,10 @@
+CREATE TABLE goals (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    target_balance DECIMAL(13, 3) NOT NULL DEFAULT 0,
+    starting_balance DECIMAL(13, 3) NOT NULL,
+    deadline DATE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    INDEX idx_goals_user_id (user_id)
+);
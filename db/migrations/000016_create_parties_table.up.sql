@@ -0,0 +1,10 @@
+CREATE TABLE parties (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The landlord, vendor or other outside party an expense was paid to. It never takes a split or a balance.
+ALTER TABLE expenses
+    ADD COLUMN payee_party_id INT NULL,
+    ADD FOREIGN KEY (payee_party_id) REFERENCES parties(id);
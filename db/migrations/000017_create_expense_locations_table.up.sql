@@ -0,0 +1,10 @@
+-- Kept apart from expenses because a spatial index needs a NOT NULL point, and most expenses have no location
+CREATE TABLE expense_locations (
+    expense_id INT PRIMARY KEY,
+    latitude DECIMAL(9, 6) NOT NULL,
+    longitude DECIMAL(9, 6) NOT NULL,
+    place_name VARCHAR(255) NOT NULL DEFAULT '',
+    location POINT NOT NULL SRID 4326,
+    FOREIGN KEY (expense_id) REFERENCES expenses(id),
+    SPATIAL INDEX idx_expense_locations_location (location)
+);
@@ -0,0 +1,14 @@
+CREATE TABLE events (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_by INT NOT NULL,
+    archived_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+-- A trip or occasion, like "Ski weekend", that groups expenses into their own sub-ledger
+ALTER TABLE expenses
+    ADD COLUMN event_id INT NULL,
+    ADD FOREIGN KEY (event_id) REFERENCES events(id),
+    ADD INDEX idx_expenses_event_id (event_id);
@@ -0,0 +1,12 @@
+-- Read-only links to an event's ledger. The signed token is derived from id and expires_at, so
+-- only revocation needs to be looked up.
+CREATE TABLE share_links (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    event_id INT NOT NULL,
+    created_by INT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (event_id) REFERENCES events(id),
+    FOREIGN KEY (created_by) REFERENCES users(id)
+);
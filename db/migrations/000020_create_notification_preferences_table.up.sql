@@ -0,0 +1,8 @@
+-- Users without a row get the defaults: a weekly digest, never sent yet
+CREATE TABLE notification_preferences (
+    user_id INT PRIMARY KEY,
+    digest_frequency ENUM('daily', 'weekly', 'never') NOT NULL DEFAULT 'weekly',
+    last_digest_at TIMESTAMP NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
@@ -0,0 +1,3 @@
+-- Moves forward whenever an expense or balance involving the user changes, for Last-Modified
+ALTER TABLE users
+    ADD COLUMN last_modified_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
@@ -0,0 +1,7 @@
+-- Which channels a user is reached on and which notifications they want. Everything is on by default.
+ALTER TABLE notification_preferences
+    ADD COLUMN email_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN push_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN new_expense_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN reminder_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN digest_enabled BOOLEAN NOT NULL DEFAULT TRUE;
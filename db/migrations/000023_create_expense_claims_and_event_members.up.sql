@@ -0,0 +1,19 @@
+-- Participants who claimed their share of an expense through its invite link.
+CREATE TABLE expense_share_claims (
+    expense_id INT NOT NULL,
+    user_id INT NOT NULL,
+    claimed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (expense_id, user_id),
+    FOREIGN KEY (expense_id) REFERENCES expenses(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- Users who joined an event through an invite link before having an expense in it.
+CREATE TABLE event_members (
+    event_id INT NOT NULL,
+    user_id INT NOT NULL,
+    joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (event_id, user_id),
+    FOREIGN KEY (event_id) REFERENCES events(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
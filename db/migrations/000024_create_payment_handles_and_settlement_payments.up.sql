@@ -0,0 +1,15 @@
+-- Where each user can be paid, used to build payment links for settle-up transfers.
+CREATE TABLE payment_handles (
+    user_id INT PRIMARY KEY,
+    upi_id VARCHAR(255) NULL,
+    paypal_me VARCHAR(255) NULL,
+    venmo VARCHAR(255) NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- The payment behind a settlement recorded from a payment callback, so each payment is recorded once.
+ALTER TABLE settlements
+    ADD COLUMN payment_provider VARCHAR(32) NULL,
+    ADD COLUMN payment_reference VARCHAR(255) NULL,
+    ADD UNIQUE KEY uq_settlements_payment (payment_provider, payment_reference);
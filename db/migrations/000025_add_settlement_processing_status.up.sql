@@ -0,0 +1,3 @@
+-- A settlement being paid in-app waits in processing until the provider reports on the payment.
+ALTER TABLE settlements
+    MODIFY COLUMN status ENUM('proposed', 'sent', 'processing', 'confirmed', 'disputed') NOT NULL DEFAULT 'proposed';
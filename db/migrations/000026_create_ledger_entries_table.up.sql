@@ -0,0 +1,21 @@
+-- The double-entry ledger: every balance change is a posting of two entries, one per user, that
+-- cancel out. balances is the running total of these entries per pair.
+CREATE TABLE ledger_entries (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    source_type ENUM('opening', 'expense', 'expense_reversal', 'loan', 'settlement') NOT NULL,
+    source_id INT NULL,
+    user_id INT NOT NULL,
+    counterparty_id INT NOT NULL,
+    amount DECIMAL(12, 2) NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (counterparty_id) REFERENCES users(id),
+    INDEX idx_ledger_entries_user_created (user_id, created_at),
+    INDEX idx_ledger_entries_source (source_type, source_id)
+);
+
+-- Balances from before the ledger carry over as opening postings, so the two agree from the start.
+INSERT INTO ledger_entries (source_type, source_id, user_id, counterparty_id, amount, created_at)
+SELECT 'opening', NULL, user1_id, user2_id, balance, last_updated FROM balances WHERE balance <> 0
+UNION ALL
+SELECT 'opening', NULL, user2_id, user1_id, -balance, last_updated FROM balances WHERE balance <> 0;
@@ -0,0 +1,10 @@
+-- The optional expense event store. Kept without a foreign key to expenses, since an expense's
+-- events outlive it.
+CREATE TABLE expense_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    expense_id INT NOT NULL,
+    type ENUM('created', 'status_changed', 'deleted') NOT NULL,
+    data JSON NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_expense_events_expense (expense_id, id)
+);
@@ -0,0 +1,4 @@
+-- How an expense's splits moved balances, so undoing it reverses the same updates. Every expense
+-- before this used the simple strategy.
+ALTER TABLE expenses
+    ADD COLUMN balance_strategy VARCHAR(32) NOT NULL DEFAULT 'simple';
@@ -0,0 +1,6 @@
+-- A settlement routed through a third user, who owes the payee and is owed by the payer. Confirming
+-- it pays down both debts.
+ALTER TABLE settlements
+    ADD COLUMN via_user_id INT NULL AFTER payee_id,
+    ADD FOREIGN KEY (via_user_id) REFERENCES users(id),
+    ADD INDEX idx_settlements_via_user_id (via_user_id);
@@ -0,0 +1,5 @@
+-- Month-end settle-up: auto_settle opts an event in to having its suggested transfers proposed as
+-- settlements, and last_settle_up_at records when the last month-end run claimed the event.
+ALTER TABLE events
+    ADD COLUMN auto_settle BOOLEAN NOT NULL DEFAULT FALSE AFTER created_by,
+    ADD COLUMN last_settle_up_at TIMESTAMP NULL AFTER archived_at;
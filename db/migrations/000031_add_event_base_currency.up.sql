@@ -0,0 +1,9 @@
+-- An event can fix a base currency. Expenses entered in another currency are converted to it at
+-- entry, keeping what was entered and the rate used.
+ALTER TABLE events
+    ADD COLUMN base_currency CHAR(3) NULL AFTER created_by;
+
+ALTER TABLE expenses
+    ADD COLUMN original_currency CHAR(3) NULL AFTER currency,
+    ADD COLUMN original_amount DECIMAL(13, 3) NULL AFTER original_currency,
+    ADD COLUMN exchange_rate DECIMAL(18, 8) NULL AFTER original_amount;
@@ -0,0 +1,26 @@
+-- Chunked CSV imports: a session collects the upload in numbered chunks, so a client can resend only
+-- the ones that failed, and a job imports it once committed. Jobs can now report their progress.
+ALTER TABLE jobs
+    ADD COLUMN progress_done INT NULL AFTER last_error,
+    ADD COLUMN progress_total INT NULL AFTER progress_done;
+
+CREATE TABLE import_sessions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    created_by INT NOT NULL,
+    status ENUM('open', 'committed', 'done', 'failed') NOT NULL DEFAULT 'open',
+    chunk_count INT NOT NULL DEFAULT 0,
+    rows_imported INT NOT NULL DEFAULT 0,
+    job_id BIGINT NULL,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE TABLE import_chunks (
+    session_id INT NOT NULL,
+    seq INT NOT NULL,
+    data MEDIUMBLOB NOT NULL,
+    PRIMARY KEY (session_id, seq),
+    FOREIGN KEY (session_id) REFERENCES import_sessions(id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+-- Counts the expenses a user created today against the daily quota.
+CREATE INDEX idx_expenses_created_by_created_at ON expenses (created_by, created_at);
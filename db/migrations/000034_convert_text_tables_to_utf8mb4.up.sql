@@ -0,0 +1,10 @@
+-- Free text is stored as full UTF-8, so descriptions, names and dispute reasons can hold emoji and
+-- other characters outside the Basic Multilingual Plane. VARCHAR lengths count characters, so
+-- VARCHAR(255) columns still hold the 255 characters the API allows.
+ALTER TABLE users CONVERT TO CHARACTER SET utf8mb4;
+ALTER TABLE expenses CONVERT TO CHARACTER SET utf8mb4;
+ALTER TABLE expense_locations CONVERT TO CHARACTER SET utf8mb4;
+ALTER TABLE tag_budgets CONVERT TO CHARACTER SET utf8mb4;
+ALTER TABLE parties CONVERT TO CHARACTER SET utf8mb4;
+ALTER TABLE events CONVERT TO CHARACTER SET utf8mb4;
+ALTER TABLE loans CONVERT TO CHARACTER SET utf8mb4;
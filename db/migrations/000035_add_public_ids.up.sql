@@ -0,0 +1,14 @@
+-- Public IDs identify users and expenses in the API without exposing the guessable integer keys.
+-- Existing rows get a random (version 4) UUID; new ones get whatever PUBLIC_IDS.FORMAT makes, or
+-- the ID the client sent.
+ALTER TABLE users ADD COLUMN public_id CHAR(36) NULL AFTER id;
+UPDATE users SET public_id = LOWER(CONCAT_WS('-', HEX(RANDOM_BYTES(4)), HEX(RANDOM_BYTES(2)), CONCAT('4', SUBSTR(HEX(RANDOM_BYTES(2)), 2)), CONCAT(ELT(1 + FLOOR(RAND() * 4), '8', '9', 'A', 'B'), SUBSTR(HEX(RANDOM_BYTES(2)), 2)), HEX(RANDOM_BYTES(6))));
+ALTER TABLE users
+    MODIFY COLUMN public_id CHAR(36) NOT NULL,
+    ADD UNIQUE INDEX uq_users_public_id (public_id);
+
+ALTER TABLE expenses ADD COLUMN public_id CHAR(36) NULL AFTER id;
+UPDATE expenses SET public_id = LOWER(CONCAT_WS('-', HEX(RANDOM_BYTES(4)), HEX(RANDOM_BYTES(2)), CONCAT('4', SUBSTR(HEX(RANDOM_BYTES(2)), 2)), CONCAT(ELT(1 + FLOOR(RAND() * 4), '8', '9', 'A', 'B'), SUBSTR(HEX(RANDOM_BYTES(2)), 2)), HEX(RANDOM_BYTES(6))));
+ALTER TABLE expenses
+    MODIFY COLUMN public_id CHAR(36) NOT NULL,
+    ADD UNIQUE INDEX uq_expenses_public_id (public_id);
@@ -0,0 +1,7 @@
+-- Keyword rules that suggest a tag from an expense's description, when it is created or on request.
+CREATE TABLE tag_rules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    keyword VARCHAR(100) NOT NULL UNIQUE,
+    tag VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) CHARACTER SET utf8mb4;
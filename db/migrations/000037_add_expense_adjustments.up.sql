@@ -0,0 +1,5 @@
+-- Under lenient validation a small difference between the splits and the total is absorbed into
+-- the creator's split. What was added to their amounts paid and owed is kept on the expense.
+ALTER TABLE expenses
+    ADD COLUMN paid_adjustment DECIMAL(13, 3) NULL AFTER exchange_rate,
+    ADD COLUMN owed_adjustment DECIMAL(13, 3) NULL AFTER paid_adjustment;
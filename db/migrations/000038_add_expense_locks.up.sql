@@ -0,0 +1,6 @@
+-- Confirming a settlement locks the expenses behind the balance it paid down, so they can't be
+-- undone or disputed until their creator unlocks them. settled_by stays set once unlocked.
+ALTER TABLE expenses
+    ADD COLUMN locked_at TIMESTAMP NULL AFTER balance_strategy,
+    ADD COLUMN settled_by INT NULL AFTER locked_at,
+    ADD FOREIGN KEY (settled_by) REFERENCES settlements(id);
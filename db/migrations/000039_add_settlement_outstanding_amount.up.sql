@@ -0,0 +1,4 @@
+-- A settlement may pay only part of what the payer owes. What they owed the payee when it was
+-- proposed is kept, so the settlement can tell what it leaves outstanding.
+ALTER TABLE settlements
+    ADD COLUMN outstanding_amount DECIMAL(10, 2) NULL AFTER amount;
@@ -0,0 +1,11 @@
+-- The minor unit of each currency that doesn't have two decimal places, per ISO 4217. Currencies
+-- missing from the table are treated as having two.
+CREATE TABLE currencies (
+    code CHAR(3) PRIMARY KEY,
+    exponent TINYINT UNSIGNED NOT NULL
+);
+
+INSERT INTO currencies (code, exponent) VALUES
+    ('BIF', 0), ('CLP', 0), ('DJF', 0), ('GNF', 0), ('ISK', 0), ('JPY', 0), ('KMF', 0), ('KRW', 0),
+    ('PYG', 0), ('RWF', 0), ('UGX', 0), ('VND', 0), ('VUV', 0), ('XAF', 0), ('XOF', 0), ('XPF', 0),
+    ('BHD', 3), ('IQD', 3), ('JOD', 3), ('KWD', 3), ('LYD', 3), ('OMR', 3), ('TND', 3);
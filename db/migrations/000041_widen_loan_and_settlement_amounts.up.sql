@@ -0,0 +1,11 @@
+-- Loans, settlements and the ledger keep three decimal places like expenses and balances, so a
+-- default currency such as KWD is stored exactly
+ALTER TABLE loans
+    MODIFY amount DECIMAL(13, 3) NOT NULL;
+
+ALTER TABLE settlements
+    MODIFY amount DECIMAL(13, 3) NOT NULL,
+    MODIFY outstanding_amount DECIMAL(13, 3) NULL;
+
+ALTER TABLE ledger_entries
+    MODIFY amount DECIMAL(13, 3) NOT NULL;
@@ -0,0 +1,4 @@
+-- A routed settlement pays down two pairs. What the user it is routed through owed the payee when it
+-- was proposed is kept next to what the payer owed that user.
+ALTER TABLE settlements
+    ADD COLUMN via_outstanding_amount DECIMAL(13, 3) NULL AFTER outstanding_amount;
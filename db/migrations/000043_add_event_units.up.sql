@@ -0,0 +1,7 @@
+-- An event can count its expenses in a unit of its own, such as chore points, instead of money.
+-- Those expenses are kept in XXX, ISO 4217's code for no currency, in whole units, and stay out of
+-- the balances.
+ALTER TABLE events
+    ADD COLUMN unit VARCHAR(64) NULL AFTER base_currency;
+
+INSERT INTO currencies (code, exponent) VALUES ('XXX', 0);
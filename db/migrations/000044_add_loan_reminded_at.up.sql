@@ -0,0 +1,5 @@
+-- The borrower is reminded once, when a loan falls due with something still owed on it. The time
+-- the reminder was claimed is kept, so it goes out once however many servers are running.
+ALTER TABLE loans
+    ADD COLUMN reminded_at TIMESTAMP NULL AFTER due_date,
+    ADD INDEX idx_loans_due_date (due_date);
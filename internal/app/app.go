@@ -0,0 +1,443 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/config"
+	"github.com/aadithya-md/split-expense/internal/handler"
+	"github.com/aadithya-md/split-expense/internal/middleware"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/repository/memory"
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/router"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/aadithya-md/split-expense/internal/util"
+	"github.com/aadithya-md/split-expense/internal/web"
+	"github.com/gorilla/mux"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// App holds the fully wired application: database handle, repositories, services and HTTP router.
+type App struct {
+	Config *config.Config
+	DB     *sql.DB // Nil with the memory storage backend
+
+	UserRepo          repository.UserRepository
+	ExpenseRepo       repository.ExpenseRepository
+	BalanceRepo       repository.BalanceRepository
+	LoanRepo          repository.LoanRepository
+	SettlementRepo    repository.SettlementRepository
+	AuditRepo         repository.AuditRepository
+	JobRepo           repository.JobRepository
+	BudgetRepo        repository.BudgetRepository
+	GoalRepo          repository.GoalRepository
+	PartyRepo         repository.PartyRepository
+	EventRepo         repository.EventRepository
+	ShareLinkRepo     repository.ShareLinkRepository
+	PreferenceRepo    repository.NotificationPreferenceRepository
+	PaymentHandleRepo repository.PaymentHandleRepository
+	LedgerRepo        repository.LedgerRepository
+	ExpenseEventRepo  repository.ExpenseEventRepository
+	ImportRepo        repository.ImportRepository
+	QuotaRepo         repository.QuotaRepository
+	TagRuleRepo       repository.TagRuleRepository
+	CurrencyRepo      repository.CurrencyRepository
+	QueryStatsRepo    repository.QueryStatsRepository // Nil unless the database's queries are recorded
+
+	UserService       service.UserService
+	ExpenseService    service.ExpenseService
+	LoanService       service.LoanService
+	SettlementService service.SettlementService
+	AnalyticsService  service.AnalyticsService
+	AuditService      service.AuditService
+	JobService        service.JobService
+	HealthService     service.HealthService
+	BudgetService     service.BudgetService
+	GoalService       service.GoalService
+	PartyService      service.PartyService
+	EventService      service.EventService
+	ShareService      service.ShareService
+	Notifier          service.Notifier
+	DigestService     service.DigestService
+	SettleUpService   service.SettleUpService
+	PreferenceService service.PreferenceService
+	InviteService     service.InviteService
+	PaymentService    service.PaymentService
+	StripeService     service.StripeService
+	LedgerService     service.LedgerService
+	RateService       service.RateService
+	ImportService     service.ImportService
+	QuotaService      service.QuotaService
+	TagService        service.TagService
+	QueryService      service.QueryService
+
+	Router http.Handler
+}
+
+// New opens the database described by cfg, verifies the connection and wires every component on top
+// of it. With the memory storage backend it wires them on an empty in-memory store instead.
+func New(cfg *config.Config) (*App, error) {
+	if cfg.Storage.Backend == "memory" {
+		if chaosDBFaults(cfg.Chaos) != (repository.DBFaults{}) {
+			log.Println("CHAOS database faults are only injected with the mysql storage backend.")
+		}
+		return NewInMemory(cfg)
+	}
+
+	var metrics *repository.QueryMetrics
+	if cfg.SQLDb.QueryMetrics {
+		metrics = repository.NewQueryMetrics()
+	}
+	db, err := openDB(cfg.SQLDb, repository.DBFaults{}, metrics)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	}
+
+	// Ping the database to verify the connection
+	if err := pingWithRetry(db, cfg.SQLDb.ConnectAttempts, cfg.SQLDb.ConnectBackoff); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to the database: %w", err)
+	}
+
+	if err := repository.VerifySchema(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Faults are only injected once the schema has been checked, so start-up can't fail on one
+	if faults := chaosDBFaults(cfg.Chaos); faults != (repository.DBFaults{}) {
+		log.Printf("CHAOS is on: failing %g of statements with deadlocks and %g with timeouts.", faults.DeadlockRate, faults.TimeoutRate)
+		db.Close()
+		if db, err = openDB(cfg.SQLDb, faults, metrics); err != nil {
+			return nil, fmt.Errorf("failed to open database connection: %w", err)
+		}
+	}
+
+	a, err := newWithDB(cfg, db, metrics)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+// pingWithRetry pings db up to attempts times, doubling the wait after each failure, so a database
+// that is still starting up doesn't take the server down with it.
+func pingWithRetry(db *sql.DB, attempts int, backoff time.Duration) error {
+	for attempt := 1; ; attempt++ {
+		err := db.Ping()
+		if err == nil || attempt >= attempts {
+			return err
+		}
+		log.Printf("Database not reachable (attempt %d of %d), retrying in %s: %v", attempt, attempts, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// openDB opens the MySQL database, capping statement time, logging slow queries, recording
+// statements in metrics and injecting faults when configured. metrics may be nil.
+func openDB(cfg config.SQLDbConfig, faults repository.DBFaults, metrics *repository.QueryMetrics) (*sql.DB, error) {
+	dsn, err := mysql.ParseDSN(cfg.ConnectionString)
+	if err != nil {
+		return nil, err
+	}
+	repository.ApplyQueryTimeout(dsn, cfg.QueryTimeout)
+	connector, err := mysql.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SlowQueryThreshold > 0 {
+		connector = repository.SlowQueryConnector(connector, cfg.SlowQueryThreshold)
+	}
+	if metrics != nil {
+		connector = repository.MetricsConnector(connector, metrics)
+	}
+	if faults != (repository.DBFaults{}) {
+		connector = repository.FaultConnector(connector, faults)
+	}
+	return sql.OpenDB(connector), nil
+}
+
+// chaosDBFaults is what cfg asks the database to fail, nothing unless chaos is enabled.
+func chaosDBFaults(cfg config.ChaosConfig) repository.DBFaults {
+	if !cfg.Enabled {
+		return repository.DBFaults{}
+	}
+	return repository.DBFaults{DeadlockRate: cfg.DBDeadlockRate, TimeoutRate: cfg.DBTimeoutRate}
+}
+
+// chaosRules turns the configured routes, like "POST /expenses" or "*", into middleware rules.
+func chaosRules(routes []config.ChaosRouteConfig) []middleware.ChaosRule {
+	rules := make([]middleware.ChaosRule, 0, len(routes))
+	for _, rc := range routes {
+		rule := middleware.ChaosRule{Route: rc.Route, Latency: rc.Latency, Jitter: rc.Jitter, ErrorRate: rc.ErrorRate, Status: rc.Status}
+		if method, route, found := strings.Cut(rc.Route, " "); found {
+			rule.Method, rule.Route = strings.ToUpper(method), strings.TrimSpace(route)
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// newNotifier sends through the configured SMTP relay, or logs notifications when there is none.
+func newNotifier(cfg config.NotificationsConfig) service.Notifier {
+	if cfg.SMTPAddr == "" {
+		return service.NewLogNotifier()
+	}
+	return service.NewSMTPNotifier(service.SMTPConfig{
+		Addr:     cfg.SMTPAddr,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.From,
+	})
+}
+
+// NewWithDB wires the application on top of an already opened database handle. Its queries are
+// not recorded for the admin report.
+func NewWithDB(cfg *config.Config, db *sql.DB) (*App, error) {
+	return newWithDB(cfg, db, nil)
+}
+
+// newWithDB is NewWithDB on a database whose connector records its statements in metrics, which
+// may be nil.
+func newWithDB(cfg *config.Config, db *sql.DB, metrics *repository.QueryMetrics) (*App, error) {
+	a := &App{Config: cfg, DB: db}
+
+	a.UserRepo = repository.NewUserRepository(db)
+	a.BalanceRepo = repository.NewBalanceRepository(db)
+	if cfg.EventStore.Enabled {
+		a.ExpenseRepo = repository.NewEventSourcedExpenseRepository(db, a.BalanceRepo)
+	} else {
+		a.ExpenseRepo = repository.NewExpenseRepository(db, a.BalanceRepo)
+	}
+	a.LoanRepo = repository.NewLoanRepository(db, a.BalanceRepo)
+	a.SettlementRepo = repository.NewSettlementRepository(db, a.BalanceRepo)
+	a.AuditRepo = repository.NewAuditRepository(db)
+	a.JobRepo = repository.NewJobRepository(db)
+	a.BudgetRepo = repository.NewBudgetRepository(db)
+	a.GoalRepo = repository.NewGoalRepository(db)
+	a.PartyRepo = repository.NewPartyRepository(db)
+	a.EventRepo = repository.NewEventRepository(db)
+	a.ShareLinkRepo = repository.NewShareLinkRepository(db)
+	a.PreferenceRepo = repository.NewNotificationPreferenceRepository(db)
+	a.PaymentHandleRepo = repository.NewPaymentHandleRepository(db)
+	a.LedgerRepo = repository.NewLedgerRepository(db)
+	a.ExpenseEventRepo = repository.NewExpenseEventRepository(db)
+	a.ImportRepo = repository.NewImportRepository(db)
+	a.QuotaRepo = repository.NewQuotaRepository(db)
+	a.TagRuleRepo = repository.NewTagRuleRepository(db)
+	a.CurrencyRepo = repository.NewCurrencyRepository(db)
+	if metrics != nil {
+		a.QueryStatsRepo = repository.NewQueryStatsRepository(db, metrics)
+	}
+
+	if err := a.wire(db); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// NewInMemory wires the application on an empty in-memory store, which needs no database and keeps
+// nothing once the process exits.
+func NewInMemory(cfg *config.Config) (*App, error) {
+	store := memory.NewStore(memory.Options{RecordExpenseEvents: cfg.EventStore.Enabled})
+	a := &App{
+		Config:            cfg,
+		UserRepo:          store.Users,
+		ExpenseRepo:       store.Expenses,
+		BalanceRepo:       store.Balances,
+		LoanRepo:          store.Loans,
+		SettlementRepo:    store.Settlements,
+		AuditRepo:         store.Audit,
+		JobRepo:           store.Jobs,
+		BudgetRepo:        store.Budgets,
+		GoalRepo:          store.Goals,
+		PartyRepo:         store.Parties,
+		EventRepo:         store.Events,
+		ShareLinkRepo:     store.ShareLinks,
+		PreferenceRepo:    store.Preferences,
+		PaymentHandleRepo: store.PaymentHandles,
+		LedgerRepo:        store.Ledger,
+		ExpenseEventRepo:  store.ExpenseEvents,
+		ImportRepo:        store.Imports,
+		QuotaRepo:         store.Quotas,
+		TagRuleRepo:       store.TagRules,
+		CurrencyRepo:      store.Currencies,
+	}
+	if err := a.wire(store); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// wire builds the services and router on top of the repositories already set on a. db is what the
+// health check pings.
+func (a *App) wire(db service.Pinger) error {
+	cfg := a.Config
+	adminNets, err := middleware.ParseIPNets(cfg.Admin.AllowedIPs)
+	if err != nil {
+		return fmt.Errorf("invalid admin allowed IPs: %w", err)
+	}
+
+	ids, err := util.NewIDGenerator(cfg.PublicIDs.Format)
+	if err != nil {
+		return fmt.Errorf("invalid public ID format: %w", err)
+	}
+
+	// Amounts are rounded to their currency's minor unit everywhere, so the units are loaded first
+	exponents, err := a.CurrencyRepo.GetCurrencyExponents()
+	if err != nil {
+		return fmt.Errorf("failed to load currencies: %w", err)
+	}
+	currencies := util.NewCurrencies(exponents)
+	if exp := currencies.Exponent(util.DefaultCurrency); exp != util.DefaultExponent {
+		return fmt.Errorf("currencies table gives %s %d decimal places, but balances are kept in %d", util.DefaultCurrency, exp, util.DefaultExponent)
+	}
+
+	a.UserService = service.NewUserService(a.UserRepo, ids)
+	a.RateService = service.NewStaticRateService(cfg.Rates.Values)
+	a.BudgetService = service.NewBudgetService(a.BudgetRepo, a.UserService, currencies, cfg.Limits.EnforceTagBudgets)
+	a.QuotaService = service.NewQuotaService(a.QuotaRepo, service.Quotas{
+		ExpensesPerDay: cfg.Quotas.ExpensesPerDay,
+		Events:         cfg.Quotas.Events,
+		UploadBytes:    int64(cfg.Quotas.UploadMB) << 20,
+	})
+	// Only the keyword rules suggest tags; no classifier model is configured
+	a.TagService = service.NewTagService(a.TagRuleRepo, nil)
+	a.JobService = service.NewJobService(a.JobRepo, service.JobOptions{
+		MaxAttempts:  cfg.Jobs.MaxAttempts,
+		PollInterval: cfg.Jobs.PollInterval,
+		Lease:        cfg.Jobs.Lease,
+		BaseBackoff:  cfg.Jobs.BaseBackoff,
+	})
+	a.Notifier = service.NewPreferenceNotifier(newNotifier(cfg.Notifications), repository.ChannelEmail, a.PreferenceRepo)
+	validation := service.ValidationPolicy{Mode: cfg.Validation.Mode, MaxAdjustment: cfg.Validation.MaxAdjustment}
+	a.ExpenseService = service.NewAnnouncingExpenseService(
+		service.NewExpenseService(a.ExpenseRepo, a.UserService, a.BalanceRepo, a.BudgetService, a.QuotaService, a.PartyRepo, a.EventRepo, a.SettlementRepo, a.LedgerRepo, a.RateService, a.TagService, ids, currencies, cfg.Limits.UndoWindow, validation),
+		a.ExpenseRepo, a.UserService, a.JobService, a.Notifier, cfg.Limits.UndoWindow,
+	)
+	a.LoanService = service.NewLoanService(a.LoanRepo, a.LedgerRepo, a.UserService, a.JobService, a.Notifier)
+	a.SettlementService = service.NewSettlementService(a.SettlementRepo, a.ExpenseRepo, a.BalanceRepo, a.UserService)
+	a.AnalyticsService = service.NewCachedAnalyticsService(service.NewAnalyticsService(a.ExpenseRepo, a.BalanceRepo, a.SettlementRepo, a.EventRepo, a.UserService, currencies), cfg.Analytics.CacheTTL)
+	a.AuditService = service.NewAuditService(a.AuditRepo)
+	a.HealthService = service.NewHealthService(db, a.JobRepo)
+	a.GoalService = service.NewGoalService(a.GoalRepo, a.BalanceRepo, a.UserService)
+	a.PartyService = service.NewPartyService(a.PartyRepo)
+	a.PaymentService = service.NewPaymentService(a.PaymentHandleRepo, a.SettlementRepo, a.UserService, cfg.Notifications.BaseURL, cfg.Payments.CallbackSecrets, currencies)
+	a.StripeService = service.NewStripeService(a.SettlementRepo, service.StripeOptions{
+		SecretKey:     cfg.Payments.StripeSecretKey,
+		WebhookSecret: cfg.Payments.StripeWebhookSecret,
+		APIBase:       cfg.Payments.StripeAPIBase,
+		Currency:      cfg.Payments.Currency,
+	}, currencies)
+	// Shared ledgers are built from the plain event service, so a share link does not hand out
+	// anyone's payment handles
+	eventService := service.NewEventService(a.EventRepo, a.UserService, a.QuotaService, currencies)
+	a.EventService = service.NewPaymentLinkingEventService(eventService, a.PaymentService)
+	a.ShareService = service.NewShareService(a.ShareLinkRepo, a.EventRepo, eventService, a.UserService, cfg.Share.Secret, cfg.Share.DefaultTTL, cfg.Share.MaxTTL)
+	a.DigestService = service.NewDigestService(a.PreferenceRepo, a.UserService, a.ExpenseService, a.SettlementService, a.LoanService, a.JobService, a.Notifier, service.DigestOptions{
+		BaseURL:    cfg.Notifications.BaseURL,
+		LinkSecret: cfg.Notifications.LinkSecret,
+	})
+	a.SettleUpService = service.NewSettleUpService(a.EventRepo, a.EventService, a.SettlementRepo, a.ExpenseRepo, a.UserService, a.JobService, a.Notifier)
+
+	a.PreferenceService = service.NewPreferenceService(a.PreferenceRepo, a.UserService)
+	a.LedgerService = service.NewLedgerService(a.LedgerRepo, a.UserService)
+	a.ImportService = service.NewImportService(a.ImportRepo, a.ExpenseService, a.UserService, a.JobService, a.QuotaService, currencies)
+	a.QueryService = service.NewQueryService(a.QueryStatsRepo)
+	a.InviteService = service.NewInviteService(a.ExpenseRepo, a.EventRepo, a.UserService, cfg.Share.Secret, cfg.Share.DefaultTTL, cfg.Notifications.BaseURL)
+
+	services := router.Services{
+		User:       a.UserService,
+		Expense:    a.ExpenseService,
+		Loan:       a.LoanService,
+		Settlement: a.SettlementService,
+		Analytics:  a.AnalyticsService,
+		Audit:      a.AuditService,
+		Jobs:       a.JobService,
+		Health:     a.HealthService,
+		Budget:     a.BudgetService,
+		Goal:       a.GoalService,
+		Party:      a.PartyService,
+		Tag:        a.TagService,
+		Event:      a.EventService,
+		Share:      a.ShareService,
+		Digest:     a.DigestService,
+		Preference: a.PreferenceService,
+		Invite:     a.InviteService,
+		Payment:    a.PaymentService,
+		Stripe:     a.StripeService,
+		Ledger:     a.LedgerService,
+		Import:     a.ImportService,
+		Quota:      a.QuotaService,
+		Query:      a.QueryService,
+	}
+	opts := router.Options{
+		ExpenseLimits: handler.ExpenseLimits{
+			MaxParticipants:      cfg.Limits.MaxParticipants,
+			MaxTotalAmount:       cfg.Limits.MaxTotalAmount,
+			MaxDescriptionLength: cfg.Limits.MaxDescriptionLength,
+		},
+		Currencies:    currencies,
+		VerboseHealth: cfg.Health.Verbose,
+		AdminMiddleware: []middleware.Middleware{
+			middleware.IPAllowlist(adminNets),
+			middleware.BasicAuth("admin", cfg.Admin.Username, cfg.Admin.Password),
+		},
+	}
+	if cfg.Analytics.MaxConcurrentPerClient > 0 {
+		opts.AnalyticsMiddleware = append(opts.AnalyticsMiddleware, middleware.ConcurrencyLimit(cfg.Analytics.MaxConcurrentPerClient))
+	}
+	accessLog := middleware.AccessLog(middleware.AccessLogOptions{
+		Format:        cfg.Logging.Format,
+		SampledRoutes: cfg.Logging.SampledRoutes,
+		SampleRate:    cfg.Logging.SampleRate,
+	})
+	mws := []middleware.Middleware{accessLog, middleware.Audit(a.AuditService), middleware.Recovery}
+	if cfg.Chaos.Enabled && len(cfg.Chaos.Routes) > 0 {
+		// Inside the access log, so injected latency and failures show up in it
+		mws = slices.Insert(mws, 1, middleware.Chaos(chaosRules(cfg.Chaos.Routes)))
+	}
+	r := router.NewRouter(services, opts, mws...)
+	if cfg.Frontend.Enabled {
+		// Registered last so the API routes always take precedence over the UI fallback. It only
+		// takes page loads and the UI's own files, so an unknown API path is still a 404, and it
+		// checks that first, before any matcher that matches would clear a 405 from the API routes
+		r.MatcherFunc(func(req *http.Request, _ *mux.RouteMatch) bool {
+			return web.Serves(req)
+		}).Methods("GET", "HEAD").Handler(web.Handler())
+	}
+	a.Router = middleware.StripTrailingSlash(r)
+	if cfg.HttpServer.LegacyResponses {
+		a.Router = response.Legacy(a.Router)
+	}
+
+	return nil
+}
+
+// Server returns an http.Server serving the application router with the configured address and timeouts.
+func (a *App) Server() *http.Server {
+	return &http.Server{
+		Addr:         fmt.Sprintf("%s:%s", a.Config.HttpServer.Address, a.Config.HttpServer.Port),
+		Handler:      a.Router,
+		ReadTimeout:  a.Config.HttpServer.ReadTimeout,
+		WriteTimeout: a.Config.HttpServer.WriteTimeout,
+		IdleTimeout:  a.Config.HttpServer.IdleTimeout,
+	}
+}
+
+// Close releases the resources held by the application.
+func (a *App) Close() error {
+	if a.DB == nil {
+		return nil
+	}
+	return a.DB.Close()
+}
@@ -0,0 +1,302 @@
+// Package clientgen generates API clients from the router's route table.
+package clientgen
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/router"
+)
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	rawMessageType  = reflect.TypeOf(json.RawMessage{})
+	marshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// TypeScript returns a TypeScript module with an interface for every request and response type the
+// routes use and a client class with a method per route. Routes under /ui/ serve HTML pages and are
+// left out. Routes without a JSON response resolve to the fetch Response.
+func TypeScript(routes []router.Route) ([]byte, error) {
+	g := &tsGenerator{names: make(map[reflect.Type]string), taken: make(map[string]reflect.Type)}
+
+	var methods bytes.Buffer
+	seen := make(map[string]string)
+	for _, route := range routes {
+		if strings.HasPrefix(route.Path, "/ui/") {
+			continue
+		}
+		name := methodName(route.Method, route.Path)
+		if other, ok := seen[name]; ok {
+			return nil, fmt.Errorf("%s %s and %s both map to method %s", route.Method, route.Path, other, name)
+		}
+		seen[name] = route.Method + " " + route.Path
+		g.writeMethod(&methods, name, route)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by cmd/genclient. DO NOT EDIT.\n\n")
+	types := make([]reflect.Type, 0, len(g.names))
+	for t := range g.names {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return g.names[types[i]] < g.names[types[j]] })
+	for _, t := range types {
+		g.writeType(&out, t)
+	}
+	out.WriteString(tsClientHeader)
+	out.Write(methods.Bytes())
+	out.WriteString("}\n")
+	return out.Bytes(), nil
+}
+
+const tsClientHeader = `export class ApiError extends Error {
+  // requestId identifies the request in the server's logs, when the API itself answered. code and
+  // field say why a request body was refused.
+  constructor(
+    public readonly status: number,
+    message: string,
+    public readonly requestId?: string,
+    public readonly code?: string,
+    public readonly field?: string,
+  ) {
+    super(message);
+  }
+}
+
+interface Envelope<T> {
+  data: T;
+  meta: { request_id?: string; api_version: string };
+  error: { message: string; code?: string; field?: string } | null;
+}
+
+export interface ClientOptions {
+  // Headers sent with every request, such as Authorization
+  headers?: Record<string, string>;
+  fetch?: typeof fetch;
+}
+
+export class SplitExpenseClient {
+  private readonly fetch: typeof fetch;
+
+  constructor(private readonly baseUrl: string, private readonly options: ClientOptions = {}) {
+    this.baseUrl = baseUrl.replace(/\/+$/, "");
+    this.fetch = options.fetch ?? fetch.bind(globalThis);
+  }
+
+  private async send(method: string, path: string, body?: unknown, query?: Record<string, string>): Promise<Response> {
+    const search = query ? "?" + new URLSearchParams(query).toString() : "";
+    const headers: Record<string, string> = { Accept: "application/json", ...this.options.headers };
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+    }
+    const response = await this.fetch(this.baseUrl + path + search, {
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    if (!response.ok) {
+      const text = (await response.text()).trim();
+      let envelope: Envelope<unknown> | undefined;
+      try {
+        envelope = JSON.parse(text);
+      } catch {
+        // Not from the API itself, e.g. a proxy in front of it
+      }
+      if (envelope?.error) {
+        const { message, code, field } = envelope.error;
+        throw new ApiError(response.status, message, envelope.meta?.request_id, code, field);
+      }
+      throw new ApiError(response.status, text);
+    }
+    return response;
+  }
+
+  private async json<T>(method: string, path: string, body?: unknown, query?: Record<string, string>): Promise<T> {
+    const response = await this.send(method, path, body, query);
+    return ((await response.json()) as Envelope<T>).data;
+  }
+`
+
+type tsGenerator struct {
+	names map[reflect.Type]string // Named struct types to declare, by their TypeScript name
+	taken map[string]reflect.Type
+}
+
+func (g *tsGenerator) writeMethod(out *bytes.Buffer, name string, route router.Route) {
+	var (
+		params []string
+		path   = route.Path
+	)
+	for _, segment := range strings.Split(route.Path, "/") {
+		if param, ok := pathParam(segment); ok {
+			kind := "string"
+			if param == "id" {
+				kind = "string | number"
+			}
+			params = append(params, param+": "+kind)
+			path = strings.Replace(path, segment, "${encodeURIComponent(String("+param+"))}", 1)
+		}
+	}
+	body := "undefined"
+	if route.Request != nil {
+		params = append(params, "body: "+g.typeOf(reflect.TypeOf(route.Request)))
+		body = "body"
+	}
+	params = append(params, "query?: Record<string, string>")
+
+	fmt.Fprintf(out, "\n  // %s %s\n", route.Method, route.Path)
+	if route.Response == nil {
+		fmt.Fprintf(out, "  %s(%s): Promise<Response> {\n", name, strings.Join(params, ", "))
+		fmt.Fprintf(out, "    return this.send(%q, `%s`, %s, query);\n  }\n", route.Method, path, body)
+		return
+	}
+	response := g.typeOf(reflect.TypeOf(route.Response))
+	fmt.Fprintf(out, "  %s(%s): Promise<%s> {\n", name, strings.Join(params, ", "), response)
+	fmt.Fprintf(out, "    return this.json<%s>(%q, `%s`, %s, query);\n  }\n", response, route.Method, path, body)
+}
+
+// typeOf returns the TypeScript for t, queueing the named structs it refers to for declaration.
+func (g *tsGenerator) typeOf(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		return g.typeOf(t.Elem()) + " | null"
+	}
+	switch {
+	case t == timeType:
+		return "string"
+	case t == rawMessageType:
+		return "unknown"
+	case t.Implements(marshalerType) || t.Implements(textMarshalType):
+		// Encodes itself however it likes
+		return "unknown"
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		// encoding/json sends bytes as a base64 string
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		elem := g.typeOf(t.Elem())
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		return "Record<string, " + g.typeOf(t.Elem()) + ">"
+	case reflect.Struct:
+		if t.Name() == "" {
+			var b bytes.Buffer
+			b.WriteString("{ ")
+			for _, f := range g.fields(t) {
+				fmt.Fprintf(&b, "%s; ", f)
+			}
+			b.WriteString("}")
+			return b.String()
+		}
+		return g.declare(t)
+	}
+	return "unknown"
+}
+
+// declare names a struct type, qualifying it with its package when another type took the name.
+func (g *tsGenerator) declare(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if other, ok := g.taken[name]; ok && other != t {
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	g.names[t] = name
+	g.taken[name] = t
+	// Fields are resolved now so the types they refer to are declared too
+	g.fields(t)
+	return name
+}
+
+func (g *tsGenerator) writeType(out *bytes.Buffer, t reflect.Type) {
+	fmt.Fprintf(out, "export interface %s {\n", g.names[t])
+	for _, f := range g.fields(t) {
+		fmt.Fprintf(out, "  %s;\n", f)
+	}
+	out.WriteString("}\n\n")
+}
+
+// fields lists the JSON fields of a struct the way encoding/json encodes them, with embedded
+// structs' fields promoted.
+func (g *tsGenerator) fields(t reflect.Type) []string {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			fields = append(fields, g.fields(f.Type)...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		optional := ""
+		if strings.Contains(","+opts+",", ",omitempty,") {
+			optional = "?"
+		}
+		fields = append(fields, fmt.Sprintf("%s%s: %s", name, optional, g.typeOf(f.Type)))
+	}
+	return fields
+}
+
+// methodName turns a route into a method name: the verb followed by the literal path segments, with
+// a trailing parameter read as "By<Param>". GET /users/by-email/{email} becomes getUsersByEmail.
+func methodName(method, path string) string {
+	name := strings.ToLower(method)
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		if param, ok := pathParam(segment); ok {
+			if i == len(segments)-1 && (i == 0 || !strings.HasPrefix(segments[i-1], "by-")) {
+				name += "By" + pascal(param)
+			}
+			continue
+		}
+		name += pascal(segment)
+	}
+	return name
+}
+
+func pathParam(segment string) (string, bool) {
+	if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+		return segment[1 : len(segment)-1], true
+	}
+	return "", false
+}
+
+// pascal joins the words of a path segment, split on "-", "_" and ".", in PascalCase.
+func pascal(s string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
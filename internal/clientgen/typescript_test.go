@@ -0,0 +1,67 @@
+package clientgen
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeScript_UpToDate(t *testing.T) {
+	client, err := TypeScript(router.Routes(router.Services{}, router.Options{}))
+	require.NoError(t, err)
+
+	committed, err := os.ReadFile("../../clients/typescript/client.ts")
+	require.NoError(t, err)
+	assert.True(t, string(committed) == string(client), "clients/typescript/client.ts is stale; run go run ./cmd/genclient -o clients/typescript/client.ts")
+}
+
+func TestTypeScript(t *testing.T) {
+	type Base struct {
+		ID int `json:"id"`
+	}
+	type Thing struct {
+		Base
+		Name     string            `json:"name"`
+		Note     *string           `json:"note,omitempty"`
+		Tags     []string          `json:"tags"`
+		Counts   map[string]int    `json:"counts"`
+		When     time.Time         `json:"when"`
+		Internal int               `json:"-"`
+		Nested   struct{ OK bool } `json:"nested"`
+	}
+
+	client, err := TypeScript([]router.Route{
+		{Method: "POST", Path: "/things", Request: Thing{}, Response: Thing{}},
+		{Method: "GET", Path: "/things/{id}/image.png"},
+		{Method: "GET", Path: "/ui/things"},
+	})
+	require.NoError(t, err)
+	ts := string(client)
+
+	// Test case 1: Structs become interfaces, with embedded fields promoted and omitempty optional
+	assert.Contains(t, ts, "export interface Thing {\n  id: number;\n  name: string;\n  note?: string | null;\n  tags: string[];\n  counts: Record<string, number>;\n  when: string;\n  nested: { OK: boolean; };\n}\n")
+
+	// Test case 2: A method per route, with path parameters first and raw responses where there is no JSON
+	assert.Contains(t, ts, "postThings(body: Thing, query?: Record<string, string>): Promise<Thing> {")
+	assert.Contains(t, ts, "getThingsImagePng(id: string | number, query?: Record<string, string>): Promise<Response> {")
+	assert.Contains(t, ts, "`/things/${encodeURIComponent(String(id))}/image.png`")
+
+	// Test case 3: HTML pages are left out
+	assert.False(t, strings.Contains(ts, "getUiThings"))
+
+	// Test case 4: Two routes that would share a method name are refused
+	_, err = TypeScript([]router.Route{{Method: "GET", Path: "/a-b"}, {Method: "GET", Path: "/a/b"}})
+	assert.ErrorContains(t, err, "both map to method getAB")
+}
+
+func TestMethodName(t *testing.T) {
+	assert.Equal(t, "getUsersById", methodName("GET", "/users/{id}"))
+	assert.Equal(t, "getUsersByEmail", methodName("GET", "/users/by-email/{email}"))
+	assert.Equal(t, "getExpensesByUserIdNearby", methodName("GET", "/expenses/by-user-id/{id}/nearby"))
+	assert.Equal(t, "postExpensesDismissDispute", methodName("POST", "/expenses/{id}/dismiss-dispute"))
+}
@@ -1,7 +1,12 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -13,24 +18,226 @@ type HttpServerConfig struct {
 	ReadTimeout  time.Duration `mapstructure:"READ_TIMEOUT"`
 	WriteTimeout time.Duration `mapstructure:"WRITE_TIMEOUT"`
 	IdleTimeout  time.Duration `mapstructure:"IDLE_TIMEOUT"`
+	// ShutdownTimeout bounds how long in-flight requests and background work get to finish on shutdown.
+	ShutdownTimeout time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	// LegacyResponses sends bare payloads and plain-text errors instead of the {data, meta, error}
+	// envelope, for clients that haven't migrated yet.
+	LegacyResponses bool `mapstructure:"LEGACY_RESPONSES"`
 }
 
 type SQLDbConfig struct {
-	ConnectionString string `mapstructure:"CONNECTION_STRING"`
+	ConnectionString   string        `mapstructure:"CONNECTION_STRING"`
+	SlowQueryThreshold time.Duration `mapstructure:"SLOW_QUERY_THRESHOLD"` // Zero disables slow query logging
+	// QueryTimeout caps how long a read runs and how long a write waits on a row lock, so a request
+	// fails with a timeout before HTTP_SERVER.WRITE_TIMEOUT cuts it off. Zero keeps the server's settings.
+	QueryTimeout time.Duration `mapstructure:"QUERY_TIMEOUT"`
+	// ConnectAttempts and ConnectBackoff let start-up wait out a database that is still coming up.
+	// The wait doubles after every failed attempt.
+	ConnectAttempts int           `mapstructure:"CONNECT_ATTEMPTS"`
+	ConnectBackoff  time.Duration `mapstructure:"CONNECT_BACKOFF"`
+	// QueryMetrics records how often each statement runs, how long it takes and how many rows it
+	// reads, for the admin report of the slowest queries.
+	QueryMetrics bool `mapstructure:"QUERY_METRICS"`
+}
+
+// LoggingConfig shapes the access log. Format is text, common, combined or json. Successful requests
+// to SampledRoutes are only logged at SampleRate; failed requests are always logged.
+type LoggingConfig struct {
+	Format        string   `mapstructure:"FORMAT"`
+	SampledRoutes []string `mapstructure:"SAMPLED_ROUTES"`
+	SampleRate    float64  `mapstructure:"SAMPLE_RATE"`
+}
+
+type FrontendConfig struct {
+	Enabled bool `mapstructure:"ENABLED"`
+}
+
+// LimitsConfig bounds the expenses the API accepts. Zero disables a limit.
+type LimitsConfig struct {
+	MaxParticipants      int     `mapstructure:"MAX_PARTICIPANTS"`
+	MaxTotalAmount       float64 `mapstructure:"MAX_TOTAL_AMOUNT"`
+	MaxDescriptionLength int     `mapstructure:"MAX_DESCRIPTION_LENGTH"`
+	// EnforceTagBudgets refuses expenses that take a participant over a tag budget instead of warning.
+	EnforceTagBudgets bool `mapstructure:"ENFORCE_TAG_BUDGETS"`
+	// UndoWindow is how long after creating an expense its creator may delete it outright. Participants
+	// hear about an expense only once the window has passed.
+	UndoWindow time.Duration `mapstructure:"UNDO_WINDOW"`
+}
+
+// QuotasConfig bounds what each user may create, to keep a free deployment within its resources.
+// Zero disables a quota. EXPENSES_PER_DAY counts by calendar day in UTC, EVENTS counts unarchived
+// events, and UPLOAD_MB is the import file data a user may have stored.
+type QuotasConfig struct {
+	ExpensesPerDay int `mapstructure:"EXPENSES_PER_DAY"`
+	Events         int `mapstructure:"EVENTS"`
+	UploadMB       int `mapstructure:"UPLOAD_MB"`
+}
+
+// ValidationConfig picks what happens when the amounts paid or owed across an expense's splits
+// don't add up to its total. A difference that is let through is made up in the creator's own split
+// and recorded on the expense; larger ones are rejected. "strict" lets through at most 0.01, in the
+// expense's currency, and "lenient" at most MaxAdjustment.
+type ValidationConfig struct {
+	Mode          string  `mapstructure:"MODE"`
+	MaxAdjustment float64 `mapstructure:"MAX_ADJUSTMENT"`
+}
+
+// PublicIDsConfig picks how the public IDs of users and expenses are generated: "uuid" for random
+// UUIDs, or "ulid" for IDs that sort by when they were made. Existing IDs are kept when it changes.
+type PublicIDsConfig struct {
+	Format string `mapstructure:"FORMAT"`
+}
+
+// AdminConfig guards the /admin routes, which expose data across users, and the routes that change
+// the shared tag rules. Requests must come from one of AllowedIPs (addresses or CIDR ranges) and
+// carry matching basic auth credentials.
+type AdminConfig struct {
+	AllowedIPs []string `mapstructure:"ALLOWED_IPS"`
+	Username   string   `mapstructure:"USERNAME"`
+	Password   string   `mapstructure:"PASSWORD"`
+}
+
+// AnalyticsConfig throttles the analytics endpoints, which scan a user's whole history.
+type AnalyticsConfig struct {
+	MaxConcurrentPerClient int `mapstructure:"MAX_CONCURRENT_PER_CLIENT"`
+	// CacheTTL is how long an analytics result is reused. Nothing clears it sooner, so a new
+	// expense, settlement or loan can take up to this long to show up in analytics. Zero turns the
+	// cache off.
+	CacheTTL time.Duration `mapstructure:"CACHE_TTL"`
+}
+
+// JobsConfig tunes the background job runner.
+type JobsConfig struct {
+	MaxAttempts  int           `mapstructure:"MAX_ATTEMPTS"`
+	PollInterval time.Duration `mapstructure:"POLL_INTERVAL"`
+	Lease        time.Duration `mapstructure:"LEASE"`
+	BaseBackoff  time.Duration `mapstructure:"BASE_BACKOFF"`
+}
+
+// ShareConfig signs the read-only links to event ledgers and the invite links to expenses. Both are
+// disabled while Secret is empty. Invites last DefaultTTL.
+type ShareConfig struct {
+	Secret     string        `mapstructure:"SECRET"`
+	DefaultTTL time.Duration `mapstructure:"DEFAULT_TTL"`
+	MaxTTL     time.Duration `mapstructure:"MAX_TTL"`
+}
+
+// NotificationsConfig sets up email. Without SMTPAddr, notifications are logged instead of sent.
+// Digests are only sent once LinkSecret is set, since every digest carries a signed unsubscribe link.
+type NotificationsConfig struct {
+	SMTPAddr     string `mapstructure:"SMTP_ADDR"`
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+	From         string `mapstructure:"FROM"`
+	// BaseURL is where users reach this server, for links in emails and invite QR codes.
+	BaseURL             string        `mapstructure:"BASE_URL"`
+	LinkSecret          string        `mapstructure:"LINK_SECRET"`
+	DigestCheckInterval time.Duration `mapstructure:"DIGEST_CHECK_INTERVAL"`
+	// ReminderCheckInterval is how often loans that have fallen due are looked for.
+	ReminderCheckInterval time.Duration `mapstructure:"REMINDER_CHECK_INTERVAL"`
+}
+
+// SettleUpConfig turns on the month-end settle-up, which emails each event's suggested transfers to
+// the people in them once a month and proposes them as settlements for events that opted in.
+type SettleUpConfig struct {
+	Enabled       bool          `mapstructure:"ENABLED"`
+	CheckInterval time.Duration `mapstructure:"CHECK_INTERVAL"`
+}
+
+// RatesConfig holds the exchange rates expenses are converted at when an event fixes a base
+// currency. Values gives what one unit of each currency is worth in a common reference currency.
+type RatesConfig struct {
+	Values map[string]float64 `mapstructure:"VALUES"`
+}
+
+// PaymentsConfig lets settlements be paid in-app through Stripe. Paying is disabled while
+// StripeSecretKey is empty. Settlements carry no currency, so every payment is taken in Currency.
+type PaymentsConfig struct {
+	StripeSecretKey     string `mapstructure:"STRIPE_SECRET_KEY"`
+	StripeWebhookSecret string `mapstructure:"STRIPE_WEBHOOK_SECRET"`
+	StripeAPIBase       string `mapstructure:"STRIPE_API_BASE"`
+	Currency            string `mapstructure:"CURRENCY"`
+	// CallbackSecrets maps upi, paypal and venmo to the secret their payment callbacks are signed
+	// with. Callbacks from a provider left out are refused.
+	CallbackSecrets map[string]string `mapstructure:"CALLBACK_SECRETS"`
+}
+
+// EventStoreConfig turns on the expense event store: every creation, status change and deletion
+// of an expense is also recorded as an event, from which cmd/replay can rebuild the expense tables.
+type EventStoreConfig struct {
+	Enabled bool `mapstructure:"ENABLED"`
+}
+
+// StorageConfig picks where data is kept: "mysql", the default, or "memory", which keeps everything
+// in process and loses it on restart. SQL_DB is not used with the memory backend.
+type StorageConfig struct {
+	Backend string `mapstructure:"BACKEND"`
+}
+
+// ChaosConfig injects faults to test how clients and the retry layers cope, and is refused unless
+// APP_ENV is unset or dev. Each of Routes delays and fails requests to a route, and DBDeadlockRate
+// and DBTimeoutRate are the shares of database statements failed with a deadlock or a timeout.
+type ChaosConfig struct {
+	Enabled        bool               `mapstructure:"ENABLED"`
+	Routes         []ChaosRouteConfig `mapstructure:"ROUTES"`
+	DBDeadlockRate float64            `mapstructure:"DB_DEADLOCK_RATE"`
+	DBTimeoutRate  float64            `mapstructure:"DB_TIMEOUT_RATE"`
+}
+
+// ChaosRouteConfig is one route's faults. Route is a route template, optionally after a method,
+// like "POST /expenses" or "/expenses/{id}", or "*" for every route not listed before it.
+type ChaosRouteConfig struct {
+	Route     string        `mapstructure:"ROUTE"`
+	Latency   time.Duration `mapstructure:"LATENCY"`
+	Jitter    time.Duration `mapstructure:"JITTER"` // Up to this much more latency, at random
+	ErrorRate float64       `mapstructure:"ERROR_RATE"`
+	Status    int           `mapstructure:"STATUS"` // Of the failed requests, 503 by default
+}
+
+type HealthConfig struct {
+	Verbose bool `mapstructure:"VERBOSE"`
 }
 
 type Config struct {
-	ServiceName string           `mapstructure:"SERVICE_NAME"`
-	HttpServer  HttpServerConfig `mapstructure:"HTTP_SERVER"`
-	SQLDb       SQLDbConfig      `mapstructure:"SQL_DB"`
+	ServiceName   string              `mapstructure:"SERVICE_NAME"`
+	HttpServer    HttpServerConfig    `mapstructure:"HTTP_SERVER"`
+	Storage       StorageConfig       `mapstructure:"STORAGE"`
+	SQLDb         SQLDbConfig         `mapstructure:"SQL_DB"`
+	Frontend      FrontendConfig      `mapstructure:"FRONTEND"`
+	Limits        LimitsConfig        `mapstructure:"LIMITS"`
+	Quotas        QuotasConfig        `mapstructure:"QUOTAS"`
+	PublicIDs     PublicIDsConfig     `mapstructure:"PUBLIC_IDS"`
+	Validation    ValidationConfig    `mapstructure:"VALIDATION"`
+	Admin         AdminConfig         `mapstructure:"ADMIN"`
+	Analytics     AnalyticsConfig     `mapstructure:"ANALYTICS"`
+	Jobs          JobsConfig          `mapstructure:"JOBS"`
+	Health        HealthConfig        `mapstructure:"HEALTH"`
+	Logging       LoggingConfig       `mapstructure:"LOGGING"`
+	Share         ShareConfig         `mapstructure:"SHARE"`
+	Notifications NotificationsConfig `mapstructure:"NOTIFICATIONS"`
+	SettleUp      SettleUpConfig      `mapstructure:"SETTLE_UP"`
+	Rates         RatesConfig         `mapstructure:"RATES"`
+	Payments      PaymentsConfig      `mapstructure:"PAYMENTS"`
+	EventStore    EventStoreConfig    `mapstructure:"EVENT_STORE"`
+	Chaos         ChaosConfig         `mapstructure:"CHAOS"`
 }
 
+// minSigningKeyLength is the shortest accepted key for signing links.
+const minSigningKeyLength = 32
+
+// LoadConfig reads ./config/default.yaml, then layers the profile named by APP_ENV (for example
+// ./config/prod.yaml) on top, so a profile only has to list the settings it changes.
 func LoadConfig() (*Config, error) {
+	return loadConfig("./config", os.Getenv("APP_ENV"))
+}
+
+func loadConfig(dir, profile string) (*Config, error) {
 	v := viper.New()
-	v.AddConfigPath("./config")
+	v.AddConfigPath(dir)
 	v.SetConfigName("default")
 	v.SetConfigType("yaml")
 
+	setDefaults(v)
 	v.AutomaticEnv()
 
 	if err := v.ReadInConfig(); err != nil {
@@ -39,10 +246,210 @@ func LoadConfig() (*Config, error) {
 		}
 	}
 
+	// A named profile must exist: silently running prod on the defaults is worse than not starting
+	if profile != "" && profile != "default" {
+		v.SetConfigName(profile)
+		if err := v.MergeInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read config profile %q: %w", profile, err)
+		}
+	}
+
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if err := cfg.resolveSecrets(); err != nil {
+		return nil, fmt.Errorf("failed to resolve secrets: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	// Injected failures in production would be indistinguishable from real ones
+	if cfg.Chaos.Enabled && profile != "" && profile != "default" && profile != "dev" {
+		return nil, fmt.Errorf("invalid config: CHAOS.ENABLED is only allowed in development, not with APP_ENV=%s", profile)
+	}
+
+	return &cfg, nil
+}
+
+// Defaults returns the configuration a server started without a config file would run with.
+func Defaults() (*Config, error) {
+	v := viper.New()
+	setDefaults(v)
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
 	return &cfg, nil
 }
+
+// setDefaults fills in the port and durations so a trimmed-down config file still yields a working server.
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("SERVICE_NAME", "split-expense")
+	v.SetDefault("HTTP_SERVER.PORT", "8080")
+	v.SetDefault("HTTP_SERVER.READ_TIMEOUT", 5*time.Second)
+	v.SetDefault("HTTP_SERVER.WRITE_TIMEOUT", 5*time.Second)
+	v.SetDefault("HTTP_SERVER.IDLE_TIMEOUT", 10*time.Second)
+	v.SetDefault("HTTP_SERVER.SHUTDOWN_TIMEOUT", 5*time.Second)
+	v.SetDefault("HTTP_SERVER.LEGACY_RESPONSES", false)
+	v.SetDefault("STORAGE.BACKEND", "mysql")
+	v.SetDefault("SQL_DB.QUERY_TIMEOUT", 4*time.Second)
+	v.SetDefault("SQL_DB.CONNECT_ATTEMPTS", 5)
+	v.SetDefault("SQL_DB.CONNECT_BACKOFF", time.Second)
+	v.SetDefault("SQL_DB.QUERY_METRICS", true)
+	v.SetDefault("LOGGING.FORMAT", "text")
+	v.SetDefault("PUBLIC_IDS.FORMAT", "uuid")
+	v.SetDefault("VALIDATION.MODE", "strict")
+	v.SetDefault("VALIDATION.MAX_ADJUSTMENT", 0.05)
+	v.SetDefault("LIMITS.UNDO_WINDOW", 30*time.Second)
+	v.SetDefault("JOBS.MAX_ATTEMPTS", 5)
+	v.SetDefault("JOBS.POLL_INTERVAL", time.Second)
+	v.SetDefault("JOBS.LEASE", 5*time.Minute)
+	v.SetDefault("JOBS.BASE_BACKOFF", 10*time.Second)
+	v.SetDefault("SHARE.DEFAULT_TTL", 7*24*time.Hour)
+	v.SetDefault("SHARE.MAX_TTL", 30*24*time.Hour)
+	v.SetDefault("NOTIFICATIONS.FROM", "split-expense@localhost")
+	v.SetDefault("NOTIFICATIONS.BASE_URL", "http://localhost:8080")
+	v.SetDefault("NOTIFICATIONS.DIGEST_CHECK_INTERVAL", time.Hour)
+	v.SetDefault("NOTIFICATIONS.REMINDER_CHECK_INTERVAL", time.Hour)
+	v.SetDefault("SETTLE_UP.CHECK_INTERVAL", time.Hour)
+	v.SetDefault("PAYMENTS.STRIPE_API_BASE", "https://api.stripe.com")
+	v.SetDefault("PAYMENTS.CURRENCY", "INR")
+}
+
+// Validate reports every setting that is out of range, not just the first.
+func (c *Config) Validate() error {
+	var errs []error
+	positive := func(name string, d time.Duration) {
+		if d <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %s", name, d))
+		}
+	}
+	nonNegative := func(name string, d time.Duration) {
+		if d < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative, got %s", name, d))
+		}
+	}
+
+	if port, err := strconv.Atoi(c.HttpServer.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("HTTP_SERVER.PORT must be a port number, got %q", c.HttpServer.Port))
+	}
+	positive("HTTP_SERVER.READ_TIMEOUT", c.HttpServer.ReadTimeout)
+	positive("HTTP_SERVER.WRITE_TIMEOUT", c.HttpServer.WriteTimeout)
+	positive("HTTP_SERVER.IDLE_TIMEOUT", c.HttpServer.IdleTimeout)
+	positive("HTTP_SERVER.SHUTDOWN_TIMEOUT", c.HttpServer.ShutdownTimeout)
+	switch c.Storage.Backend {
+	case "mysql", "memory":
+	default:
+		errs = append(errs, fmt.Errorf("STORAGE.BACKEND must be mysql or memory, got %q", c.Storage.Backend))
+	}
+	nonNegative("SQL_DB.SLOW_QUERY_THRESHOLD", c.SQLDb.SlowQueryThreshold)
+	nonNegative("SQL_DB.QUERY_TIMEOUT", c.SQLDb.QueryTimeout)
+	nonNegative("SQL_DB.CONNECT_BACKOFF", c.SQLDb.ConnectBackoff)
+	if c.SQLDb.ConnectAttempts < 1 {
+		errs = append(errs, fmt.Errorf("SQL_DB.CONNECT_ATTEMPTS must be at least 1, got %d", c.SQLDb.ConnectAttempts))
+	}
+	nonNegative("LIMITS.UNDO_WINDOW", c.Limits.UndoWindow)
+	nonNegative("ANALYTICS.CACHE_TTL", c.Analytics.CacheTTL)
+	positive("JOBS.POLL_INTERVAL", c.Jobs.PollInterval)
+	positive("JOBS.LEASE", c.Jobs.Lease)
+	nonNegative("JOBS.BASE_BACKOFF", c.Jobs.BaseBackoff)
+	switch c.Logging.Format {
+	case "text", "common", "combined", "json":
+	default:
+		errs = append(errs, fmt.Errorf("LOGGING.FORMAT must be text, common, combined or json, got %q", c.Logging.Format))
+	}
+	if c.Logging.SampleRate < 0 || c.Logging.SampleRate > 1 {
+		errs = append(errs, fmt.Errorf("LOGGING.SAMPLE_RATE must be between 0 and 1, got %g", c.Logging.SampleRate))
+	}
+	if c.Jobs.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("JOBS.MAX_ATTEMPTS must be at least 1, got %d", c.Jobs.MaxAttempts))
+	}
+	if c.Limits.MaxParticipants < 0 || c.Limits.MaxTotalAmount < 0 || c.Limits.MaxDescriptionLength < 0 {
+		errs = append(errs, errors.New("LIMITS must not be negative; use zero to disable a limit"))
+	}
+	if c.Quotas.ExpensesPerDay < 0 || c.Quotas.Events < 0 || c.Quotas.UploadMB < 0 {
+		errs = append(errs, errors.New("QUOTAS must not be negative; use zero to disable a quota"))
+	}
+	switch c.PublicIDs.Format {
+	case "uuid", "ulid":
+	default:
+		errs = append(errs, fmt.Errorf("PUBLIC_IDS.FORMAT must be uuid or ulid, got %q", c.PublicIDs.Format))
+	}
+	switch c.Validation.Mode {
+	case "strict", "lenient":
+	default:
+		errs = append(errs, fmt.Errorf("VALIDATION.MODE must be strict or lenient, got %q", c.Validation.Mode))
+	}
+	if c.Validation.MaxAdjustment < 0 {
+		errs = append(errs, fmt.Errorf("VALIDATION.MAX_ADJUSTMENT must not be negative, got %g", c.Validation.MaxAdjustment))
+	}
+	if c.Analytics.MaxConcurrentPerClient < 0 {
+		errs = append(errs, fmt.Errorf("ANALYTICS.MAX_CONCURRENT_PER_CLIENT must not be negative, got %d", c.Analytics.MaxConcurrentPerClient))
+	}
+	positive("SHARE.DEFAULT_TTL", c.Share.DefaultTTL)
+	if c.Share.MaxTTL < c.Share.DefaultTTL {
+		errs = append(errs, fmt.Errorf("SHARE.MAX_TTL must be at least SHARE.DEFAULT_TTL, got %s", c.Share.MaxTTL))
+	}
+	// A short key makes the link signatures guessable
+	signingKeys := map[string]string{"SHARE.SECRET": c.Share.Secret, "NOTIFICATIONS.LINK_SECRET": c.Notifications.LinkSecret}
+	for provider, key := range c.Payments.CallbackSecrets {
+		signingKeys["PAYMENTS.CALLBACK_SECRETS."+strings.ToUpper(provider)] = key
+	}
+	for name, key := range signingKeys {
+		if key != "" && len(key) < minSigningKeyLength {
+			errs = append(errs, fmt.Errorf("%s must be at least %d characters", name, minSigningKeyLength))
+		}
+	}
+	positive("NOTIFICATIONS.DIGEST_CHECK_INTERVAL", c.Notifications.DigestCheckInterval)
+	positive("NOTIFICATIONS.REMINDER_CHECK_INTERVAL", c.Notifications.ReminderCheckInterval)
+	positive("SETTLE_UP.CHECK_INTERVAL", c.SettleUp.CheckInterval)
+	if u, err := url.Parse(c.Notifications.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("NOTIFICATIONS.BASE_URL must be an http or https URL, got %q", c.Notifications.BaseURL))
+	}
+	// Without the webhook secret no payment would ever be confirmed
+	if c.Payments.StripeSecretKey != "" && c.Payments.StripeWebhookSecret == "" {
+		errs = append(errs, errors.New("PAYMENTS.STRIPE_WEBHOOK_SECRET is required when PAYMENTS.STRIPE_SECRET_KEY is set"))
+	}
+	if u, err := url.Parse(c.Payments.StripeAPIBase); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("PAYMENTS.STRIPE_API_BASE must be an http or https URL, got %q", c.Payments.StripeAPIBase))
+	}
+	for code, value := range c.Rates.Values {
+		if len(code) != 3 || value <= 0 {
+			errs = append(errs, fmt.Errorf("RATES.VALUES must map three-letter currency codes to positive values, got %s: %v", code, value))
+		}
+	}
+	if len(c.Payments.Currency) != 3 {
+		errs = append(errs, fmt.Errorf("PAYMENTS.CURRENCY must be a three-letter currency code, got %q", c.Payments.Currency))
+	}
+	share := func(name string, rate float64) {
+		if rate < 0 || rate > 1 {
+			errs = append(errs, fmt.Errorf("%s must be between 0 and 1, got %g", name, rate))
+		}
+	}
+	share("CHAOS.DB_DEADLOCK_RATE", c.Chaos.DBDeadlockRate)
+	share("CHAOS.DB_TIMEOUT_RATE", c.Chaos.DBTimeoutRate)
+	if c.Chaos.DBDeadlockRate+c.Chaos.DBTimeoutRate > 1 {
+		errs = append(errs, errors.New("CHAOS.DB_DEADLOCK_RATE and CHAOS.DB_TIMEOUT_RATE must add up to at most 1"))
+	}
+	for i, route := range c.Chaos.Routes {
+		name := fmt.Sprintf("CHAOS.ROUTES[%d]", i)
+		if route.Route == "" {
+			errs = append(errs, fmt.Errorf("%s.ROUTE is required", name))
+		}
+		nonNegative(name+".LATENCY", route.Latency)
+		nonNegative(name+".JITTER", route.Jitter)
+		share(name+".ERROR_RATE", route.ErrorRate)
+		if route.Status != 0 && (route.Status < 400 || route.Status > 599) {
+			errs = append(errs, fmt.Errorf("%s.STATUS must be an error status, got %d", name, route.Status))
+		}
+	}
+	// A password without a username can never match, which is easy to mistake for working auth
+	if c.Admin.Password != "" && c.Admin.Username == "" {
+		errs = append(errs, errors.New("ADMIN.USERNAME is required when ADMIN.PASSWORD is set"))
+	}
+
+	return errors.Join(errs...)
+}
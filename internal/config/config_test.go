@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultsAreValid(t *testing.T) {
+	cfg, err := Defaults()
+	require.NoError(t, err)
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, 5*time.Second, cfg.HttpServer.ShutdownTimeout)
+}
+
+func TestValidate(t *testing.T) {
+	v := viper.New()
+	setDefaults(v)
+	var cfg Config
+	require.NoError(t, v.Unmarshal(&cfg))
+
+	cfg.HttpServer.ShutdownTimeout = 0
+	cfg.HttpServer.ReadTimeout = -time.Second
+	cfg.Analytics.CacheTTL = 0 // Disables the cache, which is fine
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP_SERVER.SHUTDOWN_TIMEOUT must be positive, got 0s")
+	assert.Contains(t, err.Error(), "HTTP_SERVER.READ_TIMEOUT must be positive, got -1s")
+	assert.NotContains(t, err.Error(), "CACHE_TTL")
+}
+
+func TestResolveSecrets(t *testing.T) {
+	dir := t.TempDir()
+	dsnFile := filepath.Join(dir, "dsn")
+	require.NoError(t, os.WriteFile(dsnFile, []byte("user:secret@tcp(db:3306)/split_expense\n"), 0o600))
+	t.Setenv("TEST_ADMIN_PASSWORD", "hunter2")
+	RegisterSecretProvider("static", SecretProviderFunc(func(key string) (string, error) {
+		if key != "db" {
+			return "", fmt.Errorf("unknown key %s", key)
+		}
+		return "vault-dsn", nil
+	}))
+
+	// Test case 1: File and environment references are fetched, plain values kept
+	cfg := Config{
+		SQLDb: SQLDbConfig{ConnectionString: "secret://file" + dsnFile},
+		Admin: AdminConfig{Username: "admin", Password: "secret://env/TEST_ADMIN_PASSWORD"},
+	}
+	require.NoError(t, cfg.resolveSecrets())
+	assert.Equal(t, "user:secret@tcp(db:3306)/split_expense", cfg.SQLDb.ConnectionString)
+	assert.Equal(t, "hunter2", cfg.Admin.Password)
+	assert.Equal(t, "admin", cfg.Admin.Username)
+
+	// Test case 2: A registered provider
+	cfg = Config{SQLDb: SQLDbConfig{ConnectionString: "secret://static/db"}}
+	require.NoError(t, cfg.resolveSecrets())
+	assert.Equal(t, "vault-dsn", cfg.SQLDb.ConnectionString)
+
+	// Test case 3: Unknown providers and missing secrets fail start-up
+	cfg = Config{SQLDb: SQLDbConfig{ConnectionString: "secret://vault/db"}}
+	assert.ErrorContains(t, cfg.resolveSecrets(), `no secret provider registered for "vault"`)
+	cfg = Config{Admin: AdminConfig{Password: "secret://env/TEST_UNSET_PASSWORD"}}
+	assert.ErrorContains(t, cfg.resolveSecrets(), "ADMIN.PASSWORD")
+}
+
+func TestValidate_Combinations(t *testing.T) {
+	v := viper.New()
+	setDefaults(v)
+	var cfg Config
+	require.NoError(t, v.Unmarshal(&cfg))
+	assert.Equal(t, "8080", cfg.HttpServer.Port)
+
+	cfg.HttpServer.Port = "http"
+	cfg.Logging.SampleRate = 2
+	cfg.Admin.Password = "secret"
+	cfg.Share.Secret = "too-short"
+	cfg.Payments.StripeSecretKey = "sk_test_123"
+	cfg.Payments.CallbackSecrets = map[string]string{"upi": "short"}
+	cfg.Storage.Backend = "postgres"
+	cfg.Validation.Mode = "loose"
+	cfg.Chaos = ChaosConfig{DBDeadlockRate: 0.6, DBTimeoutRate: 0.6, Routes: []ChaosRouteConfig{{Route: "/expenses", Status: 302}}}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `HTTP_SERVER.PORT must be a port number, got "http"`)
+	assert.Contains(t, err.Error(), "LOGGING.SAMPLE_RATE must be between 0 and 1, got 2")
+	assert.Contains(t, err.Error(), "ADMIN.USERNAME is required when ADMIN.PASSWORD is set")
+	assert.Contains(t, err.Error(), "SHARE.SECRET must be at least 32 characters")
+	assert.Contains(t, err.Error(), "PAYMENTS.STRIPE_WEBHOOK_SECRET is required when PAYMENTS.STRIPE_SECRET_KEY is set")
+	assert.Contains(t, err.Error(), "PAYMENTS.CALLBACK_SECRETS.UPI must be at least 32 characters")
+	assert.Contains(t, err.Error(), `STORAGE.BACKEND must be mysql or memory, got "postgres"`)
+	assert.Contains(t, err.Error(), `VALIDATION.MODE must be strict or lenient, got "loose"`)
+	assert.Contains(t, err.Error(), "CHAOS.DB_DEADLOCK_RATE and CHAOS.DB_TIMEOUT_RATE must add up to at most 1")
+	assert.Contains(t, err.Error(), "CHAOS.ROUTES[0].STATUS must be an error status, got 302")
+}
+
+func TestSummary(t *testing.T) {
+	cfg := Config{
+		HttpServer: HttpServerConfig{Port: "8080", ReadTimeout: 5 * time.Second},
+		SQLDb:      SQLDbConfig{ConnectionString: "user:p@ss:word@tcp(127.0.0.1:3306)/split_expense?parseTime=true"},
+		Admin:      AdminConfig{Username: "admin", Password: "hunter2"},
+		Share:      ShareConfig{Secret: "correct-horse-battery-staple-0123456789"},
+		Payments:   PaymentsConfig{StripeSecretKey: "sk_live_abc", Currency: "INR", CallbackSecrets: map[string]string{"upi": "upi-callback-secret"}},
+	}
+
+	summary := cfg.Summary()
+	assert.Contains(t, summary, "HTTP_SERVER.PORT=8080\n")
+	assert.Contains(t, summary, "HTTP_SERVER.READ_TIMEOUT=5s\n")
+	assert.Contains(t, summary, "SQL_DB.CONNECTION_STRING=user:[REDACTED]@tcp(127.0.0.1:3306)/split_expense?parseTime=true\n")
+	assert.Contains(t, summary, "ADMIN.USERNAME=admin\n")
+	assert.Contains(t, summary, "ADMIN.PASSWORD=[REDACTED]\n")
+	assert.Contains(t, summary, "SHARE.SECRET=[REDACTED]\n")
+	assert.NotContains(t, summary, "hunter2")
+	assert.NotContains(t, summary, "horse")
+	assert.NotContains(t, summary, "word@")
+	assert.Contains(t, summary, "PAYMENTS.STRIPE_SECRET_KEY=[REDACTED]\n")
+	assert.Contains(t, summary, "PAYMENTS.STRIPE_WEBHOOK_SECRET=\n")
+	assert.Contains(t, summary, "PAYMENTS.CALLBACK_SECRETS=map[upi:[REDACTED]]\n")
+	assert.NotContains(t, summary, "upi-callback-secret")
+}
+
+func TestLoadConfig_Profiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "default.yaml"), []byte("HTTP_SERVER:\n  PORT: \"8080\"\nLOGGING:\n  FORMAT: \"text\"\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prod.yaml"), []byte("LOGGING:\n  FORMAT: \"json\"\n"), 0o600))
+
+	// Test case 1: No profile uses the default file
+	cfg, err := loadConfig(dir, "")
+	require.NoError(t, err)
+	assert.Equal(t, "text", cfg.Logging.Format)
+
+	// Test case 2: The profile overrides only what it sets
+	cfg, err = loadConfig(dir, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "json", cfg.Logging.Format)
+	assert.Equal(t, "8080", cfg.HttpServer.Port)
+	assert.Equal(t, 5*time.Second, cfg.HttpServer.ReadTimeout)
+
+	// Test case 3: A missing profile is an error
+	_, err = loadConfig(dir, "staging")
+	assert.ErrorContains(t, err, `failed to read config profile "staging"`)
+
+	// Test case 4: Chaos is read in development and refused in production
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dev.yaml"), []byte("CHAOS:\n  ENABLED: true\n  ROUTES:\n    - ROUTE: \"POST /expenses\"\n      LATENCY: 200ms\n      ERROR_RATE: 0.1\n"), 0o600))
+	cfg, err = loadConfig(dir, "dev")
+	require.NoError(t, err)
+	assert.Equal(t, []ChaosRouteConfig{{Route: "POST /expenses", Latency: 200 * time.Millisecond, ErrorRate: 0.1}}, cfg.Chaos.Routes)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prod.yaml"), []byte("CHAOS:\n  ENABLED: true\n"), 0o600))
+	_, err = loadConfig(dir, "prod")
+	assert.ErrorContains(t, err, "CHAOS.ENABLED is only allowed in development, not with APP_ENV=prod")
+}
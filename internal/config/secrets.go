@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// secretRefPrefix marks a config value as a reference to be fetched from a secret provider, in the
+// form secret://<provider>/<key>. Plain values are used as they are.
+const secretRefPrefix = "secret://"
+
+// SecretProvider fetches a secret by key from a backing store such as Vault or AWS Secrets Manager.
+type SecretProvider interface {
+	Secret(key string) (string, error)
+}
+
+// SecretProviderFunc adapts a function to a SecretProvider.
+type SecretProviderFunc func(key string) (string, error)
+
+func (f SecretProviderFunc) Secret(key string) (string, error) { return f(key) }
+
+var (
+	secretProvidersMu sync.RWMutex
+	secretProviders   = map[string]SecretProvider{
+		// secret://env/DB_DSN reads the DB_DSN environment variable
+		"env": SecretProviderFunc(func(key string) (string, error) {
+			value, ok := os.LookupEnv(key)
+			if !ok {
+				return "", fmt.Errorf("environment variable %s is not set", key)
+			}
+			return value, nil
+		}),
+		// secret://file/run/secrets/db_dsn reads a file, as mounted by Docker, Kubernetes or a Vault agent
+		"file": SecretProviderFunc(func(key string) (string, error) {
+			b, err := os.ReadFile("/" + key)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimRight(string(b), "\r\n"), nil
+		}),
+	}
+)
+
+// RegisterSecretProvider makes a provider available to secret:// references under name. It must be
+// called before LoadConfig; registering a name twice replaces the earlier provider.
+func RegisterSecretProvider(name string, provider SecretProvider) {
+	secretProvidersMu.Lock()
+	defer secretProvidersMu.Unlock()
+	secretProviders[name] = provider
+}
+
+// resolveSecret returns value, or the secret it refers to if it is a secret:// reference.
+func resolveSecret(value string) (string, error) {
+	ref, ok := strings.CutPrefix(value, secretRefPrefix)
+	if !ok {
+		return value, nil
+	}
+	name, key, ok := strings.Cut(ref, "/")
+	if !ok || key == "" {
+		return "", fmt.Errorf("secret reference %q must be %s<provider>/<key>", value, secretRefPrefix)
+	}
+
+	secretProvidersMu.RLock()
+	provider, ok := secretProviders[name]
+	secretProvidersMu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("no secret provider registered for %q", name)
+	}
+
+	secret, err := provider.Secret(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch secret %s%s: %w", secretRefPrefix, ref, err)
+	}
+	return secret, nil
+}
+
+// resolveSecrets replaces every secret:// reference among the settings that may hold credentials.
+func (c *Config) resolveSecrets() error {
+	for name, field := range map[string]*string{
+		"SQL_DB.CONNECTION_STRING":       &c.SQLDb.ConnectionString,
+		"ADMIN.PASSWORD":                 &c.Admin.Password,
+		"SHARE.SECRET":                   &c.Share.Secret,
+		"NOTIFICATIONS.SMTP_PASSWORD":    &c.Notifications.SMTPPassword,
+		"NOTIFICATIONS.LINK_SECRET":      &c.Notifications.LinkSecret,
+		"PAYMENTS.STRIPE_SECRET_KEY":     &c.Payments.StripeSecretKey,
+		"PAYMENTS.STRIPE_WEBHOOK_SECRET": &c.Payments.StripeWebhookSecret,
+	} {
+		secret, err := resolveSecret(*field)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		*field = secret
+	}
+	for provider, value := range c.Payments.CallbackSecrets {
+		secret, err := resolveSecret(value)
+		if err != nil {
+			return fmt.Errorf("PAYMENTS.CALLBACK_SECRETS.%s: %w", strings.ToUpper(provider), err)
+		}
+		c.Payments.CallbackSecrets[provider] = secret
+	}
+	return nil
+}
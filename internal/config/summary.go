@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// redacted replaces secrets in the effective config summary.
+const redacted = "[REDACTED]"
+
+// Summary lists every effective setting as KEY=value, one per line, in declaration order. Passwords,
+// signing keys and API keys are redacted, and so is the password inside the database connection string.
+func (c *Config) Summary() string {
+	var b strings.Builder
+	writeSettings(&b, "", reflect.ValueOf(*c))
+	return b.String()
+}
+
+func writeSettings(b *strings.Builder, prefix string, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := prefix + t.Field(i).Tag.Get("mapstructure")
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			writeSettings(b, key+".", field)
+			continue
+		}
+
+		value := fmt.Sprint(field.Interface())
+		switch key {
+		case "ADMIN.PASSWORD", "SHARE.SECRET", "NOTIFICATIONS.SMTP_PASSWORD", "NOTIFICATIONS.LINK_SECRET", "PAYMENTS.STRIPE_SECRET_KEY", "PAYMENTS.STRIPE_WEBHOOK_SECRET":
+			if value != "" {
+				value = redacted
+			}
+		case "SQL_DB.CONNECTION_STRING":
+			value = redactDSN(value)
+		case "PAYMENTS.CALLBACK_SECRETS":
+			// Which providers have a secret is worth seeing, but not the secrets
+			secrets := make(map[string]string, field.Len())
+			for _, provider := range field.MapKeys() {
+				secrets[provider.String()] = redacted
+			}
+			value = fmt.Sprint(secrets)
+		}
+		fmt.Fprintf(b, "%s=%s\n", key, value)
+	}
+}
+
+// redactDSN hides the password in a user:password@tcp(host)/db style DSN.
+func redactDSN(dsn string) string {
+	at := strings.LastIndex(dsn, "@")
+	if at < 0 {
+		return dsn
+	}
+	user, _, hasPassword := strings.Cut(dsn[:at], ":")
+	if !hasPassword {
+		return dsn
+	}
+	return user + ":" + redacted + dsn[at:]
+}
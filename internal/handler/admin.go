@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+)
+
+type AdminHandler struct {
+	auditService service.AuditService
+	queryService service.QueryService
+}
+
+func NewAdminHandler(auditService service.AuditService, queryService service.QueryService) *AdminHandler {
+	return &AdminHandler{auditService: auditService, queryService: queryService}
+}
+
+// AuditLogsHandler lists audit log entries, optionally filtered by the user query parameter and an
+// inclusive from/to range of dates.
+func (h *AdminHandler) AuditLogsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := repository.AuditLogFilter{Actor: query.Get("user")}
+
+	if from := query.Get("from"); from != "" {
+		t, err := time.Parse(service.DateLayout, from)
+		if err != nil {
+			response.Error(w, r, "from must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+		filter.From = t
+	}
+	if to := query.Get("to"); to != "" {
+		t, err := time.Parse(service.DateLayout, to)
+		if err != nil {
+			response.Error(w, r, "to must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+		// Include the whole of the last day
+		filter.To = t.AddDate(0, 0, 1)
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		response.Error(w, r, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.auditService.ListAuditLogs(filter)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, entries)
+}
+
+// SlowestQueriesHandler lists the statements that take longest on average, as many as the limit
+// query parameter asks for (10 by default), with the plan EXPLAIN gives for each SELECT.
+func (h *AdminHandler) SlowestQueriesHandler(w http.ResponseWriter, r *http.Request) {
+	limit := 10
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > service.MaxSlowestQueries {
+			response.Error(w, r, fmt.Sprintf("limit must be between 1 and %d", service.MaxSlowestQueries), http.StatusBadRequest)
+			return
+		}
+	}
+
+	stats, err := h.queryService.SlowestQueries(limit)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, stats)
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminHandler_AuditLogsHandler(t *testing.T) {
+	mockService := new(servicemock.AuditService)
+	adminHandler := NewAdminHandler(mockService, nil)
+
+	// Test case 1: The to date is inclusive
+	{
+		filter := repository.AuditLogFilter{
+			Actor: "alice@example.com",
+			From:  time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+			To:    time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+		}
+		expected := []repository.AuditLog{{ID: 1, Actor: "alice@example.com", Method: "POST", Route: "/expenses", Status: http.StatusCreated}}
+		mockService.On("ListAuditLogs", filter).Return(expected, nil).Once()
+
+		req := httptest.NewRequest("GET", "/admin/audit?user=alice@example.com&from=2025-03-01&to=2025-03-31", nil)
+		rr := httptest.NewRecorder()
+		adminHandler.AuditLogsHandler(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var actual []repository.AuditLog
+		decodeData(t, rr, &actual)
+		assert.Equal(t, expected[0].Route, actual[0].Route)
+		mockService.AssertExpectations(t)
+	}
+
+	// Test case 2: Malformed and inverted ranges are rejected
+	{
+		for _, query := range []string{"from=March", "from=2025-04-01&to=2025-03-01"} {
+			rr := httptest.NewRecorder()
+			adminHandler.AuditLogsHandler(rr, httptest.NewRequest("GET", "/admin/audit?"+query, nil))
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+		mockService.AssertNumberOfCalls(t, "ListAuditLogs", 1)
+	}
+}
+
+func TestAdminHandler_SlowestQueriesHandler(t *testing.T) {
+	mockService := new(servicemock.QueryService)
+	adminHandler := NewAdminHandler(nil, mockService)
+
+	// Test case 1: Ten queries are listed by default
+	{
+		expected := []repository.QueryStats{{Name: "SELECT * FROM users WHERE id = ?", Calls: 3, MeanMs: 1.5}}
+		mockService.On("SlowestQueries", 10).Return(expected, nil).Once()
+
+		rr := httptest.NewRecorder()
+		adminHandler.SlowestQueriesHandler(rr, httptest.NewRequest("GET", "/admin/queries", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var actual []repository.QueryStats
+		decodeData(t, rr, &actual)
+		assert.Equal(t, expected, actual)
+	}
+
+	// Test case 2: Limits out of range are rejected
+	{
+		for _, query := range []string{"limit=0", "limit=51", "limit=ten"} {
+			rr := httptest.NewRecorder()
+			adminHandler.SlowestQueriesHandler(rr, httptest.NewRequest("GET", "/admin/queries?"+query, nil))
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+		mockService.AssertExpectations(t)
+	}
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/aadithya-md/split-expense/internal/util"
+	"github.com/gorilla/mux"
+)
+
+type AnalyticsHandler struct {
+	analyticsService service.AnalyticsService
+}
+
+func NewAnalyticsHandler(analyticsService service.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsService: analyticsService}
+}
+
+// NextPayerHandler suggests who should pay next among the comma-separated users in the emails query parameter.
+func (h *AnalyticsHandler) NextPayerHandler(w http.ResponseWriter, r *http.Request) {
+	seen := util.NewSet[string]()
+	var emails []string
+	for _, email := range strings.Split(r.URL.Query().Get("emails"), ",") {
+		email = util.NormalizeEmail(email)
+		if email == "" || seen.IsMember(email) {
+			continue
+		}
+		seen.Add(email)
+		emails = append(emails, email)
+	}
+
+	if len(emails) < 2 {
+		response.Error(w, r, "emails must list at least two users", http.StatusBadRequest)
+		return
+	}
+
+	suggestion, err := h.analyticsService.SuggestNextPayer(emails)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, suggestion)
+}
+
+// YearInReviewHandler sums up the user's year, given by the year query parameter and defaulting to the current one.
+func (h *AnalyticsHandler) YearInReviewHandler(w http.ResponseWriter, r *http.Request) {
+	userEmail, err := emailParam(r)
+	if err != nil {
+		response.Error(w, r, "Invalid user email", http.StatusBadRequest)
+		return
+	}
+	if userEmail == "" {
+		response.Error(w, r, "User email is required", http.StatusBadRequest)
+		return
+	}
+
+	year, ok := yearParam(w, r)
+	if !ok {
+		return
+	}
+
+	review, err := h.analyticsService.YearInReview(userEmail, year)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, review)
+}
+
+// CounterpartiesHandler lists who the user splits with, most shared expenses first.
+func (h *AnalyticsHandler) CounterpartiesHandler(w http.ResponseWriter, r *http.Request) {
+	userEmail, err := emailParam(r)
+	if err != nil {
+		response.Error(w, r, "Invalid user email", http.StatusBadRequest)
+		return
+	}
+	if userEmail == "" {
+		response.Error(w, r, "User email is required", http.StatusBadRequest)
+		return
+	}
+
+	counterparties, err := h.analyticsService.Counterparties(userEmail)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, counterparties)
+}
+
+// HeatmapHandler returns the user's daily share of expenses over the year, given by the year query
+// parameter and defaulting to the current one.
+func (h *AnalyticsHandler) HeatmapHandler(w http.ResponseWriter, r *http.Request) {
+	userEmail, err := emailParam(r)
+	if err != nil {
+		response.Error(w, r, "Invalid user email", http.StatusBadRequest)
+		return
+	}
+	if userEmail == "" {
+		response.Error(w, r, "User email is required", http.StatusBadRequest)
+		return
+	}
+
+	year, ok := yearParam(w, r)
+	if !ok {
+		return
+	}
+
+	heatmap, err := h.analyticsService.Heatmap(userEmail, year)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, heatmap)
+}
+
+// ForecastHandler projects the user's shares for the coming months, as many as the months query
+// parameter asks for and 3 by default.
+func (h *AnalyticsHandler) ForecastHandler(w http.ResponseWriter, r *http.Request) {
+	userEmail, err := emailParam(r)
+	if err != nil {
+		response.Error(w, r, "Invalid user email", http.StatusBadRequest)
+		return
+	}
+	if userEmail == "" {
+		response.Error(w, r, "User email is required", http.StatusBadRequest)
+		return
+	}
+
+	months := 3
+	if v := r.URL.Query().Get("months"); v != "" {
+		if months, err = strconv.Atoi(v); err != nil || months < 1 || months > service.MaxForecastMonths {
+			response.Error(w, r, fmt.Sprintf("months must be between 1 and %d", service.MaxForecastMonths), http.StatusBadRequest)
+			return
+		}
+	}
+
+	forecast, err := h.analyticsService.Forecast(userEmail, months)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, forecast)
+}
+
+// FairnessHandler compares what each member of the event in {id} paid with what they owed.
+func (h *AnalyticsHandler) FairnessHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid event ID", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.analyticsService.Fairness(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrEventNotFound) {
+			response.Error(w, r, err.Error(), http.StatusNotFound)
+			return
+		}
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, report)
+}
+
+// yearParam reads the year query parameter, defaulting to the current year. It answers 400 itself
+// and reports false when the parameter is invalid.
+func yearParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("year")
+	if v == "" {
+		return time.Now().UTC().Year(), true
+	}
+	year, err := strconv.Atoi(v)
+	if err != nil || year < 1 || year > 9999 {
+		response.Error(w, r, "year must be a four-digit year", http.StatusBadRequest)
+		return 0, false
+	}
+	return year, true
+}
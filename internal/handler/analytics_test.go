@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyticsHandler_NextPayerHandler(t *testing.T) {
+	mockService := new(servicemock.AnalyticsService)
+	analyticsHandler := NewAnalyticsHandler(mockService)
+
+	// Test case 1: Emails are trimmed and de-duplicated before reaching the service
+	{
+		expected := &service.NextPayerSuggestion{UserEmail: "bob@example.com", UserName: "Bob"}
+		mockService.On("SuggestNextPayer", []string{"alice@example.com", "bob@example.com"}).Return(expected, nil).Once()
+
+		req := httptest.NewRequest("GET", "/analytics/next-payer?emails=alice@example.com,%20bob@example.com,alice@example.com", nil)
+		rr := httptest.NewRecorder()
+		analyticsHandler.NextPayerHandler(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		expectedResponseBytes, _ := json.Marshal(expected)
+		assert.JSONEq(t, string(expectedResponseBytes), dataJSON(t, rr))
+		mockService.AssertExpectations(t)
+	}
+
+	// Test case 2: A single user is not a group
+	{
+		req := httptest.NewRequest("GET", "/analytics/next-payer?emails=alice@example.com", nil)
+		rr := httptest.NewRecorder()
+		analyticsHandler.NextPayerHandler(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertNumberOfCalls(t, "SuggestNextPayer", 1)
+	}
+}
+
+func TestAnalyticsHandler_YearInReviewHandler(t *testing.T) {
+	mockService := new(servicemock.AnalyticsService)
+	analyticsHandler := NewAnalyticsHandler(mockService)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+		router.HandleFunc("/analytics/year-in-review/{email}", analyticsHandler.YearInReviewHandler).Methods("GET")
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	// Test case 1: The requested year reaches the service
+	{
+		expected := &service.YearInReview{UserEmail: "alice@example.com", Year: 2024, ExpenseCount: 3, MonthsRanked: []service.ReviewMonth{}}
+		mockService.On("YearInReview", "alice@example.com", 2024).Return(expected, nil).Once()
+
+		rr := serve("/analytics/year-in-review/alice@example.com?year=2024")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var actual service.YearInReview
+		decodeData(t, rr, &actual)
+		assert.Equal(t, *expected, actual)
+	}
+
+	// Test case 2: Without a year, the current one is used
+	{
+		mockService.On("YearInReview", "alice@example.com", time.Now().UTC().Year()).Return(&service.YearInReview{}, nil).Once()
+
+		rr := serve("/analytics/year-in-review/alice@example.com")
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	// Test case 3: Invalid years are rejected
+	for _, year := range []string{"last", "0", "20240"} {
+		rr := serve("/analytics/year-in-review/alice@example.com?year=" + year)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, year)
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestAnalyticsHandler_CounterpartiesHandler(t *testing.T) {
+	mockService := new(servicemock.AnalyticsService)
+	analyticsHandler := NewAnalyticsHandler(mockService)
+
+	expected := []service.Counterparty{{UserEmail: "bob@example.com", UserName: "Bob", ExpenseCount: 2, TotalShared: 110, Balance: 20}}
+	mockService.On("Counterparties", "alice@example.com").Return(expected, nil).Once()
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/analytics/counterparties/{email}", analyticsHandler.CounterpartiesHandler).Methods("GET")
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/analytics/counterparties/alice@example.com", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var actual []service.Counterparty
+	decodeData(t, rr, &actual)
+	assert.Equal(t, expected, actual)
+	mockService.AssertExpectations(t)
+}
+
+func TestAnalyticsHandler_HeatmapHandler(t *testing.T) {
+	mockService := new(servicemock.AnalyticsService)
+	analyticsHandler := NewAnalyticsHandler(mockService)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+		router.HandleFunc("/analytics/heatmap/{email}", analyticsHandler.HeatmapHandler).Methods("GET")
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	// Test case 1: Successful retrieval
+	{
+		expected := &service.Heatmap{UserEmail: "alice@example.com", Year: 2024, Max: 5, Days: []service.HeatmapDay{{Date: "2024-01-01", Amount: 5, ExpenseCount: 1}}}
+		mockService.On("Heatmap", "alice@example.com", 2024).Return(expected, nil).Once()
+
+		rr := serve("/analytics/heatmap/alice@example.com?year=2024")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var actual service.Heatmap
+		decodeData(t, rr, &actual)
+		assert.Equal(t, *expected, actual)
+	}
+
+	// Test case 2: Invalid year
+	{
+		rr := serve("/analytics/heatmap/alice@example.com?year=abc")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestAnalyticsHandler_FairnessHandler(t *testing.T) {
+	mockService := new(servicemock.AnalyticsService)
+	analyticsHandler := NewAnalyticsHandler(mockService)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+		router.HandleFunc("/analytics/fairness/{id}", analyticsHandler.FairnessHandler).Methods("GET")
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	// Test case 1: Successful retrieval
+	{
+		expected := &service.FairnessReport{EventID: 5, EventName: "Flat", Currencies: []service.FairnessCurrency{{
+			Currency: "INR", TotalPaid: 100, MeanPaid: 50,
+			Members: []service.FairnessMember{{UserEmail: "alice@example.com", Paid: 100, Owed: 50, Net: 50, Deviation: 50, Standing: service.FairnessOverPayer}},
+		}}}
+		mockService.On("Fairness", 5).Return(expected, nil).Once()
+
+		rr := serve("/analytics/fairness/5")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var actual service.FairnessReport
+		decodeData(t, rr, &actual)
+		assert.Equal(t, *expected, actual)
+	}
+
+	// Test case 2: Unknown event
+	{
+		mockService.On("Fairness", 6).Return(nil, repository.ErrEventNotFound).Once()
+		assert.Equal(t, http.StatusNotFound, serve("/analytics/fairness/6").Code)
+	}
+
+	// Test case 3: Invalid ID
+	{
+		assert.Equal(t, http.StatusBadRequest, serve("/analytics/fairness/flat").Code)
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestAnalyticsHandler_ForecastHandler(t *testing.T) {
+	mockService := new(servicemock.AnalyticsService)
+	analyticsHandler := NewAnalyticsHandler(mockService)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+		router.HandleFunc("/analytics/forecast/{email}", analyticsHandler.ForecastHandler).Methods("GET")
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	// Test case 1: Three months by default
+	{
+		expected := &service.Forecast{UserEmail: "alice@example.com", Recurring: []service.RecurringBill{}, Months: []service.ForecastMonth{{Month: "2024-08", Total: 550, Categories: []service.ForecastCategory{{Tag: "Housing", Recurring: 550, Amount: 550}}}}}
+		mockService.On("Forecast", "alice@example.com", 3).Return(expected, nil).Once()
+
+		rr := serve("/analytics/forecast/alice@example.com")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var actual service.Forecast
+		decodeData(t, rr, &actual)
+		assert.Equal(t, *expected, actual)
+	}
+
+	// Test case 2: Months out of range
+	{
+		assert.Equal(t, http.StatusBadRequest, serve("/analytics/forecast/alice@example.com?months=0").Code)
+		assert.Equal(t, http.StatusBadRequest, serve("/analytics/forecast/alice@example.com?months=13").Code)
+	}
+	mockService.AssertExpectations(t)
+}
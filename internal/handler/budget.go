@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+)
+
+type BudgetHandler struct {
+	budgetService service.BudgetService
+}
+
+func NewBudgetHandler(budgetService service.BudgetService) *BudgetHandler {
+	return &BudgetHandler{budgetService: budgetService}
+}
+
+// SetTagBudgetHandler sets, or with a zero limit removes, a user's monthly budget for a tag.
+func (h *BudgetHandler) SetTagBudgetHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeJSON[service.SetTagBudgetRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	if req.UserEmail == "" || strings.TrimSpace(req.Tag) == "" {
+		response.Error(w, r, "user_email and tag are required", http.StatusBadRequest)
+		return
+	}
+	if req.MonthlyLimit < 0 {
+		response.Error(w, r, "monthly_limit must not be negative", http.StatusBadRequest)
+		return
+	}
+	if req.Currency != "" && !isCurrencyCode(req.Currency) {
+		response.Error(w, r, "currency must be a three-letter ISO 4217 code", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.budgetService.SetTagBudget(req); err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetBudgetStatusHandler shows how much of each of the user's tag budgets is left this month.
+func (h *BudgetHandler) GetBudgetStatusHandler(w http.ResponseWriter, r *http.Request) {
+	userEmail, err := emailParam(r)
+	if err != nil {
+		response.Error(w, r, "Invalid user email", http.StatusBadRequest)
+		return
+	}
+	if userEmail == "" {
+		response.Error(w, r, "User email is required", http.StatusBadRequest)
+		return
+	}
+
+	statuses, err := h.budgetService.GetBudgetStatus(userEmail)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, statuses)
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBudgetHandler_SetTagBudgetHandler(t *testing.T) {
+	mockService := new(servicemock.BudgetService)
+	budgetHandler := NewBudgetHandler(mockService)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		budgetHandler.SetTagBudgetHandler(rr, jsonRequest("PUT", "/budgets", bytes.NewBufferString(body)))
+		return rr
+	}
+
+	// Test case 1: Budget is set
+	req := service.SetTagBudgetRequest{UserEmail: "alice@example.com", Tag: "Eating Out", Currency: "USD", MonthlyLimit: 500}
+	mockService.On("SetTagBudget", req).Return(nil).Once()
+	body, _ := json.Marshal(req)
+	assert.Equal(t, http.StatusNoContent, put(string(body)).Code)
+	mockService.AssertExpectations(t)
+
+	// Test case 2: Invalid requests never reach the service
+	for _, body := range []string{
+		`not json`,
+		`{"user_email":"alice@example.com","monthly_limit":5}`,
+		`{"user_email":"alice@example.com","tag":"Food","monthly_limit":-5}`,
+		`{"user_email":"alice@example.com","tag":"Food","currency":"usd","monthly_limit":5}`,
+	} {
+		assert.Equal(t, http.StatusBadRequest, put(body).Code, body)
+	}
+	mockService.AssertNumberOfCalls(t, "SetTagBudget", 1)
+}
+
+func TestBudgetHandler_GetBudgetStatusHandler(t *testing.T) {
+	mockService := new(servicemock.BudgetService)
+	budgetHandler := NewBudgetHandler(mockService)
+
+	statuses := []service.BudgetStatus{{Tag: "Food", Currency: "INR", MonthlyLimit: 100, Spent: 40, Remaining: 60}}
+	mockService.On("GetBudgetStatus", "alice@example.com").Return(statuses, nil).Once()
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/budgets/by-user/{email}", budgetHandler.GetBudgetStatusHandler).Methods("GET")
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/budgets/by-user/alice@example.com", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var actual []service.BudgetStatus
+	decodeData(t, rr, &actual)
+	assert.Equal(t, statuses, actual)
+	mockService.AssertExpectations(t)
+}
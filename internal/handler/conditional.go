@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/service"
+)
+
+// LastModified serves a route keyed by {email} with a Last-Modified header taken from when the
+// user's expenses or balances last changed, and answers a matching If-Modified-Since with 304 Not
+// Modified, so clients polling for changes skip the body when there are none.
+func LastModified(userService service.UserService, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		email, err := emailParam(r)
+		if err != nil {
+			next(w, r)
+			return
+		}
+		// Read before the data, so a change in between makes the header older rather than newer than the body
+		lastModified, err := userService.GetLastModified(email)
+		if err != nil {
+			next(w, r) // Unknown users are reported by the handler as usual
+			return
+		}
+
+		lastModified = lastModified.UTC().Truncate(time.Second)
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		// The data is per user, so shared caches must not keep it and clients must ask before reuse
+		w.Header().Set("Cache-Control", "private, no-cache")
+		if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !lastModified.After(since) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLastModified(t *testing.T) {
+	mockService := new(servicemock.UserService)
+	calls := 0
+	h := LastModified(mockService, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte("[]"))
+	})
+	lastModified := time.Date(2024, 3, 1, 12, 0, 0, 500, time.UTC)
+	request := func(email, since string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/expenses/by-user/"+email, nil)
+		if since != "" {
+			req.Header.Set("If-Modified-Since", since)
+		}
+		rr := httptest.NewRecorder()
+		h(rr, mux.SetURLVars(req, map[string]string{"email": email}))
+		return rr
+	}
+
+	// Test case 1: The list carries the time of the user's last change, in whole seconds
+	mockService.On("GetLastModified", "bob@example.com").Return(lastModified, nil)
+	rr := request("bob@example.com", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "Fri, 01 Mar 2024 12:00:00 GMT", rr.Header().Get("Last-Modified"))
+	assert.Equal(t, "private, no-cache", rr.Header().Get("Cache-Control"))
+	assert.Equal(t, 1, calls)
+
+	// Test case 2: Nothing changed since, so no body
+	rr = request("bob@example.com", "Fri, 01 Mar 2024 12:00:00 GMT")
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Empty(t, rr.Body.String())
+	assert.Equal(t, 1, calls)
+
+	// Test case 3: Changed since, or a date that can't be parsed
+	for _, since := range []string{"Fri, 01 Mar 2024 11:59:59 GMT", "yesterday"} {
+		rr = request("bob@example.com", since)
+		assert.Equal(t, http.StatusOK, rr.Code, since)
+	}
+	assert.Equal(t, 3, calls)
+
+	// Test case 4: Unknown users are left to the handler
+	mockService.On("GetLastModified", "nobody@example.com").Return(time.Time{}, errors.New("user not found")).Once()
+	rr = request("nobody@example.com", "")
+	assert.Empty(t, rr.Header().Get("Last-Modified"))
+	assert.Equal(t, 4, calls)
+	mockService.AssertExpectations(t)
+}
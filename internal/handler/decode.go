@@ -0,0 +1,321 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"mime"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// maxBodyBytes bounds a JSON request body. The largest legitimate bodies are expenses with a few
+// hundred participants, well under this.
+const maxBodyBytes = 1 << 20
+
+// Codes of the ways a request body can be refused, reported alongside the message.
+const (
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeBodyTooLarge         = "body_too_large"
+	CodeEmptyBody            = "empty_body"
+	CodeMalformedJSON        = "malformed_json"
+	CodeUnknownField         = "unknown_field"
+	CodeInvalidType          = "invalid_type"
+	CodeInvalidBody          = "invalid_body"
+	CodeInvalidField         = "invalid_field"
+)
+
+// BodyError is a request body that was refused. Field names the offending JSON field, where there
+// is one.
+type BodyError struct {
+	Status  int
+	Code    string
+	Field   string
+	Message string
+}
+
+func (e *BodyError) Error() string {
+	return e.Message
+}
+
+// FieldError is a value in a well-formed body that failed validation. Field is its JSON path, like
+// "manual_splits[1].amount_owed".
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// checkTextLength refuses free text that would be longer than util.MaxTextLength characters once
+// sanitized.
+func checkTextLength(field, value string) error {
+	if util.TextLength(value) > util.MaxTextLength {
+		return &FieldError{Field: field, Message: fmt.Sprintf("is longer than %d characters", util.MaxTextLength)}
+	}
+	return nil
+}
+
+// checkPublicID refuses a public ID given by the client in a form the server wouldn't generate one.
+// Left empty, one is generated.
+func checkPublicID(field, value string) error {
+	if value != "" && !util.ValidPublicID(value) {
+		return &FieldError{Field: field, Message: "must be a lowercase UUID or an uppercase ULID"}
+	}
+	return nil
+}
+
+// writeFieldError answers a request refused for the value of one of its fields, as a *FieldError.
+func writeFieldError(w http.ResponseWriter, r *http.Request, err error) {
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) {
+		response.Error(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	response.Problem(w, r, http.StatusBadRequest, response.ErrorBody{Message: fieldErr.Error(), Code: CodeInvalidField, Field: fieldErr.Field})
+}
+
+// decodeJSON reads the request body as a T. See decodeJSONInto for what is refused.
+func decodeJSON[T any](w http.ResponseWriter, r *http.Request) (T, error) {
+	var v T
+	err := decodeJSONInto(w, r, &v)
+	return v, err
+}
+
+// decodeJSONInto reads the request body into dst, leaving the fields the body doesn't mention as
+// they were. The body must be sent as application/json, hold a single JSON value of at most
+// maxBodyBytes, and only use fields dst has. Number fields take decimal strings as well as numbers.
+// Refusals are returned as a *BodyError.
+func decodeJSONInto(w http.ResponseWriter, r *http.Request, dst any) error {
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		return &BodyError{Status: http.StatusUnsupportedMediaType, Code: CodeUnsupportedMediaType, Message: "Content-Type must be application/json"}
+	}
+
+	var raw json.RawMessage
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err := dec.Decode(&raw); err != nil {
+		return bodyError(err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return bodyError(err)
+		}
+		return &BodyError{Status: http.StatusBadRequest, Code: CodeMalformedJSON, Message: "Invalid request body: it must hold a single JSON value"}
+	}
+
+	raw, err := withDecimalStrings(raw, reflect.TypeOf(dst))
+	if err != nil {
+		return err
+	}
+	dec = json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return bodyError(err)
+	}
+	return nil
+}
+
+// decimalPattern is a decimal number as an amount may be sent in a JSON string, like "12.50".
+var decimalPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// withDecimalStrings lets every number field of t also be sent as a decimal string, so a client can
+// send amounts without putting them through a float first. It rewrites the strings found in number
+// fields of raw as JSON numbers; everything else, including values that don't fit t, is left for
+// the decoder to take or refuse.
+func withDecimalStrings(raw json.RawMessage, t reflect.Type) (json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, bodyError(err)
+	}
+	v, changed, err := decimalStrings(v, t, "")
+	if err != nil || !changed {
+		return raw, err
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, bodyError(err)
+	}
+	return out, nil
+}
+
+var unmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+// decimalStrings walks v, a decoded JSON value, alongside the Go type it is bound for. path is
+// where v is in the body, like "manual_splits[1].amount_owed", for the error on a bad string.
+func decimalStrings(v any, t reflect.Type, path string) (any, bool, error) {
+	for t.Kind() == reflect.Pointer {
+		if t.Implements(unmarshalerType) {
+			return v, false, nil
+		}
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		// The type reads its own JSON
+		return v, false, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		s, ok := v.(string)
+		if !ok {
+			return v, false, nil
+		}
+		n, err := parseDecimal(s)
+		if err != nil {
+			return nil, false, &BodyError{Status: http.StatusBadRequest, Code: CodeInvalidType, Field: path, Message: fmt.Sprintf("Invalid request body: %s must be a number or a decimal string like \"12.50\"", path)}
+		}
+		return n, true, nil
+	case reflect.Slice, reflect.Array:
+		items, ok := v.([]any)
+		if !ok {
+			return v, false, nil
+		}
+		changed := false
+		for i, item := range items {
+			item, c, err := decimalStrings(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, false, err
+			}
+			items[i], changed = item, changed || c
+		}
+		return items, changed, nil
+	case reflect.Map:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v, false, nil
+		}
+		changed := false
+		for key, item := range obj {
+			item, c, err := decimalStrings(item, t.Elem(), joinPath(path, key))
+			if err != nil {
+				return nil, false, err
+			}
+			obj[key], changed = item, changed || c
+		}
+		return obj, changed, nil
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v, false, nil
+		}
+		fields := jsonFields(t)
+		changed := false
+		for key, item := range obj {
+			ft, ok := fields[strings.ToLower(key)]
+			if !ok {
+				continue
+			}
+			item, c, err := decimalStrings(item, ft, joinPath(path, key))
+			if err != nil {
+				return nil, false, err
+			}
+			obj[key], changed = item, changed || c
+		}
+		return obj, changed, nil
+	}
+	return v, false, nil
+}
+
+// jsonFields maps the lower-cased JSON names of t's fields, including those of embedded structs,
+// to their types; encoding/json matches names regardless of case.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			for n, et := range jsonFields(ft) {
+				if _, ok := fields[n]; !ok {
+					fields[n] = et
+				}
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = f.Type
+	}
+	return fields
+}
+
+// parseDecimal reads a decimal string exactly and returns it as the JSON number nearest to it.
+func parseDecimal(s string) (json.Number, error) {
+	if !decimalPattern.MatchString(s) {
+		return "", fmt.Errorf("not a decimal: %q", s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return "", fmt.Errorf("not a decimal: %q", s)
+	}
+	f, _ := r.Float64()
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// bodyError explains why decoding a request body failed.
+func bodyError(err error) *BodyError {
+	var maxErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &maxErr):
+		return &BodyError{Status: http.StatusRequestEntityTooLarge, Code: CodeBodyTooLarge, Message: fmt.Sprintf("Request body must not be larger than %d bytes", maxErr.Limit)}
+	case errors.Is(err, io.EOF):
+		return &BodyError{Status: http.StatusBadRequest, Code: CodeEmptyBody, Message: "Invalid request body: it is empty"}
+	case errors.As(err, &syntaxErr):
+		return &BodyError{Status: http.StatusBadRequest, Code: CodeMalformedJSON, Message: fmt.Sprintf("Invalid request body: malformed JSON at byte %d", syntaxErr.Offset)}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &BodyError{Status: http.StatusBadRequest, Code: CodeMalformedJSON, Message: "Invalid request body: malformed JSON, it ends too early"}
+	case errors.As(err, &typeErr):
+		return &BodyError{Status: http.StatusBadRequest, Code: CodeInvalidType, Field: typeErr.Field, Message: fmt.Sprintf("Invalid request body: %s can't be a JSON %s", typeErr.Field, typeErr.Value)}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no error type for this, only the message
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &BodyError{Status: http.StatusBadRequest, Code: CodeUnknownField, Field: field, Message: fmt.Sprintf("Invalid request body: unknown field %q", field)}
+	default:
+		// A field's own UnmarshalJSON refused its value
+		return &BodyError{Status: http.StatusBadRequest, Code: CodeInvalidBody, Message: "Invalid request body: " + err.Error()}
+	}
+}
+
+// writeBodyError answers a request whose body decodeJSON refused.
+func writeBodyError(w http.ResponseWriter, r *http.Request, err error) {
+	var bodyErr *BodyError
+	if !errors.As(err, &bodyErr) {
+		response.Error(w, r, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	response.Problem(w, r, bodyErr.Status, response.ErrorBody{Message: bodyErr.Message, Code: bodyErr.Code, Field: bodyErr.Field})
+}
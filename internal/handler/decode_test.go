@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jsonRequest is a request carrying a JSON body, as the handlers require.
+func jsonRequest(method, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestDecodeJSON(t *testing.T) {
+	type split struct {
+		Amount float64 `json:"amount"`
+	}
+	type payload struct {
+		Name   string  `json:"name"`
+		Amount float64 `json:"amount"`
+		Splits []split `json:"splits,omitempty"`
+	}
+	decode := func(contentType, body string) (payload, *BodyError) {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		v, err := decodeJSON[payload](httptest.NewRecorder(), req)
+		if err == nil {
+			return v, nil
+		}
+		bodyErr, ok := err.(*BodyError)
+		require.True(t, ok, "not a *BodyError: %v", err)
+		return v, bodyErr
+	}
+
+	// Test case 1: A well-formed body, with a charset
+	v, err := decode("application/json; charset=utf-8", `{"name":"Alice","amount":12.5}`)
+	assert.Nil(t, err)
+	assert.Equal(t, payload{Name: "Alice", Amount: 12.5}, v)
+
+	// Test case 2: Amounts may be sent as decimal strings, at any depth
+	v, err = decode("application/json", `{"name":"Alice","Amount":"12.50","splits":[{"amount":"0.1"},{"amount":2.4}]}`)
+	assert.Nil(t, err)
+	assert.Equal(t, payload{Name: "Alice", Amount: 12.5, Splits: []split{{Amount: 0.1}, {Amount: 2.4}}}, v)
+
+	// Test case 3: Each refusal has its status, code and, where there is one, field
+	for _, tc := range []struct {
+		contentType, body string
+		status            int
+		code, field       string
+	}{
+		{"text/plain", `{"name":"Alice"}`, http.StatusUnsupportedMediaType, CodeUnsupportedMediaType, ""},
+		{"", `{"name":"Alice"}`, http.StatusUnsupportedMediaType, CodeUnsupportedMediaType, ""},
+		{"application/json", ``, http.StatusBadRequest, CodeEmptyBody, ""},
+		{"application/json", `{"name":`, http.StatusBadRequest, CodeMalformedJSON, ""},
+		{"application/json", `{"name" "Alice"}`, http.StatusBadRequest, CodeMalformedJSON, ""},
+		{"application/json", `{"name":"Alice"} {}`, http.StatusBadRequest, CodeMalformedJSON, ""},
+		{"application/json", `{"name":"Alice","nmae":"Bob"}`, http.StatusBadRequest, CodeUnknownField, "nmae"},
+		{"application/json", `{"amount":true}`, http.StatusBadRequest, CodeInvalidType, "amount"},
+		{"application/json", `{"amount":"1e3"}`, http.StatusBadRequest, CodeInvalidType, "amount"},
+		{"application/json", `{"splits":[{"amount":"12"},{"amount":"12,50"}]}`, http.StatusBadRequest, CodeInvalidType, "splits[1].amount"},
+		{"application/json", `{"name":12.5}`, http.StatusBadRequest, CodeInvalidType, "name"},
+		{"application/json", `{"name":"` + strings.Repeat("a", maxBodyBytes) + `"}`, http.StatusRequestEntityTooLarge, CodeBodyTooLarge, ""},
+	} {
+		_, err := decode(tc.contentType, tc.body)
+		if assert.NotNil(t, err, tc.code) {
+			assert.Equal(t, tc.status, err.Status, tc.code)
+			assert.Equal(t, tc.code, err.Code)
+			assert.Equal(t, tc.field, err.Field, tc.code)
+		}
+	}
+}
+
+func TestWriteBodyError(t *testing.T) {
+	rr := httptest.NewRecorder()
+	writeBodyError(rr, httptest.NewRequest("POST", "/", nil), &BodyError{Status: http.StatusBadRequest, Code: CodeUnknownField, Field: "nmae", Message: `Invalid request body: unknown field "nmae"`})
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.JSONEq(t, `{"data":null,"meta":{"api_version":"1"},"error":{"message":"Invalid request body: unknown field \"nmae\"","code":"unknown_field","field":"nmae"}}`, rr.Body.String())
+}
@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/response"
+)
+
+// serverError reports an unexpected failure. A query that ran out of time becomes a 504 so clients
+// know the request may succeed if retried; anything else is a 500.
+func serverError(w http.ResponseWriter, r *http.Request, err error) {
+	if repository.IsQueryTimeout(err) {
+		response.Error(w, r, "The database took too long to respond, please try again", http.StatusGatewayTimeout)
+		return
+	}
+	response.Error(w, r, err.Error(), http.StatusInternalServerError)
+}
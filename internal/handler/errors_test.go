@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeData decodes the data of an enveloped response into out.
+func decodeData(t *testing.T, rr *httptest.ResponseRecorder, out any) {
+	t.Helper()
+	env := response.Envelope{Data: out}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&env))
+}
+
+// dataJSON returns the data of an enveloped response as JSON.
+func dataJSON(t *testing.T, rr *httptest.ResponseRecorder) string {
+	t.Helper()
+	var data json.RawMessage
+	decodeData(t, rr, &data)
+	return string(data)
+}
+
+// errorMessage returns the error message of an enveloped response, or "" if it has none.
+func errorMessage(t *testing.T, rr *httptest.ResponseRecorder) string {
+	t.Helper()
+	var env response.Envelope
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &env))
+	if env.Error == nil {
+		return ""
+	}
+	return env.Error.Message
+}
+
+func TestServerError(t *testing.T) {
+	// Test case 1: A statement that ran out of time
+	rr := httptest.NewRecorder()
+	serverError(rr, httptest.NewRequest("GET", "/", nil), fmt.Errorf("failed to query expenses: %w", &mysql.MySQLError{Number: 3024, Message: "maximum statement execution time exceeded"}))
+	assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "3024")
+
+	// Test case 2: Anything else
+	rr = httptest.NewRecorder()
+	serverError(rr, httptest.NewRequest("GET", "/", nil), errors.New("boom"))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, "boom", errorMessage(t, rr))
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/aadithya-md/split-expense/internal/util"
+	"github.com/gorilla/mux"
+)
+
+// maxUnitLength is as long as the events.unit column allows.
+const maxUnitLength = 64
+
+type EventHandler struct {
+	eventService service.EventService
+}
+
+func NewEventHandler(eventService service.EventService) *EventHandler {
+	return &EventHandler{eventService: eventService}
+}
+
+func (h *EventHandler) CreateEventHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeJSON[service.CreateEventRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	if util.SanitizeText(req.Name) == "" || req.CreatedByEmail == "" {
+		response.Error(w, r, "name and created_by_email are required", http.StatusBadRequest)
+		return
+	}
+	if err := checkTextLength("name", req.Name); err != nil {
+		writeFieldError(w, r, err)
+		return
+	}
+	if req.BaseCurrency != "" && !isCurrencyCode(req.BaseCurrency) {
+		response.Error(w, r, "base_currency must be a three-letter ISO 4217 code", http.StatusBadRequest)
+		return
+	}
+	if util.TextLength(req.Unit) > maxUnitLength {
+		writeFieldError(w, r, &FieldError{Field: "unit", Message: fmt.Sprintf("is longer than %d characters", maxUnitLength)})
+		return
+	}
+	if req.BaseCurrency != "" && util.SanitizeText(req.Unit) != "" {
+		response.Error(w, r, "base_currency and unit can't both be set", http.StatusBadRequest)
+		return
+	}
+
+	event, err := h.eventService.CreateEvent(req)
+	if err != nil {
+		writeEventError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusCreated, event)
+}
+
+func (h *EventHandler) GetEventSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid event ID", http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.eventService.GetEventSummary(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrEventNotFound) {
+			response.Error(w, r, err.Error(), http.StatusNotFound)
+			return
+		}
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, summary)
+}
+
+// ListEventsHandler lists the events of ?user_email=, without archived ones unless
+// ?include_archived=true.
+func (h *EventHandler) ListEventsHandler(w http.ResponseWriter, r *http.Request) {
+	userEmail := r.URL.Query().Get("user_email")
+	if userEmail == "" {
+		response.Error(w, r, "user_email is required", http.StatusBadRequest)
+		return
+	}
+	var includeArchived bool
+	if v := r.URL.Query().Get("include_archived"); v != "" {
+		var err error
+		if includeArchived, err = strconv.ParseBool(v); err != nil {
+			response.Error(w, r, "include_archived must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	events, err := h.eventService.ListEvents(userEmail, includeArchived)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, events)
+}
+
+func (h *EventHandler) ArchiveEventHandler(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, h.eventService.ArchiveEvent)
+}
+
+func (h *EventHandler) UnarchiveEventHandler(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, h.eventService.UnarchiveEvent)
+}
+
+func (h *EventHandler) setArchived(w http.ResponseWriter, r *http.Request, set func(int, service.ArchiveEventRequest) (*repository.Event, error)) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid event ID", http.StatusBadRequest)
+		return
+	}
+
+	req, err := decodeJSON[service.ArchiveEventRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if req.UserEmail == "" {
+		response.Error(w, r, "user_email is required", http.StatusBadRequest)
+		return
+	}
+
+	event, err := set(id, req)
+	if err != nil {
+		writeEventError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, event)
+}
+
+// SetAutoSettleHandler opts an event in to, or out of, having its month-end settle-up proposed as
+// settlements.
+func (h *EventHandler) SetAutoSettleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid event ID", http.StatusBadRequest)
+		return
+	}
+
+	req, err := decodeJSON[service.SetAutoSettleRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if req.UserEmail == "" {
+		response.Error(w, r, "user_email is required", http.StatusBadRequest)
+		return
+	}
+
+	event, err := h.eventService.SetAutoSettle(id, req)
+	if err != nil {
+		writeEventError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, event)
+}
+
+// SetBaseCurrencyHandler fixes the currency an event's expenses are converted to, or clears it with
+// an empty base_currency. It is refused once the event has expenses.
+func (h *EventHandler) SetBaseCurrencyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid event ID", http.StatusBadRequest)
+		return
+	}
+
+	req, err := decodeJSON[service.SetBaseCurrencyRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if req.UserEmail == "" {
+		response.Error(w, r, "user_email is required", http.StatusBadRequest)
+		return
+	}
+	if req.BaseCurrency != "" && !isCurrencyCode(req.BaseCurrency) {
+		response.Error(w, r, "base_currency must be a three-letter ISO 4217 code", http.StatusBadRequest)
+		return
+	}
+
+	event, err := h.eventService.SetBaseCurrency(id, req)
+	if err != nil {
+		writeEventError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, event)
+}
+
+// writeEventError maps the errors of changing an event to their status codes.
+func writeEventError(w http.ResponseWriter, r *http.Request, err error) {
+	if writeQuotaError(w, r, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, repository.ErrEventNotFound):
+		response.Error(w, r, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrNotEventCreator):
+		response.Error(w, r, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrEventHasExpenses), errors.Is(err, service.ErrEventHasUnit):
+		response.Error(w, r, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrUnsupportedCurrency):
+		response.Error(w, r, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		serverError(w, r, err)
+	}
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/aadithya-md/split-expense/internal/util"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventHandler_CreateEventHandler(t *testing.T) {
+	mockService := new(servicemock.EventService)
+	eventHandler := NewEventHandler(mockService)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		eventHandler.CreateEventHandler(rr, jsonRequest("POST", "/events", bytes.NewBufferString(body)))
+		return rr
+	}
+
+	// Test case 1: Successful creation
+	req := service.CreateEventRequest{Name: "Goa trip", CreatedByEmail: "alice@example.com"}
+	mockService.On("CreateEvent", req).Return(&repository.Event{ID: 1, Name: "Goa trip"}, nil).Once()
+	assert.Equal(t, http.StatusCreated, post(`{"name":"Goa trip","created_by_email":"alice@example.com"}`).Code)
+
+	// Test case 2: Missing fields
+	assert.Equal(t, http.StatusBadRequest, post(`{"name":" ","created_by_email":"alice@example.com"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"name":"Goa trip"}`).Code)
+
+	// Test case 3: Over the events quota
+	mockService.On("CreateEvent", req).Return(nil, &service.QuotaError{Quota: service.QuotaEvents, Limit: 3}).Once()
+	rr := post(`{"name":"Goa trip","created_by_email":"alice@example.com"}`)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"code":"quota_events"`)
+	assert.Empty(t, rr.Header().Get("Retry-After"))
+
+	// Test case 4: Names are counted in characters once sanitized
+	assert.Equal(t, http.StatusBadRequest, post(`{"name":"\u0007\t","created_by_email":"alice@example.com"}`).Code)
+	rr = post(`{"name":"` + strings.Repeat("🏖", util.MaxTextLength+1) + `","created_by_email":"alice@example.com"}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"code":"invalid_field"`)
+
+	// Test case 5: An event counts money in a base currency or its own unit, not both
+	rr = post(`{"name":"Flat","created_by_email":"alice@example.com","base_currency":"INR","unit":"chore points"}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "can't both be set")
+	rr = post(`{"name":"Flat","created_by_email":"alice@example.com","unit":"` + strings.Repeat("p", 65) + `"}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"field":"unit"`)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestEventHandler_ArchiveEventHandler(t *testing.T) {
+	mockService := new(servicemock.EventService)
+	eventHandler := NewEventHandler(mockService)
+
+	post := func(id, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r := jsonRequest("POST", "/events/"+id+"/archive", bytes.NewBufferString(body))
+		eventHandler.ArchiveEventHandler(rr, mux.SetURLVars(r, map[string]string{"id": id}))
+		return rr
+	}
+	bob := service.ArchiveEventRequest{UserEmail: "bob@example.com"}
+
+	// Test case 1: Not the creator
+	mockService.On("ArchiveEvent", 1, bob).Return(nil, service.ErrNotEventCreator).Once()
+	assert.Equal(t, http.StatusForbidden, post("1", `{"user_email":"bob@example.com"}`).Code)
+
+	// Test case 2: Unknown event
+	mockService.On("ArchiveEvent", 2, bob).Return(nil, fmt.Errorf("%w: 2", repository.ErrEventNotFound)).Once()
+	assert.Equal(t, http.StatusNotFound, post("2", `{"user_email":"bob@example.com"}`).Code)
+
+	// Test case 3: Bad input
+	assert.Equal(t, http.StatusBadRequest, post("x", `{"user_email":"bob@example.com"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post("1", `{}`).Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestEventHandler_UnarchiveEventHandler(t *testing.T) {
+	mockService := new(servicemock.EventService)
+	eventHandler := NewEventHandler(mockService)
+
+	post := func(id, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r := jsonRequest("POST", "/events/"+id+"/unarchive", bytes.NewBufferString(body))
+		eventHandler.UnarchiveEventHandler(rr, mux.SetURLVars(r, map[string]string{"id": id}))
+		return rr
+	}
+	alice := service.ArchiveEventRequest{UserEmail: "alice@example.com"}
+
+	// Test case 1: Success
+	mockService.On("UnarchiveEvent", 1, alice).Return(&repository.Event{ID: 1, Name: "Goa trip"}, nil).Once()
+	rr := post("1", `{"user_email":"alice@example.com"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "archived_at")
+
+	// Test case 2: Not the creator
+	mockService.On("UnarchiveEvent", 1, service.ArchiveEventRequest{UserEmail: "bob@example.com"}).Return(nil, service.ErrNotEventCreator).Once()
+	assert.Equal(t, http.StatusForbidden, post("1", `{"user_email":"bob@example.com"}`).Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestEventHandler_SetAutoSettleHandler(t *testing.T) {
+	mockService := new(servicemock.EventService)
+	eventHandler := NewEventHandler(mockService)
+
+	put := func(id, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r := jsonRequest("PUT", "/events/"+id+"/auto-settle", bytes.NewBufferString(body))
+		eventHandler.SetAutoSettleHandler(rr, mux.SetURLVars(r, map[string]string{"id": id}))
+		return rr
+	}
+
+	// Test case 1: Success
+	mockService.On("SetAutoSettle", 1, service.SetAutoSettleRequest{UserEmail: "alice@example.com", Enabled: true}).Return(&repository.Event{ID: 1, Name: "Flat", AutoSettle: true}, nil).Once()
+	rr := put("1", `{"user_email":"alice@example.com","enabled":true}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"auto_settle":true`)
+
+	// Test case 2: Not the creator
+	mockService.On("SetAutoSettle", 1, service.SetAutoSettleRequest{UserEmail: "bob@example.com"}).Return(nil, service.ErrNotEventCreator).Once()
+	assert.Equal(t, http.StatusForbidden, put("1", `{"user_email":"bob@example.com","enabled":false}`).Code)
+
+	// Test case 3: Bad input
+	assert.Equal(t, http.StatusBadRequest, put("x", `{"user_email":"alice@example.com"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, put("1", `{"enabled":true}`).Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestEventHandler_SetBaseCurrencyHandler(t *testing.T) {
+	mockService := new(servicemock.EventService)
+	eventHandler := NewEventHandler(mockService)
+
+	put := func(id, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r := jsonRequest("PUT", "/events/"+id+"/base-currency", bytes.NewBufferString(body))
+		eventHandler.SetBaseCurrencyHandler(rr, mux.SetURLVars(r, map[string]string{"id": id}))
+		return rr
+	}
+	eur := service.SetBaseCurrencyRequest{UserEmail: "alice@example.com", BaseCurrency: "EUR"}
+
+	// Test case 1: Success
+	mockService.On("SetBaseCurrency", 1, eur).Return(&repository.Event{ID: 1, Name: "Lisbon", BaseCurrency: "EUR"}, nil).Once()
+	rr := put("1", `{"user_email":"alice@example.com","base_currency":"EUR"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"base_currency":"EUR"`)
+
+	// Test case 2: The event already has expenses in the old currency
+	mockService.On("SetBaseCurrency", 2, eur).Return(nil, service.ErrEventHasExpenses).Once()
+	assert.Equal(t, http.StatusConflict, put("2", `{"user_email":"alice@example.com","base_currency":"EUR"}`).Code)
+
+	// Test case 3: Not a currency code
+	assert.Equal(t, http.StatusBadRequest, put("1", `{"user_email":"alice@example.com","base_currency":"euro"}`).Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestEventHandler_ListEventsHandler(t *testing.T) {
+	mockService := new(servicemock.EventService)
+	eventHandler := NewEventHandler(mockService)
+
+	list := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		eventHandler.ListEventsHandler(rr, httptest.NewRequest("GET", "/events?"+query, nil))
+		return rr
+	}
+
+	// Test case 1: Archived events are left out unless asked for
+	mockService.On("ListEvents", "alice@example.com", false).Return([]repository.Event{{ID: 2}}, nil).Once()
+	assert.Equal(t, http.StatusOK, list("user_email=alice@example.com").Code)
+	mockService.On("ListEvents", "alice@example.com", true).Return([]repository.Event{{ID: 2}, {ID: 1}}, nil).Once()
+	assert.Equal(t, http.StatusOK, list("user_email=alice@example.com&include_archived=true").Code)
+
+	// Test case 2: Bad input
+	assert.Equal(t, http.StatusBadRequest, list("").Code)
+	assert.Equal(t, http.StatusBadRequest, list("user_email=alice@example.com&include_archived=maybe").Code)
+
+	mockService.AssertExpectations(t)
+}
@@ -1,70 +1,350 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/response"
 	"github.com/aadithya-md/split-expense/internal/service"
 	"github.com/aadithya-md/split-expense/internal/util"
 	"github.com/gorilla/mux"
 )
 
+// ExpenseLimits caps the size of expenses accepted over the API. A zero field disables that limit.
+type ExpenseLimits struct {
+	MaxParticipants      int
+	MaxTotalAmount       float64
+	MaxDescriptionLength int
+}
+
+// Limit violations are reported with these codes so clients can tell them apart from malformed input.
+var (
+	ErrTooManyParticipants = errors.New("too_many_participants")
+	ErrTotalAmountTooLarge = errors.New("total_amount_too_large")
+	ErrDescriptionTooLong  = errors.New("description_too_long")
+)
+
+// OverallBalanceResponse is a user's balance with everyone, summed. Positive means they are owed.
+type OverallBalanceResponse struct {
+	OverallBalance float64 `json:"overall_balance"`
+}
+
 type ExpenseHandler struct {
 	expenseService service.ExpenseService
+	limits         ExpenseLimits
+	currencies     util.Currencies
 }
 
-func NewExpenseHandler(expenseService service.ExpenseService) *ExpenseHandler {
-	return &ExpenseHandler{expenseService: expenseService}
+// NewExpenseHandler builds the expense handler. Amounts finer than the minor unit currencies gives
+// their currency are refused.
+func NewExpenseHandler(expenseService service.ExpenseService, limits ExpenseLimits, currencies util.Currencies) *ExpenseHandler {
+	return &ExpenseHandler{expenseService: expenseService, limits: limits, currencies: currencies}
 }
 
 func (h *ExpenseHandler) CreateExpenseHandler(w http.ResponseWriter, r *http.Request) {
-	var req service.CreateExpenseRequest
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	req, err := decodeJSON[service.CreateExpenseRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
 		return
 	}
+	// ?explain=true does the same as "explain": true in the body
+	if explain := r.URL.Query().Get("explain"); explain != "" {
+		on, err := strconv.ParseBool(explain)
+		if err != nil {
+			response.Error(w, r, "explain must be true or false", http.StatusBadRequest)
+			return
+		}
+		req.Explain = req.Explain || on
+	}
 
 	if err := h.validateCreateExpenseRequest(req); err != nil {
-		http.Error(w, "Invalid expense data: "+err.Error(), http.StatusBadRequest)
+		var fieldErr *FieldError
+		if errors.As(err, &fieldErr) {
+			response.Problem(w, r, http.StatusBadRequest, response.ErrorBody{Message: "Invalid expense data: " + err.Error(), Code: CodeInvalidField, Field: fieldErr.Field})
+			return
+		}
+		response.Error(w, r, "Invalid expense data: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	expense, err := h.expenseService.CreateExpense(req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		if errors.Is(err, service.ErrAmountMismatch) {
+			response.Error(w, r, "Invalid expense data: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, repository.ErrExpenseNotFound) || errors.Is(err, repository.ErrPartyNotFound) || errors.Is(err, repository.ErrEventNotFound) {
+			response.Error(w, r, err.Error(), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, service.ErrTagBudgetExceeded) || errors.Is(err, service.ErrEventArchived) || errors.Is(err, repository.ErrPublicIDTaken) {
+			response.Error(w, r, err.Error(), http.StatusConflict)
+			return
+		}
+		// The event fixes a base currency the expense can't be converted to or a unit it isn't in
+		// whole numbers of, or balances can't be kept in the expense's currency
+		if errors.Is(err, service.ErrRateUnavailable) || errors.Is(err, service.ErrUnsupportedCurrency) || errors.Is(err, service.ErrFractionalUnits) {
+			response.Error(w, r, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		if writeQuotaError(w, r, err) {
+			return
+		}
+		serverError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(expense)
+	response.JSON(w, r, http.StatusCreated, expense)
+}
+
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *ExpenseHandler) DisputeExpenseHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid expense ID", http.StatusBadRequest)
+		return
+	}
+
+	req, err := decodeJSON[service.DisputeExpenseRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if req.UserEmail == "" || util.SanitizeText(req.Reason) == "" {
+		response.Error(w, r, "user_email and reason are required", http.StatusBadRequest)
+		return
+	}
+	if err := checkTextLength("reason", req.Reason); err != nil {
+		writeFieldError(w, r, err)
+		return
+	}
+
+	expense, err := h.expenseService.DisputeExpense(id, req)
+	if err != nil {
+		writeExpenseStatusError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, expense)
+}
+
+func (h *ExpenseHandler) DismissExpenseDisputeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid expense ID", http.StatusBadRequest)
+		return
+	}
+
+	req, err := decodeJSON[service.DismissDisputeRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if req.UserEmail == "" {
+		response.Error(w, r, "user_email is required", http.StatusBadRequest)
+		return
+	}
+
+	expense, err := h.expenseService.DismissExpenseDispute(id, req)
+	if err != nil {
+		writeExpenseStatusError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, expense)
+}
+
+// UndoExpenseHandler deletes an expense its creator added moments ago. Being a DELETE, it takes the
+// creator's email from the query string rather than a body.
+func (h *ExpenseHandler) UndoExpenseHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid expense ID", http.StatusBadRequest)
+		return
+	}
+
+	req := service.UndoExpenseRequest{UserEmail: r.URL.Query().Get("user_email")}
+	if req.UserEmail == "" {
+		response.Error(w, r, "user_email is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.expenseService.UndoExpense(id, req); err != nil {
+		writeExpenseStatusError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnlockExpenseHandler lifts the lock a confirmed settlement put on an expense, so its creator can
+// change it again.
+func (h *ExpenseHandler) UnlockExpenseHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid expense ID", http.StatusBadRequest)
+		return
+	}
+
+	req, err := decodeJSON[service.UnlockExpenseRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if req.UserEmail == "" {
+		response.Error(w, r, "user_email is required", http.StatusBadRequest)
+		return
+	}
+
+	expense, err := h.expenseService.UnlockExpense(id, req)
+	if err != nil {
+		writeExpenseStatusError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, expense)
+}
+
+func writeExpenseStatusError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrExpenseNotFound):
+		response.Error(w, r, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrNotExpenseParticipant):
+		response.Error(w, r, err.Error(), http.StatusForbidden)
+	case errors.Is(err, repository.ErrInvalidExpenseTransition), errors.Is(err, service.ErrExpenseNotUndoable),
+		errors.Is(err, repository.ErrExpenseLocked), errors.Is(err, repository.ErrExpenseNotLocked):
+		response.Error(w, r, err.Error(), http.StatusConflict)
+	default:
+		serverError(w, r, err)
+	}
 }
 
 func (h *ExpenseHandler) GetExpensesForUserHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	userEmail := vars["email"]
+	userEmail, err := emailParam(r)
+	if err != nil {
+		response.Error(w, r, "Invalid user email", http.StatusBadRequest)
+		return
+	}
 	if userEmail == "" {
-		http.Error(w, "User email is required", http.StatusBadRequest)
+		response.Error(w, r, "User email is required", http.StatusBadRequest)
+		return
+	}
+
+	view := r.URL.Query().Get("view")
+	if view != "" && view != "grouped" {
+		response.Error(w, r, "view must be grouped or omitted", http.StatusBadRequest)
+		return
+	}
+
+	var runningBalance bool
+	if v := r.URL.Query().Get("running_balance"); v != "" {
+		if runningBalance, err = strconv.ParseBool(v); err != nil {
+			response.Error(w, r, "running_balance must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit, offset, paged, err := pageParams(r)
+	if err != nil {
+		response.Error(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if paged && view == "grouped" {
+		response.Error(w, r, "limit and offset can't be used with the grouped view", http.StatusBadRequest)
+		return
+	}
+
+	if paged {
+		expenses, total, err := h.expenseService.GetExpensePageForUser(userEmail, limit, offset, runningBalance)
+		if err != nil {
+			serverError(w, r, err)
+			return
+		}
+		if expenses == nil {
+			expenses = []repository.UserExpenseView{}
+		}
+		response.Page(w, r, http.StatusOK, expenses, response.Pagination{Limit: limit, Offset: offset, Total: total})
 		return
 	}
 
 	expenses, err := h.expenseService.GetExpensesForUser(userEmail)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		serverError(w, r, err)
 		return
 	}
+	if runningBalance {
+		expenses = service.WithRunningBalance(expenses)
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(expenses)
+	if view == "grouped" {
+		response.JSON(w, r, http.StatusOK, service.GroupExpensesByMonth(expenses, h.currencies))
+		return
+	}
+	response.JSON(w, r, http.StatusOK, expenses)
 }
 
 func (h *ExpenseHandler) validateCreateExpenseRequest(req service.CreateExpenseRequest) error {
-	if req.Description == "" || req.TotalAmount <= 0 || req.CreatedByEmail == "" || req.SplitMethod == "" {
+	if util.SanitizeText(req.Description) == "" || req.TotalAmount <= 0 || req.CreatedByEmail == "" || req.SplitMethod == "" {
 		return fmt.Errorf("description, total_amount, created_by, and split_method are required")
 	}
+	if max := h.limits.MaxDescriptionLength; max > 0 && util.TextLength(req.Description) > max {
+		return fmt.Errorf("%w: description is longer than %d characters", ErrDescriptionTooLong, max)
+	}
+	if err := checkTextLength("description", req.Description); err != nil {
+		return err
+	}
+	if err := checkTextLength("tag", req.Tag); err != nil {
+		return err
+	}
+	if err := checkPublicID("public_id", req.PublicID); err != nil {
+		return err
+	}
+	currency := req.Currency
+	if currency == "" {
+		currency = util.DefaultCurrency
+	}
+	exp := h.currencies.Exponent(currency)
+	if max := h.limits.MaxTotalAmount; max > 0 && req.GrandTotal(exp) > max {
+		return fmt.Errorf("%w: total amount %.2f exceeds the maximum of %.2f", ErrTotalAmountTooLarge, req.GrandTotal(exp), max)
+	}
+
+	if !isCurrencyCode(currency) {
+		return fmt.Errorf("currency must be a three-letter ISO 4217 code")
+	}
+	if util.RoundToCurrency(req.TotalAmount, exp) != req.TotalAmount {
+		return fmt.Errorf("total_amount has more decimal places than %s allows (%d)", currency, exp)
+	}
+
+	if req.TaxAmount < 0 || req.TipPercentage < 0 {
+		return fmt.Errorf("tax_amount and tip_percentage cannot be negative")
+	}
+	if util.RoundToCurrency(req.TaxAmount, exp) != req.TaxAmount {
+		return fmt.Errorf("tax_amount has more decimal places than %s allows (%d)", currency, exp)
+	}
+
+	if err := checkSplitFields(req); err != nil {
+		return err
+	}
+
+	switch req.BalanceStrategy {
+	case "", service.BalanceStrategySimple, service.BalanceStrategyHighestBalance, service.BalanceStrategyPairwiseNetting:
+	default:
+		return &FieldError{Field: "balance_strategy", Message: fmt.Sprintf("unsupported balance strategy %q, use simple, highest-balance or pairwise-netting", req.BalanceStrategy)}
+	}
 
 	// Validate unique emails
 	participatingEmails := util.NewSet[string]()
@@ -74,96 +354,333 @@ func (h *ExpenseHandler) validateCreateExpenseRequest(req service.CreateExpenseR
 		if len(req.EqualSplits) == 0 {
 			return fmt.Errorf("equal split requires participants with amounts paid")
 		}
-		for _, s := range req.EqualSplits {
-			if participatingEmails.IsMember(s.UserEmail) {
+		for i, s := range req.EqualSplits {
+			if participatingEmails.IsMember(util.NormalizeEmail(s.UserEmail)) {
 				return fmt.Errorf("duplicate email found in splits: %s", s.UserEmail)
 			}
-			participatingEmails.Add(s.UserEmail)
-
+			participatingEmails.Add(util.NormalizeEmail(s.UserEmail))
+			if err := checkAmountPaid("equal_splits", i, s.AmountPaid, currency, exp); err != nil {
+				return err
+			}
 		}
 	case service.SplitMethodPercentage:
 		if len(req.PercentageSplits) == 0 {
 			return fmt.Errorf("percentage split requires percentages")
 		}
 		var totalPercentage float64
-		for _, s := range req.PercentageSplits {
-			if participatingEmails.IsMember(s.UserEmail) {
+		for i, s := range req.PercentageSplits {
+			if participatingEmails.IsMember(util.NormalizeEmail(s.UserEmail)) {
 				return fmt.Errorf("duplicate email found in percentage splits: %s", s.UserEmail)
 			}
-			participatingEmails.Add(s.UserEmail)
+			participatingEmails.Add(util.NormalizeEmail(s.UserEmail))
+			if s.Percentage < 0 {
+				return &FieldError{Field: fmt.Sprintf("percentage_splits[%d].percentage", i), Message: "must not be negative"}
+			}
+			if err := checkAmountPaid("percentage_splits", i, s.AmountPaid, currency, exp); err != nil {
+				return err
+			}
+			if s.Percentage == 0 && s.AmountPaid == 0 && !req.AllowZeroAmounts {
+				return zeroAmountError("percentage_splits", i, s.UserEmail)
+			}
 			totalPercentage += s.Percentage
 		}
-		if totalPercentage != 100 {
+		if util.RoundToTwoDecimalPlaces(totalPercentage) != 100 {
 			return fmt.Errorf("total percentage across all splits must be 100%%")
 		}
 	case service.SplitMethodManual:
 		if len(req.ManualSplits) == 0 {
 			return fmt.Errorf("manual split requires manual amounts")
 		}
-		var totalOwed float64
-		for _, s := range req.ManualSplits {
-			if participatingEmails.IsMember(s.UserEmail) {
+		for i, s := range req.ManualSplits {
+			if participatingEmails.IsMember(util.NormalizeEmail(s.UserEmail)) {
 				return fmt.Errorf("duplicate email found in manual splits: %s", s.UserEmail)
 			}
-			participatingEmails.Add(s.UserEmail)
-			totalOwed += s.AmountOwed
+			participatingEmails.Add(util.NormalizeEmail(s.UserEmail))
+			if s.AmountOwed < 0 {
+				return &FieldError{Field: fmt.Sprintf("manual_splits[%d].amount_owed", i), Message: "must not be negative"}
+			}
+			if err := checkDecimalPlaces(fmt.Sprintf("manual_splits[%d].amount_owed", i), s.AmountOwed, currency, exp); err != nil {
+				return err
+			}
+			if err := checkAmountPaid("manual_splits", i, s.AmountPaid, currency, exp); err != nil {
+				return err
+			}
+			if s.AmountOwed == 0 && s.AmountPaid == 0 && !req.AllowZeroAmounts {
+				return zeroAmountError("manual_splits", i, s.UserEmail)
+			}
+		}
+		// Whether the amounts owed add up is for the service's validation policy to decide
+	case service.SplitMethodDays:
+		if len(req.DaysSplits) == 0 {
+			return fmt.Errorf("days split requires participants with join and leave dates")
+		}
+		for i, s := range req.DaysSplits {
+			if participatingEmails.IsMember(util.NormalizeEmail(s.UserEmail)) {
+				return fmt.Errorf("duplicate email found in days splits: %s", s.UserEmail)
+			}
+			participatingEmails.Add(util.NormalizeEmail(s.UserEmail))
+			if _, err := service.StayDays(s.JoinDate, s.LeaveDate); err != nil {
+				return fmt.Errorf("days split for %s: %w", s.UserEmail, err)
+			}
+			if err := checkAmountPaid("days_splits", i, s.AmountPaid, currency, exp); err != nil {
+				return err
+			}
 		}
-		if totalOwed != req.TotalAmount {
-			return fmt.Errorf("total amount owed across all splits (%.2f) does not match total expense amount (%.2f)", totalOwed, req.TotalAmount)
+	case service.SplitMethodWeighted:
+		if len(req.WeightedSplits) == 0 {
+			return fmt.Errorf("weighted split requires participants")
+		}
+		for i, s := range req.WeightedSplits {
+			if participatingEmails.IsMember(util.NormalizeEmail(s.UserEmail)) {
+				return fmt.Errorf("duplicate email found in weighted splits: %s", s.UserEmail)
+			}
+			participatingEmails.Add(util.NormalizeEmail(s.UserEmail))
+			if err := checkAmountPaid("weighted_splits", i, s.AmountPaid, currency, exp); err != nil {
+				return err
+			}
 		}
 	default:
 		return fmt.Errorf("unsupported split method")
 	}
 
-	if !participatingEmails.IsMember(req.CreatedByEmail) {
+	if max := h.limits.MaxParticipants; max > 0 && len(*participatingEmails) > max {
+		return fmt.Errorf("%w: expense has %d participants, at most %d are allowed", ErrTooManyParticipants, len(*participatingEmails), max)
+	}
 
-		return fmt.Errorf("created_by user (%s) must be included in the split participants", req.CreatedByEmail)
+	if req.PayerOnly {
+		if participatingEmails.IsMember(util.NormalizeEmail(req.CreatedByEmail)) {
+			return &FieldError{Field: "payer_only", Message: fmt.Sprintf("created_by user (%s) pays on behalf of the participants, so can't be one", req.CreatedByEmail)}
+		}
+		if field, i := firstAmountPaid(req); field != "" {
+			return &FieldError{Field: fmt.Sprintf("%s[%d].amount_paid", field, i), Message: "must be left out, the created_by user pays the whole of a payer-only expense"}
+		}
+	} else if !participatingEmails.IsMember(util.NormalizeEmail(req.CreatedByEmail)) {
+		return fmt.Errorf("created_by user (%s) must be included in the split participants, or set payer_only", req.CreatedByEmail)
+	}
+
+	if l := req.Location; l != nil {
+		if !validCoordinates(l.Latitude, l.Longitude) {
+			return fmt.Errorf("location: latitude must be within [-90, 90] and longitude within [-180, 180]")
+		}
+		if err := checkTextLength("location.place_name", l.PlaceName); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// checkSplitFields refuses participants given in a split array other than the one split_method
+// reads, which would otherwise be silently dropped.
+func checkSplitFields(req service.CreateExpenseRequest) error {
+	arrays := []struct {
+		method service.SplitMethodType
+		field  string
+		count  int
+	}{
+		{service.SplitMethodEqual, "equal_splits", len(req.EqualSplits)},
+		{service.SplitMethodPercentage, "percentage_splits", len(req.PercentageSplits)},
+		{service.SplitMethodManual, "manual_splits", len(req.ManualSplits)},
+		{service.SplitMethodDays, "days_splits", len(req.DaysSplits)},
+		{service.SplitMethodWeighted, "weighted_splits", len(req.WeightedSplits)},
+	}
+	known := false
+	for _, a := range arrays {
+		known = known || a.method == req.SplitMethod
+	}
+	if !known {
+		return nil // Reported as an unsupported split method
+	}
+	for _, a := range arrays {
+		if a.method != req.SplitMethod && a.count > 0 {
+			return &FieldError{Field: a.field, Message: fmt.Sprintf("must be left out when split_method is %q", req.SplitMethod)}
+		}
+	}
+	return nil
+}
+
+// firstAmountPaid returns the split array and index of the first participant with an amount paid,
+// or "" if nobody paid.
+func firstAmountPaid(req service.CreateExpenseRequest) (string, int) {
+	var paid []float64
+	field := ""
+	switch req.SplitMethod {
+	case service.SplitMethodEqual:
+		field = "equal_splits"
+		for _, s := range req.EqualSplits {
+			paid = append(paid, s.AmountPaid)
+		}
+	case service.SplitMethodPercentage:
+		field = "percentage_splits"
+		for _, s := range req.PercentageSplits {
+			paid = append(paid, s.AmountPaid)
+		}
+	case service.SplitMethodManual:
+		field = "manual_splits"
+		for _, s := range req.ManualSplits {
+			paid = append(paid, s.AmountPaid)
+		}
+	case service.SplitMethodDays:
+		field = "days_splits"
+		for _, s := range req.DaysSplits {
+			paid = append(paid, s.AmountPaid)
+		}
+	case service.SplitMethodWeighted:
+		field = "weighted_splits"
+		for _, s := range req.WeightedSplits {
+			paid = append(paid, s.AmountPaid)
+		}
+	}
+	for i, p := range paid {
+		if p != 0 {
+			return field, i
+		}
+	}
+	return "", 0
+}
+
+func checkAmountPaid(field string, i int, amountPaid float64, currency string, exp int) error {
+	if amountPaid < 0 {
+		return &FieldError{Field: fmt.Sprintf("%s[%d].amount_paid", field, i), Message: "must not be negative"}
+	}
+	return checkDecimalPlaces(fmt.Sprintf("%s[%d].amount_paid", field, i), amountPaid, currency, exp)
+}
+
+// checkDecimalPlaces refuses an amount finer than the currency's minor unit of exp decimal places,
+// such as 12.345 INR.
+func checkDecimalPlaces(field string, amount float64, currency string, exp int) error {
+	if util.RoundToCurrency(amount, exp) != amount {
+		return &FieldError{Field: field, Message: fmt.Sprintf("has more decimal places than %s allows (%d)", currency, exp)}
+	}
+	return nil
+}
+
+func zeroAmountError(field string, i int, email string) error {
+	return &FieldError{Field: fmt.Sprintf("%s[%d]", field, i), Message: fmt.Sprintf("%s neither owes nor paid anything; leave them out or set allow_zero_amounts", email)}
+}
+
+const (
+	defaultNearbyRadius = 500.0   // Meters
+	maxNearbyRadius     = 50000.0 // Meters; wider searches are better served by the full history
+)
+
+func validCoordinates(latitude, longitude float64) bool {
+	return latitude >= -90 && latitude <= 90 && longitude >= -180 && longitude <= 180
+}
+
+// NearbyExpensesHandler answers "what did we spend around here" for a user, given lat, lng and an
+// optional radius in meters.
+func (h *ExpenseHandler) NearbyExpensesHandler(w http.ResponseWriter, r *http.Request) {
+	userEmail, err := emailParam(r)
+	if err != nil {
+		response.Error(w, r, "Invalid user email", http.StatusBadRequest)
+		return
+	}
+	if userEmail == "" {
+		response.Error(w, r, "User email is required", http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	latitude, latErr := strconv.ParseFloat(q.Get("lat"), 64)
+	longitude, lngErr := strconv.ParseFloat(q.Get("lng"), 64)
+	if latErr != nil || lngErr != nil || !validCoordinates(latitude, longitude) {
+		response.Error(w, r, "lat and lng are required and must be valid coordinates", http.StatusBadRequest)
+		return
+	}
+	radius := defaultNearbyRadius
+	if raw := q.Get("radius"); raw != "" {
+		if radius, err = strconv.ParseFloat(raw, 64); err != nil || radius <= 0 || radius > maxNearbyRadius {
+			response.Error(w, r, fmt.Sprintf("radius must be a distance in meters up to %g", maxNearbyRadius), http.StatusBadRequest)
+			return
+		}
+	}
+
+	expenses, err := h.expenseService.GetNearbyExpenses(userEmail, latitude, longitude, radius)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, expenses)
+}
+
 func (h *ExpenseHandler) GetOutstandingBalancesHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	userEmail := vars["email"]
+	userEmail, err := emailParam(r)
+	if err != nil {
+		response.Error(w, r, "Invalid user email", http.StatusBadRequest)
+		return
+	}
 	if userEmail == "" {
-		http.Error(w, "User email is required", http.StatusBadRequest)
+		response.Error(w, r, "User email is required", http.StatusBadRequest)
 		return
 	}
 
 	balances, err := h.expenseService.GetOutstandingBalancesForUser(userEmail)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		serverError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(balances)
+	response.JSON(w, r, http.StatusOK, balances)
 }
 
-func (h *ExpenseHandler) GetOverallOutstandingBalanceHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	userEmail := vars["email"]
+// OwedToMeHandler lists what others owe the user, largest first.
+func (h *ExpenseHandler) OwedToMeHandler(w http.ResponseWriter, r *http.Request) {
+	h.balanceList(w, r, repository.OwedToUser)
+}
+
+// IOweHandler lists what the user owes others, largest first.
+func (h *ExpenseHandler) IOweHandler(w http.ResponseWriter, r *http.Request) {
+	h.balanceList(w, r, repository.OwedByUser)
+}
+
+func (h *ExpenseHandler) balanceList(w http.ResponseWriter, r *http.Request, direction repository.DebtDirection) {
+	userEmail, err := emailParam(r)
+	if err != nil {
+		response.Error(w, r, "Invalid user email", http.StatusBadRequest)
+		return
+	}
 	if userEmail == "" {
-		http.Error(w, "User email is required", http.StatusBadRequest)
+		response.Error(w, r, "User email is required", http.StatusBadRequest)
 		return
 	}
 
-	overallBalance, err := h.expenseService.GetOverallOutstandingBalance(userEmail)
+	limit, offset, paged, err := pageParams(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, r, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Create a simple response struct for the float64 balance
-	response := struct {
-		OverallBalance float64 `json:"overall_balance"`
-	}{
-		OverallBalance: overallBalance,
+	list, err := h.expenseService.GetBalanceList(userEmail, direction, limit, offset)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	var page *response.Pagination
+	if paged {
+		page = &response.Pagination{Limit: limit, Offset: offset, Total: list.Count}
+	}
+	response.AmountList(w, r, http.StatusOK, list.Balances, list.Total, page)
+}
+
+func (h *ExpenseHandler) GetOverallOutstandingBalanceHandler(w http.ResponseWriter, r *http.Request) {
+	userEmail, err := emailParam(r)
+	if err != nil {
+		response.Error(w, r, "Invalid user email", http.StatusBadRequest)
+		return
+	}
+	if userEmail == "" {
+		response.Error(w, r, "User email is required", http.StatusBadRequest)
+		return
+	}
+
+	overallBalance, err := h.expenseService.GetOverallOutstandingBalance(userEmail)
+	if err != nil {
+		serverError(w, r, err)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	response.JSON(w, r, http.StatusOK, OverallBalanceResponse{OverallBalance: overallBalance})
 }
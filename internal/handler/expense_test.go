@@ -4,50 +4,37 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
 	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/repository/memory"
+	"github.com/aadithya-md/split-expense/internal/response"
 	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/aadithya-md/split-expense/internal/util"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
-type MockExpenseService struct {
-	mock.Mock
-}
-
-func (m *MockExpenseService) CreateExpense(req service.CreateExpenseRequest) (*repository.Expense, error) {
-	args := m.Called(req)
-	return args.Get(0).(*repository.Expense), args.Error(1)
-}
-
-func (m *MockExpenseService) GetExpense(id int) (*repository.Expense, error) {
-	args := m.Called(id)
-	return args.Get(0).(*repository.Expense), args.Error(1)
-}
-
-func (m *MockExpenseService) GetExpensesForUser(userEmail string) ([]repository.UserExpenseView, error) {
-	args := m.Called(userEmail)
-	return args.Get(0).([]repository.UserExpenseView), args.Error(1)
-}
-
-func (m *MockExpenseService) GetOutstandingBalancesForUser(userEmail string) ([]service.UserBalanceView, error) {
-	args := m.Called(userEmail)
-	return args.Get(0).([]service.UserBalanceView), args.Error(1)
-}
-
-func (m *MockExpenseService) GetOverallOutstandingBalance(userEmail string) (float64, error) {
-	args := m.Called(userEmail)
-	return args.Get(0).(float64), args.Error(1)
-}
+// seededCurrencies are the currencies the migrations seed, so amounts are checked as they are on a
+// server.
+var seededCurrencies = func() util.Currencies {
+	exponents, err := memory.NewStore(memory.Options{}).Currencies.GetCurrencyExponents()
+	if err != nil {
+		panic(err)
+	}
+	return util.NewCurrencies(exponents)
+}()
 
 func TestExpenseHandler_CreateExpenseHandler(t *testing.T) {
-	mockService := new(MockExpenseService)
-	expenseHandler := NewExpenseHandler(mockService)
+	mockService := new(servicemock.ExpenseService)
+	expenseHandler := NewExpenseHandler(mockService, ExpenseLimits{}, seededCurrencies)
 
 	// Test case 1: Successful Equal Split expense creation
 	{ // Block for scoping
@@ -72,8 +59,7 @@ func TestExpenseHandler_CreateExpenseHandler(t *testing.T) {
 		mockService.On("CreateExpense", requestBody).Return(expectedExpense, nil).Once()
 
 		reqBodyBytes, _ := json.Marshal(requestBody)
-		req := httptest.NewRequest("POST", "/expenses", bytes.NewBuffer(reqBodyBytes))
-		req.Header.Set("Content-Type", "application/json")
+		req := jsonRequest("POST", "/expenses", bytes.NewBuffer(reqBodyBytes))
 		rr := httptest.NewRecorder()
 		router := mux.NewRouter()
 		router.HandleFunc("/expenses", expenseHandler.CreateExpenseHandler).Methods("POST")
@@ -81,15 +67,14 @@ func TestExpenseHandler_CreateExpenseHandler(t *testing.T) {
 
 		assert.Equal(t, http.StatusCreated, rr.Code)
 		expectedResponseBytes, _ := json.Marshal(expectedExpense)
-		assert.JSONEq(t, string(expectedResponseBytes), rr.Body.String())
+		assert.JSONEq(t, string(expectedResponseBytes), dataJSON(t, rr))
 		mockService.AssertExpectations(t)
 	}
 
 	// Test case 2: Invalid request body (missing fields - description)
 	{ // Block for scoping
 		reqBodyBytes := []byte(`{"total_amount":100,"created_by_email":"alice@example.com","split_method":"equal","equal_splits":[]}`)
-		req := httptest.NewRequest("POST", "/expenses", bytes.NewBuffer(reqBodyBytes))
-		req.Header.Set("Content-Type", "application/json")
+		req := jsonRequest("POST", "/expenses", bytes.NewBuffer(reqBodyBytes))
 		rr := httptest.NewRecorder()
 		router := mux.NewRouter()
 		router.HandleFunc("/expenses", expenseHandler.CreateExpenseHandler).Methods("POST")
@@ -114,8 +99,7 @@ func TestExpenseHandler_CreateExpenseHandler(t *testing.T) {
 		mockService.On("CreateExpense", requestBody).Return((*repository.Expense)(nil), errors.New("failed to create expense in service")).Once()
 
 		reqBodyBytes, _ := json.Marshal(requestBody)
-		req := httptest.NewRequest("POST", "/expenses", bytes.NewBuffer(reqBodyBytes))
-		req.Header.Set("Content-Type", "application/json")
+		req := jsonRequest("POST", "/expenses", bytes.NewBuffer(reqBodyBytes))
 		rr := httptest.NewRecorder()
 		router := mux.NewRouter()
 		router.HandleFunc("/expenses", expenseHandler.CreateExpenseHandler).Methods("POST")
@@ -140,8 +124,7 @@ func TestExpenseHandler_CreateExpenseHandler(t *testing.T) {
 		}
 
 		reqBodyBytes, _ := json.Marshal(requestBody)
-		req := httptest.NewRequest("POST", "/expenses", bytes.NewBuffer(reqBodyBytes))
-		req.Header.Set("Content-Type", "application/json")
+		req := jsonRequest("POST", "/expenses", bytes.NewBuffer(reqBodyBytes))
 		rr := httptest.NewRecorder()
 		router := mux.NewRouter()
 		router.HandleFunc("/expenses", expenseHandler.CreateExpenseHandler).Methods("POST")
@@ -152,7 +135,7 @@ func TestExpenseHandler_CreateExpenseHandler(t *testing.T) {
 		mockService.AssertNotCalled(t, "CreateExpense")
 	}
 
-	// Test case 5: Manual Split with amount_owed mismatch (validation error)
+	// Test case 5: Manual Split with amount_owed mismatch, refused by the service's validation policy
 	{ // Block for scoping
 		requestBody := service.CreateExpenseRequest{
 			Description:    "Invalid Manual Test",
@@ -164,10 +147,11 @@ func TestExpenseHandler_CreateExpenseHandler(t *testing.T) {
 				{UserEmail: "bob@example.com", AmountOwed: 30.00, AmountPaid: 0.00},
 			},
 		}
+		mismatch := fmt.Errorf("%w: total amount owed across all splits (90.00) does not match total expense amount (100.00)", service.ErrAmountMismatch)
+		mockService.On("CreateExpense", requestBody).Return(nil, mismatch).Once()
 
 		reqBodyBytes, _ := json.Marshal(requestBody)
-		req := httptest.NewRequest("POST", "/expenses", bytes.NewBuffer(reqBodyBytes))
-		req.Header.Set("Content-Type", "application/json")
+		req := jsonRequest("POST", "/expenses", bytes.NewBuffer(reqBodyBytes))
 		rr := httptest.NewRecorder()
 		router := mux.NewRouter()
 		router.HandleFunc("/expenses", expenseHandler.CreateExpenseHandler).Methods("POST")
@@ -175,7 +159,7 @@ func TestExpenseHandler_CreateExpenseHandler(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
 		assert.Contains(t, rr.Body.String(), "total amount owed across all splits (90.00) does not match total expense amount (100.00)")
-		mockService.AssertNotCalled(t, "CreateExpense")
+		mockService.AssertExpectations(t)
 	}
 
 	// Test case 6: Duplicate email in Equal Splits (validation error)
@@ -192,8 +176,7 @@ func TestExpenseHandler_CreateExpenseHandler(t *testing.T) {
 		}
 
 		reqBodyBytes, _ := json.Marshal(requestBody)
-		req := httptest.NewRequest("POST", "/expenses", bytes.NewBuffer(reqBodyBytes))
-		req.Header.Set("Content-Type", "application/json")
+		req := jsonRequest("POST", "/expenses", bytes.NewBuffer(reqBodyBytes))
 		rr := httptest.NewRecorder()
 		router := mux.NewRouter()
 		router.HandleFunc("/expenses", expenseHandler.CreateExpenseHandler).Methods("POST")
@@ -217,8 +200,7 @@ func TestExpenseHandler_CreateExpenseHandler(t *testing.T) {
 		}
 
 		reqBodyBytes, _ := json.Marshal(requestBody)
-		req := httptest.NewRequest("POST", "/expenses", bytes.NewBuffer(reqBodyBytes))
-		req.Header.Set("Content-Type", "application/json")
+		req := jsonRequest("POST", "/expenses", bytes.NewBuffer(reqBodyBytes))
 		rr := httptest.NewRecorder()
 		router := mux.NewRouter()
 		router.HandleFunc("/expenses", expenseHandler.CreateExpenseHandler).Methods("POST")
@@ -228,11 +210,322 @@ func TestExpenseHandler_CreateExpenseHandler(t *testing.T) {
 		assert.Contains(t, rr.Body.String(), "created_by user (alice@example.com) must be included in the split participants")
 		mockService.AssertNotCalled(t, "CreateExpense")
 	}
+
+	// Test case 8: Days split with a leave date before the join date (validation error)
+	{
+		requestBody := service.CreateExpenseRequest{
+			Description:    "Villa",
+			TotalAmount:    900.00,
+			CreatedByEmail: "alice@example.com",
+			SplitMethod:    service.SplitMethodDays,
+			DaysSplits: []service.DaysSplitRequest{
+				{UserEmail: "alice@example.com", JoinDate: "2026-07-01", LeaveDate: "2026-07-10", AmountPaid: 900.00},
+				{UserEmail: "bob@example.com", JoinDate: "2026-07-06", LeaveDate: "2026-07-03"},
+			},
+		}
+
+		reqBodyBytes, _ := json.Marshal(requestBody)
+		req := jsonRequest("POST", "/expenses", bytes.NewBuffer(reqBodyBytes))
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+		router.HandleFunc("/expenses", expenseHandler.CreateExpenseHandler).Methods("POST")
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "leave date 2026-07-03 is before join date 2026-07-06")
+		mockService.AssertNotCalled(t, "CreateExpense")
+	}
+
+	// Test case 9: Amount finer than the currency's minor unit (validation error)
+	{
+		requestBody := service.CreateExpenseRequest{
+			Description:    "Ramen",
+			TotalAmount:    1500.50,
+			Currency:       "JPY",
+			CreatedByEmail: "alice@example.com",
+			SplitMethod:    service.SplitMethodEqual,
+			EqualSplits: []service.EqualSplitRequest{
+				{UserEmail: "alice@example.com", AmountPaid: 1500.50},
+			},
+		}
+
+		reqBodyBytes, _ := json.Marshal(requestBody)
+		req := jsonRequest("POST", "/expenses", bytes.NewBuffer(reqBodyBytes))
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+		router.HandleFunc("/expenses", expenseHandler.CreateExpenseHandler).Methods("POST")
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "total_amount has more decimal places than JPY allows (0)")
+		mockService.AssertNotCalled(t, "CreateExpense")
+	}
+
+	// Test case 10: Amounts sent as decimal strings are held to the currency's minor unit too
+	{
+		body := `{"description":"Dinner","total_amount":"30.00","created_by_email":"alice@example.com","split_method":"manual",` +
+			`"manual_splits":[{"user_email":"alice@example.com","amount_owed":"14.995","amount_paid":"30"},{"user_email":"bob@example.com","amount_owed":"15.005"}]}`
+		req := jsonRequest("POST", "/expenses", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+		router.HandleFunc("/expenses", expenseHandler.CreateExpenseHandler).Methods("POST")
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"field":"manual_splits[0].amount_owed"`)
+		mockService.AssertNotCalled(t, "CreateExpense")
+	}
+
+	// Test case 11: A currency balances can't be kept in
+	{
+		requestBody := service.CreateExpenseRequest{
+			Description:    "Ramen",
+			TotalAmount:    1500,
+			Currency:       "JPY",
+			CreatedByEmail: "alice@example.com",
+			SplitMethod:    service.SplitMethodEqual,
+			EqualSplits:    []service.EqualSplitRequest{{UserEmail: "alice@example.com", AmountPaid: 1500}},
+		}
+		mockService.On("CreateExpense", requestBody).Return((*repository.Expense)(nil), fmt.Errorf("%w: JPY", service.ErrUnsupportedCurrency)).Once()
+
+		reqBodyBytes, _ := json.Marshal(requestBody)
+		rr := httptest.NewRecorder()
+		expenseHandler.CreateExpenseHandler(rr, jsonRequest("POST", "/expenses", bytes.NewBuffer(reqBodyBytes)))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		mockService.AssertExpectations(t)
+	}
+}
+
+func TestExpenseHandler_CreateExpenseHandler_Explain(t *testing.T) {
+	mockService := new(servicemock.ExpenseService)
+	expenseHandler := NewExpenseHandler(mockService, ExpenseLimits{}, seededCurrencies)
+	requestBody := service.CreateExpenseRequest{
+		Description:    "Lunch",
+		TotalAmount:    100,
+		CreatedByEmail: "alice@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits:    []service.EqualSplitRequest{{UserEmail: "alice@example.com", AmountPaid: 100}, {UserEmail: "bob@example.com"}},
+	}
+	post := func(query string) *httptest.ResponseRecorder {
+		reqBodyBytes, _ := json.Marshal(requestBody)
+		rr := httptest.NewRecorder()
+		expenseHandler.CreateExpenseHandler(rr, jsonRequest("POST", "/expenses"+query, bytes.NewBuffer(reqBodyBytes)))
+		return rr
+	}
+
+	// Test case 1: The query flag asks the service for an explanation
+	explained := requestBody
+	explained.Explain = true
+	mockService.On("CreateExpense", explained).Return(&repository.Expense{ID: 1}, nil).Once()
+	assert.Equal(t, http.StatusCreated, post("?explain=true").Code)
+	mockService.AssertExpectations(t)
+
+	// Test case 2: Anything but a boolean is refused
+	assert.Equal(t, http.StatusBadRequest, post("?explain=please").Code)
+	mockService.AssertNumberOfCalls(t, "CreateExpense", 1)
+}
+
+func TestExpenseHandler_CreateExpenseHandler_Limits(t *testing.T) {
+	mockService := new(servicemock.ExpenseService)
+	expenseHandler := NewExpenseHandler(mockService, ExpenseLimits{MaxParticipants: 2, MaxTotalAmount: 1000, MaxDescriptionLength: 10}, seededCurrencies)
+
+	post := func(requestBody service.CreateExpenseRequest) *httptest.ResponseRecorder {
+		reqBodyBytes, _ := json.Marshal(requestBody)
+		req := jsonRequest("POST", "/expenses", bytes.NewBuffer(reqBodyBytes))
+		rr := httptest.NewRecorder()
+		expenseHandler.CreateExpenseHandler(rr, req)
+		return rr
+	}
+	base := func() service.CreateExpenseRequest {
+		return service.CreateExpenseRequest{
+			Description:    "Lunch",
+			TotalAmount:    100,
+			CreatedByEmail: "alice@example.com",
+			SplitMethod:    service.SplitMethodEqual,
+			EqualSplits: []service.EqualSplitRequest{
+				{UserEmail: "alice@example.com", AmountPaid: 100},
+				{UserEmail: "bob@example.com"},
+			},
+		}
+	}
+
+	// Test case 1: Too many participants
+	{
+		req := base()
+		req.EqualSplits = append(req.EqualSplits, service.EqualSplitRequest{UserEmail: "charlie@example.com"})
+		rr := post(req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "too_many_participants")
+	}
+
+	// Test case 2: Total including tip is over the cap
+	{
+		req := base()
+		req.TotalAmount = 950
+		req.EqualSplits[0].AmountPaid = 1045
+		req.TipPercentage = 10
+		rr := post(req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "total_amount_too_large")
+	}
+
+	// Test case 3: Description is too long
+	{
+		req := base()
+		req.Description = "Lunch at Corner Dhaba"
+		rr := post(req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "description_too_long")
+	}
+
+	// Test case 4: Description length is counted in characters, not bytes
+	{
+		req := base()
+		req.Description = "Café crème"
+		mockService.On("CreateExpense", req).Return(&repository.Expense{ID: 1}, nil).Once()
+		assert.Equal(t, http.StatusCreated, post(req).Code)
+	}
+
+	// Test case 5: A description of only control characters is missing
+	{
+		req := base()
+		req.Description = "\x00\x1b\n"
+		assert.Equal(t, http.StatusBadRequest, post(req).Code)
+	}
+
+	// Test case 6: Tags are held to the text length limit
+	{
+		req := base()
+		req.Tag = strings.Repeat("🍕", util.MaxTextLength+1)
+		rr := post(req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"field":"tag"`)
+	}
+
+	mockService.AssertNumberOfCalls(t, "CreateExpense", 1)
+}
+
+func TestExpenseHandler_CreateExpenseHandler_SplitCrossChecks(t *testing.T) {
+	mockService := new(servicemock.ExpenseService)
+	expenseHandler := NewExpenseHandler(mockService, ExpenseLimits{}, seededCurrencies)
+
+	post := func(requestBody service.CreateExpenseRequest) *httptest.ResponseRecorder {
+		reqBodyBytes, _ := json.Marshal(requestBody)
+		rr := httptest.NewRecorder()
+		expenseHandler.CreateExpenseHandler(rr, jsonRequest("POST", "/expenses", bytes.NewBuffer(reqBodyBytes)))
+		return rr
+	}
+	manual := func() service.CreateExpenseRequest {
+		return service.CreateExpenseRequest{
+			Description:    "Groceries",
+			TotalAmount:    100,
+			CreatedByEmail: "alice@example.com",
+			SplitMethod:    service.SplitMethodManual,
+			ManualSplits: []service.ManualSplitRequest{
+				{UserEmail: "alice@example.com", AmountOwed: 60, AmountPaid: 100},
+				{UserEmail: "bob@example.com", AmountOwed: 40},
+			},
+		}
+	}
+	fieldOf := func(rr *httptest.ResponseRecorder) string {
+		var env response.Envelope
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &env))
+		require.NotNil(t, env.Error)
+		assert.Equal(t, CodeInvalidField, env.Error.Code)
+		return env.Error.Field
+	}
+
+	// Test case 1: Splits for a method other than split_method
+	{
+		req := manual()
+		req.EqualSplits = []service.EqualSplitRequest{{UserEmail: "carol@example.com"}}
+		rr := post(req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, "equal_splits", fieldOf(rr))
+		assert.Contains(t, errorMessage(t, rr), `must be left out when split_method is "manual"`)
+	}
+
+	// Test case 2: A participant who neither owes nor paid
+	{
+		req := manual()
+		req.ManualSplits = append(req.ManualSplits, service.ManualSplitRequest{UserEmail: "carol@example.com"})
+		rr := post(req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, "manual_splits[2]", fieldOf(rr))
+
+		req.AllowZeroAmounts = true
+		mockService.On("CreateExpense", req).Return(&repository.Expense{ID: 1}, nil).Once()
+		assert.Equal(t, http.StatusCreated, post(req).Code)
+	}
+
+	// Test case 3: Zero percentages are refused the same way, unless the participant paid
+	{
+		req := service.CreateExpenseRequest{
+			Description:    "Cab",
+			TotalAmount:    30,
+			CreatedByEmail: "alice@example.com",
+			SplitMethod:    service.SplitMethodPercentage,
+			PercentageSplits: []service.PercentageSplitRequest{
+				{UserEmail: "alice@example.com", Percentage: 100},
+				{UserEmail: "bob@example.com"},
+			},
+		}
+		rr := post(req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, "percentage_splits[1]", fieldOf(rr))
+
+		req.PercentageSplits[1].AmountPaid = 30
+		mockService.On("CreateExpense", req).Return(&repository.Expense{ID: 2}, nil).Once()
+		assert.Equal(t, http.StatusCreated, post(req).Code)
+	}
+
+	// Test case 4: Negative amounts name the field
+	{
+		req := manual()
+		req.ManualSplits[1].AmountPaid = -5
+		assert.Equal(t, "manual_splits[1].amount_paid", fieldOf(post(req)))
+	}
+
+	// Test case 5: A payer-only creator is left out of the splits and pays it all
+	{
+		req := service.CreateExpenseRequest{
+			Description:    "Museum tickets",
+			TotalAmount:    90,
+			CreatedByEmail: "alice@example.com",
+			SplitMethod:    service.SplitMethodEqual,
+			EqualSplits:    []service.EqualSplitRequest{{UserEmail: "bob@example.com"}, {UserEmail: "carol@example.com"}},
+			PayerOnly:      true,
+		}
+		mockService.On("CreateExpense", req).Return(&repository.Expense{ID: 3}, nil).Once()
+		assert.Equal(t, http.StatusCreated, post(req).Code)
+
+		paid := req
+		paid.EqualSplits = []service.EqualSplitRequest{{UserEmail: "bob@example.com", AmountPaid: 90}, {UserEmail: "carol@example.com"}}
+		assert.Equal(t, "equal_splits[0].amount_paid", fieldOf(post(paid)))
+
+		included := req
+		included.EqualSplits = append([]service.EqualSplitRequest{{UserEmail: "alice@example.com"}}, req.EqualSplits...)
+		assert.Equal(t, "payer_only", fieldOf(post(included)))
+	}
+
+	// Test case 6: Only the known balance strategies are accepted
+	{
+		req := manual()
+		req.BalanceStrategy = "round-robin"
+		assert.Equal(t, "balance_strategy", fieldOf(post(req)))
+
+		req.BalanceStrategy = service.BalanceStrategyPairwiseNetting
+		mockService.On("CreateExpense", req).Return(&repository.Expense{ID: 4}, nil).Once()
+		assert.Equal(t, http.StatusCreated, post(req).Code)
+	}
+
+	mockService.AssertExpectations(t)
 }
 
 func TestExpenseHandler_GetExpensesForUserHandler(t *testing.T) {
-	mockService := new(MockExpenseService)
-	expenseHandler := NewExpenseHandler(mockService)
+	mockService := new(servicemock.ExpenseService)
+	expenseHandler := NewExpenseHandler(mockService, ExpenseLimits{}, seededCurrencies)
 
 	// Test Case 1: Successful retrieval of expenses for a user
 	{
@@ -252,7 +545,7 @@ func TestExpenseHandler_GetExpensesForUserHandler(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, rr.Code)
 		var actualExpenses []repository.UserExpenseView
-		json.NewDecoder(rr.Body).Decode(&actualExpenses)
+		decodeData(t, rr, &actualExpenses)
 		// Compare fields individually due to time.Time comparison issues
 		assert.Equal(t, len(expectedExpenses), len(actualExpenses))
 		if len(expectedExpenses) == len(actualExpenses) {
@@ -282,11 +575,262 @@ func TestExpenseHandler_GetExpensesForUserHandler(t *testing.T) {
 		//		assert.Contains(t, rr.Body.String(), "Failed to retrieve expenses")
 		mockService.AssertExpectations(t)
 	}
+
+	// Test Case 3: Grouped by month
+	{
+		userEmail := "alice@example.com"
+		expenses := []repository.UserExpenseView{
+			{Date: time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC), Description: "Dinner", TotalAmount: 50, Currency: "INR", Share: 25},
+			{Date: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Description: "Cab", TotalAmount: 10, Currency: "INR", Share: -5},
+			{Date: time.Date(2026, 2, 14, 0, 0, 0, 0, time.UTC), Description: "Flowers", TotalAmount: 30, Currency: "INR", Share: 15},
+		}
+		mockService.On("GetExpensesForUser", userEmail).Return(expenses, nil).Once()
+
+		req := httptest.NewRequest("GET", "/expenses/by-user/"+userEmail+"?view=grouped", nil)
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+		router.HandleFunc("/expenses/by-user/{email}", expenseHandler.GetExpensesForUserHandler).Methods("GET")
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var months []service.ExpenseMonth
+		decodeData(t, rr, &months)
+		assert.Len(t, months, 2)
+		if len(months) == 2 {
+			assert.Equal(t, "2026-03", months[0].Month)
+			assert.Equal(t, []service.MonthSubtotal{{Currency: "INR", TotalAmount: 60, Share: 20}}, months[0].Subtotals)
+			assert.Len(t, months[0].Expenses, 2)
+			assert.Equal(t, "2026-02", months[1].Month)
+		}
+		mockService.AssertExpectations(t)
+	}
+
+	// Test Case 4: Unknown view
+	{
+		req := httptest.NewRequest("GET", "/expenses/by-user/alice@example.com?view=table", nil)
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+		router.HandleFunc("/expenses/by-user/{email}", expenseHandler.GetExpensesForUserHandler).Methods("GET")
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	}
+
+	// Test Case 5: Running balance on request
+	{
+		userEmail := "alice@example.com"
+		expenses := []repository.UserExpenseView{
+			{ExpenseID: 2, Description: "Dinner", TotalAmount: 50, Share: 25},
+			{ExpenseID: 1, Description: "Cab", TotalAmount: 10, Share: -5},
+		}
+		mockService.On("GetExpensesForUser", userEmail).Return(expenses, nil).Once()
+
+		req := httptest.NewRequest("GET", "/expenses/by-user/"+userEmail+"?running_balance=true", nil)
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+		router.HandleFunc("/expenses/by-user/{email}", expenseHandler.GetExpensesForUserHandler).Methods("GET")
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var actual []repository.UserExpenseView
+		decodeData(t, rr, &actual)
+		if assert.Len(t, actual, 2) && assert.NotNil(t, actual[0].RunningBalance) {
+			assert.Equal(t, 20.0, *actual[0].RunningBalance)
+			assert.Equal(t, -5.0, *actual[1].RunningBalance)
+		}
+		mockService.AssertExpectations(t)
+	}
+
+	// Test Case 6: A page of the list, placed in the meta
+	{
+		userEmail := "alice@example.com"
+		expenses := []repository.UserExpenseView{{ExpensePublicID: "exp-2"}, {ExpensePublicID: "exp-1"}}
+		mockService.On("GetExpensePageForUser", userEmail, 2, 1, false).Return(expenses, 3, nil).Once()
+
+		req := httptest.NewRequest("GET", "/expenses/by-user/"+userEmail+"?limit=2&offset=1", nil)
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+		router.HandleFunc("/expenses/by-user/{email}", expenseHandler.GetExpensesForUserHandler).Methods("GET")
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var env struct {
+			Data []repository.UserExpenseView `json:"data"`
+			Meta response.Meta                `json:"meta"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&env))
+		if assert.Len(t, env.Data, 2) {
+			assert.Equal(t, "exp-2", env.Data[0].ExpensePublicID)
+		}
+		assert.Equal(t, &response.Pagination{Limit: 2, Offset: 1, Total: 3}, env.Meta.Pagination)
+		mockService.AssertExpectations(t)
+	}
+
+	// Test Case 7: Bad page parameters
+	{
+		for _, query := range []string{"limit=0", "offset=3", "limit=2&offset=-1", "limit=2&view=grouped"} {
+			req := httptest.NewRequest("GET", "/expenses/by-user/alice@example.com?"+query, nil)
+			rr := httptest.NewRecorder()
+			router := mux.NewRouter()
+			router.HandleFunc("/expenses/by-user/{email}", expenseHandler.GetExpensesForUserHandler).Methods("GET")
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	}
+}
+
+func TestExpenseHandler_DisputeExpenseHandler(t *testing.T) {
+	mockService := new(servicemock.ExpenseService)
+	expenseHandler := NewExpenseHandler(mockService, ExpenseLimits{}, seededCurrencies)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/expenses/{id}/dispute", expenseHandler.DisputeExpenseHandler).Methods("POST")
+
+	// Test case 1: Successful dispute
+	{
+		requestBody := service.DisputeExpenseRequest{UserEmail: "bob@example.com", Reason: "Wrong amount"}
+		expected := &repository.Expense{ID: 7, Description: "Dinner", Status: repository.ExpenseDisputed, DisputeReason: "Wrong amount"}
+		mockService.On("DisputeExpense", 7, requestBody).Return(expected, nil).Once()
+
+		reqBodyBytes, _ := json.Marshal(requestBody)
+		req := jsonRequest("POST", "/expenses/7/dispute", bytes.NewBuffer(reqBodyBytes))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		expectedResponseBytes, _ := json.Marshal(expected)
+		assert.JSONEq(t, string(expectedResponseBytes), dataJSON(t, rr))
+		mockService.AssertExpectations(t)
+	}
+
+	// Test case 2: Reason is required
+	{
+		reqBodyBytes, _ := json.Marshal(service.DisputeExpenseRequest{UserEmail: "bob@example.com"})
+		req := jsonRequest("POST", "/expenses/7/dispute", bytes.NewBuffer(reqBodyBytes))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertNumberOfCalls(t, "DisputeExpense", 1)
+	}
+
+	// Test case 3: Already disputed
+	{
+		requestBody := service.DisputeExpenseRequest{UserEmail: "bob@example.com", Reason: "Again"}
+		mockService.On("DisputeExpense", 7, requestBody).Return((*repository.Expense)(nil), fmt.Errorf("failed to dispute expense 7: %w", repository.ErrInvalidExpenseTransition)).Once()
+
+		reqBodyBytes, _ := json.Marshal(requestBody)
+		req := jsonRequest("POST", "/expenses/7/dispute", bytes.NewBuffer(reqBodyBytes))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		mockService.AssertExpectations(t)
+	}
+}
+
+func TestExpenseHandler_UndoExpenseHandler(t *testing.T) {
+	mockService := new(servicemock.ExpenseService)
+	expenseHandler := NewExpenseHandler(mockService, ExpenseLimits{}, seededCurrencies)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/expenses/{id}", expenseHandler.UndoExpenseHandler).Methods("DELETE")
+
+	// Test case 1: Successful undo
+	{
+		mockService.On("UndoExpense", 7, service.UndoExpenseRequest{UserEmail: "alice@example.com"}).Return(nil).Once()
+
+		req := httptest.NewRequest("DELETE", "/expenses/7?user_email=alice@example.com", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Empty(t, rr.Body.String())
+		mockService.AssertExpectations(t)
+	}
+
+	// Test case 2: user_email is required
+	{
+		req := httptest.NewRequest("DELETE", "/expenses/7", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertNumberOfCalls(t, "UndoExpense", 1)
+	}
+
+	// Test case 3: Window has passed
+	{
+		mockService.On("UndoExpense", 7, service.UndoExpenseRequest{UserEmail: "alice@example.com"}).Return(fmt.Errorf("%w: too late", service.ErrExpenseNotUndoable)).Once()
+
+		req := httptest.NewRequest("DELETE", "/expenses/7?user_email=alice@example.com", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		mockService.AssertExpectations(t)
+	}
+
+	// Test case 4: Locked by a confirmed settlement
+	{
+		mockService.On("UndoExpense", 8, service.UndoExpenseRequest{UserEmail: "alice@example.com"}).Return(fmt.Errorf("failed to undo expense 8: %w", repository.ErrExpenseLocked)).Once()
+
+		req := httptest.NewRequest("DELETE", "/expenses/8?user_email=alice@example.com", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		mockService.AssertExpectations(t)
+	}
+}
+
+func TestExpenseHandler_UnlockExpenseHandler(t *testing.T) {
+	mockService := new(servicemock.ExpenseService)
+	expenseHandler := NewExpenseHandler(mockService, ExpenseLimits{}, seededCurrencies)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/expenses/{id}/unlock", expenseHandler.UnlockExpenseHandler).Methods("POST")
+	post := func(id, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, jsonRequest("POST", "/expenses/"+id+"/unlock", bytes.NewBufferString(body)))
+		return rr
+	}
+	alice := service.UnlockExpenseRequest{UserEmail: "alice@example.com"}
+
+	// Test case 1: Successful unlock
+	{
+		settledBy := 3
+		mockService.On("UnlockExpense", 7, alice).Return(&repository.Expense{ID: 7, Description: "Dinner", SettledBy: &settledBy}, nil).Once()
+
+		rr := post("7", `{"user_email":"alice@example.com"}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"settled_by":3`)
+		assert.NotContains(t, rr.Body.String(), "locked_at")
+	}
+
+	// Test case 2: Not locked, or not the creator
+	{
+		mockService.On("UnlockExpense", 8, alice).Return((*repository.Expense)(nil), fmt.Errorf("failed to unlock expense 8: %w", repository.ErrExpenseNotLocked)).Once()
+		assert.Equal(t, http.StatusConflict, post("8", `{"user_email":"alice@example.com"}`).Code)
+
+		bob := service.UnlockExpenseRequest{UserEmail: "bob@example.com"}
+		mockService.On("UnlockExpense", 7, bob).Return((*repository.Expense)(nil), service.ErrNotExpenseParticipant).Once()
+		assert.Equal(t, http.StatusForbidden, post("7", `{"user_email":"bob@example.com"}`).Code)
+	}
+
+	// Test case 3: Bad input
+	{
+		assert.Equal(t, http.StatusBadRequest, post("x", `{"user_email":"alice@example.com"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, post("7", `{}`).Code)
+	}
+
+	mockService.AssertExpectations(t)
 }
 
 func TestExpenseHandler_GetOutstandingBalancesHandler(t *testing.T) {
-	mockService := new(MockExpenseService)
-	expenseHandler := NewExpenseHandler(mockService)
+	mockService := new(servicemock.ExpenseService)
+	expenseHandler := NewExpenseHandler(mockService, ExpenseLimits{}, seededCurrencies)
 
 	// Test Case 1: Successful retrieval of outstanding balances for a user
 	{
@@ -307,7 +851,7 @@ func TestExpenseHandler_GetOutstandingBalancesHandler(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, rr.Code)
 		var actualBalances []service.UserBalanceView
-		json.NewDecoder(rr.Body).Decode(&actualBalances)
+		decodeData(t, rr, &actualBalances)
 		assert.Equal(t, len(expectedBalances), len(actualBalances))
 		if len(expectedBalances) == len(actualBalances) {
 			for i := range expectedBalances {
@@ -337,9 +881,57 @@ func TestExpenseHandler_GetOutstandingBalancesHandler(t *testing.T) {
 	}
 }
 
+func TestExpenseHandler_BalanceListHandlers(t *testing.T) {
+	mockService := new(servicemock.ExpenseService)
+	expenseHandler := NewExpenseHandler(mockService, ExpenseLimits{}, seededCurrencies)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/balances/owed-to-me/{email}", expenseHandler.OwedToMeHandler).Methods("GET")
+	router.HandleFunc("/balances/i-owe/{email}", expenseHandler.IOweHandler).Methods("GET")
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	// Test case 1: The whole list of what Alice owes
+	list := &service.BalanceList{Count: 1, Total: 10, Balances: []service.UserBalanceView{{WithUserEmail: "bob@example.com", Amount: 10}}}
+	mockService.On("GetBalanceList", "alice@example.com", repository.OwedByUser, 0, 0).Return(list, nil).Once()
+	rr := get("/balances/i-owe/alice@example.com")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var env struct {
+		Data []service.UserBalanceView `json:"data"`
+		Meta response.Meta             `json:"meta"`
+	}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&env))
+	assert.Equal(t, list.Balances, env.Data)
+	if assert.NotNil(t, env.Meta.TotalAmount) {
+		assert.Equal(t, 10.0, *env.Meta.TotalAmount)
+	}
+	assert.Nil(t, env.Meta.Pagination)
+
+	// Test case 2: A page of what Alice is owed, placed in the meta with the total of the whole list
+	mockService.On("GetBalanceList", "alice@example.com", repository.OwedToUser, 1, 1).Return(&service.BalanceList{Count: 3, Total: 60, Balances: []service.UserBalanceView{}}, nil).Once()
+	rr = get("/balances/owed-to-me/alice@example.com?limit=1&offset=1")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	env.Meta = response.Meta{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&env))
+	assert.Equal(t, &response.Pagination{Limit: 1, Offset: 1, Total: 3}, env.Meta.Pagination)
+	assert.Equal(t, 60.0, *env.Meta.TotalAmount)
+
+	// Test case 3: Bad page parameters
+	assert.Equal(t, http.StatusBadRequest, get("/balances/owed-to-me/alice@example.com?limit=0").Code)
+
+	// Test case 4: Service error
+	mockService.On("GetBalanceList", "ghost@example.com", repository.OwedToUser, 0, 0).Return(nil, errors.New("user not found")).Once()
+	assert.Equal(t, http.StatusInternalServerError, get("/balances/owed-to-me/ghost@example.com").Code)
+
+	mockService.AssertExpectations(t)
+}
+
 func TestExpenseHandler_GetOverallOutstandingBalanceHandler(t *testing.T) {
-	mockService := new(MockExpenseService)
-	expenseHandler := NewExpenseHandler(mockService)
+	mockService := new(servicemock.ExpenseService)
+	expenseHandler := NewExpenseHandler(mockService, ExpenseLimits{}, seededCurrencies)
 
 	// Test Case 1: Successful retrieval of overall outstanding balance for a user
 	{
@@ -358,7 +950,7 @@ func TestExpenseHandler_GetOverallOutstandingBalanceHandler(t *testing.T) {
 		var actualResponse struct {
 			OverallBalance float64 `json:"overall_balance"`
 		}
-		json.NewDecoder(rr.Body).Decode(&actualResponse)
+		decodeData(t, rr, &actualResponse)
 		assert.Equal(t, expectedBalance, actualResponse.OverallBalance)
 		mockService.AssertExpectations(t)
 	}
@@ -379,3 +971,32 @@ func TestExpenseHandler_GetOverallOutstandingBalanceHandler(t *testing.T) {
 		mockService.AssertExpectations(t)
 	}
 }
+
+func TestExpenseHandler_NearbyExpensesHandler(t *testing.T) {
+	mockService := new(servicemock.ExpenseService)
+	expenseHandler := NewExpenseHandler(mockService, ExpenseLimits{}, seededCurrencies)
+	router := mux.NewRouter()
+	router.HandleFunc("/expenses/by-user/{email}/nearby", expenseHandler.NearbyExpensesHandler).Methods("GET")
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/expenses/by-user/alice@example.com/nearby?"+query, nil))
+		return rr
+	}
+
+	// Test case 1: The radius defaults to 500 meters
+	mockService.On("GetNearbyExpenses", "alice@example.com", 12.9716, 77.5946, 500.0).Return([]repository.NearbyExpense{{DistanceMeters: 20}}, nil).Once()
+	assert.Equal(t, http.StatusOK, get("lat=12.9716&lng=77.5946").Code)
+
+	// Test case 2: Explicit radius
+	mockService.On("GetNearbyExpenses", "alice@example.com", 12.9716, 77.5946, 2000.0).Return([]repository.NearbyExpense{}, nil).Once()
+	assert.Equal(t, http.StatusOK, get("lat=12.9716&lng=77.5946&radius=2000").Code)
+
+	// Test case 3: Missing or impossible coordinates and radii
+	assert.Equal(t, http.StatusBadRequest, get("lat=12.9716").Code)
+	assert.Equal(t, http.StatusBadRequest, get("lat=91&lng=0").Code)
+	assert.Equal(t, http.StatusBadRequest, get("lat=0&lng=0&radius=-5").Code)
+	assert.Equal(t, http.StatusBadRequest, get("lat=0&lng=0&radius=100000").Code)
+
+	mockService.AssertExpectations(t)
+}
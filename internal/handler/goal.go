@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+)
+
+type GoalHandler struct {
+	goalService service.GoalService
+}
+
+func NewGoalHandler(goalService service.GoalService) *GoalHandler {
+	return &GoalHandler{goalService: goalService}
+}
+
+func (h *GoalHandler) CreateGoalHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeJSON[service.CreateGoalRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	if req.UserEmail == "" || req.Deadline == "" {
+		response.Error(w, r, "user_email and deadline are required", http.StatusBadRequest)
+		return
+	}
+
+	goal, err := h.goalService.CreateGoal(req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidGoal) {
+			response.Error(w, r, err.Error(), http.StatusBadRequest)
+			return
+		}
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusCreated, goal)
+}
+
+// GetGoalProgressHandler reports progress on each of the user's goals, with who to nudge to reach them.
+func (h *GoalHandler) GetGoalProgressHandler(w http.ResponseWriter, r *http.Request) {
+	userEmail, err := emailParam(r)
+	if err != nil {
+		response.Error(w, r, "Invalid user email", http.StatusBadRequest)
+		return
+	}
+	if userEmail == "" {
+		response.Error(w, r, "User email is required", http.StatusBadRequest)
+		return
+	}
+
+	progress, err := h.goalService.GetGoalProgress(userEmail)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, progress)
+}
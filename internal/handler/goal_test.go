@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoalHandler_CreateGoalHandler(t *testing.T) {
+	mockService := new(servicemock.GoalService)
+	goalHandler := NewGoalHandler(mockService)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		goalHandler.CreateGoalHandler(rr, jsonRequest("POST", "/goals", bytes.NewBufferString(body)))
+		return rr
+	}
+
+	// Test case 1: Successful creation
+	req := service.CreateGoalRequest{UserEmail: "alice@example.com", Deadline: "2026-12-01"}
+	mockService.On("CreateGoal", req).Return(&repository.Goal{ID: 1, UserID: 1}, nil).Once()
+	assert.Equal(t, http.StatusCreated, post(`{"user_email":"alice@example.com","deadline":"2026-12-01"}`).Code)
+
+	// Test case 2: Missing fields
+	assert.Equal(t, http.StatusBadRequest, post(`{"user_email":"alice@example.com"}`).Code)
+
+	// Test case 3: The service rejects the goal
+	req = service.CreateGoalRequest{UserEmail: "alice@example.com", Deadline: "2020-01-01"}
+	mockService.On("CreateGoal", req).Return(nil, fmt.Errorf("%w: deadline must be in the future", service.ErrInvalidGoal)).Once()
+	assert.Equal(t, http.StatusBadRequest, post(`{"user_email":"alice@example.com","deadline":"2020-01-01"}`).Code)
+
+	mockService.AssertExpectations(t)
+}
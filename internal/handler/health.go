@@ -1,12 +1,45 @@
 package handler
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
 )
 
-// HealthCheckHandler returns a 200 OK for health checks.
-func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
-	fmt.Fprint(w, "healthy\n")
+// healthCheckTimeout bounds how long a verbose health check waits on dependencies.
+const healthCheckTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	healthService service.HealthService
+	verbose       bool
+}
+
+// NewHealthHandler returns a health handler. Dependency details are only served when verbose is set,
+// since they describe the deployment's internals.
+func NewHealthHandler(healthService service.HealthService, verbose bool) *HealthHandler {
+	return &HealthHandler{healthService: healthService, verbose: verbose}
+}
+
+// HealthCheckHandler returns a 200 OK for liveness checks. With ?verbose=true, and verbose mode
+// enabled, it checks every dependency and returns the report as JSON, with a 503 if any is down.
+func (h *HealthHandler) HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.verbose || r.URL.Query().Get("verbose") != "true" {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "healthy\n")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+	report := h.healthService.Check(ctx)
+
+	status := http.StatusOK
+	if report.Status != service.HealthUp {
+		status = http.StatusServiceUnavailable
+	}
+	response.JSON(w, r, status, report)
 }
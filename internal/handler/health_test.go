@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHealthHandler_HealthCheckHandler(t *testing.T) {
+	mockService := new(servicemock.HealthService)
+
+	// Test case 1: Plain liveness check doesn't touch dependencies
+	{
+		rr := httptest.NewRecorder()
+		NewHealthHandler(mockService, true).HealthCheckHandler(rr, httptest.NewRequest("GET", "/health", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "healthy\n", rr.Body.String())
+	}
+
+	// Test case 2: Verbose details are ignored unless enabled
+	{
+		rr := httptest.NewRecorder()
+		NewHealthHandler(mockService, false).HealthCheckHandler(rr, httptest.NewRequest("GET", "/health?verbose=true", nil))
+		assert.Equal(t, "healthy\n", rr.Body.String())
+	}
+
+	// Test case 3: A failing dependency turns into a 503 with the report
+	{
+		report := service.HealthReport{Status: service.HealthDown, Components: []service.ComponentHealth{
+			{Name: "database", Status: service.HealthDown, Error: "connection refused"},
+		}}
+		mockService.On("Check", mock.Anything).Return(report).Once()
+
+		rr := httptest.NewRecorder()
+		NewHealthHandler(mockService, true).HealthCheckHandler(rr, httptest.NewRequest("GET", "/health?verbose=true", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		var actual service.HealthReport
+		decodeData(t, rr, &actual)
+		assert.Equal(t, report, actual)
+	}
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// UploadChunkRequest carries one chunk of an import file. Data is base64 in JSON, so a chunk can be
+// at most about three quarters of maxBodyBytes.
+type UploadChunkRequest struct {
+	Data []byte `json:"data"`
+}
+
+type ImportHandler struct {
+	importService service.ImportService
+}
+
+func NewImportHandler(importService service.ImportService) *ImportHandler {
+	return &ImportHandler{importService: importService}
+}
+
+// CreateImportHandler opens an import session to upload a CSV file to in chunks.
+func (h *ImportHandler) CreateImportHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeJSON[service.CreateImportRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	if req.UserEmail == "" {
+		response.Error(w, r, "user_email is required", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.importService.CreateImport(req)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusCreated, session)
+}
+
+// GetImportHandler reports an import session, with the chunks received so far for a client resuming
+// an upload.
+func (h *ImportHandler) GetImportHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid import ID", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.importService.GetImport(id)
+	if err != nil {
+		writeImportError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, session)
+}
+
+// UploadChunkHandler stores one chunk of the file. Chunks may arrive in any order and be resent.
+func (h *ImportHandler) UploadChunkHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid import ID", http.StatusBadRequest)
+		return
+	}
+	seq, err := strconv.Atoi(mux.Vars(r)["seq"])
+	if err != nil {
+		response.Error(w, r, "Invalid chunk number", http.StatusBadRequest)
+		return
+	}
+
+	req, err := decodeJSON[UploadChunkRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if len(req.Data) == 0 {
+		response.Error(w, r, "data is required", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.importService.UploadChunk(id, seq, req.Data)
+	if err != nil {
+		writeImportError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, session)
+}
+
+// CommitImportHandler queues the import once every chunk is in. Progress is on the session's job.
+func (h *ImportHandler) CommitImportHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid import ID", http.StatusBadRequest)
+		return
+	}
+
+	req, err := decodeJSON[service.CommitImportRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	session, err := h.importService.CommitImport(id, req)
+	if err != nil {
+		writeImportError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusAccepted, session)
+}
+
+func writeImportError(w http.ResponseWriter, r *http.Request, err error) {
+	if writeQuotaError(w, r, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, repository.ErrImportSessionNotFound):
+		response.Error(w, r, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrImportNotOpen):
+		response.Error(w, r, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrInvalidImport):
+		response.Error(w, r, err.Error(), http.StatusBadRequest)
+	default:
+		serverError(w, r, err)
+	}
+}
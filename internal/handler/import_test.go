@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportHandler_UploadChunkHandler(t *testing.T) {
+	mockService := new(servicemock.ImportService)
+	importHandler := NewImportHandler(mockService)
+	router := mux.NewRouter()
+	router.HandleFunc("/imports/{id}/chunks/{seq}", importHandler.UploadChunkHandler).Methods("PUT")
+
+	put := func(path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, jsonRequest("PUT", path, bytes.NewBufferString(body)))
+		return rr
+	}
+
+	// Test case 1: The chunk arrives base64-encoded and is stored as sent
+	mockService.On("UploadChunk", 1, 0, []byte("description,amount\n")).Return(&repository.ImportSession{ID: 1, ReceivedChunks: []int{0}}, nil).Once()
+	rr := put("/imports/1/chunks/0", `{"data":"ZGVzY3JpcHRpb24sYW1vdW50Cg=="}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"received_chunks":[0]`)
+
+	// Test case 2: Bad chunk numbers and empty chunks are refused
+	assert.Equal(t, http.StatusBadRequest, put("/imports/1/chunks/first", `{"data":"eA=="}`).Code)
+	assert.Equal(t, http.StatusBadRequest, put("/imports/1/chunks/1", `{"data":""}`).Code)
+
+	// Test case 3: Too late for more chunks
+	mockService.On("UploadChunk", 1, 1, []byte("x")).Return(nil, service.ErrImportNotOpen).Once()
+	assert.Equal(t, http.StatusConflict, put("/imports/1/chunks/1", `{"data":"eA=="}`).Code)
+
+	// Test case 4: No such session
+	mockService.On("UploadChunk", 2, 0, []byte("x")).Return(nil, repository.ErrImportSessionNotFound).Once()
+	assert.Equal(t, http.StatusNotFound, put("/imports/2/chunks/0", `{"data":"eA=="}`).Code)
+
+	mockService.AssertExpectations(t)
+}
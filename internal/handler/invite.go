@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+)
+
+type InviteHandler struct {
+	inviteService service.InviteService
+}
+
+func NewInviteHandler(inviteService service.InviteService) *InviteHandler {
+	return &InviteHandler{inviteService: inviteService}
+}
+
+func (h *InviteHandler) CreateExpenseInviteHandler(w http.ResponseWriter, r *http.Request) {
+	expenseID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid expense ID", http.StatusBadRequest)
+		return
+	}
+
+	req, err := decodeJSON[service.CreateExpenseInviteRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if req.UserEmail == "" {
+		response.Error(w, r, "user_email is required", http.StatusBadRequest)
+		return
+	}
+
+	invite, err := h.inviteService.CreateExpenseInvite(expenseID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrExpenseNotFound):
+			response.Error(w, r, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrNotExpenseParticipant):
+			response.Error(w, r, err.Error(), http.StatusForbidden)
+		case errors.Is(err, service.ErrInvitesDisabled):
+			response.Error(w, r, err.Error(), http.StatusServiceUnavailable)
+		default:
+			serverError(w, r, err)
+		}
+		return
+	}
+
+	response.JSON(w, r, http.StatusCreated, invite)
+}
+
+func (h *InviteHandler) GetExpenseInviteHandler(w http.ResponseWriter, r *http.Request) {
+	noStore(w)
+	invite, err := h.inviteService.GetExpenseInvite(mux.Vars(r)["token"])
+	if err != nil {
+		writeInviteError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, invite)
+}
+
+// InviteQRCodeHandler serves the invite's link as a QR code for participants to scan.
+func (h *InviteHandler) InviteQRCodeHandler(w http.ResponseWriter, r *http.Request) {
+	noStore(w)
+	png, err := h.inviteService.InviteQRCode(mux.Vars(r)["token"])
+	if err != nil {
+		writeInviteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.WriteHeader(http.StatusOK)
+	w.Write(png)
+}
+
+func (h *InviteHandler) ClaimExpenseInviteHandler(w http.ResponseWriter, r *http.Request) {
+	noStore(w)
+	req, err := decodeJSON[service.ClaimExpenseInviteRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if req.UserEmail == "" {
+		response.Error(w, r, "user_email is required", http.StatusBadRequest)
+		return
+	}
+
+	claim, err := h.inviteService.ClaimExpenseInvite(mux.Vars(r)["token"], req)
+	if err != nil {
+		writeInviteError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, claim)
+}
+
+// noStore keeps a request's token, which is its credential, out of caches and other sites' logs.
+func noStore(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+}
+
+// writeInviteError reports a failure to follow an invite link. A link to an expense that has since
+// been undone looks the same as one that never existed.
+func writeInviteError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInviteToken), errors.Is(err, service.ErrInvitesDisabled), errors.Is(err, repository.ErrExpenseNotFound):
+		response.Error(w, r, service.ErrInvalidInviteToken.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrInviteExpired):
+		response.Error(w, r, err.Error(), http.StatusGone)
+	case errors.Is(err, service.ErrNotExpenseParticipant):
+		response.Error(w, r, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrEventArchived):
+		response.Error(w, r, err.Error(), http.StatusConflict)
+	default:
+		serverError(w, r, err)
+	}
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInviteHandler_CreateExpenseInviteHandler(t *testing.T) {
+	mockService := new(servicemock.InviteService)
+	inviteHandler := NewInviteHandler(mockService)
+
+	post := func(id, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r := jsonRequest("POST", "/expenses/"+id+"/invites", bytes.NewBufferString(body))
+		inviteHandler.CreateExpenseInviteHandler(rr, mux.SetURLVars(r, map[string]string{"id": id}))
+		return rr
+	}
+	alice := service.CreateExpenseInviteRequest{UserEmail: "alice@example.com"}
+
+	// Test case 1: Success
+	mockService.On("CreateExpenseInvite", 1, alice).Return(&service.CreatedExpenseInvite{ExpenseID: 1, Token: "t"}, nil).Once()
+	assert.Equal(t, http.StatusCreated, post("1", `{"user_email":"alice@example.com"}`).Code)
+
+	// Test case 2: Failures
+	mockService.On("CreateExpenseInvite", 2, alice).Return(nil, fmt.Errorf("%w: 2", repository.ErrExpenseNotFound)).Once()
+	assert.Equal(t, http.StatusNotFound, post("2", `{"user_email":"alice@example.com"}`).Code)
+	mockService.On("CreateExpenseInvite", 3, alice).Return(nil, service.ErrNotExpenseParticipant).Once()
+	assert.Equal(t, http.StatusForbidden, post("3", `{"user_email":"alice@example.com"}`).Code)
+	mockService.On("CreateExpenseInvite", 4, alice).Return(nil, service.ErrInvitesDisabled).Once()
+	assert.Equal(t, http.StatusServiceUnavailable, post("4", `{"user_email":"alice@example.com"}`).Code)
+
+	// Test case 3: Bad input
+	assert.Equal(t, http.StatusBadRequest, post("x", `{"user_email":"alice@example.com"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post("1", `{}`).Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestInviteHandler_TokenHandlers(t *testing.T) {
+	mockService := new(servicemock.InviteService)
+	inviteHandler := NewInviteHandler(mockService)
+
+	serve := func(h http.HandlerFunc, method, token, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r := jsonRequest(method, "/invites/"+token, bytes.NewBufferString(body))
+		h(rr, mux.SetURLVars(r, map[string]string{"token": token}))
+		return rr
+	}
+
+	// Test case 1: The QR code is a PNG nobody should cache
+	mockService.On("InviteQRCode", "good").Return([]byte("\x89PNG"), nil).Once()
+	rr := serve(inviteHandler.InviteQRCodeHandler, "GET", "good", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+	// Test case 2: Bad and expired links
+	mockService.On("GetExpenseInvite", "bad").Return(nil, service.ErrInvalidInviteToken).Once()
+	assert.Equal(t, http.StatusNotFound, serve(inviteHandler.GetExpenseInviteHandler, "GET", "bad", "").Code)
+	mockService.On("GetExpenseInvite", "undone").Return(nil, fmt.Errorf("%w: 5", repository.ErrExpenseNotFound)).Once()
+	assert.Equal(t, http.StatusNotFound, serve(inviteHandler.GetExpenseInviteHandler, "GET", "undone", "").Code)
+	mockService.On("InviteQRCode", "old").Return(nil, service.ErrInviteExpired).Once()
+	assert.Equal(t, http.StatusGone, serve(inviteHandler.InviteQRCodeHandler, "GET", "old", "").Code)
+
+	// Test case 3: Claiming
+	carol := service.ClaimExpenseInviteRequest{UserEmail: "carol@example.com"}
+	mockService.On("ClaimExpenseInvite", "good", carol).Return(&service.InviteClaim{ExpenseID: 5}, nil).Once()
+	assert.Equal(t, http.StatusOK, serve(inviteHandler.ClaimExpenseInviteHandler, "POST", "good", `{"user_email":"carol@example.com"}`).Code)
+	mockService.On("ClaimExpenseInvite", "good", carol).Return(nil, service.ErrNotExpenseParticipant).Once()
+	assert.Equal(t, http.StatusForbidden, serve(inviteHandler.ClaimExpenseInviteHandler, "POST", "good", `{"user_email":"carol@example.com"}`).Code)
+	mockService.On("ClaimExpenseInvite", "good", carol).Return(nil, service.ErrEventArchived).Once()
+	assert.Equal(t, http.StatusConflict, serve(inviteHandler.ClaimExpenseInviteHandler, "POST", "good", `{"user_email":"carol@example.com"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(inviteHandler.ClaimExpenseInviteHandler, "POST", "good", `{}`).Code)
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+)
+
+type JobHandler struct {
+	jobService service.JobService
+}
+
+func NewJobHandler(jobService service.JobService) *JobHandler {
+	return &JobHandler{jobService: jobService}
+}
+
+// GetJobHandler reports the status of a background job so clients can poll for its completion.
+func (h *JobHandler) GetJobHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		response.Error(w, r, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.jobService.GetJob(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrJobNotFound) {
+			response.Error(w, r, err.Error(), http.StatusNotFound)
+			return
+		}
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, job)
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobHandler_GetJobHandler(t *testing.T) {
+	mockService := new(servicemock.JobService)
+	jobHandler := NewJobHandler(mockService)
+	router := mux.NewRouter()
+	router.HandleFunc("/jobs/{id}", jobHandler.GetJobHandler).Methods("GET")
+
+	// Test case 1: Job found
+	{
+		expected := &repository.Job{ID: 7, Type: "export", Status: repository.JobDead, Attempts: 5, MaxAttempts: 5, LastError: "timeout"}
+		mockService.On("GetJob", int64(7)).Return(expected, nil).Once()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/jobs/7", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var actual repository.Job
+		decodeData(t, rr, &actual)
+		assert.Equal(t, repository.JobDead, actual.Status)
+		assert.Equal(t, "timeout", actual.LastError)
+	}
+
+	// Test case 2: Job not found
+	{
+		mockService.On("GetJob", int64(8)).Return(nil, repository.ErrJobNotFound).Once()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/jobs/8", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	}
+
+	// Test case 3: Invalid ID
+	{
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/jobs/abc", nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	}
+
+	mockService.AssertExpectations(t)
+}
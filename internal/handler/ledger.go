@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+)
+
+type LedgerHandler struct {
+	ledgerService service.LedgerService
+}
+
+func NewLedgerHandler(ledgerService service.LedgerService) *LedgerHandler {
+	return &LedgerHandler{ledgerService: ledgerService}
+}
+
+// GetLedgerHandler returns the user's ledger entries and the balances they add up to, as of the
+// RFC 3339 time in the as_of query parameter or now.
+func (h *LedgerHandler) GetLedgerHandler(w http.ResponseWriter, r *http.Request) {
+	userEmail, err := emailParam(r)
+	if err != nil {
+		response.Error(w, r, "Invalid user email", http.StatusBadRequest)
+		return
+	}
+	if userEmail == "" {
+		response.Error(w, r, "User email is required", http.StatusBadRequest)
+		return
+	}
+
+	var asOf time.Time
+	if v := r.URL.Query().Get("as_of"); v != "" {
+		if asOf, err = time.Parse(time.RFC3339, v); err != nil {
+			response.Error(w, r, "as_of must be an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+	}
+
+	statement, err := h.ledgerService.GetStatement(userEmail, asOf)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, statement)
+}
+
+// AgingReportHandler buckets the user's outstanding balances by how long they have gone unpaid.
+func (h *LedgerHandler) AgingReportHandler(w http.ResponseWriter, r *http.Request) {
+	userEmail, err := emailParam(r)
+	if err != nil {
+		response.Error(w, r, "Invalid user email", http.StatusBadRequest)
+		return
+	}
+	if userEmail == "" {
+		response.Error(w, r, "User email is required", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.ledgerService.GetAgingReport(userEmail)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, report)
+}
+
+// CheckBalancesHandler lists the balances that no longer match the ledger.
+func (h *LedgerHandler) CheckBalancesHandler(w http.ResponseWriter, r *http.Request) {
+	drifts, err := h.ledgerService.CheckBalances()
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, drifts)
+}
+
+// RebuildBalancesHandler brings drifted balances back in line with the ledger and lists what it corrected.
+func (h *LedgerHandler) RebuildBalancesHandler(w http.ResponseWriter, r *http.Request) {
+	drifts, err := h.ledgerService.RebuildBalances()
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, drifts)
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLedgerHandler_GetLedgerHandler(t *testing.T) {
+	mockService := new(servicemock.LedgerService)
+	ledgerHandler := NewLedgerHandler(mockService)
+
+	send := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r := mux.SetURLVars(httptest.NewRequest("GET", "/ledger/by-user/alice@example.com"+query, nil), map[string]string{"email": "alice@example.com"})
+		ledgerHandler.GetLedgerHandler(rr, r)
+		return rr
+	}
+
+	// Test case 1: As of a given time
+	asOf := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+	mockService.On("GetStatement", "alice@example.com", mock.MatchedBy(asOf.Equal)).
+		Return(&service.LedgerStatement{UserEmail: "alice@example.com", AsOf: asOf, Overall: 12}, nil).Once()
+	rr := send("?as_of=2025-03-01T09:30:00Z")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"overall":12`)
+
+	// Test case 2: Without a time, up to now
+	mockService.On("GetStatement", "alice@example.com", time.Time{}).Return(&service.LedgerStatement{UserEmail: "alice@example.com"}, nil).Once()
+	rr = send("")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Test case 3: A malformed time
+	rr = send("?as_of=2025-03-01")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestLedgerHandler_RebuildBalancesHandler(t *testing.T) {
+	mockService := new(servicemock.LedgerService)
+	ledgerHandler := NewLedgerHandler(mockService)
+
+	mockService.On("RebuildBalances").Return([]repository.BalanceDrift{{User1ID: 1, User2ID: 2, Materialized: 40, Ledger: 30}}, nil).Once()
+	rr := httptest.NewRecorder()
+	ledgerHandler.RebuildBalancesHandler(rr, jsonRequest("POST", "/admin/ledger/rebuild", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[{"user1_id":1,"user2_id":2,"materialized":40,"ledger":30}]`, dataJSON(t, rr))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestLedgerHandler_AgingReportHandler(t *testing.T) {
+	mockService := new(servicemock.LedgerService)
+	ledgerHandler := NewLedgerHandler(mockService)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/balances/aging/{email}", ledgerHandler.AgingReportHandler).Methods("GET")
+
+	// Test case 1: The user's balances by age
+	{
+		report := &service.AgingReport{
+			UserEmail: "alice@example.com",
+			Balances: []service.BalanceAge{{
+				WithUserEmail: "bob@example.com",
+				Amount:        30,
+				OldestSource:  repository.LedgerSource{Type: repository.LedgerExpense, ID: 4},
+				AgeDays:       45,
+				Bucket:        service.Aging31To60,
+			}},
+		}
+		mockService.On("GetAgingReport", "alice@example.com").Return(report, nil).Once()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/balances/aging/alice@example.com", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var got service.AgingReport
+		decodeData(t, rr, &got)
+		assert.Equal(t, *report, got)
+	}
+
+	// Test case 2: Unknown user
+	{
+		mockService.On("GetAgingReport", "ghost@example.com").Return(nil, fmt.Errorf("user with email ghost@example.com not found")).Once()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/balances/aging/ghost@example.com", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	}
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+type LoanHandler struct {
+	loanService service.LoanService
+}
+
+func NewLoanHandler(loanService service.LoanService) *LoanHandler {
+	return &LoanHandler{loanService: loanService}
+}
+
+func (h *LoanHandler) CreateLoanHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeJSON[service.CreateLoanRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	if err := h.validateCreateLoanRequest(req); err != nil {
+		response.Error(w, r, "Invalid loan data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	loan, err := h.loanService.CreateLoan(req)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusCreated, loan)
+}
+
+func (h *LoanHandler) validateCreateLoanRequest(req service.CreateLoanRequest) error {
+	if req.LenderEmail == "" || req.BorrowerEmail == "" || req.Amount <= 0 {
+		return fmt.Errorf("lender_email, borrower_email, and a positive amount are required")
+	}
+
+	if err := checkTextLength("description", req.Description); err != nil {
+		return err
+	}
+
+	if util.NormalizeEmail(req.LenderEmail) == util.NormalizeEmail(req.BorrowerEmail) {
+		return fmt.Errorf("lender and borrower must be different users")
+	}
+
+	// Balances are kept in the default currency
+	if err := checkDecimalPlaces("amount", req.Amount, util.DefaultCurrency, util.DefaultExponent); err != nil {
+		return err
+	}
+
+	if req.DueDate != "" {
+		if _, err := time.Parse(service.DateLayout, req.DueDate); err != nil {
+			return fmt.Errorf("due_date must be in YYYY-MM-DD format")
+		}
+	}
+
+	return nil
+}
+
+func (h *LoanHandler) GetLoansForUserHandler(w http.ResponseWriter, r *http.Request) {
+	userEmail, err := emailParam(r)
+	if err != nil {
+		response.Error(w, r, "Invalid user email", http.StatusBadRequest)
+		return
+	}
+	if userEmail == "" {
+		response.Error(w, r, "User email is required", http.StatusBadRequest)
+		return
+	}
+
+	loans, err := h.loanService.GetLoansForUser(userEmail)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, loans)
+}
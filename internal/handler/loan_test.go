@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoanHandler_CreateLoanHandler(t *testing.T) {
+	mockService := new(servicemock.LoanService)
+	loanHandler := NewLoanHandler(mockService)
+
+	// Test case 1: Successful loan creation
+	{
+		requestBody := service.CreateLoanRequest{LenderEmail: "alice@example.com", BorrowerEmail: "bob@example.com", Amount: 200, DueDate: "2024-12-31"}
+		expectedLoan := &repository.Loan{ID: 1, LenderID: 1, BorrowerID: 2, Amount: 200}
+		mockService.On("CreateLoan", requestBody).Return(expectedLoan, nil).Once()
+
+		reqBodyBytes, _ := json.Marshal(requestBody)
+		req := jsonRequest("POST", "/loans", bytes.NewBuffer(reqBodyBytes))
+		rr := httptest.NewRecorder()
+		loanHandler.CreateLoanHandler(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		expectedResponseBytes, _ := json.Marshal(expectedLoan)
+		assert.JSONEq(t, string(expectedResponseBytes), dataJSON(t, rr))
+		mockService.AssertExpectations(t)
+	}
+
+	// Test case 2: Lender and borrower are the same user
+	{
+		requestBody := service.CreateLoanRequest{LenderEmail: "alice@example.com", BorrowerEmail: "alice@example.com", Amount: 200}
+
+		reqBodyBytes, _ := json.Marshal(requestBody)
+		req := jsonRequest("POST", "/loans", bytes.NewBuffer(reqBodyBytes))
+		rr := httptest.NewRecorder()
+		loanHandler.CreateLoanHandler(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "lender and borrower must be different users")
+	}
+
+	// Test case 3: Malformed due date
+	{
+		requestBody := service.CreateLoanRequest{LenderEmail: "alice@example.com", BorrowerEmail: "bob@example.com", Amount: 200, DueDate: "31/12/2024"}
+
+		reqBodyBytes, _ := json.Marshal(requestBody)
+		req := jsonRequest("POST", "/loans", bytes.NewBuffer(reqBodyBytes))
+		rr := httptest.NewRecorder()
+		loanHandler.CreateLoanHandler(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "due_date must be in YYYY-MM-DD format")
+		mockService.AssertNumberOfCalls(t, "CreateLoan", 1)
+	}
+}
+
+func TestLoanHandler_GetLoansForUserHandler(t *testing.T) {
+	mockService := new(servicemock.LoanService)
+	loanHandler := NewLoanHandler(mockService)
+
+	// Test case 1: Successful retrieval
+	{
+		expectedLoans := []service.LoanView{{ID: 1, Direction: "lent", WithUserEmail: "bob@example.com", Amount: 200}}
+		mockService.On("GetLoansForUser", "alice@example.com").Return(expectedLoans, nil).Once()
+
+		req := httptest.NewRequest("GET", "/loans/by-user/alice@example.com", nil)
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+		router.HandleFunc("/loans/by-user/{email}", loanHandler.GetLoansForUserHandler).Methods("GET")
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var actualLoans []service.LoanView
+		decodeData(t, rr, &actualLoans)
+		assert.Equal(t, expectedLoans[0].Direction, actualLoans[0].Direction)
+		assert.Equal(t, expectedLoans[0].Amount, actualLoans[0].Amount)
+		mockService.AssertExpectations(t)
+	}
+
+	// Test case 2: Service returns an error
+	{
+		mockService.On("GetLoansForUser", "ghost@example.com").Return([]service.LoanView{}, errors.New("user with email ghost@example.com not found")).Once()
+
+		req := httptest.NewRequest("GET", "/loans/by-user/ghost@example.com", nil)
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+		router.HandleFunc("/loans/by-user/{email}", loanHandler.GetLoansForUserHandler).Methods("GET")
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Contains(t, rr.Body.String(), "user with email ghost@example.com not found")
+		mockService.AssertExpectations(t)
+	}
+}
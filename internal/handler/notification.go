@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// SetDigestFrequencyRequest is the body of PUT /users/{id}/digest-frequency, which is also sent back.
+type SetDigestFrequencyRequest struct {
+	Frequency repository.DigestFrequency `json:"frequency"`
+}
+
+type NotificationHandler struct {
+	digestService     service.DigestService
+	preferenceService service.PreferenceService
+}
+
+func NewNotificationHandler(digestService service.DigestService, preferenceService service.PreferenceService) *NotificationHandler {
+	return &NotificationHandler{digestService: digestService, preferenceService: preferenceService}
+}
+
+func (h *NotificationHandler) GetPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	prefs, err := h.preferenceService.GetPreferences(id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, prefs)
+}
+
+// SetPreferencesHandler updates a user's notification preferences. The body is decoded over the
+// current ones, so fields left out keep their values.
+func (h *NotificationHandler) SetPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	prefs, err := h.preferenceService.GetPreferences(id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+	if err := decodeJSONInto(w, r, prefs); err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	updated, err := h.preferenceService.SetPreferences(id, *prefs)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDigestFrequency) {
+			response.Error(w, r, err.Error(), http.StatusBadRequest)
+			return
+		}
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, updated)
+}
+
+func (h *NotificationHandler) SetDigestFrequencyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	req, err := decodeJSON[SetDigestFrequencyRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	if err := h.digestService.SetDigestFrequency(id, req.Frequency); err != nil {
+		if errors.Is(err, service.ErrInvalidDigestFrequency) {
+			response.Error(w, r, err.Error(), http.StatusBadRequest)
+			return
+		}
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, req)
+}
+
+// UnsubscribeHandler follows the unsubscribe link in a digest. It answers GET for people clicking
+// the link and POST for mail clients' one-click unsubscribe.
+func (h *NotificationHandler) UnsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	if err := h.digestService.Unsubscribe(r.URL.Query().Get("token")); err != nil {
+		if errors.Is(err, service.ErrInvalidUnsubscribeToken) {
+			response.Error(w, r, err.Error(), http.StatusNotFound)
+			return
+		}
+		serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("You will no longer receive expense digests.\n"))
+}
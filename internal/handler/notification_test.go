@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationHandler_SetDigestFrequencyHandler(t *testing.T) {
+	mockService := new(servicemock.DigestService)
+	notificationHandler := NewNotificationHandler(mockService, nil)
+
+	put := func(id, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r := jsonRequest("PUT", "/users/"+id+"/digest-frequency", bytes.NewBufferString(body))
+		notificationHandler.SetDigestFrequencyHandler(rr, mux.SetURLVars(r, map[string]string{"id": id}))
+		return rr
+	}
+
+	// Test case 1: Successful update
+	mockService.On("SetDigestFrequency", 1, repository.DigestDaily).Return(nil).Once()
+	assert.Equal(t, http.StatusOK, put("1", `{"frequency":"daily"}`).Code)
+
+	// Test case 2: Unknown frequency
+	mockService.On("SetDigestFrequency", 1, repository.DigestFrequency("hourly")).Return(fmt.Errorf("%w, got \"hourly\"", service.ErrInvalidDigestFrequency)).Once()
+	assert.Equal(t, http.StatusBadRequest, put("1", `{"frequency":"hourly"}`).Code)
+
+	// Test case 3: Bad input
+	assert.Equal(t, http.StatusBadRequest, put("x", `{"frequency":"daily"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, put("1", `{`).Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestNotificationHandler_UnsubscribeHandler(t *testing.T) {
+	mockService := new(servicemock.DigestService)
+	notificationHandler := NewNotificationHandler(mockService, nil)
+
+	unsubscribe := func(method, token string) int {
+		rr := httptest.NewRecorder()
+		notificationHandler.UnsubscribeHandler(rr, httptest.NewRequest(method, "/notifications/unsubscribe?token="+token, nil))
+		return rr.Code
+	}
+
+	// Test case 1: Both the link and one-click unsubscribe work
+	mockService.On("Unsubscribe", "good").Return(nil).Twice()
+	assert.Equal(t, http.StatusOK, unsubscribe("GET", "good"))
+	assert.Equal(t, http.StatusOK, unsubscribe("POST", "good"))
+
+	// Test case 2: Bad tokens
+	mockService.On("Unsubscribe", "bad").Return(service.ErrInvalidUnsubscribeToken).Once()
+	assert.Equal(t, http.StatusNotFound, unsubscribe("GET", "bad"))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestNotificationHandler_PreferencesHandlers(t *testing.T) {
+	mockService := new(servicemock.PreferenceService)
+	notificationHandler := NewNotificationHandler(nil, mockService)
+
+	send := func(method, id, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r := mux.SetURLVars(jsonRequest(method, "/users/"+id+"/preferences", bytes.NewBufferString(body)), map[string]string{"id": id})
+		if method == "GET" {
+			notificationHandler.GetPreferencesHandler(rr, r)
+		} else {
+			notificationHandler.SetPreferencesHandler(rr, r)
+		}
+		return rr
+	}
+
+	// Test case 1: Reading
+	// Each call gets its own copy, as the handler decodes over it
+	for i := 0; i < 4; i++ {
+		mockService.On("GetPreferences", 1).Return(repository.DefaultNotificationPreferences(1), nil).Once()
+	}
+	rr := send("GET", "1", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"channels":{"email":true,"push":true},"events":{"new_expense":true,"reminder":true,"digest":true},"digest_frequency":"weekly"}`, dataJSON(t, rr))
+
+	// Test case 2: A partial update keeps everything it leaves out
+	want := *repository.DefaultNotificationPreferences(1)
+	want.Channels.Push = false
+	mockService.On("SetPreferences", 1, want).Return(&want, nil).Once()
+	rr = send("PUT", "1", `{"channels":{"push":false}}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"push":false`)
+
+	// Test case 3: Unknown digest frequency
+	bad := *repository.DefaultNotificationPreferences(1)
+	bad.DigestFrequency = "hourly"
+	mockService.On("SetPreferences", 1, bad).Return(nil, fmt.Errorf("%w, got \"hourly\"", service.ErrInvalidDigestFrequency)).Once()
+	assert.Equal(t, http.StatusBadRequest, send("PUT", "1", `{"digest_frequency":"hourly"}`).Code)
+
+	// Test case 4: Bad input
+	assert.Equal(t, http.StatusBadRequest, send("GET", "x", "").Code)
+	assert.Equal(t, http.StatusBadRequest, send("PUT", "1", `{`).Code)
+
+	mockService.AssertExpectations(t)
+}
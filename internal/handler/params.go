@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/aadithya-md/split-expense/internal/util"
+	"github.com/gorilla/mux"
+)
+
+// emailParam returns the {email} path variable, unescaped. The router matches on the escaped path
+// so that an encoded "/" stays inside the segment, which leaves the decoding to us. A literal "+"
+// is kept as is: in a path it never means a space.
+func emailParam(r *http.Request) (string, error) {
+	return url.PathUnescape(mux.Vars(r)["email"])
+}
+
+// ByUserID serves a route keyed by {id} with a handler that expects {email}, so every by-user
+// lookup can also be made without putting an email in the URL. {id} is the user's public ID.
+func ByUserID(userService service.UserService, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		user, ok := lookupUser(w, r, userService, vars["id"])
+		if !ok {
+			return
+		}
+
+		withEmail := map[string]string{"email": url.PathEscape(user.Email)}
+		for k, v := range vars {
+			if k != "id" {
+				withEmail[k] = v
+			}
+		}
+		next(w, mux.SetURLVars(r, withEmail))
+	}
+}
+
+// PublicUserID serves a route keyed by a user's public ID in {id} with a handler that expects their
+// number there. Anything but a public ID is not found, so users can't be enumerated.
+func PublicUserID(userService service.UserService, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := lookupUser(w, r, userService, mux.Vars(r)["id"])
+		if !ok {
+			return
+		}
+		next(w, withID(r, user.ID))
+	}
+}
+
+// PublicExpenseID serves a route keyed by an expense's public ID in {id} with a handler that
+// expects its number there. Anything but a public ID is not found, so expenses can't be enumerated.
+func PublicExpenseID(expenseService service.ExpenseService, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		if !util.ValidPublicID(id) {
+			response.Error(w, r, repository.ErrExpenseNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		expense, err := expenseService.GetExpenseByPublicID(id)
+		if err != nil {
+			if errors.Is(err, repository.ErrExpenseNotFound) {
+				response.Error(w, r, err.Error(), http.StatusNotFound)
+				return
+			}
+			serverError(w, r, err)
+			return
+		}
+		next(w, withID(r, expense.ID))
+	}
+}
+
+// lookupUser finds the user the public ID publicID names, answering the request itself when there
+// is none.
+func lookupUser(w http.ResponseWriter, r *http.Request, userService service.UserService, publicID string) (*repository.User, bool) {
+	if !util.ValidPublicID(publicID) {
+		response.Error(w, r, "user not found", http.StatusNotFound)
+		return nil, false
+	}
+	user, err := userService.GetUserByPublicID(publicID)
+	if err != nil {
+		response.Error(w, r, err.Error(), http.StatusNotFound)
+		return nil, false
+	}
+	return user, true
+}
+
+// withID returns r with its {id} path variable set to id.
+func withID(r *http.Request, id int) *http.Request {
+	vars := map[string]string{}
+	for k, v := range mux.Vars(r) {
+		vars[k] = v
+	}
+	vars["id"] = strconv.Itoa(id)
+	return mux.SetURLVars(r, vars)
+}
+
+// pageParams reads the limit and offset query parameters. paged is false when neither is given, in
+// which case the whole list is wanted. A limit must be positive; the offset defaults to 0.
+func pageParams(r *http.Request) (limit, offset int, paged bool, err error) {
+	q := r.URL.Query()
+	if q.Get("limit") == "" && q.Get("offset") == "" {
+		return 0, 0, false, nil
+	}
+	if limit, err = strconv.Atoi(q.Get("limit")); err != nil || limit <= 0 {
+		return 0, 0, false, errors.New("limit must be a positive integer")
+	}
+	if v := q.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, false, errors.New("offset must be a non-negative integer")
+		}
+	}
+	return limit, offset, true, nil
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailParam(t *testing.T) {
+	for raw, want := range map[string]string{
+		"bob@example.com":           "bob@example.com",
+		"bob+trips@example.com":     "bob+trips@example.com",
+		"bob%2Btrips%40example.com": "bob+trips@example.com",
+		"a%2Fb@example.com":         "a/b@example.com",
+	} {
+		req := mux.SetURLVars(httptest.NewRequest("GET", "/", nil), map[string]string{"email": raw})
+		got, err := emailParam(req)
+		assert.NoError(t, err, raw)
+		assert.Equal(t, want, got, raw)
+	}
+
+	req := mux.SetURLVars(httptest.NewRequest("GET", "/", nil), map[string]string{"email": "bad%zz"})
+	_, err := emailParam(req)
+	assert.Error(t, err)
+}
+
+func TestByUserID(t *testing.T) {
+	mockService := new(servicemock.UserService)
+	var got string
+	h := ByUserID(mockService, func(w http.ResponseWriter, r *http.Request) {
+		got, _ = emailParam(r)
+	})
+
+	publicID := "01HZX3Q8K2M4N6P8R0T2V4W6Y8"
+	call := func(id string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h(rr, mux.SetURLVars(httptest.NewRequest("GET", "/balances/by-user-id/"+id, nil), map[string]string{"id": id}))
+		return rr
+	}
+
+	// Test case 1: The user's email is handed on
+	mockService.On("GetUserByPublicID", publicID).Return(&repository.User{ID: 7, Email: "a/b+c@example.com"}, nil).Once()
+	assert.Equal(t, http.StatusOK, call(publicID).Code)
+	assert.Equal(t, "a/b+c@example.com", got)
+
+	// Test case 2: A user's number is not found, so users can't be enumerated
+	got = ""
+	assert.Equal(t, http.StatusNotFound, call("7").Code)
+	assert.Equal(t, http.StatusNotFound, call("x").Code)
+	assert.Empty(t, got)
+
+	// Test case 3: Unknown user
+	mockService.On("GetUserByPublicID", publicID).Return((*repository.User)(nil), errors.New("user not found")).Once()
+	assert.Equal(t, http.StatusNotFound, call(publicID).Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestPublicExpenseID(t *testing.T) {
+	mockService := new(servicemock.ExpenseService)
+	var got string
+	h := PublicExpenseID(mockService, func(w http.ResponseWriter, r *http.Request) {
+		got = mux.Vars(r)["id"]
+	})
+	call := func(id string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h(rr, mux.SetURLVars(httptest.NewRequest("DELETE", "/expenses/"+id, nil), map[string]string{"id": id}))
+		return rr
+	}
+	publicID := "9b2e4f0c-3d1a-4c8e-a7f2-5e6d8c9b0a1f"
+
+	// Test case 1: A public ID is swapped for the expense's number
+	mockService.On("GetExpenseByPublicID", publicID).Return(&repository.Expense{ID: 12, PublicID: publicID}, nil).Once()
+	assert.Equal(t, http.StatusOK, call(publicID).Code)
+	assert.Equal(t, "12", got)
+
+	// Test case 2: An expense's number is not found, so expenses can't be enumerated
+	assert.Equal(t, http.StatusNotFound, call("5").Code)
+	assert.Equal(t, "12", got)
+
+	// Test case 3: Unknown expense
+	mockService.On("GetExpenseByPublicID", publicID).Return((*repository.Expense)(nil), repository.ErrExpenseNotFound).Once()
+	assert.Equal(t, http.StatusNotFound, call(publicID).Code)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+type PartyHandler struct {
+	partyService service.PartyService
+}
+
+func NewPartyHandler(partyService service.PartyService) *PartyHandler {
+	return &PartyHandler{partyService: partyService}
+}
+
+func (h *PartyHandler) CreatePartyHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeJSON[service.CreatePartyRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	if util.SanitizeText(req.Name) == "" {
+		response.Error(w, r, "name is required", http.StatusBadRequest)
+		return
+	}
+	if err := checkTextLength("name", req.Name); err != nil {
+		writeFieldError(w, r, err)
+		return
+	}
+
+	party, err := h.partyService.CreateParty(req)
+	if err != nil {
+		if errors.Is(err, repository.ErrPartyNameTaken) {
+			response.Error(w, r, err.Error(), http.StatusConflict)
+			return
+		}
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusCreated, party)
+}
+
+func (h *PartyHandler) ListPartiesHandler(w http.ResponseWriter, r *http.Request) {
+	parties, err := h.partyService.ListParties()
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, parties)
+}
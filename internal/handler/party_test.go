@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartyHandler_CreatePartyHandler(t *testing.T) {
+	mockService := new(servicemock.PartyService)
+	partyHandler := NewPartyHandler(mockService)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		partyHandler.CreatePartyHandler(rr, jsonRequest("POST", "/parties", bytes.NewBufferString(body)))
+		return rr
+	}
+
+	// Test case 1: Successful creation
+	mockService.On("CreateParty", service.CreatePartyRequest{Name: "Landlord"}).Return(&repository.Party{ID: 1, Name: "Landlord"}, nil).Once()
+	assert.Equal(t, http.StatusCreated, post(`{"name":"Landlord"}`).Code)
+
+	// Test case 2: Blank name
+	assert.Equal(t, http.StatusBadRequest, post(`{"name":"  "}`).Code)
+
+	// Test case 3: Name already taken
+	mockService.On("CreateParty", service.CreatePartyRequest{Name: "Landlord"}).Return(nil, fmt.Errorf("%w: Landlord", repository.ErrPartyNameTaken)).Once()
+	assert.Equal(t, http.StatusConflict, post(`{"name":"Landlord"}`).Code)
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+)
+
+type PaymentHandler struct {
+	paymentService service.PaymentService
+}
+
+func NewPaymentHandler(paymentService service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{paymentService: paymentService}
+}
+
+func (h *PaymentHandler) GetPaymentHandlesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	handles, err := h.paymentService.GetPaymentHandles(id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, handles)
+}
+
+// SetPaymentHandlesHandler updates where a user can be paid. The body is decoded over the current
+// handles, so handles left out keep their values and an empty string clears one.
+func (h *PaymentHandler) SetPaymentHandlesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	handles, err := h.paymentService.GetPaymentHandles(id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+	if err := decodeJSONInto(w, r, handles); err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	updated, err := h.paymentService.SetPaymentHandles(id, *handles)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPaymentHandle) {
+			response.Error(w, r, err.Error(), http.StatusBadRequest)
+			return
+		}
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, updated)
+}
+
+// PaymentQRCodeHandler serves the payment link in the url query parameter as a QR code.
+func (h *PaymentHandler) PaymentQRCodeHandler(w http.ResponseWriter, r *http.Request) {
+	png, err := h.paymentService.PaymentQRCode(r.URL.Query().Get("url"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPaymentLink) {
+			response.Error(w, r, err.Error(), http.StatusBadRequest)
+			return
+		}
+		serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.WriteHeader(http.StatusOK)
+	w.Write(png)
+}
+
+// PaymentCallbackHandler records a transfer paid through a payment provider as a confirmed
+// settlement, once the X-Payment-Signature header shows the provider sent it.
+func (h *PaymentHandler) PaymentCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBytes))
+	if err != nil {
+		response.Error(w, r, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	settlement, err := h.paymentService.RecordPayment(payload, r.Header.Get("X-Payment-Signature"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidWebhookSignature):
+			response.Error(w, r, err.Error(), http.StatusUnauthorized)
+		case errors.Is(err, service.ErrInvalidPaymentCallback):
+			response.Error(w, r, err.Error(), http.StatusBadRequest)
+		default:
+			serverError(w, r, err)
+		}
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, settlement)
+}
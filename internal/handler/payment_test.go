@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentHandler_SetPaymentHandlesHandler(t *testing.T) {
+	mockService := new(servicemock.PaymentService)
+	paymentHandler := NewPaymentHandler(mockService)
+
+	send := func(id, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r := mux.SetURLVars(jsonRequest("PUT", "/users/"+id+"/payment-handles", bytes.NewBufferString(body)), map[string]string{"id": id})
+		paymentHandler.SetPaymentHandlesHandler(rr, r)
+		return rr
+	}
+
+	// Test case 1: A partial update keeps the handles it leaves out
+	mockService.On("GetPaymentHandles", 1).Return(&repository.PaymentHandles{UserID: 1, UPIID: "a@okbank"}, nil).Once()
+	want := repository.PaymentHandles{UserID: 1, UPIID: "a@okbank", PayPalMe: "alice"}
+	mockService.On("SetPaymentHandles", 1, want).Return(&want, nil).Once()
+	rr := send("1", `{"paypal_me":"alice"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"user_id":1,"upi_id":"a@okbank","paypal_me":"alice"}`, dataJSON(t, rr))
+
+	// Test case 2: A malformed handle
+	mockService.On("GetPaymentHandles", 1).Return(&repository.PaymentHandles{UserID: 1}, nil).Once()
+	mockService.On("SetPaymentHandles", 1, repository.PaymentHandles{UserID: 1, UPIID: "nope"}).
+		Return(nil, fmt.Errorf("%w: bad UPI ID", service.ErrInvalidPaymentHandle)).Once()
+	rr = send("1", `{"upi_id":"nope"}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Test case 3: Invalid user ID
+	rr = send("abc", `{}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestPaymentHandler_PaymentQRCodeHandler(t *testing.T) {
+	mockService := new(servicemock.PaymentService)
+	paymentHandler := NewPaymentHandler(mockService)
+
+	// Test case 1: A payment link
+	mockService.On("PaymentQRCode", "https://paypal.me/alice/5.00USD").Return([]byte("\x89PNG"), nil).Once()
+	rr := httptest.NewRecorder()
+	paymentHandler.PaymentQRCodeHandler(rr, httptest.NewRequest("GET", "/payments/qr.png?url=https%3A%2F%2Fpaypal.me%2Falice%2F5.00USD", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+
+	// Test case 2: Not a payment link
+	mockService.On("PaymentQRCode", "https://evil.example").Return(nil, service.ErrInvalidPaymentLink).Once()
+	rr = httptest.NewRecorder()
+	paymentHandler.PaymentQRCodeHandler(rr, httptest.NewRequest("GET", "/payments/qr.png?url=https%3A%2F%2Fevil.example", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestPaymentHandler_PaymentCallbackHandler(t *testing.T) {
+	mockService := new(servicemock.PaymentService)
+	paymentHandler := NewPaymentHandler(mockService)
+
+	send := func(body, signature string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r := jsonRequest("POST", "/payments/callback", bytes.NewBufferString(body))
+		r.Header.Set("X-Payment-Signature", signature)
+		paymentHandler.PaymentCallbackHandler(rr, r)
+		return rr
+	}
+	body := `{"from_email":"a@example.com","to_email":"b@example.com","amount":5,"provider":"paypal","reference":"PP-1"}`
+
+	// Test case 1: Recorded, with the body and signature passed on as sent
+	mockService.On("RecordPayment", []byte(body), "t=1,v1=ab").Return(&repository.Settlement{ID: 3, Status: repository.SettlementConfirmed}, nil).Once()
+	rr := send(body, "t=1,v1=ab")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"status":"confirmed"`)
+
+	// Test case 2: Unsigned or wrongly signed
+	mockService.On("RecordPayment", []byte(body), "").Return(nil, service.ErrInvalidWebhookSignature).Once()
+	rr = send(body, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// Test case 3: Rejected by the service
+	mockService.On("RecordPayment", []byte(`{"provider":"cash"}`), "t=1,v1=ab").Return(nil, service.ErrInvalidPaymentCallback).Once()
+	rr = send(`{"provider":"cash"}`, "t=1,v1=ab")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	mockService.AssertExpectations(t)
+}
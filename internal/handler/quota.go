@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// CodeQuotaPrefix starts the error code of a refusal for going over a quota, followed by the quota's
+// name, like "quota_expenses_per_day".
+const CodeQuotaPrefix = "quota_"
+
+type QuotaHandler struct {
+	quotaService service.QuotaService
+}
+
+func NewQuotaHandler(quotaService service.QuotaService) *QuotaHandler {
+	return &QuotaHandler{quotaService: quotaService}
+}
+
+// GetQuotaHandler reports where a user stands against their quotas.
+func (h *QuotaHandler) GetQuotaHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	usage, err := h.quotaService.GetUsage(id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, usage)
+}
+
+// writeQuotaError answers a QuotaError with 429 and a code naming the quota, and a Retry-After when
+// the quota frees up by itself. It reports whether err was one.
+func writeQuotaError(w http.ResponseWriter, r *http.Request, err error) bool {
+	var quotaErr *service.QuotaError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+	if quotaErr.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(quotaErr.RetryAfter.Seconds()))))
+	}
+	response.Problem(w, r, http.StatusTooManyRequests, response.ErrorBody{Message: quotaErr.Error(), Code: CodeQuotaPrefix + quotaErr.Quota})
+	return true
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/aadithya-md/split-expense/internal/util"
+	"github.com/gorilla/mux"
+)
+
+type SettlementHandler struct {
+	settlementService service.SettlementService
+}
+
+func NewSettlementHandler(settlementService service.SettlementService) *SettlementHandler {
+	return &SettlementHandler{settlementService: settlementService}
+}
+
+func (h *SettlementHandler) ProposeSettlementHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeJSON[service.ProposeSettlementRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	if err := h.validateProposeSettlementRequest(req); err != nil {
+		response.Error(w, r, "Invalid settlement data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	settlement, err := h.settlementService.ProposeSettlement(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrSettlementBlockedByDispute):
+			response.Error(w, r, err.Error(), http.StatusConflict)
+		case errors.Is(err, service.ErrSettlementExceedsBalance):
+			response.Error(w, r, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			serverError(w, r, err)
+		}
+		return
+	}
+
+	response.JSON(w, r, http.StatusCreated, settlement)
+}
+
+func (h *SettlementHandler) validateProposeSettlementRequest(req service.ProposeSettlementRequest) error {
+	if req.PayerEmail == "" || req.PayeeEmail == "" || req.Amount <= 0 {
+		return fmt.Errorf("payer_email, payee_email, and a positive amount are required")
+	}
+
+	if util.NormalizeEmail(req.PayerEmail) == util.NormalizeEmail(req.PayeeEmail) {
+		return fmt.Errorf("payer and payee must be different users")
+	}
+
+	if via := util.NormalizeEmail(req.OnBehalfOfEmail); via != "" && (via == util.NormalizeEmail(req.PayerEmail) || via == util.NormalizeEmail(req.PayeeEmail)) {
+		return fmt.Errorf("the user paid on behalf of must be neither the payer nor the payee")
+	}
+
+	// Balances are kept in the default currency
+	if err := checkDecimalPlaces("amount", req.Amount, util.DefaultCurrency, util.DefaultExponent); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (h *SettlementHandler) MarkSettlementSentHandler(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, repository.SettlementSent)
+}
+
+func (h *SettlementHandler) ConfirmSettlementHandler(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, repository.SettlementConfirmed)
+}
+
+func (h *SettlementHandler) DisputeSettlementHandler(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, repository.SettlementDisputed)
+}
+
+func (h *SettlementHandler) transition(w http.ResponseWriter, r *http.Request, to repository.SettlementStatus) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid settlement ID", http.StatusBadRequest)
+		return
+	}
+
+	req, err := decodeJSON[service.TransitionSettlementRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if req.UserEmail == "" {
+		response.Error(w, r, "user_email is required", http.StatusBadRequest)
+		return
+	}
+
+	settlement, err := h.settlementService.TransitionSettlement(id, to, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrSettlementNotFound):
+			response.Error(w, r, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrNotSettlementParty):
+			response.Error(w, r, err.Error(), http.StatusForbidden)
+		case errors.Is(err, repository.ErrInvalidSettlementTransition):
+			response.Error(w, r, err.Error(), http.StatusConflict)
+		default:
+			serverError(w, r, err)
+		}
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, settlement)
+}
+
+func (h *SettlementHandler) GetSettlementsForUserHandler(w http.ResponseWriter, r *http.Request) {
+	userEmail, err := emailParam(r)
+	if err != nil {
+		response.Error(w, r, "Invalid user email", http.StatusBadRequest)
+		return
+	}
+	if userEmail == "" {
+		response.Error(w, r, "User email is required", http.StatusBadRequest)
+		return
+	}
+
+	settlements, err := h.settlementService.GetSettlementsForUser(userEmail)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, settlements)
+}
+
+func (h *SettlementHandler) SimplifyDebtsHandler(w http.ResponseWriter, r *http.Request) {
+	userEmail, err := emailParam(r)
+	if err != nil {
+		response.Error(w, r, "Invalid user email", http.StatusBadRequest)
+		return
+	}
+	if userEmail == "" {
+		response.Error(w, r, "User email is required", http.StatusBadRequest)
+		return
+	}
+
+	simplification, err := h.settlementService.SimplifyDebts(userEmail)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, simplification)
+}
+
+func (h *SettlementHandler) ApplyDebtSimplificationHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeJSON[service.ApplyDebtSimplificationRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if req.UserEmail == "" {
+		response.Error(w, r, "Invalid simplification data: user_email is required", http.StatusBadRequest)
+		return
+	}
+
+	settlements, err := h.settlementService.ApplyDebtSimplification(req)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusCreated, settlements)
+}
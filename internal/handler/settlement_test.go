@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettlementHandler_ProposeSettlementHandler(t *testing.T) {
+	mockService := new(servicemock.SettlementService)
+	settlementHandler := NewSettlementHandler(mockService)
+
+	// Test case 1: Successful proposal
+	{
+		requestBody := service.ProposeSettlementRequest{PayerEmail: "bob@example.com", PayeeEmail: "alice@example.com", Amount: 25}
+		expected := &repository.Settlement{ID: 1, PayerID: 2, PayeeID: 1, Amount: 25, Status: repository.SettlementProposed}
+		mockService.On("ProposeSettlement", requestBody).Return(expected, nil).Once()
+
+		reqBodyBytes, _ := json.Marshal(requestBody)
+		req := jsonRequest("POST", "/settlements", bytes.NewBuffer(reqBodyBytes))
+		rr := httptest.NewRecorder()
+		settlementHandler.ProposeSettlementHandler(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		expectedResponseBytes, _ := json.Marshal(expected)
+		assert.JSONEq(t, string(expectedResponseBytes), dataJSON(t, rr))
+		mockService.AssertExpectations(t)
+	}
+
+	// Test case 2: Payer and payee are the same user
+	{
+		requestBody := service.ProposeSettlementRequest{PayerEmail: "bob@example.com", PayeeEmail: "bob@example.com", Amount: 25}
+
+		reqBodyBytes, _ := json.Marshal(requestBody)
+		req := jsonRequest("POST", "/settlements", bytes.NewBuffer(reqBodyBytes))
+		rr := httptest.NewRecorder()
+		settlementHandler.ProposeSettlementHandler(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "payer and payee must be different users")
+		mockService.AssertNumberOfCalls(t, "ProposeSettlement", 1)
+	}
+
+	// Test case 3: Paying on behalf of the payee
+	{
+		requestBody := service.ProposeSettlementRequest{PayerEmail: "carol@example.com", PayeeEmail: "alice@example.com", OnBehalfOfEmail: "Alice@example.com", Amount: 25}
+
+		reqBodyBytes, _ := json.Marshal(requestBody)
+		req := jsonRequest("POST", "/settlements", bytes.NewBuffer(reqBodyBytes))
+		rr := httptest.NewRecorder()
+		settlementHandler.ProposeSettlementHandler(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "neither the payer nor the payee")
+		mockService.AssertNumberOfCalls(t, "ProposeSettlement", 1)
+	}
+
+	// Test case 4: Paying more than is owed
+	{
+		requestBody := service.ProposeSettlementRequest{PayerEmail: "bob@example.com", PayeeEmail: "alice@example.com", Amount: 60}
+		mockService.On("ProposeSettlement", requestBody).Return((*repository.Settlement)(nil), fmt.Errorf("%w: only 55.00 is owed by bob@example.com to alice@example.com", service.ErrSettlementExceedsBalance)).Once()
+
+		reqBodyBytes, _ := json.Marshal(requestBody)
+		req := jsonRequest("POST", "/settlements", bytes.NewBuffer(reqBodyBytes))
+		rr := httptest.NewRecorder()
+		settlementHandler.ProposeSettlementHandler(rr, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), "only 55.00 is owed")
+		mockService.AssertExpectations(t)
+	}
+}
+
+func TestSettlementHandler_ConfirmSettlementHandler(t *testing.T) {
+	mockService := new(servicemock.SettlementService)
+	settlementHandler := NewSettlementHandler(mockService)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/settlements/{id}/confirm", settlementHandler.ConfirmSettlementHandler).Methods("POST")
+	confirm := func(id, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, jsonRequest("POST", "/settlements/"+id+"/confirm", bytes.NewBufferString(body)))
+		return rr
+	}
+	alice := service.TransitionSettlementRequest{UserEmail: "alice@example.com"}
+
+	// Test case 1: Successful confirmation
+	{
+		mockService.On("TransitionSettlement", 1, repository.SettlementConfirmed, alice).Return(&repository.Settlement{ID: 1, Status: repository.SettlementConfirmed}, nil).Once()
+
+		rr := confirm("1", `{"user_email":"alice@example.com"}`)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockService.AssertExpectations(t)
+	}
+
+	// Test case 2: Settlement already confirmed
+	{
+		err := fmt.Errorf("failed to mark settlement 1 as confirmed: %w", repository.ErrInvalidSettlementTransition)
+		mockService.On("TransitionSettlement", 1, repository.SettlementConfirmed, alice).Return((*repository.Settlement)(nil), err).Once()
+
+		rr := confirm("1", `{"user_email":"alice@example.com"}`)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		mockService.AssertExpectations(t)
+	}
+
+	// Test case 3: Unknown settlement
+	{
+		err := fmt.Errorf("failed to get settlement 99: %w", repository.ErrSettlementNotFound)
+		mockService.On("TransitionSettlement", 99, repository.SettlementConfirmed, alice).Return((*repository.Settlement)(nil), err).Once()
+
+		rr := confirm("99", `{"user_email":"alice@example.com"}`)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockService.AssertExpectations(t)
+	}
+
+	// Test case 4: Confirmed by someone other than the payee
+	{
+		bob := service.TransitionSettlementRequest{UserEmail: "bob@example.com"}
+		err := fmt.Errorf("%w: only the payee can mark settlement 1 as confirmed", service.ErrNotSettlementParty)
+		mockService.On("TransitionSettlement", 1, repository.SettlementConfirmed, bob).Return((*repository.Settlement)(nil), err).Once()
+
+		rr := confirm("1", `{"user_email":"bob@example.com"}`)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		mockService.AssertExpectations(t)
+	}
+
+	// Test case 5: Non-numeric ID, or nobody named
+	{
+		assert.Equal(t, http.StatusBadRequest, confirm("abc", `{"user_email":"alice@example.com"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, confirm("1", `{}`).Code)
+		mockService.AssertNumberOfCalls(t, "TransitionSettlement", 4)
+	}
+}
+
+func TestSettlementHandler_DebtSimplification(t *testing.T) {
+	mockService := new(servicemock.SettlementService)
+	settlementHandler := NewSettlementHandler(mockService)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/settlements/simplify/by-user/{email}", settlementHandler.SimplifyDebtsHandler).Methods("GET")
+	router.HandleFunc("/settlements/simplify", settlementHandler.ApplyDebtSimplificationHandler).Methods("POST")
+
+	// Test case 1: The proposed transfers
+	{
+		expected := &service.DebtSimplification{
+			UserEmail:       "alice@example.com",
+			Transfers:       []service.SimplifiedTransfer{{PayerEmail: "bob@example.com", PayeeEmail: "carol@example.com", ViaUserEmail: "alice@example.com", Amount: 30}},
+			TransfersBefore: 2,
+		}
+		mockService.On("SimplifyDebts", "alice@example.com").Return(expected, nil).Once()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/settlements/simplify/by-user/alice@example.com", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var got service.DebtSimplification
+		decodeData(t, rr, &got)
+		assert.Equal(t, *expected, got)
+	}
+
+	// Test case 2: Applying them
+	{
+		via := 1
+		created := []*repository.Settlement{{ID: 5, PayerID: 2, PayeeID: 3, ViaUserID: &via, Amount: 30, Status: repository.SettlementProposed}}
+		mockService.On("ApplyDebtSimplification", service.ApplyDebtSimplificationRequest{UserEmail: "alice@example.com"}).Return(created, nil).Once()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, jsonRequest("POST", "/settlements/simplify", bytes.NewBufferString(`{"user_email": "alice@example.com"}`)))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		expectedResponseBytes, _ := json.Marshal(created)
+		assert.JSONEq(t, string(expectedResponseBytes), dataJSON(t, rr))
+	}
+
+	// Test case 3: The user is required
+	{
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, jsonRequest("POST", "/settlements/simplify", bytes.NewBufferString(`{}`)))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, errorMessage(t, rr), "user_email is required")
+	}
+
+	mockService.AssertExpectations(t)
+}
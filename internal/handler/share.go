@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+)
+
+var sharedLedgerPage = template.Must(template.ParseFS(templateFiles, "templates/shared_ledger.html"))
+
+type ShareHandler struct {
+	shareService service.ShareService
+}
+
+func NewShareHandler(shareService service.ShareService) *ShareHandler {
+	return &ShareHandler{shareService: shareService}
+}
+
+func (h *ShareHandler) CreateShareLinkHandler(w http.ResponseWriter, r *http.Request) {
+	eventID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid event ID", http.StatusBadRequest)
+		return
+	}
+
+	req, err := decodeJSON[service.CreateShareLinkRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if req.UserEmail == "" {
+		response.Error(w, r, "user_email is required", http.StatusBadRequest)
+		return
+	}
+
+	link, err := h.shareService.CreateShareLink(eventID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidShareTTL):
+			response.Error(w, r, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, repository.ErrEventNotFound):
+			response.Error(w, r, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrNotEventCreator):
+			response.Error(w, r, err.Error(), http.StatusForbidden)
+		case errors.Is(err, service.ErrSharingDisabled):
+			response.Error(w, r, err.Error(), http.StatusServiceUnavailable)
+		default:
+			serverError(w, r, err)
+		}
+		return
+	}
+
+	response.JSON(w, r, http.StatusCreated, link)
+}
+
+func (h *ShareHandler) RevokeShareLinkHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid share link ID", http.StatusBadRequest)
+		return
+	}
+
+	req, err := decodeJSON[service.RevokeShareLinkRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if req.UserEmail == "" {
+		response.Error(w, r, "user_email is required", http.StatusBadRequest)
+		return
+	}
+
+	link, err := h.shareService.RevokeShareLink(id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrShareLinkNotFound), errors.Is(err, repository.ErrEventNotFound):
+			response.Error(w, r, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrNotEventCreator):
+			response.Error(w, r, err.Error(), http.StatusForbidden)
+		default:
+			serverError(w, r, err)
+		}
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, link)
+}
+
+// SharedLedgerHandler serves the ledger behind a share link to anyone holding it, as HTML for
+// browsers (or ?format=html) and JSON otherwise.
+func (h *ShareHandler) SharedLedgerHandler(w http.ResponseWriter, r *http.Request) {
+	noStore(w)
+
+	ledger, err := h.shareService.GetSharedLedger(mux.Vars(r)["token"])
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidShareToken), errors.Is(err, service.ErrSharingDisabled), errors.Is(err, repository.ErrEventNotFound):
+			response.Error(w, r, service.ErrInvalidShareToken.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrShareLinkGone):
+			response.Error(w, r, err.Error(), http.StatusGone)
+		default:
+			serverError(w, r, err)
+		}
+		return
+	}
+
+	if wantsHTML(r) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := sharedLedgerPage.ExecuteTemplate(w, "shared_ledger", ledger); err != nil {
+			log.Printf("failed to render shared ledger page: %v", err)
+		}
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, ledger)
+}
+
+func wantsHTML(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "html"
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShareHandler_SharedLedgerHandler(t *testing.T) {
+	mockService := new(servicemock.ShareService)
+	shareHandler := NewShareHandler(mockService)
+
+	get := func(token, query, accept string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/share/"+token+query, nil)
+		r.Header.Set("Accept", accept)
+		shareHandler.SharedLedgerHandler(rr, mux.SetURLVars(r, map[string]string{"token": token}))
+		return rr
+	}
+	ledger := &service.SharedLedger{Name: "Ski weekend", ExpiresAt: time.Now().Add(time.Hour)}
+
+	// Test case 1: JSON by default, HTML for browsers, either forced by ?format
+	mockService.On("GetSharedLedger", "ok").Return(ledger, nil).Times(3)
+	rr := get("ok", "", "application/json")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+	rr = get("ok", "", "text/html,application/xhtml+xml")
+	assert.Contains(t, rr.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, rr.Body.String(), "Ski weekend")
+	rr = get("ok", "?format=json", "text/html")
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	// Test case 2: Bad and expired links
+	mockService.On("GetSharedLedger", "bad").Return(nil, service.ErrInvalidShareToken).Once()
+	assert.Equal(t, http.StatusNotFound, get("bad", "", "").Code)
+	mockService.On("GetSharedLedger", "old").Return(nil, service.ErrShareLinkGone).Once()
+	assert.Equal(t, http.StatusGone, get("old", "", "").Code)
+
+	// Test case 3: Unexpected errors
+	mockService.On("GetSharedLedger", "err").Return(nil, fmt.Errorf("db down")).Once()
+	assert.Equal(t, http.StatusInternalServerError, get("err", "", "").Code)
+
+	mockService.AssertExpectations(t)
+}
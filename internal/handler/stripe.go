@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// maxWebhookBytes bounds a webhook body. Stripe's payment intent events are a few kilobytes.
+const maxWebhookBytes = 1 << 16
+
+type StripeHandler struct {
+	stripeService service.StripeService
+}
+
+func NewStripeHandler(stripeService service.StripeService) *StripeHandler {
+	return &StripeHandler{stripeService: stripeService}
+}
+
+// PaySettlementHandler starts paying a settlement in-app and returns what the client needs to finish
+// the payment with Stripe.
+func (h *StripeHandler) PaySettlementHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid settlement ID", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := h.stripeService.PaySettlement(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrSettlementNotFound):
+			response.Error(w, r, err.Error(), http.StatusNotFound)
+		case errors.Is(err, repository.ErrInvalidSettlementTransition):
+			response.Error(w, r, err.Error(), http.StatusConflict)
+		case errors.Is(err, service.ErrPaymentsDisabled):
+			response.Error(w, r, err.Error(), http.StatusServiceUnavailable)
+		case errors.Is(err, service.ErrPaymentProvider):
+			response.Error(w, r, err.Error(), http.StatusBadGateway)
+		default:
+			serverError(w, r, err)
+		}
+		return
+	}
+
+	noStore(w)
+	response.JSON(w, r, http.StatusOK, payment)
+}
+
+// WebhookHandler takes Stripe's reports on payments. Anything but a 2xx makes Stripe send the
+// event again later.
+func (h *StripeHandler) WebhookHandler(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBytes))
+	if err != nil {
+		response.Error(w, r, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.stripeService.HandleWebhook(payload, r.Header.Get("Stripe-Signature")); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidWebhookSignature):
+			response.Error(w, r, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrPaymentsDisabled):
+			response.Error(w, r, err.Error(), http.StatusServiceUnavailable)
+		default:
+			serverError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripeHandler_PaySettlementHandler(t *testing.T) {
+	mockService := new(servicemock.StripeService)
+	stripeHandler := NewStripeHandler(mockService)
+
+	send := func(id string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r := mux.SetURLVars(httptest.NewRequest("POST", "/settlements/"+id+"/pay", nil), map[string]string{"id": id})
+		stripeHandler.PaySettlementHandler(rr, r)
+		return rr
+	}
+
+	// Test case 1: Started
+	mockService.On("PaySettlement", 1).Return(&service.SettlementPayment{SettlementID: 1, ClientSecret: "pi_1_secret", Status: repository.SettlementProcessing}, nil).Once()
+	rr := send("1")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"client_secret":"pi_1_secret"`)
+	assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+	// Test case 2: Errors map to statuses
+	for err, code := range map[error]int{
+		repository.ErrSettlementNotFound:                      http.StatusNotFound,
+		repository.ErrInvalidSettlementTransition:             http.StatusConflict,
+		service.ErrPaymentsDisabled:                           http.StatusServiceUnavailable,
+		fmt.Errorf("%w: timeout", service.ErrPaymentProvider): http.StatusBadGateway,
+	} {
+		mockService.On("PaySettlement", 2).Return(nil, err).Once()
+		assert.Equal(t, code, send("2").Code, err.Error())
+	}
+
+	mockService.AssertExpectations(t)
+}
+
+func TestStripeHandler_WebhookHandler(t *testing.T) {
+	mockService := new(servicemock.StripeService)
+	stripeHandler := NewStripeHandler(mockService)
+
+	send := func(body, signature string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/payments/stripe/webhook", bytes.NewBufferString(body))
+		r.Header.Set("Stripe-Signature", signature)
+		stripeHandler.WebhookHandler(rr, r)
+		return rr
+	}
+
+	// Test case 1: Applied
+	mockService.On("HandleWebhook", []byte(`{"id":"evt_1"}`), "t=1,v1=ab").Return(nil).Once()
+	assert.Equal(t, http.StatusOK, send(`{"id":"evt_1"}`, "t=1,v1=ab").Code)
+
+	// Test case 2: Badly signed
+	mockService.On("HandleWebhook", []byte(`{"id":"evt_2"}`), "").Return(service.ErrInvalidWebhookSignature).Once()
+	assert.Equal(t, http.StatusBadRequest, send(`{"id":"evt_2"}`, "").Code)
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/aadithya-md/split-expense/internal/util"
+	"github.com/gorilla/mux"
+)
+
+// TagSuggestionResponse is the tag suggested for a description. Suggestion is null when nothing
+// matched.
+type TagSuggestionResponse struct {
+	Suggestion *service.TagSuggestion `json:"suggestion"`
+}
+
+type TagHandler struct {
+	tagService service.TagService
+}
+
+func NewTagHandler(tagService service.TagService) *TagHandler {
+	return &TagHandler{tagService: tagService}
+}
+
+// SuggestTagHandler suggests a tag for the description in the query string, so a client can offer
+// one while the expense is still being typed in.
+func (h *TagHandler) SuggestTagHandler(w http.ResponseWriter, r *http.Request) {
+	description := r.URL.Query().Get("description")
+	if util.SanitizeText(description) == "" {
+		response.Error(w, r, "description is required", http.StatusBadRequest)
+		return
+	}
+	if err := checkTextLength("description", description); err != nil {
+		writeFieldError(w, r, err)
+		return
+	}
+
+	suggestion, err := h.tagService.SuggestTag(description)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, TagSuggestionResponse{Suggestion: suggestion})
+}
+
+func (h *TagHandler) CreateTagRuleHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeJSON[service.CreateTagRuleRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	keyword := service.TagKeyword(req.Keyword)
+	if keyword == "" {
+		response.Error(w, r, "keyword is required and must contain a letter or digit", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(keyword) > service.MaxTagKeywordLength {
+		writeFieldError(w, r, &FieldError{Field: "keyword", Message: fmt.Sprintf("is longer than %d characters", service.MaxTagKeywordLength)})
+		return
+	}
+	if util.SanitizeText(req.Tag) == "" {
+		response.Error(w, r, "tag is required", http.StatusBadRequest)
+		return
+	}
+	if err := checkTextLength("tag", req.Tag); err != nil {
+		writeFieldError(w, r, err)
+		return
+	}
+
+	rule, err := h.tagService.CreateTagRule(req)
+	if err != nil {
+		if errors.Is(err, repository.ErrTagRuleKeywordTaken) {
+			response.Error(w, r, err.Error(), http.StatusConflict)
+			return
+		}
+		if errors.Is(err, service.ErrInvalidTagKeyword) {
+			response.Error(w, r, err.Error(), http.StatusBadRequest)
+			return
+		}
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusCreated, rule)
+}
+
+func (h *TagHandler) ListTagRulesHandler(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.tagService.ListTagRules()
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, rules)
+}
+
+func (h *TagHandler) DeleteTagRuleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid tag rule ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.tagService.DeleteTagRule(id); err != nil {
+		if errors.Is(err, repository.ErrTagRuleNotFound) {
+			response.Error(w, r, err.Error(), http.StatusNotFound)
+			return
+		}
+		serverError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagHandler_SuggestTagHandler(t *testing.T) {
+	mockService := new(servicemock.TagService)
+	tagHandler := NewTagHandler(mockService)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		tagHandler.SuggestTagHandler(rr, httptest.NewRequest("GET", "/tags/suggest?"+query, nil))
+		return rr
+	}
+
+	// Test case 1: A suggestion
+	mockService.On("SuggestTag", "Uber home").Return(&service.TagSuggestion{Tag: "Transport", Source: service.TagSourceRule, Keyword: "uber", Confidence: 1}, nil).Once()
+	rr := get("description=Uber+home")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"tag":"Transport"`)
+
+	// Test case 2: Nothing matched
+	mockService.On("SuggestTag", "Gift").Return(nil, nil).Once()
+	rr = get("description=Gift")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"suggestion":null`)
+
+	// Test case 3: Bad input
+	assert.Equal(t, http.StatusBadRequest, get("").Code)
+	assert.Equal(t, http.StatusBadRequest, get("description="+strings.Repeat("a", 256)).Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTagHandler_CreateTagRuleHandler(t *testing.T) {
+	mockService := new(servicemock.TagService)
+	tagHandler := NewTagHandler(mockService)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		tagHandler.CreateTagRuleHandler(rr, jsonRequest("POST", "/tag-rules", bytes.NewBufferString(body)))
+		return rr
+	}
+	req := service.CreateTagRuleRequest{Keyword: "Uber", Tag: "Transport"}
+
+	// Test case 1: Successful creation
+	mockService.On("CreateTagRule", req).Return(&repository.TagRule{ID: 1, Keyword: "uber", Tag: "Transport"}, nil).Once()
+	assert.Equal(t, http.StatusCreated, post(`{"keyword":"Uber","tag":"Transport"}`).Code)
+
+	// Test case 2: Keyword already has a rule
+	mockService.On("CreateTagRule", req).Return(nil, fmt.Errorf("%w: uber", repository.ErrTagRuleKeywordTaken)).Once()
+	assert.Equal(t, http.StatusConflict, post(`{"keyword":"Uber","tag":"Transport"}`).Code)
+
+	// Test case 3: Bad input
+	assert.Equal(t, http.StatusBadRequest, post(`{"keyword":"!!","tag":"Transport"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"keyword":"Uber","tag":" "}`).Code)
+	rr := post(`{"keyword":"` + strings.Repeat("a", service.MaxTagKeywordLength+1) + `","tag":"Transport"}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"field":"keyword"`)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTagHandler_DeleteTagRuleHandler(t *testing.T) {
+	mockService := new(servicemock.TagService)
+	tagHandler := NewTagHandler(mockService)
+
+	del := func(id string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r := httptest.NewRequest("DELETE", "/tag-rules/"+id, nil)
+		tagHandler.DeleteTagRuleHandler(rr, mux.SetURLVars(r, map[string]string{"id": id}))
+		return rr
+	}
+
+	// Test case 1: Deleted
+	mockService.On("DeleteTagRule", 1).Return(nil).Once()
+	assert.Equal(t, http.StatusNoContent, del("1").Code)
+
+	// Test case 2: Unknown rule
+	mockService.On("DeleteTagRule", 2).Return(fmt.Errorf("%w: 2", repository.ErrTagRuleNotFound)).Once()
+	assert.Equal(t, http.StatusNotFound, del("2").Code)
+
+	// Test case 3: Bad ID
+	assert.Equal(t, http.StatusBadRequest, del("x").Code)
+
+	mockService.AssertExpectations(t)
+}
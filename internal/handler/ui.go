@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"embed"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+//go:embed templates/*.html
+var templateFiles embed.FS
+
+var (
+	expensesPage   = parsePage("templates/expenses.html")
+	balancesPage   = parsePage("templates/balances.html")
+	newExpensePage = parsePage("templates/new_expense.html")
+)
+
+func parsePage(name string) *template.Template {
+	return template.Must(template.ParseFS(templateFiles, "templates/layout.html", name))
+}
+
+// pageData is the view model shared by all server-rendered pages.
+type pageData struct {
+	Title          string
+	Email          string
+	Error          string
+	Expenses       []repository.UserExpenseView
+	Balances       []service.UserBalanceView
+	OverallBalance float64
+}
+
+// UIHandler serves a minimal server-rendered HTML interface on top of the service layer.
+type UIHandler struct {
+	expenseService service.ExpenseService
+	expenseHandler *ExpenseHandler
+}
+
+func NewUIHandler(expenseService service.ExpenseService, limits ExpenseLimits, currencies util.Currencies) *UIHandler {
+	return &UIHandler{expenseService: expenseService, expenseHandler: NewExpenseHandler(expenseService, limits, currencies)}
+}
+
+func (h *UIHandler) render(w http.ResponseWriter, page *template.Template, data pageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := page.ExecuteTemplate(w, "layout", data); err != nil {
+		log.Printf("failed to render %s page: %v", data.Title, err)
+	}
+}
+
+func (h *UIHandler) ExpensesPageHandler(w http.ResponseWriter, r *http.Request) {
+	data := pageData{Title: "Expenses", Email: r.URL.Query().Get("email")}
+	if data.Email != "" {
+		expenses, err := h.expenseService.GetExpensesForUser(data.Email)
+		if err != nil {
+			data.Error = err.Error()
+		}
+		data.Expenses = expenses
+	}
+	h.render(w, expensesPage, data)
+}
+
+func (h *UIHandler) BalancesPageHandler(w http.ResponseWriter, r *http.Request) {
+	data := pageData{Title: "Balances", Email: r.URL.Query().Get("email")}
+	if data.Email != "" {
+		balances, err := h.expenseService.GetOutstandingBalancesForUser(data.Email)
+		if err != nil {
+			data.Error = err.Error()
+		}
+		data.Balances = balances
+
+		if data.Error == "" {
+			overall, err := h.expenseService.GetOverallOutstandingBalance(data.Email)
+			if err != nil {
+				data.Error = err.Error()
+			}
+			data.OverallBalance = overall
+		}
+	}
+	h.render(w, balancesPage, data)
+}
+
+func (h *UIHandler) NewExpensePageHandler(w http.ResponseWriter, r *http.Request) {
+	h.render(w, newExpensePage, pageData{Title: "New expense", Email: r.URL.Query().Get("email")})
+}
+
+// CreateExpenseFormHandler accepts the new-expense form, splitting the amount equally with the
+// creator paying the full amount, and redirects to the creator's expense list on success.
+func (h *UIHandler) CreateExpenseFormHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form body", http.StatusBadRequest)
+		return
+	}
+
+	creator := strings.TrimSpace(r.PostForm.Get("created_by_email"))
+	data := pageData{Title: "New expense", Email: creator}
+
+	totalAmount, err := strconv.ParseFloat(r.PostForm.Get("total_amount"), 64)
+	if err != nil {
+		data.Error = "Invalid total amount"
+		w.WriteHeader(http.StatusBadRequest)
+		h.render(w, newExpensePage, data)
+		return
+	}
+
+	req := service.CreateExpenseRequest{
+		Description:    r.PostForm.Get("description"),
+		Tag:            r.PostForm.Get("tag"),
+		TotalAmount:    totalAmount,
+		CreatedByEmail: creator,
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits:    []service.EqualSplitRequest{{UserEmail: creator, AmountPaid: totalAmount}},
+	}
+	for _, email := range strings.Split(r.PostForm.Get("participants"), ",") {
+		email = strings.TrimSpace(email)
+		if email == "" || email == creator {
+			continue
+		}
+		req.EqualSplits = append(req.EqualSplits, service.EqualSplitRequest{UserEmail: email})
+	}
+
+	if err := h.expenseHandler.validateCreateExpenseRequest(req); err != nil {
+		data.Error = "Invalid expense data: " + err.Error()
+		w.WriteHeader(http.StatusBadRequest)
+		h.render(w, newExpensePage, data)
+		return
+	}
+
+	if _, err := h.expenseService.CreateExpense(req); err != nil {
+		data.Error = err.Error()
+		w.WriteHeader(http.StatusInternalServerError)
+		h.render(w, newExpensePage, data)
+		return
+	}
+
+	http.Redirect(w, r, "/ui/expenses?email="+url.QueryEscape(creator), http.StatusSeeOther)
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUIHandler_ExpensesPageHandler(t *testing.T) {
+	mockService := new(servicemock.ExpenseService)
+	uiHandler := NewUIHandler(mockService, ExpenseLimits{}, seededCurrencies)
+
+	// Test case 1: Expenses are rendered for the given email
+	{
+		expenses := []repository.UserExpenseView{
+			{Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Tag: "Food", Description: "Dinner <3", TotalAmount: 50.00, Share: 25.00},
+		}
+		mockService.On("GetExpensesForUser", "alice@example.com").Return(expenses, nil).Once()
+
+		req := httptest.NewRequest("GET", "/ui/expenses?email=alice@example.com", nil)
+		rr := httptest.NewRecorder()
+		uiHandler.ExpensesPageHandler(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), "2024-03-01")
+		assert.Contains(t, rr.Body.String(), "Dinner &lt;3")
+		assert.Contains(t, rr.Body.String(), "25.00")
+		mockService.AssertExpectations(t)
+	}
+
+	// Test case 2: No email renders the lookup form only
+	{
+		req := httptest.NewRequest("GET", "/ui/expenses", nil)
+		rr := httptest.NewRecorder()
+		uiHandler.ExpensesPageHandler(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "<table>")
+	}
+
+	// Test case 3: Service errors are shown on the page
+	{
+		mockService.On("GetExpensesForUser", "ghost@example.com").Return([]repository.UserExpenseView(nil), errors.New("user with email ghost@example.com not found")).Once()
+
+		req := httptest.NewRequest("GET", "/ui/expenses?email=ghost@example.com", nil)
+		rr := httptest.NewRecorder()
+		uiHandler.ExpensesPageHandler(rr, req)
+
+		assert.Contains(t, rr.Body.String(), "user with email ghost@example.com not found")
+		mockService.AssertExpectations(t)
+	}
+}
+
+func TestUIHandler_CreateExpenseFormHandler(t *testing.T) {
+	mockService := new(servicemock.ExpenseService)
+	uiHandler := NewUIHandler(mockService, ExpenseLimits{}, seededCurrencies)
+
+	// Test case 1: Valid form creates an equal split paid by the creator
+	{
+		expectedReq := service.CreateExpenseRequest{
+			Description:    "Groceries",
+			Tag:            "Food",
+			TotalAmount:    90,
+			CreatedByEmail: "alice@example.com",
+			SplitMethod:    service.SplitMethodEqual,
+			EqualSplits: []service.EqualSplitRequest{
+				{UserEmail: "alice@example.com", AmountPaid: 90},
+				{UserEmail: "bob@example.com"},
+				{UserEmail: "charlie@example.com"},
+			},
+		}
+		mockService.On("CreateExpense", expectedReq).Return(&repository.Expense{ID: 1}, nil).Once()
+
+		form := url.Values{
+			"description":      {"Groceries"},
+			"tag":              {"Food"},
+			"total_amount":     {"90"},
+			"created_by_email": {"alice@example.com"},
+			"participants":     {"bob@example.com, charlie@example.com,"},
+		}
+		req := httptest.NewRequest("POST", "/ui/new-expense", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		uiHandler.CreateExpenseFormHandler(rr, req)
+
+		assert.Equal(t, http.StatusSeeOther, rr.Code)
+		assert.Equal(t, "/ui/expenses?email=alice%40example.com", rr.Header().Get("Location"))
+		mockService.AssertExpectations(t)
+	}
+
+	// Test case 2: Invalid amount re-renders the form with an error
+	{
+		form := url.Values{
+			"description":      {"Groceries"},
+			"total_amount":     {"lots"},
+			"created_by_email": {"alice@example.com"},
+		}
+		req := httptest.NewRequest("POST", "/ui/new-expense", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		uiHandler.CreateExpenseFormHandler(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Invalid total amount")
+		mockService.AssertNotCalled(t, "CreateExpense")
+	}
+}
@@ -1,15 +1,31 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/response"
 	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/aadithya-md/split-expense/internal/util"
 	"github.com/gorilla/mux"
 )
 
+// CreateUserRequest is the body of POST /users.
+type CreateUserRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	// PublicID is the ID the user is to be known by, generated when left out.
+	PublicID string `json:"public_id,omitempty"`
+}
+
+// SetSplitWeightRequest is the body of PUT /users/{id}/split-weight.
+type SetSplitWeightRequest struct {
+	SplitWeight float64 `json:"split_weight"`
+}
+
 type UserHandler struct {
 	userService service.UserService
 }
@@ -18,31 +34,68 @@ func NewUserHandler(userService service.UserService) *UserHandler {
 	return &UserHandler{userService: userService}
 }
 
+// CreateUserHandler registers a user. An email that is already registered is refused with 409,
+// unless ?mode=upsert is given: then the registered user is returned as is, with 200 rather than
+// 201, so an import or invite can make sure of a user in one request.
 func (h *UserHandler) CreateUserHandler(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Name  string `json:"name"`
-		Email string `json:"email"`
+	var upsert bool
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "", "create":
+	case "upsert":
+		upsert = true
+	default:
+		response.Error(w, r, fmt.Sprintf("unsupported mode %q, use create or upsert", mode), http.StatusBadRequest)
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	req, err := decodeJSON[CreateUserRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
 		return
 	}
 
-	if req.Name == "" || req.Email == "" {
-		http.Error(w, "Name and Email are required", http.StatusBadRequest)
+	if util.SanitizeText(req.Name) == "" || util.NormalizeEmail(req.Email) == "" {
+		response.Error(w, r, "Name and Email are required", http.StatusBadRequest)
+		return
+	}
+	if err := checkTextLength("name", req.Name); err != nil {
+		writeFieldError(w, r, err)
+		return
+	}
+	if err := checkPublicID("public_id", req.PublicID); err != nil {
+		writeFieldError(w, r, err)
+		return
+	}
+
+	if upsert {
+		user, created, err := h.userService.GetOrCreateUser(req.Name, req.Email, req.PublicID)
+		if err != nil {
+			if errors.Is(err, repository.ErrPublicIDTaken) {
+				response.Error(w, r, err.Error(), http.StatusConflict)
+				return
+			}
+			serverError(w, r, err)
+			return
+		}
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		response.JSON(w, r, status, user)
 		return
 	}
 
-	user, err := h.userService.CreateUser(req.Name, req.Email)
+	user, err := h.userService.CreateUser(req.Name, req.Email, req.PublicID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		if errors.Is(err, repository.ErrEmailTaken) || errors.Is(err, repository.ErrPublicIDTaken) {
+			response.Error(w, r, err.Error(), http.StatusConflict)
+			return
+		}
+		serverError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(user)
+	response.JSON(w, r, http.StatusCreated, user)
 }
 
 func (h *UserHandler) GetUserHandler(w http.ResponseWriter, r *http.Request) {
@@ -51,42 +104,69 @@ func (h *UserHandler) GetUserHandler(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		response.Error(w, r, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
 
 	user, err := h.userService.GetUser(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		serverError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(user)
+	response.JSON(w, r, http.StatusOK, user)
+}
+
+func (h *UserHandler) SetSplitWeightHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, r, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	req, err := decodeJSON[SetSplitWeightRequest](w, r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	if req.SplitWeight <= 0 {
+		response.Error(w, r, "split_weight must be positive", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.SetSplitWeight(id, req.SplitWeight)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, user)
 }
 
 func (h *UserHandler) GetUserByEmailHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r) // Use mux.Vars to get path parameters
-	email := vars["email"]
+	email, err := emailParam(r)
+	if err != nil {
+		response.Error(w, r, "Invalid email parameter", http.StatusBadRequest)
+		return
+	}
 
 	if email == "" {
-		// This check might be redundant if mux.Vars ensures it's present
-		http.Error(w, "Email parameter is required", http.StatusBadRequest)
+		response.Error(w, r, "Email parameter is required", http.StatusBadRequest)
 		return
 	}
 
 	users, err := h.userService.GetUsersByEmails([]string{email})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		serverError(w, r, err)
 		return
 	}
 
 	if len(users) == 0 {
-		http.Error(w, fmt.Sprintf("user not found for email: %s", email), http.StatusInternalServerError)
+		response.Error(w, r, fmt.Sprintf("user not found for email: %s", email), http.StatusInternalServerError)
 		return
 	}
 
 	user := users[0] // Assuming only one user for a single email lookup
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(user)
+	response.JSON(w, r, http.StatusOK, user)
 }
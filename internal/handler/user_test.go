@@ -9,62 +9,36 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/aadithya-md/split-expense/internal/mocks/servicemock"
 	"github.com/aadithya-md/split-expense/internal/repository"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
 )
 
-type MockUserService struct {
-	mock.Mock
-}
-
-func (m *MockUserService) CreateUser(name, email string) (*repository.User, error) {
-	args := m.Called(name, email)
-	return args.Get(0).(*repository.User), args.Error(1)
-}
-
-func (m *MockUserService) GetUser(id int) (*repository.User, error) {
-	args := m.Called(id)
-	return args.Get(0).(*repository.User), args.Error(1)
-}
-
-func (m *MockUserService) GetUsersByEmails(emails []string) ([]*repository.User, error) {
-	args := m.Called(emails)
-	return args.Get(0).([]*repository.User), args.Error(1)
-}
-
-func (m *MockUserService) GetUsersByIDs(ids []int) ([]*repository.User, error) {
-	args := m.Called(ids)
-	return args.Get(0).([]*repository.User), args.Error(1)
-}
-
 func TestUserHandler_CreateUserHandler(t *testing.T) {
-	mockService := new(MockUserService)
+	mockService := new(servicemock.UserService)
 	handler := NewUserHandler(mockService)
 
 	// Test case 1: Successful user creation
-	userToCreate := &repository.User{Name: "Test User", Email: "test@example.com"}
-	expectedUser := &repository.User{ID: 1, Name: "Test User", Email: "test@example.com"}
+	userToCreate := CreateUserRequest{Name: "Test User", Email: "test@example.com"}
+	expectedUser := &repository.User{ID: 1, PublicID: "01HZX3Q8K2M4N6P8R0T2V4W6Y8", Name: "Test User", Email: "test@example.com"}
 
-	mockService.On("CreateUser", userToCreate.Name, userToCreate.Email).Return(expectedUser, nil).Once()
+	mockService.On("CreateUser", userToCreate.Name, userToCreate.Email, "").Return(expectedUser, nil).Once()
 
 	body, _ := json.Marshal(userToCreate)
-	req := httptest.NewRequest("POST", "/users", bytes.NewBuffer(body))
-	req.Header.Set("Content-Type", "application/json")
+	req := jsonRequest("POST", "/users", bytes.NewBuffer(body))
 	rr := httptest.NewRecorder()
 
 	handler.CreateUserHandler(rr, req)
 
 	assert.Equal(t, http.StatusCreated, rr.Code)
 	var createdUser repository.User
-	json.NewDecoder(rr.Body).Decode(&createdUser)
-	assert.Equal(t, expectedUser, &createdUser)
+	decodeData(t, rr, &createdUser)
+	assert.Equal(t, apiUser(expectedUser), &createdUser)
 	mockService.AssertExpectations(t)
 
 	// Test case 2: Invalid request body
-	req = httptest.NewRequest("POST", "/users", bytes.NewBuffer([]byte("invalid json")))
-	req.Header.Set("Content-Type", "application/json")
+	req = jsonRequest("POST", "/users", bytes.NewBuffer([]byte("invalid json")))
 	rr = httptest.NewRecorder()
 
 	handler.CreateUserHandler(rr, req)
@@ -75,8 +49,7 @@ func TestUserHandler_CreateUserHandler(t *testing.T) {
 
 	// Test case 3: Missing name or email
 	body, _ = json.Marshal(struct{ Email string }{Email: "missingname@example.com"})
-	req = httptest.NewRequest("POST", "/users", bytes.NewBuffer(body))
-	req.Header.Set("Content-Type", "application/json")
+	req = jsonRequest("POST", "/users", bytes.NewBuffer(body))
 	rr = httptest.NewRecorder()
 
 	handler.CreateUserHandler(rr, req)
@@ -86,11 +59,10 @@ func TestUserHandler_CreateUserHandler(t *testing.T) {
 	mockService.AssertNotCalled(t, "CreateUser")
 
 	// Test case 4: Service error
-	mockService.On("CreateUser", "Error User", "error@example.com").Return((*repository.User)(nil), fmt.Errorf("service error")).Once()
+	mockService.On("CreateUser", "Error User", "error@example.com", "").Return((*repository.User)(nil), fmt.Errorf("service error")).Once()
 
 	body, _ = json.Marshal(struct{ Name, Email string }{Name: "Error User", Email: "error@example.com"})
-	req = httptest.NewRequest("POST", "/users", bytes.NewBuffer(body))
-	req.Header.Set("Content-Type", "application/json")
+	req = jsonRequest("POST", "/users", bytes.NewBuffer(body))
 	rr = httptest.NewRecorder()
 
 	handler.CreateUserHandler(rr, req)
@@ -98,14 +70,72 @@ func TestUserHandler_CreateUserHandler(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, rr.Code)
 	assert.Contains(t, rr.Body.String(), "service error")
 	mockService.AssertExpectations(t)
+
+	// Test case 5: Email already registered
+	mockService.On("CreateUser", "Dup User", "Dup@example.com", "").Return((*repository.User)(nil), fmt.Errorf("%w: dup@example.com", repository.ErrEmailTaken)).Once()
+
+	body, _ = json.Marshal(struct{ Name, Email string }{Name: "Dup User", Email: "Dup@example.com"})
+	req = jsonRequest("POST", "/users", bytes.NewBuffer(body))
+	rr = httptest.NewRecorder()
+
+	handler.CreateUserHandler(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	mockService.AssertExpectations(t)
+
+	// Test case 6: A public ID of the client's own, taken or malformed
+	mine := "9b2e4f0c-3d1a-4c8e-a7f2-5e6d8c9b0a1f"
+	mockService.On("CreateUser", "Own ID", "own@example.com", mine).Return((*repository.User)(nil), fmt.Errorf("%w: %s", repository.ErrPublicIDTaken, mine)).Once()
+
+	rr = httptest.NewRecorder()
+	handler.CreateUserHandler(rr, jsonRequest("POST", "/users", bytes.NewBufferString(`{"name":"Own ID","email":"own@example.com","public_id":"`+mine+`"}`)))
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = httptest.NewRecorder()
+	handler.CreateUserHandler(rr, jsonRequest("POST", "/users", bytes.NewBufferString(`{"name":"Own ID","email":"own@example.com","public_id":"user-42"}`)))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"field":"public_id"`)
+	mockService.AssertExpectations(t)
+}
+
+func TestUserHandler_CreateUserHandler_Upsert(t *testing.T) {
+	mockService := new(servicemock.UserService)
+	handler := NewUserHandler(mockService)
+
+	post := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.CreateUserHandler(rr, jsonRequest("POST", "/users"+query, bytes.NewBufferString(`{"name":"Dup User","email":"dup@example.com"}`)))
+		return rr
+	}
+	existing := &repository.User{ID: 7, PublicID: "01HZX3Q8K2M4N6P8R0T2V4W6Y9", Name: "Original Name", Email: "dup@example.com"}
+
+	// Test case 1: A registered email returns the user as stored
+	mockService.On("GetOrCreateUser", "Dup User", "dup@example.com", "").Return(existing, false, nil).Once()
+	rr := post("?mode=upsert")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var user repository.User
+	decodeData(t, rr, &user)
+	assert.Equal(t, apiUser(existing), &user)
+
+	// Test case 2: A new email is registered
+	mockService.On("GetOrCreateUser", "Dup User", "dup@example.com", "").Return(&repository.User{ID: 8, Name: "Dup User", Email: "dup@example.com"}, true, nil).Once()
+	assert.Equal(t, http.StatusCreated, post("?mode=upsert").Code)
+
+	// Test case 3: Unknown mode
+	rr = post("?mode=replace")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "unsupported mode")
+
+	mockService.AssertExpectations(t)
+	mockService.AssertNotCalled(t, "CreateUser")
 }
 
 func TestUserHandler_GetUserHandler(t *testing.T) {
-	mockService := new(MockUserService)
+	mockService := new(servicemock.UserService)
 	handler := NewUserHandler(mockService)
 
 	// Test case 1: Successful retrieval
-	expectedUser := &repository.User{ID: 1, Name: "Test User", Email: "test@example.com"}
+	expectedUser := &repository.User{ID: 1, PublicID: "01HZX3Q8K2M4N6P8R0T2V4W6Y8", Name: "Test User", Email: "test@example.com"}
 	mockService.On("GetUser", 1).Return(expectedUser, nil).Once()
 
 	req := httptest.NewRequest("GET", "/users/1", nil)
@@ -117,8 +147,8 @@ func TestUserHandler_GetUserHandler(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, rr.Code)
 	var retrievedUser repository.User
-	json.NewDecoder(rr.Body).Decode(&retrievedUser)
-	assert.Equal(t, expectedUser, &retrievedUser)
+	decodeData(t, rr, &retrievedUser)
+	assert.Equal(t, apiUser(expectedUser), &retrievedUser)
 	mockService.AssertExpectations(t)
 
 	// Test case 2: Invalid ID
@@ -149,11 +179,11 @@ func TestUserHandler_GetUserHandler(t *testing.T) {
 }
 
 func TestUserHandler_GetUserByEmailHandler(t *testing.T) {
-	mockService := new(MockUserService)
+	mockService := new(servicemock.UserService)
 	handler := NewUserHandler(mockService)
 
 	// Test case 1: Successful retrieval by email
-	expectedUser := &repository.User{ID: 1, Name: "Test User", Email: "test@example.com"}
+	expectedUser := &repository.User{ID: 1, PublicID: "01HZX3Q8K2M4N6P8R0T2V4W6Y8", Name: "Test User", Email: "test@example.com"}
 	mockService.On("GetUsersByEmails", []string{"test@example.com"}).Return([]*repository.User{expectedUser}, nil).Once()
 
 	req := httptest.NewRequest("GET", "/users/by-email/test@example.com", nil)
@@ -165,8 +195,8 @@ func TestUserHandler_GetUserByEmailHandler(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, rr.Code)
 	var retrievedUser repository.User
-	json.NewDecoder(rr.Body).Decode(&retrievedUser)
-	assert.Equal(t, expectedUser, &retrievedUser)
+	decodeData(t, rr, &retrievedUser)
+	assert.Equal(t, apiUser(expectedUser), &retrievedUser)
 	mockService.AssertExpectations(t)
 
 	// Test case 2: Missing email parameter
@@ -183,7 +213,7 @@ func TestUserHandler_GetUserByEmailHandler(t *testing.T) {
 		handler.GetUserByEmailHandler(rr, req)
 
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
-		assert.Contains(t, rr.Body.String(), "Email parameter is required\n")
+		assert.Equal(t, "Email parameter is required", errorMessage(t, rr))
 		mockService.AssertNotCalled(t, "GetUsersByEmails")
 	}
 	mockService.AssertNotCalled(t, "GetUsersByEmails")
@@ -218,7 +248,7 @@ func TestUserHandler_GetUserByEmailHandler(t *testing.T) {
 }
 
 func TestUserHandler_GetUsersByIDsHandler(t *testing.T) {
-	mockService := new(MockUserService)
+	mockService := new(servicemock.UserService)
 	//handler := NewUserHandler(mockService)
 
 	// Setup users for testing
@@ -254,3 +284,10 @@ func TestUserHandler_GetUsersByIDsHandler(t *testing.T) {
 		mockService.AssertExpectations(t)
 	}
 }
+
+// apiUser is user as a client sees it, without the number the API keeps to itself.
+func apiUser(user *repository.User) *repository.User {
+	seen := *user
+	seen.ID = 0
+	return &seen
+}
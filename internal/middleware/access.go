@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/aadithya-md/split-expense/internal/response"
+)
+
+// ParseIPNets parses a list of IP addresses and CIDR ranges. A bare address matches only itself.
+func ParseIPNets(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			_, ipNet, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR range %q: %w", entry, err)
+			}
+			nets = append(nets, ipNet)
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", entry)
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return nets, nil
+}
+
+// IPAllowlist rejects requests whose peer address is outside the allowed networks with 403.
+// Forwarding headers are not trusted, so the server must be reached directly or through a proxy on an allowed address.
+func IPAllowlist(allowed []*net.IPNet) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+			if ip := net.ParseIP(host); ip != nil {
+				for _, n := range allowed {
+					if n.Contains(ip) {
+						next.ServeHTTP(w, r)
+						return
+					}
+				}
+			}
+			log.Printf("rejected %s %s from %s: address not allowed", r.Method, r.URL.Path, r.RemoteAddr)
+			response.Error(w, r, "Forbidden", http.StatusForbidden)
+		})
+	}
+}
+
+// BasicAuth requires HTTP basic credentials matching username and password. An empty password
+// rejects every request, so an unconfigured deployment stays locked.
+func BasicAuth(realm, username, password string) Middleware {
+	wantUser := sha256.Sum256([]byte(username))
+	wantPass := sha256.Sum256([]byte(password))
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, pass, ok := r.BasicAuth()
+			if ok && password != "" {
+				gotUser := sha256.Sum256([]byte(user))
+				gotPass := sha256.Sum256([]byte(pass))
+				userMatch := subtle.ConstantTimeCompare(gotUser[:], wantUser[:])
+				passMatch := subtle.ConstantTimeCompare(gotPass[:], wantPass[:])
+				if userMatch&passMatch == 1 {
+					SetAuthenticatedUser(r, user)
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", realm))
+			response.Error(w, r, "Unauthorized", http.StatusUnauthorized)
+		})
+	}
+}
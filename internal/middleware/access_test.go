@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+func TestParseIPNets(t *testing.T) {
+	nets, err := ParseIPNets([]string{"127.0.0.1", "10.0.0.0/8", "::1"})
+	require.NoError(t, err)
+	require.Len(t, nets, 3)
+	assert.Equal(t, "127.0.0.1/32", nets[0].String())
+	assert.Equal(t, "10.0.0.0/8", nets[1].String())
+	assert.Equal(t, "::1/128", nets[2].String())
+
+	_, err = ParseIPNets([]string{"localhost"})
+	assert.Error(t, err)
+	_, err = ParseIPNets([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
+
+func TestIPAllowlist(t *testing.T) {
+	nets, err := ParseIPNets([]string{"127.0.0.1", "10.0.0.0/8"})
+	require.NoError(t, err)
+	h := IPAllowlist(nets)(okHandler)
+
+	for remote, want := range map[string]int{
+		"127.0.0.1:5000": http.StatusOK,
+		"10.1.2.3:5000":  http.StatusOK,
+		"192.168.1.1:80": http.StatusForbidden,
+		"[::1]:5000":     http.StatusForbidden,
+		"garbage":        http.StatusForbidden,
+	} {
+		req := httptest.NewRequest("GET", "/admin/audit", nil)
+		req.RemoteAddr = remote
+		// Forwarding headers must not let an outside client in
+		req.Header.Set("X-Forwarded-For", "127.0.0.1")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		assert.Equal(t, want, rr.Code, remote)
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	h := BasicAuth("admin", "root", "s3cret")(okHandler)
+
+	// Test case 1: Correct credentials
+	{
+		req := httptest.NewRequest("GET", "/admin/audit", nil)
+		req.SetBasicAuth("root", "s3cret")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	// Test case 2: Wrong or missing credentials are challenged
+	{
+		req := httptest.NewRequest("GET", "/admin/audit", nil)
+		req.SetBasicAuth("root", "guess")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Equal(t, `Basic realm="admin"`, rr.Header().Get("WWW-Authenticate"))
+
+		rr = httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest("GET", "/admin/audit", nil))
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	}
+
+	// Test case 3: No configured password locks everyone out
+	{
+		locked := BasicAuth("admin", "", "")(okHandler)
+		req := httptest.NewRequest("GET", "/admin/audit", nil)
+		req.SetBasicAuth("", "")
+		rr := httptest.NewRecorder()
+		locked.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	}
+}
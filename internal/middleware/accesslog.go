@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	mathrand "math/rand"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Access log formats. Every format logs the route template rather than the raw path, since paths
+// carry user emails.
+const (
+	LogFormatText     = "text"     // method, route, status and latency
+	LogFormatCommon   = "common"   // NCSA common log format
+	LogFormatCombined = "combined" // common plus referer and user agent
+	LogFormatJSON     = "json"     // one JSON object per request
+)
+
+// RequestIDHeader carries the request ID in both directions. A well-formed ID sent by the client
+// (or a proxy in front) is kept so a request can be followed across services.
+const RequestIDHeader = "X-Request-ID"
+
+type AccessLogOptions struct {
+	Format string
+	// Successful requests to SampledRoutes are only logged with probability SampleRate.
+	SampledRoutes []string
+	SampleRate    float64
+	Output        io.Writer // Defaults to stderr
+}
+
+type requestInfoKey struct{}
+
+// requestInfo is shared between the access log and inner middleware through the request context.
+type requestInfo struct {
+	id   string
+	user string
+}
+
+// RequestID returns the ID the access log assigned to the request, or "" outside of it.
+func RequestID(ctx context.Context) string {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		return info.id
+	}
+	return ""
+}
+
+// SetAuthenticatedUser records who the request was authenticated as, for the access log.
+func SetAuthenticatedUser(r *http.Request, user string) {
+	if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+		info.user = user
+	}
+}
+
+// Logging logs the method, route, status and latency of every request.
+func Logging(next http.Handler) http.Handler {
+	return AccessLog(AccessLogOptions{Format: LogFormatText})(next)
+}
+
+// AccessLog tags each request with an ID and logs it once served. Failed requests are always logged.
+func AccessLog(opts AccessLogOptions) Middleware {
+	return accessLog(opts, mathrand.Float64)
+}
+
+func accessLog(opts AccessLogOptions, random func() float64) Middleware {
+	out := opts.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	flags := 0
+	if opts.Format == LogFormatText || opts.Format == "" {
+		flags = log.LstdFlags
+	}
+	logger := log.New(out, "", flags)
+
+	sampled := make(map[string]bool, len(opts.SampledRoutes))
+	for _, route := range opts.SampledRoutes {
+		sampled[route] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			info := &requestInfo{id: r.Header.Get(RequestIDHeader)}
+			if !validRequestID(info.id) {
+				info.id = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, info.id)
+			r = r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info))
+
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+
+			route := routeTemplate(r)
+			if rec.status < http.StatusBadRequest && sampled[route] && random() >= opts.SampleRate {
+				return
+			}
+			logger.Print(formatAccessLog(opts.Format, r, route, info, rec, start))
+		})
+	}
+}
+
+func formatAccessLog(format string, r *http.Request, route string, info *requestInfo, rec *statusRecorder, start time.Time) string {
+	user := info.user
+	switch format {
+	case LogFormatCommon, LogFormatCombined:
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		if user == "" {
+			user = "-"
+		}
+		line := fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %d", host, user, start.Format("02/Jan/2006:15:04:05 -0700"), r.Method, route, r.Proto, rec.status, rec.bytes)
+		if format == LogFormatCombined {
+			line += fmt.Sprintf(" %q %q", r.Referer(), r.UserAgent())
+		}
+		return line
+	case LogFormatJSON:
+		entry, _ := json.Marshal(struct {
+			Time       time.Time `json:"time"`
+			RequestID  string    `json:"request_id"`
+			Method     string    `json:"method"`
+			Route      string    `json:"route"`
+			Status     int       `json:"status"`
+			Bytes      int       `json:"bytes"`
+			DurationMs float64   `json:"duration_ms"`
+			User       string    `json:"user,omitempty"`
+		}{start, info.id, r.Method, route, rec.status, rec.bytes, float64(time.Since(start).Microseconds()) / 1000, user})
+		return string(entry)
+	default:
+		line := fmt.Sprintf("%s %s %d %s request_id=%s", r.Method, route, rec.status, time.Since(start), info.id)
+		if user != "" {
+			line += " user=" + user
+		}
+		return line
+	}
+}
+
+// validRequestID accepts short IDs of visible ASCII so a client can't forge log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if c <= ' ' || c > '~' || c == '"' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
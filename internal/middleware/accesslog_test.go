@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAccessLogRouter(opts AccessLogOptions, status *int) *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(*status) })
+	r.Handle("/users/by-email/{email}", BasicAuth("admin", "root", "s3cret")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})))
+	// Every draw lands outside the sample
+	r.Use(mux.MiddlewareFunc(accessLog(opts, func() float64 { return 0.5 })))
+	return r
+}
+
+func TestAccessLog_Formats(t *testing.T) {
+	status := http.StatusOK
+	serve := func(format string) (string, *httptest.ResponseRecorder) {
+		var out bytes.Buffer
+		r := newAccessLogRouter(AccessLogOptions{Format: format, Output: &out}, &status)
+		req := httptest.NewRequest("GET", "/users/by-email/alice@example.com", nil)
+		req.RemoteAddr = "10.0.0.1:5000"
+		req.Header.Set(RequestIDHeader, "req-42")
+		req.Header.Set("User-Agent", "curl/8.0")
+		req.SetBasicAuth("root", "s3cret")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return out.String(), rr
+	}
+
+	// Test case 1: JSON carries the route template, user and request ID, never the raw path
+	{
+		line, rr := serve(LogFormatJSON)
+		assert.Equal(t, "req-42", rr.Header().Get(RequestIDHeader))
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		assert.Equal(t, "/users/by-email/{email}", entry["route"])
+		assert.Equal(t, "root", entry["user"])
+		assert.Equal(t, "req-42", entry["request_id"])
+		assert.Equal(t, float64(5), entry["bytes"])
+		assert.NotContains(t, line, "alice@example.com")
+	}
+
+	// Test case 2: Common and combined
+	{
+		line, _ := serve(LogFormatCommon)
+		assert.True(t, strings.HasPrefix(line, "10.0.0.1 - root ["), line)
+		assert.Contains(t, line, `"GET /users/by-email/{email} HTTP/1.1" 200 5`)
+
+		line, _ = serve(LogFormatCombined)
+		assert.Contains(t, line, `200 5 "" "curl/8.0"`)
+	}
+
+	// Test case 3: Text
+	{
+		line, _ := serve(LogFormatText)
+		assert.Contains(t, line, "GET /users/by-email/{email} 200")
+		assert.Contains(t, line, "request_id=req-42 user=root")
+	}
+}
+
+func TestAccessLog_RequestID(t *testing.T) {
+	status := http.StatusOK
+	r := newAccessLogRouter(AccessLogOptions{Format: LogFormatJSON, Output: &bytes.Buffer{}}, &status)
+
+	// A malformed ID is replaced rather than written into the logs
+	req := httptest.NewRequest("GET", "/health", nil)
+	req.Header.Set(RequestIDHeader, "forged\nline")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	id := rr.Header().Get(RequestIDHeader)
+	assert.Len(t, id, 16)
+	assert.NotEqual(t, "forged\nline", id)
+}
+
+func TestAccessLog_Sampling(t *testing.T) {
+	var out bytes.Buffer
+	status := http.StatusOK
+	r := newAccessLogRouter(AccessLogOptions{Format: LogFormatText, Output: &out, SampledRoutes: []string{"/health"}, SampleRate: 0.1}, &status)
+
+	// Test case 1: Successful requests to sampled routes are skipped
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+	assert.Empty(t, out.String())
+
+	// Test case 2: Other routes are always logged
+	req := httptest.NewRequest("GET", "/users/by-email/bob@example.com", nil)
+	req.SetBasicAuth("root", "s3cret")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Contains(t, out.String(), "GET /users/by-email/{email} 200")
+
+	// Test case 3: Failures on sampled routes are always logged
+	status = http.StatusServiceUnavailable
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+	assert.Contains(t, out.String(), "GET /health 503")
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/gorilla/mux"
+)
+
+// AuditRecorder persists audit log entries.
+type AuditRecorder interface {
+	RecordAuditLog(entry *repository.AuditLog) error
+}
+
+// actorFields are the request body fields naming the user a call acts as, in order of preference.
+var actorFields = []string{"created_by_email", "user_email", "payer_email", "lender_email", "email"}
+
+// Audit records every POST, PUT, PATCH and DELETE with its actor, route, a hash of the body, the
+// response status and latency. Failing to record is logged but never fails the request.
+func Audit(recorder AuditRecorder) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var body []byte
+			if r.Body != nil {
+				body, _ = io.ReadAll(r.Body)
+				r.Body.Close()
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+
+			hash := sha256.Sum256(body)
+			entry := &repository.AuditLog{
+				Actor:       auditActor(r, body),
+				Method:      r.Method,
+				Route:       routeTemplate(r),
+				Path:        r.URL.Path,
+				PayloadHash: hex.EncodeToString(hash[:]),
+				Status:      rec.status,
+				LatencyMs:   time.Since(start).Milliseconds(),
+			}
+			if err := recorder.RecordAuditLog(entry); err != nil {
+				log.Printf("failed to record audit log for %s %s: %v", r.Method, r.URL.Path, err)
+			}
+		})
+	}
+}
+
+// auditActor picks the acting user's email out of a JSON body, or a form body for the UI.
+func auditActor(r *http.Request, body []byte) string {
+	var fields map[string]interface{}
+	if json.Unmarshal(body, &fields) == nil {
+		for _, name := range actorFields {
+			if v, ok := fields[name].(string); ok && v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	if r.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
+		form, _ := url.ParseQuery(string(body))
+		for _, name := range actorFields {
+			if v := form.Get(name); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return r.URL.Path
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorderFunc func(entry *repository.AuditLog) error
+
+func (f recorderFunc) RecordAuditLog(entry *repository.AuditLog) error { return f(entry) }
+
+func TestAudit(t *testing.T) {
+	var entries []*repository.AuditLog
+	recorder := recorderFunc(func(entry *repository.AuditLog) error {
+		entries = append(entries, entry)
+		return nil
+	})
+
+	r := mux.NewRouter()
+	r.HandleFunc("/expenses/{id}/dispute", func(w http.ResponseWriter, r *http.Request) {
+		// The handler still sees the full body after the middleware has hashed it
+		body, _ := io.ReadAll(r.Body)
+		assert.Contains(t, string(body), "bob@example.com")
+		w.WriteHeader(http.StatusConflict)
+	}).Methods("POST")
+	r.HandleFunc("/expenses/{id}", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+	r.Use(mux.MiddlewareFunc(Audit(recorder)))
+
+	// Test case 1: Mutating calls are recorded with their route template and actor
+	{
+		body := `{"user_email":"bob@example.com","reason":"never ordered"}`
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest("POST", "/expenses/7/dispute", strings.NewReader(body)))
+
+		require.Len(t, entries, 1)
+		hash := sha256.Sum256([]byte(body))
+		assert.Equal(t, "bob@example.com", entries[0].Actor)
+		assert.Equal(t, "POST", entries[0].Method)
+		assert.Equal(t, "/expenses/{id}/dispute", entries[0].Route)
+		assert.Equal(t, "/expenses/7/dispute", entries[0].Path)
+		assert.Equal(t, hex.EncodeToString(hash[:]), entries[0].PayloadHash)
+		assert.Equal(t, http.StatusConflict, entries[0].Status)
+	}
+
+	// Test case 2: Reads are not recorded
+	{
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/expenses/7", nil))
+		assert.Len(t, entries, 1)
+	}
+
+	// Test case 3: A failing recorder doesn't fail the request
+	{
+		failing := Audit(recorderFunc(func(entry *repository.AuditLog) error { return errors.New("db down") }))
+		h := failing(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		}))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest("POST", "/users", strings.NewReader(`{"email":"carol@example.com"}`)))
+		assert.Equal(t, http.StatusCreated, rr.Code)
+	}
+}
+
+func TestAuditActor(t *testing.T) {
+	// Test case 1: created_by_email wins over the other fields
+	{
+		req := httptest.NewRequest("POST", "/expenses", nil)
+		assert.Equal(t, "alice@example.com", auditActor(req, []byte(`{"user_email":"bob@example.com","created_by_email":"alice@example.com"}`)))
+	}
+
+	// Test case 2: Form posts from the UI
+	{
+		req := httptest.NewRequest("POST", "/ui/new-expense", nil)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		assert.Equal(t, "alice@example.com", auditActor(req, []byte("description=Lunch&created_by_email=alice%40example.com")))
+	}
+
+	// Test case 3: No recognisable actor
+	{
+		req := httptest.NewRequest("POST", "/settlements/1/confirm", nil)
+		assert.Equal(t, "", auditActor(req, nil))
+	}
+}
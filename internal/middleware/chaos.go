@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	mathrand "math/rand"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/response"
+)
+
+// ChaosRule injects faults into requests to a route: a route template like "/expenses/{id}", or
+// "*" for every route. Method, when set, narrows the rule to requests with that method.
+type ChaosRule struct {
+	Method    string
+	Route     string
+	Latency   time.Duration // Added to every request
+	Jitter    time.Duration // Up to this much more, at random
+	ErrorRate float64       // Share of requests failed with Status instead of being served
+	Status    int           // Defaults to 503
+}
+
+func (rule ChaosRule) matches(method, route string) bool {
+	return (rule.Method == "" || strings.EqualFold(rule.Method, method)) && (rule.Route == "*" || rule.Route == route)
+}
+
+// Chaos delays and fails requests by the first rule matching them, to test how clients and their
+// retries cope. A failed request never reaches the handler, so it changes nothing, and a 429 or 503
+// carries Retry-After like a real one would. It is for development only, and must run inside the
+// router to see route templates.
+func Chaos(rules []ChaosRule) Middleware {
+	return chaos(rules, mathrand.Float64)
+}
+
+func chaos(rules []ChaosRule, random func() float64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeTemplate(r)
+			i := slices.IndexFunc(rules, func(rule ChaosRule) bool { return rule.matches(r.Method, route) })
+			if i < 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			rule := rules[i]
+
+			if delay := rule.Latency + time.Duration(random()*float64(rule.Jitter)); delay > 0 {
+				timer := time.NewTimer(delay)
+				select {
+				case <-timer.C:
+				case <-r.Context().Done():
+					timer.Stop()
+					return
+				}
+			}
+
+			if rule.ErrorRate > 0 && random() < rule.ErrorRate {
+				status := rule.Status
+				if status == 0 {
+					status = http.StatusServiceUnavailable
+				}
+				if status == http.StatusServiceUnavailable || status == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "1")
+				}
+				response.Error(w, r, "injected fault", status)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChaos(t *testing.T) {
+	served := 0
+	draw := 0.0
+	r := mux.NewRouter()
+	ok := func(w http.ResponseWriter, r *http.Request) { served++ }
+	r.HandleFunc("/expenses", ok).Methods("GET", "POST")
+	r.HandleFunc("/users/{id}", ok)
+	r.HandleFunc("/health", ok)
+	r.Use(mux.MiddlewareFunc(chaos([]ChaosRule{
+		{Method: "POST", Route: "/expenses", ErrorRate: 0.5},
+		{Route: "/users/{id}", Latency: 20 * time.Millisecond, ErrorRate: 0.5, Status: http.StatusInternalServerError},
+		{Route: "*", Jitter: time.Second},
+	}, func() float64 { return draw })))
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		return rr
+	}
+
+	// Test case 1: A request drawn into the error rate fails before the handler runs
+	draw = 0.1
+	rr := serve("POST", "/expenses")
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+	assert.Equal(t, 0, served)
+
+	// Test case 2: Rules match the route template and the method
+	start := time.Now()
+	rr = serve("GET", "/users/7")
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Empty(t, rr.Header().Get("Retry-After"))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	// Test case 3: Past the error rate the request is served, after the catch-all's jitter
+	draw = 0.01
+	start = time.Now()
+	assert.Equal(t, http.StatusOK, serve("GET", "/expenses").Code)
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+	assert.Equal(t, 1, served)
+}
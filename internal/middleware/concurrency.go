@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/aadithya-md/split-expense/internal/response"
+)
+
+// ConcurrencyLimit lets each client run at most limit requests through the wrapped handler at
+// once and answers the rest with 429, so a single caller can't tie up the database with expensive
+// queries. Clients are told apart by their peer address.
+func ConcurrencyLimit(limit int) Middleware {
+	var mu sync.Mutex
+	inFlight := make(map[string]int)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				client = r.RemoteAddr
+			}
+
+			mu.Lock()
+			if inFlight[client] >= limit {
+				mu.Unlock()
+				w.Header().Set("Retry-After", "1")
+				response.Error(w, r, "Too many concurrent requests", http.StatusTooManyRequests)
+				return
+			}
+			inFlight[client]++
+			mu.Unlock()
+
+			defer func() {
+				mu.Lock()
+				if inFlight[client]--; inFlight[client] == 0 {
+					delete(inFlight, client)
+				}
+				mu.Unlock()
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h := ConcurrencyLimit(1)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("block") != "" {
+			started <- struct{}{}
+			<-release
+		}
+	}))
+
+	serve := func(remote, path string) int {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = remote
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		serve("10.0.0.1:1000", "/analytics?block=1")
+	}()
+	<-started
+
+	// Test case 1: A second request from the same client is turned away while the first runs
+	assert.Equal(t, http.StatusTooManyRequests, serve("10.0.0.1:2000", "/analytics"))
+
+	// Test case 2: Other clients are unaffected
+	assert.Equal(t, http.StatusOK, serve("10.0.0.2:1000", "/analytics"))
+
+	// Test case 3: The slot is released once the first request finishes
+	close(release)
+	wg.Wait()
+	assert.Equal(t, http.StatusOK, serve("10.0.0.1:3000", "/analytics"))
+}
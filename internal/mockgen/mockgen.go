@@ -0,0 +1,181 @@
+// Package mockgen generates testify mocks of Go interfaces.
+package mockgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// Spec says which interfaces of a package to mock, and into which package.
+type Spec struct {
+	ImportPath string   // Package declaring the interfaces
+	Interfaces []string // Names of the interfaces to mock; empty mocks every exported one
+	Package    string   // Name of the generated package
+}
+
+// Generated lists the mocks kept under internal/mocks for the tests, by the file, relative to the
+// repository root, each package is written to.
+var Generated = []struct {
+	File string
+	Spec Spec
+}{
+	{"internal/mocks/repomock/mocks.go", Spec{ImportPath: "github.com/aadithya-md/split-expense/internal/repository", Package: "repomock"}},
+	{"internal/mocks/servicemock/mocks.go", Spec{ImportPath: "github.com/aadithya-md/split-expense/internal/service", Package: "servicemock"}},
+}
+
+// Generate returns the source of a package with a testify mock for each interface in spec. Each mock
+// is named after its interface. dir is where the module containing ImportPath is checked out.
+func Generate(dir string, spec Spec) ([]byte, error) {
+	imp := importer.ForCompiler(token.NewFileSet(), "source", nil).(types.ImporterFrom)
+	pkg, err := imp.ImportFrom(spec.ImportPath, dir, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", spec.ImportPath, err)
+	}
+
+	names := spec.Interfaces
+	if len(names) == 0 {
+		for _, name := range pkg.Scope().Names() {
+			obj := pkg.Scope().Lookup(name)
+			if _, ok := obj.Type().Underlying().(*types.Interface); ok && obj.Exported() {
+				if _, isType := obj.(*types.TypeName); isType {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+
+	g := &generator{imports: map[string]string{"github.com/stretchr/testify/mock": "mock"}}
+	var body bytes.Buffer
+	for _, name := range names {
+		obj := pkg.Scope().Lookup(name)
+		if obj == nil {
+			return nil, fmt.Errorf("%s has no %s", spec.ImportPath, name)
+		}
+		iface, ok := obj.Type().Underlying().(*types.Interface)
+		if !ok {
+			return nil, fmt.Errorf("%s.%s is not an interface", pkg.Name(), name)
+		}
+		if err := g.writeMock(&body, obj.Type().(*types.Named), iface); err != nil {
+			return nil, err
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by cmd/genmocks. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "// Package %s has testify mocks of the %s interfaces.\n", spec.Package, pkg.Name())
+	fmt.Fprintf(&out, "package %s\n\nimport (\n", spec.Package)
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	// Standard library first, like goimports
+	isStd := func(path string) bool { return !strings.Contains(strings.Split(path, "/")[0], ".") }
+	sort.Slice(paths, func(i, j int) bool {
+		if isStd(paths[i]) != isStd(paths[j]) {
+			return isStd(paths[i])
+		}
+		return paths[i] < paths[j]
+	})
+	for i, path := range paths {
+		if i > 0 && isStd(paths[i-1]) && !isStd(path) {
+			out.WriteString("\n")
+		}
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+	out.WriteString(")\n")
+	out.Write(body.Bytes())
+	return format.Source(out.Bytes())
+}
+
+type generator struct {
+	imports map[string]string // Import path to package name
+	err     error
+}
+
+func (g *generator) qualifier(pkg *types.Package) string {
+	if name, ok := g.imports[pkg.Path()]; ok && name != pkg.Name() {
+		g.err = fmt.Errorf("packages %s and %s are both named %s", pkg.Path(), name, pkg.Name())
+	}
+	for path, name := range g.imports {
+		if name == pkg.Name() && path != pkg.Path() {
+			g.err = fmt.Errorf("packages %s and %s are both named %s", pkg.Path(), path, name)
+		}
+	}
+	g.imports[pkg.Path()] = pkg.Name()
+	return pkg.Name()
+}
+
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, g.qualifier)
+}
+
+func (g *generator) writeMock(out *bytes.Buffer, named *types.Named, iface *types.Interface) error {
+	name := named.Obj().Name()
+	qualified := g.typeString(named)
+	fmt.Fprintf(out, "\n// %s is a mock of %s.\ntype %s struct {\n\tmock.Mock\n}\n\n", name, qualified, name)
+	fmt.Fprintf(out, "var _ %s = (*%s)(nil)\n", qualified, name)
+
+	for i := 0; i < iface.NumMethods(); i++ {
+		g.writeMethod(out, name, iface.Method(i))
+	}
+	return g.err
+}
+
+func (g *generator) writeMethod(out *bytes.Buffer, mockName string, method *types.Func) {
+	sig := method.Type().(*types.Signature)
+
+	params := make([]string, sig.Params().Len())
+	args := make([]string, sig.Params().Len())
+	for i := range params {
+		p := sig.Params().At(i)
+		argName := p.Name()
+		if argName == "" || argName == "_" || argName == "m" || argName == "args" {
+			argName = fmt.Sprintf("arg%d", i)
+		}
+		typ := g.typeString(p.Type())
+		if sig.Variadic() && i == len(params)-1 {
+			// Recorded as the slice it arrives as
+			typ = "..." + g.typeString(p.Type().(*types.Slice).Elem())
+		}
+		params[i] = argName + " " + typ
+		args[i] = argName
+	}
+	call := "m.Called(" + strings.Join(args, ", ") + ")"
+
+	results := make([]string, sig.Results().Len())
+	for i := range results {
+		results[i] = g.typeString(sig.Results().At(i).Type())
+	}
+	resultList := strings.Join(results, ", ")
+	if len(results) > 1 {
+		resultList = "(" + resultList + ")"
+	}
+
+	fmt.Fprintf(out, "\nfunc (m *%s) %s(%s) %s {\n", mockName, method.Name(), strings.Join(params, ", "), resultList)
+	switch {
+	case len(results) == 0:
+		fmt.Fprintf(out, "\t%s\n", call)
+	case len(results) == 1 && results[0] == "error":
+		fmt.Fprintf(out, "\treturn %s.Error(0)\n", call)
+	default:
+		fmt.Fprintf(out, "\targs := %s\n", call)
+		returns := make([]string, len(results))
+		for i, typ := range results {
+			if typ == "error" {
+				returns[i] = fmt.Sprintf("args.Error(%d)", i)
+				continue
+			}
+			// Tolerates a nil the test passed without a type
+			fmt.Fprintf(out, "\tr%d, _ := args.Get(%d).(%s)\n", i, i, typ)
+			returns[i] = fmt.Sprintf("r%d", i)
+		}
+		fmt.Fprintf(out, "\treturn %s\n", strings.Join(returns, ", "))
+	}
+	out.WriteString("}\n")
+}
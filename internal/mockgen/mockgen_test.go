@@ -0,0 +1,30 @@
+package mockgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	const sample = "github.com/aadithya-md/split-expense/internal/mockgen/testdata/sample"
+	src, err := Generate("../..", Spec{ImportPath: sample, Package: "fake"})
+	require.NoError(t, err)
+	out := string(src)
+
+	// Test case 1: Results are read so that an untyped nil doesn't panic, and a lone error directly
+	assert.Contains(t, out, "func (m *JobService) GetJob(id int64) (*sample.Job, error) {\n\targs := m.Called(id)\n\tr0, _ := args.Get(0).(*sample.Job)\n\treturn r0, args.Error(1)\n}")
+	assert.Contains(t, out, "func (m *Notifier) Notify(ctx context.Context, n sample.Notification) error {\n\treturn m.Called(ctx, n).Error(0)\n}")
+
+	// Test case 2: Methods without results only record the call
+	assert.Contains(t, out, "func (m *JobService) Run(ctx context.Context) {\n\tm.Called(ctx)\n}")
+
+	// Test case 3: With no interfaces named, every interface is mocked and nothing else
+	assert.Contains(t, out, "type Notifier struct {")
+	assert.NotContains(t, out, "type Job struct {")
+
+	// Test case 4: Unknown interfaces are refused
+	_, err = Generate("../..", Spec{ImportPath: sample, Interfaces: []string{"Nope"}, Package: "fake"})
+	assert.ErrorContains(t, err, "has no Nope")
+}
@@ -0,0 +1,18 @@
+// Package sample has interfaces for the mockgen tests to mock, so they don't have to load the
+// whole module.
+package sample
+
+import "context"
+
+type Job struct{ ID int64 }
+
+type Notification struct{ To string }
+
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+type JobService interface {
+	GetJob(id int64) (*Job, error)
+	Run(ctx context.Context)
+}
@@ -0,0 +1,758 @@
+// Code generated by cmd/genmocks. DO NOT EDIT.
+
+// Package repomock has testify mocks of the repository interfaces.
+package repomock
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+// AuditRepository is a mock of repository.AuditRepository.
+type AuditRepository struct {
+	mock.Mock
+}
+
+var _ repository.AuditRepository = (*AuditRepository)(nil)
+
+func (m *AuditRepository) CreateAuditLog(entry *repository.AuditLog) error {
+	return m.Called(entry).Error(0)
+}
+
+func (m *AuditRepository) ListAuditLogs(filter repository.AuditLogFilter) ([]repository.AuditLog, error) {
+	args := m.Called(filter)
+	r0, _ := args.Get(0).([]repository.AuditLog)
+	return r0, args.Error(1)
+}
+
+// BalanceRepository is a mock of repository.BalanceRepository.
+type BalanceRepository struct {
+	mock.Mock
+}
+
+var _ repository.BalanceRepository = (*BalanceRepository)(nil)
+
+func (m *BalanceRepository) GetBalancesByUserID(userID int) ([]repository.Balance, error) {
+	args := m.Called(userID)
+	r0, _ := args.Get(0).([]repository.Balance)
+	return r0, args.Error(1)
+}
+
+func (m *BalanceRepository) GetDirectedBalances(userID int, direction repository.DebtDirection, limit int, offset int) (*repository.DirectedBalances, error) {
+	args := m.Called(userID, direction, limit, offset)
+	r0, _ := args.Get(0).(*repository.DirectedBalances)
+	return r0, args.Error(1)
+}
+
+func (m *BalanceRepository) GetOverallBalanceByUserID(userID int) (float64, error) {
+	args := m.Called(userID)
+	r0, _ := args.Get(0).(float64)
+	return r0, args.Error(1)
+}
+
+func (m *BalanceRepository) UpdateBalance(tx *sql.Tx, source repository.LedgerSource, user1ID int, user2ID int, amount float64) error {
+	return m.Called(tx, source, user1ID, user2ID, amount).Error(0)
+}
+
+func (m *BalanceRepository) UpdateBalances(tx *sql.Tx, source repository.LedgerSource, updates []repository.BalanceUpdate) error {
+	return m.Called(tx, source, updates).Error(0)
+}
+
+// BudgetRepository is a mock of repository.BudgetRepository.
+type BudgetRepository struct {
+	mock.Mock
+}
+
+var _ repository.BudgetRepository = (*BudgetRepository)(nil)
+
+func (m *BudgetRepository) DeleteTagBudget(userID int, tag string, currency string) error {
+	return m.Called(userID, tag, currency).Error(0)
+}
+
+func (m *BudgetRepository) GetTagBudgets(userIDs []int) ([]repository.TagBudget, error) {
+	args := m.Called(userIDs)
+	r0, _ := args.Get(0).([]repository.TagBudget)
+	return r0, args.Error(1)
+}
+
+func (m *BudgetRepository) GetTagSpend(userID int, tag string, currency string, since time.Time) (float64, error) {
+	args := m.Called(userID, tag, currency, since)
+	r0, _ := args.Get(0).(float64)
+	return r0, args.Error(1)
+}
+
+func (m *BudgetRepository) SetTagBudget(budget *repository.TagBudget) error {
+	return m.Called(budget).Error(0)
+}
+
+// CurrencyRepository is a mock of repository.CurrencyRepository.
+type CurrencyRepository struct {
+	mock.Mock
+}
+
+var _ repository.CurrencyRepository = (*CurrencyRepository)(nil)
+
+func (m *CurrencyRepository) GetCurrencyExponents() (map[string]int, error) {
+	args := m.Called()
+	r0, _ := args.Get(0).(map[string]int)
+	return r0, args.Error(1)
+}
+
+// EventRepository is a mock of repository.EventRepository.
+type EventRepository struct {
+	mock.Mock
+}
+
+var _ repository.EventRepository = (*EventRepository)(nil)
+
+func (m *EventRepository) AddEventMember(eventID int, userID int) error {
+	return m.Called(eventID, userID).Error(0)
+}
+
+func (m *EventRepository) ArchiveEvent(id int) (*repository.Event, error) {
+	args := m.Called(id)
+	r0, _ := args.Get(0).(*repository.Event)
+	return r0, args.Error(1)
+}
+
+func (m *EventRepository) ClaimSettleUp(id int, last *time.Time, at time.Time) (bool, error) {
+	args := m.Called(id, last, at)
+	r0, _ := args.Get(0).(bool)
+	return r0, args.Error(1)
+}
+
+func (m *EventRepository) CreateEvent(event *repository.Event) (*repository.Event, error) {
+	args := m.Called(event)
+	r0, _ := args.Get(0).(*repository.Event)
+	return r0, args.Error(1)
+}
+
+func (m *EventRepository) GetEvent(id int) (*repository.Event, error) {
+	args := m.Called(id)
+	r0, _ := args.Get(0).(*repository.Event)
+	return r0, args.Error(1)
+}
+
+func (m *EventRepository) GetEventExpenses(eventID int) ([]repository.EventExpense, error) {
+	args := m.Called(eventID)
+	r0, _ := args.Get(0).([]repository.EventExpense)
+	return r0, args.Error(1)
+}
+
+func (m *EventRepository) GetEventSplits(eventID int) ([]repository.EventSplit, error) {
+	args := m.Called(eventID)
+	r0, _ := args.Get(0).([]repository.EventSplit)
+	return r0, args.Error(1)
+}
+
+func (m *EventRepository) GetEventsDueForSettleUp(before time.Time) ([]repository.Event, error) {
+	args := m.Called(before)
+	r0, _ := args.Get(0).([]repository.Event)
+	return r0, args.Error(1)
+}
+
+func (m *EventRepository) ListEvents(userID int, includeArchived bool) ([]repository.Event, error) {
+	args := m.Called(userID, includeArchived)
+	r0, _ := args.Get(0).([]repository.Event)
+	return r0, args.Error(1)
+}
+
+func (m *EventRepository) SetAutoSettle(id int, enabled bool) (*repository.Event, error) {
+	args := m.Called(id, enabled)
+	r0, _ := args.Get(0).(*repository.Event)
+	return r0, args.Error(1)
+}
+
+func (m *EventRepository) SetBaseCurrency(id int, currency string) (*repository.Event, error) {
+	args := m.Called(id, currency)
+	r0, _ := args.Get(0).(*repository.Event)
+	return r0, args.Error(1)
+}
+
+func (m *EventRepository) UnarchiveEvent(id int) (*repository.Event, error) {
+	args := m.Called(id)
+	r0, _ := args.Get(0).(*repository.Event)
+	return r0, args.Error(1)
+}
+
+// ExpenseEventRepository is a mock of repository.ExpenseEventRepository.
+type ExpenseEventRepository struct {
+	mock.Mock
+}
+
+var _ repository.ExpenseEventRepository = (*ExpenseEventRepository)(nil)
+
+func (m *ExpenseEventRepository) GetExpenseEvents(expenseID int) ([]repository.ExpenseEvent, error) {
+	args := m.Called(expenseID)
+	r0, _ := args.Get(0).([]repository.ExpenseEvent)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseEventRepository) RebuildProjections() (*repository.ProjectionReport, error) {
+	args := m.Called()
+	r0, _ := args.Get(0).(*repository.ProjectionReport)
+	return r0, args.Error(1)
+}
+
+// ExpenseRepository is a mock of repository.ExpenseRepository.
+type ExpenseRepository struct {
+	mock.Mock
+}
+
+var _ repository.ExpenseRepository = (*ExpenseRepository)(nil)
+
+func (m *ExpenseRepository) ClaimShare(expenseID int, userID int) (time.Time, error) {
+	args := m.Called(expenseID, userID)
+	r0, _ := args.Get(0).(time.Time)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseRepository) CreateExpense(expense *repository.Expense, splits []repository.ExpenseSplit, balanceUpdates []repository.BalanceUpdate) (*repository.Expense, error) {
+	args := m.Called(expense, splits, balanceUpdates)
+	r0, _ := args.Get(0).(*repository.Expense)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseRepository) DeleteExpense(id int, balanceUpdates []repository.BalanceUpdate) error {
+	return m.Called(id, balanceUpdates).Error(0)
+}
+
+func (m *ExpenseRepository) GetDailySpend(userID int, from time.Time, to time.Time) ([]repository.DailySpend, error) {
+	args := m.Called(userID, from, to)
+	r0, _ := args.Get(0).([]repository.DailySpend)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseRepository) GetExpense(id int) (*repository.Expense, error) {
+	args := m.Called(id)
+	r0, _ := args.Get(0).(*repository.Expense)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseRepository) GetExpenseByPublicID(publicID string) (*repository.Expense, error) {
+	args := m.Called(publicID)
+	r0, _ := args.Get(0).(*repository.Expense)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseRepository) GetExpensePageByUserID(userID int, limit int, offset int) (*repository.UserExpensePage, error) {
+	args := m.Called(userID, limit, offset)
+	r0, _ := args.Get(0).(*repository.UserExpensePage)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseRepository) GetExpenseSplits(expenseID int) ([]repository.ExpenseSplit, error) {
+	args := m.Called(expenseID)
+	r0, _ := args.Get(0).([]repository.ExpenseSplit)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseRepository) GetExpensesByUserID(userID int) ([]repository.UserExpenseView, error) {
+	args := m.Called(userID)
+	r0, _ := args.Get(0).([]repository.UserExpenseView)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseRepository) GetNearbyExpenses(userID int, latitude float64, longitude float64, radius float64) ([]repository.NearbyExpense, error) {
+	args := m.Called(userID, latitude, longitude, radius)
+	r0, _ := args.Get(0).([]repository.NearbyExpense)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseRepository) GetRefundedAmount(expenseID int) (float64, error) {
+	args := m.Called(expenseID)
+	r0, _ := args.Get(0).(float64)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseRepository) GetShareClaims(expenseID int) (map[int]time.Time, error) {
+	args := m.Called(expenseID)
+	r0, _ := args.Get(0).(map[int]time.Time)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseRepository) GetSplitsForExpensesInvolving(userIDs []int) ([]repository.ExpenseSplit, error) {
+	args := m.Called(userIDs)
+	r0, _ := args.Get(0).([]repository.ExpenseSplit)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseRepository) GetUserActivity(userID int, from time.Time, to time.Time) ([]repository.ExpenseActivity, error) {
+	args := m.Called(userID, from, to)
+	r0, _ := args.Get(0).([]repository.ExpenseActivity)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseRepository) HasDisputedExpenseBetween(user1ID int, user2ID int) (bool, error) {
+	args := m.Called(user1ID, user2ID)
+	r0, _ := args.Get(0).(bool)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseRepository) TransitionExpense(id int, from repository.ExpenseStatus, to repository.ExpenseStatus, reason string) (*repository.Expense, error) {
+	args := m.Called(id, from, to, reason)
+	r0, _ := args.Get(0).(*repository.Expense)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseRepository) UnlockExpense(id int) (*repository.Expense, error) {
+	args := m.Called(id)
+	r0, _ := args.Get(0).(*repository.Expense)
+	return r0, args.Error(1)
+}
+
+// GoalRepository is a mock of repository.GoalRepository.
+type GoalRepository struct {
+	mock.Mock
+}
+
+var _ repository.GoalRepository = (*GoalRepository)(nil)
+
+func (m *GoalRepository) CreateGoal(goal *repository.Goal) (*repository.Goal, error) {
+	args := m.Called(goal)
+	r0, _ := args.Get(0).(*repository.Goal)
+	return r0, args.Error(1)
+}
+
+func (m *GoalRepository) GetGoalsByUserID(userID int) ([]repository.Goal, error) {
+	args := m.Called(userID)
+	r0, _ := args.Get(0).([]repository.Goal)
+	return r0, args.Error(1)
+}
+
+// ImportRepository is a mock of repository.ImportRepository.
+type ImportRepository struct {
+	mock.Mock
+}
+
+var _ repository.ImportRepository = (*ImportRepository)(nil)
+
+func (m *ImportRepository) CommitImportSession(id int, chunkCount int) (bool, error) {
+	args := m.Called(id, chunkCount)
+	r0, _ := args.Get(0).(bool)
+	return r0, args.Error(1)
+}
+
+func (m *ImportRepository) CreateImportSession(session *repository.ImportSession) (*repository.ImportSession, error) {
+	args := m.Called(session)
+	r0, _ := args.Get(0).(*repository.ImportSession)
+	return r0, args.Error(1)
+}
+
+func (m *ImportRepository) FinishImportSession(id int, status repository.ImportStatus, lastError string) error {
+	return m.Called(id, status, lastError).Error(0)
+}
+
+func (m *ImportRepository) GetImportData(sessionID int, count int) ([]byte, error) {
+	args := m.Called(sessionID, count)
+	r0, _ := args.Get(0).([]byte)
+	return r0, args.Error(1)
+}
+
+func (m *ImportRepository) GetImportSession(id int) (*repository.ImportSession, error) {
+	args := m.Called(id)
+	r0, _ := args.Get(0).(*repository.ImportSession)
+	return r0, args.Error(1)
+}
+
+func (m *ImportRepository) PutImportChunk(sessionID int, seq int, data []byte) error {
+	return m.Called(sessionID, seq, data).Error(0)
+}
+
+func (m *ImportRepository) SetImportJob(id int, jobID int64) error {
+	return m.Called(id, jobID).Error(0)
+}
+
+func (m *ImportRepository) SetImportProgress(id int, rowsImported int) error {
+	return m.Called(id, rowsImported).Error(0)
+}
+
+// JobRepository is a mock of repository.JobRepository.
+type JobRepository struct {
+	mock.Mock
+}
+
+var _ repository.JobRepository = (*JobRepository)(nil)
+
+func (m *JobRepository) ClaimNextJob(now time.Time, lease time.Duration) (*repository.Job, error) {
+	args := m.Called(now, lease)
+	r0, _ := args.Get(0).(*repository.Job)
+	return r0, args.Error(1)
+}
+
+func (m *JobRepository) CompleteJob(id int64) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *JobRepository) CountJobsByStatus() (map[repository.JobStatus]int, error) {
+	args := m.Called()
+	r0, _ := args.Get(0).(map[repository.JobStatus]int)
+	return r0, args.Error(1)
+}
+
+func (m *JobRepository) CreateJob(job *repository.Job) (*repository.Job, error) {
+	args := m.Called(job)
+	r0, _ := args.Get(0).(*repository.Job)
+	return r0, args.Error(1)
+}
+
+func (m *JobRepository) FailJob(id int64, errMsg string, retryAt time.Time) error {
+	return m.Called(id, errMsg, retryAt).Error(0)
+}
+
+func (m *JobRepository) GetJob(id int64) (*repository.Job, error) {
+	args := m.Called(id)
+	r0, _ := args.Get(0).(*repository.Job)
+	return r0, args.Error(1)
+}
+
+func (m *JobRepository) SetJobProgress(id int64, done int, total int) error {
+	return m.Called(id, done, total).Error(0)
+}
+
+// LedgerRepository is a mock of repository.LedgerRepository.
+type LedgerRepository struct {
+	mock.Mock
+}
+
+var _ repository.LedgerRepository = (*LedgerRepository)(nil)
+
+func (m *LedgerRepository) CheckBalances() ([]repository.BalanceDrift, error) {
+	args := m.Called()
+	r0, _ := args.Get(0).([]repository.BalanceDrift)
+	return r0, args.Error(1)
+}
+
+func (m *LedgerRepository) GetBalancesAt(userID int, at time.Time) ([]repository.Balance, error) {
+	args := m.Called(userID, at)
+	r0, _ := args.Get(0).([]repository.Balance)
+	return r0, args.Error(1)
+}
+
+func (m *LedgerRepository) GetEntries(userID int, at time.Time) ([]repository.LedgerEntry, error) {
+	args := m.Called(userID, at)
+	r0, _ := args.Get(0).([]repository.LedgerEntry)
+	return r0, args.Error(1)
+}
+
+func (m *LedgerRepository) GetUnsettledEntries(userID int) ([]repository.LedgerEntry, error) {
+	args := m.Called(userID)
+	r0, _ := args.Get(0).([]repository.LedgerEntry)
+	return r0, args.Error(1)
+}
+
+func (m *LedgerRepository) RebuildBalances() ([]repository.BalanceDrift, error) {
+	args := m.Called()
+	r0, _ := args.Get(0).([]repository.BalanceDrift)
+	return r0, args.Error(1)
+}
+
+// LoanRepository is a mock of repository.LoanRepository.
+type LoanRepository struct {
+	mock.Mock
+}
+
+var _ repository.LoanRepository = (*LoanRepository)(nil)
+
+func (m *LoanRepository) ClaimReminder(id int, at time.Time) (bool, error) {
+	args := m.Called(id, at)
+	r0, _ := args.Get(0).(bool)
+	return r0, args.Error(1)
+}
+
+func (m *LoanRepository) CreateLoan(loan *repository.Loan) (*repository.Loan, error) {
+	args := m.Called(loan)
+	r0, _ := args.Get(0).(*repository.Loan)
+	return r0, args.Error(1)
+}
+
+func (m *LoanRepository) GetLoan(id int) (*repository.Loan, error) {
+	args := m.Called(id)
+	r0, _ := args.Get(0).(*repository.Loan)
+	return r0, args.Error(1)
+}
+
+func (m *LoanRepository) GetLoansByUserID(userID int) ([]repository.Loan, error) {
+	args := m.Called(userID)
+	r0, _ := args.Get(0).([]repository.Loan)
+	return r0, args.Error(1)
+}
+
+func (m *LoanRepository) GetLoansDueForReminder(day time.Time) ([]repository.Loan, error) {
+	args := m.Called(day)
+	r0, _ := args.Get(0).([]repository.Loan)
+	return r0, args.Error(1)
+}
+
+// NotificationPreferenceRepository is a mock of repository.NotificationPreferenceRepository.
+type NotificationPreferenceRepository struct {
+	mock.Mock
+}
+
+var _ repository.NotificationPreferenceRepository = (*NotificationPreferenceRepository)(nil)
+
+func (m *NotificationPreferenceRepository) ClaimDigest(userID int, last *time.Time, at time.Time) (bool, error) {
+	args := m.Called(userID, last, at)
+	r0, _ := args.Get(0).(bool)
+	return r0, args.Error(1)
+}
+
+func (m *NotificationPreferenceRepository) GetDueDigests(now time.Time) ([]repository.DueDigest, error) {
+	args := m.Called(now)
+	r0, _ := args.Get(0).([]repository.DueDigest)
+	return r0, args.Error(1)
+}
+
+func (m *NotificationPreferenceRepository) GetPreferences(userID int) (*repository.NotificationPreferences, error) {
+	args := m.Called(userID)
+	r0, _ := args.Get(0).(*repository.NotificationPreferences)
+	return r0, args.Error(1)
+}
+
+func (m *NotificationPreferenceRepository) SetDigestFrequency(userID int, frequency repository.DigestFrequency) error {
+	return m.Called(userID, frequency).Error(0)
+}
+
+func (m *NotificationPreferenceRepository) SetPreferences(prefs *repository.NotificationPreferences) error {
+	return m.Called(prefs).Error(0)
+}
+
+// PartyRepository is a mock of repository.PartyRepository.
+type PartyRepository struct {
+	mock.Mock
+}
+
+var _ repository.PartyRepository = (*PartyRepository)(nil)
+
+func (m *PartyRepository) CreateParty(party *repository.Party) (*repository.Party, error) {
+	args := m.Called(party)
+	r0, _ := args.Get(0).(*repository.Party)
+	return r0, args.Error(1)
+}
+
+func (m *PartyRepository) GetParty(id int) (*repository.Party, error) {
+	args := m.Called(id)
+	r0, _ := args.Get(0).(*repository.Party)
+	return r0, args.Error(1)
+}
+
+func (m *PartyRepository) ListParties() ([]repository.Party, error) {
+	args := m.Called()
+	r0, _ := args.Get(0).([]repository.Party)
+	return r0, args.Error(1)
+}
+
+// PaymentHandleRepository is a mock of repository.PaymentHandleRepository.
+type PaymentHandleRepository struct {
+	mock.Mock
+}
+
+var _ repository.PaymentHandleRepository = (*PaymentHandleRepository)(nil)
+
+func (m *PaymentHandleRepository) GetPaymentHandles(userIDs []int) (map[int]repository.PaymentHandles, error) {
+	args := m.Called(userIDs)
+	r0, _ := args.Get(0).(map[int]repository.PaymentHandles)
+	return r0, args.Error(1)
+}
+
+func (m *PaymentHandleRepository) SetPaymentHandles(handles *repository.PaymentHandles) error {
+	return m.Called(handles).Error(0)
+}
+
+// QueryStatsRepository is a mock of repository.QueryStatsRepository.
+type QueryStatsRepository struct {
+	mock.Mock
+}
+
+var _ repository.QueryStatsRepository = (*QueryStatsRepository)(nil)
+
+func (m *QueryStatsRepository) ExplainQuery(name string) ([]map[string]any, error) {
+	args := m.Called(name)
+	r0, _ := args.Get(0).([]map[string]any)
+	return r0, args.Error(1)
+}
+
+func (m *QueryStatsRepository) SlowestQueries(n int) ([]repository.QueryStats, error) {
+	args := m.Called(n)
+	r0, _ := args.Get(0).([]repository.QueryStats)
+	return r0, args.Error(1)
+}
+
+// QuotaRepository is a mock of repository.QuotaRepository.
+type QuotaRepository struct {
+	mock.Mock
+}
+
+var _ repository.QuotaRepository = (*QuotaRepository)(nil)
+
+func (m *QuotaRepository) CountActiveEvents(userID int) (int, error) {
+	args := m.Called(userID)
+	r0, _ := args.Get(0).(int)
+	return r0, args.Error(1)
+}
+
+func (m *QuotaRepository) CountExpensesCreatedSince(userID int, since time.Time) (int, error) {
+	args := m.Called(userID, since)
+	r0, _ := args.Get(0).(int)
+	return r0, args.Error(1)
+}
+
+func (m *QuotaRepository) CountImportBytes(userID int) (int64, error) {
+	args := m.Called(userID)
+	r0, _ := args.Get(0).(int64)
+	return r0, args.Error(1)
+}
+
+// SettlementRepository is a mock of repository.SettlementRepository.
+type SettlementRepository struct {
+	mock.Mock
+}
+
+var _ repository.SettlementRepository = (*SettlementRepository)(nil)
+
+func (m *SettlementRepository) CreateSettlement(settlement *repository.Settlement) (*repository.Settlement, error) {
+	args := m.Called(settlement)
+	r0, _ := args.Get(0).(*repository.Settlement)
+	return r0, args.Error(1)
+}
+
+func (m *SettlementRepository) CreateSettlements(settlements []*repository.Settlement) ([]*repository.Settlement, error) {
+	args := m.Called(settlements)
+	r0, _ := args.Get(0).([]*repository.Settlement)
+	return r0, args.Error(1)
+}
+
+func (m *SettlementRepository) GetSettlement(id int) (*repository.Settlement, error) {
+	args := m.Called(id)
+	r0, _ := args.Get(0).(*repository.Settlement)
+	return r0, args.Error(1)
+}
+
+func (m *SettlementRepository) GetSettlementByPaymentReference(provider string, reference string) (*repository.Settlement, error) {
+	args := m.Called(provider, reference)
+	r0, _ := args.Get(0).(*repository.Settlement)
+	return r0, args.Error(1)
+}
+
+func (m *SettlementRepository) GetSettlementsByUserID(userID int) ([]repository.Settlement, error) {
+	args := m.Called(userID)
+	r0, _ := args.Get(0).([]repository.Settlement)
+	return r0, args.Error(1)
+}
+
+func (m *SettlementRepository) SetSettlementPayment(id int, provider string, reference string) error {
+	return m.Called(id, provider, reference).Error(0)
+}
+
+func (m *SettlementRepository) TransitionSettlement(id int, from []repository.SettlementStatus, to repository.SettlementStatus) (*repository.Settlement, error) {
+	args := m.Called(id, from, to)
+	r0, _ := args.Get(0).(*repository.Settlement)
+	return r0, args.Error(1)
+}
+
+// ShareLinkRepository is a mock of repository.ShareLinkRepository.
+type ShareLinkRepository struct {
+	mock.Mock
+}
+
+var _ repository.ShareLinkRepository = (*ShareLinkRepository)(nil)
+
+func (m *ShareLinkRepository) CreateShareLink(link *repository.ShareLink) (*repository.ShareLink, error) {
+	args := m.Called(link)
+	r0, _ := args.Get(0).(*repository.ShareLink)
+	return r0, args.Error(1)
+}
+
+func (m *ShareLinkRepository) GetShareLink(id int) (*repository.ShareLink, error) {
+	args := m.Called(id)
+	r0, _ := args.Get(0).(*repository.ShareLink)
+	return r0, args.Error(1)
+}
+
+func (m *ShareLinkRepository) RevokeShareLink(id int) (*repository.ShareLink, error) {
+	args := m.Called(id)
+	r0, _ := args.Get(0).(*repository.ShareLink)
+	return r0, args.Error(1)
+}
+
+// TagRuleRepository is a mock of repository.TagRuleRepository.
+type TagRuleRepository struct {
+	mock.Mock
+}
+
+var _ repository.TagRuleRepository = (*TagRuleRepository)(nil)
+
+func (m *TagRuleRepository) CreateTagRule(rule *repository.TagRule) (*repository.TagRule, error) {
+	args := m.Called(rule)
+	r0, _ := args.Get(0).(*repository.TagRule)
+	return r0, args.Error(1)
+}
+
+func (m *TagRuleRepository) DeleteTagRule(id int) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *TagRuleRepository) ListTagRules() ([]repository.TagRule, error) {
+	args := m.Called()
+	r0, _ := args.Get(0).([]repository.TagRule)
+	return r0, args.Error(1)
+}
+
+// UserRepository is a mock of repository.UserRepository.
+type UserRepository struct {
+	mock.Mock
+}
+
+var _ repository.UserRepository = (*UserRepository)(nil)
+
+func (m *UserRepository) CreateUser(user *repository.User) (*repository.User, error) {
+	args := m.Called(user)
+	r0, _ := args.Get(0).(*repository.User)
+	return r0, args.Error(1)
+}
+
+func (m *UserRepository) GetLastModified(id int) (time.Time, error) {
+	args := m.Called(id)
+	r0, _ := args.Get(0).(time.Time)
+	return r0, args.Error(1)
+}
+
+func (m *UserRepository) GetOrCreateUser(user *repository.User) (*repository.User, bool, error) {
+	args := m.Called(user)
+	r0, _ := args.Get(0).(*repository.User)
+	r1, _ := args.Get(1).(bool)
+	return r0, r1, args.Error(2)
+}
+
+func (m *UserRepository) GetUser(id int) (*repository.User, error) {
+	args := m.Called(id)
+	r0, _ := args.Get(0).(*repository.User)
+	return r0, args.Error(1)
+}
+
+func (m *UserRepository) GetUserByPublicID(publicID string) (*repository.User, error) {
+	args := m.Called(publicID)
+	r0, _ := args.Get(0).(*repository.User)
+	return r0, args.Error(1)
+}
+
+func (m *UserRepository) GetUsersByEmails(emails []string) ([]*repository.User, error) {
+	args := m.Called(emails)
+	r0, _ := args.Get(0).([]*repository.User)
+	return r0, args.Error(1)
+}
+
+func (m *UserRepository) GetUsersByIDs(ids []int) ([]*repository.User, error) {
+	args := m.Called(ids)
+	r0, _ := args.Get(0).([]*repository.User)
+	return r0, args.Error(1)
+}
+
+func (m *UserRepository) UpdateSplitWeight(id int, weight float64) (*repository.User, error) {
+	args := m.Called(id, weight)
+	r0, _ := args.Get(0).(*repository.User)
+	return r0, args.Error(1)
+}
@@ -0,0 +1,808 @@
+// Code generated by cmd/genmocks. DO NOT EDIT.
+
+// Package servicemock has testify mocks of the service interfaces.
+package servicemock
+
+import (
+	"context"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/stretchr/testify/mock"
+)
+
+// AnalyticsService is a mock of service.AnalyticsService.
+type AnalyticsService struct {
+	mock.Mock
+}
+
+var _ service.AnalyticsService = (*AnalyticsService)(nil)
+
+func (m *AnalyticsService) Counterparties(userEmail string) ([]service.Counterparty, error) {
+	args := m.Called(userEmail)
+	r0, _ := args.Get(0).([]service.Counterparty)
+	return r0, args.Error(1)
+}
+
+func (m *AnalyticsService) Fairness(eventID int) (*service.FairnessReport, error) {
+	args := m.Called(eventID)
+	r0, _ := args.Get(0).(*service.FairnessReport)
+	return r0, args.Error(1)
+}
+
+func (m *AnalyticsService) Forecast(userEmail string, months int) (*service.Forecast, error) {
+	args := m.Called(userEmail, months)
+	r0, _ := args.Get(0).(*service.Forecast)
+	return r0, args.Error(1)
+}
+
+func (m *AnalyticsService) Heatmap(userEmail string, year int) (*service.Heatmap, error) {
+	args := m.Called(userEmail, year)
+	r0, _ := args.Get(0).(*service.Heatmap)
+	return r0, args.Error(1)
+}
+
+func (m *AnalyticsService) SuggestNextPayer(userEmails []string) (*service.NextPayerSuggestion, error) {
+	args := m.Called(userEmails)
+	r0, _ := args.Get(0).(*service.NextPayerSuggestion)
+	return r0, args.Error(1)
+}
+
+func (m *AnalyticsService) YearInReview(userEmail string, year int) (*service.YearInReview, error) {
+	args := m.Called(userEmail, year)
+	r0, _ := args.Get(0).(*service.YearInReview)
+	return r0, args.Error(1)
+}
+
+// AuditService is a mock of service.AuditService.
+type AuditService struct {
+	mock.Mock
+}
+
+var _ service.AuditService = (*AuditService)(nil)
+
+func (m *AuditService) ListAuditLogs(filter repository.AuditLogFilter) ([]repository.AuditLog, error) {
+	args := m.Called(filter)
+	r0, _ := args.Get(0).([]repository.AuditLog)
+	return r0, args.Error(1)
+}
+
+func (m *AuditService) RecordAuditLog(entry *repository.AuditLog) error {
+	return m.Called(entry).Error(0)
+}
+
+// BalanceStrategy is a mock of service.BalanceStrategy.
+type BalanceStrategy struct {
+	mock.Mock
+}
+
+var _ service.BalanceStrategy = (*BalanceStrategy)(nil)
+
+func (m *BalanceStrategy) CalculateBalanceUpdates(expense *repository.Expense, splits []repository.ExpenseSplit, exp int) []repository.BalanceUpdate {
+	args := m.Called(expense, splits, exp)
+	r0, _ := args.Get(0).([]repository.BalanceUpdate)
+	return r0
+}
+
+// BudgetService is a mock of service.BudgetService.
+type BudgetService struct {
+	mock.Mock
+}
+
+var _ service.BudgetService = (*BudgetService)(nil)
+
+func (m *BudgetService) CheckExpense(expense *repository.Expense, splits []repository.ExpenseSplit) ([]repository.BudgetWarning, error) {
+	args := m.Called(expense, splits)
+	r0, _ := args.Get(0).([]repository.BudgetWarning)
+	return r0, args.Error(1)
+}
+
+func (m *BudgetService) GetBudgetStatus(userEmail string) ([]service.BudgetStatus, error) {
+	args := m.Called(userEmail)
+	r0, _ := args.Get(0).([]service.BudgetStatus)
+	return r0, args.Error(1)
+}
+
+func (m *BudgetService) SetTagBudget(req service.SetTagBudgetRequest) error {
+	return m.Called(req).Error(0)
+}
+
+// DigestService is a mock of service.DigestService.
+type DigestService struct {
+	mock.Mock
+}
+
+var _ service.DigestService = (*DigestService)(nil)
+
+func (m *DigestService) BuildDigest(userID int, since time.Time, until time.Time) (*service.Digest, error) {
+	args := m.Called(userID, since, until)
+	r0, _ := args.Get(0).(*service.Digest)
+	return r0, args.Error(1)
+}
+
+func (m *DigestService) Run(ctx context.Context, interval time.Duration) {
+	m.Called(ctx, interval)
+}
+
+func (m *DigestService) ScheduleDue() (int, error) {
+	args := m.Called()
+	r0, _ := args.Get(0).(int)
+	return r0, args.Error(1)
+}
+
+func (m *DigestService) SetDigestFrequency(userID int, frequency repository.DigestFrequency) error {
+	return m.Called(userID, frequency).Error(0)
+}
+
+func (m *DigestService) Unsubscribe(token string) error {
+	return m.Called(token).Error(0)
+}
+
+// EventService is a mock of service.EventService.
+type EventService struct {
+	mock.Mock
+}
+
+var _ service.EventService = (*EventService)(nil)
+
+func (m *EventService) ArchiveEvent(id int, req service.ArchiveEventRequest) (*repository.Event, error) {
+	args := m.Called(id, req)
+	r0, _ := args.Get(0).(*repository.Event)
+	return r0, args.Error(1)
+}
+
+func (m *EventService) CreateEvent(req service.CreateEventRequest) (*repository.Event, error) {
+	args := m.Called(req)
+	r0, _ := args.Get(0).(*repository.Event)
+	return r0, args.Error(1)
+}
+
+func (m *EventService) GetEventSummary(id int) (*service.EventSummary, error) {
+	args := m.Called(id)
+	r0, _ := args.Get(0).(*service.EventSummary)
+	return r0, args.Error(1)
+}
+
+func (m *EventService) ListEvents(userEmail string, includeArchived bool) ([]repository.Event, error) {
+	args := m.Called(userEmail, includeArchived)
+	r0, _ := args.Get(0).([]repository.Event)
+	return r0, args.Error(1)
+}
+
+func (m *EventService) SetAutoSettle(id int, req service.SetAutoSettleRequest) (*repository.Event, error) {
+	args := m.Called(id, req)
+	r0, _ := args.Get(0).(*repository.Event)
+	return r0, args.Error(1)
+}
+
+func (m *EventService) SetBaseCurrency(id int, req service.SetBaseCurrencyRequest) (*repository.Event, error) {
+	args := m.Called(id, req)
+	r0, _ := args.Get(0).(*repository.Event)
+	return r0, args.Error(1)
+}
+
+func (m *EventService) UnarchiveEvent(id int, req service.ArchiveEventRequest) (*repository.Event, error) {
+	args := m.Called(id, req)
+	r0, _ := args.Get(0).(*repository.Event)
+	return r0, args.Error(1)
+}
+
+// ExpenseService is a mock of service.ExpenseService.
+type ExpenseService struct {
+	mock.Mock
+}
+
+var _ service.ExpenseService = (*ExpenseService)(nil)
+
+func (m *ExpenseService) CreateExpense(req service.CreateExpenseRequest) (*repository.Expense, error) {
+	args := m.Called(req)
+	r0, _ := args.Get(0).(*repository.Expense)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseService) DismissExpenseDispute(id int, req service.DismissDisputeRequest) (*repository.Expense, error) {
+	args := m.Called(id, req)
+	r0, _ := args.Get(0).(*repository.Expense)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseService) DisputeExpense(id int, req service.DisputeExpenseRequest) (*repository.Expense, error) {
+	args := m.Called(id, req)
+	r0, _ := args.Get(0).(*repository.Expense)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseService) GetBalanceList(userEmail string, direction repository.DebtDirection, limit int, offset int) (*service.BalanceList, error) {
+	args := m.Called(userEmail, direction, limit, offset)
+	r0, _ := args.Get(0).(*service.BalanceList)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseService) GetExpenseByPublicID(publicID string) (*repository.Expense, error) {
+	args := m.Called(publicID)
+	r0, _ := args.Get(0).(*repository.Expense)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseService) GetExpensePageForUser(userEmail string, limit int, offset int, runningBalance bool) ([]repository.UserExpenseView, int, error) {
+	args := m.Called(userEmail, limit, offset, runningBalance)
+	r0, _ := args.Get(0).([]repository.UserExpenseView)
+	r1, _ := args.Get(1).(int)
+	return r0, r1, args.Error(2)
+}
+
+func (m *ExpenseService) GetExpensesForUser(userEmail string) ([]repository.UserExpenseView, error) {
+	args := m.Called(userEmail)
+	r0, _ := args.Get(0).([]repository.UserExpenseView)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseService) GetNearbyExpenses(userEmail string, latitude float64, longitude float64, radius float64) ([]repository.NearbyExpense, error) {
+	args := m.Called(userEmail, latitude, longitude, radius)
+	r0, _ := args.Get(0).([]repository.NearbyExpense)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseService) GetOutstandingBalancesForUser(userEmail string) ([]service.UserBalanceView, error) {
+	args := m.Called(userEmail)
+	r0, _ := args.Get(0).([]service.UserBalanceView)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseService) GetOverallOutstandingBalance(userEmail string) (float64, error) {
+	args := m.Called(userEmail)
+	r0, _ := args.Get(0).(float64)
+	return r0, args.Error(1)
+}
+
+func (m *ExpenseService) UndoExpense(id int, req service.UndoExpenseRequest) error {
+	return m.Called(id, req).Error(0)
+}
+
+func (m *ExpenseService) UnlockExpense(id int, req service.UnlockExpenseRequest) (*repository.Expense, error) {
+	args := m.Called(id, req)
+	r0, _ := args.Get(0).(*repository.Expense)
+	return r0, args.Error(1)
+}
+
+// GoalService is a mock of service.GoalService.
+type GoalService struct {
+	mock.Mock
+}
+
+var _ service.GoalService = (*GoalService)(nil)
+
+func (m *GoalService) CreateGoal(req service.CreateGoalRequest) (*repository.Goal, error) {
+	args := m.Called(req)
+	r0, _ := args.Get(0).(*repository.Goal)
+	return r0, args.Error(1)
+}
+
+func (m *GoalService) GetGoalProgress(userEmail string) ([]service.GoalProgress, error) {
+	args := m.Called(userEmail)
+	r0, _ := args.Get(0).([]service.GoalProgress)
+	return r0, args.Error(1)
+}
+
+// HealthService is a mock of service.HealthService.
+type HealthService struct {
+	mock.Mock
+}
+
+var _ service.HealthService = (*HealthService)(nil)
+
+func (m *HealthService) Check(ctx context.Context) service.HealthReport {
+	args := m.Called(ctx)
+	r0, _ := args.Get(0).(service.HealthReport)
+	return r0
+}
+
+// ImportService is a mock of service.ImportService.
+type ImportService struct {
+	mock.Mock
+}
+
+var _ service.ImportService = (*ImportService)(nil)
+
+func (m *ImportService) CommitImport(id int, req service.CommitImportRequest) (*repository.ImportSession, error) {
+	args := m.Called(id, req)
+	r0, _ := args.Get(0).(*repository.ImportSession)
+	return r0, args.Error(1)
+}
+
+func (m *ImportService) CreateImport(req service.CreateImportRequest) (*repository.ImportSession, error) {
+	args := m.Called(req)
+	r0, _ := args.Get(0).(*repository.ImportSession)
+	return r0, args.Error(1)
+}
+
+func (m *ImportService) GetImport(id int) (*repository.ImportSession, error) {
+	args := m.Called(id)
+	r0, _ := args.Get(0).(*repository.ImportSession)
+	return r0, args.Error(1)
+}
+
+func (m *ImportService) UploadChunk(id int, seq int, data []byte) (*repository.ImportSession, error) {
+	args := m.Called(id, seq, data)
+	r0, _ := args.Get(0).(*repository.ImportSession)
+	return r0, args.Error(1)
+}
+
+// InviteService is a mock of service.InviteService.
+type InviteService struct {
+	mock.Mock
+}
+
+var _ service.InviteService = (*InviteService)(nil)
+
+func (m *InviteService) ClaimExpenseInvite(token string, req service.ClaimExpenseInviteRequest) (*service.InviteClaim, error) {
+	args := m.Called(token, req)
+	r0, _ := args.Get(0).(*service.InviteClaim)
+	return r0, args.Error(1)
+}
+
+func (m *InviteService) CreateExpenseInvite(expenseID int, req service.CreateExpenseInviteRequest) (*service.CreatedExpenseInvite, error) {
+	args := m.Called(expenseID, req)
+	r0, _ := args.Get(0).(*service.CreatedExpenseInvite)
+	return r0, args.Error(1)
+}
+
+func (m *InviteService) GetExpenseInvite(token string) (*service.ExpenseInvite, error) {
+	args := m.Called(token)
+	r0, _ := args.Get(0).(*service.ExpenseInvite)
+	return r0, args.Error(1)
+}
+
+func (m *InviteService) InviteQRCode(token string) ([]byte, error) {
+	args := m.Called(token)
+	r0, _ := args.Get(0).([]byte)
+	return r0, args.Error(1)
+}
+
+// JobService is a mock of service.JobService.
+type JobService struct {
+	mock.Mock
+}
+
+var _ service.JobService = (*JobService)(nil)
+
+func (m *JobService) Enqueue(jobType string, payload interface{}) (*repository.Job, error) {
+	args := m.Called(jobType, payload)
+	r0, _ := args.Get(0).(*repository.Job)
+	return r0, args.Error(1)
+}
+
+func (m *JobService) EnqueueAt(jobType string, payload interface{}, runAt time.Time) (*repository.Job, error) {
+	args := m.Called(jobType, payload, runAt)
+	r0, _ := args.Get(0).(*repository.Job)
+	return r0, args.Error(1)
+}
+
+func (m *JobService) GetJob(id int64) (*repository.Job, error) {
+	args := m.Called(id)
+	r0, _ := args.Get(0).(*repository.Job)
+	return r0, args.Error(1)
+}
+
+func (m *JobService) Register(jobType string, fn service.JobFunc) {
+	m.Called(jobType, fn)
+}
+
+func (m *JobService) Run(ctx context.Context) {
+	m.Called(ctx)
+}
+
+// LedgerService is a mock of service.LedgerService.
+type LedgerService struct {
+	mock.Mock
+}
+
+var _ service.LedgerService = (*LedgerService)(nil)
+
+func (m *LedgerService) CheckBalances() ([]repository.BalanceDrift, error) {
+	args := m.Called()
+	r0, _ := args.Get(0).([]repository.BalanceDrift)
+	return r0, args.Error(1)
+}
+
+func (m *LedgerService) GetAgingReport(userEmail string) (*service.AgingReport, error) {
+	args := m.Called(userEmail)
+	r0, _ := args.Get(0).(*service.AgingReport)
+	return r0, args.Error(1)
+}
+
+func (m *LedgerService) GetStatement(userEmail string, asOf time.Time) (*service.LedgerStatement, error) {
+	args := m.Called(userEmail, asOf)
+	r0, _ := args.Get(0).(*service.LedgerStatement)
+	return r0, args.Error(1)
+}
+
+func (m *LedgerService) RebuildBalances() ([]repository.BalanceDrift, error) {
+	args := m.Called()
+	r0, _ := args.Get(0).([]repository.BalanceDrift)
+	return r0, args.Error(1)
+}
+
+// LoanService is a mock of service.LoanService.
+type LoanService struct {
+	mock.Mock
+}
+
+var _ service.LoanService = (*LoanService)(nil)
+
+func (m *LoanService) CreateLoan(req service.CreateLoanRequest) (*repository.Loan, error) {
+	args := m.Called(req)
+	r0, _ := args.Get(0).(*repository.Loan)
+	return r0, args.Error(1)
+}
+
+func (m *LoanService) GetLoansForUser(userEmail string) ([]service.LoanView, error) {
+	args := m.Called(userEmail)
+	r0, _ := args.Get(0).([]service.LoanView)
+	return r0, args.Error(1)
+}
+
+func (m *LoanService) Run(ctx context.Context, interval time.Duration) {
+	m.Called(ctx, interval)
+}
+
+func (m *LoanService) ScheduleReminders() (int, error) {
+	args := m.Called()
+	r0, _ := args.Get(0).(int)
+	return r0, args.Error(1)
+}
+
+// Notifier is a mock of service.Notifier.
+type Notifier struct {
+	mock.Mock
+}
+
+var _ service.Notifier = (*Notifier)(nil)
+
+func (m *Notifier) Notify(ctx context.Context, n service.Notification) error {
+	return m.Called(ctx, n).Error(0)
+}
+
+// PartyService is a mock of service.PartyService.
+type PartyService struct {
+	mock.Mock
+}
+
+var _ service.PartyService = (*PartyService)(nil)
+
+func (m *PartyService) CreateParty(req service.CreatePartyRequest) (*repository.Party, error) {
+	args := m.Called(req)
+	r0, _ := args.Get(0).(*repository.Party)
+	return r0, args.Error(1)
+}
+
+func (m *PartyService) ListParties() ([]repository.Party, error) {
+	args := m.Called()
+	r0, _ := args.Get(0).([]repository.Party)
+	return r0, args.Error(1)
+}
+
+// PaymentService is a mock of service.PaymentService.
+type PaymentService struct {
+	mock.Mock
+}
+
+var _ service.PaymentService = (*PaymentService)(nil)
+
+func (m *PaymentService) GetPaymentHandles(userID int) (*repository.PaymentHandles, error) {
+	args := m.Called(userID)
+	r0, _ := args.Get(0).(*repository.PaymentHandles)
+	return r0, args.Error(1)
+}
+
+func (m *PaymentService) LinkTransfers(transfers []service.SettleUpTransfer, note string) error {
+	return m.Called(transfers, note).Error(0)
+}
+
+func (m *PaymentService) PaymentQRCode(link string) ([]byte, error) {
+	args := m.Called(link)
+	r0, _ := args.Get(0).([]byte)
+	return r0, args.Error(1)
+}
+
+func (m *PaymentService) RecordPayment(payload []byte, signature string) (*repository.Settlement, error) {
+	args := m.Called(payload, signature)
+	r0, _ := args.Get(0).(*repository.Settlement)
+	return r0, args.Error(1)
+}
+
+func (m *PaymentService) SetPaymentHandles(userID int, handles repository.PaymentHandles) (*repository.PaymentHandles, error) {
+	args := m.Called(userID, handles)
+	r0, _ := args.Get(0).(*repository.PaymentHandles)
+	return r0, args.Error(1)
+}
+
+// Pinger is a mock of service.Pinger.
+type Pinger struct {
+	mock.Mock
+}
+
+var _ service.Pinger = (*Pinger)(nil)
+
+func (m *Pinger) PingContext(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+// PreferenceService is a mock of service.PreferenceService.
+type PreferenceService struct {
+	mock.Mock
+}
+
+var _ service.PreferenceService = (*PreferenceService)(nil)
+
+func (m *PreferenceService) GetPreferences(userID int) (*repository.NotificationPreferences, error) {
+	args := m.Called(userID)
+	r0, _ := args.Get(0).(*repository.NotificationPreferences)
+	return r0, args.Error(1)
+}
+
+func (m *PreferenceService) SetPreferences(userID int, prefs repository.NotificationPreferences) (*repository.NotificationPreferences, error) {
+	args := m.Called(userID, prefs)
+	r0, _ := args.Get(0).(*repository.NotificationPreferences)
+	return r0, args.Error(1)
+}
+
+// QueryService is a mock of service.QueryService.
+type QueryService struct {
+	mock.Mock
+}
+
+var _ service.QueryService = (*QueryService)(nil)
+
+func (m *QueryService) SlowestQueries(n int) ([]repository.QueryStats, error) {
+	args := m.Called(n)
+	r0, _ := args.Get(0).([]repository.QueryStats)
+	return r0, args.Error(1)
+}
+
+// QuotaService is a mock of service.QuotaService.
+type QuotaService struct {
+	mock.Mock
+}
+
+var _ service.QuotaService = (*QuotaService)(nil)
+
+func (m *QuotaService) CheckEvent(userID int) error {
+	return m.Called(userID).Error(0)
+}
+
+func (m *QuotaService) CheckExpense(userID int) error {
+	return m.Called(userID).Error(0)
+}
+
+func (m *QuotaService) CheckUpload(userID int, size int) error {
+	return m.Called(userID, size).Error(0)
+}
+
+func (m *QuotaService) GetUsage(userID int) (*service.QuotaUsage, error) {
+	args := m.Called(userID)
+	r0, _ := args.Get(0).(*service.QuotaUsage)
+	return r0, args.Error(1)
+}
+
+// RateService is a mock of service.RateService.
+type RateService struct {
+	mock.Mock
+}
+
+var _ service.RateService = (*RateService)(nil)
+
+func (m *RateService) Rate(from string, to string) (float64, error) {
+	args := m.Called(from, to)
+	r0, _ := args.Get(0).(float64)
+	return r0, args.Error(1)
+}
+
+// SettleUpService is a mock of service.SettleUpService.
+type SettleUpService struct {
+	mock.Mock
+}
+
+var _ service.SettleUpService = (*SettleUpService)(nil)
+
+func (m *SettleUpService) Run(ctx context.Context, interval time.Duration) {
+	m.Called(ctx, interval)
+}
+
+func (m *SettleUpService) ScheduleDue() (int, error) {
+	args := m.Called()
+	r0, _ := args.Get(0).(int)
+	return r0, args.Error(1)
+}
+
+// SettlementService is a mock of service.SettlementService.
+type SettlementService struct {
+	mock.Mock
+}
+
+var _ service.SettlementService = (*SettlementService)(nil)
+
+func (m *SettlementService) ApplyDebtSimplification(req service.ApplyDebtSimplificationRequest) ([]*repository.Settlement, error) {
+	args := m.Called(req)
+	r0, _ := args.Get(0).([]*repository.Settlement)
+	return r0, args.Error(1)
+}
+
+func (m *SettlementService) GetSettlementsForUser(userEmail string) ([]service.SettlementView, error) {
+	args := m.Called(userEmail)
+	r0, _ := args.Get(0).([]service.SettlementView)
+	return r0, args.Error(1)
+}
+
+func (m *SettlementService) ProposeSettlement(req service.ProposeSettlementRequest) (*repository.Settlement, error) {
+	args := m.Called(req)
+	r0, _ := args.Get(0).(*repository.Settlement)
+	return r0, args.Error(1)
+}
+
+func (m *SettlementService) SimplifyDebts(userEmail string) (*service.DebtSimplification, error) {
+	args := m.Called(userEmail)
+	r0, _ := args.Get(0).(*service.DebtSimplification)
+	return r0, args.Error(1)
+}
+
+func (m *SettlementService) TransitionSettlement(id int, to repository.SettlementStatus, req service.TransitionSettlementRequest) (*repository.Settlement, error) {
+	args := m.Called(id, to, req)
+	r0, _ := args.Get(0).(*repository.Settlement)
+	return r0, args.Error(1)
+}
+
+// ShareService is a mock of service.ShareService.
+type ShareService struct {
+	mock.Mock
+}
+
+var _ service.ShareService = (*ShareService)(nil)
+
+func (m *ShareService) CreateShareLink(eventID int, req service.CreateShareLinkRequest) (*service.CreatedShareLink, error) {
+	args := m.Called(eventID, req)
+	r0, _ := args.Get(0).(*service.CreatedShareLink)
+	return r0, args.Error(1)
+}
+
+func (m *ShareService) GetSharedLedger(token string) (*service.SharedLedger, error) {
+	args := m.Called(token)
+	r0, _ := args.Get(0).(*service.SharedLedger)
+	return r0, args.Error(1)
+}
+
+func (m *ShareService) RevokeShareLink(id int, req service.RevokeShareLinkRequest) (*repository.ShareLink, error) {
+	args := m.Called(id, req)
+	r0, _ := args.Get(0).(*repository.ShareLink)
+	return r0, args.Error(1)
+}
+
+// SplitStrategy is a mock of service.SplitStrategy.
+type SplitStrategy struct {
+	mock.Mock
+}
+
+var _ service.SplitStrategy = (*SplitStrategy)(nil)
+
+func (m *SplitStrategy) CalculateSplits(req service.CreateExpenseRequest, exp int) ([]repository.ExpenseSplit, error) {
+	args := m.Called(req, exp)
+	r0, _ := args.Get(0).([]repository.ExpenseSplit)
+	return r0, args.Error(1)
+}
+
+// StripeService is a mock of service.StripeService.
+type StripeService struct {
+	mock.Mock
+}
+
+var _ service.StripeService = (*StripeService)(nil)
+
+func (m *StripeService) HandleWebhook(payload []byte, signature string) error {
+	return m.Called(payload, signature).Error(0)
+}
+
+func (m *StripeService) PaySettlement(id int) (*service.SettlementPayment, error) {
+	args := m.Called(id)
+	r0, _ := args.Get(0).(*service.SettlementPayment)
+	return r0, args.Error(1)
+}
+
+// TagClassifier is a mock of service.TagClassifier.
+type TagClassifier struct {
+	mock.Mock
+}
+
+var _ service.TagClassifier = (*TagClassifier)(nil)
+
+func (m *TagClassifier) ClassifyTag(description string) (string, float64, error) {
+	args := m.Called(description)
+	r0, _ := args.Get(0).(string)
+	r1, _ := args.Get(1).(float64)
+	return r0, r1, args.Error(2)
+}
+
+// TagService is a mock of service.TagService.
+type TagService struct {
+	mock.Mock
+}
+
+var _ service.TagService = (*TagService)(nil)
+
+func (m *TagService) CreateTagRule(req service.CreateTagRuleRequest) (*repository.TagRule, error) {
+	args := m.Called(req)
+	r0, _ := args.Get(0).(*repository.TagRule)
+	return r0, args.Error(1)
+}
+
+func (m *TagService) DeleteTagRule(id int) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *TagService) ListTagRules() ([]repository.TagRule, error) {
+	args := m.Called()
+	r0, _ := args.Get(0).([]repository.TagRule)
+	return r0, args.Error(1)
+}
+
+func (m *TagService) SuggestTag(description string) (*service.TagSuggestion, error) {
+	args := m.Called(description)
+	r0, _ := args.Get(0).(*service.TagSuggestion)
+	return r0, args.Error(1)
+}
+
+// UserService is a mock of service.UserService.
+type UserService struct {
+	mock.Mock
+}
+
+var _ service.UserService = (*UserService)(nil)
+
+func (m *UserService) CreateUser(name string, email string, publicID string) (*repository.User, error) {
+	args := m.Called(name, email, publicID)
+	r0, _ := args.Get(0).(*repository.User)
+	return r0, args.Error(1)
+}
+
+func (m *UserService) GetLastModified(email string) (time.Time, error) {
+	args := m.Called(email)
+	r0, _ := args.Get(0).(time.Time)
+	return r0, args.Error(1)
+}
+
+func (m *UserService) GetOrCreateUser(name string, email string, publicID string) (*repository.User, bool, error) {
+	args := m.Called(name, email, publicID)
+	r0, _ := args.Get(0).(*repository.User)
+	r1, _ := args.Get(1).(bool)
+	return r0, r1, args.Error(2)
+}
+
+func (m *UserService) GetUser(id int) (*repository.User, error) {
+	args := m.Called(id)
+	r0, _ := args.Get(0).(*repository.User)
+	return r0, args.Error(1)
+}
+
+func (m *UserService) GetUserByPublicID(publicID string) (*repository.User, error) {
+	args := m.Called(publicID)
+	r0, _ := args.Get(0).(*repository.User)
+	return r0, args.Error(1)
+}
+
+func (m *UserService) GetUsersByEmails(emails []string) ([]*repository.User, error) {
+	args := m.Called(emails)
+	r0, _ := args.Get(0).([]*repository.User)
+	return r0, args.Error(1)
+}
+
+func (m *UserService) GetUsersByIDs(ids []int) ([]*repository.User, error) {
+	args := m.Called(ids)
+	r0, _ := args.Get(0).([]*repository.User)
+	return r0, args.Error(1)
+}
+
+func (m *UserService) SetSplitWeight(id int, weight float64) (*repository.User, error) {
+	args := m.Called(id, weight)
+	r0, _ := args.Get(0).(*repository.User)
+	return r0, args.Error(1)
+}
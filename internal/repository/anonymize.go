@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// AnonymizeReport counts what Anonymize changed.
+type AnonymizeReport struct {
+	Users           int
+	Expenses        int
+	Loans           int
+	Settlements     int
+	BalancesRebuilt int
+}
+
+// scaledColumn is an assignment scaling column by anonymizeFactor, in SQL, for the kind and ID
+// expressions given. Its one placeholder is the seed. MySQL's CRC32 is the IEEE one Go uses.
+func scaledColumn(column, kind, id string) string {
+	return fmt.Sprintf("%s = ROUND(%s * (0.5 + MOD(CRC32(CONCAT(?, ':', %s, ':', %s)), 1001) / 1000), 2)", column, column, kind, id)
+}
+
+// anonymizeFactor is what every amount of one expense, loan, settlement or user is scaled by: between
+// 0.5 and 1.5, fixed by seed so the amounts can't be scaled back without it.
+func anonymizeFactor(seed, kind string, id int) float64 {
+	sum := crc32.ChecksumIEEE(fmt.Appendf(nil, "%s:%s:%d", seed, kind, id))
+	return 0.5 + float64(sum%1001)/1000
+}
+
+// Anonymize scrambles a copy of the database for use in staging, in one transaction. People,
+// parties and events are renamed after their IDs, free text is replaced, and what ties the data to
+// the outside world (payment handles, locations, audit logs, jobs, imports, the expense event store)
+// is deleted. Every amount is scaled by a factor per expense, loan, settlement or user, keeping an
+// expense's splits adding up to it and each ledger posting cancelling out; balances are then rebuilt
+// from the ledger. It must never be pointed at a database in use.
+func Anonymize(db *sql.DB, seed string) (*AnonymizeReport, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback on error, no-op on commit
+
+	report := &AnonymizeReport{}
+	statements := []struct {
+		query string
+		count *int // Set to the rows changed, where wanted
+	}{
+		{"UPDATE users SET name = CONCAT('User ', id), email = CONCAT('user', id, '@example.invalid')", &report.Users},
+		{"UPDATE expenses SET description = CONCAT('Expense ', id), dispute_reason = IF(dispute_reason IS NULL, NULL, 'Disputed')", nil},
+		{"UPDATE loans SET description = CONCAT('Loan ', id), " + scaledColumn("amount", "'loan'", "id"), &report.Loans},
+		{"UPDATE settlements SET payment_reference = IF(payment_reference IS NULL, NULL, CONCAT('ref-', id)), " + scaledColumn("amount", "'settlement'", "id"), &report.Settlements},
+		{"UPDATE events SET name = CONCAT('Event ', id)", nil},
+		{"UPDATE parties SET name = CONCAT('Party ', id)", nil},
+		{"UPDATE tag_budgets SET " + scaledColumn("monthly_limit", "'user'", "user_id"), nil},
+		{"UPDATE goals SET " + scaledColumn("target_balance", "'user'", "user_id") + ", " + scaledColumn("starting_balance", "'user'", "user_id"), nil},
+		// A reversal is scaled like the expense it reverses. ROUND on a DECIMAL rounds half away from
+		// zero, so both entries of a posting stay opposite.
+		{"UPDATE ledger_entries SET " + scaledColumn("amount", "IF(source_type = 'expense_reversal', 'expense', source_type)", "COALESCE(source_id, 0)"), nil},
+		{"DELETE FROM payment_handles", nil},
+		{"DELETE FROM expense_locations", nil},
+		{"DELETE FROM audit_logs", nil},
+		{"DELETE FROM jobs", nil},
+		{"DELETE FROM import_sessions", nil}, // Its chunks go with it
+		{"DELETE FROM expense_events", nil},
+		{"DELETE FROM share_links", nil},
+	}
+	for _, st := range statements {
+		// Every placeholder is the seed
+		args := slices.Repeat([]any{seed}, strings.Count(st.query, "?"))
+		result, err := tx.Exec(st.query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to anonymize: %w", err)
+		}
+		if st.count == nil {
+			continue
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rows affected anonymizing: %w", err)
+		}
+		*st.count = int(n)
+	}
+
+	if report.Expenses, err = anonymizeExpenseAmounts(tx, seed); err != nil {
+		return nil, err
+	}
+
+	drifts, err := rebuildBalances(tx)
+	if err != nil {
+		return nil, err
+	}
+	report.BalancesRebuilt = len(drifts)
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return report, nil
+}
+
+// anonymizedSplit is a split's amounts in minor units.
+type anonymizedSplit struct {
+	id         int
+	paid, owed int64
+}
+
+type anonymizedExpense struct {
+	id             int
+	exp            int
+	total          int64
+	originalAmount *float64
+	splits         []anonymizedSplit
+}
+
+// anonymizeExpenseAmounts scales each expense and its splits, in the expense's minor unit.
+func anonymizeExpenseAmounts(tx *sql.Tx, seed string) (int, error) {
+	expenses, err := loadExpensesToAnonymize(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	updateExpense, err := tx.Prepare("UPDATE expenses SET total_amount = ?, original_amount = ? WHERE id = ?")
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare expense update: %w", err)
+	}
+	defer updateExpense.Close()
+	updateSplit, err := tx.Prepare("UPDATE expense_splits SET amount_paid = ?, amount_owed = ? WHERE id = ?")
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare split update: %w", err)
+	}
+	defer updateSplit.Close()
+
+	for _, e := range expenses {
+		factor := anonymizeFactor(seed, "expense", e.id)
+		paid := make([]int64, len(e.splits))
+		owed := make([]int64, len(e.splits))
+		for i, s := range e.splits {
+			paid[i], owed[i] = s.paid, s.owed
+		}
+		paid, owed = scaleUnitsBy(paid, factor), scaleUnitsBy(owed, factor)
+
+		var original *float64
+		if e.originalAmount != nil {
+			scaled := math.Round(*e.originalAmount*factor*1000) / 1000
+			original = &scaled
+		}
+		total := int64(math.Round(float64(e.total) * factor))
+		if _, err := updateExpense.Exec(util.FromMinorUnits(total, e.exp), original, e.id); err != nil {
+			return 0, fmt.Errorf("failed to anonymize expense %d: %w", e.id, err)
+		}
+		for i, s := range e.splits {
+			if _, err := updateSplit.Exec(util.FromMinorUnits(paid[i], e.exp), util.FromMinorUnits(owed[i], e.exp), s.id); err != nil {
+				return 0, fmt.Errorf("failed to anonymize split %d: %w", s.id, err)
+			}
+		}
+	}
+	return len(expenses), nil
+}
+
+// loadExpensesToAnonymize reads every expense with its splits. They are read in full before any is
+// updated, as a connection can't run statements while it streams rows.
+func loadExpensesToAnonymize(tx *sql.Tx) ([]*anonymizedExpense, error) {
+	// Currencies the currencies table doesn't list have two decimal places
+	rows, err := tx.Query("SELECT e.id, COALESCE(c.exponent, 2), e.total_amount, e.original_amount FROM expenses e LEFT JOIN currencies c ON c.code = e.currency ORDER BY e.id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expenses to anonymize: %w", err)
+	}
+	defer rows.Close()
+
+	var expenses []*anonymizedExpense
+	byID := make(map[int]*anonymizedExpense)
+	for rows.Next() {
+		e := &anonymizedExpense{}
+		var total float64
+		var original sql.NullFloat64
+		if err := rows.Scan(&e.id, &e.exp, &total, &original); err != nil {
+			return nil, fmt.Errorf("failed to scan expense: %w", err)
+		}
+		e.total = util.ToMinorUnits(total, e.exp)
+		if original.Valid {
+			e.originalAmount = &original.Float64
+		}
+		expenses = append(expenses, e)
+		byID[e.id] = e
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over expenses: %w", err)
+	}
+	rows.Close()
+
+	splitRows, err := tx.Query("SELECT id, expense_id, amount_paid, amount_owed FROM expense_splits ORDER BY expense_id, id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query splits to anonymize: %w", err)
+	}
+	defer splitRows.Close()
+
+	for splitRows.Next() {
+		var id, expenseID int
+		var paid, owed float64
+		if err := splitRows.Scan(&id, &expenseID, &paid, &owed); err != nil {
+			return nil, fmt.Errorf("failed to scan split: %w", err)
+		}
+		if e, ok := byID[expenseID]; ok {
+			e.splits = append(e.splits, anonymizedSplit{id: id, paid: util.ToMinorUnits(paid, e.exp), owed: util.ToMinorUnits(owed, e.exp)})
+		}
+	}
+	if err := splitRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over splits: %w", err)
+	}
+
+	return expenses, nil
+}
+
+// scaleUnitsBy scales each amount by factor, rounding, and puts what rounding gained or lost on the
+// largest amount, so the scaled amounts add up to the scaled total.
+func scaleUnitsBy(units []int64, factor float64) []int64 {
+	var total int64
+	largest := -1
+	scaled := make([]int64, len(units))
+	for i, u := range units {
+		total += u
+		scaled[i] = int64(math.Round(float64(u) * factor))
+		if largest < 0 || abs64(u) > abs64(units[largest]) {
+			largest = i
+		}
+	}
+	if largest < 0 {
+		return scaled
+	}
+
+	var sum int64
+	for _, s := range scaled {
+		sum += s
+	}
+	scaled[largest] += int64(math.Round(float64(total)*factor)) - sum
+	return scaled
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnonymizeFactor(t *testing.T) {
+	// Test case 1: The same seed always gives the same factor, within bounds
+	for id := 1; id <= 100; id++ {
+		factor := anonymizeFactor("seed", "expense", id)
+		assert.Equal(t, factor, anonymizeFactor("seed", "expense", id))
+		assert.GreaterOrEqual(t, factor, 0.5)
+		assert.LessOrEqual(t, factor, 1.5)
+	}
+
+	// Test case 2: Another seed or kind gives another factor
+	assert.NotEqual(t, anonymizeFactor("seed", "expense", 1), anonymizeFactor("other", "expense", 1))
+	assert.NotEqual(t, anonymizeFactor("seed", "expense", 1), anonymizeFactor("seed", "loan", 1))
+}
+
+func TestScaleUnitsBy(t *testing.T) {
+	// Test case 1: Rounding is made up on the largest amount, so the total scales exactly
+	scaled := scaleUnitsBy([]int64{3333, 3333, 3334}, 1.2345)
+	assert.Equal(t, []int64{4115, 4115, 4115}, scaled)
+
+	// Test case 2: Refunds scale the same way
+	scaled = scaleUnitsBy([]int64{-1001, -1001, 0}, 0.5)
+	assert.Equal(t, []int64{-500, -501, 0}, scaled)
+
+	// Test case 3: Nothing to scale
+	assert.Empty(t, scaleUnitsBy(nil, 1.1))
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AuditLog records a single mutating API call.
+type AuditLog struct {
+	ID          int64     `json:"id"`
+	Actor       string    `json:"actor"`
+	Method      string    `json:"method"`
+	Route       string    `json:"route"`
+	Path        string    `json:"path"`
+	PayloadHash string    `json:"payload_hash"`
+	Status      int       `json:"status"`
+	LatencyMs   int64     `json:"latency_ms"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AuditLogFilter narrows an audit query. Zero fields are not filtered on; To is exclusive.
+type AuditLogFilter struct {
+	Actor string
+	From  time.Time
+	To    time.Time
+}
+
+type AuditRepository interface {
+	CreateAuditLog(entry *AuditLog) error
+	// ListAuditLogs returns the matching entries, newest first.
+	ListAuditLogs(filter AuditLogFilter) ([]AuditLog, error)
+}
+
+type auditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) CreateAuditLog(entry *AuditLog) error {
+	query := "INSERT INTO audit_logs (actor, method, route, path, payload_hash, status, latency_ms, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	result, err := r.db.Exec(query, entry.Actor, entry.Method, entry.Route, entry.Path, entry.PayloadHash, entry.Status, entry.LatencyMs, entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert ID for audit log: %w", err)
+	}
+	entry.ID = id
+
+	return nil
+}
+
+func (r *auditRepository) ListAuditLogs(filter AuditLogFilter) ([]AuditLog, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.Actor != "" {
+		conditions = append(conditions, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From)
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.To)
+	}
+
+	query := "SELECT id, actor, method, route, path, payload_hash, status, latency_ms, created_at FROM audit_logs"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []AuditLog
+	for rows.Next() {
+		var e AuditLog
+		if err := rows.Scan(&e.ID, &e.Actor, &e.Method, &e.Route, &e.Path, &e.PayloadHash, &e.Status, &e.LatencyMs, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over audit log rows: %w", err)
+	}
+
+	return entries, nil
+}
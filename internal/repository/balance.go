@@ -3,7 +3,11 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/aadithya-md/split-expense/internal/util"
 )
 
 type Balance struct {
@@ -13,10 +17,40 @@ type Balance struct {
 	LastUpdated time.Time `json:"last_updated"`
 }
 
+// DebtDirection picks one side of a user's balances.
+type DebtDirection string
+
+const (
+	OwedToUser DebtDirection = "owed_to_user"
+	OwedByUser DebtDirection = "owed_by_user"
+)
+
+// DirectedBalance is what a counterparty owes the user, or the user owes them, as a positive amount.
+type DirectedBalance struct {
+	CounterpartyID int
+	Amount         float64
+	LastUpdated    time.Time
+}
+
+// DirectedBalances is a page of one side of a user's balances. Count and Total cover the whole
+// side, not just the page.
+type DirectedBalances struct {
+	Balances []DirectedBalance
+	Count    int
+	Total    float64
+}
+
+// BalanceRepository keeps the balances table, a running total of the ledger per pair of users.
+// Every change is posted to the ledger in the same transaction, so the two always agree.
 type BalanceRepository interface {
-	UpdateBalance(tx *sql.Tx, user1ID, user2ID int, amount float64) error
+	UpdateBalance(tx *sql.Tx, source LedgerSource, user1ID, user2ID int, amount float64) error
+	// UpdateBalances applies a batch of balance changes, such as all the pairs of one expense, in a single statement.
+	UpdateBalances(tx *sql.Tx, source LedgerSource, updates []BalanceUpdate) error
 	GetBalancesByUserID(userID int) ([]Balance, error)
 	GetOverallBalanceByUserID(userID int) (float64, error)
+	// GetDirectedBalances lists the balances owed to or by the user, largest first, limit of them
+	// from offset. A limit of 0 lists them all.
+	GetDirectedBalances(userID int, direction DebtDirection, limit, offset int) (*DirectedBalances, error)
 }
 
 type balanceRepository struct {
@@ -27,28 +61,73 @@ func NewBalanceRepository(db *sql.DB) BalanceRepository {
 	return &balanceRepository{db: db}
 }
 
-func (r *balanceRepository) UpdateBalance(tx *sql.Tx, user1ID, user2ID int, amount float64) error {
+// OrderedPair keys a balance change by the lower user ID first, reversing the amount when the IDs
+// are swapped so that it keeps describing the same debt.
+func OrderedPair(user1ID, user2ID int, amount float64) (int, int, float64) {
 	// Ensure user1ID is always less than user2ID for consistent keying
 	if user1ID > user2ID {
-		user1ID, user2ID = user2ID, user1ID
-		amount = -amount // Reverse amount if IDs are swapped
+		return user2ID, user1ID, -amount // Reverse amount if IDs are swapped
 	}
+	return user1ID, user2ID, amount
+}
 
-	query := `
-		INSERT INTO balances (user1_id, user2_id, balance, last_updated)
-		VALUES (?, ?, ?, NOW())
-		ON DUPLICATE KEY UPDATE
-		balance = balance + ?, last_updated = NOW()
-	`
+func (r *balanceRepository) UpdateBalance(tx *sql.Tx, source LedgerSource, user1ID, user2ID int, amount float64) error {
+	return r.UpdateBalances(tx, source, []BalanceUpdate{{User1ID: user1ID, User2ID: user2ID, Amount: amount}})
+}
 
-	_, err := tx.Exec(query, user1ID, user2ID, amount, amount)
-	if err != nil {
-		return fmt.Errorf("failed to update balance: %w", err)
+func (r *balanceRepository) UpdateBalances(tx *sql.Tx, source LedgerSource, updates []BalanceUpdate) error {
+	updates = orderedBalanceUpdates(updates)
+	if len(updates) == 0 {
+		return nil
+	}
+
+	if err := postLedgerEntries(tx, source, updates); err != nil {
+		return err
 	}
+	return applyBalanceUpdates(tx, updates)
+}
 
+// applyBalanceUpdates adds already ordered updates to the balances table without touching the ledger.
+func applyBalanceUpdates(tx *sql.Tx, updates []BalanceUpdate) error {
+	args := make([]interface{}, 0, 3*len(updates))
+	for _, u := range updates {
+		args = append(args, u.User1ID, u.User2ID, u.Amount)
+	}
+	if _, err := tx.Exec(balanceUpsertQuery(len(updates)), args...); err != nil {
+		return fmt.Errorf("failed to update %d balances: %w", len(updates), err)
+	}
 	return nil
 }
 
+// orderedBalanceUpdates keys every update by OrderedPair, merges updates to the same pair and sorts
+// them by pair, so concurrent batches lock the balance rows in the same order.
+func orderedBalanceUpdates(updates []BalanceUpdate) []BalanceUpdate {
+	merged := make(map[[2]int]float64, len(updates))
+	for _, u := range updates {
+		user1ID, user2ID, amount := OrderedPair(u.User1ID, u.User2ID, u.Amount)
+		merged[[2]int{user1ID, user2ID}] += amount
+	}
+
+	ordered := make([]BalanceUpdate, 0, len(merged))
+	for pair, amount := range merged {
+		ordered = append(ordered, BalanceUpdate{User1ID: pair[0], User2ID: pair[1], Amount: amount})
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].User1ID != ordered[j].User1ID {
+			return ordered[i].User1ID < ordered[j].User1ID
+		}
+		return ordered[i].User2ID < ordered[j].User2ID
+	})
+	return ordered
+}
+
+// balanceUpsertQuery adds n (user1_id, user2_id, amount) rows to their balances, creating missing ones.
+func balanceUpsertQuery(n int) string {
+	rows := strings.TrimSuffix(strings.Repeat("(?, ?, ?, NOW()), ", n), ", ")
+	return "INSERT INTO balances (user1_id, user2_id, balance, last_updated) VALUES " + rows +
+		" AS new ON DUPLICATE KEY UPDATE balance = balances.balance + new.balance, last_updated = new.last_updated"
+}
+
 func (r *balanceRepository) GetBalancesByUserID(userID int) ([]Balance, error) {
 	query := `
 		SELECT user1_id, user2_id, balance, last_updated
@@ -96,3 +175,60 @@ func (r *balanceRepository) GetOverallBalanceByUserID(userID int) (float64, erro
 	}
 	return overallBalance, nil
 }
+
+// directedBalancesFrom is the user's balances as counterparty_id and amount, the amount positive
+// when owed in the direction asked for. Its arguments are the user ID, the sign, the user ID three
+// more times, then the smallest amount that doesn't round to zero.
+const directedBalancesFrom = `
+	FROM (
+		SELECT
+			CASE WHEN user1_id = ? THEN user2_id ELSE user1_id END AS counterparty_id,
+			? * CASE WHEN user1_id = ? THEN balance ELSE -balance END AS amount,
+			last_updated
+		FROM balances
+		WHERE user1_id = ? OR user2_id = ?
+	) b
+	WHERE amount >= ?
+`
+
+func (r *balanceRepository) GetDirectedBalances(userID int, direction DebtDirection, limit, offset int) (*DirectedBalances, error) {
+	sign := 1
+	if direction == OwedByUser {
+		sign = -1
+	}
+	// Balances are kept in the default currency, so anything under half its minor unit is settled
+	minAmount := util.FromMinorUnits(1, util.DefaultExponent) / 2
+	args := []any{userID, sign, userID, userID, userID, minAmount}
+
+	page := &DirectedBalances{Balances: []DirectedBalance{}}
+	var total sql.NullFloat64
+	if err := r.db.QueryRow("SELECT COUNT(*), SUM(amount)"+directedBalancesFrom, args...).Scan(&page.Count, &total); err != nil {
+		return nil, fmt.Errorf("failed to total %s balances for user %d: %w", direction, userID, err)
+	}
+	page.Total = total.Float64
+
+	query := "SELECT counterparty_id, amount, last_updated" + directedBalancesFrom + " ORDER BY amount DESC, counterparty_id"
+	if limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, offset)
+	}
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s balances for user %d: %w", direction, userID, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b DirectedBalance
+		if err := rows.Scan(&b.CounterpartyID, &b.Amount, &b.LastUpdated); err != nil {
+			return nil, fmt.Errorf("failed to scan %s balance row for user %d: %w", direction, userID, err)
+		}
+		page.Balances = append(page.Balances, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over %s balance rows for user %d: %w", direction, userID, err)
+	}
+
+	return page, nil
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedBalanceUpdates(t *testing.T) {
+	updates := []BalanceUpdate{
+		{User1ID: 3, User2ID: 1, Amount: 10}, // Stored as 1 owing 3 a negative amount
+		{User1ID: 2, User2ID: 1, Amount: 5},
+		{User1ID: 1, User2ID: 3, Amount: 4},
+	}
+
+	assert.Equal(t, []BalanceUpdate{
+		{User1ID: 1, User2ID: 2, Amount: -5},
+		{User1ID: 1, User2ID: 3, Amount: -6},
+	}, orderedBalanceUpdates(updates))
+	assert.Empty(t, orderedBalanceUpdates(nil))
+}
+
+func TestBalanceUpsertQuery(t *testing.T) {
+	assert.Equal(t,
+		"INSERT INTO balances (user1_id, user2_id, balance, last_updated) VALUES (?, ?, ?, NOW()), (?, ?, ?, NOW()) AS new ON DUPLICATE KEY UPDATE balance = balances.balance + new.balance, last_updated = new.last_updated",
+		balanceUpsertQuery(2))
+}
+
+func TestBalanceUpdate_Delta(t *testing.T) {
+	assert.Equal(t, BalanceDelta{FromUserID: 2, ToUserID: 1, Amount: 15}, BalanceUpdate{User1ID: 1, User2ID: 2, Amount: 15}.Delta())
+	assert.Equal(t, BalanceDelta{FromUserID: 1, ToUserID: 2, Amount: 15}, BalanceUpdate{User1ID: 1, User2ID: 2, Amount: -15}.Delta())
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TagBudget caps how much a user may owe, per calendar month, on expenses with one tag and currency.
+type TagBudget struct {
+	UserID       int       `json:"user_id"`
+	Tag          string    `json:"tag"`
+	Currency     string    `json:"currency"`
+	MonthlyLimit float64   `json:"monthly_limit"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// BudgetWarning tells that an expense takes a participant past one of their tag budgets.
+type BudgetWarning struct {
+	UserEmail    string  `json:"user_email"`
+	Tag          string  `json:"tag"`
+	Currency     string  `json:"currency"`
+	MonthlyLimit float64 `json:"monthly_limit"`
+	SpentAfter   float64 `json:"spent_after"`
+}
+
+type BudgetRepository interface {
+	// SetTagBudget creates or replaces the user's budget for the tag and currency.
+	SetTagBudget(budget *TagBudget) error
+	DeleteTagBudget(userID int, tag, currency string) error
+	GetTagBudgets(userIDs []int) ([]TagBudget, error)
+	// GetTagSpend sums what the user owes on expenses with the tag and currency made since the given time.
+	GetTagSpend(userID int, tag, currency string, since time.Time) (float64, error)
+}
+
+type budgetRepository struct {
+	db *sql.DB
+}
+
+func NewBudgetRepository(db *sql.DB) BudgetRepository {
+	return &budgetRepository{db: db}
+}
+
+func (r *budgetRepository) SetTagBudget(budget *TagBudget) error {
+	query := `
+		INSERT INTO tag_budgets (user_id, tag, currency, monthly_limit, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE monthly_limit = VALUES(monthly_limit), updated_at = VALUES(updated_at)
+	`
+	budget.UpdatedAt = time.Now()
+	if _, err := r.db.Exec(query, budget.UserID, budget.Tag, budget.Currency, budget.MonthlyLimit, budget.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to set %s budget for user %d: %w", budget.Tag, budget.UserID, err)
+	}
+	return nil
+}
+
+func (r *budgetRepository) DeleteTagBudget(userID int, tag, currency string) error {
+	query := "DELETE FROM tag_budgets WHERE user_id = ? AND tag = ? AND currency = ?"
+	if _, err := r.db.Exec(query, userID, tag, currency); err != nil {
+		return fmt.Errorf("failed to delete %s budget for user %d: %w", tag, userID, err)
+	}
+	return nil
+}
+
+func (r *budgetRepository) GetTagBudgets(userIDs []int) ([]TagBudget, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(userIDs))
+	args := make([]interface{}, len(userIDs))
+	for i, id := range userIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := fmt.Sprintf("SELECT user_id, tag, currency, monthly_limit, updated_at FROM tag_budgets WHERE user_id IN (%s) ORDER BY user_id, tag, currency", strings.Join(placeholders, ","))
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tag budgets: %w", err)
+	}
+	defer rows.Close()
+
+	var budgets []TagBudget
+	for rows.Next() {
+		var b TagBudget
+		if err := rows.Scan(&b.UserID, &b.Tag, &b.Currency, &b.MonthlyLimit, &b.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tag budget row: %w", err)
+		}
+		budgets = append(budgets, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over tag budget rows: %w", err)
+	}
+
+	return budgets, nil
+}
+
+func (r *budgetRepository) GetTagSpend(userID int, tag, currency string, since time.Time) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(es.amount_owed), 0)
+		FROM expense_splits es
+		JOIN expenses e ON e.id = es.expense_id
+		WHERE es.user_id = ? AND e.tag = ? AND e.currency = ? AND e.created_at >= ?
+	`
+	var spent float64
+	if err := r.db.QueryRow(query, userID, tag, currency, since).Scan(&spent); err != nil {
+		return 0, fmt.Errorf("failed to sum %s spend for user %d: %w", tag, userID, err)
+	}
+	return spent, nil
+}
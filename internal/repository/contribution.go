@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// Contribution is the part of one posting to a pair's balance that is still unsettled, in minor units.
+type Contribution struct {
+	Source LedgerSource
+	At     time.Time
+	Units  int64
+}
+
+// UnsettledContributions replays a pair's entries, one user's side of them oldest first, and
+// returns what is left of each contribution to the balance, and how much settlements have paid
+// towards them since the pair was last even, in minor units. Payments settle the oldest
+// contributions first, except that reversing an expense takes back that expense's own contribution.
+func UnsettledContributions(entries []LedgerEntry) (open []Contribution, paid int64) {
+	exp := util.DefaultExponent
+	for _, e := range entries {
+		units := util.ToMinorUnits(e.Amount, exp)
+		if e.Source.Type == LedgerExpenseReversal {
+			reversed := LedgerSource{Type: LedgerExpense, ID: e.Source.ID}
+			for i := range open {
+				if open[i].Source != reversed || (open[i].Units > 0) == (units > 0) {
+					continue
+				}
+				if abs64(units) < abs64(open[i].Units) {
+					open[i].Units += units
+					units = 0
+				} else {
+					units += open[i].Units
+					open = append(open[:i], open[i+1:]...)
+				}
+				break
+			}
+		}
+		// What runs the same way as the balance adds to it; the rest pays off the oldest first and
+		// whatever is left over runs the balance the other way
+		unpaid := units
+		for units != 0 && len(open) > 0 && (open[0].Units > 0) != (units > 0) {
+			if abs64(units) < abs64(open[0].Units) {
+				open[0].Units += units
+				units = 0
+				break
+			}
+			units += open[0].Units
+			open = open[1:]
+		}
+		if e.Source.Type == LedgerSettlement {
+			paid += abs64(unpaid - units)
+		}
+		if len(open) == 0 {
+			paid = 0 // Even again, or the other way round
+		}
+		if units != 0 {
+			open = append(open, Contribution{Source: e.Source, At: e.CreatedAt, Units: units})
+		}
+	}
+	return open, paid
+}
+
+// CoveredExpenses lists, oldest first, the expenses whose contribution to a pair's balance was
+// still open before the postings of source and is paid off entirely after them. entries are one
+// user's side of the pair's postings, oldest first, including those of source.
+func CoveredExpenses(entries []LedgerEntry, source LedgerSource) []int {
+	before := make([]LedgerEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.Source != source {
+			before = append(before, e)
+		}
+	}
+	openBefore, _ := UnsettledContributions(before)
+	openAfter, _ := UnsettledContributions(entries)
+
+	stillOpen := make(map[int]bool, len(openAfter))
+	for _, c := range openAfter {
+		if c.Source.Type == LedgerExpense {
+			stillOpen[c.Source.ID] = true
+		}
+	}
+	var ids []int
+	for _, c := range openBefore {
+		if c.Source.Type == LedgerExpense && !stillOpen[c.Source.ID] {
+			ids = append(ids, c.Source.ID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnsettledContributions(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	expense := func(id int) LedgerSource {
+		return LedgerSource{Type: LedgerExpense, ID: id}
+	}
+	settlement := LedgerSource{Type: LedgerSettlement, ID: 1}
+	entry := func(source LedgerSource, amount float64, d int) LedgerEntry {
+		return LedgerEntry{Source: source, Amount: amount, CreatedAt: day(d)}
+	}
+
+	// Test case 1: A payment settles the oldest expense first and eats into the next
+	open, paid := UnsettledContributions([]LedgerEntry{
+		entry(expense(1), 30, 1),
+		entry(expense(2), 20, 2),
+		entry(settlement, -40, 3),
+	})
+	assert.Equal(t, []Contribution{{Source: expense(2), At: day(2), Units: 1000}}, open)
+	assert.Equal(t, int64(4000), paid)
+
+	// Test case 2: Undoing an expense takes back that expense, not the oldest one
+	open, paid = UnsettledContributions([]LedgerEntry{
+		entry(expense(1), 30, 1),
+		entry(expense(2), 20, 2),
+		entry(LedgerSource{Type: LedgerExpenseReversal, ID: 1}, -30, 3),
+	})
+	assert.Equal(t, []Contribution{{Source: expense(2), At: day(2), Units: 2000}}, open)
+	assert.Zero(t, paid)
+
+	// Test case 3: Overpaying flips the balance, dated from the payment
+	open, paid = UnsettledContributions([]LedgerEntry{
+		entry(expense(1), 30, 1),
+		entry(settlement, -50, 4),
+	})
+	assert.Equal(t, []Contribution{{Source: settlement, At: day(4), Units: -2000}}, open)
+	assert.Zero(t, paid)
+
+	// Test case 4: Paid off exactly
+	open, paid = UnsettledContributions([]LedgerEntry{
+		entry(expense(1), 30, 1),
+		entry(settlement, -30, 2),
+	})
+	assert.Empty(t, open)
+	assert.Zero(t, paid)
+
+	// Test case 5: Partial payments add up until the pair is even again, and start over after
+	open, paid = UnsettledContributions([]LedgerEntry{
+		entry(expense(1), 30, 1),
+		entry(settlement, -30, 2),
+		entry(expense(2), 55, 3),
+		entry(settlement, -20, 4),
+		entry(expense(3), -5, 5), // Offsetting what is owed is not a payment
+		entry(settlement, -10, 6),
+	})
+	assert.Equal(t, []Contribution{{Source: expense(2), At: day(3), Units: 2000}}, open)
+	assert.Equal(t, int64(3000), paid)
+}
+
+func TestCoveredExpenses(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	expense := func(id int) LedgerSource { return LedgerSource{Type: LedgerExpense, ID: id} }
+	settlement := func(id int) LedgerSource { return LedgerSource{Type: LedgerSettlement, ID: id} }
+	entry := func(source LedgerSource, amount float64, d int) LedgerEntry {
+		return LedgerEntry{Source: source, Amount: amount, CreatedAt: day(d)}
+	}
+	entries := []LedgerEntry{
+		entry(expense(1), 30, 1),
+		entry(expense(2), 20, 2),
+		entry(expense(3), 5, 3),
+	}
+
+	// Test case 1: A partial payment covers the oldest expenses it pays off in full
+	paid := append(entries, entry(settlement(1), -40, 4))
+	assert.Equal(t, []int{1}, CoveredExpenses(paid, settlement(1)))
+
+	// Test case 2: The next payment covers what it finishes paying off, not what was covered before
+	paid = append(paid, entry(settlement(2), -15, 5))
+	assert.Equal(t, []int{2, 3}, CoveredExpenses(paid, settlement(2)))
+
+	// Test case 3: Too little to pay off any expense covers none
+	assert.Empty(t, CoveredExpenses(append(entries, entry(settlement(1), -10, 4)), settlement(1)))
+
+	// Test case 4: Paying the wrong way covers nothing
+	assert.Empty(t, CoveredExpenses(append(entries, entry(settlement(1), 10, 4)), settlement(1)))
+}
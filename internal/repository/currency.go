@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// CurrencyRepository reads the currencies table: the minor unit of every currency that doesn't have
+// two decimal places.
+type CurrencyRepository interface {
+	// GetCurrencyExponents maps currency codes to the number of decimal places in their minor unit.
+	GetCurrencyExponents() (map[string]int, error)
+}
+
+type currencyRepository struct {
+	db *sql.DB
+}
+
+func NewCurrencyRepository(db *sql.DB) CurrencyRepository {
+	return &currencyRepository{db: db}
+}
+
+func (r *currencyRepository) GetCurrencyExponents() (map[string]int, error) {
+	rows, err := r.db.Query("SELECT code, exponent FROM currencies")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query currencies: %w", err)
+	}
+	defer rows.Close()
+
+	exponents := make(map[string]int)
+	for rows.Next() {
+		var (
+			code     string
+			exponent int
+		)
+		if err := rows.Scan(&code, &exponent); err != nil {
+			return nil, fmt.Errorf("failed to scan currency row: %w", err)
+		}
+		exponents[code] = exponent
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over currency rows: %w", err)
+	}
+
+	return exponents, nil
+}
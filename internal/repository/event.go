@@ -0,0 +1,312 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrEventNotFound is returned when an event ID does not exist.
+var ErrEventNotFound = errors.New("event not found")
+
+// Event is a trip or occasion whose expenses form their own sub-ledger, with totals and a settle-up
+// of their own. Archived events take no new expenses, but stay readable and can be unarchived.
+type Event struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	CreatedBy int    `json:"created_by"`
+	// BaseCurrency, when set, is the currency the event's expenses are kept in; expenses entered in
+	// another currency are converted to it.
+	BaseCurrency string `json:"base_currency,omitempty"`
+	// Unit, when set, names what the event counts instead of money, such as chore points. Its
+	// expenses are in util.UnitCurrency, in whole units, and don't move balances.
+	Unit string `json:"unit,omitempty"`
+	// AutoSettle has the month-end settle-up propose the event's transfers as settlements, on top of
+	// emailing them.
+	AutoSettle bool       `json:"auto_settle"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	// LastSettleUpAt is when the month-end settle-up last ran for the event.
+	LastSettleUpAt *time.Time `json:"last_settle_up_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// EventSplit is one participant's part in one of an event's expenses.
+type EventSplit struct {
+	ExpenseID  int
+	Currency   string
+	UserID     int
+	AmountPaid float64
+	AmountOwed float64
+}
+
+// EventExpense is the part of an expense shown in an event's ledger.
+type EventExpense struct {
+	ID          int       `json:"id"`
+	Description string    `json:"description"`
+	Tag         string    `json:"tag"`
+	TotalAmount float64   `json:"total_amount"`
+	Currency    string    `json:"currency"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type EventRepository interface {
+	CreateEvent(event *Event) (*Event, error)
+	GetEvent(id int) (*Event, error)
+	// ArchiveEvent marks the event archived; archiving an archived event keeps the first time.
+	ArchiveEvent(id int) (*Event, error)
+	UnarchiveEvent(id int) (*Event, error)
+	// ListEvents returns the events the user created, joined or has an expense in, newest first.
+	ListEvents(userID int, includeArchived bool) ([]Event, error)
+	// AddEventMember adds the user to the event; joining twice is a no-op.
+	AddEventMember(eventID, userID int) error
+	// GetEventSplits returns every split of the event's expenses, by expense.
+	GetEventSplits(eventID int) ([]EventSplit, error)
+	// GetEventExpenses returns the event's expenses, newest first.
+	GetEventExpenses(eventID int) ([]EventExpense, error)
+	SetAutoSettle(id int, enabled bool) (*Event, error)
+	// SetBaseCurrency fixes the event's base currency, or clears it when currency is empty.
+	SetBaseCurrency(id int, currency string) (*Event, error)
+	// GetEventsDueForSettleUp returns the unarchived events whose last settle-up, or creation if they
+	// have had none, is before the given time.
+	GetEventsDueForSettleUp(before time.Time) ([]Event, error)
+	// ClaimSettleUp moves the event's last settle-up time from last to at, and reports false if another
+	// runner got there first, so each month is settled up once however many servers are running.
+	ClaimSettleUp(id int, last *time.Time, at time.Time) (bool, error)
+}
+
+type eventRepository struct {
+	db *sql.DB
+}
+
+func NewEventRepository(db *sql.DB) EventRepository {
+	return &eventRepository{db: db}
+}
+
+// eventColumns is what scanEvent reads, in order.
+const eventColumns = "id, name, created_by, base_currency, unit, auto_settle, archived_at, last_settle_up_at, created_at"
+
+func scanEvent(row interface{ Scan(...any) error }, e *Event) error {
+	var (
+		baseCurrency, unit         sql.NullString
+		archivedAt, lastSettleUpAt sql.NullTime
+	)
+	if err := row.Scan(&e.ID, &e.Name, &e.CreatedBy, &baseCurrency, &unit, &e.AutoSettle, &archivedAt, &lastSettleUpAt, &e.CreatedAt); err != nil {
+		return err
+	}
+	e.BaseCurrency, e.Unit = baseCurrency.String, unit.String
+	if archivedAt.Valid {
+		e.ArchivedAt = &archivedAt.Time
+	}
+	if lastSettleUpAt.Valid {
+		e.LastSettleUpAt = &lastSettleUpAt.Time
+	}
+	return nil
+}
+
+func (r *eventRepository) CreateEvent(event *Event) (*Event, error) {
+	query := "INSERT INTO events (name, created_by, base_currency, unit, created_at) VALUES (?, ?, NULLIF(?, ''), NULLIF(?, ''), ?)"
+	event.CreatedAt = time.Now()
+	result, err := r.db.Exec(query, event.Name, event.CreatedBy, event.BaseCurrency, event.Unit, event.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID for event: %w", err)
+	}
+	event.ID = int(id)
+
+	return event, nil
+}
+
+func (r *eventRepository) GetEvent(id int) (*Event, error) {
+	query := "SELECT " + eventColumns + " FROM events WHERE id = ?"
+	e := &Event{}
+	if err := scanEvent(r.db.QueryRow(query, id), e); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %d", ErrEventNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+	return e, nil
+}
+
+func (r *eventRepository) ArchiveEvent(id int) (*Event, error) {
+	if _, err := r.db.Exec("UPDATE events SET archived_at = COALESCE(archived_at, ?) WHERE id = ?", time.Now(), id); err != nil {
+		return nil, fmt.Errorf("failed to archive event %d: %w", id, err)
+	}
+	return r.GetEvent(id)
+}
+
+func (r *eventRepository) UnarchiveEvent(id int) (*Event, error) {
+	if _, err := r.db.Exec("UPDATE events SET archived_at = NULL WHERE id = ?", id); err != nil {
+		return nil, fmt.Errorf("failed to unarchive event %d: %w", id, err)
+	}
+	return r.GetEvent(id)
+}
+
+func (r *eventRepository) ListEvents(userID int, includeArchived bool) ([]Event, error) {
+	query := `
+		SELECT ` + eventColumns + `
+		FROM events
+		WHERE (created_by = ? OR id IN (
+			SELECT e.event_id FROM expenses e
+			JOIN expense_splits es ON es.expense_id = e.id
+			WHERE e.event_id IS NOT NULL AND es.user_id = ?
+		) OR id IN (SELECT event_id FROM event_members WHERE user_id = ?))
+		AND (? OR archived_at IS NULL)
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := r.db.Query(query, userID, userID, userID, includeArchived)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		var e Event
+		if err := scanEvent(rows, &e); err != nil {
+			return nil, fmt.Errorf("failed to scan event row for user %d: %w", userID, err)
+		}
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over event rows for user %d: %w", userID, err)
+	}
+
+	return events, nil
+}
+
+func (r *eventRepository) AddEventMember(eventID, userID int) error {
+	if _, err := r.db.Exec("INSERT IGNORE INTO event_members (event_id, user_id) VALUES (?, ?)", eventID, userID); err != nil {
+		return fmt.Errorf("failed to add user %d to event %d: %w", userID, eventID, err)
+	}
+	return nil
+}
+
+func (r *eventRepository) GetEventSplits(eventID int) ([]EventSplit, error) {
+	query := `
+		SELECT e.id, e.currency, es.user_id, es.amount_paid, es.amount_owed
+		FROM expenses e
+		JOIN expense_splits es ON es.expense_id = e.id
+		WHERE e.event_id = ?
+		ORDER BY e.id, es.id
+	`
+
+	rows, err := r.db.Query(query, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query splits for event %d: %w", eventID, err)
+	}
+	defer rows.Close()
+
+	var splits []EventSplit
+	for rows.Next() {
+		var s EventSplit
+		if err := rows.Scan(&s.ExpenseID, &s.Currency, &s.UserID, &s.AmountPaid, &s.AmountOwed); err != nil {
+			return nil, fmt.Errorf("failed to scan split row for event %d: %w", eventID, err)
+		}
+		splits = append(splits, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over split rows for event %d: %w", eventID, err)
+	}
+
+	return splits, nil
+}
+
+func (r *eventRepository) GetEventExpenses(eventID int) ([]EventExpense, error) {
+	query := `
+		SELECT id, description, tag, total_amount, currency, created_at
+		FROM expenses
+		WHERE event_id = ?
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := r.db.Query(query, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expenses for event %d: %w", eventID, err)
+	}
+	defer rows.Close()
+
+	var expenses []EventExpense
+	for rows.Next() {
+		var e EventExpense
+		if err := rows.Scan(&e.ID, &e.Description, &e.Tag, &e.TotalAmount, &e.Currency, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan expense row for event %d: %w", eventID, err)
+		}
+		expenses = append(expenses, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over expense rows for event %d: %w", eventID, err)
+	}
+
+	return expenses, nil
+}
+
+func (r *eventRepository) SetAutoSettle(id int, enabled bool) (*Event, error) {
+	if _, err := r.db.Exec("UPDATE events SET auto_settle = ? WHERE id = ?", enabled, id); err != nil {
+		return nil, fmt.Errorf("failed to set auto-settle for event %d: %w", id, err)
+	}
+	return r.GetEvent(id)
+}
+
+func (r *eventRepository) SetBaseCurrency(id int, currency string) (*Event, error) {
+	if _, err := r.db.Exec("UPDATE events SET base_currency = NULLIF(?, '') WHERE id = ?", currency, id); err != nil {
+		return nil, fmt.Errorf("failed to set base currency for event %d: %w", id, err)
+	}
+	return r.GetEvent(id)
+}
+
+func (r *eventRepository) GetEventsDueForSettleUp(before time.Time) ([]Event, error) {
+	query := `
+		SELECT ` + eventColumns + `
+		FROM events
+		WHERE archived_at IS NULL AND COALESCE(last_settle_up_at, created_at) < ?
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(query, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events due for settle-up: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		if err := scanEvent(rows, &e); err != nil {
+			return nil, fmt.Errorf("failed to scan event due for settle-up: %w", err)
+		}
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over events due for settle-up: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *eventRepository) ClaimSettleUp(id int, last *time.Time, at time.Time) (bool, error) {
+	var prev sql.NullTime
+	if last != nil {
+		prev = sql.NullTime{Time: *last, Valid: true}
+	}
+
+	result, err := r.db.Exec("UPDATE events SET last_settle_up_at = ? WHERE id = ? AND last_settle_up_at <=> ?", at, id, prev)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim settle-up for event %d: %w", id, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim settle-up for event %d: %w", id, err)
+	}
+	return n > 0, nil
+}
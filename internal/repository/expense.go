@@ -2,22 +2,93 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// ExpenseStatus tells whether an expense is accepted by its participants or under dispute.
+type ExpenseStatus string
+
+const (
+	ExpenseActive   ExpenseStatus = "active"
+	ExpenseDisputed ExpenseStatus = "disputed"
+)
+
+var (
+	ErrExpenseNotFound          = errors.New("expense not found")
+	ErrInvalidExpenseTransition = errors.New("invalid expense status transition")
+	ErrExpenseLocked            = errors.New("expense is locked by a confirmed settlement")
+	ErrExpenseNotLocked         = errors.New("expense is not locked")
 )
 
 type Expense struct {
-	ID          int       `json:"id"`
-	Description string    `json:"description"`
-	Tag         string    `json:"tag"`
-	TotalAmount float64   `json:"total_amount"`
-	CreatedBy   int       `json:"created_by"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID int `json:"-"`
+	// PublicID identifies the expense outside the database; unlike ID it can't be guessed, so it is
+	// the only one the API gives out.
+	PublicID      string        `json:"id"`
+	Description   string        `json:"description"`
+	Tag           string        `json:"tag"`
+	TotalAmount   float64       `json:"total_amount"`
+	Currency      string        `json:"currency"`
+	CreatedBy     int           `json:"created_by"`
+	Status        ExpenseStatus `json:"status"`
+	DisputeReason string        `json:"dispute_reason,omitempty"`
+	RefundOf      *int          `json:"-"` // Set on refunds, whose amounts are negative
+	// RefundOfPublicID is the public ID of the expense RefundOf names.
+	RefundOfPublicID string    `json:"refund_of,omitempty"`
+	PayeeParty       *Party    `json:"payee_party,omitempty"` // Outside party the expense was paid to, if any
+	Location         *Location `json:"location,omitempty"`
+	EventID          *int      `json:"event_id,omitempty"` // Trip or occasion the expense belongs to
+	// BalanceStrategy names how the splits moved balances, so they can be reversed the same way.
+	// Empty on expenses recorded before it was, which all used "simple".
+	BalanceStrategy string `json:"balance_strategy,omitempty"`
+	// Conversion is set when the expense was entered in another currency than its event's base
+	// currency and converted on entry; the amounts and splits are in the base currency.
+	Conversion *CurrencyConversion `json:"conversion,omitempty"`
+	// Adjustment is set when validation changed the creator's split so the splits add up.
+	Adjustment *ExpenseAdjustment `json:"adjustment,omitempty"`
+	CreatedAt  time.Time          `json:"created_at"`
+	// LockedAt is set once a confirmed settlement has paid off the expense's share of a balance; it
+	// can't be undone or disputed until its creator unlocks it. SettledBy is the settlement that
+	// first locked it and stays set once unlocked.
+	LockedAt  *time.Time `json:"locked_at,omitempty"`
+	SettledBy *int       `json:"settled_by,omitempty"`
+	// BudgetWarnings, Splits, BalanceDeltas and UndoUntil are filled on creation only. Splits are
+	// stored in their own table and the deltas are folded into the balances.
+	BudgetWarnings []BudgetWarning `json:"budget_warnings,omitempty"`
+	Splits         []ExpenseSplit  `json:"splits,omitempty"`
+	BalanceDeltas  []BalanceDelta  `json:"balance_deltas,omitempty"`
+	UndoUntil      *time.Time      `json:"undo_until,omitempty"` // Until when the creator may still delete it
+	// Explanation is filled on creation when the client asks for it.
+	Explanation *SplitExplanation `json:"explanation,omitempty"`
+	// SuggestedTag is filled on creation when the expense was given no tag and one can be suggested
+	// from its description. It is not stored.
+	SuggestedTag string `json:"suggested_tag,omitempty"`
+}
+
+// CurrencyConversion is what an expense was entered as before it was converted.
+type CurrencyConversion struct {
+	OriginalCurrency string  `json:"original_currency"`
+	OriginalAmount   float64 `json:"original_amount"`
+	// Rate is how much one unit of the original currency was worth in the expense's currency.
+	Rate float64 `json:"rate"`
+}
+
+// ExpenseAdjustment is how much the creator's amounts paid and owed were changed by to absorb a
+// small difference between the splits as entered and the total. It is in the currency and sign the
+// expense was entered in, before any conversion.
+type ExpenseAdjustment struct {
+	AmountPaid float64 `json:"amount_paid"`
+	AmountOwed float64 `json:"amount_owed"`
 }
 
 type ExpenseSplit struct {
-	ID         int     `json:"id"`
-	ExpenseID  int     `json:"expense_id"`
+	ID         int     `json:"-"`
+	ExpenseID  int     `json:"-"`
 	UserID     int     `json:"user_id"`
 	AmountPaid float64 `json:"amount_paid"`
 	AmountOwed float64 `json:"amount_owed"`
@@ -29,29 +100,181 @@ type BalanceUpdate struct {
 	Amount  float64
 }
 
+// BalanceDelta tells a client that FromUserID now owes ToUserID Amount more than before.
+type BalanceDelta struct {
+	FromUserID int     `json:"from_user_id"`
+	ToUserID   int     `json:"to_user_id"`
+	Amount     float64 `json:"amount"`
+}
+
+// SplitExplanation shows the math behind an expense's splits: each participant's exact share, how it
+// was rounded down to the currency's minor unit, who took the units left over, and the balances that
+// moved as a result. Refunds are split like expenses and then reversed; the explanation shows the
+// split before the reversal.
+type SplitExplanation struct {
+	Method         string             `json:"method"`
+	Currency       string             `json:"currency"`
+	MinorUnit      float64            `json:"minor_unit"` // Smallest amount the currency is kept in, like 0.01
+	Subtotal       float64            `json:"subtotal"`   // Before tax and tip
+	TaxAndTip      float64            `json:"tax_and_tip"`
+	Shares         []ShareExplanation `json:"shares"`
+	RemainderUnits int64              `json:"remainder_units"` // Minor units left after rounding every share down
+	RemainderTo    string             `json:"remainder_to,omitempty"`
+	Steps          []string           `json:"steps"`
+	BalanceDeltas  []BalanceDelta     `json:"balance_deltas"`
+}
+
+type ShareExplanation struct {
+	UserEmail   string  `json:"user_email"`
+	Basis       float64 `json:"basis"`      // What the share is in proportion to: 1 for equal, the percentage, days, weight or manual amount
+	RawAmount   float64 `json:"raw_amount"` // Exact share of the subtotal, before rounding
+	RoundedDown float64 `json:"rounded_down"`
+	Remainder   float64 `json:"remainder"`   // Leftover units added to this share
+	TaxAndTip   float64 `json:"tax_and_tip"` // This share's part of the tax and tip, rounded the same way
+	AmountOwed  float64 `json:"amount_owed"`
+	AmountPaid  float64 `json:"amount_paid"`
+}
+
+// Delta describes a balance update, in which User2ID owes User1ID Amount, from the debtor's side.
+func (u BalanceUpdate) Delta() BalanceDelta {
+	if u.Amount < 0 {
+		return BalanceDelta{FromUserID: u.User1ID, ToUserID: u.User2ID, Amount: -u.Amount}
+	}
+	return BalanceDelta{FromUserID: u.User2ID, ToUserID: u.User1ID, Amount: u.Amount}
+}
+
 type UserExpenseView struct {
-	Date        time.Time `json:"date"`
-	Tag         string    `json:"tag"`
-	Description string    `json:"description"`
-	TotalAmount float64   `json:"total_amount"`
-	Share       float64   `json:"share"`
+	ExpenseID       int           `json:"-"`
+	ExpensePublicID string        `json:"expense_id"`
+	Date            time.Time     `json:"date"`
+	Tag             string        `json:"tag"`
+	Description     string        `json:"description"`
+	TotalAmount     float64       `json:"total_amount"`
+	Currency        string        `json:"currency"`
+	Share           float64       `json:"share"`
+	Status          ExpenseStatus `json:"status"`
+	Payee           string        `json:"payee,omitempty"` // Name of the outside party the expense was paid to
+	// CreatedByName and CreatedByEmail are who added the expense.
+	CreatedByName  string `json:"created_by_name"`
+	CreatedByEmail string `json:"created_by_email"`
+	// IsPayer reports whether the user the view is for paid towards the expense, or for a refund
+	// was paid back.
+	IsPayer bool `json:"is_payer"`
+	// RunningBalance is the sum of the user's shares up to and including this expense. Only filled on request.
+	RunningBalance *float64 `json:"running_balance,omitempty"`
+}
+
+// UserExpensePage is a page of a user's expenses. Count is how many expenses the user has in all,
+// and OlderShare sums the user's shares in the expenses after the page, the older ones, so running
+// balances can carry on from where the page leaves off. Like the balances, it leaves out expenses
+// counted in an event's own unit.
+type UserExpensePage struct {
+	Expenses   []UserExpenseView
+	Count      int
+	OlderShare float64
+}
+
+// Location is where an expense was paid, in WGS 84 degrees.
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	PlaceName string  `json:"place_name,omitempty"`
+}
+
+// NearbyExpense is an expense the user took part in, with where it happened and how far that is
+// from the point searched around.
+type NearbyExpense struct {
+	UserExpenseView
+	Location       Location `json:"location"`
+	DistanceMeters float64  `json:"distance_meters"`
+}
+
+// ExpenseActivity is one expense a user took part in, with their split and who else shared it.
+type ExpenseActivity struct {
+	ExpenseID      int
+	Description    string
+	Tag            string
+	TotalAmount    float64
+	Currency       string
+	CreatedAt      time.Time
+	AmountPaid     float64
+	AmountOwed     float64
+	CoParticipants []int
+}
+
+// DailySpend is what a user owed on the expenses of one day.
+type DailySpend struct {
+	Date         time.Time
+	Owed         float64
+	ExpenseCount int
 }
 
 type ExpenseRepository interface {
 	CreateExpense(expense *Expense, splits []ExpenseSplit, balanceUpdates []BalanceUpdate) (*Expense, error)
+	GetExpense(id int) (*Expense, error)
+	GetExpenseByPublicID(publicID string) (*Expense, error)
+	// DeleteExpense removes an expense with its splits and location, and applies balanceUpdates,
+	// which should cancel out the ones the expense was created with. A locked expense can't be deleted.
+	DeleteExpense(id int, balanceUpdates []BalanceUpdate) error
+	GetExpenseSplits(expenseID int) ([]ExpenseSplit, error)
 	GetExpensesByUserID(userID int) ([]UserExpenseView, error)
+	// GetExpensePageByUserID returns limit of the user's expenses from offset, newest first, as
+	// GetExpensesByUserID orders them.
+	GetExpensePageByUserID(userID, limit, offset int) (*UserExpensePage, error)
+	// GetUserActivity returns the expenses the user took part in within [from, to), oldest first.
+	GetUserActivity(userID int, from, to time.Time) ([]ExpenseActivity, error)
+	// GetDailySpend sums the user's shares per day within [from, to), leaving out days without expenses.
+	GetDailySpend(userID int, from, to time.Time) ([]DailySpend, error)
+	// GetNearbyExpenses returns the user's expenses located within radius meters of the point, nearest first.
+	GetNearbyExpenses(userID int, latitude, longitude, radius float64) ([]NearbyExpense, error)
+	// GetSplitsForExpensesInvolving returns every split of the expenses any of the users took part in.
+	GetSplitsForExpensesInvolving(userIDs []int) ([]ExpenseSplit, error)
+	// TransitionExpense moves an expense from one status to another, recording the dispute reason.
+	// A locked expense keeps its status.
+	TransitionExpense(id int, from, to ExpenseStatus, reason string) (*Expense, error)
+	// UnlockExpense lifts the lock a confirmed settlement put on an expense, keeping SettledBy.
+	UnlockExpense(id int) (*Expense, error)
+	// GetRefundedAmount returns how much of an expense has been given back through refunds, as a positive amount.
+	GetRefundedAmount(expenseID int) (float64, error)
+	// ClaimShare records that the user claimed their share of the expense and returns when they
+	// first did; claiming again keeps the first time.
+	ClaimShare(expenseID, userID int) (time.Time, error)
+	// GetShareClaims returns when each participant who claimed their share of the expense did so.
+	GetShareClaims(expenseID int) (map[int]time.Time, error)
+	// HasDisputedExpenseBetween reports whether an expense affecting the balance of the two users is under dispute.
+	HasDisputedExpenseBetween(user1ID, user2ID int) (bool, error)
 }
 
 type expenseRepository struct {
 	db          *sql.DB
 	balanceRepo BalanceRepository
+	// recordEvents appends every change to the expense_events store, in the same transaction.
+	recordEvents bool
 }
 
 func NewExpenseRepository(db *sql.DB, balanceRepo BalanceRepository) ExpenseRepository {
 	return &expenseRepository{db: db, balanceRepo: balanceRepo}
 }
 
+// NewEventSourcedExpenseRepository is NewExpenseRepository that also records every creation,
+// status change and deletion as an event, from which the expense tables can be rebuilt.
+func NewEventSourcedExpenseRepository(db *sql.DB, balanceRepo BalanceRepository) ExpenseRepository {
+	return &expenseRepository{db: db, balanceRepo: balanceRepo, recordEvents: true}
+}
+
+// appendEvent records an event for the expense as part of tx when the event store is enabled.
+func (r *expenseRepository) appendEvent(tx *sql.Tx, expenseID int, eventType ExpenseEventType, data any) error {
+	if !r.recordEvents {
+		return nil
+	}
+	return appendExpenseEvent(tx, expenseID, eventType, data)
+}
+
 func (r *expenseRepository) CreateExpense(expense *Expense, splits []ExpenseSplit, balanceUpdates []BalanceUpdate) (*Expense, error) {
+	return withRetry("create expense", func() (*Expense, error) { return r.createExpense(expense, splits, balanceUpdates) })
+}
+
+func (r *expenseRepository) createExpense(expense *Expense, splits []ExpenseSplit, balanceUpdates []BalanceUpdate) (*Expense, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
@@ -59,10 +282,20 @@ func (r *expenseRepository) CreateExpense(expense *Expense, splits []ExpenseSpli
 	defer tx.Rollback() // Rollback on error, no-op on commit
 
 	// Insert expense
-	expenseQuery := "INSERT INTO expenses (description, tag, total_amount, created_by, created_at) VALUES (?, ?, ?, ?, ?)"
+	expenseQuery := "INSERT INTO expenses (public_id, description, tag, total_amount, currency, original_currency, original_amount, exchange_rate, paid_adjustment, owed_adjustment, created_by, status, refund_of, payee_party_id, event_id, balance_strategy, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	expense.Status = ExpenseActive
 	expense.CreatedAt = time.Now() // Set CreatedAt before insertion
-	result, err := tx.Exec(expenseQuery, expense.Description, expense.Tag, expense.TotalAmount, expense.CreatedBy, expense.CreatedAt)
+	var payeePartyID *int
+	if expense.PayeeParty != nil {
+		payeePartyID = &expense.PayeeParty.ID
+	}
+	originalCurrency, originalAmount, rate := expense.Conversion.columns()
+	paidAdjustment, owedAdjustment := expense.Adjustment.columns()
+	result, err := tx.Exec(expenseQuery, expense.PublicID, expense.Description, expense.Tag, expense.TotalAmount, expense.Currency, originalCurrency, originalAmount, rate, paidAdjustment, owedAdjustment, expense.CreatedBy, expense.Status, expense.RefundOf, payeePartyID, expense.EventID, expense.BalanceStrategy, expense.CreatedAt)
 	if err != nil {
+		if isDuplicatePublicID(err) {
+			return nil, fmt.Errorf("%w: %s", ErrPublicIDTaken, expense.PublicID)
+		}
 		return nil, fmt.Errorf("failed to create expense: %w", err)
 	}
 
@@ -72,23 +305,38 @@ func (r *expenseRepository) CreateExpense(expense *Expense, splits []ExpenseSpli
 	}
 	expense.ID = int(id)
 
-	// Insert expense splits
-	for _, split := range splits {
-		// Insert split
+	// Insert expense splits, filling in their IDs
+	for i, split := range splits {
 		splitQuery := "INSERT INTO expense_splits (expense_id, user_id, amount_paid, amount_owed) VALUES (?, ?, ?, ?)"
-		_, err := tx.Exec(splitQuery, expense.ID, split.UserID, split.AmountPaid, split.AmountOwed)
+		result, err := tx.Exec(splitQuery, expense.ID, split.UserID, split.AmountPaid, split.AmountOwed)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create expense split: %w", err)
 		}
+		splitID, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get last insert ID for expense split: %w", err)
+		}
+		splits[i].ID = int(splitID)
+		splits[i].ExpenseID = expense.ID
 	}
 
-	// Update balances
-	for _, update := range balanceUpdates {
-		err = r.balanceRepo.UpdateBalance(tx, update.User1ID, update.User2ID, update.Amount)
-		if err != nil {
-			return nil, fmt.Errorf("failed to update balance between user %d and %d: %w", update.User1ID, update.User2ID, err)
+	if l := expense.Location; l != nil {
+		locationQuery := "INSERT INTO expense_locations (expense_id, latitude, longitude, place_name, location) VALUES (?, ?, ?, ?, ST_GeomFromText(?, 4326))"
+		if _, err := tx.Exec(locationQuery, expense.ID, l.Latitude, l.Longitude, l.PlaceName, pointWKT(l.Latitude, l.Longitude)); err != nil {
+			return nil, fmt.Errorf("failed to store expense location: %w", err)
 		}
 	}
+	if err := r.appendEvent(tx, expense.ID, ExpenseCreatedEvent, ExpenseCreatedData(expense, splits)); err != nil {
+		return nil, err
+	}
+
+	// Update balances
+	if err := r.balanceRepo.UpdateBalances(tx, LedgerSource{Type: LedgerExpense, ID: expense.ID}, balanceUpdates); err != nil {
+		return nil, fmt.Errorf("failed to update balances for expense: %w", err)
+	}
+	if err := touchUsers(tx, expenseUserIDs(expense, splits)...); err != nil {
+		return nil, err
+	}
 
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
@@ -97,52 +345,564 @@ func (r *expenseRepository) CreateExpense(expense *Expense, splits []ExpenseSpli
 	return expense, nil
 }
 
-func (r *expenseRepository) GetExpensesByUserID(userID int) ([]UserExpenseView, error) {
+// expenseUserIDs lists the creator and everyone with a split.
+func expenseUserIDs(expense *Expense, splits []ExpenseSplit) []int {
+	ids := make([]int, 0, len(splits)+1)
+	ids = append(ids, expense.CreatedBy)
+	for _, split := range splits {
+		ids = append(ids, split.UserID)
+	}
+	return ids
+}
+
+func (r *expenseRepository) DeleteExpense(id int, balanceUpdates []BalanceUpdate) error {
+	_, err := withRetry("delete expense", func() (struct{}, error) { return struct{}{}, r.deleteExpense(id, balanceUpdates) })
+	return err
+}
+
+func (r *expenseRepository) deleteExpense(id int, balanceUpdates []BalanceUpdate) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback on error, no-op on commit
+
+	// Lock the expense so a concurrent delete can't reverse its balances twice, nor a settlement
+	// confirmed meanwhile lock it
+	var lockedAt sql.NullTime
+	if err := tx.QueryRow("SELECT locked_at FROM expenses WHERE id = ? FOR UPDATE", id).Scan(&lockedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrExpenseNotFound
+		}
+		return fmt.Errorf("failed to lock expense %d: %w", id, err)
+	}
+	if lockedAt.Valid {
+		return fmt.Errorf("%w: expense %d", ErrExpenseLocked, id)
+	}
+	// While the splits still say who was in it
+	if err := touchExpenseParticipants(tx, id); err != nil {
+		return err
+	}
+
+	for _, query := range []string{
+		"DELETE FROM expense_locations WHERE expense_id = ?",
+		"DELETE FROM expense_share_claims WHERE expense_id = ?",
+		"DELETE FROM expense_splits WHERE expense_id = ?",
+		"DELETE FROM expenses WHERE id = ?",
+	} {
+		if _, err := tx.Exec(query, id); err != nil {
+			return fmt.Errorf("failed to delete expense %d: %w", id, err)
+		}
+	}
+	if err := r.appendEvent(tx, id, ExpenseDeletedEvent, struct{}{}); err != nil {
+		return err
+	}
+
+	if err := r.balanceRepo.UpdateBalances(tx, LedgerSource{Type: LedgerExpenseReversal, ID: id}, balanceUpdates); err != nil {
+		return fmt.Errorf("failed to update balances for deleted expense: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *expenseRepository) GetExpense(id int) (*Expense, error) {
+	query := "SELECT " + expenseColumns + " FROM " + expenseJoins + " WHERE e.id = ?"
+	e, err := scanExpense(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrExpenseNotFound
+		}
+		return nil, fmt.Errorf("failed to get expense: %w", err)
+	}
+	return e, nil
+}
+
+func (r *expenseRepository) GetExpenseByPublicID(publicID string) (*Expense, error) {
+	query := "SELECT " + expenseColumns + " FROM " + expenseJoins + " WHERE e.public_id = ?"
+	e, err := scanExpense(r.db.QueryRow(query, publicID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrExpenseNotFound
+		}
+		return nil, fmt.Errorf("failed to get expense: %w", err)
+	}
+	return e, nil
+}
+
+// expenseColumns selects an expense, aliased e, the expense it refunds, aliased r, its payee party,
+// aliased p, and its location, aliased l, for scanExpense. Use it with expenseJoins.
+const (
+	expenseColumns = "e.id, e.public_id, e.description, e.tag, e.total_amount, e.currency, e.original_currency, e.original_amount, e.exchange_rate, e.paid_adjustment, e.owed_adjustment, e.created_by, e.status, e.dispute_reason, e.refund_of, r.public_id, e.event_id, e.balance_strategy, e.created_at, e.locked_at, e.settled_by, p.id, p.name, p.created_at, l.latitude, l.longitude, l.place_name"
+	expenseJoins   = "expenses e LEFT JOIN expenses r ON r.id = e.refund_of LEFT JOIN parties p ON p.id = e.payee_party_id LEFT JOIN expense_locations l ON l.expense_id = e.id"
+)
+
+// columns returns the conversion as the nullable original_currency, original_amount and
+// exchange_rate columns.
+func (c *CurrencyConversion) columns() (sql.NullString, sql.NullFloat64, sql.NullFloat64) {
+	if c == nil {
+		return sql.NullString{}, sql.NullFloat64{}, sql.NullFloat64{}
+	}
+	return sql.NullString{String: c.OriginalCurrency, Valid: true}, sql.NullFloat64{Float64: c.OriginalAmount, Valid: true}, sql.NullFloat64{Float64: c.Rate, Valid: true}
+}
+
+// columns returns the adjustment as the nullable paid_adjustment and owed_adjustment columns.
+func (a *ExpenseAdjustment) columns() (sql.NullFloat64, sql.NullFloat64) {
+	if a == nil {
+		return sql.NullFloat64{}, sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: a.AmountPaid, Valid: true}, sql.NullFloat64{Float64: a.AmountOwed, Valid: true}
+}
+
+// scanExpense reads a row selected with expenseColumns.
+func scanExpense(row *sql.Row) (*Expense, error) {
+	e := &Expense{}
+	var (
+		refundOf         sql.NullInt64
+		refundOfPublicID sql.NullString
+		eventID          sql.NullInt64
+		originalCurrency sql.NullString
+		originalAmount   sql.NullFloat64
+		exchangeRate     sql.NullFloat64
+		paidAdjustment   sql.NullFloat64
+		owedAdjustment   sql.NullFloat64
+		lockedAt         sql.NullTime
+		settledBy        sql.NullInt64
+		partyID          sql.NullInt64
+		partyName        sql.NullString
+		partyCreatedAt   sql.NullTime
+		latitude         sql.NullFloat64
+		longitude        sql.NullFloat64
+		placeName        sql.NullString
+	)
+	if err := row.Scan(&e.ID, &e.PublicID, &e.Description, &e.Tag, &e.TotalAmount, &e.Currency, &originalCurrency, &originalAmount, &exchangeRate, &paidAdjustment, &owedAdjustment, &e.CreatedBy, &e.Status, &e.DisputeReason, &refundOf, &refundOfPublicID, &eventID, &e.BalanceStrategy, &e.CreatedAt, &lockedAt, &settledBy, &partyID, &partyName, &partyCreatedAt, &latitude, &longitude, &placeName); err != nil {
+		return nil, err
+	}
+	if refundOf.Valid {
+		id := int(refundOf.Int64)
+		e.RefundOf, e.RefundOfPublicID = &id, refundOfPublicID.String
+	}
+	if eventID.Valid {
+		id := int(eventID.Int64)
+		e.EventID = &id
+	}
+	if originalCurrency.Valid {
+		e.Conversion = &CurrencyConversion{OriginalCurrency: originalCurrency.String, OriginalAmount: originalAmount.Float64, Rate: exchangeRate.Float64}
+	}
+	if paidAdjustment.Valid {
+		e.Adjustment = &ExpenseAdjustment{AmountPaid: paidAdjustment.Float64, AmountOwed: owedAdjustment.Float64}
+	}
+	if lockedAt.Valid {
+		e.LockedAt = &lockedAt.Time
+	}
+	if settledBy.Valid {
+		id := int(settledBy.Int64)
+		e.SettledBy = &id
+	}
+	if partyID.Valid {
+		e.PayeeParty = &Party{ID: int(partyID.Int64), Name: partyName.String, CreatedAt: partyCreatedAt.Time}
+	}
+	if latitude.Valid {
+		e.Location = &Location{Latitude: latitude.Float64, Longitude: longitude.Float64, PlaceName: placeName.String}
+	}
+	return e, nil
+}
+
+func (r *expenseRepository) GetExpenseSplits(expenseID int) ([]ExpenseSplit, error) {
+	query := "SELECT id, expense_id, user_id, amount_paid, amount_owed FROM expense_splits WHERE expense_id = ? ORDER BY id"
+
+	rows, err := r.db.Query(query, expenseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query splits for expense %d: %w", expenseID, err)
+	}
+	defer rows.Close()
+
+	var splits []ExpenseSplit
+	for rows.Next() {
+		var s ExpenseSplit
+		if err := rows.Scan(&s.ID, &s.ExpenseID, &s.UserID, &s.AmountPaid, &s.AmountOwed); err != nil {
+			return nil, fmt.Errorf("failed to scan split row for expense %d: %w", expenseID, err)
+		}
+		splits = append(splits, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over split rows for expense %d: %w", expenseID, err)
+	}
+
+	return splits, nil
+}
+
+func (r *expenseRepository) GetSplitsForExpensesInvolving(userIDs []int) ([]ExpenseSplit, error) {
+	if len(userIDs) == 0 {
+		return []ExpenseSplit{}, nil
+	}
+
+	placeholders := make([]string, len(userIDs))
+	args := make([]interface{}, len(userIDs))
+	for i, id := range userIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, expense_id, user_id, amount_paid, amount_owed
+		FROM expense_splits
+		WHERE expense_id IN (SELECT expense_id FROM expense_splits WHERE user_id IN (%s))
+		ORDER BY expense_id, id
+	`, strings.Join(placeholders, ", "))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query splits for users: %w", err)
+	}
+	defer rows.Close()
+
+	var splits []ExpenseSplit
+	for rows.Next() {
+		var s ExpenseSplit
+		if err := rows.Scan(&s.ID, &s.ExpenseID, &s.UserID, &s.AmountPaid, &s.AmountOwed); err != nil {
+			return nil, fmt.Errorf("failed to scan split row: %w", err)
+		}
+		splits = append(splits, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over split rows: %w", err)
+	}
+
+	return splits, nil
+}
+
+func (r *expenseRepository) GetUserActivity(userID int, from, to time.Time) ([]ExpenseActivity, error) {
+	// One row per co-participant, or a single row with a NULL one for expenses the user had alone
+	query := `
+		SELECT e.id, e.description, e.tag, e.total_amount, e.currency, e.created_at, es.amount_paid, es.amount_owed, other.user_id
+		FROM expense_splits es
+		JOIN expenses e ON e.id = es.expense_id
+		LEFT JOIN expense_splits other ON other.expense_id = es.expense_id AND other.user_id <> es.user_id
+		WHERE es.user_id = ? AND e.created_at >= ? AND e.created_at < ?
+		ORDER BY e.created_at, e.id, other.user_id
+	`
+
+	rows, err := r.db.Query(query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query activity for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var activity []ExpenseActivity
+	for rows.Next() {
+		var a ExpenseActivity
+		var other sql.NullInt64
+		if err := rows.Scan(&a.ExpenseID, &a.Description, &a.Tag, &a.TotalAmount, &a.Currency, &a.CreatedAt, &a.AmountPaid, &a.AmountOwed, &other); err != nil {
+			return nil, fmt.Errorf("failed to scan activity row for user %d: %w", userID, err)
+		}
+		if n := len(activity); n == 0 || activity[n-1].ExpenseID != a.ExpenseID {
+			activity = append(activity, a)
+		}
+		if other.Valid {
+			last := &activity[len(activity)-1]
+			last.CoParticipants = append(last.CoParticipants, int(other.Int64))
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over activity rows for user %d: %w", userID, err)
+	}
+
+	return activity, nil
+}
+
+func (r *expenseRepository) GetDailySpend(userID int, from, to time.Time) ([]DailySpend, error) {
 	query := `
+		SELECT DATE(e.created_at) AS day, SUM(es.amount_owed), COUNT(*)
+		FROM expense_splits es
+		JOIN expenses e ON e.id = es.expense_id
+		WHERE es.user_id = ? AND e.created_at >= ? AND e.created_at < ?
+		GROUP BY day
+		ORDER BY day
+	`
+
+	rows, err := r.db.Query(query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily spend for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var days []DailySpend
+	for rows.Next() {
+		var d DailySpend
+		if err := rows.Scan(&d.Date, &d.Owed, &d.ExpenseCount); err != nil {
+			return nil, fmt.Errorf("failed to scan daily spend row for user %d: %w", userID, err)
+		}
+		days = append(days, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over daily spend rows for user %d: %w", userID, err)
+	}
+
+	return days, nil
+}
+
+func (r *expenseRepository) TransitionExpense(id int, from, to ExpenseStatus, reason string) (*Expense, error) {
+	return withRetry("transition expense", func() (*Expense, error) { return r.transitionExpense(id, from, to, reason) })
+}
+
+func (r *expenseRepository) transitionExpense(id int, from, to ExpenseStatus, reason string) (*Expense, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback on error, no-op on commit
+
+	query := "SELECT " + expenseColumns + " FROM " + expenseJoins + " WHERE e.id = ? FOR UPDATE OF e"
+	e, err := scanExpense(tx.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrExpenseNotFound
+		}
+		return nil, fmt.Errorf("failed to get expense: %w", err)
+	}
+
+	if e.Status != from {
+		return nil, fmt.Errorf("%w: cannot move from %s to %s", ErrInvalidExpenseTransition, e.Status, to)
+	}
+	if e.LockedAt != nil {
+		return nil, fmt.Errorf("%w: expense %d", ErrExpenseLocked, e.ID)
+	}
+
+	e.Status = to
+	e.DisputeReason = reason
+	if _, err := tx.Exec("UPDATE expenses SET status = ?, dispute_reason = ? WHERE id = ?", e.Status, e.DisputeReason, e.ID); err != nil {
+		return nil, fmt.Errorf("failed to update expense status: %w", err)
+	}
+	if err := r.appendEvent(tx, e.ID, ExpenseStatusChangedEvent, ExpenseStatusChange{Status: e.Status, DisputeReason: e.DisputeReason}); err != nil {
+		return nil, err
+	}
+	if err := touchExpenseParticipants(tx, e.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return e, nil
+}
+
+func (r *expenseRepository) UnlockExpense(id int) (*Expense, error) {
+	return withRetry("unlock expense", func() (*Expense, error) { return r.unlockExpense(id) })
+}
+
+func (r *expenseRepository) unlockExpense(id int) (*Expense, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback on error, no-op on commit
+
+	query := "SELECT " + expenseColumns + " FROM " + expenseJoins + " WHERE e.id = ? FOR UPDATE OF e"
+	e, err := scanExpense(tx.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrExpenseNotFound
+		}
+		return nil, fmt.Errorf("failed to get expense: %w", err)
+	}
+	if e.LockedAt == nil {
+		return nil, fmt.Errorf("%w: expense %d", ErrExpenseNotLocked, e.ID)
+	}
+
+	e.LockedAt = nil
+	if _, err := tx.Exec("UPDATE expenses SET locked_at = NULL WHERE id = ?", e.ID); err != nil {
+		return nil, fmt.Errorf("failed to unlock expense %d: %w", e.ID, err)
+	}
+	if err := touchExpenseParticipants(tx, e.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return e, nil
+}
+
+// lockCoveredExpenses locks, as part of tx, the active expenses between the pair of the leg that the
+// settlement's postings paid off, oldest first, recording the settlement that locked them unless an
+// earlier one had. Expenses the payment only partly covers stay open.
+func lockCoveredExpenses(tx *sql.Tx, leg BalanceUpdate, settlementID int, at time.Time) error {
+	query := "SELECT " + ledgerEntryColumns + " FROM ledger_entries WHERE user_id = ? AND counterparty_id = ? ORDER BY created_at, id"
+	rows, err := tx.Query(query, leg.User1ID, leg.User2ID)
+	if err != nil {
+		return fmt.Errorf("failed to query ledger entries between user %d and %d: %w", leg.User1ID, leg.User2ID, err)
+	}
+	entries, err := scanLedgerEntries(rows, leg.User1ID)
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	ids := CoveredExpenses(entries, LedgerSource{Type: LedgerSettlement, ID: settlementID})
+	if len(ids) == 0 {
+		return nil
+	}
+	args := []interface{}{at, settlementID, ExpenseActive}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	query = "UPDATE expenses SET locked_at = ?, settled_by = COALESCE(settled_by, ?) WHERE status = ? AND locked_at IS NULL AND id IN (" + placeholders + ")"
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to lock expenses paid by settlement %d: %w", settlementID, err)
+	}
+	return touchExpenseParticipants(tx, ids...)
+}
+
+// movesBalanceBetween matches an expense, aliased e, that moved the balance between two users,
+// given by movesBalanceBetweenArgs. A simple expense only moves the balance between its creator and
+// each other participant; the other strategies may move it between any two participants.
+const movesBalanceBetween = `(
+	(e.balance_strategy = 'simple' AND (
+		(e.created_by = ? AND EXISTS (SELECT 1 FROM expense_splits es WHERE es.expense_id = e.id AND es.user_id = ?))
+		OR (e.created_by = ? AND EXISTS (SELECT 1 FROM expense_splits es WHERE es.expense_id = e.id AND es.user_id = ?))
+	))
+	OR (e.balance_strategy <> 'simple'
+		AND EXISTS (SELECT 1 FROM expense_splits es WHERE es.expense_id = e.id AND es.user_id = ?)
+		AND EXISTS (SELECT 1 FROM expense_splits es WHERE es.expense_id = e.id AND es.user_id = ?))
+)`
+
+func movesBalanceBetweenArgs(user1ID, user2ID int) []interface{} {
+	return []interface{}{user1ID, user2ID, user2ID, user1ID, user1ID, user2ID}
+}
+
+func (r *expenseRepository) GetRefundedAmount(expenseID int) (float64, error) {
+	var refunded float64
+	err := r.db.QueryRow("SELECT COALESCE(-SUM(total_amount), 0) FROM expenses WHERE refund_of = ?", expenseID).Scan(&refunded)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get refunded amount for expense %d: %w", expenseID, err)
+	}
+	return refunded, nil
+}
+
+func (r *expenseRepository) HasDisputedExpenseBetween(user1ID, user2ID int) (bool, error) {
+	query := "SELECT COUNT(*) FROM expenses e WHERE e.status = ? AND " + movesBalanceBetween
+
+	var count int
+	err := r.db.QueryRow(query, append([]interface{}{ExpenseDisputed}, movesBalanceBetweenArgs(user1ID, user2ID)...)...).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to count disputed expenses between user %d and %d: %w", user1ID, user2ID, err)
+	}
+	return count > 0, nil
+}
+
+const userExpenseColumns = `
 		SELECT
+			e.id,
+			e.public_id,
 			e.created_at,
 			e.tag,
 			e.description,
 			e.total_amount,
+			e.currency,
 			es.amount_paid,
-			es.amount_owed
+			es.amount_owed,
+			e.status,
+			COALESCE(p.name, ''),
+			u.name,
+			u.email`
+
+const userExpensesFrom = `
 		FROM
 			expenses e
 		JOIN
 			expense_splits es ON e.id = es.expense_id
+		JOIN
+			users u ON u.id = e.created_by
+		LEFT JOIN
+			parties p ON p.id = e.payee_party_id
 		WHERE
-			es.user_id = ?
+			es.user_id = ?`
+
+const userExpensesOrder = `
 		ORDER BY
-			e.created_at DESC
-	`
+			e.created_at DESC, e.id DESC`
 
-	rows, err := r.db.Query(query, userID)
+func (r *expenseRepository) GetExpensesByUserID(userID int) ([]UserExpenseView, error) {
+	rows, err := r.db.Query(userExpenseColumns+userExpensesFrom+userExpensesOrder, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query expenses for user %d: %w", userID, err)
 	}
 	defer rows.Close()
+	return scanUserExpenses(rows, userID)
+}
+
+func (r *expenseRepository) GetExpensePageByUserID(userID, limit, offset int) (*UserExpensePage, error) {
+	page := &UserExpensePage{}
+	if err := r.db.QueryRow("SELECT COUNT(*)"+userExpensesFrom, userID).Scan(&page.Count); err != nil {
+		return nil, fmt.Errorf("failed to count expenses for user %d: %w", userID, err)
+	}
+
+	// MySQL has no OFFSET without a LIMIT, so the largest BIGINT UNSIGNED stands in for "the rest".
+	var older sql.NullFloat64
+	olderQuery := "SELECT SUM(share) FROM (SELECT IF(e.currency = ?, 0, es.amount_paid - es.amount_owed) AS share" + userExpensesFrom + userExpensesOrder +
+		" LIMIT 18446744073709551615 OFFSET ?) older"
+	if err := r.db.QueryRow(olderQuery, util.UnitCurrency, userID, offset+limit).Scan(&older); err != nil {
+		return nil, fmt.Errorf("failed to sum older expenses for user %d: %w", userID, err)
+	}
+	page.OlderShare = older.Float64
+
+	rows, err := r.db.Query(userExpenseColumns+userExpensesFrom+userExpensesOrder+" LIMIT ? OFFSET ?", userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expenses for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+	if page.Expenses, err = scanUserExpenses(rows, userID); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
 
+func scanUserExpenses(rows *sql.Rows, userID int) ([]UserExpenseView, error) {
 	var expenses []UserExpenseView
 	for rows.Next() {
 		var (
-			Date        time.Time
-			Tag         string
-			Description string
-			TotalAmount float64
-			AmountPaid  float64
-			AmountOwed  float64
+			ExpenseID      int
+			PublicID       string
+			Date           time.Time
+			Tag            string
+			Description    string
+			TotalAmount    float64
+			Currency       string
+			AmountPaid     float64
+			AmountOwed     float64
+			Status         ExpenseStatus
+			Payee          string
+			CreatedByName  string
+			CreatedByEmail string
 		)
 
-		if err := rows.Scan(&Date, &Tag, &Description, &TotalAmount, &AmountPaid, &AmountOwed); err != nil {
+		if err := rows.Scan(&ExpenseID, &PublicID, &Date, &Tag, &Description, &TotalAmount, &Currency, &AmountPaid, &AmountOwed, &Status, &Payee, &CreatedByName, &CreatedByEmail); err != nil {
 			return nil, fmt.Errorf("failed to scan expense row for user %d: %w", userID, err)
 		}
 
 		expenses = append(expenses, UserExpenseView{
-			Date:        Date,
-			Tag:         Tag,
-			Description: Description,
-			TotalAmount: TotalAmount,
-			Share:       AmountPaid - AmountOwed,
+			ExpenseID:       ExpenseID,
+			ExpensePublicID: PublicID,
+			Date:            Date,
+			Tag:             Tag,
+			Description:     Description,
+			TotalAmount:     TotalAmount,
+			Currency:        Currency,
+			Share:           AmountPaid - AmountOwed,
+			Status:          Status,
+			Payee:           Payee,
+			CreatedByName:   CreatedByName,
+			CreatedByEmail:  CreatedByEmail,
+			IsPayer:         AmountPaid != 0,
 		})
 	}
 
@@ -152,3 +912,39 @@ func (r *expenseRepository) GetExpensesByUserID(userID int) ([]UserExpenseView,
 
 	return expenses, nil
 }
+
+func (r *expenseRepository) ClaimShare(expenseID, userID int) (time.Time, error) {
+	if _, err := r.db.Exec("INSERT IGNORE INTO expense_share_claims (expense_id, user_id) VALUES (?, ?)", expenseID, userID); err != nil {
+		return time.Time{}, fmt.Errorf("failed to claim share of expense %d for user %d: %w", expenseID, userID, err)
+	}
+	var claimedAt time.Time
+	query := "SELECT claimed_at FROM expense_share_claims WHERE expense_id = ? AND user_id = ?"
+	if err := r.db.QueryRow(query, expenseID, userID).Scan(&claimedAt); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get claim on expense %d for user %d: %w", expenseID, userID, err)
+	}
+	return claimedAt, nil
+}
+
+func (r *expenseRepository) GetShareClaims(expenseID int) (map[int]time.Time, error) {
+	rows, err := r.db.Query("SELECT user_id, claimed_at FROM expense_share_claims WHERE expense_id = ?", expenseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query claims for expense %d: %w", expenseID, err)
+	}
+	defer rows.Close()
+
+	claims := make(map[int]time.Time)
+	for rows.Next() {
+		var userID int
+		var claimedAt time.Time
+		if err := rows.Scan(&userID, &claimedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan claim row for expense %d: %w", expenseID, err)
+		}
+		claims[userID] = claimedAt
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over claim rows for expense %d: %w", expenseID, err)
+	}
+
+	return claims, nil
+}
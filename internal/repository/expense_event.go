@@ -0,0 +1,333 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ExpenseEventType is a change to an expense recorded in the event store.
+type ExpenseEventType string
+
+const (
+	// ExpenseCreatedEvent carries the whole expense, with its splits and location.
+	ExpenseCreatedEvent ExpenseEventType = "created"
+	// ExpenseStatusChangedEvent carries the status and dispute reason the expense moved to.
+	ExpenseStatusChangedEvent ExpenseEventType = "status_changed"
+	ExpenseDeletedEvent       ExpenseEventType = "deleted"
+)
+
+// ExpenseEvent is one change to an expense, in the order it was made. Data is the JSON payload for
+// the event's type.
+type ExpenseEvent struct {
+	ID        int64            `json:"id"`
+	ExpenseID int              `json:"expense_id"`
+	Type      ExpenseEventType `json:"type"`
+	Data      json.RawMessage  `json:"data"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// ExpenseStatusChange is the payload of an ExpenseStatusChangedEvent.
+type ExpenseStatusChange struct {
+	Status        ExpenseStatus `json:"status"`
+	DisputeReason string        `json:"dispute_reason,omitempty"`
+}
+
+// ProjectionReport tells what rebuilding the expense projections from the event store changed.
+type ProjectionReport struct {
+	Events   int `json:"events"`
+	Expenses int `json:"expenses"` // Expenses with at least one event
+	Restored int `json:"restored"` // Recreated because the row was missing
+	Updated  int `json:"updated"`  // Status or dispute reason brought back in line
+	Deleted  int `json:"deleted"`  // Removed because their last event deleted them
+}
+
+// ExpenseEventRepository reads the expense event store, which an event-sourced expense repository
+// appends to in the same transaction as every change it makes.
+type ExpenseEventRepository interface {
+	// GetExpenseEvents returns every event of the expense, oldest first.
+	GetExpenseEvents(expenseID int) ([]ExpenseEvent, error)
+	// RebuildProjections replays every event and brings the expenses, expense_splits and
+	// expense_locations tables in line with the result. Expenses created before the store was
+	// enabled are only removed if an event deleted them, and balances are not touched: they follow
+	// the ledger.
+	RebuildProjections() (*ProjectionReport, error)
+}
+
+type expenseEventRepository struct {
+	db *sql.DB
+}
+
+func NewExpenseEventRepository(db *sql.DB) ExpenseEventRepository {
+	return &expenseEventRepository{db: db}
+}
+
+// appendExpenseEvent records an event for the expense as part of tx.
+func appendExpenseEvent(tx *sql.Tx, expenseID int, eventType ExpenseEventType, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event for expense %d: %w", eventType, expenseID, err)
+	}
+	query := "INSERT INTO expense_events (expense_id, type, data, created_at) VALUES (?, ?, ?, NOW(6))"
+	if _, err := tx.Exec(query, expenseID, eventType, payload); err != nil {
+		return fmt.Errorf("failed to append %s event for expense %d: %w", eventType, expenseID, err)
+	}
+	return nil
+}
+
+// ExpenseCreatedRecord is the payload of an ExpenseCreatedEvent. The API leaves the expense's and
+// its splits' database IDs out, but the store needs them to restore the rows, so they are put back
+// under the names events have always used.
+type ExpenseCreatedRecord struct {
+	Expense
+	ID       int                  `json:"id"`
+	PublicID string               `json:"public_id"`
+	RefundOf *int                 `json:"refund_of,omitempty"`
+	Splits   []expenseSplitRecord `json:"splits,omitempty"`
+}
+
+type expenseSplitRecord struct {
+	ExpenseSplit
+	ID        int `json:"id"`
+	ExpenseID int `json:"expense_id"`
+}
+
+// ExpenseCreatedData is the payload of an ExpenseCreatedEvent: the expense as it was created, with
+// only what is stored about it.
+func ExpenseCreatedData(expense *Expense, splits []ExpenseSplit) *ExpenseCreatedRecord {
+	record := &ExpenseCreatedRecord{
+		Expense: Expense{
+			Description:     expense.Description,
+			Tag:             expense.Tag,
+			TotalAmount:     expense.TotalAmount,
+			Currency:        expense.Currency,
+			CreatedBy:       expense.CreatedBy,
+			Status:          expense.Status,
+			PayeeParty:      expense.PayeeParty,
+			Location:        expense.Location,
+			EventID:         expense.EventID,
+			BalanceStrategy: expense.BalanceStrategy,
+			Conversion:      expense.Conversion,
+			Adjustment:      expense.Adjustment,
+			CreatedAt:       expense.CreatedAt,
+		},
+		ID:       expense.ID,
+		PublicID: expense.PublicID,
+		RefundOf: expense.RefundOf,
+	}
+	for _, split := range splits {
+		record.Splits = append(record.Splits, expenseSplitRecord{ExpenseSplit: split, ID: split.ID, ExpenseID: split.ExpenseID})
+	}
+	return record
+}
+
+// expense returns the expense the record was made from.
+func (record *ExpenseCreatedRecord) expense() *Expense {
+	expense := record.Expense
+	expense.ID, expense.PublicID, expense.RefundOf, expense.Splits = record.ID, record.PublicID, record.RefundOf, nil
+	for _, split := range record.Splits {
+		split.ExpenseSplit.ID, split.ExpenseSplit.ExpenseID = split.ID, split.ExpenseID
+		expense.Splits = append(expense.Splits, split.ExpenseSplit)
+	}
+	return &expense
+}
+
+// ReplayExpense folds an expense's events, oldest first, into the expense they leave behind, with
+// its splits. It returns a nil expense for one the events end up deleting, with deleted set, and for
+// one created before the store was enabled, whose events can't rebuild it.
+func ReplayExpense(events []ExpenseEvent) (expense *Expense, deleted bool, err error) {
+	for _, event := range events {
+		switch event.Type {
+		case ExpenseCreatedEvent:
+			var record ExpenseCreatedRecord
+			if err := json.Unmarshal(event.Data, &record); err != nil {
+				return nil, false, fmt.Errorf("failed to decode event %d: %w", event.ID, err)
+			}
+			expense, deleted = record.expense(), false
+			expense.ID = event.ExpenseID
+		case ExpenseStatusChangedEvent:
+			if expense == nil {
+				// Created before the store was enabled; there is nothing to apply the change to
+				continue
+			}
+			var change ExpenseStatusChange
+			if err := json.Unmarshal(event.Data, &change); err != nil {
+				return nil, false, fmt.Errorf("failed to decode event %d: %w", event.ID, err)
+			}
+			expense.Status, expense.DisputeReason = change.Status, change.DisputeReason
+		case ExpenseDeletedEvent:
+			expense, deleted = nil, true
+		default:
+			return nil, false, fmt.Errorf("unknown event type %q in event %d", event.Type, event.ID)
+		}
+	}
+	return expense, deleted, nil
+}
+
+func (r *expenseEventRepository) GetExpenseEvents(expenseID int) ([]ExpenseEvent, error) {
+	rows, err := r.db.Query("SELECT id, expense_id, type, data, created_at FROM expense_events WHERE expense_id = ? ORDER BY id", expenseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events for expense %d: %w", expenseID, err)
+	}
+	defer rows.Close()
+	return scanExpenseEvents(rows)
+}
+
+func scanExpenseEvents(rows *sql.Rows) ([]ExpenseEvent, error) {
+	var events []ExpenseEvent
+	for rows.Next() {
+		var e ExpenseEvent
+		if err := rows.Scan(&e.ID, &e.ExpenseID, &e.Type, &e.Data, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan expense event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over expense events: %w", err)
+	}
+	return events, nil
+}
+
+func (r *expenseEventRepository) RebuildProjections() (*ProjectionReport, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback on error, no-op on commit
+
+	// Sharing every event and the gap after the last keeps new events, and so the writes that
+	// append them, waiting until the rebuild is done
+	rows, err := tx.Query("SELECT id, expense_id, type, data, created_at FROM expense_events ORDER BY id FOR SHARE")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expense events: %w", err)
+	}
+	events, err := scanExpenseEvents(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	byExpense := make(map[int][]ExpenseEvent)
+	var ids []int
+	for _, e := range events {
+		if _, ok := byExpense[e.ExpenseID]; !ok {
+			ids = append(ids, e.ExpenseID)
+		}
+		byExpense[e.ExpenseID] = append(byExpense[e.ExpenseID], e)
+	}
+	report := &ProjectionReport{Events: len(events), Expenses: len(ids)}
+	if len(ids) == 0 {
+		return report, tx.Commit()
+	}
+	// Refunds come after what they refund, so they are restored after it and deleted before it
+	sort.Ints(ids)
+
+	stored, err := storedExpenseStatuses(tx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []int
+	for _, id := range ids {
+		expense, isDeleted, err := ReplayExpense(byExpense[id])
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay expense %d: %w", id, err)
+		}
+		current, exists := stored[id]
+		switch {
+		case isDeleted && exists:
+			deleted = append(deleted, id)
+		case expense == nil:
+		case !exists:
+			if err := restoreExpense(tx, expense); err != nil {
+				return nil, err
+			}
+			report.Restored++
+		case current != ExpenseStatusChange{Status: expense.Status, DisputeReason: expense.DisputeReason}:
+			if _, err := tx.Exec("UPDATE expenses SET status = ?, dispute_reason = ? WHERE id = ?", expense.Status, expense.DisputeReason, id); err != nil {
+				return nil, fmt.Errorf("failed to update expense %d: %w", id, err)
+			}
+			report.Updated++
+		}
+	}
+	for i := len(deleted) - 1; i >= 0; i-- {
+		for _, query := range []string{
+			"DELETE FROM expense_locations WHERE expense_id = ?",
+			"DELETE FROM expense_share_claims WHERE expense_id = ?",
+			"DELETE FROM expense_splits WHERE expense_id = ?",
+			"DELETE FROM expenses WHERE id = ?",
+		} {
+			if _, err := tx.Exec(query, deleted[i]); err != nil {
+				return nil, fmt.Errorf("failed to delete expense %d: %w", deleted[i], err)
+			}
+		}
+		report.Deleted++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return report, nil
+}
+
+// storedExpenseStatuses returns the status of each of the expenses that has a row.
+func storedExpenseStatuses(tx *sql.Tx, ids []int) (map[int]ExpenseStatusChange, error) {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	rows, err := tx.Query("SELECT id, status, dispute_reason FROM expenses WHERE id IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stored expenses: %w", err)
+	}
+	defer rows.Close()
+
+	stored := make(map[int]ExpenseStatusChange, len(ids))
+	for rows.Next() {
+		var (
+			id     int
+			status ExpenseStatusChange
+		)
+		if err := rows.Scan(&id, &status.Status, &status.DisputeReason); err != nil {
+			return nil, fmt.Errorf("failed to scan stored expense: %w", err)
+		}
+		stored[id] = status
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over stored expenses: %w", err)
+	}
+	return stored, nil
+}
+
+// restoreExpense inserts a replayed expense with its original IDs.
+func restoreExpense(tx *sql.Tx, expense *Expense) error {
+	var payeePartyID *int
+	if expense.PayeeParty != nil {
+		payeePartyID = &expense.PayeeParty.ID
+	}
+	// Events recorded before expenses had a balance strategy carry none, and those all used simple.
+	// Nor do those recorded before public IDs carry one, so they are given a new one.
+	query := "INSERT INTO expenses (id, public_id, description, tag, total_amount, currency, original_currency, original_amount, exchange_rate, paid_adjustment, owed_adjustment, created_by, status, dispute_reason, refund_of, payee_party_id, event_id, balance_strategy, created_at) VALUES (?, COALESCE(NULLIF(?, ''), " + randomUUIDSQL + "), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, COALESCE(NULLIF(?, ''), 'simple'), ?)"
+	originalCurrency, originalAmount, rate := expense.Conversion.columns()
+	paidAdjustment, owedAdjustment := expense.Adjustment.columns()
+	if _, err := tx.Exec(query, expense.ID, expense.PublicID, expense.Description, expense.Tag, expense.TotalAmount, expense.Currency, originalCurrency, originalAmount, rate, paidAdjustment, owedAdjustment, expense.CreatedBy, expense.Status, expense.DisputeReason, expense.RefundOf, payeePartyID, expense.EventID, expense.BalanceStrategy, expense.CreatedAt); err != nil {
+		return fmt.Errorf("failed to restore expense %d: %w", expense.ID, err)
+	}
+	for _, split := range expense.Splits {
+		query := "INSERT INTO expense_splits (id, expense_id, user_id, amount_paid, amount_owed) VALUES (?, ?, ?, ?, ?)"
+		if _, err := tx.Exec(query, split.ID, expense.ID, split.UserID, split.AmountPaid, split.AmountOwed); err != nil {
+			return fmt.Errorf("failed to restore split of expense %d: %w", expense.ID, err)
+		}
+	}
+	if l := expense.Location; l != nil {
+		query := "INSERT INTO expense_locations (expense_id, latitude, longitude, place_name, location) VALUES (?, ?, ?, ?, ST_GeomFromText(?, 4326))"
+		if _, err := tx.Exec(query, expense.ID, l.Latitude, l.Longitude, l.PlaceName, pointWKT(l.Latitude, l.Longitude)); err != nil {
+			return fmt.Errorf("failed to restore location of expense %d: %w", expense.ID, err)
+		}
+	}
+	return nil
+}
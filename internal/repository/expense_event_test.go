@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayExpense(t *testing.T) {
+	event := func(id int64, eventType ExpenseEventType, data any) ExpenseEvent {
+		payload, err := json.Marshal(data)
+		require.NoError(t, err)
+		return ExpenseEvent{ID: id, ExpenseID: 7, Type: eventType, Data: payload}
+	}
+	createdAt := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	splits := []ExpenseSplit{{ID: 1, ExpenseID: 7, UserID: 1, AmountPaid: 30, AmountOwed: 15}, {ID: 2, ExpenseID: 7, UserID: 2, AmountOwed: 15}}
+	created := event(1, ExpenseCreatedEvent, ExpenseCreatedData(&Expense{
+		ID: 7, Description: "Taxi", Tag: "Travel", TotalAmount: 30, Currency: "INR", CreatedBy: 1, Status: ExpenseActive,
+		Location: &Location{Latitude: 12.97, Longitude: 77.59}, CreatedAt: createdAt,
+		BudgetWarnings: []BudgetWarning{{Tag: "Travel"}}, // Creation-only fields are not recorded
+	}, splits))
+	disputed := event(2, ExpenseStatusChangedEvent, ExpenseStatusChange{Status: ExpenseDisputed, DisputeReason: "Wrong amount"})
+
+	// Test case 1: Creation and a dispute leave the disputed expense with its splits
+	expense, deleted, err := ReplayExpense([]ExpenseEvent{created, disputed})
+	require.NoError(t, err)
+	assert.False(t, deleted)
+	assert.Equal(t, &Expense{
+		ID: 7, Description: "Taxi", Tag: "Travel", TotalAmount: 30, Currency: "INR", CreatedBy: 1,
+		Status: ExpenseDisputed, DisputeReason: "Wrong amount",
+		Location: &Location{Latitude: 12.97, Longitude: 77.59}, CreatedAt: createdAt, Splits: splits,
+	}, expense)
+
+	// Test case 2: The payload keeps the IDs the API hides, under the names older events use
+	var payload struct {
+		ID     int `json:"id"`
+		Splits []struct {
+			ID        int `json:"id"`
+			ExpenseID int `json:"expense_id"`
+		} `json:"splits"`
+	}
+	require.NoError(t, json.Unmarshal(created.Data, &payload))
+	assert.Equal(t, 7, payload.ID)
+	assert.Equal(t, 2, payload.Splits[1].ID)
+	assert.Equal(t, 7, payload.Splits[1].ExpenseID)
+
+	// Test case 3: A deletion leaves nothing
+	expense, deleted, err = ReplayExpense([]ExpenseEvent{created, disputed, event(3, ExpenseDeletedEvent, struct{}{})})
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	assert.Nil(t, expense)
+
+	// Test case 4: An expense created before the store can't be rebuilt from a later change
+	expense, deleted, err = ReplayExpense([]ExpenseEvent{disputed})
+	require.NoError(t, err)
+	assert.False(t, deleted)
+	assert.Nil(t, expense)
+
+	// Test case 5: Unknown events are refused rather than skipped
+	_, _, err = ReplayExpense([]ExpenseEvent{created, event(4, "settled", struct{}{})})
+	assert.ErrorContains(t, err, `unknown event type "settled"`)
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	mathrand "math/rand"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// DBFaults are the shares of statements, between 0 and 1, FaultConnector fails.
+type DBFaults struct {
+	DeadlockRate float64
+	TimeoutRate  float64
+}
+
+// FaultConnector wraps a database connector so that statements fail at random, without running,
+// with the errors MySQL gives for a deadlock or a timeout. It exercises withRetry, IsQueryTimeout
+// and the callers above them, and is for development only.
+func FaultConnector(connector driver.Connector, faults DBFaults) driver.Connector {
+	return &faultConnector{Connector: connector, fault: faultInjector(faults, mathrand.Float64)}
+}
+
+// faultInjector returns a function giving the error, if any, to fail query with.
+func faultInjector(faults DBFaults, random func() float64) func(query string) error {
+	return func(query string) error {
+		n := random()
+		switch {
+		case n < faults.DeadlockRate:
+			return &mysql.MySQLError{Number: mysqlDeadlock, Message: "Deadlock found when trying to get lock; try restarting transaction (injected)"}
+		case n < faults.DeadlockRate+faults.TimeoutRate:
+			// Only a SELECT runs into max_execution_time; writes time out waiting for a lock
+			if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query)), "SELECT") {
+				return &mysql.MySQLError{Number: mysqlQueryTimeout, Message: "Query execution was interrupted, maximum statement execution time exceeded (injected)"}
+			}
+			return &mysql.MySQLError{Number: mysqlLockWaitTimeout, Message: "Lock wait timeout exceeded; try restarting transaction (injected)"}
+		}
+		return nil
+	}
+}
+
+type faultConnector struct {
+	driver.Connector
+	fault func(query string) error
+}
+
+func (c *faultConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &faultConn{Conn: conn, fault: c.fault}, nil
+}
+
+// faultConn may fail a statement when it is run directly on the connection or prepared, passing
+// every optional driver interface through to the wrapped connection.
+type faultConn struct {
+	driver.Conn
+	fault func(query string) error
+	// passedOn is set when the driver turned a statement that got past fault back to database/sql
+	// to prepare, so preparing it doesn't give it a second chance to fail.
+	passedOn bool
+}
+
+func (c *faultConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	if err := c.fault(query); err != nil {
+		return nil, err
+	}
+	rows, err := queryer.QueryContext(ctx, query, args)
+	c.passedOn = err == driver.ErrSkip
+	return rows, err
+}
+
+func (c *faultConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	if err := c.fault(query); err != nil {
+		return nil, err
+	}
+	result, err := execer.ExecContext(ctx, query, args)
+	c.passedOn = err == driver.ErrSkip
+	return result, err
+}
+
+func (c *faultConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if c.passedOn {
+		c.passedOn = false
+	} else if err := c.fault(query); err != nil {
+		return nil, err
+	}
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *faultConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *faultConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *faultConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *faultConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *faultConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
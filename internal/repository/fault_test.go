@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaultConnector(t *testing.T) {
+	draws := []float64{0.1, 0.6, 0.9}
+	random := func() float64 {
+		n := draws[0]
+		draws = draws[1:]
+		return n
+	}
+	db := sql.OpenDB(&faultConnector{Connector: fakeConnector{}, fault: faultInjector(DBFaults{DeadlockRate: 0.5, TimeoutRate: 0.25}, random)})
+	defer db.Close()
+
+	// Test case 1: An injected deadlock is one withRetry reruns
+	_, err := db.Exec("UPDATE balances SET balance = ? WHERE id = ?", 10, 1)
+	assert.True(t, isTransient(err))
+
+	// Test case 2: An injected timeout on a write is a lock wait timeout
+	_, err = db.Exec("UPDATE balances SET balance = ? WHERE id = ?", 10, 1)
+	assert.True(t, IsQueryTimeout(err))
+
+	// Test case 3: Past both rates the statement runs
+	_, err = db.Exec("UPDATE balances SET balance = ? WHERE id = ?", 10, 1)
+	require.NoError(t, err)
+
+	// Test case 4: A SELECT times out on max_execution_time
+	err = faultInjector(DBFaults{TimeoutRate: 1}, func() float64 { return 0 })(" select * from users")
+	var mysqlErr *mysql.MySQLError
+	require.True(t, errors.As(err, &mysqlErr))
+	assert.Equal(t, uint16(mysqlQueryTimeout), mysqlErr.Number)
+}
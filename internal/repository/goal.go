@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Goal is a user's aim to bring their overall balance to TargetBalance by Deadline.
+type Goal struct {
+	ID              int       `json:"id"`
+	UserID          int       `json:"user_id"`
+	TargetBalance   float64   `json:"target_balance"`
+	StartingBalance float64   `json:"starting_balance"` // Overall balance when the goal was set
+	Deadline        time.Time `json:"deadline"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type GoalRepository interface {
+	CreateGoal(goal *Goal) (*Goal, error)
+	// GetGoalsByUserID returns the user's goals, nearest deadline first.
+	GetGoalsByUserID(userID int) ([]Goal, error)
+}
+
+type goalRepository struct {
+	db *sql.DB
+}
+
+func NewGoalRepository(db *sql.DB) GoalRepository {
+	return &goalRepository{db: db}
+}
+
+func (r *goalRepository) CreateGoal(goal *Goal) (*Goal, error) {
+	query := "INSERT INTO goals (user_id, target_balance, starting_balance, deadline, created_at) VALUES (?, ?, ?, ?, ?)"
+	goal.CreatedAt = time.Now()
+	result, err := r.db.Exec(query, goal.UserID, goal.TargetBalance, goal.StartingBalance, goal.Deadline, goal.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create goal: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID for goal: %w", err)
+	}
+	goal.ID = int(id)
+
+	return goal, nil
+}
+
+func (r *goalRepository) GetGoalsByUserID(userID int) ([]Goal, error) {
+	query := `
+		SELECT id, user_id, target_balance, starting_balance, deadline, created_at
+		FROM goals
+		WHERE user_id = ?
+		ORDER BY deadline, id
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query goals for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var goals []Goal
+	for rows.Next() {
+		var g Goal
+		if err := rows.Scan(&g.ID, &g.UserID, &g.TargetBalance, &g.StartingBalance, &g.Deadline, &g.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan goal row for user %d: %w", userID, err)
+		}
+		goals = append(goals, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over goal rows for user %d: %w", userID, err)
+	}
+
+	return goals, nil
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ImportStatus is where a chunked import is in its lifecycle.
+type ImportStatus string
+
+const (
+	ImportOpen      ImportStatus = "open"      // Taking chunks
+	ImportCommitted ImportStatus = "committed" // Queued or being imported
+	ImportDone      ImportStatus = "done"
+	ImportFailed    ImportStatus = "failed" // Stopped at a row it couldn't import
+)
+
+var ErrImportSessionNotFound = errors.New("import session not found")
+
+// ImportSession is a CSV import uploaded in chunks.
+type ImportSession struct {
+	ID        int          `json:"id"`
+	CreatedBy int          `json:"created_by"`
+	Status    ImportStatus `json:"status"`
+	// ChunkCount is how many chunks the file was sent in, known once the session is committed.
+	ChunkCount int `json:"chunk_count"`
+	// ReceivedChunks lists the chunks uploaded so far, so a client can resend just the missing ones.
+	ReceivedChunks []int     `json:"received_chunks"`
+	RowsImported   int       `json:"rows_imported"`
+	JobID          *int64    `json:"job_id,omitempty"`
+	LastError      string    `json:"last_error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type ImportRepository interface {
+	CreateImportSession(session *ImportSession) (*ImportSession, error)
+	GetImportSession(id int) (*ImportSession, error)
+	// PutImportChunk stores chunk seq of the session, replacing it if it was sent before.
+	PutImportChunk(sessionID, seq int, data []byte) error
+	// GetImportData returns the session's chunks 0 to count-1 joined together.
+	GetImportData(sessionID, count int) ([]byte, error)
+	// CommitImportSession moves an open or failed session to committed with chunkCount chunks. It
+	// reports false, changing nothing, if the session was in any other status.
+	CommitImportSession(id, chunkCount int) (bool, error)
+	// SetImportJob records the job importing the session.
+	SetImportJob(id int, jobID int64) error
+	SetImportProgress(id, rowsImported int) error
+	// FinishImportSession moves the session to done or failed, with lastError saying why it failed.
+	FinishImportSession(id int, status ImportStatus, lastError string) error
+}
+
+type importRepository struct {
+	db *sql.DB
+}
+
+func NewImportRepository(db *sql.DB) ImportRepository {
+	return &importRepository{db: db}
+}
+
+func (r *importRepository) CreateImportSession(session *ImportSession) (*ImportSession, error) {
+	query := "INSERT INTO import_sessions (created_by, status, created_at, updated_at) VALUES (?, ?, ?, ?)"
+	session.Status = ImportOpen
+	session.CreatedAt = time.Now()
+	session.UpdatedAt = session.CreatedAt
+	result, err := r.db.Exec(query, session.CreatedBy, session.Status, session.CreatedAt, session.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create import session: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID for import session: %w", err)
+	}
+	session.ID = int(id)
+	session.ReceivedChunks = []int{}
+
+	return session, nil
+}
+
+func (r *importRepository) GetImportSession(id int) (*ImportSession, error) {
+	query := `
+		SELECT id, created_by, status, chunk_count, rows_imported, job_id, COALESCE(last_error, ''), created_at, updated_at
+		FROM import_sessions
+		WHERE id = ?
+	`
+	s := &ImportSession{}
+	var jobID sql.NullInt64
+	err := r.db.QueryRow(query, id).Scan(&s.ID, &s.CreatedBy, &s.Status, &s.ChunkCount, &s.RowsImported, &jobID, &s.LastError, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrImportSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get import session: %w", err)
+	}
+	if jobID.Valid {
+		s.JobID = &jobID.Int64
+	}
+
+	rows, err := r.db.Query("SELECT seq FROM import_chunks WHERE session_id = ? ORDER BY seq", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chunks of import session %d: %w", id, err)
+	}
+	defer rows.Close()
+
+	s.ReceivedChunks = []int{}
+	for rows.Next() {
+		var seq int
+		if err := rows.Scan(&seq); err != nil {
+			return nil, fmt.Errorf("failed to scan import chunk: %w", err)
+		}
+		s.ReceivedChunks = append(s.ReceivedChunks, seq)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over import chunks: %w", err)
+	}
+
+	return s, nil
+}
+
+func (r *importRepository) PutImportChunk(sessionID, seq int, data []byte) error {
+	query := "INSERT INTO import_chunks (session_id, seq, data) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE data = VALUES(data)"
+	if _, err := r.db.Exec(query, sessionID, seq, data); err != nil {
+		return fmt.Errorf("failed to store chunk %d of import session %d: %w", seq, sessionID, err)
+	}
+	return nil
+}
+
+func (r *importRepository) GetImportData(sessionID, count int) ([]byte, error) {
+	rows, err := r.db.Query("SELECT data FROM import_chunks WHERE session_id = ? AND seq < ? ORDER BY seq", sessionID, count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chunks of import session %d: %w", sessionID, err)
+	}
+	defer rows.Close()
+
+	var data bytes.Buffer
+	for rows.Next() {
+		var chunk []byte
+		if err := rows.Scan(&chunk); err != nil {
+			return nil, fmt.Errorf("failed to scan import chunk: %w", err)
+		}
+		data.Write(chunk)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over import chunks: %w", err)
+	}
+
+	return data.Bytes(), nil
+}
+
+func (r *importRepository) CommitImportSession(id, chunkCount int) (bool, error) {
+	query := "UPDATE import_sessions SET status = ?, chunk_count = ?, last_error = NULL, updated_at = ? WHERE id = ? AND status IN (?, ?)"
+	result, err := r.db.Exec(query, ImportCommitted, chunkCount, time.Now(), id, ImportOpen, ImportFailed)
+	if err != nil {
+		return false, fmt.Errorf("failed to commit import session %d: %w", id, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected committing import session %d: %w", id, err)
+	}
+	return n > 0, nil
+}
+
+func (r *importRepository) SetImportJob(id int, jobID int64) error {
+	if _, err := r.db.Exec("UPDATE import_sessions SET job_id = ?, updated_at = ? WHERE id = ?", jobID, time.Now(), id); err != nil {
+		return fmt.Errorf("failed to record job of import session %d: %w", id, err)
+	}
+	return nil
+}
+
+func (r *importRepository) SetImportProgress(id, rowsImported int) error {
+	if _, err := r.db.Exec("UPDATE import_sessions SET rows_imported = ?, updated_at = ? WHERE id = ?", rowsImported, time.Now(), id); err != nil {
+		return fmt.Errorf("failed to record progress of import session %d: %w", id, err)
+	}
+	return nil
+}
+
+func (r *importRepository) FinishImportSession(id int, status ImportStatus, lastError string) error {
+	query := "UPDATE import_sessions SET status = ?, last_error = NULLIF(?, ''), updated_at = ? WHERE id = ?"
+	if _, err := r.db.Exec(query, status, lastError, time.Now(), id); err != nil {
+		return fmt.Errorf("failed to finish import session %d: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// JobStatus is the lifecycle state of a background job.
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobDead      JobStatus = "dead"
+)
+
+var ErrJobNotFound = errors.New("job not found")
+
+type Job struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      JobStatus       `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	Progress    *JobProgress    `json:"progress,omitempty"` // Set by jobs that report how far they have got
+	RunAt       time.Time       `json:"run_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// JobProgress is how many of a job's items it has been through.
+type JobProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+type JobRepository interface {
+	CreateJob(job *Job) (*Job, error)
+	GetJob(id int64) (*Job, error)
+	// ClaimNextJob marks the oldest due job as running, leased until lease is over, and returns it.
+	// Running jobs whose lease has run out are claimed again. It returns nil when nothing is due.
+	ClaimNextJob(now time.Time, lease time.Duration) (*Job, error)
+	CompleteJob(id int64) error
+	// FailJob records a failed run. The job is queued again at retryAt, or moved to dead if retryAt is zero.
+	FailJob(id int64, errMsg string, retryAt time.Time) error
+	// SetJobProgress records how far a running job has got.
+	SetJobProgress(id int64, done, total int) error
+	// CountJobsByStatus returns how many jobs are in each status. Statuses without jobs are left out.
+	CountJobsByStatus() (map[JobStatus]int, error)
+}
+
+type jobRepository struct {
+	db *sql.DB
+}
+
+func NewJobRepository(db *sql.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+const jobColumns = "id, type, payload, status, attempts, max_attempts, COALESCE(last_error, ''), progress_done, progress_total, run_at, created_at, updated_at"
+
+func scanJob(row *sql.Row) (*Job, error) {
+	j := &Job{}
+	var payload []byte
+	var done, total sql.NullInt64
+	if err := row.Scan(&j.ID, &j.Type, &payload, &j.Status, &j.Attempts, &j.MaxAttempts, &j.LastError, &done, &total, &j.RunAt, &j.CreatedAt, &j.UpdatedAt); err != nil {
+		return nil, err
+	}
+	j.Payload = payload
+	if done.Valid {
+		j.Progress = &JobProgress{Done: int(done.Int64), Total: int(total.Int64)}
+	}
+	return j, nil
+}
+
+func (r *jobRepository) CreateJob(job *Job) (*Job, error) {
+	query := "INSERT INTO jobs (type, payload, status, attempts, max_attempts, run_at, created_at, updated_at) VALUES (?, ?, ?, 0, ?, ?, ?, ?)"
+	job.Status = JobQueued
+	job.CreatedAt = time.Now()
+	job.UpdatedAt = job.CreatedAt
+	if job.RunAt.IsZero() {
+		job.RunAt = job.CreatedAt
+	}
+	result, err := r.db.Exec(query, job.Type, []byte(job.Payload), job.Status, job.MaxAttempts, job.RunAt, job.CreatedAt, job.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID for job: %w", err)
+	}
+	job.ID = id
+
+	return job, nil
+}
+
+func (r *jobRepository) GetJob(id int64) (*Job, error) {
+	job, err := scanJob(r.db.QueryRow("SELECT "+jobColumns+" FROM jobs WHERE id = ?", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return job, nil
+}
+
+func (r *jobRepository) ClaimNextJob(now time.Time, lease time.Duration) (*Job, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback on error, no-op on commit
+
+	// SKIP LOCKED lets several runners poll the table without handing out the same job twice
+	query := "SELECT " + jobColumns + " FROM jobs WHERE status IN (?, ?) AND run_at <= ? ORDER BY run_at, id LIMIT 1 FOR UPDATE SKIP LOCKED"
+	job, err := scanJob(tx.QueryRow(query, JobQueued, JobRunning, now))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	job.Status = JobRunning
+	job.Attempts++
+	job.RunAt = now.Add(lease)
+	job.UpdatedAt = now
+	if _, err := tx.Exec("UPDATE jobs SET status = ?, attempts = ?, run_at = ?, updated_at = ? WHERE id = ?", job.Status, job.Attempts, job.RunAt, job.UpdatedAt, job.ID); err != nil {
+		return nil, fmt.Errorf("failed to mark job %d running: %w", job.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return job, nil
+}
+
+func (r *jobRepository) CompleteJob(id int64) error {
+	if _, err := r.db.Exec("UPDATE jobs SET status = ?, last_error = NULL, updated_at = ? WHERE id = ?", JobSucceeded, time.Now(), id); err != nil {
+		return fmt.Errorf("failed to complete job %d: %w", id, err)
+	}
+	return nil
+}
+
+func (r *jobRepository) FailJob(id int64, errMsg string, retryAt time.Time) error {
+	var err error
+	if retryAt.IsZero() {
+		_, err = r.db.Exec("UPDATE jobs SET status = ?, last_error = ?, updated_at = ? WHERE id = ?", JobDead, errMsg, time.Now(), id)
+	} else {
+		_, err = r.db.Exec("UPDATE jobs SET status = ?, last_error = ?, run_at = ?, updated_at = ? WHERE id = ?", JobQueued, errMsg, retryAt, time.Now(), id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record failure of job %d: %w", id, err)
+	}
+	return nil
+}
+
+func (r *jobRepository) SetJobProgress(id int64, done, total int) error {
+	if _, err := r.db.Exec("UPDATE jobs SET progress_done = ?, progress_total = ?, updated_at = ? WHERE id = ?", done, total, time.Now(), id); err != nil {
+		return fmt.Errorf("failed to record progress of job %d: %w", id, err)
+	}
+	return nil
+}
+
+func (r *jobRepository) CountJobsByStatus() (map[JobStatus]int, error) {
+	rows, err := r.db.Query("SELECT status, COUNT(*) FROM jobs GROUP BY status")
+	if err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[JobStatus]int)
+	for rows.Next() {
+		var status JobStatus
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan job count: %w", err)
+		}
+		counts[status] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over job counts: %w", err)
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LedgerSourceType is what kind of record a ledger posting comes from.
+type LedgerSourceType string
+
+const (
+	// LedgerOpening carries over a balance that stood before the ledger was kept, dated when that
+	// balance last changed.
+	LedgerOpening         LedgerSourceType = "opening"
+	LedgerExpense         LedgerSourceType = "expense"
+	LedgerExpenseReversal LedgerSourceType = "expense_reversal"
+	LedgerLoan            LedgerSourceType = "loan"
+	LedgerSettlement      LedgerSourceType = "settlement"
+)
+
+// LedgerSource is the record behind a posting. Opening postings have no ID.
+type LedgerSource struct {
+	Type LedgerSourceType `json:"type"`
+	ID   int              `json:"id,omitempty"`
+}
+
+// LedgerEntry is one side of a posting. Amount is what CounterpartyID owes UserID because of the
+// source, negative when UserID owes. Every posting is a pair of entries that cancel out.
+type LedgerEntry struct {
+	ID             int64        `json:"id"`
+	Source         LedgerSource `json:"source"`
+	UserID         int          `json:"user_id"`
+	CounterpartyID int          `json:"counterparty_id"`
+	Amount         float64      `json:"amount"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+// BalanceDrift is a pair whose materialized balance no longer matches the sum of its ledger entries.
+type BalanceDrift struct {
+	User1ID      int     `json:"user1_id"`
+	User2ID      int     `json:"user2_id"`
+	Materialized float64 `json:"materialized"`
+	Ledger       float64 `json:"ledger"`
+}
+
+// LedgerRepository reads the ledger, the source of truth the balances table is derived from.
+type LedgerRepository interface {
+	// GetEntries returns the user's side of every posting made up to and including at, oldest first.
+	GetEntries(userID int, at time.Time) ([]LedgerEntry, error)
+	// GetUnsettledEntries returns the user's side of every posting with the counterparties they have
+	// an outstanding balance with, oldest first, tracing each balance back to what built it up.
+	GetUnsettledEntries(userID int) ([]LedgerEntry, error)
+	// GetBalancesAt reconstructs the user's non-zero balances as they stood at the given time.
+	GetBalancesAt(userID int, at time.Time) ([]Balance, error)
+	// CheckBalances returns every pair whose materialized balance differs from the ledger.
+	CheckBalances() ([]BalanceDrift, error)
+	// RebuildBalances brings every drifted balance back in line with the ledger and returns the
+	// drifts it corrected.
+	RebuildBalances() ([]BalanceDrift, error)
+}
+
+type ledgerRepository struct {
+	db *sql.DB
+}
+
+func NewLedgerRepository(db *sql.DB) LedgerRepository {
+	return &ledgerRepository{db: db}
+}
+
+// postLedgerEntries records ordered balance updates as postings of source, two entries each.
+func postLedgerEntries(tx *sql.Tx, source LedgerSource, updates []BalanceUpdate) error {
+	var sourceID *int
+	if source.ID != 0 {
+		sourceID = &source.ID
+	}
+	args := make([]interface{}, 0, 10*len(updates))
+	for _, u := range updates {
+		args = append(args,
+			source.Type, sourceID, u.User1ID, u.User2ID, u.Amount,
+			source.Type, sourceID, u.User2ID, u.User1ID, -u.Amount,
+		)
+	}
+	rows := strings.TrimSuffix(strings.Repeat("(?, ?, ?, ?, ?, NOW(6)), ", 2*len(updates)), ", ")
+	query := "INSERT INTO ledger_entries (source_type, source_id, user_id, counterparty_id, amount, created_at) VALUES " + rows
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to post %d ledger entries for %s %d: %w", 2*len(updates), source.Type, source.ID, err)
+	}
+	return nil
+}
+
+const ledgerEntryColumns = "id, source_type, source_id, user_id, counterparty_id, amount, created_at"
+
+func (r *ledgerRepository) GetEntries(userID int, at time.Time) ([]LedgerEntry, error) {
+	query := `
+		SELECT ` + ledgerEntryColumns + `
+		FROM ledger_entries
+		WHERE user_id = ? AND created_at <= ?
+		ORDER BY created_at, id
+	`
+	return r.queryEntries(userID, query, userID, at)
+}
+
+func (r *ledgerRepository) GetUnsettledEntries(userID int) ([]LedgerEntry, error) {
+	query := `
+		SELECT e.id, e.source_type, e.source_id, e.user_id, e.counterparty_id, e.amount, e.created_at
+		FROM ledger_entries e
+		JOIN (
+			SELECT counterparty_id FROM ledger_entries
+			WHERE user_id = ?
+			GROUP BY counterparty_id
+			HAVING ABS(SUM(amount)) >= 0.005
+		) o ON o.counterparty_id = e.counterparty_id
+		WHERE e.user_id = ?
+		ORDER BY e.created_at, e.id
+	`
+	return r.queryEntries(userID, query, userID, userID)
+}
+
+func (r *ledgerRepository) queryEntries(userID int, query string, args ...any) ([]LedgerEntry, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ledger entries for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+	return scanLedgerEntries(rows, userID)
+}
+
+// scanLedgerEntries reads the user's entries selected with the columns of ledgerEntryColumns.
+func scanLedgerEntries(rows *sql.Rows, userID int) ([]LedgerEntry, error) {
+	var entries []LedgerEntry
+	for rows.Next() {
+		var (
+			e        LedgerEntry
+			sourceID sql.NullInt64
+		)
+		if err := rows.Scan(&e.ID, &e.Source.Type, &sourceID, &e.UserID, &e.CounterpartyID, &e.Amount, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ledger entry for user %d: %w", userID, err)
+		}
+		e.Source.ID = int(sourceID.Int64)
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over ledger entries for user %d: %w", userID, err)
+	}
+	return entries, nil
+}
+
+func (r *ledgerRepository) GetBalancesAt(userID int, at time.Time) ([]Balance, error) {
+	query := `
+		SELECT counterparty_id, SUM(amount), MAX(created_at)
+		FROM ledger_entries
+		WHERE user_id = ? AND created_at <= ?
+		GROUP BY counterparty_id
+		HAVING ABS(SUM(amount)) >= 0.005
+		ORDER BY MAX(created_at) DESC
+	`
+	rows, err := r.db.Query(query, userID, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query balances for user %d at %s: %w", userID, at, err)
+	}
+	defer rows.Close()
+
+	var balances []Balance
+	for rows.Next() {
+		var (
+			counterpartyID int
+			amount         float64
+			b              Balance
+		)
+		if err := rows.Scan(&counterpartyID, &amount, &b.LastUpdated); err != nil {
+			return nil, fmt.Errorf("failed to scan balance for user %d at %s: %w", userID, at, err)
+		}
+		// Stored the way the balances table would hold it, lower user ID first
+		b.User1ID, b.User2ID, b.Balance = OrderedPair(userID, counterpartyID, amount)
+		balances = append(balances, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over balances for user %d at %s: %w", userID, at, err)
+	}
+	return balances, nil
+}
+
+// driftQuery finds pairs whose balance row and ledger entries disagree, including pairs that only
+// have one of the two. A pair's ledger total is read from the entries of its lower user ID.
+const driftQuery = `
+	SELECT b.user1_id, b.user2_id, b.balance, COALESCE(l.total, 0)
+	FROM balances b
+	LEFT JOIN (
+		SELECT user_id, counterparty_id, SUM(amount) AS total FROM ledger_entries
+		WHERE user_id < counterparty_id GROUP BY user_id, counterparty_id
+	) l ON l.user_id = b.user1_id AND l.counterparty_id = b.user2_id
+	WHERE ABS(b.balance - COALESCE(l.total, 0)) >= 0.005
+	UNION ALL
+	SELECT l.user_id, l.counterparty_id, 0, l.total
+	FROM (
+		SELECT user_id, counterparty_id, SUM(amount) AS total FROM ledger_entries
+		WHERE user_id < counterparty_id GROUP BY user_id, counterparty_id
+	) l
+	LEFT JOIN balances b ON b.user1_id = l.user_id AND b.user2_id = l.counterparty_id
+	WHERE b.user1_id IS NULL AND ABS(l.total) >= 0.005
+	ORDER BY 1, 2
+`
+
+func (r *ledgerRepository) CheckBalances() ([]BalanceDrift, error) {
+	return queryDrifts(r.db)
+}
+
+func (r *ledgerRepository) RebuildBalances() ([]BalanceDrift, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback on error, no-op on commit
+
+	drifts, err := rebuildBalances(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return drifts, nil
+}
+
+// rebuildBalances is RebuildBalances within tx.
+func rebuildBalances(tx *sql.Tx) ([]BalanceDrift, error) {
+	drifts, err := queryDrifts(tx)
+	if err != nil {
+		return nil, err
+	}
+	if len(drifts) == 0 {
+		return drifts, nil
+	}
+
+	// Corrections are added rather than set, so postings made since the check still count
+	updates := make([]BalanceUpdate, 0, len(drifts))
+	for _, d := range drifts {
+		updates = append(updates, BalanceUpdate{User1ID: d.User1ID, User2ID: d.User2ID, Amount: d.Ledger - d.Materialized})
+	}
+	if err := applyBalanceUpdates(tx, orderedBalanceUpdates(updates)); err != nil {
+		return nil, err
+	}
+	return drifts, nil
+}
+
+func queryDrifts(q interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}) ([]BalanceDrift, error) {
+	rows, err := q.Query(driftQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare balances with the ledger: %w", err)
+	}
+	defer rows.Close()
+
+	drifts := []BalanceDrift{}
+	for rows.Next() {
+		var d BalanceDrift
+		if err := rows.Scan(&d.User1ID, &d.User2ID, &d.Materialized, &d.Ledger); err != nil {
+			return nil, fmt.Errorf("failed to scan balance drift: %w", err)
+		}
+		drifts = append(drifts, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over balance drifts: %w", err)
+	}
+	return drifts, nil
+}
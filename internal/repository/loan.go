@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrLoanNotFound = errors.New("loan not found")
+
+type Loan struct {
+	ID          int        `json:"id"`
+	LenderID    int        `json:"lender_id"`
+	BorrowerID  int        `json:"borrower_id"`
+	Amount      float64    `json:"amount"`
+	Description string     `json:"description"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	// RemindedAt is when the borrower was reminded that the loan fell due.
+	RemindedAt *time.Time `json:"reminded_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type LoanRepository interface {
+	CreateLoan(loan *Loan) (*Loan, error)
+	GetLoan(id int) (*Loan, error)
+	GetLoansByUserID(userID int) ([]Loan, error)
+	// GetLoansDueForReminder returns the loans due on or before the given day whose borrower has not
+	// been reminded yet.
+	GetLoansDueForReminder(day time.Time) ([]Loan, error)
+	// ClaimReminder marks the loan's borrower as reminded at the given time, and reports false if
+	// another runner got there first, so each borrower is reminded once however many servers are running.
+	ClaimReminder(id int, at time.Time) (bool, error)
+}
+
+type loanRepository struct {
+	db          *sql.DB
+	balanceRepo BalanceRepository
+}
+
+func NewLoanRepository(db *sql.DB, balanceRepo BalanceRepository) LoanRepository {
+	return &loanRepository{db: db, balanceRepo: balanceRepo}
+}
+
+func (r *loanRepository) CreateLoan(loan *Loan) (*Loan, error) {
+	return withRetry("create loan", func() (*Loan, error) { return r.createLoan(loan) })
+}
+
+func (r *loanRepository) createLoan(loan *Loan) (*Loan, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback on error, no-op on commit
+
+	query := "INSERT INTO loans (lender_id, borrower_id, amount, description, due_date, created_at) VALUES (?, ?, ?, ?, ?, ?)"
+	loan.CreatedAt = time.Now()
+	result, err := tx.Exec(query, loan.LenderID, loan.BorrowerID, loan.Amount, loan.Description, loan.DueDate, loan.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create loan: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID for loan: %w", err)
+	}
+	loan.ID = int(id)
+
+	// The borrower now owes the lender the full amount
+	if err := r.balanceRepo.UpdateBalance(tx, LedgerSource{Type: LedgerLoan, ID: loan.ID}, loan.LenderID, loan.BorrowerID, loan.Amount); err != nil {
+		return nil, fmt.Errorf("failed to update balance between user %d and %d: %w", loan.LenderID, loan.BorrowerID, err)
+	}
+	if err := touchUsers(tx, loan.LenderID, loan.BorrowerID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return loan, nil
+}
+
+// loanColumns is what scanLoan reads, in order.
+const loanColumns = "id, lender_id, borrower_id, amount, description, due_date, reminded_at, created_at"
+
+func scanLoan(row interface{ Scan(...any) error }, l *Loan) error {
+	var dueDate, remindedAt sql.NullTime
+	if err := row.Scan(&l.ID, &l.LenderID, &l.BorrowerID, &l.Amount, &l.Description, &dueDate, &remindedAt, &l.CreatedAt); err != nil {
+		return err
+	}
+	if dueDate.Valid {
+		l.DueDate = &dueDate.Time
+	}
+	if remindedAt.Valid {
+		l.RemindedAt = &remindedAt.Time
+	}
+	return nil
+}
+
+func (r *loanRepository) GetLoan(id int) (*Loan, error) {
+	l := &Loan{}
+	if err := scanLoan(r.db.QueryRow("SELECT "+loanColumns+" FROM loans WHERE id = ?", id), l); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrLoanNotFound
+		}
+		return nil, fmt.Errorf("failed to get loan %d: %w", id, err)
+	}
+	return l, nil
+}
+
+func (r *loanRepository) GetLoansByUserID(userID int) ([]Loan, error) {
+	query := `
+		SELECT ` + loanColumns + `
+		FROM loans
+		WHERE lender_id = ? OR borrower_id = ?
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(query, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query loans for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var loans []Loan
+	for rows.Next() {
+		var l Loan
+		if err := scanLoan(rows, &l); err != nil {
+			return nil, fmt.Errorf("failed to scan loan row for user %d: %w", userID, err)
+		}
+		loans = append(loans, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over loan rows for user %d: %w", userID, err)
+	}
+
+	return loans, nil
+}
+
+func (r *loanRepository) GetLoansDueForReminder(day time.Time) ([]Loan, error) {
+	query := `
+		SELECT ` + loanColumns + `
+		FROM loans
+		WHERE due_date <= ? AND reminded_at IS NULL
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(query, day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query loans due for a reminder: %w", err)
+	}
+	defer rows.Close()
+
+	var loans []Loan
+	for rows.Next() {
+		var l Loan
+		if err := scanLoan(rows, &l); err != nil {
+			return nil, fmt.Errorf("failed to scan loan due for a reminder: %w", err)
+		}
+		loans = append(loans, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over loans due for a reminder: %w", err)
+	}
+
+	return loans, nil
+}
+
+func (r *loanRepository) ClaimReminder(id int, at time.Time) (bool, error) {
+	result, err := r.db.Exec("UPDATE loans SET reminded_at = ? WHERE id = ? AND reminded_at IS NULL", at, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim reminder for loan %d: %w", id, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim reminder for loan %d: %w", id, err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"fmt"
+	"math"
+)
+
+// metersPerDegreeLatitude is the length of one degree of latitude, near enough everywhere.
+const metersPerDegreeLatitude = 111320.0
+
+// pointWKT writes a point for SRID 4326, whose axis order in MySQL is latitude first.
+func pointWKT(latitude, longitude float64) string {
+	return fmt.Sprintf("POINT(%f %f)", latitude, longitude)
+}
+
+// boundingBoxWKT returns a polygon, latitude first, that contains every point within radius meters
+// of the centre. It is only a pre-filter for the spatial index, so it may be generous but never
+// too small; it is clamped at the poles and the antimeridian.
+func boundingBoxWKT(latitude, longitude, radius float64) string {
+	dLat := radius / metersPerDegreeLatitude
+	dLng := 180.0
+	if cos := math.Cos(latitude * math.Pi / 180); cos > 1e-6 {
+		dLng = math.Min(dLat/cos, 180)
+	}
+	minLat, maxLat := math.Max(latitude-dLat, -90), math.Min(latitude+dLat, 90)
+	minLng, maxLng := math.Max(longitude-dLng, -180), math.Min(longitude+dLng, 180)
+	return fmt.Sprintf("POLYGON((%f %f, %f %f, %f %f, %f %f, %f %f))",
+		minLat, minLng, maxLat, minLng, maxLat, maxLng, minLat, maxLng, minLat, minLng)
+}
+
+func (r *expenseRepository) GetNearbyExpenses(userID int, latitude, longitude, radius float64) ([]NearbyExpense, error) {
+	// MBRContains narrows the rows through the spatial index; the sphere distance then drops the box's corners
+	query := `
+		SELECT
+			e.id, e.created_at, e.tag, e.description, e.total_amount, e.currency,
+			es.amount_paid, es.amount_owed, e.status, COALESCE(p.name, ''), u.name, u.email,
+			l.latitude, l.longitude, l.place_name,
+			ST_Distance_Sphere(l.location, ST_GeomFromText(?, 4326)) AS distance
+		FROM
+			expense_locations l
+		JOIN
+			expenses e ON e.id = l.expense_id
+		JOIN
+			expense_splits es ON es.expense_id = e.id AND es.user_id = ?
+		JOIN
+			users u ON u.id = e.created_by
+		LEFT JOIN
+			parties p ON p.id = e.payee_party_id
+		WHERE
+			MBRContains(ST_GeomFromText(?, 4326), l.location)
+		HAVING
+			distance <= ?
+		ORDER BY
+			distance, e.id
+	`
+
+	rows, err := r.db.Query(query, pointWKT(latitude, longitude), userID, boundingBoxWKT(latitude, longitude, radius), radius)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nearby expenses for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var expenses []NearbyExpense
+	for rows.Next() {
+		var (
+			n                      NearbyExpense
+			amountPaid, amountOwed float64
+		)
+		if err := rows.Scan(&n.ExpenseID, &n.Date, &n.Tag, &n.Description, &n.TotalAmount, &n.Currency,
+			&amountPaid, &amountOwed, &n.Status, &n.Payee, &n.CreatedByName, &n.CreatedByEmail,
+			&n.Location.Latitude, &n.Location.Longitude, &n.Location.PlaceName, &n.DistanceMeters); err != nil {
+			return nil, fmt.Errorf("failed to scan nearby expense row for user %d: %w", userID, err)
+		}
+		n.Share = amountPaid - amountOwed
+		n.IsPayer = amountPaid != 0
+		expenses = append(expenses, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over nearby expense rows for user %d: %w", userID, err)
+	}
+
+	return expenses, nil
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPointWKT(t *testing.T) {
+	assert.Equal(t, "POINT(12.971600 77.594600)", pointWKT(12.9716, 77.5946))
+}
+
+func TestBoundingBoxWKT(t *testing.T) {
+	// Test case 1: About a kilometre around a point near the equator
+	assert.Equal(t,
+		"POLYGON((-0.008983 -0.008983, 0.008983 -0.008983, 0.008983 0.008983, -0.008983 0.008983, -0.008983 -0.008983))",
+		boundingBoxWKT(0, 0, 1000))
+
+	// Test case 2: At a pole the box spans every longitude and stops at 90
+	assert.Equal(t,
+		"POLYGON((89.991017 -180.000000, 90.000000 -180.000000, 90.000000 180.000000, 89.991017 180.000000, 89.991017 -180.000000))",
+		boundingBoxWKT(90, 0, 1000))
+}
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+type auditRepository struct {
+	mu      sync.Mutex
+	entries []repository.AuditLog
+}
+
+func newAuditRepository() *auditRepository {
+	return &auditRepository{}
+}
+
+func (r *auditRepository) CreateAuditLog(entry *repository.AuditLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	entry.ID = int64(len(r.entries) + 1)
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *auditRepository) ListAuditLogs(filter repository.AuditLogFilter) ([]repository.AuditLog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var entries []repository.AuditLog
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		e := r.entries[i]
+		if filter.Actor != "" && e.Actor != filter.Actor {
+			continue
+		}
+		if !filter.From.IsZero() && e.CreatedAt.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && !e.CreatedAt.Before(filter.To) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
@@ -0,0 +1,256 @@
+package memory
+
+import (
+	"database/sql"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+type balanceRepository struct {
+	mu       sync.Mutex
+	balances map[[2]int]*repository.Balance
+	entries  []repository.LedgerEntry
+}
+
+func newBalanceRepository() *balanceRepository {
+	return &balanceRepository{balances: make(map[[2]int]*repository.Balance)}
+}
+
+func (r *balanceRepository) UpdateBalance(tx *sql.Tx, source repository.LedgerSource, user1ID, user2ID int, amount float64) error {
+	return r.UpdateBalances(tx, source, []repository.BalanceUpdate{{User1ID: user1ID, User2ID: user2ID, Amount: amount}})
+}
+
+func (r *balanceRepository) UpdateBalances(_ *sql.Tx, source repository.LedgerSource, updates []repository.BalanceUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, update := range updates {
+		user1ID, user2ID, amount := repository.OrderedPair(update.User1ID, update.User2ID, update.Amount)
+		r.entries = append(r.entries,
+			repository.LedgerEntry{ID: int64(len(r.entries) + 1), Source: source, UserID: user1ID, CounterpartyID: user2ID, Amount: amount, CreatedAt: now},
+			repository.LedgerEntry{ID: int64(len(r.entries) + 2), Source: source, UserID: user2ID, CounterpartyID: user1ID, Amount: -amount, CreatedAt: now},
+		)
+		r.apply(user1ID, user2ID, amount, now)
+	}
+	return nil
+}
+
+func (r *balanceRepository) apply(user1ID, user2ID int, amount float64, at time.Time) {
+	key := [2]int{user1ID, user2ID}
+	b, ok := r.balances[key]
+	if !ok {
+		b = &repository.Balance{User1ID: user1ID, User2ID: user2ID}
+		r.balances[key] = b
+	}
+	b.Balance += amount
+	b.LastUpdated = at
+}
+
+// The memory balance repository keeps its own ledger, so it is the ledger repository as well.
+
+func (r *balanceRepository) GetEntries(userID int, at time.Time) ([]repository.LedgerEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var entries []repository.LedgerEntry
+	for _, e := range r.entries {
+		if e.UserID == userID && !e.CreatedAt.After(at) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// pairEntries returns the user's side of every posting with the counterparty, oldest first.
+func (r *balanceRepository) pairEntries(userID, counterpartyID int) []repository.LedgerEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var entries []repository.LedgerEntry
+	for _, e := range r.entries {
+		if e.UserID == userID && e.CounterpartyID == counterpartyID {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+func (r *balanceRepository) GetUnsettledEntries(userID int) ([]repository.LedgerEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	totals := make(map[int]float64)
+	for _, e := range r.entries {
+		if e.UserID == userID {
+			totals[e.CounterpartyID] += e.Amount
+		}
+	}
+	var entries []repository.LedgerEntry
+	for _, e := range r.entries {
+		if e.UserID == userID && math.Abs(totals[e.CounterpartyID]) >= 0.005 {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (r *balanceRepository) GetBalancesAt(userID int, at time.Time) ([]repository.Balance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	totals := make(map[int]*repository.Balance)
+	var order []int
+	for _, e := range r.entries {
+		if e.UserID != userID || e.CreatedAt.After(at) {
+			continue
+		}
+		b, ok := totals[e.CounterpartyID]
+		if !ok {
+			b = &repository.Balance{}
+			totals[e.CounterpartyID] = b
+			order = append(order, e.CounterpartyID)
+		}
+		b.Balance += e.Amount
+		b.LastUpdated = e.CreatedAt
+	}
+	var balances []repository.Balance
+	for _, counterpartyID := range order {
+		b := totals[counterpartyID]
+		if math.Abs(b.Balance) < 0.005 {
+			continue
+		}
+		b.User1ID, b.User2ID, b.Balance = repository.OrderedPair(userID, counterpartyID, b.Balance)
+		balances = append(balances, *b)
+	}
+	sort.SliceStable(balances, func(i, j int) bool { return balances[i].LastUpdated.After(balances[j].LastUpdated) })
+	return balances, nil
+}
+
+func (r *balanceRepository) CheckBalances() ([]repository.BalanceDrift, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.drifts(), nil
+}
+
+func (r *balanceRepository) RebuildBalances() ([]repository.BalanceDrift, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	drifts := r.drifts()
+	for _, d := range drifts {
+		r.apply(d.User1ID, d.User2ID, d.Ledger-d.Materialized, time.Now())
+	}
+	return drifts, nil
+}
+
+func (r *balanceRepository) drifts() []repository.BalanceDrift {
+	ledger := make(map[[2]int]float64)
+	for _, e := range r.entries {
+		if e.UserID < e.CounterpartyID {
+			ledger[[2]int{e.UserID, e.CounterpartyID}] += e.Amount
+		}
+	}
+	keys := make(map[[2]int]bool)
+	for key := range ledger {
+		keys[key] = true
+	}
+	for key := range r.balances {
+		keys[key] = true
+	}
+
+	drifts := []repository.BalanceDrift{}
+	for key := range keys {
+		var materialized float64
+		if b, ok := r.balances[key]; ok {
+			materialized = b.Balance
+		}
+		if math.Abs(materialized-ledger[key]) >= 0.005 {
+			drifts = append(drifts, repository.BalanceDrift{User1ID: key[0], User2ID: key[1], Materialized: materialized, Ledger: ledger[key]})
+		}
+	}
+	sort.Slice(drifts, func(i, j int) bool {
+		if drifts[i].User1ID != drifts[j].User1ID {
+			return drifts[i].User1ID < drifts[j].User1ID
+		}
+		return drifts[i].User2ID < drifts[j].User2ID
+	})
+	return drifts
+}
+
+func (r *balanceRepository) GetBalancesByUserID(userID int) ([]repository.Balance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var balances []repository.Balance
+	for _, b := range r.balances {
+		if b.User1ID == userID || b.User2ID == userID {
+			balances = append(balances, *b)
+		}
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].LastUpdated.After(balances[j].LastUpdated) })
+	return balances, nil
+}
+
+func (r *balanceRepository) GetOverallBalanceByUserID(userID int) (float64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var overall float64
+	for _, b := range r.balances {
+		switch userID {
+		case b.User1ID:
+			overall += b.Balance
+		case b.User2ID:
+			overall -= b.Balance
+		}
+	}
+	return overall, nil
+}
+
+func (r *balanceRepository) GetDirectedBalances(userID int, direction repository.DebtDirection, limit, offset int) (*repository.DirectedBalances, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sign := 1.0
+	if direction == repository.OwedByUser {
+		sign = -1
+	}
+	var all []repository.DirectedBalance
+	var total float64
+	for _, b := range r.balances {
+		counterpartyID, amount := b.User2ID, b.Balance
+		switch userID {
+		case b.User1ID:
+		case b.User2ID:
+			counterpartyID, amount = b.User1ID, -amount
+		default:
+			continue
+		}
+		// Balances are kept in the default currency, so anything that rounds to no minor units is settled
+		if amount *= sign; util.ToMinorUnits(amount, util.DefaultExponent) > 0 {
+			all = append(all, repository.DirectedBalance{CounterpartyID: counterpartyID, Amount: amount, LastUpdated: b.LastUpdated})
+			total += amount
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Amount != all[j].Amount {
+			return all[i].Amount > all[j].Amount
+		}
+		return all[i].CounterpartyID < all[j].CounterpartyID
+	})
+
+	page := &repository.DirectedBalances{Balances: []repository.DirectedBalance{}, Count: len(all), Total: total}
+	if limit == 0 {
+		limit = len(all)
+	}
+	if offset < len(all) {
+		page.Balances = append(page.Balances, all[offset:min(len(all), offset+limit)]...)
+	}
+	return page, nil
+}
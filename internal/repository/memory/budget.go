@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+type budgetRepository struct {
+	mu          sync.Mutex
+	budgets     []repository.TagBudget
+	expenseRepo *expenseRepository
+}
+
+func newBudgetRepository(expenseRepo *expenseRepository) *budgetRepository {
+	return &budgetRepository{expenseRepo: expenseRepo}
+}
+
+func (r *budgetRepository) SetTagBudget(budget *repository.TagBudget) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	budget.UpdatedAt = time.Now()
+	for i, b := range r.budgets {
+		if b.UserID == budget.UserID && b.Tag == budget.Tag && b.Currency == budget.Currency {
+			r.budgets[i] = *budget
+			return nil
+		}
+	}
+	r.budgets = append(r.budgets, *budget)
+	return nil
+}
+
+func (r *budgetRepository) DeleteTagBudget(userID int, tag, currency string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, b := range r.budgets {
+		if b.UserID == userID && b.Tag == tag && b.Currency == currency {
+			r.budgets = append(r.budgets[:i], r.budgets[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *budgetRepository) GetTagBudgets(userIDs []int) ([]repository.TagBudget, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var budgets []repository.TagBudget
+	for _, b := range r.budgets {
+		for _, id := range userIDs {
+			if b.UserID == id {
+				budgets = append(budgets, b)
+				break
+			}
+		}
+	}
+	return budgets, nil
+}
+
+func (r *budgetRepository) GetTagSpend(userID int, tag, currency string, since time.Time) (float64, error) {
+	r.expenseRepo.mu.Lock()
+	defer r.expenseRepo.mu.Unlock()
+
+	var spent float64
+	for _, e := range r.expenseRepo.expenses {
+		if e.Tag != tag || e.Currency != currency || e.CreatedAt.Before(since) {
+			continue
+		}
+		for _, s := range r.expenseRepo.splits {
+			if s.ExpenseID == e.ID && s.UserID == userID {
+				spent += s.AmountOwed
+			}
+		}
+	}
+	return spent, nil
+}
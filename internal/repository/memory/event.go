@@ -0,0 +1,210 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+type eventRepository struct {
+	mu          sync.Mutex
+	nextID      int
+	events      []repository.Event
+	members     map[int]map[int]bool // By event, then user
+	expenseRepo *expenseRepository
+}
+
+func newEventRepository(expenseRepo *expenseRepository) *eventRepository {
+	return &eventRepository{nextID: 1, members: make(map[int]map[int]bool), expenseRepo: expenseRepo}
+}
+
+func (r *eventRepository) CreateEvent(event *repository.Event) (*repository.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event.ID = r.nextID
+	r.nextID++
+	event.CreatedAt = time.Now()
+	r.events = append(r.events, *event)
+	created := *event
+	return &created, nil
+}
+
+func (r *eventRepository) GetEvent(id int) (*repository.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.events {
+		if e.ID == id {
+			event := e
+			return &event, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", repository.ErrEventNotFound, id)
+}
+
+func (r *eventRepository) ArchiveEvent(id int) (*repository.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.events {
+		if r.events[i].ID == id {
+			if r.events[i].ArchivedAt == nil {
+				now := time.Now()
+				r.events[i].ArchivedAt = &now
+			}
+			event := r.events[i]
+			return &event, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", repository.ErrEventNotFound, id)
+}
+
+func (r *eventRepository) UnarchiveEvent(id int) (*repository.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.events {
+		if r.events[i].ID == id {
+			r.events[i].ArchivedAt = nil
+			event := r.events[i]
+			return &event, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", repository.ErrEventNotFound, id)
+}
+
+func (r *eventRepository) ListEvents(userID int, includeArchived bool) ([]repository.Event, error) {
+	r.expenseRepo.mu.Lock()
+	joined := map[int]bool{}
+	for _, e := range r.expenseRepo.expenses {
+		if e.EventID == nil {
+			continue
+		}
+		for _, s := range r.expenseRepo.splits {
+			if s.ExpenseID == e.ID && s.UserID == userID {
+				joined[*e.EventID] = true
+			}
+		}
+	}
+	r.expenseRepo.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := []repository.Event{}
+	for i := len(r.events) - 1; i >= 0; i-- {
+		e := r.events[i]
+		if (e.CreatedBy == userID || joined[e.ID] || r.members[e.ID][userID]) && (includeArchived || e.ArchivedAt == nil) {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (r *eventRepository) AddEventMember(eventID, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.members[eventID] == nil {
+		r.members[eventID] = make(map[int]bool)
+	}
+	r.members[eventID][userID] = true
+	return nil
+}
+
+func (r *eventRepository) GetEventSplits(eventID int) ([]repository.EventSplit, error) {
+	r.expenseRepo.mu.Lock()
+	defer r.expenseRepo.mu.Unlock()
+
+	var splits []repository.EventSplit
+	for _, e := range r.expenseRepo.expenses {
+		if e.EventID == nil || *e.EventID != eventID {
+			continue
+		}
+		for _, s := range r.expenseRepo.splits {
+			if s.ExpenseID == e.ID {
+				splits = append(splits, repository.EventSplit{ExpenseID: e.ID, Currency: e.Currency, UserID: s.UserID, AmountPaid: s.AmountPaid, AmountOwed: s.AmountOwed})
+			}
+		}
+	}
+	return splits, nil
+}
+
+func (r *eventRepository) GetEventExpenses(eventID int) ([]repository.EventExpense, error) {
+	r.expenseRepo.mu.Lock()
+	defer r.expenseRepo.mu.Unlock()
+
+	var expenses []repository.EventExpense
+	for i := len(r.expenseRepo.expenses) - 1; i >= 0; i-- {
+		e := r.expenseRepo.expenses[i]
+		if e.EventID != nil && *e.EventID == eventID {
+			expenses = append(expenses, repository.EventExpense{ID: e.ID, Description: e.Description, Tag: e.Tag, TotalAmount: e.TotalAmount, Currency: e.Currency, CreatedAt: e.CreatedAt})
+		}
+	}
+	return expenses, nil
+}
+
+func (r *eventRepository) SetAutoSettle(id int, enabled bool) (*repository.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.events {
+		if r.events[i].ID == id {
+			r.events[i].AutoSettle = enabled
+			event := r.events[i]
+			return &event, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", repository.ErrEventNotFound, id)
+}
+
+func (r *eventRepository) SetBaseCurrency(id int, currency string) (*repository.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.events {
+		if r.events[i].ID == id {
+			r.events[i].BaseCurrency = currency
+			event := r.events[i]
+			return &event, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", repository.ErrEventNotFound, id)
+}
+
+func (r *eventRepository) GetEventsDueForSettleUp(before time.Time) ([]repository.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []repository.Event
+	for _, e := range r.events {
+		last := e.CreatedAt
+		if e.LastSettleUpAt != nil {
+			last = *e.LastSettleUpAt
+		}
+		if e.ArchivedAt == nil && last.Before(before) {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (r *eventRepository) ClaimSettleUp(id int, last *time.Time, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.events {
+		e := &r.events[i]
+		if e.ID != id {
+			continue
+		}
+		if (e.LastSettleUpAt == nil) != (last == nil) || (last != nil && !e.LastSettleUpAt.Equal(*last)) {
+			return false, nil
+		}
+		e.LastSettleUpAt = &at
+		return true, nil
+	}
+	return false, nil
+}
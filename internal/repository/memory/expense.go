@@ -0,0 +1,540 @@
+package memory
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+type expenseRepository struct {
+	mu          sync.Mutex
+	nextID      int
+	nextSplitID int
+	expenses    []repository.Expense
+	splits      []repository.ExpenseSplit
+	claims      map[int]map[int]time.Time // By expense, then user
+	balanceRepo repository.BalanceRepository
+	users       *userRepository
+	events      *expenseEventRepository // Nil unless the event store is enabled
+}
+
+func newExpenseRepository(balanceRepo repository.BalanceRepository, users *userRepository) *expenseRepository {
+	return &expenseRepository{nextID: 1, nextSplitID: 1, claims: make(map[int]map[int]time.Time), balanceRepo: balanceRepo, users: users}
+}
+
+// appendEvent records an event for the expense when the event store is enabled. The caller holds r.mu.
+func (r *expenseRepository) appendEvent(expenseID int, eventType repository.ExpenseEventType, data any) error {
+	if r.events == nil {
+		return nil
+	}
+	return r.events.append(expenseID, eventType, data)
+}
+
+// find returns the stored expense, or nil. The caller holds r.mu.
+func (r *expenseRepository) find(id int) *repository.Expense {
+	for i := range r.expenses {
+		if r.expenses[i].ID == id {
+			return &r.expenses[i]
+		}
+	}
+	return nil
+}
+
+// remove drops the expense with its splits and claims, reporting whether it was there. The caller
+// holds r.mu.
+func (r *expenseRepository) remove(id int) bool {
+	found := false
+	expenses := r.expenses[:0]
+	for _, e := range r.expenses {
+		if e.ID == id {
+			found = true
+			continue
+		}
+		expenses = append(expenses, e)
+	}
+	r.expenses = expenses
+
+	splits := r.splits[:0]
+	for _, s := range r.splits {
+		if s.ExpenseID != id {
+			splits = append(splits, s)
+		}
+	}
+	r.splits = splits
+	delete(r.claims, id)
+	return found
+}
+
+// restore puts back a replayed expense with its original IDs, keeping expenses in ID order. The
+// caller holds r.mu.
+func (r *expenseRepository) restore(expense *repository.Expense) {
+	stored := cloneExpense(expense)
+	stored.Splits = nil
+	// Refunds are restored after what they refund, which events only know by number
+	if stored.RefundOf != nil {
+		if original := r.find(*stored.RefundOf); original != nil {
+			stored.RefundOfPublicID = original.PublicID
+		}
+	}
+	at := sort.Search(len(r.expenses), func(i int) bool { return r.expenses[i].ID > expense.ID })
+	r.expenses = append(r.expenses[:at], append([]repository.Expense{*stored}, r.expenses[at:]...)...)
+	for _, s := range expense.Splits {
+		s.ExpenseID = expense.ID
+		r.splits = append(r.splits, s)
+	}
+	sort.SliceStable(r.splits, func(i, j int) bool { return r.splits[i].ID < r.splits[j].ID })
+	if expense.ID >= r.nextID {
+		r.nextID = expense.ID + 1
+	}
+	for _, s := range expense.Splits {
+		if s.ID >= r.nextSplitID {
+			r.nextSplitID = s.ID + 1
+		}
+	}
+}
+
+// cloneExpense copies an expense deep enough that changing the copy leaves the original alone.
+func cloneExpense(e *repository.Expense) *repository.Expense {
+	c := *e
+	if e.RefundOf != nil {
+		refundOf := *e.RefundOf
+		c.RefundOf = &refundOf
+	}
+	if e.EventID != nil {
+		eventID := *e.EventID
+		c.EventID = &eventID
+	}
+	if e.PayeeParty != nil {
+		party := *e.PayeeParty
+		c.PayeeParty = &party
+	}
+	if e.Location != nil {
+		location := *e.Location
+		c.Location = &location
+	}
+	if e.Conversion != nil {
+		conversion := *e.Conversion
+		c.Conversion = &conversion
+	}
+	if e.Adjustment != nil {
+		adjustment := *e.Adjustment
+		c.Adjustment = &adjustment
+	}
+	if e.LockedAt != nil {
+		lockedAt := *e.LockedAt
+		c.LockedAt = &lockedAt
+	}
+	if e.SettledBy != nil {
+		settledBy := *e.SettledBy
+		c.SettledBy = &settledBy
+	}
+	return &c
+}
+
+// touchParticipants marks the data of the expense's creator and everyone with a split as changed.
+// The caller holds r.mu.
+func (r *expenseRepository) touchParticipants(id int) {
+	var ids []int
+	for _, e := range r.expenses {
+		if e.ID == id {
+			ids = append(ids, e.CreatedBy)
+		}
+	}
+	for _, s := range r.splits {
+		if s.ExpenseID == id {
+			ids = append(ids, s.UserID)
+		}
+	}
+	r.users.touch(ids...)
+}
+
+func (r *expenseRepository) CreateExpense(expense *repository.Expense, splits []repository.ExpenseSplit, balanceUpdates []repository.BalanceUpdate) (*repository.Expense, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if expense.PublicID != "" {
+		for _, e := range r.expenses {
+			if e.PublicID == expense.PublicID {
+				return nil, fmt.Errorf("%w: %s", repository.ErrPublicIDTaken, expense.PublicID)
+			}
+		}
+	}
+	expense.ID = r.nextID
+	r.nextID++
+	expense.Status = repository.ExpenseActive
+	expense.CreatedAt = time.Now()
+	stored := cloneExpense(expense)
+	stored.BudgetWarnings, stored.Splits, stored.BalanceDeltas, stored.UndoUntil, stored.Explanation = nil, nil, nil, nil, nil
+	r.expenses = append(r.expenses, *stored)
+
+	for i := range splits {
+		splits[i].ID = r.nextSplitID
+		r.nextSplitID++
+		splits[i].ExpenseID = expense.ID
+		r.splits = append(r.splits, splits[i])
+	}
+	if err := r.appendEvent(expense.ID, repository.ExpenseCreatedEvent, repository.ExpenseCreatedData(expense, splits)); err != nil {
+		return nil, err
+	}
+
+	if err := r.balanceRepo.UpdateBalances(nil, repository.LedgerSource{Type: repository.LedgerExpense, ID: expense.ID}, balanceUpdates); err != nil {
+		return nil, fmt.Errorf("failed to update balances for expense: %w", err)
+	}
+	r.touchParticipants(expense.ID)
+
+	return expense, nil
+}
+
+func (r *expenseRepository) GetExpensesByUserID(userID int) ([]repository.UserExpenseView, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var views []repository.UserExpenseView
+	for i := len(r.expenses) - 1; i >= 0; i-- {
+		e := r.expenses[i]
+		for _, s := range r.splits {
+			if s.ExpenseID == e.ID && s.UserID == userID {
+				view := repository.UserExpenseView{
+					ExpenseID:       e.ID,
+					ExpensePublicID: e.PublicID,
+					Date:            e.CreatedAt,
+					Tag:             e.Tag,
+					Description:     e.Description,
+					TotalAmount:     e.TotalAmount,
+					Currency:        e.Currency,
+					Share:           s.AmountPaid - s.AmountOwed,
+					Status:          e.Status,
+					IsPayer:         s.AmountPaid != 0,
+				}
+				if e.PayeeParty != nil {
+					view.Payee = e.PayeeParty.Name
+				}
+				if creator, err := r.users.GetUser(e.CreatedBy); err == nil {
+					view.CreatedByName, view.CreatedByEmail = creator.Name, creator.Email
+				}
+				views = append(views, view)
+			}
+		}
+	}
+	return views, nil
+}
+
+func (r *expenseRepository) GetExpensePageByUserID(userID, limit, offset int) (*repository.UserExpensePage, error) {
+	views, err := r.GetExpensesByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	page := &repository.UserExpensePage{Count: len(views)}
+	from, to := min(offset, len(views)), min(offset+limit, len(views))
+	page.Expenses = views[from:to]
+	for _, v := range views[to:] {
+		if v.Currency != util.UnitCurrency {
+			page.OlderShare += v.Share
+		}
+	}
+	return page, nil
+}
+
+func (r *expenseRepository) GetNearbyExpenses(userID int, latitude, longitude, radius float64) ([]repository.NearbyExpense, error) {
+	views, err := r.GetExpensesByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var nearby []repository.NearbyExpense
+	for _, v := range views {
+		for _, e := range r.expenses {
+			if e.ID != v.ExpenseID || e.Location == nil {
+				continue
+			}
+			if d := haversineMeters(latitude, longitude, e.Location.Latitude, e.Location.Longitude); d <= radius {
+				nearby = append(nearby, repository.NearbyExpense{UserExpenseView: v, Location: *e.Location, DistanceMeters: d})
+			}
+		}
+	}
+	sort.SliceStable(nearby, func(i, j int) bool { return nearby[i].DistanceMeters < nearby[j].DistanceMeters })
+	return nearby, nil
+}
+
+// haversineMeters is the great-circle distance MySQL's ST_Distance_Sphere computes.
+func haversineMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	const earthRadius = 6370986 // Meters, ST_Distance_Sphere's default
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat, dLng := toRad(lat2-lat1), toRad(lng2-lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+func (r *expenseRepository) GetExpense(id int) (*repository.Expense, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e := r.find(id); e != nil {
+		return cloneExpense(e), nil
+	}
+	return nil, repository.ErrExpenseNotFound
+}
+
+func (r *expenseRepository) GetExpenseByPublicID(publicID string) (*repository.Expense, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.expenses {
+		if r.expenses[i].PublicID == publicID {
+			return cloneExpense(&r.expenses[i]), nil
+		}
+	}
+	return nil, repository.ErrExpenseNotFound
+}
+
+func (r *expenseRepository) DeleteExpense(id int, balanceUpdates []repository.BalanceUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e := r.find(id); e != nil && e.LockedAt != nil {
+		return fmt.Errorf("%w: expense %d", repository.ErrExpenseLocked, id)
+	}
+	r.touchParticipants(id)
+	if !r.remove(id) {
+		return repository.ErrExpenseNotFound
+	}
+	if err := r.appendEvent(id, repository.ExpenseDeletedEvent, struct{}{}); err != nil {
+		return err
+	}
+
+	return r.balanceRepo.UpdateBalances(nil, repository.LedgerSource{Type: repository.LedgerExpenseReversal, ID: id}, balanceUpdates)
+}
+
+func (r *expenseRepository) GetExpenseSplits(expenseID int) ([]repository.ExpenseSplit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var splits []repository.ExpenseSplit
+	for _, s := range r.splits {
+		if s.ExpenseID == expenseID {
+			splits = append(splits, s)
+		}
+	}
+	return splits, nil
+}
+
+func (r *expenseRepository) GetUserActivity(userID int, from, to time.Time) ([]repository.ExpenseActivity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var activity []repository.ExpenseActivity
+	for _, e := range r.expenses {
+		if e.CreatedAt.Before(from) || !e.CreatedAt.Before(to) {
+			continue
+		}
+		var own *repository.ExpenseSplit
+		var others []int
+		for i, s := range r.splits {
+			switch {
+			case s.ExpenseID != e.ID:
+			case s.UserID == userID:
+				own = &r.splits[i]
+			default:
+				others = append(others, s.UserID)
+			}
+		}
+		if own == nil {
+			continue
+		}
+		sort.Ints(others)
+		activity = append(activity, repository.ExpenseActivity{
+			ExpenseID:      e.ID,
+			Description:    e.Description,
+			Tag:            e.Tag,
+			TotalAmount:    e.TotalAmount,
+			Currency:       e.Currency,
+			CreatedAt:      e.CreatedAt,
+			AmountPaid:     own.AmountPaid,
+			AmountOwed:     own.AmountOwed,
+			CoParticipants: others,
+		})
+	}
+	return activity, nil
+}
+
+func (r *expenseRepository) GetDailySpend(userID int, from, to time.Time) ([]repository.DailySpend, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var days []repository.DailySpend
+	for _, e := range r.expenses {
+		if e.CreatedAt.Before(from) || !e.CreatedAt.Before(to) {
+			continue
+		}
+		for _, s := range r.splits {
+			if s.ExpenseID != e.ID || s.UserID != userID {
+				continue
+			}
+			day := e.CreatedAt.UTC().Truncate(24 * time.Hour)
+			if n := len(days); n == 0 || !days[n-1].Date.Equal(day) {
+				days = append(days, repository.DailySpend{Date: day})
+			}
+			days[len(days)-1].Owed += s.AmountOwed
+			days[len(days)-1].ExpenseCount++
+		}
+	}
+	return days, nil
+}
+
+func (r *expenseRepository) GetSplitsForExpensesInvolving(userIDs []int) ([]repository.ExpenseSplit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	involved := make(map[int]bool)
+	for _, s := range r.splits {
+		for _, id := range userIDs {
+			if s.UserID == id {
+				involved[s.ExpenseID] = true
+			}
+		}
+	}
+
+	var splits []repository.ExpenseSplit
+	for _, s := range r.splits {
+		if involved[s.ExpenseID] {
+			splits = append(splits, s)
+		}
+	}
+	return splits, nil
+}
+
+func (r *expenseRepository) TransitionExpense(id int, from, to repository.ExpenseStatus, reason string) (*repository.Expense, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.expenses {
+		e := &r.expenses[i]
+		if e.ID != id {
+			continue
+		}
+		if e.Status != from {
+			return nil, fmt.Errorf("%w: cannot move from %s to %s", repository.ErrInvalidExpenseTransition, e.Status, to)
+		}
+		if e.LockedAt != nil {
+			return nil, fmt.Errorf("%w: expense %d", repository.ErrExpenseLocked, id)
+		}
+		e.Status = to
+		e.DisputeReason = reason
+		if err := r.appendEvent(id, repository.ExpenseStatusChangedEvent, repository.ExpenseStatusChange{Status: to, DisputeReason: reason}); err != nil {
+			return nil, err
+		}
+		r.touchParticipants(id)
+		return cloneExpense(e), nil
+	}
+	return nil, repository.ErrExpenseNotFound
+}
+
+func (r *expenseRepository) UnlockExpense(id int) (*repository.Expense, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e := r.find(id)
+	if e == nil {
+		return nil, repository.ErrExpenseNotFound
+	}
+	if e.LockedAt == nil {
+		return nil, fmt.Errorf("%w: expense %d", repository.ErrExpenseNotLocked, id)
+	}
+	e.LockedAt = nil
+	r.touchParticipants(id)
+	return cloneExpense(e), nil
+}
+
+// lock locks those of the expenses that are active and not already locked, recording the
+// settlement that locked them unless an earlier one had.
+func (r *expenseRepository) lock(ids []int, settlementID int, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range ids {
+		e := r.find(id)
+		if e == nil || e.Status != repository.ExpenseActive || e.LockedAt != nil {
+			continue
+		}
+		lockedAt := at
+		e.LockedAt = &lockedAt
+		if e.SettledBy == nil {
+			settledBy := settlementID
+			e.SettledBy = &settledBy
+		}
+		r.touchParticipants(e.ID)
+	}
+}
+
+// movesBalanceBetween reports whether the expense moved the balance between the two users. The
+// caller holds r.mu.
+func (r *expenseRepository) movesBalanceBetween(e *repository.Expense, user1ID, user2ID int) bool {
+	participants := map[int]bool{}
+	for _, s := range r.splits {
+		if s.ExpenseID == e.ID {
+			participants[s.UserID] = true
+		}
+	}
+	// A simple expense only moves the balance between its creator and each other participant
+	if e.BalanceStrategy == "" || e.BalanceStrategy == "simple" {
+		return (e.CreatedBy == user1ID && participants[user2ID]) || (e.CreatedBy == user2ID && participants[user1ID])
+	}
+	return participants[user1ID] && participants[user2ID]
+}
+
+func (r *expenseRepository) GetRefundedAmount(expenseID int) (float64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var refunded float64
+	for _, e := range r.expenses {
+		if e.RefundOf != nil && *e.RefundOf == expenseID {
+			refunded -= e.TotalAmount
+		}
+	}
+	return refunded, nil
+}
+
+func (r *expenseRepository) ClaimShare(expenseID, userID int) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.claims[expenseID] == nil {
+		r.claims[expenseID] = make(map[int]time.Time)
+	}
+	if _, ok := r.claims[expenseID][userID]; !ok {
+		r.claims[expenseID][userID] = time.Now()
+	}
+	return r.claims[expenseID][userID], nil
+}
+
+func (r *expenseRepository) GetShareClaims(expenseID int) (map[int]time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	claims := make(map[int]time.Time, len(r.claims[expenseID]))
+	for userID, at := range r.claims[expenseID] {
+		claims[userID] = at
+	}
+	return claims, nil
+}
+
+func (r *expenseRepository) HasDisputedExpenseBetween(user1ID, user2ID int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.expenses {
+		if r.expenses[i].Status == repository.ExpenseDisputed && r.movesBalanceBetween(&r.expenses[i], user1ID, user2ID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
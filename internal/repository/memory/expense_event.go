@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+type expenseEventRepository struct {
+	mu       sync.Mutex
+	events   []repository.ExpenseEvent
+	expenses *expenseRepository
+}
+
+func newExpenseEventRepository(expenses *expenseRepository) *expenseEventRepository {
+	return &expenseEventRepository{expenses: expenses}
+}
+
+// append records an event for the expense.
+func (r *expenseEventRepository) append(expenseID int, eventType repository.ExpenseEventType, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event for expense %d: %w", eventType, expenseID, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, repository.ExpenseEvent{
+		ID:        int64(len(r.events) + 1),
+		ExpenseID: expenseID,
+		Type:      eventType,
+		Data:      payload,
+		CreatedAt: time.Now(),
+	})
+	return nil
+}
+
+func (r *expenseEventRepository) GetExpenseEvents(expenseID int) ([]repository.ExpenseEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []repository.ExpenseEvent
+	for _, e := range r.events {
+		if e.ExpenseID == expenseID {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (r *expenseEventRepository) RebuildProjections() (*repository.ProjectionReport, error) {
+	// The expense lock is taken first, as writes to expenses do before appending their events
+	r.expenses.mu.Lock()
+	defer r.expenses.mu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byExpense := make(map[int][]repository.ExpenseEvent)
+	var ids []int
+	for _, e := range r.events {
+		if _, ok := byExpense[e.ExpenseID]; !ok {
+			ids = append(ids, e.ExpenseID)
+		}
+		byExpense[e.ExpenseID] = append(byExpense[e.ExpenseID], e)
+	}
+	sort.Ints(ids)
+	report := &repository.ProjectionReport{Events: len(r.events), Expenses: len(ids)}
+
+	for _, id := range ids {
+		expense, deleted, err := repository.ReplayExpense(byExpense[id])
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay expense %d: %w", id, err)
+		}
+		current := r.expenses.find(id)
+		switch {
+		case deleted && current != nil:
+			r.expenses.remove(id)
+			report.Deleted++
+		case expense == nil:
+		case current == nil:
+			r.expenses.restore(expense)
+			report.Restored++
+		case current.Status != expense.Status || current.DisputeReason != expense.DisputeReason:
+			current.Status, current.DisputeReason = expense.Status, expense.DisputeReason
+			report.Updated++
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+type goalRepository struct {
+	mu     sync.Mutex
+	nextID int
+	goals  []repository.Goal
+}
+
+func newGoalRepository() *goalRepository {
+	return &goalRepository{nextID: 1}
+}
+
+func (r *goalRepository) CreateGoal(goal *repository.Goal) (*repository.Goal, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	goal.ID = r.nextID
+	r.nextID++
+	goal.CreatedAt = time.Now()
+	r.goals = append(r.goals, *goal)
+	created := *goal
+	return &created, nil
+}
+
+func (r *goalRepository) GetGoalsByUserID(userID int) ([]repository.Goal, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var goals []repository.Goal
+	for _, g := range r.goals {
+		if g.UserID == userID {
+			goals = append(goals, g)
+		}
+	}
+	sort.SliceStable(goals, func(i, j int) bool { return goals[i].Deadline.Before(goals[j].Deadline) })
+	return goals, nil
+}
@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+type importRepository struct {
+	mu       sync.Mutex
+	nextID   int
+	sessions map[int]*repository.ImportSession
+	chunks   map[int]map[int][]byte
+}
+
+func newImportRepository() *importRepository {
+	return &importRepository{nextID: 1, sessions: make(map[int]*repository.ImportSession), chunks: make(map[int]map[int][]byte)}
+}
+
+func (r *importRepository) CreateImportSession(session *repository.ImportSession) (*repository.ImportSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session.ID = r.nextID
+	r.nextID++
+	session.Status = repository.ImportOpen
+	session.CreatedAt = time.Now()
+	session.UpdatedAt = session.CreatedAt
+	session.ReceivedChunks = []int{}
+	stored := *session
+	r.sessions[session.ID] = &stored
+	r.chunks[session.ID] = make(map[int][]byte)
+	return session, nil
+}
+
+func (r *importRepository) GetImportSession(id int) (*repository.ImportSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[id]
+	if !ok {
+		return nil, repository.ErrImportSessionNotFound
+	}
+	session := *s
+	if s.JobID != nil {
+		jobID := *s.JobID
+		session.JobID = &jobID
+	}
+	session.ReceivedChunks = []int{}
+	for seq := range r.chunks[id] {
+		session.ReceivedChunks = append(session.ReceivedChunks, seq)
+	}
+	sort.Ints(session.ReceivedChunks)
+	return &session, nil
+}
+
+func (r *importRepository) PutImportChunk(sessionID, seq int, data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Like the foreign key it stands in for, a chunk needs its session
+	chunks, ok := r.chunks[sessionID]
+	if !ok {
+		return repository.ErrImportSessionNotFound
+	}
+	chunks[seq] = bytes.Clone(data)
+	return nil
+}
+
+func (r *importRepository) GetImportData(sessionID, count int) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var data bytes.Buffer
+	for seq := 0; seq < count; seq++ {
+		data.Write(r.chunks[sessionID][seq])
+	}
+	return data.Bytes(), nil
+}
+
+func (r *importRepository) CommitImportSession(id, chunkCount int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[id]
+	if !ok || (s.Status != repository.ImportOpen && s.Status != repository.ImportFailed) {
+		return false, nil
+	}
+	s.Status = repository.ImportCommitted
+	s.ChunkCount = chunkCount
+	s.LastError = ""
+	s.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (r *importRepository) SetImportJob(id int, jobID int64) error {
+	return r.update(id, func(s *repository.ImportSession) { s.JobID = &jobID })
+}
+
+func (r *importRepository) SetImportProgress(id, rowsImported int) error {
+	return r.update(id, func(s *repository.ImportSession) { s.RowsImported = rowsImported })
+}
+
+func (r *importRepository) FinishImportSession(id int, status repository.ImportStatus, lastError string) error {
+	return r.update(id, func(s *repository.ImportSession) {
+		s.Status = status
+		s.LastError = lastError
+	})
+}
+
+// update applies fn to the session, if there is one; an UPDATE of a missing row is not an error.
+func (r *importRepository) update(id int, fn func(*repository.ImportSession)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s, ok := r.sessions[id]; ok {
+		fn(s)
+		s.UpdatedAt = time.Now()
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+type jobRepository struct {
+	mu     sync.Mutex
+	nextID int64
+	jobs   map[int64]*repository.Job
+}
+
+func newJobRepository() *jobRepository {
+	return &jobRepository{nextID: 1, jobs: make(map[int64]*repository.Job)}
+}
+
+func (r *jobRepository) CreateJob(job *repository.Job) (*repository.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job.ID = r.nextID
+	r.nextID++
+	job.Status = repository.JobQueued
+	job.CreatedAt = time.Now()
+	job.UpdatedAt = job.CreatedAt
+	if job.RunAt.IsZero() {
+		job.RunAt = job.CreatedAt
+	}
+	stored := *job
+	r.jobs[job.ID] = &stored
+	return job, nil
+}
+
+func (r *jobRepository) GetJob(id int64) (*repository.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	j, ok := r.jobs[id]
+	if !ok {
+		return nil, repository.ErrJobNotFound
+	}
+	job := *j
+	if j.Progress != nil {
+		progress := *j.Progress
+		job.Progress = &progress
+	}
+	return &job, nil
+}
+
+func (r *jobRepository) ClaimNextJob(now time.Time, lease time.Duration) (*repository.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var next *repository.Job
+	for id := int64(1); id < r.nextID; id++ {
+		j := r.jobs[id]
+		if (j.Status != repository.JobQueued && j.Status != repository.JobRunning) || j.RunAt.After(now) {
+			continue
+		}
+		if next == nil || j.RunAt.Before(next.RunAt) {
+			next = j
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	next.Status = repository.JobRunning
+	next.Attempts++
+	next.RunAt = now.Add(lease)
+	next.UpdatedAt = now
+	job := *next
+	return &job, nil
+}
+
+func (r *jobRepository) CompleteJob(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Like the UPDATE it stands in for, an unknown job is not an error
+	j, ok := r.jobs[id]
+	if !ok {
+		return nil
+	}
+	j.Status = repository.JobSucceeded
+	j.LastError = ""
+	j.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *jobRepository) FailJob(id int64, errMsg string, retryAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	j, ok := r.jobs[id]
+	if !ok {
+		return nil
+	}
+	j.LastError = errMsg
+	j.UpdatedAt = time.Now()
+	if retryAt.IsZero() {
+		j.Status = repository.JobDead
+	} else {
+		j.Status = repository.JobQueued
+		j.RunAt = retryAt
+	}
+	return nil
+}
+
+func (r *jobRepository) SetJobProgress(id int64, done, total int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	j, ok := r.jobs[id]
+	if !ok {
+		return nil
+	}
+	j.Progress = &repository.JobProgress{Done: done, Total: total}
+	j.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *jobRepository) CountJobsByStatus() (map[repository.JobStatus]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := make(map[repository.JobStatus]int)
+	for _, j := range r.jobs {
+		counts[j.Status]++
+	}
+	return counts, nil
+}
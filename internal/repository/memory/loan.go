@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+type loanRepository struct {
+	mu          sync.Mutex
+	nextID      int
+	loans       []repository.Loan
+	balanceRepo repository.BalanceRepository
+	users       *userRepository
+}
+
+func newLoanRepository(balanceRepo repository.BalanceRepository, users *userRepository) *loanRepository {
+	return &loanRepository{nextID: 1, balanceRepo: balanceRepo, users: users}
+}
+
+func (r *loanRepository) CreateLoan(loan *repository.Loan) (*repository.Loan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loan.ID = r.nextID
+	r.nextID++
+	loan.CreatedAt = time.Now()
+	r.loans = append(r.loans, *loan)
+
+	if err := r.balanceRepo.UpdateBalance(nil, repository.LedgerSource{Type: repository.LedgerLoan, ID: loan.ID}, loan.LenderID, loan.BorrowerID, loan.Amount); err != nil {
+		return nil, fmt.Errorf("failed to update balance between user %d and %d: %w", loan.LenderID, loan.BorrowerID, err)
+	}
+	r.users.touch(loan.LenderID, loan.BorrowerID)
+	return loan, nil
+}
+
+func (r *loanRepository) GetLoan(id int) (*repository.Loan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, l := range r.loans {
+		if l.ID == id {
+			return &l, nil
+		}
+	}
+	return nil, repository.ErrLoanNotFound
+}
+
+func (r *loanRepository) GetLoansByUserID(userID int) ([]repository.Loan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var loans []repository.Loan
+	for i := len(r.loans) - 1; i >= 0; i-- {
+		if r.loans[i].LenderID == userID || r.loans[i].BorrowerID == userID {
+			loans = append(loans, r.loans[i])
+		}
+	}
+	return loans, nil
+}
+
+func (r *loanRepository) GetLoansDueForReminder(day time.Time) ([]repository.Loan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var loans []repository.Loan
+	for _, l := range r.loans {
+		if l.DueDate != nil && !l.DueDate.After(day) && l.RemindedAt == nil {
+			loans = append(loans, l)
+		}
+	}
+	return loans, nil
+}
+
+func (r *loanRepository) ClaimReminder(id int, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.loans {
+		if r.loans[i].ID == id && r.loans[i].RemindedAt == nil {
+			r.loans[i].RemindedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+type notificationPreferenceRepository struct {
+	mu       sync.Mutex
+	prefs    map[int]*repository.DueDigest
+	settings map[int]repository.NotificationPreferences // Channels and events; the frequency lives in prefs
+	userRepo *userRepository
+}
+
+func newNotificationPreferenceRepository(userRepo *userRepository) *notificationPreferenceRepository {
+	return &notificationPreferenceRepository{
+		prefs:    make(map[int]*repository.DueDigest),
+		settings: make(map[int]repository.NotificationPreferences),
+		userRepo: userRepo,
+	}
+}
+
+// preferences returns the user's preferences. Callers hold r.mu.
+func (r *notificationPreferenceRepository) preferences(userID int) *repository.NotificationPreferences {
+	p, ok := r.settings[userID]
+	if !ok {
+		p = *repository.DefaultNotificationPreferences(userID)
+	}
+	p.DigestFrequency = r.pref(userID).Frequency
+	return &p
+}
+
+func (r *notificationPreferenceRepository) GetPreferences(userID int) (*repository.NotificationPreferences, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.preferences(userID), nil
+}
+
+func (r *notificationPreferenceRepository) SetPreferences(p *repository.NotificationPreferences) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.settings[p.UserID] = *p
+	r.pref(p.UserID).Frequency = p.DigestFrequency
+	return nil
+}
+
+// pref returns the user's row, creating it with the defaults. Callers hold r.mu.
+func (r *notificationPreferenceRepository) pref(userID int) *repository.DueDigest {
+	p, ok := r.prefs[userID]
+	if !ok {
+		p = &repository.DueDigest{UserID: userID, Frequency: repository.DefaultDigestFrequency}
+		r.prefs[userID] = p
+	}
+	return p
+}
+
+func (r *notificationPreferenceRepository) SetDigestFrequency(userID int, frequency repository.DigestFrequency) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pref(userID).Frequency = frequency
+	return nil
+}
+
+func (r *notificationPreferenceRepository) GetDueDigests(now time.Time) ([]repository.DueDigest, error) {
+	r.userRepo.mu.Lock()
+	var ids []int
+	for id := range r.userRepo.users {
+		ids = append(ids, id)
+	}
+	r.userRepo.mu.Unlock()
+	sort.Ints(ids)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []repository.DueDigest
+	for _, id := range ids {
+		p := r.pref(id)
+		if p.Frequency == repository.DigestNever || !r.preferences(id).Events.Digest {
+			continue
+		}
+		if p.LastDigestAt == nil || !p.LastDigestAt.After(now.Add(-p.Frequency.Period())) {
+			due = append(due, *p)
+		}
+	}
+	return due, nil
+}
+
+func (r *notificationPreferenceRepository) ClaimDigest(userID int, last *time.Time, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := r.pref(userID)
+	if (p.LastDigestAt == nil) != (last == nil) || (last != nil && !p.LastDigestAt.Equal(*last)) {
+		return false, nil
+	}
+	p.LastDigestAt = &at
+	return true, nil
+}
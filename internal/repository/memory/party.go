@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+type partyRepository struct {
+	mu      sync.Mutex
+	nextID  int
+	parties []repository.Party
+}
+
+func newPartyRepository() *partyRepository {
+	return &partyRepository{nextID: 1}
+}
+
+func (r *partyRepository) CreateParty(party *repository.Party) (*repository.Party, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range r.parties {
+		if p.Name == party.Name {
+			return nil, fmt.Errorf("%w: %s", repository.ErrPartyNameTaken, party.Name)
+		}
+	}
+	party.ID = r.nextID
+	r.nextID++
+	party.CreatedAt = time.Now()
+	r.parties = append(r.parties, *party)
+	created := *party
+	return &created, nil
+}
+
+func (r *partyRepository) GetParty(id int) (*repository.Party, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range r.parties {
+		if p.ID == id {
+			party := p
+			return &party, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", repository.ErrPartyNotFound, id)
+}
+
+func (r *partyRepository) ListParties() ([]repository.Party, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	parties := append([]repository.Party(nil), r.parties...)
+	sort.Slice(parties, func(i, j int) bool { return parties[i].Name < parties[j].Name })
+	return parties, nil
+}
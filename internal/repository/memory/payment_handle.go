@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+type paymentHandleRepository struct {
+	mu      sync.Mutex
+	handles map[int]repository.PaymentHandles
+}
+
+func newPaymentHandleRepository() *paymentHandleRepository {
+	return &paymentHandleRepository{handles: make(map[int]repository.PaymentHandles)}
+}
+
+func (r *paymentHandleRepository) GetPaymentHandles(userIDs []int) (map[int]repository.PaymentHandles, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	handles := make(map[int]repository.PaymentHandles, len(userIDs))
+	for _, id := range userIDs {
+		if h, ok := r.handles[id]; ok {
+			handles[id] = h
+		}
+	}
+	return handles, nil
+}
+
+func (r *paymentHandleRepository) SetPaymentHandles(handles *repository.PaymentHandles) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.handles[handles.UserID] = *handles
+	return nil
+}
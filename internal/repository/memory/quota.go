@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"time"
+)
+
+// quotaRepository counts usage straight from the other repositories, like the MySQL one does from
+// their tables.
+type quotaRepository struct {
+	expenses *expenseRepository
+	events   *eventRepository
+	imports  *importRepository
+}
+
+func newQuotaRepository(expenses *expenseRepository, events *eventRepository, imports *importRepository) *quotaRepository {
+	return &quotaRepository{expenses: expenses, events: events, imports: imports}
+}
+
+func (r *quotaRepository) CountExpensesCreatedSince(userID int, since time.Time) (int, error) {
+	r.expenses.mu.Lock()
+	defer r.expenses.mu.Unlock()
+
+	count := 0
+	for _, e := range r.expenses.expenses {
+		if e.CreatedBy == userID && !e.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *quotaRepository) CountActiveEvents(userID int) (int, error) {
+	r.events.mu.Lock()
+	defer r.events.mu.Unlock()
+
+	count := 0
+	for _, e := range r.events.events {
+		if e.CreatedBy == userID && e.ArchivedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *quotaRepository) CountImportBytes(userID int) (int64, error) {
+	r.imports.mu.Lock()
+	defer r.imports.mu.Unlock()
+
+	var total int64
+	for id, session := range r.imports.sessions {
+		if session.CreatedBy != userID {
+			continue
+		}
+		for _, data := range r.imports.chunks[id] {
+			total += int64(len(data))
+		}
+	}
+	return total, nil
+}
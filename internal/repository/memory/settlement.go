@@ -0,0 +1,161 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+type settlementRepository struct {
+	mu          sync.Mutex
+	nextID      int
+	settlements map[int]*repository.Settlement
+	balanceRepo *balanceRepository
+	users       *userRepository
+	expenses    *expenseRepository
+}
+
+func newSettlementRepository(balanceRepo *balanceRepository, users *userRepository, expenses *expenseRepository) *settlementRepository {
+	return &settlementRepository{nextID: 1, settlements: make(map[int]*repository.Settlement), balanceRepo: balanceRepo, users: users, expenses: expenses}
+}
+
+func (r *settlementRepository) CreateSettlement(settlement *repository.Settlement) (*repository.Settlement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkPaymentReference(settlement); err != nil {
+		return nil, err
+	}
+	r.insert(settlement)
+	return settlement, nil
+}
+
+func (r *settlementRepository) CreateSettlements(settlements []*repository.Settlement) ([]*repository.Settlement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, settlement := range settlements {
+		if err := r.checkPaymentReference(settlement); err != nil {
+			return nil, err
+		}
+	}
+	for _, settlement := range settlements {
+		r.insert(settlement)
+	}
+	return settlements, nil
+}
+
+func (r *settlementRepository) checkPaymentReference(settlement *repository.Settlement) error {
+	if settlement.PaymentReference == "" {
+		return nil
+	}
+	for _, s := range r.settlements {
+		if s.PaymentProvider == settlement.PaymentProvider && s.PaymentReference == settlement.PaymentReference {
+			return repository.ErrDuplicatePaymentReference
+		}
+	}
+	return nil
+}
+
+func (r *settlementRepository) insert(settlement *repository.Settlement) {
+	settlement.ID = r.nextID
+	r.nextID++
+	settlement.Status = repository.SettlementProposed
+	settlement.CreatedAt = time.Now()
+	settlement.UpdatedAt = settlement.CreatedAt
+	stored := *settlement
+	r.settlements[settlement.ID] = &stored
+}
+
+func (r *settlementRepository) GetSettlement(id int) (*repository.Settlement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.settlements[id]
+	if !ok {
+		return nil, repository.ErrSettlementNotFound
+	}
+	settlement := *s
+	return &settlement, nil
+}
+
+func (r *settlementRepository) GetSettlementsByUserID(userID int) ([]repository.Settlement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var settlements []repository.Settlement
+	for id := r.nextID - 1; id > 0; id-- {
+		if s, ok := r.settlements[id]; ok && (s.PayerID == userID || s.PayeeID == userID || (s.ViaUserID != nil && *s.ViaUserID == userID)) {
+			settlements = append(settlements, *s)
+		}
+	}
+	return settlements, nil
+}
+
+func (r *settlementRepository) GetSettlementByPaymentReference(provider, reference string) (*repository.Settlement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range r.settlements {
+		if s.PaymentProvider == provider && s.PaymentReference == reference {
+			settlement := *s
+			return &settlement, nil
+		}
+	}
+	return nil, repository.ErrSettlementNotFound
+}
+
+func (r *settlementRepository) SetSettlementPayment(id int, provider, reference string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.settlements[id]
+	if !ok {
+		return repository.ErrSettlementNotFound
+	}
+	for _, other := range r.settlements {
+		if other.ID != id && other.PaymentProvider == provider && other.PaymentReference == reference {
+			return repository.ErrDuplicatePaymentReference
+		}
+	}
+	s.PaymentProvider, s.PaymentReference = provider, reference
+	return nil
+}
+
+func (r *settlementRepository) TransitionSettlement(id int, from []repository.SettlementStatus, to repository.SettlementStatus) (*repository.Settlement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.settlements[id]
+	if !ok {
+		return nil, repository.ErrSettlementNotFound
+	}
+
+	allowed := false
+	for _, status := range from {
+		allowed = allowed || status == s.Status
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: cannot move from %s to %s", repository.ErrInvalidSettlementTransition, s.Status, to)
+	}
+
+	if to == repository.SettlementConfirmed {
+		if err := r.balanceRepo.UpdateBalances(nil, repository.LedgerSource{Type: repository.LedgerSettlement, ID: s.ID}, s.BalanceUpdates()); err != nil {
+			return nil, fmt.Errorf("failed to update balances for settlement %d: %w", s.ID, err)
+		}
+		r.users.touch(s.UserIDs()...)
+	}
+
+	s.Status = to
+	s.UpdatedAt = time.Now()
+	if to == repository.SettlementConfirmed {
+		for _, u := range s.BalanceUpdates() {
+			entries := r.balanceRepo.pairEntries(u.User1ID, u.User2ID)
+			r.expenses.lock(repository.CoveredExpenses(entries, repository.LedgerSource{Type: repository.LedgerSettlement, ID: s.ID}), s.ID, s.UpdatedAt)
+		}
+	}
+	settlement := *s
+	return &settlement, nil
+}
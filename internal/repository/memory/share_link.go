@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+type shareLinkRepository struct {
+	mu     sync.Mutex
+	nextID int
+	links  []repository.ShareLink
+}
+
+func newShareLinkRepository() *shareLinkRepository {
+	return &shareLinkRepository{nextID: 1}
+}
+
+func (r *shareLinkRepository) CreateShareLink(link *repository.ShareLink) (*repository.ShareLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	link.ID = r.nextID
+	r.nextID++
+	link.CreatedAt = time.Now()
+	r.links = append(r.links, *link)
+	created := *link
+	return &created, nil
+}
+
+func (r *shareLinkRepository) GetShareLink(id int) (*repository.ShareLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, l := range r.links {
+		if l.ID == id {
+			link := l
+			return &link, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", repository.ErrShareLinkNotFound, id)
+}
+
+func (r *shareLinkRepository) RevokeShareLink(id int) (*repository.ShareLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.links {
+		if r.links[i].ID == id {
+			if r.links[i].RevokedAt == nil {
+				now := time.Now()
+				r.links[i].RevokedAt = &now
+			}
+			link := r.links[i]
+			return &link, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", repository.ErrShareLinkNotFound, id)
+}
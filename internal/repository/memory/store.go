@@ -0,0 +1,84 @@
+// Package memory implements every repository interface in process memory. The repositories are
+// safe for concurrent use and return the same errors as their MySQL counterparts, so the service
+// can run without a database: for demos, tests, or embedded in another Go program. Nothing survives
+// a restart.
+package memory
+
+import (
+	"context"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+// Options configures a Store.
+type Options struct {
+	// RecordExpenseEvents makes the expense repository append to the expense event store, like
+	// repository.NewEventSourcedExpenseRepository does.
+	RecordExpenseEvents bool
+}
+
+// Store holds one set of in-memory repositories that share their data the way the MySQL ones
+// share a database.
+type Store struct {
+	Users          repository.UserRepository
+	Balances       repository.BalanceRepository
+	Ledger         repository.LedgerRepository
+	Expenses       repository.ExpenseRepository
+	ExpenseEvents  repository.ExpenseEventRepository
+	Loans          repository.LoanRepository
+	Settlements    repository.SettlementRepository
+	Audit          repository.AuditRepository
+	Jobs           repository.JobRepository
+	Budgets        repository.BudgetRepository
+	Goals          repository.GoalRepository
+	Parties        repository.PartyRepository
+	Events         repository.EventRepository
+	ShareLinks     repository.ShareLinkRepository
+	Preferences    repository.NotificationPreferenceRepository
+	PaymentHandles repository.PaymentHandleRepository
+	Imports        repository.ImportRepository
+	Quotas         repository.QuotaRepository
+	TagRules       repository.TagRuleRepository
+	Currencies     repository.CurrencyRepository
+}
+
+// NewStore returns an empty store.
+func NewStore(opts Options) *Store {
+	users := newUserRepository()
+	balances := newBalanceRepository()
+	expenses := newExpenseRepository(balances, users)
+	expenseEvents := newExpenseEventRepository(expenses)
+	events := newEventRepository(expenses)
+	imports := newImportRepository()
+	if opts.RecordExpenseEvents {
+		expenses.events = expenseEvents
+	}
+
+	return &Store{
+		Users:          users,
+		Balances:       balances,
+		Ledger:         balances,
+		Expenses:       expenses,
+		ExpenseEvents:  expenseEvents,
+		Loans:          newLoanRepository(balances, users),
+		Settlements:    newSettlementRepository(balances, users, expenses),
+		Audit:          newAuditRepository(),
+		Jobs:           newJobRepository(),
+		Budgets:        newBudgetRepository(expenses),
+		Goals:          newGoalRepository(),
+		Parties:        newPartyRepository(),
+		Events:         events,
+		ShareLinks:     newShareLinkRepository(),
+		Preferences:    newNotificationPreferenceRepository(users),
+		PaymentHandles: newPaymentHandleRepository(),
+		Imports:        imports,
+		Quotas:         newQuotaRepository(expenses, events, imports),
+		TagRules:       newTagRuleRepository(),
+		Currencies:     currencyRepository{},
+	}
+}
+
+// PingContext always succeeds, so a Store can stand in for the database in health checks.
+func (s *Store) PingContext(ctx context.Context) error {
+	return nil
+}
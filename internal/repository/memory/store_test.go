@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_ErrorSemantics(t *testing.T) {
+	s := NewStore(Options{})
+
+	// Test case 1: Emails are unique regardless of case
+	alice, err := s.Users.CreateUser(&repository.User{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	_, err = s.Users.CreateUser(&repository.User{Name: "Alice", Email: "ALICE@example.com"})
+	assert.ErrorIs(t, err, repository.ErrEmailTaken)
+
+	// Test case 2: Missing records come back as the same sentinels the MySQL repositories use
+	_, err = s.Expenses.GetExpense(42)
+	assert.ErrorIs(t, err, repository.ErrExpenseNotFound)
+	assert.ErrorIs(t, s.Expenses.DeleteExpense(42, nil), repository.ErrExpenseNotFound)
+	_, err = s.Settlements.GetSettlement(42)
+	assert.ErrorIs(t, err, repository.ErrSettlementNotFound)
+
+	// Test case 3: An expense can only move out of the status it is in
+	bob, err := s.Users.CreateUser(&repository.User{Name: "Bob", Email: "bob@example.com"})
+	require.NoError(t, err)
+	expense, err := s.Expenses.CreateExpense(
+		&repository.Expense{Description: "Dinner", TotalAmount: 30, CreatedBy: alice.ID},
+		[]repository.ExpenseSplit{{UserID: alice.ID, AmountPaid: 30, AmountOwed: 15}, {UserID: bob.ID, AmountOwed: 15}},
+		[]repository.BalanceUpdate{{User1ID: alice.ID, User2ID: bob.ID, Amount: 15}},
+	)
+	require.NoError(t, err)
+	_, err = s.Expenses.TransitionExpense(expense.ID, repository.ExpenseDisputed, repository.ExpenseActive, "")
+	assert.ErrorIs(t, err, repository.ErrInvalidExpenseTransition)
+
+	// Test case 4: What a caller gets back is a copy, not the stored expense
+	got, err := s.Expenses.GetExpense(expense.ID)
+	require.NoError(t, err)
+	got.Description = "Changed"
+	got, err = s.Expenses.GetExpense(expense.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Dinner", got.Description)
+
+	// Test case 5: Balances and the ledger move together
+	drifts, err := s.Ledger.CheckBalances()
+	require.NoError(t, err)
+	assert.Empty(t, drifts)
+
+	// Test case 6: A confirmed settlement locks the expenses behind the pair's balance until unlocked
+	settlement, err := s.Settlements.CreateSettlement(&repository.Settlement{PayerID: bob.ID, PayeeID: alice.ID, Amount: 15})
+	require.NoError(t, err)
+	_, err = s.Settlements.TransitionSettlement(settlement.ID, []repository.SettlementStatus{repository.SettlementProposed}, repository.SettlementConfirmed)
+	require.NoError(t, err)
+	locked, err := s.Expenses.GetExpense(expense.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, locked.LockedAt)
+	assert.Equal(t, &settlement.ID, locked.SettledBy)
+	_, err = s.Expenses.TransitionExpense(expense.ID, repository.ExpenseActive, repository.ExpenseDisputed, "Wrong amount")
+	assert.ErrorIs(t, err, repository.ErrExpenseLocked)
+	assert.ErrorIs(t, s.Expenses.DeleteExpense(expense.ID, nil), repository.ErrExpenseLocked)
+	unlocked, err := s.Expenses.UnlockExpense(expense.ID)
+	require.NoError(t, err)
+	assert.Nil(t, unlocked.LockedAt)
+	assert.Equal(t, &settlement.ID, unlocked.SettledBy)
+	_, err = s.Expenses.UnlockExpense(expense.ID)
+	assert.ErrorIs(t, err, repository.ErrExpenseNotLocked)
+
+	// Test case 7: Always reachable
+	assert.NoError(t, s.PingContext(t.Context()))
+}
+
+func TestStore_RebuildProjections(t *testing.T) {
+	s := NewStore(Options{RecordExpenseEvents: true})
+	alice, err := s.Users.CreateUser(&repository.User{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	bob, err := s.Users.CreateUser(&repository.User{Name: "Bob", Email: "bob@example.com"})
+	require.NoError(t, err)
+	create := func(description string) *repository.Expense {
+		expense, err := s.Expenses.CreateExpense(
+			&repository.Expense{Description: description, TotalAmount: 10, CreatedBy: alice.ID},
+			[]repository.ExpenseSplit{{UserID: alice.ID, AmountPaid: 10, AmountOwed: 5}, {UserID: bob.ID, AmountOwed: 5}},
+			[]repository.BalanceUpdate{{User1ID: alice.ID, User2ID: bob.ID, Amount: 5}},
+		)
+		require.NoError(t, err)
+		return expense
+	}
+	kept, disputed, deleted := create("Kept"), create("Disputed"), create("Deleted")
+	_, err = s.Expenses.TransitionExpense(disputed.ID, repository.ExpenseActive, repository.ExpenseDisputed, "wrong amount")
+	require.NoError(t, err)
+	require.NoError(t, s.Expenses.DeleteExpense(deleted.ID, []repository.BalanceUpdate{{User1ID: alice.ID, User2ID: bob.ID, Amount: -5}}))
+
+	// Test case 1: Every change was recorded
+	events, err := s.ExpenseEvents.GetExpenseEvents(disputed.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, repository.ExpenseStatusChangedEvent, events[1].Type)
+
+	// Test case 2: Projections that match their events are left alone
+	report, err := s.ExpenseEvents.RebuildProjections()
+	require.NoError(t, err)
+	assert.Equal(t, &repository.ProjectionReport{Events: 5, Expenses: 3}, report)
+
+	// Test case 3: Projections that drifted are brought back in line
+	expenses := s.Expenses.(*expenseRepository)
+	expenses.mu.Lock()
+	expenses.remove(kept.ID)
+	expenses.find(disputed.ID).Status = repository.ExpenseActive
+	expenses.mu.Unlock()
+	report, err = s.ExpenseEvents.RebuildProjections()
+	require.NoError(t, err)
+	assert.Equal(t, &repository.ProjectionReport{Events: 5, Expenses: 3, Restored: 1, Updated: 1}, report)
+
+	restored, err := s.Expenses.GetExpense(kept.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Kept", restored.Description)
+	splits, err := s.Expenses.GetExpenseSplits(kept.ID)
+	require.NoError(t, err)
+	assert.Len(t, splits, 2)
+	got, err := s.Expenses.GetExpense(disputed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, repository.ExpenseDisputed, got.Status)
+	_, err = s.Expenses.GetExpense(deleted.ID)
+	assert.ErrorIs(t, err, repository.ErrExpenseNotFound)
+}
@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+type tagRuleRepository struct {
+	mu     sync.Mutex
+	nextID int
+	rules  []repository.TagRule
+}
+
+func newTagRuleRepository() *tagRuleRepository {
+	return &tagRuleRepository{nextID: 1}
+}
+
+func (r *tagRuleRepository) CreateTagRule(rule *repository.TagRule) (*repository.TagRule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.rules {
+		if existing.Keyword == rule.Keyword {
+			return nil, fmt.Errorf("%w: %s", repository.ErrTagRuleKeywordTaken, rule.Keyword)
+		}
+	}
+	rule.ID = r.nextID
+	r.nextID++
+	rule.CreatedAt = time.Now()
+	r.rules = append(r.rules, *rule)
+	created := *rule
+	return &created, nil
+}
+
+func (r *tagRuleRepository) ListTagRules() ([]repository.TagRule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rules := append([]repository.TagRule(nil), r.rules...)
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Keyword < rules[j].Keyword })
+	return rules, nil
+}
+
+func (r *tagRuleRepository) DeleteTagRule(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, rule := range r.rules {
+		if rule.ID == id {
+			r.rules = append(r.rules[:i], r.rules[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %d", repository.ErrTagRuleNotFound, id)
+}
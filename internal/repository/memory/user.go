@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+type userRepository struct {
+	mu           sync.Mutex
+	nextID       int
+	users        map[int]*repository.User
+	lastModified map[int]time.Time
+}
+
+func newUserRepository() *userRepository {
+	return &userRepository{nextID: 1, users: make(map[int]*repository.User), lastModified: make(map[int]time.Time)}
+}
+
+func (r *userRepository) CreateUser(user *repository.User) (*repository.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if strings.EqualFold(u.Email, user.Email) {
+			return nil, fmt.Errorf("%w: %s", repository.ErrEmailTaken, user.Email)
+		}
+		if user.PublicID != "" && u.PublicID == user.PublicID {
+			return nil, fmt.Errorf("%w: %s", repository.ErrPublicIDTaken, user.PublicID)
+		}
+	}
+
+	if user.SplitWeight == 0 {
+		user.SplitWeight = repository.DefaultSplitWeight
+	}
+	user.ID = r.nextID
+	r.nextID++
+	stored := *user
+	r.users[user.ID] = &stored
+	r.lastModified[user.ID] = time.Now().Truncate(time.Second)
+	return user, nil
+}
+
+func (r *userRepository) GetOrCreateUser(user *repository.User) (*repository.User, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if strings.EqualFold(u.Email, user.Email) {
+			existing := *u
+			return &existing, false, nil
+		}
+	}
+	for _, u := range r.users {
+		if user.PublicID != "" && u.PublicID == user.PublicID {
+			return nil, false, fmt.Errorf("%w: %s", repository.ErrPublicIDTaken, user.PublicID)
+		}
+	}
+
+	if user.SplitWeight == 0 {
+		user.SplitWeight = repository.DefaultSplitWeight
+	}
+	user.ID = r.nextID
+	r.nextID++
+	stored := *user
+	r.users[user.ID] = &stored
+	r.lastModified[user.ID] = time.Now().Truncate(time.Second)
+	return user, true, nil
+}
+
+func (r *userRepository) GetUser(id int) (*repository.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return nil, fmt.Errorf("user not found")
+	}
+	user := *u
+	return &user, nil
+}
+
+func (r *userRepository) GetUserByPublicID(publicID string) (*repository.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if u.PublicID == publicID {
+			user := *u
+			return &user, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+func (r *userRepository) GetUsersByEmails(emails []string) ([]*repository.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var users []*repository.User
+	var missing []string
+	for _, email := range emails {
+		found := false
+		for _, u := range r.users {
+			if u.Email == email {
+				user := *u
+				users = append(users, &user)
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, email)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("some users not found for emails: %s", strings.Join(missing, ", "))
+	}
+	return users, nil
+}
+
+func (r *userRepository) GetUsersByIDs(ids []int) ([]*repository.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var users []*repository.User
+	var missing []string
+	for _, id := range ids {
+		u, ok := r.users[id]
+		if !ok {
+			missing = append(missing, fmt.Sprintf("%d", id))
+			continue
+		}
+		user := *u
+		users = append(users, &user)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("some users not found for IDs: %s", strings.Join(missing, ", "))
+	}
+	return users, nil
+}
+
+func (r *userRepository) UpdateSplitWeight(id int, weight float64) (*repository.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return nil, fmt.Errorf("user not found")
+	}
+	u.SplitWeight = weight
+	user := *u
+	return &user, nil
+}
+
+func (r *userRepository) GetLastModified(id int) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lastModified, ok := r.lastModified[id]
+	if !ok {
+		return time.Time{}, fmt.Errorf("user not found")
+	}
+	return lastModified, nil
+}
+
+// touch moves the users' last modified time forward by at least a second, like touchUsers.
+func (r *userRepository) touch(ids ...int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().Truncate(time.Second)
+	for _, id := range ids {
+		if next := r.lastModified[id].Add(time.Second); next.After(now) {
+			r.lastModified[id] = next
+		} else {
+			r.lastModified[id] = now
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type DigestFrequency string
+
+const (
+	DigestDaily  DigestFrequency = "daily"
+	DigestWeekly DigestFrequency = "weekly"
+	DigestNever  DigestFrequency = "never"
+)
+
+// DefaultDigestFrequency applies to users who have never set one.
+const DefaultDigestFrequency = DigestWeekly
+
+// Period is how much time one digest covers; zero for DigestNever.
+func (f DigestFrequency) Period() time.Duration {
+	switch f {
+	case DigestDaily:
+		return 24 * time.Hour
+	case DigestWeekly:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// NotificationChannel is a way of reaching a user.
+type NotificationChannel string
+
+const (
+	ChannelEmail NotificationChannel = "email"
+	ChannelPush  NotificationChannel = "push"
+)
+
+// NotificationKind is what a notification is about.
+type NotificationKind string
+
+const (
+	KindNewExpense NotificationKind = "new_expense"
+	KindReminder   NotificationKind = "reminder"
+	KindDigest     NotificationKind = "digest"
+	// KindDispute has no preference of its own, so disputes always reach the users they hold up.
+	KindDispute NotificationKind = "dispute"
+)
+
+type ChannelPreferences struct {
+	Email bool `json:"email"`
+	Push  bool `json:"push"`
+}
+
+type EventPreferences struct {
+	NewExpense bool `json:"new_expense"`
+	Reminder   bool `json:"reminder"`
+	Digest     bool `json:"digest"`
+}
+
+// NotificationPreferences is what a user wants to hear about and how.
+type NotificationPreferences struct {
+	UserID          int                `json:"-"`
+	Channels        ChannelPreferences `json:"channels"`
+	Events          EventPreferences   `json:"events"`
+	DigestFrequency DigestFrequency    `json:"digest_frequency"`
+}
+
+// DefaultNotificationPreferences applies to users who have never set any: everything on, weekly digests.
+func DefaultNotificationPreferences(userID int) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserID:          userID,
+		Channels:        ChannelPreferences{Email: true, Push: true},
+		Events:          EventPreferences{NewExpense: true, Reminder: true, Digest: true},
+		DigestFrequency: DefaultDigestFrequency,
+	}
+}
+
+// Allows reports whether the user wants notifications of the kind on the channel. Unknown channels
+// and kinds are allowed, so a new kind of notification is not silently dropped.
+func (p *NotificationPreferences) Allows(channel NotificationChannel, kind NotificationKind) bool {
+	switch channel {
+	case ChannelEmail:
+		if !p.Channels.Email {
+			return false
+		}
+	case ChannelPush:
+		if !p.Channels.Push {
+			return false
+		}
+	}
+	switch kind {
+	case KindNewExpense:
+		return p.Events.NewExpense
+	case KindReminder:
+		return p.Events.Reminder
+	case KindDigest:
+		return p.Events.Digest
+	}
+	return true
+}
+
+// DueDigest is a user whose next digest is due. LastDigestAt is nil before their first one.
+type DueDigest struct {
+	UserID       int
+	Frequency    DigestFrequency
+	LastDigestAt *time.Time
+}
+
+type NotificationPreferenceRepository interface {
+	// GetPreferences returns the user's preferences, or the defaults if they have never set any.
+	GetPreferences(userID int) (*NotificationPreferences, error)
+	SetPreferences(prefs *NotificationPreferences) error
+	SetDigestFrequency(userID int, frequency DigestFrequency) error
+	// GetDueDigests returns every user with digests on whose last digest is at least one period older than now.
+	GetDueDigests(now time.Time) ([]DueDigest, error)
+	// ClaimDigest moves the user's last digest time from last to at, and reports false if another
+	// runner got there first, so each digest is sent once however many servers are running.
+	ClaimDigest(userID int, last *time.Time, at time.Time) (bool, error)
+}
+
+type notificationPreferenceRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationPreferenceRepository(db *sql.DB) NotificationPreferenceRepository {
+	return &notificationPreferenceRepository{db: db}
+}
+
+func (r *notificationPreferenceRepository) GetPreferences(userID int) (*NotificationPreferences, error) {
+	query := `
+		SELECT email_enabled, push_enabled, new_expense_enabled, reminder_enabled, digest_enabled, digest_frequency
+		FROM notification_preferences WHERE user_id = ?
+	`
+	p := &NotificationPreferences{UserID: userID}
+	err := r.db.QueryRow(query, userID).Scan(&p.Channels.Email, &p.Channels.Push, &p.Events.NewExpense, &p.Events.Reminder, &p.Events.Digest, &p.DigestFrequency)
+	if err == sql.ErrNoRows {
+		return DefaultNotificationPreferences(userID), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences for user %d: %w", userID, err)
+	}
+	return p, nil
+}
+
+func (r *notificationPreferenceRepository) SetPreferences(p *NotificationPreferences) error {
+	query := `
+		INSERT INTO notification_preferences (user_id, email_enabled, push_enabled, new_expense_enabled, reminder_enabled, digest_enabled, digest_frequency)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			email_enabled = VALUES(email_enabled), push_enabled = VALUES(push_enabled),
+			new_expense_enabled = VALUES(new_expense_enabled), reminder_enabled = VALUES(reminder_enabled),
+			digest_enabled = VALUES(digest_enabled), digest_frequency = VALUES(digest_frequency)
+	`
+	if _, err := r.db.Exec(query, p.UserID, p.Channels.Email, p.Channels.Push, p.Events.NewExpense, p.Events.Reminder, p.Events.Digest, p.DigestFrequency); err != nil {
+		return fmt.Errorf("failed to set notification preferences for user %d: %w", p.UserID, err)
+	}
+	return nil
+}
+
+func (r *notificationPreferenceRepository) SetDigestFrequency(userID int, frequency DigestFrequency) error {
+	query := `
+		INSERT INTO notification_preferences (user_id, digest_frequency) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE digest_frequency = VALUES(digest_frequency)
+	`
+	if _, err := r.db.Exec(query, userID, frequency); err != nil {
+		return fmt.Errorf("failed to set digest frequency for user %d: %w", userID, err)
+	}
+	return nil
+}
+
+func (r *notificationPreferenceRepository) GetDueDigests(now time.Time) ([]DueDigest, error) {
+	query := `
+		SELECT u.id, COALESCE(np.digest_frequency, ?), np.last_digest_at
+		FROM users u
+		LEFT JOIN notification_preferences np ON np.user_id = u.id
+		WHERE COALESCE(np.digest_enabled, TRUE)
+		  AND ((COALESCE(np.digest_frequency, ?) = 'daily' AND (np.last_digest_at IS NULL OR np.last_digest_at <= ?))
+		    OR (COALESCE(np.digest_frequency, ?) = 'weekly' AND (np.last_digest_at IS NULL OR np.last_digest_at <= ?)))
+		ORDER BY u.id
+	`
+
+	rows, err := r.db.Query(query,
+		DefaultDigestFrequency,
+		DefaultDigestFrequency, now.Add(-DigestDaily.Period()),
+		DefaultDigestFrequency, now.Add(-DigestWeekly.Period()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due digests: %w", err)
+	}
+	defer rows.Close()
+
+	var due []DueDigest
+	for rows.Next() {
+		var (
+			d    DueDigest
+			last sql.NullTime
+		)
+		if err := rows.Scan(&d.UserID, &d.Frequency, &last); err != nil {
+			return nil, fmt.Errorf("failed to scan due digest row: %w", err)
+		}
+		if last.Valid {
+			d.LastDigestAt = &last.Time
+		}
+		due = append(due, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over due digest rows: %w", err)
+	}
+
+	return due, nil
+}
+
+func (r *notificationPreferenceRepository) ClaimDigest(userID int, last *time.Time, at time.Time) (bool, error) {
+	// The row is only touched when last_digest_at still holds what the caller saw. MySQL reports 1
+	// affected row for an insert, 2 for a changed update and 0 when nothing changed.
+	query := `
+		INSERT INTO notification_preferences (user_id, last_digest_at) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE last_digest_at = IF(last_digest_at <=> ?, VALUES(last_digest_at), last_digest_at)
+	`
+	var prev sql.NullTime
+	if last != nil {
+		prev = sql.NullTime{Time: *last, Valid: true}
+	}
+
+	result, err := r.db.Exec(query, userID, at, prev)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim digest for user %d: %w", userID, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim digest for user %d: %w", userID, err)
+	}
+	return n > 0, nil
+}
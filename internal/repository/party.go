@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ErrPartyNotFound is returned when an external party ID does not exist.
+var ErrPartyNotFound = errors.New("party not found")
+
+// ErrPartyNameTaken is returned when creating a party whose name is already used.
+var ErrPartyNameTaken = errors.New("party name is already registered")
+
+// Party is someone outside the app, like a landlord or vendor, that an expense can be paid to.
+// Parties never take part in splits, so they have no balances.
+type Party struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PartyRepository interface {
+	CreateParty(party *Party) (*Party, error)
+	GetParty(id int) (*Party, error)
+	// ListParties returns every party, by name.
+	ListParties() ([]Party, error)
+}
+
+type partyRepository struct {
+	db *sql.DB
+}
+
+func NewPartyRepository(db *sql.DB) PartyRepository {
+	return &partyRepository{db: db}
+}
+
+func (r *partyRepository) CreateParty(party *Party) (*Party, error) {
+	query := "INSERT INTO parties (name, created_at) VALUES (?, ?)"
+	party.CreatedAt = time.Now()
+	result, err := r.db.Exec(query, party.Name, party.CreatedAt)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+			return nil, fmt.Errorf("%w: %s", ErrPartyNameTaken, party.Name)
+		}
+		return nil, fmt.Errorf("failed to create party: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID for party: %w", err)
+	}
+	party.ID = int(id)
+
+	return party, nil
+}
+
+func (r *partyRepository) GetParty(id int) (*Party, error) {
+	query := "SELECT id, name, created_at FROM parties WHERE id = ?"
+	p := &Party{}
+	if err := r.db.QueryRow(query, id).Scan(&p.ID, &p.Name, &p.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %d", ErrPartyNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get party: %w", err)
+	}
+	return p, nil
+}
+
+func (r *partyRepository) ListParties() ([]Party, error) {
+	rows, err := r.db.Query("SELECT id, name, created_at FROM parties ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query parties: %w", err)
+	}
+	defer rows.Close()
+
+	var parties []Party
+	for rows.Next() {
+		var p Party
+		if err := rows.Scan(&p.ID, &p.Name, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan party row: %w", err)
+		}
+		parties = append(parties, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over party rows: %w", err)
+	}
+
+	return parties, nil
+}
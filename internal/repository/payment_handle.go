@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// PaymentHandles is where a user can be paid. Empty fields are handles the user has not set.
+type PaymentHandles struct {
+	UserID   int    `json:"user_id"`
+	UPIID    string `json:"upi_id,omitempty"`
+	PayPalMe string `json:"paypal_me,omitempty"`
+	Venmo    string `json:"venmo,omitempty"`
+}
+
+type PaymentHandleRepository interface {
+	// GetPaymentHandles returns the handles of each of the users who have set any, keyed by user ID.
+	GetPaymentHandles(userIDs []int) (map[int]PaymentHandles, error)
+	SetPaymentHandles(handles *PaymentHandles) error
+}
+
+type paymentHandleRepository struct {
+	db *sql.DB
+}
+
+func NewPaymentHandleRepository(db *sql.DB) PaymentHandleRepository {
+	return &paymentHandleRepository{db: db}
+}
+
+func (r *paymentHandleRepository) GetPaymentHandles(userIDs []int) (map[int]PaymentHandles, error) {
+	handles := make(map[int]PaymentHandles, len(userIDs))
+	if len(userIDs) == 0 {
+		return handles, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(userIDs)), ", ")
+	query := "SELECT user_id, upi_id, paypal_me, venmo FROM payment_handles WHERE user_id IN (" + placeholders + ")"
+	args := make([]any, len(userIDs))
+	for i, id := range userIDs {
+		args[i] = id
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payment handles: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			h                  PaymentHandles
+			upi, paypal, venmo sql.NullString
+		)
+		if err := rows.Scan(&h.UserID, &upi, &paypal, &venmo); err != nil {
+			return nil, fmt.Errorf("failed to scan payment handles row: %w", err)
+		}
+		h.UPIID, h.PayPalMe, h.Venmo = upi.String, paypal.String, venmo.String
+		handles[h.UserID] = h
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate payment handles: %w", err)
+	}
+	return handles, nil
+}
+
+func (r *paymentHandleRepository) SetPaymentHandles(h *PaymentHandles) error {
+	query := `
+		INSERT INTO payment_handles (user_id, upi_id, paypal_me, venmo) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE upi_id = VALUES(upi_id), paypal_me = VALUES(paypal_me), venmo = VALUES(venmo)
+	`
+	if _, err := r.db.Exec(query, h.UserID, nullIfEmpty(h.UPIID), nullIfEmpty(h.PayPalMe), nullIfEmpty(h.Venmo)); err != nil {
+		return fmt.Errorf("failed to set payment handles for user %d: %w", h.UserID, err)
+	}
+	return nil
+}
@@ -0,0 +1,548 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrQueryNotRecorded  = errors.New("query not recorded")
+	ErrQueryNotExplained = errors.New("only SELECT statements can be explained")
+)
+
+// maxRecordedQueries caps how many distinct statements are recorded; later ones are not.
+const maxRecordedQueries = 500
+
+// QueryStats is what has been recorded of one statement. Statements are named by their SQL, with
+// lists of placeholders and of identical rows folded, so an IN list of any length is one statement.
+type QueryStats struct {
+	Name    string  `json:"name"`
+	Calls   int64   `json:"calls"`
+	Errors  int64   `json:"errors"`
+	TotalMs float64 `json:"total_ms"`
+	MeanMs  float64 `json:"mean_ms"`
+	MaxMs   float64 `json:"max_ms"`
+	// RowsReturned counts the rows read from a statement's results, or for a write the rows it
+	// changed. A SELECT can examine far more rows than it returns; see RowsExamined.
+	RowsReturned     int64   `json:"rows_returned"`
+	MeanRowsReturned float64 `json:"mean_rows_returned"`
+	// RowsExamined is the server's estimate, from the last EXPLAIN of a SELECT, of the rows one run
+	// examines. The driver doesn't report what a statement actually examined.
+	RowsExamined *int64 `json:"rows_examined,omitempty"`
+	// Explain is the plan the last EXPLAIN of a SELECT gave, a map of column to value per row.
+	Explain     []map[string]any `json:"explain,omitempty"`
+	ExplainedAt *time.Time       `json:"explained_at,omitempty"`
+	// ExplainError is why the last attempt to explain the statement failed, if it did.
+	ExplainError string `json:"explain_error,omitempty"`
+}
+
+// QueryStatsRepository reports on the statements run through a connector wrapped by
+// MetricsConnector.
+type QueryStatsRepository interface {
+	// SlowestQueries lists up to n statements, the slowest on average first.
+	SlowestQueries(n int) ([]QueryStats, error)
+	// ExplainQuery runs EXPLAIN on the named SELECT with the arguments it last ran with and keeps
+	// the plan with its stats.
+	ExplainQuery(name string) ([]map[string]any, error)
+}
+
+// QueryMetrics records how often each statement ran, how long it took and how many rows it returned
+// or changed. It keeps the arguments each statement last ran with so it can be explained; they never
+// leave the process, since they carry user data.
+type QueryMetrics struct {
+	mu      sync.Mutex
+	queries map[string]*queryRecord
+}
+
+type queryRecord struct {
+	stats      QueryStats
+	total, max time.Duration
+	query      string // The SQL as last run, before folding
+	args       []any
+}
+
+func NewQueryMetrics() *QueryMetrics {
+	return &QueryMetrics{queries: make(map[string]*queryRecord)}
+}
+
+// record adds one run of query, which failed if err isn't nil.
+func (m *QueryMetrics) record(query string, args []driver.NamedValue, elapsed time.Duration, rows int64, err error) {
+	name := queryName(query)
+	if strings.HasPrefix(name, "EXPLAIN ") {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec, ok := m.queries[name]
+	if !ok {
+		if len(m.queries) >= maxRecordedQueries {
+			return
+		}
+		rec = &queryRecord{stats: QueryStats{Name: name}}
+		m.queries[name] = rec
+	}
+	rec.stats.Calls++
+	if err != nil {
+		rec.stats.Errors++
+	}
+	rec.stats.RowsReturned += rows
+	rec.total += elapsed
+	rec.max = max(rec.max, elapsed)
+	rec.query = query
+	rec.args = make([]any, len(args))
+	for i, arg := range args {
+		rec.args[i] = arg.Value
+	}
+}
+
+// Slowest lists up to n statements, the slowest on average first.
+func (m *QueryMetrics) Slowest(n int) []QueryStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make([]QueryStats, 0, len(m.queries))
+	for _, rec := range m.queries {
+		s := rec.stats
+		s.TotalMs = milliseconds(rec.total)
+		s.MeanMs = milliseconds(rec.total / time.Duration(s.Calls))
+		s.MaxMs = milliseconds(rec.max)
+		s.MeanRowsReturned = float64(s.RowsReturned) / float64(s.Calls)
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].MeanMs != stats[j].MeanMs {
+			return stats[i].MeanMs > stats[j].MeanMs
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	return stats[:min(n, len(stats))]
+}
+
+// lastRun returns the SQL and arguments the named statement last ran with.
+func (m *QueryMetrics) lastRun(name string) (string, []any, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec, ok := m.queries[name]
+	if !ok {
+		return "", nil, false
+	}
+	return rec.query, rec.args, true
+}
+
+// setExplain keeps the outcome of explaining the named statement. A failed attempt keeps the
+// last plan that was found.
+func (m *QueryMetrics) setExplain(name string, plan []map[string]any, at time.Time, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec, ok := m.queries[name]
+	if !ok {
+		return
+	}
+	if err != nil {
+		rec.stats.ExplainError = err.Error()
+		return
+	}
+	examined := rowsExamined(plan)
+	rec.stats.Explain, rec.stats.ExplainedAt, rec.stats.ExplainError, rec.stats.RowsExamined = plan, &at, "", &examined
+}
+
+// rowsExamined estimates from a MySQL plan how many rows one run of the statement examines. Within a
+// select, MySQL joins in nested loops, so each table is read once for every row that comes out of the
+// tables before it: its rows are multiplied by those, and what is left of its own after filtering
+// feeds the next one. The selects of the statement, told apart by id, add up.
+func rowsExamined(plan []map[string]any) int64 {
+	var total float64
+	prefix := make(map[string]float64)
+	for _, step := range plan {
+		rows, ok := planNumber(step["rows"])
+		if !ok {
+			continue
+		}
+		filtered, ok := planNumber(step["filtered"])
+		if !ok {
+			filtered = 100
+		}
+		id := fmt.Sprint(step["id"])
+		before, ok := prefix[id]
+		if !ok {
+			before = 1
+		}
+		total += before * rows
+		prefix[id] = before * rows * filtered / 100
+	}
+	return int64(total + 0.5)
+}
+
+// planNumber reads a numeric EXPLAIN column, which arrives as a number or as text depending on
+// whether the statement ran prepared.
+func planNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+var placeholderList = regexp.MustCompile(`\?(?:, \?)+`)
+
+// queryName is query with its whitespace collapsed, each list of placeholders folded to "?, ..."
+// and each run of identical parenthesized groups, like the rows of a multi-row INSERT, to the first
+// and ", ...".
+func queryName(query string) string {
+	s := placeholderList.ReplaceAllString(strings.Join(strings.Fields(query), " "), "?, ...")
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		end := -1
+		if s[i] == '(' {
+			end = closingParen(s, i)
+		}
+		if end < 0 {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		group := s[i : end+1]
+		b.WriteString(group)
+		i = end + 1
+		if strings.HasPrefix(s[i:], ", "+group) {
+			for strings.HasPrefix(s[i:], ", "+group) {
+				i += len(group) + 2
+			}
+			b.WriteString(", ...")
+		}
+	}
+	return b.String()
+}
+
+// closingParen returns the index of the parenthesis closing the one at open, or -1 if there is none.
+func closingParen(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+type queryStatsRepository struct {
+	db      *sql.DB
+	metrics *QueryMetrics
+}
+
+// NewQueryStatsRepository reports what metrics recorded, explaining statements on db.
+func NewQueryStatsRepository(db *sql.DB, metrics *QueryMetrics) QueryStatsRepository {
+	return &queryStatsRepository{db: db, metrics: metrics}
+}
+
+func (r *queryStatsRepository) SlowestQueries(n int) ([]QueryStats, error) {
+	return r.metrics.Slowest(n), nil
+}
+
+func (r *queryStatsRepository) ExplainQuery(name string) ([]map[string]any, error) {
+	query, args, ok := r.metrics.lastRun(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrQueryNotRecorded, name)
+	}
+	if !strings.HasPrefix(strings.ToUpper(name), "SELECT ") {
+		return nil, fmt.Errorf("%w: %s", ErrQueryNotExplained, name)
+	}
+
+	plan, err := r.explain(query, args)
+	r.metrics.setExplain(name, plan, time.Now(), err)
+	return plan, err
+}
+
+func (r *queryStatsRepository) explain(query string, args []any) ([]map[string]any, error) {
+	rows, err := r.db.Query("EXPLAIN "+query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain query: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read explain columns: %w", err)
+	}
+	plan := []map[string]any{}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("failed to scan explain row: %w", err)
+		}
+		row := make(map[string]any, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			row[column] = values[i]
+		}
+		plan = append(plan, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over explain rows: %w", err)
+	}
+	return plan, nil
+}
+
+// MetricsConnector wraps a database connector so that every statement run through it is recorded
+// in metrics, timed until its rows are closed.
+func MetricsConnector(connector driver.Connector, metrics *QueryMetrics) driver.Connector {
+	return &metricsConnector{Connector: connector, metrics: metrics}
+}
+
+type metricsConnector struct {
+	driver.Connector
+	metrics *QueryMetrics
+}
+
+func (c *metricsConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsConn{Conn: conn, metrics: c.metrics}, nil
+}
+
+// metricsConn records queries run directly on the connection and through prepared statements,
+// passing every optional driver interface through to the wrapped connection.
+type metricsConn struct {
+	driver.Conn
+	metrics *QueryMetrics
+}
+
+func (c *metricsConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err == driver.ErrSkip {
+		return nil, err
+	}
+	return recordRows(c.metrics, start, query, args, rows, err)
+}
+
+func (c *metricsConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		recordResult(c.metrics, start, query, args, result, err)
+	}
+	return result, err
+}
+
+func (c *metricsConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &metricsStmt{Stmt: stmt, query: query, metrics: c.metrics}, nil
+}
+
+func (c *metricsConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *metricsConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *metricsConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *metricsConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *metricsConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type metricsStmt struct {
+	driver.Stmt
+	query   string
+	metrics *QueryMetrics
+}
+
+func (s *metricsStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValuesToValues(args); err != nil {
+			return nil, err
+		}
+		rows, err = s.Stmt.Query(values)
+	}
+	return recordRows(s.metrics, start, s.query, args, rows, err)
+}
+
+func (s *metricsStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var result driver.Result
+	var err error
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValuesToValues(args); err != nil {
+			return nil, err
+		}
+		result, err = s.Stmt.Exec(values)
+	}
+	recordResult(s.metrics, start, s.query, args, result, err)
+	return result, err
+}
+
+func (s *metricsStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// recordRows records a failed query straight away and a successful one once its rows are closed.
+func recordRows(metrics *QueryMetrics, start time.Time, query string, args []driver.NamedValue, rows driver.Rows, err error) (driver.Rows, error) {
+	if err != nil {
+		metrics.record(query, args, time.Since(start), 0, err)
+		return nil, err
+	}
+	return &metricsRows{Rows: rows, done: func(n int64, err error) {
+		metrics.record(query, args, time.Since(start), n, err)
+	}}, nil
+}
+
+func recordResult(metrics *QueryMetrics, start time.Time, query string, args []driver.NamedValue, result driver.Result, err error) {
+	var n int64
+	if err == nil {
+		n, _ = result.RowsAffected()
+	}
+	metrics.record(query, args, time.Since(start), n, err)
+}
+
+// metricsRows counts the rows read, passing the optional column type interfaces through.
+type metricsRows struct {
+	driver.Rows
+	n    int64
+	err  error
+	done func(n int64, err error)
+}
+
+func (r *metricsRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch {
+	case err == nil:
+		r.n++
+	case err != io.EOF:
+		r.err = err
+	}
+	return err
+}
+
+func (r *metricsRows) Close() error {
+	err := r.Rows.Close()
+	if r.done != nil {
+		r.done(r.n, r.err)
+		r.done = nil
+	}
+	return err
+}
+
+func (r *metricsRows) ColumnTypeDatabaseTypeName(index int) string {
+	if typed, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return typed.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+func (r *metricsRows) ColumnTypeScanType(index int) reflect.Type {
+	if typed, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return typed.ColumnTypeScanType(index)
+	}
+	return reflect.TypeFor[any]()
+}
+
+func (r *metricsRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	if typed, isTyped := r.Rows.(driver.RowsColumnTypeNullable); isTyped {
+		return typed.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+func (r *metricsRows) ColumnTypeLength(index int) (length int64, ok bool) {
+	if typed, isTyped := r.Rows.(driver.RowsColumnTypeLength); isTyped {
+		return typed.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+func (r *metricsRows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	if typed, isTyped := r.Rows.(driver.RowsColumnTypePrecisionScale); isTyped {
+		return typed.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryName(t *testing.T) {
+	assert.Equal(t, "SELECT * FROM users WHERE id = ?", queryName("SELECT *\n\t\tFROM users\n\t\tWHERE id = ?"))
+	assert.Equal(t, "SELECT * FROM users WHERE id IN (?, ...)", queryName("SELECT * FROM users WHERE id IN (?, ?, ?)"))
+	assert.Equal(t, "INSERT INTO splits (a, b) VALUES (?, ...), ...", queryName("INSERT INTO splits (a, b) VALUES (?, ?), (?, ?), (?, ?)"))
+}
+
+func TestMetricsConnector(t *testing.T) {
+	metrics := NewQueryMetrics()
+	db := sql.OpenDB(MetricsConnector(fakeConnector{delay: time.Millisecond}, metrics))
+	defer db.Close()
+
+	// Test case 1: Calls, time and rows are recorded under the statement's name
+	for _, id := range []int{1, 2} {
+		_, err := db.Exec("UPDATE users SET email = ? WHERE id IN (?, ?)", "alice@example.com", id, id+1)
+		require.NoError(t, err)
+	}
+	stats := metrics.Slowest(10)
+	require.Len(t, stats, 1)
+	assert.Equal(t, "UPDATE users SET email = ? WHERE id IN (?, ...)", stats[0].Name)
+	assert.Equal(t, int64(2), stats[0].Calls)
+	assert.Equal(t, int64(2), stats[0].RowsReturned)
+	assert.GreaterOrEqual(t, stats[0].MeanMs, 1.0)
+
+	// Test case 2: Failures are counted, and EXPLAIN statements are left out
+	metrics.record("DELETE FROM jobs WHERE id = ?", nil, 5*time.Millisecond, 0, errors.New("lock wait timeout"))
+	metrics.record("EXPLAIN SELECT * FROM users", nil, time.Second, 1, nil)
+	stats = metrics.Slowest(10)
+	require.Len(t, stats, 2)
+	assert.Equal(t, "DELETE FROM jobs WHERE id = ?", stats[0].Name)
+	assert.Equal(t, int64(1), stats[0].Errors)
+
+	// Test case 3: Only the slowest n are listed
+	assert.Len(t, metrics.Slowest(1), 1)
+}
+
+func TestQueryStatsRepository_ExplainQuery(t *testing.T) {
+	metrics := NewQueryMetrics()
+	metrics.record("DELETE FROM jobs WHERE id = ?", nil, time.Millisecond, 1, nil)
+	repo := NewQueryStatsRepository(nil, metrics)
+
+	_, err := repo.ExplainQuery("SELECT * FROM users")
+	assert.ErrorIs(t, err, ErrQueryNotRecorded)
+
+	_, err = repo.ExplainQuery("DELETE FROM jobs WHERE id = ?")
+	assert.ErrorIs(t, err, ErrQueryNotExplained)
+}
+
+func TestQueryMetrics_RowsExamined(t *testing.T) {
+	metrics := NewQueryMetrics()
+	name := "SELECT * FROM expenses e JOIN expense_splits s ON s.expense_id = e.id WHERE e.created_by = ?"
+	metrics.record(name, nil, time.Millisecond, 3, nil)
+
+	// Test case 1: Nothing is estimated before the statement is explained
+	require.Len(t, metrics.Slowest(1), 1)
+	assert.Nil(t, metrics.Slowest(1)[0].RowsExamined)
+
+	// Test case 2: A joined table is read once per row left from the tables before it, and a
+	// subquery's rows add up; plans read unprepared come back as text
+	metrics.setExplain(name, []map[string]any{
+		{"id": int64(1), "table": "e", "rows": int64(100), "filtered": float64(10)},
+		{"id": int64(1), "table": "s", "rows": int64(5), "filtered": float64(100)},
+		{"id": "2", "table": "u", "rows": "20", "filtered": "100.00"},
+	}, time.Now(), nil)
+	stats := metrics.Slowest(1)[0]
+	require.NotNil(t, stats.RowsExamined)
+	assert.Equal(t, int64(100+10*5+20), *stats.RowsExamined)
+	assert.Equal(t, int64(3), stats.RowsReturned)
+
+	// Test case 3: A failed EXPLAIN keeps the last estimate
+	metrics.setExplain(name, nil, time.Now(), errors.New("connection refused"))
+	assert.Equal(t, int64(170), *metrics.Slowest(1)[0].RowsExamined)
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// QuotaRepository measures what a user has used against their quotas. Usage is counted from the
+// data itself, so deleting an expense or archiving an event gives the room back.
+type QuotaRepository interface {
+	// CountExpensesCreatedSince counts the expenses the user created at or after since.
+	CountExpensesCreatedSince(userID int, since time.Time) (int, error)
+	// CountActiveEvents counts the unarchived events the user created.
+	CountActiveEvents(userID int) (int, error)
+	// CountImportBytes totals the import chunks stored for the user's import sessions.
+	CountImportBytes(userID int) (int64, error)
+}
+
+type quotaRepository struct {
+	db *sql.DB
+}
+
+func NewQuotaRepository(db *sql.DB) QuotaRepository {
+	return &quotaRepository{db: db}
+}
+
+func (r *quotaRepository) CountExpensesCreatedSince(userID int, since time.Time) (int, error) {
+	var count int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM expenses WHERE created_by = ? AND created_at >= ?", userID, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count expenses created by user %d: %w", userID, err)
+	}
+	return count, nil
+}
+
+func (r *quotaRepository) CountActiveEvents(userID int) (int, error) {
+	var count int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM events WHERE created_by = ? AND archived_at IS NULL", userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count events created by user %d: %w", userID, err)
+	}
+	return count, nil
+}
+
+func (r *quotaRepository) CountImportBytes(userID int) (int64, error) {
+	var total int64
+	query := `
+		SELECT COALESCE(SUM(LENGTH(c.data)), 0)
+		FROM import_chunks c
+		JOIN import_sessions s ON s.id = c.session_id
+		WHERE s.created_by = ?`
+	if err := r.db.QueryRow(query, userID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to total import uploads of user %d: %w", userID, err)
+	}
+	return total, nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	mysqlLockWaitTimeout = 1205
+	mysqlDeadlock        = 1213
+)
+
+// retryPolicy bounds how often a transaction is rerun after a transient failure. The wait doubles
+// after every attempt.
+type retryPolicy struct {
+	attempts int
+	backoff  time.Duration
+}
+
+// transientRetry is the policy for write transactions. It is a variable so tests can skip the waits.
+var transientRetry = retryPolicy{attempts: 3, backoff: 50 * time.Millisecond}
+
+// isTransient reports whether err is safe to retry because the database guarantees nothing was
+// applied: MySQL rolls back the whole transaction on a deadlock, and the driver only returns
+// ErrBadConn before a statement reached the server. Lock wait timeouts are not retried: they
+// enforce the query timeout, and waiting again would run past it.
+func isTransient(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlDeadlock
+	}
+	return errors.Is(err, driver.ErrBadConn)
+}
+
+// withRetry runs fn, running it again under transientRetry while it fails with a transient error.
+// fn must do all its work in one transaction so a rerun starts from a clean slate.
+func withRetry[T any](op string, fn func() (T, error)) (T, error) {
+	backoff := transientRetry.backoff
+	for attempt := 1; ; attempt++ {
+		result, err := fn()
+		if err == nil || attempt >= transientRetry.attempts || !isTransient(err) {
+			return result, err
+		}
+		log.Printf("%s failed on attempt %d, retrying in %s: %v", op, attempt, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
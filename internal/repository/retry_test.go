@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRetry(t *testing.T) {
+	defer func(p retryPolicy) { transientRetry = p }(transientRetry)
+	transientRetry = retryPolicy{attempts: 3}
+
+	deadlock := fmt.Errorf("failed to update balance: %w", &mysql.MySQLError{Number: mysqlDeadlock})
+
+	// Test case 1: A deadlock is retried until the transaction goes through
+	calls := 0
+	result, err := withRetry("test", func() (int, error) {
+		calls++
+		if calls < 3 {
+			return 0, deadlock
+		}
+		return 42, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 42, result)
+	assert.Equal(t, 3, calls)
+
+	// Test case 2: Attempts run out
+	calls = 0
+	_, err = withRetry("test", func() (int, error) {
+		calls++
+		return 0, driver.ErrBadConn
+	})
+	assert.ErrorIs(t, err, driver.ErrBadConn)
+	assert.Equal(t, 3, calls)
+
+	// Test case 3: Other errors are returned straight away
+	calls = 0
+	_, err = withRetry("test", func() (int, error) {
+		calls++
+		return 0, &mysql.MySQLError{Number: mysqlDuplicateEntry}
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+	assert.False(t, isTransient(errors.New("insufficient balance")))
+	assert.False(t, isTransient(&mysql.MySQLError{Number: mysqlLockWaitTimeout}))
+}
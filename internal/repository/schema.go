@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var ErrSchemaOutOfDate = errors.New("database schema is out of date")
+
+// expectedSchema lists every table and column the repositories rely on. Keep it in step with db/migrations.
+var expectedSchema = map[string][]string{
+	"users":                    {"id", "public_id", "name", "email", "split_weight", "created_at", "last_modified_at"},
+	"expenses":                 {"id", "public_id", "description", "total_amount", "tag", "created_by", "created_at", "status", "dispute_reason", "currency", "refund_of", "payee_party_id", "event_id", "balance_strategy", "original_currency", "original_amount", "exchange_rate", "paid_adjustment", "owed_adjustment", "locked_at", "settled_by"},
+	"expense_splits":           {"id", "expense_id", "user_id", "amount_paid", "amount_owed"},
+	"balances":                 {"user1_id", "user2_id", "balance", "last_updated"},
+	"loans":                    {"id", "lender_id", "borrower_id", "amount", "description", "due_date", "reminded_at", "created_at"},
+	"settlements":              {"id", "payer_id", "payee_id", "via_user_id", "amount", "outstanding_amount", "via_outstanding_amount", "status", "payment_provider", "payment_reference", "created_at", "updated_at"},
+	"audit_logs":               {"id", "actor", "method", "route", "path", "payload_hash", "status", "latency_ms", "created_at"},
+	"jobs":                     {"id", "type", "payload", "status", "attempts", "max_attempts", "last_error", "progress_done", "progress_total", "run_at", "created_at", "updated_at"},
+	"tag_budgets":              {"user_id", "tag", "currency", "monthly_limit", "updated_at"},
+	"goals":                    {"id", "user_id", "target_balance", "starting_balance", "deadline", "created_at"},
+	"parties":                  {"id", "name", "created_at"},
+	"expense_locations":        {"expense_id", "latitude", "longitude", "place_name", "location"},
+	"events":                   {"id", "name", "created_by", "base_currency", "unit", "auto_settle", "archived_at", "last_settle_up_at", "created_at"},
+	"share_links":              {"id", "event_id", "created_by", "expires_at", "revoked_at", "created_at"},
+	"expense_share_claims":     {"expense_id", "user_id", "claimed_at"},
+	"event_members":            {"event_id", "user_id", "joined_at"},
+	"notification_preferences": {"user_id", "digest_frequency", "last_digest_at", "updated_at", "email_enabled", "push_enabled", "new_expense_enabled", "reminder_enabled", "digest_enabled"},
+	"payment_handles":          {"user_id", "upi_id", "paypal_me", "venmo", "updated_at"},
+	"ledger_entries":           {"id", "source_type", "source_id", "user_id", "counterparty_id", "amount", "created_at"},
+	"expense_events":           {"id", "expense_id", "type", "data", "created_at"},
+	"import_sessions":          {"id", "created_by", "status", "chunk_count", "rows_imported", "job_id", "last_error", "created_at", "updated_at"},
+	"import_chunks":            {"session_id", "seq", "data"},
+	"tag_rules":                {"id", "keyword", "tag", "created_at"},
+	"currencies":               {"code", "exponent"},
+}
+
+// VerifySchema checks that the connected database has every table and column the repositories
+// query, so a missed migration fails start-up instead of the first request that needs it.
+func VerifySchema(db *sql.DB) error {
+	rows, err := db.Query("SELECT table_name, column_name FROM information_schema.columns WHERE table_schema = DATABASE()")
+	if err != nil {
+		return fmt.Errorf("failed to read database schema: %w", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]map[string]bool)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return fmt.Errorf("failed to scan schema column: %w", err)
+		}
+		if existing[table] == nil {
+			existing[table] = make(map[string]bool)
+		}
+		existing[table][strings.ToLower(column)] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating over schema columns: %w", err)
+	}
+
+	if missing := missingSchemaObjects(existing); len(missing) > 0 {
+		return fmt.Errorf("%w, run the migrations in db/migrations; missing: %s", ErrSchemaOutOfDate, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// missingSchemaObjects lists the expected tables, and columns of present tables, that are not in existing.
+func missingSchemaObjects(existing map[string]map[string]bool) []string {
+	var missing []string
+	for table, columns := range expectedSchema {
+		have, ok := existing[table]
+		if !ok {
+			missing = append(missing, "table "+table)
+			continue
+		}
+		for _, column := range columns {
+			if !have[column] {
+				missing = append(missing, "column "+table+"."+column)
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMissingSchemaObjects(t *testing.T) {
+	existing := make(map[string]map[string]bool)
+	for table, columns := range expectedSchema {
+		existing[table] = make(map[string]bool)
+		for _, column := range columns {
+			existing[table][column] = true
+		}
+	}
+
+	// Test case 1: Fully migrated
+	assert.Empty(t, missingSchemaObjects(existing))
+
+	// Test case 2: A table and a column are missing
+	delete(existing, "jobs")
+	delete(existing["expenses"], "refund_of")
+	assert.Equal(t, []string{"column expenses.refund_of", "table jobs"}, missingSchemaObjects(existing))
+}
@@ -0,0 +1,334 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/util"
+	"github.com/go-sql-driver/mysql"
+)
+
+// SettlementStatus is the lifecycle state of a settle-up transfer.
+type SettlementStatus string
+
+const (
+	SettlementProposed  SettlementStatus = "proposed"
+	SettlementSent      SettlementStatus = "sent"
+	SettlementConfirmed SettlementStatus = "confirmed"
+	SettlementDisputed  SettlementStatus = "disputed"
+	// SettlementProcessing is a settlement being paid in-app, waiting for the provider to report
+	// whether the payment went through.
+	SettlementProcessing SettlementStatus = "processing"
+)
+
+var (
+	ErrSettlementNotFound          = errors.New("settlement not found")
+	ErrDuplicatePaymentReference   = errors.New("a settlement already records this payment")
+	ErrInvalidSettlementTransition = errors.New("invalid settlement status transition")
+)
+
+type Settlement struct {
+	ID      int     `json:"id"`
+	PayerID int     `json:"payer_id"`
+	PayeeID int     `json:"payee_id"`
+	Amount  float64 `json:"amount"`
+	// OutstandingAmount is what the payer owed the payee, or on a routed settlement the via user,
+	// when the settlement was proposed, which the amount may pay only part of. It is nil when the
+	// payer owed nothing.
+	OutstandingAmount *float64         `json:"outstanding_amount,omitempty"`
+	Status            SettlementStatus `json:"status"`
+	// ViaUserID routes the settlement through a third user: the payer pays the via user's debt to the
+	// payee, which the via user then owes the payer, and confirming it adjusts both pairs at once.
+	// ViaOutstandingAmount is what the via user owed the payee when it was proposed.
+	ViaUserID            *int     `json:"via_user_id,omitempty"`
+	ViaOutstandingAmount *float64 `json:"via_outstanding_amount,omitempty"`
+	// PaymentProvider and PaymentReference identify the payment behind a settlement recorded from a
+	// payment app or provider. Each payment can be recorded once.
+	PaymentProvider  string    `json:"payment_provider,omitempty"`
+	PaymentReference string    `json:"payment_reference,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type SettlementRepository interface {
+	CreateSettlement(settlement *Settlement) (*Settlement, error)
+	// CreateSettlements proposes several settlements at once; either all of them are created or none.
+	CreateSettlements(settlements []*Settlement) ([]*Settlement, error)
+	GetSettlement(id int) (*Settlement, error)
+	// GetSettlementsByUserID lists the settlements the user pays, receives or is routed through.
+	GetSettlementsByUserID(userID int) ([]Settlement, error)
+	// GetSettlementByPaymentReference returns the settlement recording the provider's payment.
+	GetSettlementByPaymentReference(provider, reference string) (*Settlement, error)
+	// SetSettlementPayment records the provider's payment behind a settlement.
+	SetSettlementPayment(id int, provider, reference string) error
+	// TransitionSettlement moves a settlement from one of the given states to the target state.
+	// Moving to confirmed applies the payment to the pair's balance in the same transaction, and
+	// locks the expenses that moved it.
+	TransitionSettlement(id int, from []SettlementStatus, to SettlementStatus) (*Settlement, error)
+}
+
+type settlementRepository struct {
+	db          *sql.DB
+	balanceRepo BalanceRepository
+}
+
+func NewSettlementRepository(db *sql.DB, balanceRepo BalanceRepository) SettlementRepository {
+	return &settlementRepository{db: db, balanceRepo: balanceRepo}
+}
+
+// settlementColumns is what scanSettlement reads, in order.
+const settlementColumns = "id, payer_id, payee_id, via_user_id, amount, outstanding_amount, via_outstanding_amount, status, payment_provider, payment_reference, created_at, updated_at"
+
+func scanSettlement(row interface{ Scan(...any) error }, s *Settlement) error {
+	var (
+		viaUserID           sql.NullInt64
+		outstanding         sql.NullFloat64
+		viaOutstanding      sql.NullFloat64
+		provider, reference sql.NullString
+	)
+	if err := row.Scan(&s.ID, &s.PayerID, &s.PayeeID, &viaUserID, &s.Amount, &outstanding, &viaOutstanding, &s.Status, &provider, &reference, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return err
+	}
+	if viaUserID.Valid {
+		id := int(viaUserID.Int64)
+		s.ViaUserID = &id
+	}
+	if outstanding.Valid {
+		s.OutstandingAmount = &outstanding.Float64
+	}
+	if viaOutstanding.Valid {
+		s.ViaOutstandingAmount = &viaOutstanding.Float64
+	}
+	s.PaymentProvider, s.PaymentReference = provider.String, reference.String
+	return nil
+}
+
+// BalanceUpdates is what confirming the settlement does to balances: the payer's debt to the payee
+// goes down by the amount, or for a routed settlement the payer's debt to the via user and the via
+// user's debt to the payee both do.
+func (s *Settlement) BalanceUpdates() []BalanceUpdate {
+	if s.ViaUserID == nil {
+		return []BalanceUpdate{{User1ID: s.PayerID, User2ID: s.PayeeID, Amount: s.Amount}}
+	}
+	return []BalanceUpdate{
+		{User1ID: s.PayerID, User2ID: *s.ViaUserID, Amount: s.Amount},
+		{User1ID: *s.ViaUserID, User2ID: s.PayeeID, Amount: s.Amount},
+	}
+}
+
+// RemainingAmount is what the payer still owes the payee, or on a routed settlement the via user,
+// once the settlement is paid, never below zero. It is nil when the settlement has no outstanding
+// amount on record.
+func (s *Settlement) RemainingAmount() *float64 {
+	return remainingAfter(s.OutstandingAmount, s.Amount)
+}
+
+// ViaRemainingAmount is what the via user of a routed settlement still owes the payee once it is
+// paid, never below zero, or nil when nothing was on record.
+func (s *Settlement) ViaRemainingAmount() *float64 {
+	return remainingAfter(s.ViaOutstandingAmount, s.Amount)
+}
+
+func remainingAfter(outstanding *float64, amount float64) *float64 {
+	if outstanding == nil {
+		return nil
+	}
+	exp := util.DefaultExponent
+	remaining := util.FromMinorUnits(max(0, util.ToMinorUnits(*outstanding, exp)-util.ToMinorUnits(amount, exp)), exp)
+	return &remaining
+}
+
+// UserIDs lists the payer, the payee and the via user of a routed settlement.
+func (s *Settlement) UserIDs() []int {
+	if s.ViaUserID == nil {
+		return []int{s.PayerID, s.PayeeID}
+	}
+	return []int{s.PayerID, s.PayeeID, *s.ViaUserID}
+}
+
+func nullIfEmpty(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func (r *settlementRepository) CreateSettlement(settlement *Settlement) (*Settlement, error) {
+	return insertSettlement(r.db, settlement)
+}
+
+func (r *settlementRepository) CreateSettlements(settlements []*Settlement) ([]*Settlement, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback on error, no-op on commit
+
+	for _, settlement := range settlements {
+		if _, err := insertSettlement(tx, settlement); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return settlements, nil
+}
+
+// insertSettlement proposes a settlement through db, which may be a transaction.
+func insertSettlement(db interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}, settlement *Settlement) (*Settlement, error) {
+	query := "INSERT INTO settlements (payer_id, payee_id, via_user_id, amount, outstanding_amount, via_outstanding_amount, status, payment_provider, payment_reference, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	settlement.Status = SettlementProposed
+	settlement.CreatedAt = time.Now()
+	settlement.UpdatedAt = settlement.CreatedAt
+	result, err := db.Exec(query, settlement.PayerID, settlement.PayeeID, settlement.ViaUserID, settlement.Amount, settlement.OutstandingAmount, settlement.ViaOutstandingAmount, settlement.Status,
+		nullIfEmpty(settlement.PaymentProvider), nullIfEmpty(settlement.PaymentReference), settlement.CreatedAt, settlement.UpdatedAt)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+			return nil, fmt.Errorf("%w: %s %s", ErrDuplicatePaymentReference, settlement.PaymentProvider, settlement.PaymentReference)
+		}
+		return nil, fmt.Errorf("failed to create settlement: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID for settlement: %w", err)
+	}
+	settlement.ID = int(id)
+
+	return settlement, nil
+}
+
+func (r *settlementRepository) GetSettlement(id int) (*Settlement, error) {
+	query := "SELECT " + settlementColumns + " FROM settlements WHERE id = ?"
+	s := &Settlement{}
+	if err := scanSettlement(r.db.QueryRow(query, id), s); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSettlementNotFound
+		}
+		return nil, fmt.Errorf("failed to get settlement: %w", err)
+	}
+	return s, nil
+}
+
+func (r *settlementRepository) GetSettlementsByUserID(userID int) ([]Settlement, error) {
+	query := `
+		SELECT ` + settlementColumns + `
+		FROM settlements
+		WHERE payer_id = ? OR payee_id = ? OR via_user_id = ?
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(query, userID, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query settlements for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var settlements []Settlement
+	for rows.Next() {
+		var s Settlement
+		if err := scanSettlement(rows, &s); err != nil {
+			return nil, fmt.Errorf("failed to scan settlement row for user %d: %w", userID, err)
+		}
+		settlements = append(settlements, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over settlement rows for user %d: %w", userID, err)
+	}
+
+	return settlements, nil
+}
+
+func (r *settlementRepository) GetSettlementByPaymentReference(provider, reference string) (*Settlement, error) {
+	query := "SELECT " + settlementColumns + " FROM settlements WHERE payment_provider = ? AND payment_reference = ?"
+	s := &Settlement{}
+	if err := scanSettlement(r.db.QueryRow(query, provider, reference), s); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSettlementNotFound
+		}
+		return nil, fmt.Errorf("failed to get settlement for %s payment %s: %w", provider, reference, err)
+	}
+	return s, nil
+}
+
+func (r *settlementRepository) SetSettlementPayment(id int, provider, reference string) error {
+	result, err := r.db.Exec("UPDATE settlements SET payment_provider = ?, payment_reference = ? WHERE id = ?", provider, reference, id)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+			return fmt.Errorf("%w: %s %s", ErrDuplicatePaymentReference, provider, reference)
+		}
+		return fmt.Errorf("failed to set payment of settlement %d: %w", id, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrSettlementNotFound
+	}
+	return nil
+}
+
+func (r *settlementRepository) TransitionSettlement(id int, from []SettlementStatus, to SettlementStatus) (*Settlement, error) {
+	return withRetry("transition settlement", func() (*Settlement, error) { return r.transitionSettlement(id, from, to) })
+}
+
+func (r *settlementRepository) transitionSettlement(id int, from []SettlementStatus, to SettlementStatus) (*Settlement, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback on error, no-op on commit
+
+	// Lock the row so concurrent transitions can't both apply the payment
+	query := "SELECT " + settlementColumns + " FROM settlements WHERE id = ? FOR UPDATE"
+	s := &Settlement{}
+	if err := scanSettlement(tx.QueryRow(query, id), s); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSettlementNotFound
+		}
+		return nil, fmt.Errorf("failed to get settlement: %w", err)
+	}
+
+	if !containsStatus(from, s.Status) {
+		return nil, fmt.Errorf("%w: cannot move from %s to %s", ErrInvalidSettlementTransition, s.Status, to)
+	}
+
+	s.Status = to
+	s.UpdatedAt = time.Now()
+	if _, err := tx.Exec("UPDATE settlements SET status = ?, updated_at = ? WHERE id = ?", s.Status, s.UpdatedAt, s.ID); err != nil {
+		return nil, fmt.Errorf("failed to update settlement status: %w", err)
+	}
+
+	if to == SettlementConfirmed {
+		// The payer handed over the amount, so the payee now owes it back relative to the pair's balance
+		if err := r.balanceRepo.UpdateBalances(tx, LedgerSource{Type: LedgerSettlement, ID: s.ID}, s.BalanceUpdates()); err != nil {
+			return nil, fmt.Errorf("failed to update balances for settlement %d: %w", s.ID, err)
+		}
+		if err := touchUsers(tx, s.UserIDs()...); err != nil {
+			return nil, err
+		}
+		// The expenses the payment paid off are settled now; changing them would silently reopen them
+		for _, u := range s.BalanceUpdates() {
+			if err := lockCoveredExpenses(tx, u, s.ID, s.UpdatedAt); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return s, nil
+}
+
+func containsStatus(statuses []SettlementStatus, status SettlementStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrShareLinkNotFound is returned when a share link ID does not exist.
+var ErrShareLinkNotFound = errors.New("share link not found")
+
+// ShareLink lets anyone holding its signed token read an event's ledger until it expires or is
+// revoked. The token itself is never stored; it is derived from the ID and expiry.
+type ShareLink struct {
+	ID        int        `json:"id"`
+	EventID   int        `json:"event_id"`
+	CreatedBy int        `json:"created_by"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type ShareLinkRepository interface {
+	CreateShareLink(link *ShareLink) (*ShareLink, error)
+	GetShareLink(id int) (*ShareLink, error)
+	// RevokeShareLink marks the link revoked; revoking a revoked link keeps the first time.
+	RevokeShareLink(id int) (*ShareLink, error)
+}
+
+type shareLinkRepository struct {
+	db *sql.DB
+}
+
+func NewShareLinkRepository(db *sql.DB) ShareLinkRepository {
+	return &shareLinkRepository{db: db}
+}
+
+func (r *shareLinkRepository) CreateShareLink(link *ShareLink) (*ShareLink, error) {
+	query := "INSERT INTO share_links (event_id, created_by, expires_at, created_at) VALUES (?, ?, ?, ?)"
+	link.CreatedAt = time.Now()
+	result, err := r.db.Exec(query, link.EventID, link.CreatedBy, link.ExpiresAt, link.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create share link: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID for share link: %w", err)
+	}
+	link.ID = int(id)
+
+	return link, nil
+}
+
+func (r *shareLinkRepository) GetShareLink(id int) (*ShareLink, error) {
+	query := "SELECT id, event_id, created_by, expires_at, revoked_at, created_at FROM share_links WHERE id = ?"
+	l := &ShareLink{}
+	var revokedAt sql.NullTime
+	if err := r.db.QueryRow(query, id).Scan(&l.ID, &l.EventID, &l.CreatedBy, &l.ExpiresAt, &revokedAt, &l.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %d", ErrShareLinkNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+	if revokedAt.Valid {
+		l.RevokedAt = &revokedAt.Time
+	}
+	return l, nil
+}
+
+func (r *shareLinkRepository) RevokeShareLink(id int) (*ShareLink, error) {
+	if _, err := r.db.Exec("UPDATE share_links SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ?", time.Now(), id); err != nil {
+		return nil, fmt.Errorf("failed to revoke share link %d: %w", id, err)
+	}
+	return r.GetShareLink(id)
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConnector hands out connections whose statements take delay to execute.
+type fakeConnector struct {
+	delay time.Duration
+}
+
+func (c fakeConnector) Connect(ctx context.Context) (driver.Conn, error) { return fakeConn(c), nil }
+func (c fakeConnector) Driver() driver.Driver                            { return nil }
+
+type fakeConn struct {
+	delay time.Duration
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+func (c fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	time.Sleep(c.delay)
+	return driver.RowsAffected(1), nil
+}
+
+func TestSlowQueryConnector(t *testing.T) {
+	var logs bytes.Buffer
+	orig := log.Writer()
+	log.SetOutput(&logs)
+	defer log.SetOutput(orig)
+
+	// Test case 1: Slow statements are logged without their arguments
+	{
+		db := sql.OpenDB(SlowQueryConnector(fakeConnector{delay: 5 * time.Millisecond}, time.Millisecond))
+		defer db.Close()
+
+		_, err := db.Exec("UPDATE users SET email = ? WHERE id = ?", "alice@example.com", 1)
+		require.NoError(t, err)
+		assert.Contains(t, logs.String(), "UPDATE users SET email = ? WHERE id = ? [2 args redacted]")
+		assert.NotContains(t, logs.String(), "alice@example.com")
+	}
+
+	// Test case 2: Fast statements are not
+	{
+		logs.Reset()
+		db := sql.OpenDB(SlowQueryConnector(fakeConnector{}, time.Second))
+		defer db.Close()
+
+		_, err := db.Exec("DELETE FROM jobs WHERE id = ?", 1)
+		require.NoError(t, err)
+		assert.Empty(t, logs.String())
+	}
+}
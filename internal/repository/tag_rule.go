@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ErrTagRuleNotFound is returned when a tag rule ID does not exist.
+var ErrTagRuleNotFound = errors.New("tag rule not found")
+
+// ErrTagRuleKeywordTaken is returned when creating a rule for a keyword that already has one.
+var ErrTagRuleKeywordTaken = errors.New("tag rule keyword is already registered")
+
+// TagRule suggests Tag for expenses whose description contains Keyword as a whole word. Keywords
+// are stored lowercased.
+type TagRule struct {
+	ID        int       `json:"id"`
+	Keyword   string    `json:"keyword"`
+	Tag       string    `json:"tag"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TagRuleRepository interface {
+	CreateTagRule(rule *TagRule) (*TagRule, error)
+	// ListTagRules returns every rule, by keyword.
+	ListTagRules() ([]TagRule, error)
+	DeleteTagRule(id int) error
+}
+
+type tagRuleRepository struct {
+	db *sql.DB
+}
+
+func NewTagRuleRepository(db *sql.DB) TagRuleRepository {
+	return &tagRuleRepository{db: db}
+}
+
+func (r *tagRuleRepository) CreateTagRule(rule *TagRule) (*TagRule, error) {
+	query := "INSERT INTO tag_rules (keyword, tag, created_at) VALUES (?, ?, ?)"
+	rule.CreatedAt = time.Now()
+	result, err := r.db.Exec(query, rule.Keyword, rule.Tag, rule.CreatedAt)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+			return nil, fmt.Errorf("%w: %s", ErrTagRuleKeywordTaken, rule.Keyword)
+		}
+		return nil, fmt.Errorf("failed to create tag rule: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID for tag rule: %w", err)
+	}
+	rule.ID = int(id)
+
+	return rule, nil
+}
+
+func (r *tagRuleRepository) ListTagRules() ([]TagRule, error) {
+	rows, err := r.db.Query("SELECT id, keyword, tag, created_at FROM tag_rules ORDER BY keyword")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tag rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []TagRule
+	for rows.Next() {
+		var rule TagRule
+		if err := rows.Scan(&rule.ID, &rule.Keyword, &rule.Tag, &rule.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tag rule row: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over tag rule rows: %w", err)
+	}
+
+	return rules, nil
+}
+
+func (r *tagRuleRepository) DeleteTagRule(id int) error {
+	result, err := r.db.Exec("DELETE FROM tag_rules WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag rule: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows for tag rule: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: %d", ErrTagRuleNotFound, id)
+	}
+	return nil
+}
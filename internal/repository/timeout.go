@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlQueryTimeout is the MySQL error number for a SELECT stopped by max_execution_time.
+const mysqlQueryTimeout = 3024
+
+// ApplyQueryTimeout sets session variables on every connection opened from dsn so that a SELECT
+// is stopped after timeout and a write gives up waiting for a row lock after timeout, rounded up
+// to whole seconds as MySQL requires. A zero timeout leaves the server defaults.
+func ApplyQueryTimeout(dsn *mysql.Config, timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	if dsn.Params == nil {
+		dsn.Params = make(map[string]string)
+	}
+	dsn.Params["max_execution_time"] = strconv.FormatInt(timeout.Milliseconds(), 10)
+	dsn.Params["innodb_lock_wait_timeout"] = strconv.FormatInt(int64((timeout+time.Second-1)/time.Second), 10)
+}
+
+// IsQueryTimeout reports whether err comes from a statement that ran out of time: a SELECT past
+// max_execution_time, a write that timed out waiting for a lock, or a cancelled context deadline.
+func IsQueryTimeout(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlQueryTimeout || mysqlErr.Number == mysqlLockWaitTimeout
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
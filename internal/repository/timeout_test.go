@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyQueryTimeout(t *testing.T) {
+	// Test case 1: Lock waits are rounded up to whole seconds
+	dsn := mysql.NewConfig()
+	ApplyQueryTimeout(dsn, 2500*time.Millisecond)
+	assert.Equal(t, map[string]string{"max_execution_time": "2500", "innodb_lock_wait_timeout": "3"}, dsn.Params)
+
+	// Test case 2: Zero keeps the server defaults
+	dsn = mysql.NewConfig()
+	ApplyQueryTimeout(dsn, 0)
+	assert.Empty(t, dsn.Params)
+}
+
+func TestIsQueryTimeout(t *testing.T) {
+	assert.True(t, IsQueryTimeout(fmt.Errorf("failed to query expenses: %w", &mysql.MySQLError{Number: mysqlQueryTimeout})))
+	assert.True(t, IsQueryTimeout(&mysql.MySQLError{Number: mysqlLockWaitTimeout}))
+	assert.True(t, IsQueryTimeout(fmt.Errorf("failed to get user: %w", context.DeadlineExceeded)))
+	assert.False(t, IsQueryTimeout(&mysql.MySQLError{Number: mysqlDeadlock}))
+	assert.False(t, IsQueryTimeout(errors.New("user not found")))
+}
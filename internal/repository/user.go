@@ -2,21 +2,51 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
 )
 
+// mysqlDuplicateEntry is the MySQL error number for a unique key violation.
+const mysqlDuplicateEntry = 1062
+
+// ErrEmailTaken is returned when creating a user whose email is already registered, in any letter case.
+var ErrEmailTaken = errors.New("email is already registered")
+
+// ErrPublicIDTaken is returned when creating a user or expense with a public ID another already has.
+var ErrPublicIDTaken = errors.New("public_id is already in use")
+
+// DefaultSplitWeight is the weight a user carries in weighted splits until they set their own.
+const DefaultSplitWeight = 1.0
+
 type User struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	ID int `json:"-"`
+	// PublicID identifies the user outside the database; unlike ID it can't be guessed, so it is the
+	// only one the API gives out.
+	PublicID    string  `json:"id"`
+	Name        string  `json:"name"`
+	Email       string  `json:"email"`
+	SplitWeight float64 `json:"split_weight"`
 }
 
 type UserRepository interface {
+	// CreateUser stores the user. Emails are expected in normalized (lowercase) form; the schema rejects any other.
 	CreateUser(user *User) (*User, error)
+	// GetOrCreateUser returns the user registered with user's email, creating user first if there is
+	// none, in one step so concurrent calls for the same email agree on one user. created reports
+	// whether it was created; an existing user is returned as stored, name included.
+	GetOrCreateUser(user *User) (stored *User, created bool, err error)
 	GetUser(id int) (*User, error)
+	GetUserByPublicID(publicID string) (*User, error)
 	GetUsersByEmails(emails []string) ([]*User, error)
 	GetUsersByIDs(ids []int) ([]*User, error)
+	UpdateSplitWeight(id int, weight float64) (*User, error)
+	// GetLastModified returns when an expense or balance involving the user last changed.
+	GetLastModified(id int) (time.Time, error)
 }
 
 type userRepository struct {
@@ -28,9 +58,20 @@ func NewUserRepository(db *sql.DB) UserRepository {
 }
 
 func (r *userRepository) CreateUser(user *User) (*User, error) {
-	query := "INSERT INTO users (name, email) VALUES (?, ?)"
-	result, err := r.db.Exec(query, user.Name, user.Email)
+	if user.SplitWeight == 0 {
+		user.SplitWeight = DefaultSplitWeight
+	}
+
+	query := "INSERT INTO users (public_id, name, email, split_weight) VALUES (?, ?, ?, ?)"
+	result, err := r.db.Exec(query, user.PublicID, user.Name, user.Email, user.SplitWeight)
 	if err != nil {
+		if isDuplicatePublicID(err) {
+			return nil, fmt.Errorf("%w: %s", ErrPublicIDTaken, user.PublicID)
+		}
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+			return nil, fmt.Errorf("%w: %s", ErrEmailTaken, user.Email)
+		}
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
@@ -43,10 +84,60 @@ func (r *userRepository) CreateUser(user *User) (*User, error) {
 	return user, nil
 }
 
+func (r *userRepository) GetOrCreateUser(user *User) (*User, bool, error) {
+	if user.SplitWeight == 0 {
+		user.SplitWeight = DefaultSplitWeight
+	}
+
+	// On a duplicate email, LAST_INSERT_ID(id) hands back the existing row's ID without changing the
+	// row, so no row counts as affected
+	query := "INSERT INTO users (public_id, name, email, split_weight) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)"
+	result, err := r.db.Exec(query, user.PublicID, user.Name, user.Email, user.SplitWeight)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get or create user: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 1 {
+		user.ID = int(id)
+		return user, true, nil
+	}
+
+	existing, err := r.GetUser(int(id))
+	if err != nil {
+		return nil, false, err
+	}
+	// The row that clashed may be another user's, holding the public ID asked for
+	if existing.Email != user.Email {
+		return nil, false, fmt.Errorf("%w: %s", ErrPublicIDTaken, user.PublicID)
+	}
+	return existing, false, nil
+}
+
 func (r *userRepository) GetUser(id int) (*User, error) {
-	query := "SELECT id, name, email FROM users WHERE id = ?"
+	query := "SELECT id, public_id, name, email, split_weight FROM users WHERE id = ?"
 	user := &User{}
-	err := r.db.QueryRow(query, id).Scan(&user.ID, &user.Name, &user.Email)
+	err := r.db.QueryRow(query, id).Scan(&user.ID, &user.PublicID, &user.Name, &user.Email, &user.SplitWeight)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+func (r *userRepository) GetUserByPublicID(publicID string) (*User, error) {
+	query := "SELECT id, public_id, name, email, split_weight FROM users WHERE public_id = ?"
+	user := &User{}
+	err := r.db.QueryRow(query, publicID).Scan(&user.ID, &user.PublicID, &user.Name, &user.Email, &user.SplitWeight)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("user not found")
@@ -68,7 +159,7 @@ func (r *userRepository) GetUsersByEmails(emails []string) ([]*User, error) {
 		args[i] = email
 	}
 
-	query := fmt.Sprintf("SELECT id, name, email FROM users WHERE email IN (%s)", strings.Join(placeholders, ", "))
+	query := fmt.Sprintf("SELECT id, public_id, name, email, split_weight FROM users WHERE email IN (%s)", strings.Join(placeholders, ", "))
 	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get users by emails: %w", err)
@@ -79,7 +170,7 @@ func (r *userRepository) GetUsersByEmails(emails []string) ([]*User, error) {
 	foundEmails := make(map[string]bool)
 	for rows.Next() {
 		user := &User{}
-		if err := rows.Scan(&user.ID, &user.Name, &user.Email); err != nil {
+		if err := rows.Scan(&user.ID, &user.PublicID, &user.Name, &user.Email, &user.SplitWeight); err != nil {
 			return nil, fmt.Errorf("failed to scan user row: %w", err)
 		}
 		users = append(users, user)
@@ -116,7 +207,7 @@ func (r *userRepository) GetUsersByIDs(ids []int) ([]*User, error) {
 		args[i] = id
 	}
 
-	query := fmt.Sprintf("SELECT id, name, email FROM users WHERE id IN (%s)", strings.Join(placeholders, ", "))
+	query := fmt.Sprintf("SELECT id, public_id, name, email, split_weight FROM users WHERE id IN (%s)", strings.Join(placeholders, ", "))
 	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get users by IDs: %w", err)
@@ -127,7 +218,7 @@ func (r *userRepository) GetUsersByIDs(ids []int) ([]*User, error) {
 	foundIDs := make(map[int]bool)
 	for rows.Next() {
 		user := &User{}
-		if err := rows.Scan(&user.ID, &user.Name, &user.Email); err != nil {
+		if err := rows.Scan(&user.ID, &user.PublicID, &user.Name, &user.Email, &user.SplitWeight); err != nil {
 			return nil, fmt.Errorf("failed to scan user row: %w", err)
 		}
 		users = append(users, user)
@@ -151,3 +242,74 @@ func (r *userRepository) GetUsersByIDs(ids []int) ([]*User, error) {
 
 	return users, nil
 }
+
+func (r *userRepository) UpdateSplitWeight(id int, weight float64) (*User, error) {
+	if _, err := r.db.Exec("UPDATE users SET split_weight = ? WHERE id = ?", weight, id); err != nil {
+		return nil, fmt.Errorf("failed to update split weight: %w", err)
+	}
+
+	// MySQL reports zero affected rows when the weight is unchanged, so read the user back instead
+	return r.GetUser(id)
+}
+
+func (r *userRepository) GetLastModified(id int) (time.Time, error) {
+	var lastModified time.Time
+	if err := r.db.QueryRow("SELECT last_modified_at FROM users WHERE id = ?", id).Scan(&lastModified); err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, fmt.Errorf("user not found")
+		}
+		return time.Time{}, fmt.Errorf("failed to get last modified time: %w", err)
+	}
+	return lastModified, nil
+}
+
+// touchUsersQuery moves last_modified_at forward for the selected users. HTTP dates only have whole
+// seconds, so every change moves it at least a second past the one before; otherwise a client that
+// read the data earlier in the same second would be told nothing had changed since.
+const touchUsersQuery = "UPDATE users SET last_modified_at = GREATEST(NOW(), last_modified_at + INTERVAL 1 SECOND) WHERE id IN "
+
+// touchUsers marks the users' data as changed within tx. IDs are sorted so that concurrent
+// transactions lock the rows in the same order.
+func touchUsers(tx *sql.Tx, userIDs ...int) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	ids := append([]int(nil), userIDs...)
+	sort.Ints(ids)
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	if _, err := tx.Exec(touchUsersQuery+"("+placeholders+")", args...); err != nil {
+		return fmt.Errorf("failed to update last modified time: %w", err)
+	}
+	return nil
+}
+
+// touchExpenseParticipants is touchUsers for the creators of the expenses and everyone with a split in them.
+func touchExpenseParticipants(tx *sql.Tx, expenseIDs ...int) error {
+	args := make([]interface{}, 0, 2*len(expenseIDs))
+	for range 2 {
+		for _, id := range expenseIDs {
+			args = append(args, id)
+		}
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(expenseIDs)), ", ")
+	query := touchUsersQuery + "(SELECT user_id FROM expense_splits WHERE expense_id IN (" + placeholders + ") UNION SELECT created_by FROM expenses WHERE id IN (" + placeholders + "))"
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to update last modified time: %w", err)
+	}
+	return nil
+}
+
+// randomUUIDSQL is an SQL expression for a random (version 4) UUID, for rows given a public ID by
+// the database rather than util.IDGenerator.
+const randomUUIDSQL = "LOWER(CONCAT_WS('-', HEX(RANDOM_BYTES(4)), HEX(RANDOM_BYTES(2)), CONCAT('4', SUBSTR(HEX(RANDOM_BYTES(2)), 2)), CONCAT(ELT(1 + FLOOR(RAND() * 4), '8', '9', 'A', 'B'), SUBSTR(HEX(RANDOM_BYTES(2)), 2)), HEX(RANDOM_BYTES(6))))"
+
+// isDuplicatePublicID reports whether err is MySQL refusing a public_id that is already taken.
+func isDuplicatePublicID(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry && strings.Contains(mysqlErr.Message, "public_id")
+}
@@ -0,0 +1,122 @@
+// Package response writes API responses in the standard envelope:
+//
+//	{"data": ..., "meta": {"request_id": "...", "api_version": "1"}, "error": null}
+//
+// A failed request has a null data and an error of {"message": "..."}. Requests passed through
+// Legacy get the bare payloads and plain-text errors the API sent before the envelope, so existing
+// clients keep working while they migrate.
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// APIVersion is reported in the meta of every enveloped response.
+const APIVersion = "1"
+
+// requestIDHeader is set on the response by the access log before the handler runs.
+const requestIDHeader = "X-Request-ID"
+
+// Envelope is the body of every JSON response. Exactly one of Data and Error is non-null.
+type Envelope struct {
+	Data  any        `json:"data"`
+	Meta  Meta       `json:"meta"`
+	Error *ErrorBody `json:"error"`
+}
+
+// Meta describes the response rather than the resource.
+type Meta struct {
+	RequestID  string `json:"request_id,omitempty"`
+	APIVersion string `json:"api_version"`
+	// Pagination is set by list endpoints that were asked for a page.
+	Pagination *Pagination `json:"pagination,omitempty"`
+	// TotalAmount is set by lists of amounts: what the whole list adds up to, not just the page.
+	TotalAmount *float64 `json:"total_amount,omitempty"`
+}
+
+// Pagination places a page within the full list. Total counts every item, not just the page.
+type Pagination struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+	Total  int `json:"total"`
+}
+
+// ErrorBody explains why a request failed. Code and Field are set where a client may want to act on
+// the failure without parsing the message, like a request body that was refused.
+type ErrorBody struct {
+	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
+	Field   string `json:"field,omitempty"`
+}
+
+type legacyKey struct{}
+
+// Legacy makes the responses to the requests it wraps bare payloads and plain-text errors.
+func Legacy(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), legacyKey{}, true)))
+	})
+}
+
+func isLegacy(r *http.Request) bool {
+	legacy, _ := r.Context().Value(legacyKey{}).(bool)
+	return legacy
+}
+
+// JSON writes data with the given status.
+func JSON(w http.ResponseWriter, r *http.Request, status int, data any) {
+	write(w, r, status, data, nil)
+}
+
+// Page writes one page of a list with the given status, and where it sits in the list.
+func Page(w http.ResponseWriter, r *http.Request, status int, data any, page Pagination) {
+	write(w, r, status, data, &page)
+}
+
+// AmountList writes a list of amounts with the given status, and what the whole list adds up to.
+// page is nil unless only a page of the list was asked for.
+func AmountList(w http.ResponseWriter, r *http.Request, status int, data any, total float64, page *Pagination) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if isLegacy(r) {
+		json.NewEncoder(w).Encode(data)
+		return
+	}
+	m := meta(w, page)
+	m.TotalAmount = &total
+	json.NewEncoder(w).Encode(Envelope{Data: data, Meta: m})
+}
+
+// Error writes a failure with the given status, like http.Error.
+func Error(w http.ResponseWriter, r *http.Request, message string, status int) {
+	Problem(w, r, status, ErrorBody{Message: message})
+}
+
+// Problem writes a failure with the given status, explained by body. Legacy requests only get the
+// message.
+func Problem(w http.ResponseWriter, r *http.Request, status int, body ErrorBody) {
+	if isLegacy(r) {
+		http.Error(w, body.Message, status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Envelope{Meta: meta(w, nil), Error: &body})
+}
+
+func write(w http.ResponseWriter, r *http.Request, status int, data any, page *Pagination) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if isLegacy(r) {
+		json.NewEncoder(w).Encode(data)
+		return
+	}
+	json.NewEncoder(w).Encode(Envelope{Data: data, Meta: meta(w, page)})
+}
+
+func meta(w http.ResponseWriter, page *Pagination) Meta {
+	return Meta{RequestID: w.Header().Get(requestIDHeader), APIVersion: APIVersion, Pagination: page}
+}
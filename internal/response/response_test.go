@@ -0,0 +1,42 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSON(t *testing.T) {
+	// Test case 1: Data is wrapped with the request ID the access log set
+	rr := httptest.NewRecorder()
+	rr.Header().Set("X-Request-ID", "req-1")
+	JSON(rr, httptest.NewRequest("GET", "/", nil), http.StatusCreated, map[string]int{"id": 7})
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"data":{"id":7},"meta":{"request_id":"req-1","api_version":"1"},"error":null}`, rr.Body.String())
+
+	// Test case 2: Legacy requests get the bare payload
+	rr = httptest.NewRecorder()
+	Legacy(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		JSON(w, r, http.StatusOK, map[string]int{"id": 7})
+	})).ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	assert.JSONEq(t, `{"id":7}`, rr.Body.String())
+}
+
+func TestError(t *testing.T) {
+	// Test case 1: The message goes in the error, and data is null
+	rr := httptest.NewRecorder()
+	Error(rr, httptest.NewRequest("GET", "/", nil), "user not found", http.StatusNotFound)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.JSONEq(t, `{"data":null,"meta":{"api_version":"1"},"error":{"message":"user not found"}}`, rr.Body.String())
+
+	// Test case 2: Legacy requests get plain text
+	rr = httptest.NewRecorder()
+	Legacy(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Error(w, r, "user not found", http.StatusNotFound)
+	})).ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "text/plain; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "user not found\n", rr.Body.String())
+}
@@ -0,0 +1,2458 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/handler"
+	"github.com/aadithya-md/split-expense/internal/middleware"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/repository/memory"
+	"github.com/aadithya-md/split-expense/internal/response"
+	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/aadithya-md/split-expense/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServer boots the real router and services on top of an in-memory store.
+func newTestServer(t *testing.T) *httptest.Server {
+	srv, _ := newTestServerWithServices(t)
+	return srv
+}
+
+// testShareSecret signs share and unsubscribe links in the end-to-end tests.
+const testShareSecret = "e2e-share-secret-0123456789abcdef"
+
+// testStripeWebhookSecret signs the Stripe webhooks the end-to-end tests send.
+const testStripeWebhookSecret = "whsec_e2e"
+
+// testCallbackSecret signs the UPI payment callbacks the end-to-end tests send.
+const testCallbackSecret = "e2e-upi-callback-secret-0123456789"
+
+// newFakeStripe stands in for the Stripe API, handing out a new payment intent for every request
+// to create one.
+func newFakeStripe(t *testing.T) *httptest.Server {
+	var (
+		mu      sync.Mutex
+		intents int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, found := strings.CutPrefix(r.URL.Path, "/v1/payment_intents/")
+		if r.Method == "POST" && r.URL.Path == "/v1/payment_intents" {
+			mu.Lock()
+			intents++
+			id, found = fmt.Sprintf("pi_%d", intents), true
+			mu.Unlock()
+		}
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"id":%q,"client_secret":%q,"status":"requires_payment_method"}`, id, id+"_secret")
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// testNotifier collects the notifications the end-to-end tests send. Tests that look at it use
+// email addresses of their own.
+var testNotifier = &recordingNotifier{}
+
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent []service.Notification
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, notification service.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+// sentTo returns the notifications sent to email so far.
+func (n *recordingNotifier) sentTo(email string) []service.Notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var sent []service.Notification
+	for _, notification := range n.sent {
+		if notification.To == email {
+			sent = append(sent, notification)
+		}
+	}
+	return sent
+}
+
+// newTestServerWithServices is newTestServer for tests that also need to drive services directly.
+func newTestServerWithServices(t *testing.T) (*httptest.Server, Services) {
+	store := memory.NewStore(memory.Options{})
+	userRepo, balanceRepo, expenseRepo := store.Users, store.Balances, store.Expenses
+	loanRepo, settlementRepo := store.Loans, store.Settlements
+	auditService := service.NewAuditService(store.Audit)
+	jobService := service.NewJobService(store.Jobs, service.JobOptions{MaxAttempts: 2, PollInterval: time.Millisecond, Lease: time.Minute})
+	exponents, err := store.Currencies.GetCurrencyExponents()
+	require.NoError(t, err)
+	currencies := util.NewCurrencies(exponents)
+
+	userService := service.NewUserService(userRepo, nil)
+	budgetService := service.NewBudgetService(store.Budgets, userService, currencies, false)
+	partyRepo := store.Parties
+	eventRepo := store.Events
+	eventService := service.NewEventService(eventRepo, userService, nil, currencies)
+	tagService := service.NewTagService(store.TagRules, nil)
+	paymentService := service.NewPaymentService(store.PaymentHandles, settlementRepo, userService, "http://split.example", map[string]string{service.ProviderUPI: testCallbackSecret}, currencies)
+	prefRepo := store.Preferences
+	notifier := service.NewPreferenceNotifier(testNotifier, repository.ChannelEmail, prefRepo)
+	expenseService := service.NewAnnouncingExpenseService(
+		service.NewExpenseService(expenseRepo, userService, balanceRepo, budgetService, nil, partyRepo, eventRepo, settlementRepo, store.Ledger, nil, tagService, nil, currencies, time.Minute, service.ValidationPolicy{}),
+		expenseRepo, userService, jobService, notifier, time.Minute,
+	)
+	services := Services{
+		User:       userService,
+		Expense:    expenseService,
+		Loan:       service.NewLoanService(loanRepo, store.Ledger, userService, jobService, notifier),
+		Settlement: service.NewSettlementService(settlementRepo, expenseRepo, balanceRepo, userService),
+		Analytics:  service.NewAnalyticsService(expenseRepo, balanceRepo, settlementRepo, eventRepo, userService, currencies),
+		Audit:      auditService,
+		Query:      service.NewQueryService(nil),
+		Jobs:       jobService,
+		Budget:     budgetService,
+		Goal:       service.NewGoalService(store.Goals, balanceRepo, userService),
+		Party:      service.NewPartyService(partyRepo),
+		Tag:        tagService,
+		Event:      service.NewPaymentLinkingEventService(eventService, paymentService),
+		Share:      service.NewShareService(store.ShareLinks, eventRepo, eventService, userService, testShareSecret, 24*time.Hour, 48*time.Hour),
+		Preference: service.NewPreferenceService(prefRepo, userService),
+		Invite:     service.NewInviteService(expenseRepo, eventRepo, userService, testShareSecret, time.Hour, "http://split.example"),
+		Payment:    paymentService,
+		Ledger:     service.NewLedgerService(store.Ledger, userService),
+		Import:     service.NewImportService(store.Imports, expenseService, userService, jobService, nil, currencies),
+		Stripe: service.NewStripeService(settlementRepo, service.StripeOptions{
+			SecretKey:     "sk_test_e2e",
+			WebhookSecret: testStripeWebhookSecret,
+			APIBase:       newFakeStripe(t).URL,
+			Currency:      "INR",
+		}, currencies),
+	}
+	services.Digest = service.NewDigestService(prefRepo, userService, services.Expense, services.Settlement, services.Loan, jobService, notifier, service.DigestOptions{
+		BaseURL:    "http://split.example",
+		LinkSecret: testShareSecret,
+	})
+
+	srv := httptest.NewServer(middleware.StripTrailingSlash(NewRouter(services, Options{Currencies: currencies}, middleware.Audit(auditService), middleware.Recovery)))
+	t.Cleanup(srv.Close)
+	return srv, services
+}
+
+// call sends body as JSON and decodes a successful JSON response into out, returning the status code.
+func call(t *testing.T, srv *httptest.Server, method, path string, body, out interface{}) int {
+	t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(b)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, srv.URL+path, reader)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode < 300 {
+		// A test that wants the meta too passes the envelope, with its Data set to decode into
+		env, ok := out.(*response.Envelope)
+		if !ok {
+			env = &response.Envelope{Data: out}
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(env))
+		assert.Equal(t, response.APIVersion, env.Meta.APIVersion)
+	}
+	return resp.StatusCode
+}
+
+func overallBalance(t *testing.T, srv *httptest.Server, email string) float64 {
+	t.Helper()
+
+	var resp struct {
+		OverallBalance float64 `json:"overall_balance"`
+	}
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/balances/overall/by-user/"+email, nil, &resp))
+	return resp.OverallBalance
+}
+
+func TestE2E_ExpenseJourney(t *testing.T) {
+	srv := newTestServer(t)
+
+	// Create users
+	for _, u := range []struct{ Name, Email string }{
+		{"Alice", "alice@example.com"},
+		{"Bob", "bob@example.com"},
+		{"Charlie", "charlie@example.com"},
+	} {
+		var created repository.User
+		status := call(t, srv, "POST", "/users", map[string]string{"name": u.Name, "email": u.Email}, &created)
+		require.Equal(t, http.StatusCreated, status)
+		assert.NotEmpty(t, created.PublicID)
+	}
+
+	// Duplicate emails are rejected
+	assert.Equal(t, http.StatusConflict, call(t, srv, "POST", "/users", map[string]string{"name": "Alice Again", "email": "alice@example.com"}, nil))
+
+	// Alice pays 90 split equally three ways
+	status := call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Groceries",
+		Tag:            "Food",
+		TotalAmount:    90,
+		CreatedByEmail: "alice@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits: []service.EqualSplitRequest{
+			{UserEmail: "alice@example.com", AmountPaid: 90},
+			{UserEmail: "bob@example.com"},
+			{UserEmail: "charlie@example.com"},
+		},
+	}, nil)
+	require.Equal(t, http.StatusCreated, status)
+
+	assert.Equal(t, 60.0, overallBalance(t, srv, "alice@example.com"))
+	assert.Equal(t, -30.0, overallBalance(t, srv, "bob@example.com"))
+	assert.Equal(t, -30.0, overallBalance(t, srv, "charlie@example.com"))
+
+	// Bob pays 30 for himself and Alice, split manually
+	status = call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Taxi",
+		Tag:            "Transport",
+		TotalAmount:    30,
+		CreatedByEmail: "bob@example.com",
+		SplitMethod:    service.SplitMethodManual,
+		ManualSplits: []service.ManualSplitRequest{
+			{UserEmail: "alice@example.com", AmountOwed: 20},
+			{UserEmail: "bob@example.com", AmountOwed: 10, AmountPaid: 30},
+		},
+	}, nil)
+	require.Equal(t, http.StatusCreated, status)
+
+	// Charlie pays 100 split by percentage
+	status = call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Concert",
+		Tag:            "Fun",
+		TotalAmount:    100,
+		CreatedByEmail: "charlie@example.com",
+		SplitMethod:    service.SplitMethodPercentage,
+		PercentageSplits: []service.PercentageSplitRequest{
+			{UserEmail: "charlie@example.com", Percentage: 50, AmountPaid: 100},
+			{UserEmail: "alice@example.com", Percentage: 25},
+			{UserEmail: "bob@example.com", Percentage: 25},
+		},
+	}, nil)
+	require.Equal(t, http.StatusCreated, status)
+
+	alice := overallBalance(t, srv, "alice@example.com")
+	bob := overallBalance(t, srv, "bob@example.com")
+	charlie := overallBalance(t, srv, "charlie@example.com")
+	assert.Equal(t, 15.0, alice)
+	assert.Equal(t, -35.0, bob)
+	assert.Equal(t, 20.0, charlie)
+	assert.Equal(t, 0.0, alice+bob+charlie)
+
+	// Pairwise balances from Bob's point of view
+	var balances []service.UserBalanceView
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/balances/by-user/bob@example.com", nil, &balances))
+	byEmail := make(map[string]float64)
+	for _, b := range balances {
+		byEmail[b.WithUserEmail] = b.Amount
+	}
+	assert.Equal(t, map[string]float64{"alice@example.com": -10, "charlie@example.com": -25}, byEmail)
+
+	// Bob's expense history, newest first
+	var expenses []repository.UserExpenseView
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/expenses/by-user/bob@example.com", nil, &expenses))
+	require.Len(t, expenses, 3)
+	assert.Equal(t, "Concert", expenses[0].Description)
+	assert.Equal(t, -25.0, expenses[0].Share)
+	assert.Equal(t, "Taxi", expenses[1].Description)
+	assert.Equal(t, 20.0, expenses[1].Share)
+	assert.Equal(t, "Groceries", expenses[2].Description)
+	assert.Equal(t, -30.0, expenses[2].Share)
+
+	// Bob settles up with Alice: nothing moves until the transfer is confirmed
+	var settlement repository.Settlement
+	status = call(t, srv, "POST", "/settlements", service.ProposeSettlementRequest{PayerEmail: "bob@example.com", PayeeEmail: "alice@example.com", Amount: 10}, &settlement)
+	require.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, repository.SettlementProposed, settlement.Status)
+
+	assert.Equal(t, http.StatusForbidden, call(t, srv, "POST", fmt.Sprintf("/settlements/%d/send", settlement.ID), service.TransitionSettlementRequest{UserEmail: "alice@example.com"}, nil))
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", fmt.Sprintf("/settlements/%d/send", settlement.ID), service.TransitionSettlementRequest{UserEmail: "bob@example.com"}, nil))
+	assert.Equal(t, -35.0, overallBalance(t, srv, "bob@example.com"))
+
+	// Only Alice can say the money arrived
+	assert.Equal(t, http.StatusForbidden, call(t, srv, "POST", fmt.Sprintf("/settlements/%d/confirm", settlement.ID), service.TransitionSettlementRequest{UserEmail: "bob@example.com"}, nil))
+	assert.Equal(t, -35.0, overallBalance(t, srv, "bob@example.com"))
+
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", fmt.Sprintf("/settlements/%d/confirm", settlement.ID), service.TransitionSettlementRequest{UserEmail: "alice@example.com"}, nil))
+	assert.Equal(t, -25.0, overallBalance(t, srv, "bob@example.com"))
+	assert.Equal(t, 5.0, overallBalance(t, srv, "alice@example.com"))
+
+	// A confirmed settlement can't be confirmed again
+	assert.Equal(t, http.StatusConflict, call(t, srv, "POST", fmt.Sprintf("/settlements/%d/confirm", settlement.ID), service.TransitionSettlementRequest{UserEmail: "alice@example.com"}, nil))
+	assert.Equal(t, -25.0, overallBalance(t, srv, "bob@example.com"))
+}
+
+func TestE2E_InvalidExpenses(t *testing.T) {
+	srv := newTestServer(t)
+
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": "Alice", "email": "alice@example.com"}, nil))
+
+	// Unknown participant
+	status := call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Lunch",
+		TotalAmount:    20,
+		CreatedByEmail: "alice@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits: []service.EqualSplitRequest{
+			{UserEmail: "alice@example.com", AmountPaid: 20},
+			{UserEmail: "ghost@example.com"},
+		},
+	}, nil)
+	assert.Equal(t, http.StatusInternalServerError, status)
+
+	// Paid amounts don't cover the total
+	status = call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Lunch",
+		TotalAmount:    20,
+		CreatedByEmail: "alice@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits: []service.EqualSplitRequest{
+			{UserEmail: "alice@example.com", AmountPaid: 10},
+		},
+	}, nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	// Nothing was recorded
+	var expenses []repository.UserExpenseView
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/expenses/by-user/alice@example.com", nil, &expenses))
+	assert.Empty(t, expenses)
+	assert.Equal(t, 0.0, overallBalance(t, srv, "alice@example.com"))
+}
+
+func TestE2E_LoanJourney(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, u := range []struct{ Name, Email string }{
+		{"Alice", "alice@example.com"},
+		{"Bob", "bob@example.com"},
+	} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": u.Name, "email": u.Email}, nil))
+	}
+
+	// Alice lends Bob 200, then Bob pays a 50 dinner for both
+	status := call(t, srv, "POST", "/loans", service.CreateLoanRequest{
+		LenderEmail:   "alice@example.com",
+		BorrowerEmail: "bob@example.com",
+		Amount:        200,
+		Description:   "Deposit",
+		DueDate:       "2030-01-31",
+	}, nil)
+	require.Equal(t, http.StatusCreated, status)
+
+	status = call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Dinner",
+		TotalAmount:    50,
+		CreatedByEmail: "bob@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits: []service.EqualSplitRequest{
+			{UserEmail: "bob@example.com", AmountPaid: 50},
+			{UserEmail: "alice@example.com"},
+		},
+	}, nil)
+	require.Equal(t, http.StatusCreated, status)
+
+	assert.Equal(t, 175.0, overallBalance(t, srv, "alice@example.com"))
+	assert.Equal(t, -175.0, overallBalance(t, srv, "bob@example.com"))
+
+	// Loans are listed separately from expenses
+	var loans []service.LoanView
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/loans/by-user/bob@example.com", nil, &loans))
+	require.Len(t, loans, 1)
+	assert.Equal(t, "borrowed", loans[0].Direction)
+	assert.Equal(t, "alice@example.com", loans[0].WithUserEmail)
+	assert.Equal(t, 200.0, loans[0].Amount)
+	assert.Equal(t, "2030-01-31", loans[0].DueDate)
+	assert.False(t, loans[0].Overdue)
+
+	var expenses []repository.UserExpenseView
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/expenses/by-user/bob@example.com", nil, &expenses))
+	assert.Len(t, expenses, 1)
+}
+
+func TestE2E_DisputeBlocksSettlement(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, u := range []struct{ Name, Email string }{
+		{"Alice", "alice@example.com"},
+		{"Bob", "bob@example.com"},
+	} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": u.Name, "email": u.Email}, nil))
+	}
+
+	var expense repository.Expense
+	status := call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Dinner",
+		TotalAmount:    40,
+		CreatedByEmail: "alice@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits: []service.EqualSplitRequest{
+			{UserEmail: "alice@example.com", AmountPaid: 40},
+			{UserEmail: "bob@example.com"},
+		},
+	}, &expense)
+	require.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, repository.ExpenseActive, expense.Status)
+
+	// Bob disputes, after which neither side can settle up
+	disputePath := fmt.Sprintf("/expenses/%s/dispute", expense.PublicID)
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", disputePath, service.DisputeExpenseRequest{UserEmail: "bob@example.com", Reason: "I only had a drink"}, nil))
+	assert.Equal(t, http.StatusConflict, call(t, srv, "POST", disputePath, service.DisputeExpenseRequest{UserEmail: "bob@example.com", Reason: "Again"}, nil))
+
+	settle := service.ProposeSettlementRequest{PayerEmail: "bob@example.com", PayeeEmail: "alice@example.com", Amount: 20}
+	assert.Equal(t, http.StatusConflict, call(t, srv, "POST", "/settlements", settle, nil))
+
+	var expenses []repository.UserExpenseView
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/expenses/by-user/bob@example.com", nil, &expenses))
+	require.Len(t, expenses, 1)
+	assert.Equal(t, expense.PublicID, expenses[0].ExpensePublicID)
+	assert.Equal(t, repository.ExpenseDisputed, expenses[0].Status)
+
+	// Only Alice, the creator, can dismiss it
+	dismissPath := fmt.Sprintf("/expenses/%s/dismiss-dispute", expense.PublicID)
+	assert.Equal(t, http.StatusForbidden, call(t, srv, "POST", dismissPath, service.DismissDisputeRequest{UserEmail: "bob@example.com"}, nil))
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", dismissPath, service.DismissDisputeRequest{UserEmail: "alice@example.com"}, nil))
+
+	assert.Equal(t, http.StatusCreated, call(t, srv, "POST", "/settlements", settle, nil))
+}
+
+func TestE2E_WeightedSplit(t *testing.T) {
+	srv := newTestServer(t)
+
+	users := make(map[string]repository.User)
+	for _, u := range []struct{ Name, Email string }{
+		{"Alice", "alice@example.com"},
+		{"Bob", "bob@example.com"},
+	} {
+		var created repository.User
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": u.Name, "email": u.Email}, &created))
+		assert.Equal(t, repository.DefaultSplitWeight, created.SplitWeight)
+		users[u.Email] = created
+	}
+
+	// Alice earns three times what Bob does
+	var updated repository.User
+	path := fmt.Sprintf("/users/%s/split-weight", users["alice@example.com"].PublicID)
+	require.Equal(t, http.StatusOK, call(t, srv, "PUT", path, map[string]float64{"split_weight": 3}, &updated))
+	assert.Equal(t, 3.0, updated.SplitWeight)
+	assert.Equal(t, http.StatusBadRequest, call(t, srv, "PUT", path, map[string]float64{"split_weight": -1}, nil))
+
+	// Bob pays the 2000 rent and the stored weights decide the shares
+	status := call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Rent",
+		TotalAmount:    2000,
+		CreatedByEmail: "bob@example.com",
+		SplitMethod:    service.SplitMethodWeighted,
+		WeightedSplits: []service.WeightedSplitRequest{
+			{UserEmail: "alice@example.com"},
+			{UserEmail: "bob@example.com", AmountPaid: 2000},
+		},
+	}, nil)
+	require.Equal(t, http.StatusCreated, status)
+
+	assert.Equal(t, -1500.0, overallBalance(t, srv, "alice@example.com"))
+	assert.Equal(t, 1500.0, overallBalance(t, srv, "bob@example.com"))
+}
+
+func TestE2E_Refund(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, u := range []struct{ Name, Email string }{
+		{"Alice", "alice@example.com"},
+		{"Bob", "bob@example.com"},
+	} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": u.Name, "email": u.Email}, nil))
+	}
+
+	// Alice books two 100 tickets for both of them
+	var tickets repository.Expense
+	status := call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Tickets",
+		TotalAmount:    200,
+		CreatedByEmail: "alice@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits: []service.EqualSplitRequest{
+			{UserEmail: "alice@example.com", AmountPaid: 200},
+			{UserEmail: "bob@example.com"},
+		},
+	}, &tickets)
+	require.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, -100.0, overallBalance(t, srv, "bob@example.com"))
+
+	// Bob's ticket is refunded to Alice's card
+	refund := service.CreateExpenseRequest{
+		Description:    "Tickets refund",
+		TotalAmount:    100,
+		CreatedByEmail: "alice@example.com",
+		RefundOf:       tickets.PublicID,
+		SplitMethod:    service.SplitMethodManual,
+		ManualSplits: []service.ManualSplitRequest{
+			{UserEmail: "alice@example.com", AmountPaid: 100},
+			{UserEmail: "bob@example.com", AmountOwed: 100},
+		},
+	}
+	var created repository.Expense
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", refund, &created))
+	assert.Equal(t, -100.0, created.TotalAmount)
+	assert.Equal(t, tickets.PublicID, created.RefundOfPublicID)
+
+	assert.Equal(t, 0.0, overallBalance(t, srv, "bob@example.com"))
+	assert.Equal(t, 0.0, overallBalance(t, srv, "alice@example.com"))
+
+	var expenses []repository.UserExpenseView
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/expenses/by-user/bob@example.com", nil, &expenses))
+	require.Len(t, expenses, 2)
+	assert.Equal(t, 100.0, expenses[0].Share)
+
+	// Only 100 of the 200 is left to refund
+	refund.TotalAmount = 150
+	refund.ManualSplits = []service.ManualSplitRequest{
+		{UserEmail: "alice@example.com", AmountPaid: 150, AmountOwed: 150},
+	}
+	assert.Equal(t, http.StatusInternalServerError, call(t, srv, "POST", "/expenses", refund, nil))
+
+	// Refunds must point at an existing expense
+	refund.RefundOf = "9b2e4f0c-3d1a-4c8e-a7f2-5e6d8c9b0a1f"
+	assert.Equal(t, http.StatusNotFound, call(t, srv, "POST", "/expenses", refund, nil))
+}
+
+func TestE2E_TaxAndTip(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, u := range []struct{ Name, Email string }{
+		{"Alice", "alice@example.com"},
+		{"Bob", "bob@example.com"},
+	} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": u.Name, "email": u.Email}, nil))
+	}
+
+	// Dinner is 100 before 5 tax and a 15% tip; Alice pays the whole 120
+	var expense repository.Expense
+	status := call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Dinner",
+		TotalAmount:    100,
+		TaxAmount:      5,
+		TipPercentage:  15,
+		CreatedByEmail: "alice@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits: []service.EqualSplitRequest{
+			{UserEmail: "alice@example.com", AmountPaid: 120},
+			{UserEmail: "bob@example.com"},
+		},
+	}, &expense)
+	require.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, 120.0, expense.TotalAmount)
+	assert.Equal(t, -60.0, overallBalance(t, srv, "bob@example.com"))
+}
+
+func TestE2E_PayerOnly(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, u := range []struct{ Name, Email string }{
+		{"Parent", "parent@example.com"},
+		{"Kid One", "kid1@example.com"},
+		{"Kid Two", "kid2@example.com"},
+	} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": u.Name, "email": u.Email}, nil))
+	}
+
+	// The parent pays 90 for the kids' tickets and has no ticket of their own
+	tickets := service.CreateExpenseRequest{
+		Description:    "Museum tickets",
+		TotalAmount:    90,
+		CreatedByEmail: "parent@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits:    []service.EqualSplitRequest{{UserEmail: "kid1@example.com"}, {UserEmail: "kid2@example.com"}},
+	}
+
+	// Test case 1: Without the flag the payer has to be a participant
+	assert.Equal(t, http.StatusBadRequest, call(t, srv, "POST", "/expenses", tickets, nil))
+
+	// Test case 2: With it, each kid owes the parent their share and the parent owes nothing
+	tickets.PayerOnly = true
+	var expense repository.Expense
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", tickets, &expense))
+	assert.Len(t, expense.BalanceDeltas, 2)
+	assert.Equal(t, 90.0, overallBalance(t, srv, "parent@example.com"))
+	assert.Equal(t, -45.0, overallBalance(t, srv, "kid1@example.com"))
+	assert.Equal(t, -45.0, overallBalance(t, srv, "kid2@example.com"))
+
+	// Test case 3: The parent still sees what they paid for, all of it owed back to them
+	var expenses []repository.UserExpenseView
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/expenses/by-user/parent@example.com", nil, &expenses))
+	if assert.Len(t, expenses, 1) {
+		assert.Equal(t, "Museum tickets", expenses[0].Description)
+		assert.Equal(t, 90.0, expenses[0].Share)
+	}
+}
+
+func TestE2E_SimplifyDebts(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, u := range []struct{ Name, Email string }{
+		{"Alice", "alice@example.com"},
+		{"Bob", "bob@example.com"},
+		{"Carol", "carol@example.com"},
+	} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": u.Name, "email": u.Email}, nil))
+	}
+
+	// Bob owes Alice 30 for dinner, and Alice owes Carol 30 for the cab
+	for _, e := range []struct{ payer, other string }{{"alice@example.com", "bob@example.com"}, {"carol@example.com", "alice@example.com"}} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+			Description:    "Night out",
+			TotalAmount:    60,
+			CreatedByEmail: e.payer,
+			SplitMethod:    service.SplitMethodEqual,
+			EqualSplits:    []service.EqualSplitRequest{{UserEmail: e.payer, AmountPaid: 60}, {UserEmail: e.other}},
+		}, nil))
+	}
+
+	// Test case 1: Bob paying Carol on Alice's behalf settles both balances in one transfer
+	var simplification service.DebtSimplification
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/settlements/simplify/by-user/alice@example.com", nil, &simplification))
+	assert.Equal(t, 2, simplification.TransfersBefore)
+	assert.Equal(t, []service.SimplifiedTransfer{
+		{PayerEmail: "bob@example.com", PayeeEmail: "carol@example.com", ViaUserEmail: "alice@example.com", Amount: 30},
+	}, simplification.Transfers)
+
+	// Test case 2: Applying it proposes the settlement, once
+	var settlements []repository.Settlement
+	apply := service.ApplyDebtSimplificationRequest{UserEmail: "alice@example.com"}
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/settlements/simplify", apply, &settlements))
+	require.Len(t, settlements, 1)
+	assert.Equal(t, repository.SettlementProposed, settlements[0].Status)
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/settlements/simplify", apply, &settlements))
+	assert.Empty(t, settlements)
+
+	// Test case 3: Bob sees who he pays and on whose behalf
+	var views []service.SettlementView
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/settlements/by-user/bob@example.com", nil, &views))
+	if assert.Len(t, views, 1) {
+		assert.Equal(t, "paying", views[0].Direction)
+		assert.Equal(t, "carol@example.com", views[0].WithUserEmail)
+		assert.Equal(t, "alice@example.com", views[0].ViaUserEmail)
+	}
+
+	// Test case 4: Confirming the one transfer clears everyone
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", fmt.Sprintf("/settlements/%d/confirm", views[0].ID), service.TransitionSettlementRequest{UserEmail: "carol@example.com"}, nil))
+	for _, email := range []string{"alice@example.com", "bob@example.com", "carol@example.com"} {
+		assert.Equal(t, 0.0, overallBalance(t, srv, email), email)
+	}
+	var balances []service.UserBalanceView
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/balances/by-user/alice@example.com", nil, &balances))
+	for _, b := range balances {
+		assert.Zero(t, b.Amount, b.WithUserEmail)
+	}
+}
+
+func TestE2E_AgingReport(t *testing.T) {
+	srv, services := newTestServerWithServices(t)
+
+	for _, u := range []struct{ Name, Email string }{
+		{"Alice", "alice@example.com"},
+		{"Bob", "bob@example.com"},
+	} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": u.Name, "email": u.Email}, nil))
+	}
+	var expense repository.Expense
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Dinner",
+		TotalAmount:    60,
+		CreatedByEmail: "alice@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits:    []service.EqualSplitRequest{{UserEmail: "alice@example.com", AmountPaid: 60}, {UserEmail: "bob@example.com"}},
+	}, &expense))
+
+	// Test case 1: Bob's share of today's dinner is current, traced back to the dinner
+	var report service.AgingReport
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/balances/aging/alice@example.com", nil, &report))
+	if assert.Len(t, report.Balances, 1) {
+		assert.Equal(t, "bob@example.com", report.Balances[0].WithUserEmail)
+		assert.Equal(t, 30.0, report.Balances[0].Amount)
+		stored, err := services.Expense.GetExpenseByPublicID(expense.PublicID)
+		require.NoError(t, err)
+		assert.Equal(t, repository.LedgerSource{Type: repository.LedgerExpense, ID: stored.ID}, report.Balances[0].OldestSource)
+		assert.Equal(t, service.AgingCurrent, report.Balances[0].Bucket)
+	}
+
+	// Test case 2: Once Bob pays up there is nothing left to age
+	var settlement repository.Settlement
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/settlements", service.ProposeSettlementRequest{PayerEmail: "bob@example.com", PayeeEmail: "alice@example.com", Amount: 30}, &settlement))
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", fmt.Sprintf("/settlements/%d/confirm", settlement.ID), service.TransitionSettlementRequest{UserEmail: "alice@example.com"}, nil))
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/balances/aging/bob@example.com", nil, &report))
+	assert.Empty(t, report.Balances)
+}
+
+func TestE2E_PartialSettlement(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, u := range []struct{ Name, Email string }{
+		{"Alice", "alice@example.com"},
+		{"Bob", "bob@example.com"},
+	} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": u.Name, "email": u.Email}, nil))
+	}
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Concert tickets",
+		TotalAmount:    110,
+		CreatedByEmail: "alice@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits:    []service.EqualSplitRequest{{UserEmail: "alice@example.com", AmountPaid: 110}, {UserEmail: "bob@example.com"}},
+	}, nil))
+
+	// Test case 1: Bob pays $20 of the $55 he owes, leaving $35
+	var settlement repository.Settlement
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/settlements", service.ProposeSettlementRequest{PayerEmail: "bob@example.com", PayeeEmail: "alice@example.com", Amount: 20}, &settlement))
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", fmt.Sprintf("/settlements/%d/confirm", settlement.ID), service.TransitionSettlementRequest{UserEmail: "alice@example.com"}, nil))
+	var views []service.SettlementView
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/settlements/by-user/bob@example.com", nil, &views))
+	if assert.Len(t, views, 1) && assert.NotNil(t, views[0].RemainingAmount) {
+		assert.Equal(t, 55.0, *views[0].OutstandingAmount)
+		assert.Equal(t, 35.0, *views[0].RemainingAmount)
+	}
+
+	// Test case 2: The balance and the aging report show what has been paid towards it
+	var balances []service.UserBalanceView
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/balances/by-user/alice@example.com", nil, &balances))
+	if assert.Len(t, balances, 1) {
+		assert.Equal(t, 35.0, balances[0].Amount)
+		assert.Equal(t, 20.0, balances[0].PaidAmount)
+	}
+	var report service.AgingReport
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/balances/aging/alice@example.com", nil, &report))
+	if assert.Len(t, report.Balances, 1) {
+		assert.Equal(t, 35.0, report.Balances[0].Amount)
+		assert.Equal(t, 20.0, report.Balances[0].PaidAmount)
+	}
+
+	// Test case 3: Paying more than the 35 left is refused
+	assert.Equal(t, http.StatusUnprocessableEntity, call(t, srv, "POST", "/settlements", service.ProposeSettlementRequest{PayerEmail: "bob@example.com", PayeeEmail: "alice@example.com", Amount: 40}, nil))
+
+	// Test case 4: Paying the rest clears the balance and what was paid towards it
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/settlements", service.ProposeSettlementRequest{PayerEmail: "bob@example.com", PayeeEmail: "alice@example.com", Amount: 35}, &settlement))
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", fmt.Sprintf("/settlements/%d/confirm", settlement.ID), service.TransitionSettlementRequest{UserEmail: "alice@example.com"}, nil))
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/balances/aging/alice@example.com", nil, &report))
+	assert.Empty(t, report.Balances)
+	assert.Equal(t, 0.0, overallBalance(t, srv, "bob@example.com"))
+}
+
+func TestE2E_SettleOnBehalf(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, u := range []struct{ Name, Email string }{
+		{"Alice", "alice@example.com"},
+		{"Bob", "bob@example.com"},
+		{"Carol", "carol@example.com"},
+	} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": u.Name, "email": u.Email}, nil))
+	}
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Dinner",
+		TotalAmount:    60,
+		CreatedByEmail: "alice@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits:    []service.EqualSplitRequest{{UserEmail: "alice@example.com", AmountPaid: 60}, {UserEmail: "bob@example.com"}},
+	}, nil))
+
+	// Test case 1: Carol pays Alice the 30 Bob owes her, which Bob sees routed through him
+	var settlement repository.Settlement
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/settlements", service.ProposeSettlementRequest{
+		PayerEmail: "carol@example.com", PayeeEmail: "alice@example.com", OnBehalfOfEmail: "bob@example.com", Amount: 30,
+	}, &settlement))
+	var views []service.SettlementView
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/settlements/by-user/bob@example.com", nil, &views))
+	if assert.Len(t, views, 1) {
+		assert.Equal(t, "routed", views[0].Direction)
+		assert.Equal(t, "carol@example.com", views[0].WithUserEmail)
+		assert.Equal(t, "alice@example.com", views[0].ViaUserEmail)
+		assert.Nil(t, views[0].OutstandingAmount)
+		if assert.NotNil(t, views[0].ViaRemainingAmount) {
+			assert.Equal(t, 30.0, *views[0].ViaOutstandingAmount)
+			assert.Equal(t, 0.0, *views[0].ViaRemainingAmount)
+		}
+	}
+
+	// Test case 2: Confirming it clears Bob's debt to Alice and leaves him owing Carol
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", fmt.Sprintf("/settlements/%d/confirm", settlement.ID), service.TransitionSettlementRequest{UserEmail: "alice@example.com"}, nil))
+	assert.Equal(t, 0.0, overallBalance(t, srv, "alice@example.com"))
+	assert.Equal(t, -30.0, overallBalance(t, srv, "bob@example.com"))
+	assert.Equal(t, 30.0, overallBalance(t, srv, "carol@example.com"))
+
+	// Test case 3: Bob owes Alice nothing now, so Carol can't pay her on his behalf again
+	assert.Equal(t, http.StatusUnprocessableEntity, call(t, srv, "POST", "/settlements", service.ProposeSettlementRequest{
+		PayerEmail: "carol@example.com", PayeeEmail: "alice@example.com", OnBehalfOfEmail: "bob@example.com", Amount: 10,
+	}, nil))
+
+	// Test case 4: The user paid on behalf of has to be a third user
+	assert.Equal(t, http.StatusBadRequest, call(t, srv, "POST", "/settlements", service.ProposeSettlementRequest{
+		PayerEmail: "carol@example.com", PayeeEmail: "alice@example.com", OnBehalfOfEmail: "carol@example.com", Amount: 30,
+	}, nil))
+}
+
+func TestE2E_BalanceLists(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, u := range []struct{ Name, Email string }{
+		{"Alice", "alice@example.com"},
+		{"Bob", "bob@example.com"},
+		{"Carol", "carol@example.com"},
+		{"Dave", "dave@example.com"},
+	} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": u.Name, "email": u.Email}, nil))
+	}
+	for _, e := range []struct {
+		Payer, Other string
+		Total        float64
+	}{
+		{"alice@example.com", "bob@example.com", 60},
+		{"alice@example.com", "carol@example.com", 40},
+		{"dave@example.com", "alice@example.com", 50},
+	} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+			Description:    "Dinner",
+			TotalAmount:    e.Total,
+			CreatedByEmail: e.Payer,
+			SplitMethod:    service.SplitMethodEqual,
+			EqualSplits:    []service.EqualSplitRequest{{UserEmail: e.Payer, AmountPaid: e.Total}, {UserEmail: e.Other}},
+		}, nil))
+	}
+
+	// Test case 1: What Alice is owed, largest first
+	var balances []service.UserBalanceView
+	env := response.Envelope{Data: &balances}
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/balances/owed-to-me/alice@example.com", nil, &env))
+	require.NotNil(t, env.Meta.TotalAmount)
+	assert.Equal(t, 50.0, *env.Meta.TotalAmount)
+	assert.Nil(t, env.Meta.Pagination)
+	if assert.Len(t, balances, 2) {
+		assert.Equal(t, "bob@example.com", balances[0].WithUserEmail)
+		assert.Equal(t, 30.0, balances[0].Amount)
+		assert.Equal(t, "carol@example.com", balances[1].WithUserEmail)
+	}
+
+	// Test case 2: A page keeps the count and total of the whole list
+	balances = nil
+	env = response.Envelope{Data: &balances}
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/balances/owed-to-me/alice@example.com?limit=1&offset=1", nil, &env))
+	assert.Equal(t, 50.0, *env.Meta.TotalAmount)
+	assert.Equal(t, &response.Pagination{Limit: 1, Offset: 1, Total: 2}, env.Meta.Pagination)
+	if assert.Len(t, balances, 1) {
+		assert.Equal(t, "carol@example.com", balances[0].WithUserEmail)
+	}
+
+	// Test case 3: What Alice owes, as a positive amount
+	balances = nil
+	env = response.Envelope{Data: &balances}
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/balances/i-owe/alice@example.com", nil, &env))
+	assert.Equal(t, 25.0, *env.Meta.TotalAmount)
+	if assert.Len(t, balances, 1) {
+		assert.Equal(t, "dave@example.com", balances[0].WithUserEmail)
+		assert.Equal(t, 25.0, balances[0].Amount)
+	}
+
+	// Test case 4: A page of Alice's expenses is counted and balanced against the whole history
+	var expenses []repository.UserExpenseView
+	env = response.Envelope{Data: &expenses}
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/expenses/by-user/alice@example.com?limit=1&offset=1&running_balance=true", nil, &env))
+	assert.Equal(t, &response.Pagination{Limit: 1, Offset: 1, Total: 3}, env.Meta.Pagination)
+	if assert.Len(t, expenses, 1) && assert.NotNil(t, expenses[0].RunningBalance) {
+		assert.Equal(t, 20.0, expenses[0].Share)
+		assert.Equal(t, 50.0, *expenses[0].RunningBalance)
+	}
+}
+
+func TestE2E_NextPayer(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, u := range []struct{ Name, Email string }{
+		{"Alice", "alice@example.com"},
+		{"Bob", "bob@example.com"},
+	} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": u.Name, "email": u.Email}, nil))
+	}
+
+	// Alice picks up dinner, so Bob is next
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Dinner",
+		TotalAmount:    60,
+		CreatedByEmail: "alice@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits: []service.EqualSplitRequest{
+			{UserEmail: "alice@example.com", AmountPaid: 60},
+			{UserEmail: "bob@example.com"},
+		},
+	}, nil))
+
+	var suggestion service.NextPayerSuggestion
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/analytics/next-payer?emails=alice@example.com,bob@example.com", nil, &suggestion))
+	assert.Equal(t, "bob@example.com", suggestion.UserEmail)
+	require.Len(t, suggestion.Standings, 2)
+	assert.Equal(t, 30.0, suggestion.Standings[0].Owed)
+
+	require.Equal(t, http.StatusBadRequest, call(t, srv, "GET", "/analytics/next-payer?emails=alice@example.com", nil, nil))
+}
+
+func TestE2E_AuditLog(t *testing.T) {
+	srv := newTestServer(t)
+
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": "Alice", "email": "alice@example.com"}, nil))
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": "Bob", "email": "bob@example.com"}, nil))
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/users/by-email/alice@example.com", nil, nil))
+
+	var entries []repository.AuditLog
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/admin/audit?user=bob@example.com", nil, &entries))
+	require.Len(t, entries, 1)
+	assert.Equal(t, "/users", entries[0].Route)
+	assert.Equal(t, http.StatusCreated, entries[0].Status)
+
+	// Reads, including the audit query itself, are not recorded
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/admin/audit", nil, &entries))
+	assert.Len(t, entries, 2)
+}
+
+func TestE2E_JobLifecycle(t *testing.T) {
+	srv, services := newTestServerWithServices(t)
+
+	// The first run fails and is retried straight away since there is no backoff
+	runs := 0
+	services.Jobs.Register("flaky", func(ctx context.Context, payload json.RawMessage) error {
+		runs++
+		if runs == 1 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+	services.Jobs.Register("broken", func(ctx context.Context, payload json.RawMessage) error {
+		return errors.New("permanent failure")
+	})
+
+	flaky, err := services.Jobs.Enqueue("flaky", nil)
+	require.NoError(t, err)
+	broken, err := services.Jobs.Enqueue("broken", nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		services.Jobs.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	var job repository.Job
+	require.Eventually(t, func() bool {
+		call(t, srv, "GET", fmt.Sprintf("/jobs/%d", broken.ID), nil, &job)
+		return job.Status == repository.JobDead
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 2, job.Attempts)
+	assert.Equal(t, "permanent failure", job.LastError)
+
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", fmt.Sprintf("/jobs/%d", flaky.ID), nil, &job))
+	assert.Equal(t, repository.JobSucceeded, job.Status)
+	assert.Equal(t, 2, job.Attempts)
+
+	require.Equal(t, http.StatusNotFound, call(t, srv, "GET", "/jobs/999", nil, nil))
+}
+
+func TestE2E_ChunkedImport(t *testing.T) {
+	srv, services := newTestServerWithServices(t)
+	for _, u := range []struct{ Name, Email string }{{"Alice", "import-alice@example.com"}, {"Bob", "import-bob@example.com"}} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": u.Name, "email": u.Email}, nil))
+	}
+
+	var session repository.ImportSession
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/imports", map[string]string{"user_email": "import-alice@example.com"}, &session))
+	assert.Equal(t, repository.ImportOpen, session.Status)
+
+	// The file is split mid-row; chunks go up out of order, and the second is resent after a failure
+	file := "description,amount,paid_by,split_between\n"
+	for i := 0; i < 250; i++ {
+		file += fmt.Sprintf("Coffee %d,4.00,import-alice@example.com,import-alice@example.com;import-bob@example.com\n", i)
+	}
+	chunks := []string{file[:5000], file[5000:10000], file[10000:]}
+	base := fmt.Sprintf("/imports/%d", session.ID)
+	for _, seq := range []int{2, 0, 1, 1} {
+		require.Equal(t, http.StatusOK, call(t, srv, "PUT", fmt.Sprintf("%s/chunks/%d", base, seq), map[string][]byte{"data": []byte(chunks[seq])}, &session))
+	}
+	assert.Equal(t, []int{0, 1, 2}, session.ReceivedChunks)
+
+	require.Equal(t, http.StatusBadRequest, call(t, srv, "POST", base+"/commit", map[string]int{"chunk_count": 4}, nil))
+	require.Equal(t, http.StatusAccepted, call(t, srv, "POST", base+"/commit", map[string]int{"chunk_count": 3}, &session))
+	require.NotNil(t, session.JobID)
+	require.Equal(t, http.StatusConflict, call(t, srv, "PUT", base+"/chunks/3", map[string][]byte{"data": []byte("x")}, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		services.Jobs.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	var job repository.Job
+	require.Eventually(t, func() bool {
+		call(t, srv, "GET", fmt.Sprintf("/jobs/%d", *session.JobID), nil, &job)
+		return job.Status == repository.JobSucceeded
+	}, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, &repository.JobProgress{Done: 250, Total: 250}, job.Progress)
+
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", base, nil, &session))
+	assert.Equal(t, repository.ImportDone, session.Status)
+	assert.Equal(t, 250, session.RowsImported)
+	assert.InDelta(t, 500.0, overallBalance(t, srv, "import-alice@example.com"), 0.001)
+}
+
+func TestE2E_HeadAndOptions(t *testing.T) {
+	srv := newTestServer(t)
+
+	do := func(method, path string) *http.Response {
+		req, err := http.NewRequest(method, srv.URL+path, nil)
+		require.NoError(t, err)
+		resp, err := srv.Client().Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	// Test case 1: HEAD works wherever GET does
+	resp := do("HEAD", "/health")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Test case 2: OPTIONS lists the methods of the path
+	resp = do("OPTIONS", "/expenses")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "POST, OPTIONS", resp.Header.Get("Allow"))
+
+	resp = do("OPTIONS", "/ui/new-expense")
+	assert.Equal(t, "GET, HEAD, POST, OPTIONS", resp.Header.Get("Allow"))
+
+	// Test case 3: A wrong method gets a 405 that says what would have worked
+	resp = do("DELETE", "/users/1")
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	assert.Equal(t, "GET, HEAD, OPTIONS", resp.Header.Get("Allow"))
+}
+
+func TestE2E_ResponseEnvelope(t *testing.T) {
+	srv, services := newTestServerWithServices(t)
+	legacy := httptest.NewServer(response.Legacy(NewRouter(services, Options{})))
+	defer legacy.Close()
+
+	get := func(srv *httptest.Server, path string) (*http.Response, string) {
+		resp, err := srv.Client().Get(srv.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	// Test case 1: Errors from handlers and from the router alike are enveloped
+	for path, status := range map[string]int{"/users/999": http.StatusNotFound, "/nowhere": http.StatusNotFound, "/expenses/by-user/alice@example.com?limit=0": http.StatusBadRequest} {
+		resp, body := get(srv, path)
+		assert.Equal(t, status, resp.StatusCode, path)
+		var env response.Envelope
+		require.NoError(t, json.Unmarshal([]byte(body), &env), path)
+		assert.NotNil(t, env.Error, path)
+		assert.Nil(t, env.Data, path)
+	}
+
+	// Test case 2: In legacy mode payloads are bare and errors plain text
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": "Alice", "email": "alice@example.com"}, nil))
+	_, body := get(legacy, "/users/by-email/alice@example.com")
+	assert.Contains(t, body, `"name":"Alice"`)
+	assert.NotContains(t, body, `"data"`)
+
+	resp, body := get(legacy, "/expenses/by-user/alice@example.com?limit=0")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.NotContains(t, body, `"error"`)
+}
+
+func TestE2E_EmailCaseAndTrailingSlash(t *testing.T) {
+	srv := newTestServer(t)
+
+	var alice repository.User
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users/", map[string]string{"name": "Alice", "email": " Alice@Example.com"}, &alice))
+	assert.Equal(t, "alice@example.com", alice.Email)
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": "Bob", "email": "bob@example.com"}, nil))
+
+	// Test case 1: An email differing only in case is already taken
+	assert.Equal(t, http.StatusConflict, call(t, srv, "POST", "/users", map[string]string{"name": "Alice Again", "email": "ALICE@example.com"}, nil))
+
+	// Test case 2: Participants resolve whatever the case, and count once
+	status := call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Lunch",
+		Tag:            "Food",
+		TotalAmount:    40,
+		CreatedByEmail: "ALICE@EXAMPLE.COM",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits: []service.EqualSplitRequest{
+			{UserEmail: "Alice@Example.com", AmountPaid: 40},
+			{UserEmail: "Bob@Example.com"},
+		},
+	}, nil)
+	require.Equal(t, http.StatusCreated, status)
+
+	// Test case 3: Lookups by email and paths with a trailing slash match too
+	assert.Equal(t, 20.0, overallBalance(t, srv, "Alice@Example.com"))
+	var found repository.User
+	assert.Equal(t, http.StatusOK, call(t, srv, "GET", "/users/by-email/BOB@example.com/", nil, &found))
+	assert.Equal(t, "bob@example.com", found.Email)
+}
+
+func TestE2E_UserLookupParams(t *testing.T) {
+	srv := newTestServer(t)
+
+	var tom, ann repository.User
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": "Tom", "email": "tom+trips@example.com"}, &tom))
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": "Ann", "email": "ann/home@example.com"}, &ann))
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Tickets",
+		Tag:            "Travel",
+		TotalAmount:    50,
+		CreatedByEmail: "tom+trips@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits: []service.EqualSplitRequest{
+			{UserEmail: "tom+trips@example.com", AmountPaid: 50},
+			{UserEmail: "ann/home@example.com"},
+		},
+	}, nil))
+
+	overall := func(path string) (int, float64) {
+		var resp struct {
+			OverallBalance float64 `json:"overall_balance"`
+		}
+		status := call(t, srv, "GET", path, nil, &resp)
+		return status, resp.OverallBalance
+	}
+
+	// Test case 1: Plus signs work literally and percent-encoded
+	for _, path := range []string{
+		"/balances/overall/by-user/tom+trips@example.com",
+		"/balances/overall/by-user/tom%2Btrips%40example.com",
+	} {
+		status, balance := overall(path)
+		assert.Equal(t, http.StatusOK, status, path)
+		assert.Equal(t, 25.0, balance, path)
+	}
+
+	// Test case 2: An encoded slash stays inside the segment
+	status, balance := overall("/balances/overall/by-user/ann%2Fhome%40example.com")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, -25.0, balance)
+
+	// Test case 3: The same lookups by user ID
+	status, balance = overall(fmt.Sprintf("/balances/overall/by-user-id/%s", ann.PublicID))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, -25.0, balance)
+
+	var expenses []repository.UserExpenseView
+	assert.Equal(t, http.StatusOK, call(t, srv, "GET", fmt.Sprintf("/expenses/by-user-id/%s", tom.PublicID), nil, &expenses))
+	require.Len(t, expenses, 1)
+
+	assert.Equal(t, http.StatusNotFound, call(t, srv, "GET", "/balances/by-user-id/999", nil, nil))
+
+	// Test case 4: Expense views name the creator and whether the viewer paid
+	assert.Equal(t, "Tom", expenses[0].CreatedByName)
+	assert.Equal(t, "tom+trips@example.com", expenses[0].CreatedByEmail)
+	assert.True(t, expenses[0].IsPayer)
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", fmt.Sprintf("/expenses/by-user-id/%s", ann.PublicID), nil, &expenses))
+	require.Len(t, expenses, 1)
+	assert.Equal(t, "Tom", expenses[0].CreatedByName)
+	assert.False(t, expenses[0].IsPayer)
+}
+
+func TestE2E_PublicIDs(t *testing.T) {
+	srv := newTestServer(t)
+
+	// Test case 1: A public ID of the client's own is kept, and others are generated
+	var ivy, jon repository.User
+	ivyID := "01HZX3Q8K2M4N6P8R0T2V4W6Y8"
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": "Ivy", "email": "ivy@ids.example", "public_id": ivyID}, &ivy))
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": "Jon", "email": "jon@ids.example"}, &jon))
+	assert.Equal(t, ivyID, ivy.PublicID)
+	assert.True(t, util.ValidPublicID(jon.PublicID))
+	assert.Equal(t, http.StatusConflict, call(t, srv, "POST", "/users", map[string]string{"name": "Kim", "email": "kim@ids.example", "public_id": ivyID}, nil))
+
+	// Test case 2: Users are found by their public ID
+	var got repository.User
+	assert.Equal(t, http.StatusOK, call(t, srv, "GET", "/users/"+ivyID, nil, &got))
+	assert.Equal(t, ivy.PublicID, got.PublicID)
+	assert.Equal(t, http.StatusOK, call(t, srv, "GET", "/balances/overall/by-user-id/"+jon.PublicID, nil, nil))
+	assert.Equal(t, http.StatusNotFound, call(t, srv, "GET", "/users/9b2e4f0c-3d1a-4c8e-a7f2-5e6d8c9b0a1f", nil, nil))
+
+	// Test case 3: Expenses are acted on by their public ID
+	var expense repository.Expense
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Ferry",
+		TotalAmount:    30,
+		CreatedByEmail: "ivy@ids.example",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits:    []service.EqualSplitRequest{{UserEmail: "ivy@ids.example", AmountPaid: 30}, {UserEmail: "jon@ids.example"}},
+	}, &expense))
+	require.True(t, util.ValidPublicID(expense.PublicID))
+	assert.Equal(t, http.StatusNoContent, call(t, srv, "DELETE", "/expenses/"+expense.PublicID+"?user_email=ivy@ids.example", nil, nil))
+	assert.Equal(t, http.StatusNotFound, call(t, srv, "DELETE", "/expenses/"+expense.PublicID+"?user_email=ivy@ids.example", nil, nil))
+}
+
+func TestE2E_TagBudgets(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, email := range []string{"alice@example.com", "bob@example.com"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": email, "email": email}, nil))
+	}
+	require.Equal(t, http.StatusNoContent, call(t, srv, "PUT", "/budgets", service.SetTagBudgetRequest{UserEmail: "bob@example.com", Tag: "Eating Out", MonthlyLimit: 100}, nil))
+
+	dinner := func(amount float64) (int, repository.Expense) {
+		var expense repository.Expense
+		status := call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+			Description:    "Dinner",
+			Tag:            "Eating Out",
+			TotalAmount:    amount,
+			CreatedByEmail: "alice@example.com",
+			SplitMethod:    service.SplitMethodEqual,
+			EqualSplits: []service.EqualSplitRequest{
+				{UserEmail: "alice@example.com", AmountPaid: amount},
+				{UserEmail: "bob@example.com"},
+			},
+		}, &expense)
+		return status, expense
+	}
+
+	// Test case 1: Within the budget nothing is said
+	status, expense := dinner(160)
+	require.Equal(t, http.StatusCreated, status)
+	assert.Empty(t, expense.BudgetWarnings)
+
+	// Test case 2: Going over is allowed, with a warning
+	status, expense = dinner(60)
+	require.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, []repository.BudgetWarning{
+		{UserEmail: "bob@example.com", Tag: "Eating Out", Currency: "INR", MonthlyLimit: 100, SpentAfter: 110},
+	}, expense.BudgetWarnings)
+
+	// Test case 3: The status shows the overrun
+	var statuses []service.BudgetStatus
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/budgets/by-user/bob@example.com", nil, &statuses))
+	assert.Equal(t, []service.BudgetStatus{{Tag: "Eating Out", Currency: "INR", MonthlyLimit: 100, Spent: 110, Remaining: -10}}, statuses)
+}
+
+func TestE2E_YearInReview(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, email := range []string{"alice@example.com", "bob@example.com"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": email, "email": email}, nil))
+	}
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Concert",
+		Tag:            "Fun",
+		TotalAmount:    80,
+		CreatedByEmail: "alice@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits: []service.EqualSplitRequest{
+			{UserEmail: "alice@example.com", AmountPaid: 80},
+			{UserEmail: "bob@example.com"},
+		},
+	}, nil))
+
+	year := time.Now().UTC().Year()
+	var review service.YearInReview
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", fmt.Sprintf("/analytics/year-in-review/bob@example.com?year=%d", year), nil, &review))
+	assert.Equal(t, 1, review.ExpenseCount)
+	assert.Equal(t, 40.0, review.TotalOwed)
+	if assert.NotNil(t, review.TopCoSpender) {
+		assert.Equal(t, "alice@example.com", review.TopCoSpender.UserEmail)
+	}
+
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", fmt.Sprintf("/analytics/year-in-review/bob@example.com?year=%d", year-1), nil, &review))
+	assert.Zero(t, review.ExpenseCount)
+}
+
+func TestE2E_Counterparties(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, email := range []string{"alice@example.com", "bob@example.com", "carol@example.com"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": email, "email": email}, nil))
+	}
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Cabin",
+		Tag:            "Travel",
+		TotalAmount:    300,
+		CreatedByEmail: "alice@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits: []service.EqualSplitRequest{
+			{UserEmail: "alice@example.com", AmountPaid: 300},
+			{UserEmail: "bob@example.com"},
+			{UserEmail: "carol@example.com"},
+		},
+	}, nil))
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Snacks",
+		Tag:            "Food",
+		TotalAmount:    20,
+		CreatedByEmail: "bob@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits: []service.EqualSplitRequest{
+			{UserEmail: "alice@example.com"},
+			{UserEmail: "bob@example.com", AmountPaid: 20},
+		},
+	}, nil))
+
+	var settlement repository.Settlement
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/settlements", service.ProposeSettlementRequest{PayerEmail: "carol@example.com", PayeeEmail: "alice@example.com", Amount: 100}, &settlement))
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", fmt.Sprintf("/settlements/%d/confirm", settlement.ID), service.TransitionSettlementRequest{UserEmail: "alice@example.com"}, nil))
+
+	var counterparties []service.Counterparty
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/analytics/counterparties/alice@example.com", nil, &counterparties))
+	require.Len(t, counterparties, 2)
+
+	assert.Equal(t, "bob@example.com", counterparties[0].UserEmail)
+	assert.Equal(t, 2, counterparties[0].ExpenseCount)
+	assert.Equal(t, 320.0, counterparties[0].TotalShared)
+	assert.Equal(t, 90.0, counterparties[0].Balance)
+	assert.Nil(t, counterparties[0].AverageSettleHours)
+
+	assert.Equal(t, "carol@example.com", counterparties[1].UserEmail)
+	assert.Equal(t, 0.0, counterparties[1].Balance)
+	assert.Equal(t, 1, counterparties[1].SettlementCount)
+	assert.NotNil(t, counterparties[1].AverageSettleHours)
+}
+
+func TestE2E_Heatmap(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, email := range []string{"alice@example.com", "bob@example.com"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": email, "email": email}, nil))
+	}
+	for _, amount := range []float64{30, 10} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+			Description:    "Coffee",
+			Tag:            "Food",
+			TotalAmount:    amount,
+			CreatedByEmail: "alice@example.com",
+			SplitMethod:    service.SplitMethodEqual,
+			EqualSplits: []service.EqualSplitRequest{
+				{UserEmail: "alice@example.com", AmountPaid: amount},
+				{UserEmail: "bob@example.com"},
+			},
+		}, nil))
+	}
+
+	now := time.Now().UTC()
+	var heatmap service.Heatmap
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", fmt.Sprintf("/analytics/heatmap/bob@example.com?year=%d", now.Year()), nil, &heatmap))
+	assert.GreaterOrEqual(t, len(heatmap.Days), 365)
+	today := heatmap.Days[now.YearDay()-1]
+	assert.Equal(t, service.HeatmapDay{Date: now.Format("2006-01-02"), Amount: 20, ExpenseCount: 2}, today)
+	assert.Equal(t, 20.0, heatmap.Max)
+}
+
+func TestE2E_Goals(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, email := range []string{"alice@example.com", "bob@example.com"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": email, "email": email}, nil))
+	}
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Rent",
+		Tag:            "Home",
+		TotalAmount:    200,
+		CreatedByEmail: "alice@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits: []service.EqualSplitRequest{
+			{UserEmail: "alice@example.com", AmountPaid: 200},
+			{UserEmail: "bob@example.com"},
+		},
+	}, nil))
+
+	deadline := time.Now().UTC().AddDate(0, 1, 0).Format("2006-01-02")
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/goals", service.CreateGoalRequest{UserEmail: "alice@example.com", Deadline: deadline}, nil))
+
+	progress := func() service.GoalProgress {
+		var progress []service.GoalProgress
+		require.Equal(t, http.StatusOK, call(t, srv, "GET", "/goals/by-user/alice@example.com", nil, &progress))
+		require.Len(t, progress, 1)
+		return progress[0]
+	}
+
+	// Test case 1: Bob is nudged for everything he owes
+	p := progress()
+	assert.Equal(t, 100.0, p.StartingBalance)
+	assert.Equal(t, 0.0, p.Progress)
+	assert.Equal(t, []service.GoalNudge{{UserEmail: "bob@example.com", UserName: "bob@example.com", Amount: 100}}, p.Nudges)
+
+	// Test case 2: A confirmed settlement moves the goal along
+	var settlement repository.Settlement
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/settlements", service.ProposeSettlementRequest{PayerEmail: "bob@example.com", PayeeEmail: "alice@example.com", Amount: 100}, &settlement))
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", fmt.Sprintf("/settlements/%d/confirm", settlement.ID), service.TransitionSettlementRequest{UserEmail: "alice@example.com"}, nil))
+
+	p = progress()
+	assert.True(t, p.Achieved)
+	assert.Empty(t, p.Nudges)
+}
+
+func TestE2E_ExternalPayee(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, email := range []string{"alice@example.com", "bob@example.com"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": email, "email": email}, nil))
+	}
+
+	var landlord repository.Party
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/parties", service.CreatePartyRequest{Name: "Landlord"}, &landlord))
+	assert.Equal(t, http.StatusConflict, call(t, srv, "POST", "/parties", service.CreatePartyRequest{Name: "Landlord"}, nil))
+
+	rent := service.CreateExpenseRequest{
+		Description:    "Rent",
+		Tag:            "Home",
+		TotalAmount:    1000,
+		CreatedByEmail: "alice@example.com",
+		PayeePartyID:   &landlord.ID,
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits: []service.EqualSplitRequest{
+			{UserEmail: "alice@example.com", AmountPaid: 1000},
+			{UserEmail: "bob@example.com"},
+		},
+	}
+
+	// Test case 1: The landlord is named on the expense but takes no share
+	var expense repository.Expense
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", rent, &expense))
+	require.NotNil(t, expense.PayeeParty)
+	assert.Equal(t, "Landlord", expense.PayeeParty.Name)
+	assert.Equal(t, 500.0, overallBalance(t, srv, "alice@example.com"))
+
+	var history []repository.UserExpenseView
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/expenses/by-user/bob@example.com", nil, &history))
+	require.Len(t, history, 1)
+	assert.Equal(t, "Landlord", history[0].Payee)
+
+	// Test case 2: Unknown parties are rejected
+	missing := 99
+	rent.PayeePartyID = &missing
+	assert.Equal(t, http.StatusNotFound, call(t, srv, "POST", "/expenses", rent, nil))
+
+	var parties []repository.Party
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/parties", nil, &parties))
+	assert.Len(t, parties, 1)
+}
+
+func TestE2E_TagSuggestions(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, email := range []string{"alice@example.com", "bob@example.com"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": email, "email": email}, nil))
+	}
+
+	var rule repository.TagRule
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/tag-rules", service.CreateTagRuleRequest{Keyword: "Uber", Tag: "Transport"}, &rule))
+	assert.Equal(t, "uber", rule.Keyword)
+	assert.Equal(t, http.StatusConflict, call(t, srv, "POST", "/tag-rules", service.CreateTagRuleRequest{Keyword: "UBER", Tag: "Taxi"}, nil))
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/tag-rules", service.CreateTagRuleRequest{Keyword: "uber eats", Tag: "Food"}, nil))
+
+	// Test case 1: The longest matching keyword wins
+	var suggested handler.TagSuggestionResponse
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/tags/suggest?description=Uber+Eats+order", nil, &suggested))
+	require.NotNil(t, suggested.Suggestion)
+	assert.Equal(t, "Food", suggested.Suggestion.Tag)
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/tags/suggest?description=Uberlandia", nil, &suggested))
+	assert.Nil(t, suggested.Suggestion)
+
+	// Test case 2: An expense created without a tag comes back with a suggestion, but isn't tagged
+	ride := service.CreateExpenseRequest{
+		Description:    "Uber to the airport",
+		TotalAmount:    30,
+		CreatedByEmail: "alice@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits: []service.EqualSplitRequest{
+			{UserEmail: "alice@example.com", AmountPaid: 30},
+			{UserEmail: "bob@example.com"},
+		},
+	}
+	var expense repository.Expense
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", ride, &expense))
+	assert.Equal(t, "Transport", expense.SuggestedTag)
+	assert.Empty(t, expense.Tag)
+
+	// Test case 3: Nothing is suggested once the client picked a tag
+	ride.Tag = "Travel"
+	var tagged repository.Expense
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", ride, &tagged))
+	assert.Empty(t, tagged.SuggestedTag)
+
+	// Test case 4: Deleted rules stop matching
+	assert.Equal(t, http.StatusNoContent, call(t, srv, "DELETE", fmt.Sprintf("/tag-rules/%d", rule.ID), nil, nil))
+	assert.Equal(t, http.StatusNotFound, call(t, srv, "DELETE", fmt.Sprintf("/tag-rules/%d", rule.ID), nil, nil))
+	var rules []repository.TagRule
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/tag-rules", nil, &rules))
+	assert.Len(t, rules, 1)
+}
+
+func TestE2E_TagRulesNeedAdmin(t *testing.T) {
+	_, services := newTestServerWithServices(t)
+	srv := httptest.NewServer(NewRouter(services, Options{AdminMiddleware: []middleware.Middleware{middleware.BasicAuth("admin", "admin", "secret")}}))
+	t.Cleanup(srv.Close)
+	asAdmin := func(method, path string, body string) int {
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.SetBasicAuth("admin", "secret")
+		resp, err := srv.Client().Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Test case 1: Anyone can list the rules, but only an admin can change them
+	assert.Equal(t, http.StatusOK, call(t, srv, "GET", "/tag-rules", nil, nil))
+	assert.Equal(t, http.StatusUnauthorized, call(t, srv, "POST", "/tag-rules", service.CreateTagRuleRequest{Keyword: "uber", Tag: "Transport"}, nil))
+	assert.Equal(t, http.StatusUnauthorized, call(t, srv, "DELETE", "/tag-rules/1", nil, nil))
+
+	// Test case 2: The admin's credentials let the change through
+	assert.Equal(t, http.StatusCreated, asAdmin("POST", "/tag-rules", `{"keyword":"uber","tag":"Transport"}`))
+	var rules []repository.TagRule
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/tag-rules", nil, &rules))
+	require.Len(t, rules, 1)
+	assert.Equal(t, http.StatusNoContent, asAdmin("DELETE", fmt.Sprintf("/tag-rules/%d", rules[0].ID), ""))
+}
+
+func TestE2E_CreateExpenseReturnsSplits(t *testing.T) {
+	srv, services := newTestServerWithServices(t)
+
+	users := map[string]repository.User{}
+	for _, email := range []string{"alice@example.com", "bob@example.com", "carol@example.com"} {
+		var u repository.User
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": email, "email": email}, &u))
+		users[email] = u
+	}
+	alice, err := services.User.GetUserByPublicID(users["alice@example.com"].PublicID)
+	require.NoError(t, err)
+	carol, err := services.User.GetUserByPublicID(users["carol@example.com"].PublicID)
+	require.NoError(t, err)
+
+	var expense repository.Expense
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Dinner",
+		TotalAmount:    90,
+		CreatedByEmail: "alice@example.com",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits: []service.EqualSplitRequest{
+			{UserEmail: "alice@example.com", AmountPaid: 60},
+			{UserEmail: "bob@example.com", AmountPaid: 30},
+			{UserEmail: "carol@example.com"},
+		},
+	}, &expense))
+
+	require.Len(t, expense.Splits, 3)
+	for _, s := range expense.Splits {
+		assert.Equal(t, 30.0, s.AmountOwed)
+	}
+	// Bob paid exactly his share, so only Carol's debt to Alice moves
+	assert.Equal(t, []repository.BalanceDelta{{FromUserID: carol.ID, ToUserID: alice.ID, Amount: 30}}, expense.BalanceDeltas)
+}
+
+func TestE2E_NearbyExpenses(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, email := range []string{"alice@example.com", "bob@example.com"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": email, "email": email}, nil))
+	}
+	expenseAt := func(description string, location *repository.Location) service.CreateExpenseRequest {
+		return service.CreateExpenseRequest{
+			Description:    description,
+			TotalAmount:    40,
+			CreatedByEmail: "alice@example.com",
+			Location:       location,
+			SplitMethod:    service.SplitMethodEqual,
+			EqualSplits: []service.EqualSplitRequest{
+				{UserEmail: "alice@example.com", AmountPaid: 40},
+				{UserEmail: "bob@example.com"},
+			},
+		}
+	}
+
+	var dosa repository.Expense
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", expenseAt("Dosa", &repository.Location{Latitude: 12.9716, Longitude: 77.5946, PlaceName: "Vidyarthi Bhavan"}), &dosa))
+	require.NotNil(t, dosa.Location)
+	assert.Equal(t, "Vidyarthi Bhavan", dosa.Location.PlaceName)
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", expenseAt("Coffee", &repository.Location{Latitude: 12.9750, Longitude: 77.5946}), nil))
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", expenseAt("Airport cab", &repository.Location{Latitude: 13.1986, Longitude: 77.7066}), nil))
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", expenseAt("Groceries", nil), nil))
+
+	// Test case 1: Only expenses within the radius, nearest first
+	var nearby []repository.NearbyExpense
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/expenses/by-user/bob@example.com/nearby?lat=12.9716&lng=77.5946&radius=1000", nil, &nearby))
+	require.Len(t, nearby, 2)
+	assert.Equal(t, "Dosa", nearby[0].Description)
+	assert.Equal(t, -20.0, nearby[0].Share)
+	assert.Equal(t, "Coffee", nearby[1].Description)
+	assert.InDelta(t, 378, nearby[1].DistanceMeters, 2)
+
+	// Test case 2: Out-of-range coordinates are rejected when recording
+	assert.Equal(t, http.StatusBadRequest, call(t, srv, "POST", "/expenses", expenseAt("Nowhere", &repository.Location{Latitude: 95, Longitude: 0}), nil))
+}
+
+func TestE2E_Events(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, email := range []string{"alice@example.com", "bob@example.com", "carol@example.com"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": email, "email": email}, nil))
+	}
+
+	var trip repository.Event
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/events", service.CreateEventRequest{Name: "Goa trip", CreatedByEmail: "alice@example.com"}, &trip))
+
+	expense := func(desc string, amount float64, payer string, eventID *int) service.CreateExpenseRequest {
+		req := service.CreateExpenseRequest{
+			Description:    desc,
+			TotalAmount:    amount,
+			CreatedByEmail: payer,
+			EventID:        eventID,
+			SplitMethod:    service.SplitMethodEqual,
+		}
+		for _, email := range []string{"alice@example.com", "bob@example.com", "carol@example.com"} {
+			split := service.EqualSplitRequest{UserEmail: email}
+			if email == payer {
+				split.AmountPaid = amount
+			}
+			req.EqualSplits = append(req.EqualSplits, split)
+		}
+		return req
+	}
+
+	// Test case 1: Only the event's expenses count towards its ledger
+	var hotel repository.Expense
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", expense("Hotel", 300, "alice@example.com", &trip.ID), &hotel))
+	require.NotNil(t, hotel.EventID)
+	assert.Equal(t, trip.ID, *hotel.EventID)
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", expense("Taxi", 60, "bob@example.com", &trip.ID), nil))
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", expense("Groceries at home", 90, "carol@example.com", nil), nil))
+
+	var summary service.EventSummary
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", fmt.Sprintf("/events/%d", trip.ID), nil, &summary))
+	require.Len(t, summary.Totals, 1)
+	assert.Equal(t, 360.0, summary.Totals[0].TotalAmount)
+	assert.Equal(t, 2, summary.Totals[0].ExpenseCount)
+	assert.Len(t, summary.Participants, 3)
+
+	// Test case 2: Settle-up squares the event in at most n-1 transfers
+	require.Len(t, summary.SettleUp, 2)
+	received := map[string]float64{}
+	for _, tr := range summary.SettleUp {
+		received[tr.ToEmail] += tr.Amount
+		received[tr.FromEmail] -= tr.Amount
+	}
+	assert.Equal(t, 180.0, received["alice@example.com"])
+	assert.Equal(t, -60.0, received["bob@example.com"])
+	assert.Equal(t, -120.0, received["carol@example.com"])
+
+	// Test case 3: Only the creator can archive, after which the event takes no more expenses
+	assert.Equal(t, http.StatusForbidden, call(t, srv, "POST", fmt.Sprintf("/events/%d/archive", trip.ID), service.ArchiveEventRequest{UserEmail: "bob@example.com"}, nil))
+	var archived repository.Event
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", fmt.Sprintf("/events/%d/archive", trip.ID), service.ArchiveEventRequest{UserEmail: "alice@example.com"}, &archived))
+	assert.NotNil(t, archived.ArchivedAt)
+	assert.Equal(t, http.StatusConflict, call(t, srv, "POST", "/expenses", expense("Late snack", 30, "alice@example.com", &trip.ID), nil))
+
+	// Test case 4: Archived events stay readable but drop out of the default list
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", fmt.Sprintf("/events/%d", trip.ID), nil, &summary))
+	assert.Equal(t, 360.0, summary.Totals[0].TotalAmount)
+	var party repository.Event
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/events", service.CreateEventRequest{Name: "Birthday", CreatedByEmail: "alice@example.com"}, &party))
+	var events []repository.Event
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/events?user_email=bob@example.com", nil, &events))
+	assert.Empty(t, events)
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/events?user_email=bob@example.com&include_archived=true", nil, &events))
+	require.Len(t, events, 1)
+	assert.Equal(t, trip.ID, events[0].ID)
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/events?user_email=alice@example.com", nil, &events))
+	require.Len(t, events, 1)
+	assert.Equal(t, party.ID, events[0].ID)
+
+	// Test case 5: Unarchiving reopens the event
+	var reopened repository.Event
+	assert.Equal(t, http.StatusForbidden, call(t, srv, "POST", fmt.Sprintf("/events/%d/unarchive", trip.ID), service.ArchiveEventRequest{UserEmail: "bob@example.com"}, nil))
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", fmt.Sprintf("/events/%d/unarchive", trip.ID), service.ArchiveEventRequest{UserEmail: "alice@example.com"}, &reopened))
+	assert.Nil(t, reopened.ArchivedAt)
+	assert.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", expense("Late snack", 30, "alice@example.com", &trip.ID), nil))
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/events?user_email=bob@example.com", nil, &events))
+	assert.Len(t, events, 1)
+
+	// Test case 6: Unknown events
+	missing := 99
+	assert.Equal(t, http.StatusNotFound, call(t, srv, "POST", "/expenses", expense("Lost", 30, "alice@example.com", &missing), nil))
+	assert.Equal(t, http.StatusNotFound, call(t, srv, "GET", "/events/99", nil, nil))
+}
+
+func TestE2E_EventUnits(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, email := range []string{"alice@example.com", "bob@example.com", "carol@example.com"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": email, "email": email}, nil))
+	}
+	var flat repository.Event
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/events", service.CreateEventRequest{Name: "Flat", CreatedByEmail: "alice@example.com", Unit: "chore points"}, &flat))
+	assert.Equal(t, "chore points", flat.Unit)
+	chores := func(points float64) service.CreateExpenseRequest {
+		return service.CreateExpenseRequest{
+			Description:    "Bins",
+			TotalAmount:    points,
+			CreatedByEmail: "alice@example.com",
+			EventID:        &flat.ID,
+			SplitMethod:    service.SplitMethodEqual,
+			EqualSplits: []service.EqualSplitRequest{
+				{UserEmail: "alice@example.com", AmountPaid: points}, {UserEmail: "bob@example.com"}, {UserEmail: "carol@example.com"},
+			},
+		}
+	}
+
+	// Test case 1: Points are split in whole points and kept out of the balances
+	var bins repository.Expense
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", chores(10), &bins))
+	assert.Equal(t, "XXX", bins.Currency)
+	assert.Empty(t, bins.BalanceDeltas)
+	assert.Equal(t, 0.0, overallBalance(t, srv, "bob@example.com"))
+	var expenses []repository.UserExpenseView
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/expenses/by-user/bob@example.com?running_balance=true", nil, &expenses))
+	if assert.Len(t, expenses, 1) && assert.NotNil(t, expenses[0].RunningBalance) {
+		assert.Equal(t, -3.0, expenses[0].Share)
+		assert.Equal(t, 0.0, *expenses[0].RunningBalance)
+	}
+
+	// Test case 2: The event's own ledger squares up the points
+	var summary service.EventSummary
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", fmt.Sprintf("/events/%d", flat.ID), nil, &summary))
+	assert.Equal(t, "chore points", summary.Unit)
+	require.Len(t, summary.SettleUp, 2)
+	for _, tr := range summary.SettleUp {
+		assert.Equal(t, "alice@example.com", tr.ToEmail)
+		assert.Equal(t, 3.0, tr.Amount)
+		assert.Empty(t, tr.Payments)
+	}
+
+	// Test case 3: Fractions of a point and money are refused
+	assert.Equal(t, http.StatusUnprocessableEntity, call(t, srv, "POST", "/expenses", chores(2.5), nil))
+	inRupees := chores(10)
+	inRupees.Currency = "INR"
+	assert.Equal(t, http.StatusUnprocessableEntity, call(t, srv, "POST", "/expenses", inRupees, nil))
+
+	// Test case 4: Undoing points leaves the balances alone too
+	assert.Equal(t, http.StatusNoContent, call(t, srv, "DELETE", fmt.Sprintf("/expenses/%s?user_email=alice@example.com", bins.PublicID), nil, nil))
+	assert.Equal(t, 0.0, overallBalance(t, srv, "alice@example.com"))
+}
+
+func TestE2E_ShareLinks(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, email := range []string{"alice@example.com", "bob@example.com"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": strings.Split(email, "@")[0], "email": email}, nil))
+	}
+	var trip repository.Event
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/events", service.CreateEventRequest{Name: "Ski weekend", CreatedByEmail: "alice@example.com"}, &trip))
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Chalet",
+		TotalAmount:    400,
+		CreatedByEmail: "alice@example.com",
+		EventID:        &trip.ID,
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits:    []service.EqualSplitRequest{{UserEmail: "alice@example.com", AmountPaid: 400}, {UserEmail: "bob@example.com"}},
+	}, nil))
+
+	sharePath := fmt.Sprintf("/events/%d/share-links", trip.ID)
+
+	// Test case 1: Only the event's creator can share it, for no longer than the maximum
+	assert.Equal(t, http.StatusForbidden, call(t, srv, "POST", sharePath, service.CreateShareLinkRequest{UserEmail: "bob@example.com"}, nil))
+	assert.Equal(t, http.StatusBadRequest, call(t, srv, "POST", sharePath, service.CreateShareLinkRequest{UserEmail: "alice@example.com", ExpiresIn: "1000h"}, nil))
+
+	var link service.CreatedShareLink
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", sharePath, service.CreateShareLinkRequest{UserEmail: "alice@example.com", ExpiresIn: "2h"}, &link))
+	assert.Equal(t, "/share/"+link.Token, link.URL)
+
+	// Test case 2: Anyone with the link sees the ledger by name
+	var ledger service.SharedLedger
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", link.URL, nil, &ledger))
+	assert.Equal(t, "Ski weekend", ledger.Name)
+	require.Len(t, ledger.Expenses, 1)
+	assert.Equal(t, "Chalet", ledger.Expenses[0].Description)
+	assert.Equal(t, []service.SharedTransfer{{From: "bob", To: "alice", Currency: "INR", Amount: 200}}, ledger.SettleUp)
+
+	resp, err := srv.Client().Get(srv.URL + link.URL + "?format=html")
+	require.NoError(t, err)
+	page, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+	assert.Contains(t, string(page), "Ski weekend")
+	assert.NotContains(t, string(page), "alice@example.com")
+
+	// Test case 3: A tampered token is unknown
+	assert.Equal(t, http.StatusNotFound, call(t, srv, "GET", "/share/"+strings.Replace(link.Token, ".", "9.", 1), nil, nil))
+
+	// Test case 4: A revoked link is gone
+	assert.Equal(t, http.StatusForbidden, call(t, srv, "POST", fmt.Sprintf("/share-links/%d/revoke", link.ID), service.RevokeShareLinkRequest{UserEmail: "bob@example.com"}, nil))
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", fmt.Sprintf("/share-links/%d/revoke", link.ID), service.RevokeShareLinkRequest{UserEmail: "alice@example.com"}, nil))
+	assert.Equal(t, http.StatusGone, call(t, srv, "GET", link.URL, nil, nil))
+}
+
+func TestE2E_Digest(t *testing.T) {
+	srv, services := newTestServerWithServices(t)
+
+	users := map[string]repository.User{}
+	for _, email := range []string{"dana@digest.example", "eli@digest.example"} {
+		var u repository.User
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": strings.Split(email, "@")[0], "email": email}, &u))
+		users[email] = u
+	}
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Dinner",
+		TotalAmount:    100,
+		CreatedByEmail: "dana@digest.example",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits:    []service.EqualSplitRequest{{UserEmail: "dana@digest.example", AmountPaid: 100}, {UserEmail: "eli@digest.example"}},
+	}, nil))
+
+	// Test case 1: Eli opts out; Dana keeps the weekly default
+	eliPath := fmt.Sprintf("/users/%s/digest-frequency", users["eli@digest.example"].PublicID)
+	assert.Equal(t, http.StatusBadRequest, call(t, srv, "PUT", eliPath, map[string]string{"frequency": "hourly"}, nil))
+	require.Equal(t, http.StatusOK, call(t, srv, "PUT", eliPath, map[string]string{"frequency": "never"}, nil))
+
+	// Test case 2: Each due digest is queued once. Digests cover whole seconds, so the expense
+	// only makes this one once its second is over.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	n, err := services.Digest.ScheduleDue()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	n, err = services.Digest.ScheduleDue()
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		services.Jobs.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	require.Eventually(t, func() bool { return len(testNotifier.sentTo("dana@digest.example")) == 1 }, time.Second, 5*time.Millisecond)
+	digest := testNotifier.sentTo("dana@digest.example")[0]
+	assert.Contains(t, digest.Body, "Dinner: 100.00 INR (your share +50.00)")
+	assert.Contains(t, digest.Body, "eli: +50.00")
+	assert.Empty(t, testNotifier.sentTo("eli@digest.example"))
+
+	// Test case 3: The digest's unsubscribe link works without logging in, and only as signed
+	unsubscribe := strings.TrimPrefix(digest.UnsubscribeURL, "http://split.example")
+	require.True(t, strings.HasPrefix(unsubscribe, "/notifications/unsubscribe?token="))
+	assert.Equal(t, http.StatusOK, call(t, srv, "POST", unsubscribe, nil, nil))
+	assert.Equal(t, http.StatusNotFound, call(t, srv, "GET", unsubscribe+"x", nil, nil))
+}
+
+func TestE2E_NotificationPreferences(t *testing.T) {
+	srv, services := newTestServerWithServices(t)
+
+	users := map[string]repository.User{}
+	for _, email := range []string{"jo@prefs.example", "kit@prefs.example"} {
+		var u repository.User
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": strings.Split(email, "@")[0], "email": email}, &u))
+		users[email] = u
+	}
+	kitPath := fmt.Sprintf("/users/%s/preferences", users["kit@prefs.example"].PublicID)
+
+	// Test case 1: Everything is on until the user says otherwise
+	var prefs repository.NotificationPreferences
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", kitPath, nil, &prefs))
+	assert.Equal(t, *repository.DefaultNotificationPreferences(0), prefs)
+
+	// Test case 2: A partial update, which the digest frequency endpoint also sees
+	require.Equal(t, http.StatusOK, call(t, srv, "PUT", kitPath, map[string]interface{}{"events": map[string]bool{"digest": false}}, &prefs))
+	assert.False(t, prefs.Events.Digest)
+	assert.True(t, prefs.Events.NewExpense)
+	require.Equal(t, http.StatusOK, call(t, srv, "PUT", fmt.Sprintf("/users/%s/digest-frequency", users["kit@prefs.example"].PublicID), map[string]string{"frequency": "daily"}, nil))
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", kitPath, nil, &prefs))
+	assert.Equal(t, repository.DigestDaily, prefs.DigestFrequency)
+	assert.False(t, prefs.Events.Digest)
+	assert.Equal(t, http.StatusBadRequest, call(t, srv, "PUT", kitPath, map[string]string{"digest_frequency": "hourly"}, nil))
+
+	// Test case 3: Users with digests off are not sent one
+	n, err := services.Digest.ScheduleDue()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestE2E_LoanReminders(t *testing.T) {
+	srv, services := newTestServerWithServices(t)
+
+	users := map[string]repository.User{}
+	for _, email := range []string{"lara@loans.example", "max@loans.example", "ned@loans.example"} {
+		var u repository.User
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": strings.Split(email, "@")[0], "email": email}, &u))
+		users[email] = u
+	}
+	for _, borrower := range []string{"max@loans.example", "ned@loans.example"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/loans", service.CreateLoanRequest{
+			LenderEmail:   "lara@loans.example",
+			BorrowerEmail: borrower,
+			Amount:        200,
+			DueDate:       "2020-01-31",
+		}, nil))
+	}
+	// Max pays for a dinner, which pays back part of his loan
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Dinner",
+		TotalAmount:    100,
+		CreatedByEmail: "max@loans.example",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits:    []service.EqualSplitRequest{{UserEmail: "max@loans.example", AmountPaid: 100}, {UserEmail: "lara@loans.example"}},
+	}, nil))
+
+	// Test case 1: What is still owed comes from the pair's ledger
+	var loans []service.LoanView
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/loans/by-user/max@loans.example", nil, &loans))
+	require.Len(t, loans, 1)
+	assert.Equal(t, 150.0, loans[0].Outstanding)
+	assert.True(t, loans[0].Overdue)
+
+	// Test case 2: Each loan that fell due is queued for a reminder once
+	nedPath := fmt.Sprintf("/users/%s/preferences", users["ned@loans.example"].PublicID)
+	require.Equal(t, http.StatusOK, call(t, srv, "PUT", nedPath, map[string]interface{}{"events": map[string]bool{"reminder": false}}, nil))
+	n, err := services.Loan.ScheduleReminders()
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	n, err = services.Loan.ScheduleReminders()
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	n, err = services.Digest.ScheduleDue()
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		services.Jobs.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	// Test case 3: Borrowers are reminded unless they turned reminders off
+	require.Eventually(t, func() bool { return len(testNotifier.sentTo("max@loans.example")) == 2 }, time.Second, 5*time.Millisecond)
+	var reminder *service.Notification
+	for _, sent := range testNotifier.sentTo("max@loans.example") {
+		if sent.Kind == repository.KindReminder {
+			reminder = &sent
+		}
+	}
+	require.NotNil(t, reminder)
+	assert.Equal(t, "Your loan from lara is due", reminder.Subject)
+	assert.Contains(t, reminder.Body, "Of the 200.00 lent, 150.00 is still owed.")
+
+	// Test case 4: The lender's digest lists the loans that are due
+	require.Eventually(t, func() bool { return len(testNotifier.sentTo("lara@loans.example")) == 1 }, time.Second, 5*time.Millisecond)
+	digest := testNotifier.sentTo("lara@loans.example")[0]
+	assert.Contains(t, digest.Body, "lent 200.00 with max, due 2020-01-31: 150.00 still owed\n")
+	assert.Contains(t, digest.Body, "lent 200.00 with ned, due 2020-01-31: 200.00 still owed\n")
+
+	require.Eventually(t, func() bool { return len(testNotifier.sentTo("ned@loans.example")) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, repository.KindDigest, testNotifier.sentTo("ned@loans.example")[0].Kind)
+}
+
+func TestE2E_UndoExpense(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, email := range []string{"fay@undo.example", "gus@undo.example"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": strings.Split(email, "@")[0], "email": email}, nil))
+	}
+	var expense repository.Expense
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Taxi",
+		TotalAmount:    40,
+		CreatedByEmail: "fay@undo.example",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits:    []service.EqualSplitRequest{{UserEmail: "fay@undo.example", AmountPaid: 40}, {UserEmail: "gus@undo.example"}},
+	}, &expense))
+	require.NotNil(t, expense.UndoUntil)
+	assert.Equal(t, expense.CreatedAt.Add(time.Minute), *expense.UndoUntil)
+	assert.Equal(t, 20.0, overallBalance(t, srv, "fay@undo.example"))
+
+	path := fmt.Sprintf("/expenses/%s?user_email=", expense.PublicID)
+
+	// Test case 1: Only the creator can undo
+	assert.Equal(t, http.StatusForbidden, call(t, srv, "DELETE", path+"gus@undo.example", nil, nil))
+
+	// Test case 2: Undoing leaves no trace of the expense or its balances
+	assert.Equal(t, http.StatusNoContent, call(t, srv, "DELETE", path+"fay@undo.example", nil, nil))
+	assert.Zero(t, overallBalance(t, srv, "fay@undo.example"))
+	assert.Zero(t, overallBalance(t, srv, "gus@undo.example"))
+	var expenses []repository.UserExpenseView
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/expenses/by-user/gus@undo.example", nil, &expenses))
+	assert.Empty(t, expenses)
+
+	// Test case 3: It is gone for good
+	assert.Equal(t, http.StatusNotFound, call(t, srv, "DELETE", path+"fay@undo.example", nil, nil))
+}
+
+func TestE2E_SettledExpenseLock(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, email := range []string{"ida@lock.example", "jon@lock.example"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": strings.Split(email, "@")[0], "email": email}, nil))
+	}
+	var expense repository.Expense
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Dinner",
+		TotalAmount:    40,
+		CreatedByEmail: "ida@lock.example",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits:    []service.EqualSplitRequest{{UserEmail: "ida@lock.example", AmountPaid: 40}, {UserEmail: "jon@lock.example"}},
+	}, &expense))
+
+	// Test case 1: An expense can't be unlocked before a settlement locked it
+	unlock := fmt.Sprintf("/expenses/%s/unlock", expense.PublicID)
+	assert.Equal(t, http.StatusConflict, call(t, srv, "POST", unlock, service.UnlockExpenseRequest{UserEmail: "ida@lock.example"}, nil))
+
+	// Test case 2: Once Jon's payment is confirmed, the dinner can't be undone or disputed
+	var settlement repository.Settlement
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/settlements", service.ProposeSettlementRequest{PayerEmail: "jon@lock.example", PayeeEmail: "ida@lock.example", Amount: 20}, &settlement))
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", fmt.Sprintf("/settlements/%d/confirm", settlement.ID), service.TransitionSettlementRequest{UserEmail: "ida@lock.example"}, nil))
+	undo := fmt.Sprintf("/expenses/%s?user_email=ida@lock.example", expense.PublicID)
+	assert.Equal(t, http.StatusConflict, call(t, srv, "DELETE", undo, nil, nil))
+	dispute := service.DisputeExpenseRequest{UserEmail: "jon@lock.example", Reason: "Wrong amount"}
+	assert.Equal(t, http.StatusConflict, call(t, srv, "POST", fmt.Sprintf("/expenses/%s/dispute", expense.PublicID), dispute, nil))
+
+	// Test case 3: Only the creator can unlock it
+	assert.Equal(t, http.StatusForbidden, call(t, srv, "POST", unlock, service.UnlockExpenseRequest{UserEmail: "jon@lock.example"}, nil))
+	var unlocked repository.Expense
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", unlock, service.UnlockExpenseRequest{UserEmail: "ida@lock.example"}, &unlocked))
+	assert.Nil(t, unlocked.LockedAt)
+	assert.Equal(t, &settlement.ID, unlocked.SettledBy)
+
+	// Test case 4: Undoing it then proposes paying Jon back what he paid for it
+	assert.Equal(t, http.StatusNoContent, call(t, srv, "DELETE", undo, nil, nil))
+	assert.Equal(t, 20.0, overallBalance(t, srv, "jon@lock.example"))
+	var settlements []service.SettlementView
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/settlements/by-user/ida@lock.example", nil, &settlements))
+	require.Len(t, settlements, 2)
+	var proposed []service.SettlementView
+	for _, st := range settlements {
+		if st.Status == repository.SettlementProposed {
+			proposed = append(proposed, st)
+		}
+	}
+	if assert.Len(t, proposed, 1) {
+		assert.Equal(t, "paying", proposed[0].Direction)
+		assert.Equal(t, "jon@lock.example", proposed[0].WithUserEmail)
+		assert.Equal(t, 20.0, proposed[0].Amount)
+	}
+}
+
+func TestE2E_SettlementLocksCoveredExpenses(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, email := range []string{"ida@lock.example", "jon@lock.example", "kim@lock.example"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": strings.Split(email, "@")[0], "email": email}, nil))
+	}
+	expenses := make([]repository.Expense, 2)
+	for i, total := range []float64{60, 40} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+			Description:    "Dinner",
+			TotalAmount:    total,
+			CreatedByEmail: "ida@lock.example",
+			SplitMethod:    service.SplitMethodEqual,
+			EqualSplits:    []service.EqualSplitRequest{{UserEmail: "ida@lock.example", AmountPaid: total}, {UserEmail: "jon@lock.example"}},
+		}, &expenses[i]))
+	}
+	unlock := func(e repository.Expense) int {
+		return call(t, srv, "POST", fmt.Sprintf("/expenses/%s/unlock", e.PublicID), service.UnlockExpenseRequest{UserEmail: "ida@lock.example"}, nil)
+	}
+
+	// Test case 1: Paying 40 of the 50 Jon owes locks the first dinner, which it pays off, but not the second
+	var settlement repository.Settlement
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/settlements", service.ProposeSettlementRequest{PayerEmail: "jon@lock.example", PayeeEmail: "ida@lock.example", Amount: 40}, &settlement))
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", fmt.Sprintf("/settlements/%d/confirm", settlement.ID), service.TransitionSettlementRequest{UserEmail: "ida@lock.example"}, nil))
+	assert.Equal(t, http.StatusConflict, unlock(expenses[1]))
+	assert.Equal(t, http.StatusOK, unlock(expenses[0]))
+
+	// Test case 2: Kim paying the last 10 on Jon's behalf locks the second dinner
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/settlements", service.ProposeSettlementRequest{
+		PayerEmail: "kim@lock.example", PayeeEmail: "ida@lock.example", OnBehalfOfEmail: "jon@lock.example", Amount: 10,
+	}, &settlement))
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", fmt.Sprintf("/settlements/%d/confirm", settlement.ID), service.TransitionSettlementRequest{UserEmail: "ida@lock.example"}, nil))
+	assert.Equal(t, http.StatusOK, unlock(expenses[1]))
+}
+
+func TestE2E_LastModified(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, email := range []string{"hal@cache.example", "ivy@cache.example"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": strings.Split(email, "@")[0], "email": email}, nil))
+	}
+	get := func(path, since string) (int, string) {
+		req, err := http.NewRequest("GET", srv.URL+path, nil)
+		require.NoError(t, err)
+		if since != "" {
+			req.Header.Set("If-Modified-Since", since)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode, resp.Header.Get("Last-Modified")
+	}
+	addTaxi := func() {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+			Description:    "Taxi",
+			TotalAmount:    40,
+			CreatedByEmail: "hal@cache.example",
+			SplitMethod:    service.SplitMethodEqual,
+			EqualSplits:    []service.EqualSplitRequest{{UserEmail: "hal@cache.example", AmountPaid: 40}, {UserEmail: "ivy@cache.example"}},
+		}, nil))
+	}
+
+	// Test case 1: Polling with the last Last-Modified gets 304 until something changes
+	addTaxi()
+	code, lastModified := get("/expenses/by-user/ivy@cache.example", "")
+	require.Equal(t, http.StatusOK, code)
+	require.NotEmpty(t, lastModified)
+	code, _ = get("/expenses/by-user/ivy@cache.example", lastModified)
+	assert.Equal(t, http.StatusNotModified, code)
+	code, _ = get("/balances/overall/by-user/ivy@cache.example", lastModified)
+	assert.Equal(t, http.StatusNotModified, code)
+
+	// Test case 2: A new expense shows up even within the same second
+	addTaxi()
+	code, newer := get("/expenses/by-user/ivy@cache.example", lastModified)
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotEqual(t, lastModified, newer)
+	code, _ = get("/balances/by-user/ivy@cache.example", lastModified)
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestE2E_ExplainSplit(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, email := range []string{"jo@explain.example", "kai@explain.example", "lea@explain.example"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": strings.Split(email, "@")[0], "email": email}, nil))
+	}
+	var expense repository.Expense
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses?explain=true", service.CreateExpenseRequest{
+		Description:    "Pizza",
+		TotalAmount:    100,
+		CreatedByEmail: "jo@explain.example",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits: []service.EqualSplitRequest{
+			{UserEmail: "jo@explain.example", AmountPaid: 100}, {UserEmail: "kai@explain.example"}, {UserEmail: "lea@explain.example"},
+		},
+	}, &expense))
+
+	require.NotNil(t, expense.Explanation)
+	assert.Equal(t, "jo@explain.example", expense.Explanation.RemainderTo)
+	assert.Equal(t, 33.34, expense.Explanation.Shares[0].AmountOwed)
+	assert.Len(t, expense.Explanation.BalanceDeltas, 2)
+	assert.Contains(t, expense.Explanation.Steps, "kai@explain.example now owes jo@explain.example 33.33 INR more.")
+}
+
+func TestE2E_ExpenseInvites(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, email := range []string{"lou@invite.example", "max@invite.example", "ned@invite.example"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": strings.Split(email, "@")[0], "email": email}, nil))
+	}
+	var trip repository.Event
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/events", service.CreateEventRequest{Name: "Lake house", CreatedByEmail: "lou@invite.example"}, &trip))
+	var expense repository.Expense
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Boat rental",
+		TotalAmount:    90,
+		CreatedByEmail: "lou@invite.example",
+		EventID:        &trip.ID,
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits:    []service.EqualSplitRequest{{UserEmail: "lou@invite.example", AmountPaid: 90}, {UserEmail: "max@invite.example"}},
+	}, &expense))
+
+	// Test case 1: Only participants can hand out an invite
+	invitesPath := fmt.Sprintf("/expenses/%s/invites", expense.PublicID)
+	assert.Equal(t, http.StatusForbidden, call(t, srv, "POST", invitesPath, service.CreateExpenseInviteRequest{UserEmail: "ned@invite.example"}, nil))
+	var invite service.CreatedExpenseInvite
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", invitesPath, service.CreateExpenseInviteRequest{UserEmail: "lou@invite.example"}, &invite))
+	path := strings.TrimPrefix(invite.URL, "http://split.example")
+	require.Equal(t, "/invites/"+invite.Token, path)
+
+	// Test case 2: The QR code is a PNG of the link
+	resp, err := http.Get(srv.URL + strings.TrimPrefix(invite.QRCodeURL, "http://split.example"))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	_, err = png.Decode(bytes.NewReader(body))
+	assert.NoError(t, err)
+
+	// Test case 3: Max claims his share; Ned, who isn't in it, joins the event instead
+	var claim service.InviteClaim
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", path+"/claim", service.ClaimExpenseInviteRequest{UserEmail: "max@invite.example"}, &claim))
+	require.NotNil(t, claim.AmountOwed)
+	assert.Equal(t, 45.0, *claim.AmountOwed)
+	var preview service.ExpenseInvite
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", path, nil, &preview))
+	assert.Equal(t, "Lake house", preview.EventName)
+	assert.Equal(t, []service.InviteShare{{Name: "lou", AmountOwed: 45}, {Name: "max", AmountOwed: 45, Claimed: true}}, preview.Shares)
+
+	var joined service.InviteClaim
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", path+"/claim", service.ClaimExpenseInviteRequest{UserEmail: "ned@invite.example"}, &joined))
+	require.NotNil(t, joined.JoinedEventID)
+	assert.Equal(t, trip.ID, *joined.JoinedEventID)
+	var events []repository.Event
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/events?user_email=ned@invite.example", nil, &events))
+	require.Len(t, events, 1)
+	assert.Equal(t, trip.ID, events[0].ID)
+
+	// Test case 4: Tampered links and undone expenses lead nowhere
+	assert.Equal(t, http.StatusNotFound, call(t, srv, "GET", path+"x", nil, nil))
+	require.Equal(t, http.StatusNoContent, call(t, srv, "DELETE", fmt.Sprintf("/expenses/%s?user_email=lou@invite.example", expense.PublicID), nil, nil))
+	assert.Equal(t, http.StatusNotFound, call(t, srv, "GET", path, nil, nil))
+}
+
+func TestE2E_PaymentLinks(t *testing.T) {
+	srv := newTestServer(t)
+
+	var payee repository.User
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": "Oli", "email": "oli@pay.example"}, &payee))
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": "Pat", "email": "pat@pay.example"}, nil))
+	var trip repository.Event
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/events", service.CreateEventRequest{Name: "Hampi", CreatedByEmail: "oli@pay.example"}, &trip))
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Homestay",
+		TotalAmount:    3000,
+		CreatedByEmail: "oli@pay.example",
+		EventID:        &trip.ID,
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits:    []service.EqualSplitRequest{{UserEmail: "oli@pay.example", AmountPaid: 3000}, {UserEmail: "pat@pay.example"}},
+	}, nil))
+
+	// Test case 1: Malformed handles are refused
+	handlesPath := fmt.Sprintf("/users/%s/payment-handles", payee.PublicID)
+	assert.Equal(t, http.StatusBadRequest, call(t, srv, "PUT", handlesPath, map[string]string{"upi_id": "not-a-vpa"}, nil))
+
+	// Test case 2: Once Oli has a UPI ID, the settle-up says how to pay them
+	var handles repository.PaymentHandles
+	require.Equal(t, http.StatusOK, call(t, srv, "PUT", handlesPath, map[string]string{"upi_id": "oli@okbank"}, &handles))
+	assert.Equal(t, "oli@okbank", handles.UPIID)
+	var summary service.EventSummary
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", fmt.Sprintf("/events/%d", trip.ID), nil, &summary))
+	require.Len(t, summary.SettleUp, 1)
+	require.Len(t, summary.SettleUp[0].Payments, 1)
+	link := summary.SettleUp[0].Payments[0]
+	assert.Equal(t, service.ProviderUPI, link.Provider)
+	assert.Equal(t, "upi://pay?am=1500.00&cu=INR&pa=oli%40okbank&pn=Oli&tn=Settle+up%3A+Hampi", link.URL)
+
+	resp, err := http.Get(srv.URL + strings.TrimPrefix(link.QRCodeURL, "http://split.example"))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_, err = png.Decode(bytes.NewReader(body))
+	assert.NoError(t, err)
+
+	// Test case 3: An unsigned callback settles nothing
+	callback := func(secret string, out *repository.Settlement) int {
+		payload := `{"from_email":"pat@pay.example","to_email":"oli@pay.example","amount":1500,"provider":"upi","reference":"UPI-42"}`
+		ts := fmt.Sprint(time.Now().Unix())
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(ts + "." + payload))
+		req, err := http.NewRequest("POST", srv.URL+"/payments/callback", strings.NewReader(payload))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Payment-Signature", "t="+ts+",v1="+hex.EncodeToString(mac.Sum(nil)))
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		if out != nil {
+			var envelope struct {
+				Data *repository.Settlement `json:"data"`
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&envelope))
+			if envelope.Data != nil {
+				*out = *envelope.Data
+			}
+		}
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusUnauthorized, callback("not the upi secret", nil))
+	assert.Equal(t, -1500.0, overallBalance(t, srv, "pat@pay.example"))
+
+	// Test case 4: A signed callback settles the transfer, however many times it is reported
+	var settlement repository.Settlement
+	require.Equal(t, http.StatusOK, callback(testCallbackSecret, &settlement))
+	assert.Equal(t, repository.SettlementConfirmed, settlement.Status)
+	var again repository.Settlement
+	require.Equal(t, http.StatusOK, callback(testCallbackSecret, &again))
+	assert.Equal(t, settlement.ID, again.ID)
+	assert.Equal(t, 0.0, overallBalance(t, srv, "pat@pay.example"))
+
+	var views []service.SettlementView
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/settlements/by-user/pat@pay.example", nil, &views))
+	assert.Len(t, views, 1)
+}
+
+func TestE2E_StripeSettlements(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, email := range []string{"quinn@stripe.example", "rae@stripe.example"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": strings.Split(email, "@")[0], "email": email}, nil))
+	}
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Tickets",
+		TotalAmount:    80,
+		CreatedByEmail: "rae@stripe.example",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits:    []service.EqualSplitRequest{{UserEmail: "rae@stripe.example", AmountPaid: 80}, {UserEmail: "quinn@stripe.example"}},
+	}, nil))
+	var settlement repository.Settlement
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/settlements", service.ProposeSettlementRequest{PayerEmail: "quinn@stripe.example", PayeeEmail: "rae@stripe.example", Amount: 40}, &settlement))
+	require.Equal(t, -40.0, overallBalance(t, srv, "quinn@stripe.example"))
+
+	webhook := func(eventType, intent string) int {
+		payload := fmt.Sprintf(`{"id":"evt_%s","type":%q,"data":{"object":{"id":%q}}}`, intent, eventType, intent)
+		ts := fmt.Sprint(time.Now().Unix())
+		mac := hmac.New(sha256.New, []byte(testStripeWebhookSecret))
+		mac.Write([]byte(ts + "." + payload))
+		req, err := http.NewRequest("POST", srv.URL+"/payments/stripe/webhook", strings.NewReader(payload))
+		require.NoError(t, err)
+		req.Header.Set("Stripe-Signature", "t="+ts+",v1="+hex.EncodeToString(mac.Sum(nil)))
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Test case 1: Paying moves the settlement to processing, where it can't be confirmed by hand
+	payPath := fmt.Sprintf("/settlements/%d/pay", settlement.ID)
+	var payment service.SettlementPayment
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", payPath, nil, &payment))
+	assert.Equal(t, repository.SettlementProcessing, payment.Status)
+	assert.Equal(t, payment.PaymentIntentID+"_secret", payment.ClientSecret)
+	assert.Equal(t, http.StatusConflict, call(t, srv, "POST", fmt.Sprintf("/settlements/%d/confirm", settlement.ID), service.TransitionSettlementRequest{UserEmail: "rae@stripe.example"}, nil))
+	var resumed service.SettlementPayment
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", payPath, nil, &resumed))
+	assert.Equal(t, payment.PaymentIntentID, resumed.PaymentIntentID)
+
+	// Test case 2: A canceled payment puts it back, and paying again starts a new one
+	require.Equal(t, http.StatusOK, webhook("payment_intent.canceled", payment.PaymentIntentID))
+	var retried service.SettlementPayment
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", payPath, nil, &retried))
+	assert.NotEqual(t, payment.PaymentIntentID, retried.PaymentIntentID)
+	assert.Equal(t, -40.0, overallBalance(t, srv, "quinn@stripe.example"))
+
+	// Test case 3: Only a signed success confirms it and clears the balance, once
+	req, err := http.NewRequest("POST", srv.URL+"/payments/stripe/webhook", strings.NewReader(`{"type":"payment_intent.succeeded"}`))
+	require.NoError(t, err)
+	req.Header.Set("Stripe-Signature", "t=1,v1=00")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Equal(t, http.StatusOK, webhook("payment_intent.succeeded", retried.PaymentIntentID))
+	require.Equal(t, http.StatusOK, webhook("payment_intent.succeeded", retried.PaymentIntentID))
+	assert.Equal(t, 0.0, overallBalance(t, srv, "quinn@stripe.example"))
+	assert.Equal(t, http.StatusConflict, call(t, srv, "POST", payPath, nil, nil))
+}
+
+func TestE2E_Ledger(t *testing.T) {
+	srv, services := newTestServerWithServices(t)
+
+	for _, email := range []string{"sam@ledger.example", "tess@ledger.example"} {
+		require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/users", map[string]string{"name": strings.Split(email, "@")[0], "email": email}, nil))
+	}
+	var expense repository.Expense
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/expenses", service.CreateExpenseRequest{
+		Description:    "Groceries",
+		TotalAmount:    60,
+		CreatedByEmail: "sam@ledger.example",
+		SplitMethod:    service.SplitMethodEqual,
+		EqualSplits:    []service.EqualSplitRequest{{UserEmail: "sam@ledger.example", AmountPaid: 60}, {UserEmail: "tess@ledger.example"}},
+	}, &expense))
+	afterExpense := time.Now()
+	var settlement repository.Settlement
+	require.Equal(t, http.StatusCreated, call(t, srv, "POST", "/settlements", service.ProposeSettlementRequest{PayerEmail: "tess@ledger.example", PayeeEmail: "sam@ledger.example", Amount: 20}, &settlement))
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", fmt.Sprintf("/settlements/%d/confirm", settlement.ID), service.TransitionSettlementRequest{UserEmail: "sam@ledger.example"}, nil))
+
+	// Test case 1: Each posting shows up on the user's side, adding up to the current balance
+	var statement service.LedgerStatement
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/ledger/by-user/tess@ledger.example", nil, &statement))
+	require.Len(t, statement.Entries, 2)
+	stored, err := services.Expense.GetExpenseByPublicID(expense.PublicID)
+	require.NoError(t, err)
+	assert.Equal(t, repository.LedgerSource{Type: repository.LedgerExpense, ID: stored.ID}, statement.Entries[0].Source)
+	assert.Equal(t, -30.0, statement.Entries[0].Amount)
+	assert.Equal(t, repository.LedgerSource{Type: repository.LedgerSettlement, ID: settlement.ID}, statement.Entries[1].Source)
+	assert.Equal(t, 20.0, statement.Entries[1].Amount)
+	assert.Equal(t, -10.0, statement.Overall)
+	assert.Equal(t, overallBalance(t, srv, "tess@ledger.example"), statement.Overall)
+	require.Len(t, statement.Balances, 1)
+	assert.Equal(t, "sam@ledger.example", statement.Balances[0].WithUserEmail)
+
+	// Test case 2: The balance is reconstructed as it stood before the settlement
+	asOf := url.QueryEscape(afterExpense.Format(time.RFC3339Nano))
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/ledger/by-user/sam@ledger.example?as_of="+asOf, nil, &statement))
+	assert.Len(t, statement.Entries, 1)
+	assert.Equal(t, 30.0, statement.Overall)
+	assert.Equal(t, []service.UserBalanceView{{WithUserEmail: "tess@ledger.example", WithUserName: "tess", Amount: 30, LastUpdated: statement.Entries[0].CreatedAt}}, statement.Balances)
+	assert.Equal(t, http.StatusBadRequest, call(t, srv, "GET", "/ledger/by-user/sam@ledger.example?as_of=yesterday", nil, nil))
+
+	// Test case 3: Balances kept in step with the ledger have nothing to correct
+	var drifts []repository.BalanceDrift
+	require.Equal(t, http.StatusOK, call(t, srv, "GET", "/admin/ledger/check", nil, &drifts))
+	assert.Empty(t, drifts)
+	require.Equal(t, http.StatusOK, call(t, srv, "POST", "/admin/ledger/rebuild", nil, &drifts))
+	assert.Empty(t, drifts)
+}
@@ -1,26 +1,252 @@
 package router
 
 import (
+	"net/http"
+	"strings"
+
 	"github.com/aadithya-md/split-expense/internal/handler"
+	"github.com/aadithya-md/split-expense/internal/middleware"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/response"
 	"github.com/aadithya-md/split-expense/internal/service"
+	"github.com/aadithya-md/split-expense/internal/util"
 	"github.com/gorilla/mux"
 )
 
-func NewRouter(userService service.UserService, expenseService service.ExpenseService) *mux.Router {
-	r := mux.NewRouter()
+// Route describes a single endpoint along with any middleware that applies only to it.
+type Route struct {
+	Method     string
+	Path       string
+	Handler    http.HandlerFunc
+	Middleware []middleware.Middleware
+	// Request and Response are zero values of the JSON bodies the handler reads and writes, so
+	// clients can be generated from the routes. They are nil for routes without a JSON body.
+	Request  any
+	Response any
+}
+
+// Services groups the service layer the router exposes over HTTP.
+type Services struct {
+	User       service.UserService
+	Expense    service.ExpenseService
+	Loan       service.LoanService
+	Settlement service.SettlementService
+	Analytics  service.AnalyticsService
+	Audit      service.AuditService
+	Jobs       service.JobService
+	Health     service.HealthService
+	Budget     service.BudgetService
+	Goal       service.GoalService
+	Party      service.PartyService
+	Tag        service.TagService
+	Event      service.EventService
+	Share      service.ShareService
+	Digest     service.DigestService
+	Preference service.PreferenceService
+	Invite     service.InviteService
+	Payment    service.PaymentService
+	Stripe     service.StripeService
+	Ledger     service.LedgerService
+	Import     service.ImportService
+	Quota      service.QuotaService
+	Query      service.QueryService
+}
+
+// Options carries the request-level policy the handlers enforce.
+type Options struct {
+	ExpenseLimits handler.ExpenseLimits
+	// Currencies gives the minor unit each currency's amounts are checked against.
+	Currencies util.Currencies
+	// AdminMiddleware guards every /admin route, and the routes that change the tag rules every
+	// user's expenses are tagged by, outermost first.
+	AdminMiddleware []middleware.Middleware
+	// AnalyticsMiddleware wraps every /analytics route, outermost first.
+	AnalyticsMiddleware []middleware.Middleware
+	// VerboseHealth lets /health?verbose=true report on each dependency.
+	VerboseHealth bool
+}
+
+// Routes returns every route of the API, on top of services.
+func Routes(services Services, opts Options) []Route {
+	healthHandler := handler.NewHealthHandler(services.Health, opts.VerboseHealth)
+	userHandler := handler.NewUserHandler(services.User)
+	expenseHandler := handler.NewExpenseHandler(services.Expense, opts.ExpenseLimits, opts.Currencies)
+	loanHandler := handler.NewLoanHandler(services.Loan)
+	settlementHandler := handler.NewSettlementHandler(services.Settlement)
+	analyticsHandler := handler.NewAnalyticsHandler(services.Analytics)
+	adminHandler := handler.NewAdminHandler(services.Audit, services.Query)
+	jobHandler := handler.NewJobHandler(services.Jobs)
+	budgetHandler := handler.NewBudgetHandler(services.Budget)
+	goalHandler := handler.NewGoalHandler(services.Goal)
+	partyHandler := handler.NewPartyHandler(services.Party)
+	tagHandler := handler.NewTagHandler(services.Tag)
+	eventHandler := handler.NewEventHandler(services.Event)
+	shareHandler := handler.NewShareHandler(services.Share)
+	inviteHandler := handler.NewInviteHandler(services.Invite)
+	paymentHandler := handler.NewPaymentHandler(services.Payment)
+	stripeHandler := handler.NewStripeHandler(services.Stripe)
+	ledgerHandler := handler.NewLedgerHandler(services.Ledger)
+	quotaHandler := handler.NewQuotaHandler(services.Quota)
+	importHandler := handler.NewImportHandler(services.Import)
+	notificationHandler := handler.NewNotificationHandler(services.Digest, services.Preference)
+	uiHandler := handler.NewUIHandler(services.Expense, opts.ExpenseLimits, opts.Currencies)
 
-	healthHandler := handler.HealthCheckHandler
-	userHandler := handler.NewUserHandler(userService)
-	expenseHandler := handler.NewExpenseHandler(expenseService)
+	return []Route{
+		{Method: "GET", Path: "/health", Handler: healthHandler.HealthCheckHandler, Response: service.HealthReport{}},
+		{Method: "POST", Path: "/users", Handler: userHandler.CreateUserHandler, Request: handler.CreateUserRequest{}, Response: repository.User{}},
+		{Method: "GET", Path: "/users/{id}", Handler: handler.PublicUserID(services.User, userHandler.GetUserHandler), Response: repository.User{}},
+		{Method: "PUT", Path: "/users/{id}/split-weight", Handler: handler.PublicUserID(services.User, userHandler.SetSplitWeightHandler), Request: handler.SetSplitWeightRequest{}, Response: repository.User{}},
+		{Method: "GET", Path: "/users/by-email/{email}", Handler: userHandler.GetUserByEmailHandler, Response: repository.User{}},
+		{Method: "PUT", Path: "/users/{id}/digest-frequency", Handler: handler.PublicUserID(services.User, notificationHandler.SetDigestFrequencyHandler), Request: handler.SetDigestFrequencyRequest{}, Response: handler.SetDigestFrequencyRequest{}},
+		{Method: "GET", Path: "/users/{id}/preferences", Handler: handler.PublicUserID(services.User, notificationHandler.GetPreferencesHandler), Response: repository.NotificationPreferences{}},
+		{Method: "PUT", Path: "/users/{id}/preferences", Handler: handler.PublicUserID(services.User, notificationHandler.SetPreferencesHandler), Request: repository.NotificationPreferences{}, Response: repository.NotificationPreferences{}},
+		{Method: "GET", Path: "/users/{id}/payment-handles", Handler: handler.PublicUserID(services.User, paymentHandler.GetPaymentHandlesHandler), Response: repository.PaymentHandles{}},
+		{Method: "PUT", Path: "/users/{id}/payment-handles", Handler: handler.PublicUserID(services.User, paymentHandler.SetPaymentHandlesHandler), Request: repository.PaymentHandles{}, Response: repository.PaymentHandles{}},
+		{Method: "GET", Path: "/users/{id}/quota", Handler: handler.PublicUserID(services.User, quotaHandler.GetQuotaHandler), Response: service.QuotaUsage{}},
+		{Method: "GET", Path: "/notifications/unsubscribe", Handler: notificationHandler.UnsubscribeHandler},
+		{Method: "POST", Path: "/notifications/unsubscribe", Handler: notificationHandler.UnsubscribeHandler},
+		{Method: "POST", Path: "/expenses", Handler: expenseHandler.CreateExpenseHandler, Request: service.CreateExpenseRequest{}, Response: repository.Expense{}},
+		{Method: "GET", Path: "/expenses/by-user/{email}", Handler: handler.LastModified(services.User, expenseHandler.GetExpensesForUserHandler), Response: []repository.UserExpenseView{}},
+		{Method: "GET", Path: "/expenses/by-user-id/{id}", Handler: handler.ByUserID(services.User, handler.LastModified(services.User, expenseHandler.GetExpensesForUserHandler)), Response: []repository.UserExpenseView{}},
+		{Method: "GET", Path: "/expenses/by-user/{email}/nearby", Handler: handler.LastModified(services.User, expenseHandler.NearbyExpensesHandler), Response: []repository.NearbyExpense{}},
+		{Method: "GET", Path: "/expenses/by-user-id/{id}/nearby", Handler: handler.ByUserID(services.User, handler.LastModified(services.User, expenseHandler.NearbyExpensesHandler)), Response: []repository.NearbyExpense{}},
+		{Method: "POST", Path: "/expenses/{id}/dispute", Handler: handler.PublicExpenseID(services.Expense, expenseHandler.DisputeExpenseHandler), Request: service.DisputeExpenseRequest{}, Response: repository.Expense{}},
+		{Method: "POST", Path: "/expenses/{id}/dismiss-dispute", Handler: handler.PublicExpenseID(services.Expense, expenseHandler.DismissExpenseDisputeHandler), Request: service.DismissDisputeRequest{}, Response: repository.Expense{}},
+		{Method: "DELETE", Path: "/expenses/{id}", Handler: handler.PublicExpenseID(services.Expense, expenseHandler.UndoExpenseHandler)},
+		{Method: "POST", Path: "/expenses/{id}/unlock", Handler: handler.PublicExpenseID(services.Expense, expenseHandler.UnlockExpenseHandler), Request: service.UnlockExpenseRequest{}, Response: repository.Expense{}},
+		{Method: "POST", Path: "/parties", Handler: partyHandler.CreatePartyHandler, Request: service.CreatePartyRequest{}, Response: repository.Party{}},
+		{Method: "GET", Path: "/parties", Handler: partyHandler.ListPartiesHandler, Response: []repository.Party{}},
+		{Method: "GET", Path: "/tags/suggest", Handler: tagHandler.SuggestTagHandler, Response: handler.TagSuggestionResponse{}},
+		{Method: "POST", Path: "/tag-rules", Handler: tagHandler.CreateTagRuleHandler, Middleware: opts.AdminMiddleware, Request: service.CreateTagRuleRequest{}, Response: repository.TagRule{}},
+		{Method: "GET", Path: "/tag-rules", Handler: tagHandler.ListTagRulesHandler, Response: []repository.TagRule{}},
+		{Method: "DELETE", Path: "/tag-rules/{id}", Handler: tagHandler.DeleteTagRuleHandler, Middleware: opts.AdminMiddleware},
+		{Method: "POST", Path: "/events", Handler: eventHandler.CreateEventHandler, Request: service.CreateEventRequest{}, Response: repository.Event{}},
+		{Method: "GET", Path: "/events", Handler: eventHandler.ListEventsHandler, Response: []repository.Event{}},
+		{Method: "GET", Path: "/events/{id}", Handler: eventHandler.GetEventSummaryHandler, Response: service.EventSummary{}},
+		{Method: "POST", Path: "/events/{id}/archive", Handler: eventHandler.ArchiveEventHandler, Request: service.ArchiveEventRequest{}, Response: repository.Event{}},
+		{Method: "POST", Path: "/events/{id}/unarchive", Handler: eventHandler.UnarchiveEventHandler, Request: service.ArchiveEventRequest{}, Response: repository.Event{}},
+		{Method: "PUT", Path: "/events/{id}/base-currency", Handler: eventHandler.SetBaseCurrencyHandler, Request: service.SetBaseCurrencyRequest{}, Response: repository.Event{}},
+		{Method: "PUT", Path: "/events/{id}/auto-settle", Handler: eventHandler.SetAutoSettleHandler, Request: service.SetAutoSettleRequest{}, Response: repository.Event{}},
+		{Method: "POST", Path: "/events/{id}/share-links", Handler: shareHandler.CreateShareLinkHandler, Request: service.CreateShareLinkRequest{}, Response: service.CreatedShareLink{}},
+		{Method: "POST", Path: "/share-links/{id}/revoke", Handler: shareHandler.RevokeShareLinkHandler, Request: service.RevokeShareLinkRequest{}, Response: repository.ShareLink{}},
+		{Method: "GET", Path: "/share/{token}", Handler: shareHandler.SharedLedgerHandler, Response: service.SharedLedger{}},
+		{Method: "POST", Path: "/expenses/{id}/invites", Handler: handler.PublicExpenseID(services.Expense, inviteHandler.CreateExpenseInviteHandler), Request: service.CreateExpenseInviteRequest{}, Response: service.CreatedExpenseInvite{}},
+		{Method: "GET", Path: "/invites/{token}", Handler: inviteHandler.GetExpenseInviteHandler, Response: service.ExpenseInvite{}},
+		{Method: "GET", Path: "/invites/{token}/qr.png", Handler: inviteHandler.InviteQRCodeHandler},
+		{Method: "POST", Path: "/invites/{token}/claim", Handler: inviteHandler.ClaimExpenseInviteHandler, Request: service.ClaimExpenseInviteRequest{}, Response: service.InviteClaim{}},
+		{Method: "GET", Path: "/balances/by-user/{email}", Handler: handler.LastModified(services.User, expenseHandler.GetOutstandingBalancesHandler), Response: []service.UserBalanceView{}},
+		{Method: "GET", Path: "/balances/by-user-id/{id}", Handler: handler.ByUserID(services.User, handler.LastModified(services.User, expenseHandler.GetOutstandingBalancesHandler)), Response: []service.UserBalanceView{}},
+		{Method: "GET", Path: "/balances/overall/by-user/{email}", Handler: handler.LastModified(services.User, expenseHandler.GetOverallOutstandingBalanceHandler), Response: handler.OverallBalanceResponse{}},
+		{Method: "GET", Path: "/balances/overall/by-user-id/{id}", Handler: handler.ByUserID(services.User, handler.LastModified(services.User, expenseHandler.GetOverallOutstandingBalanceHandler)), Response: handler.OverallBalanceResponse{}},
+		{Method: "GET", Path: "/balances/owed-to-me/{email}", Handler: handler.LastModified(services.User, expenseHandler.OwedToMeHandler), Response: []service.UserBalanceView{}},
+		{Method: "GET", Path: "/balances/i-owe/{email}", Handler: handler.LastModified(services.User, expenseHandler.IOweHandler), Response: []service.UserBalanceView{}},
+		{Method: "GET", Path: "/balances/aging/{email}", Handler: ledgerHandler.AgingReportHandler, Response: service.AgingReport{}},
+		{Method: "GET", Path: "/balances/aging/by-user-id/{id}", Handler: handler.ByUserID(services.User, ledgerHandler.AgingReportHandler), Response: service.AgingReport{}},
+		{Method: "GET", Path: "/ledger/by-user/{email}", Handler: ledgerHandler.GetLedgerHandler, Response: service.LedgerStatement{}},
+		{Method: "GET", Path: "/ledger/by-user-id/{id}", Handler: handler.ByUserID(services.User, ledgerHandler.GetLedgerHandler), Response: service.LedgerStatement{}},
+		{Method: "POST", Path: "/loans", Handler: loanHandler.CreateLoanHandler, Request: service.CreateLoanRequest{}, Response: repository.Loan{}},
+		{Method: "GET", Path: "/loans/by-user/{email}", Handler: loanHandler.GetLoansForUserHandler, Response: []service.LoanView{}},
+		{Method: "GET", Path: "/loans/by-user-id/{id}", Handler: handler.ByUserID(services.User, loanHandler.GetLoansForUserHandler), Response: []service.LoanView{}},
+		{Method: "POST", Path: "/settlements", Handler: settlementHandler.ProposeSettlementHandler, Request: service.ProposeSettlementRequest{}, Response: repository.Settlement{}},
+		{Method: "GET", Path: "/payments/qr.png", Handler: paymentHandler.PaymentQRCodeHandler},
+		{Method: "POST", Path: "/payments/callback", Handler: paymentHandler.PaymentCallbackHandler, Request: service.PaymentCallbackRequest{}, Response: repository.Settlement{}},
+		{Method: "POST", Path: "/payments/stripe/webhook", Handler: stripeHandler.WebhookHandler},
+		{Method: "GET", Path: "/settlements/by-user/{email}", Handler: settlementHandler.GetSettlementsForUserHandler, Response: []service.SettlementView{}},
+		{Method: "GET", Path: "/settlements/by-user-id/{id}", Handler: handler.ByUserID(services.User, settlementHandler.GetSettlementsForUserHandler), Response: []service.SettlementView{}},
+		{Method: "GET", Path: "/settlements/simplify/by-user/{email}", Handler: settlementHandler.SimplifyDebtsHandler, Response: service.DebtSimplification{}},
+		{Method: "GET", Path: "/settlements/simplify/by-user-id/{id}", Handler: handler.ByUserID(services.User, settlementHandler.SimplifyDebtsHandler), Response: service.DebtSimplification{}},
+		{Method: "POST", Path: "/settlements/simplify", Handler: settlementHandler.ApplyDebtSimplificationHandler, Request: service.ApplyDebtSimplificationRequest{}, Response: []repository.Settlement{}},
+		{Method: "POST", Path: "/settlements/{id}/send", Handler: settlementHandler.MarkSettlementSentHandler, Request: service.TransitionSettlementRequest{}, Response: repository.Settlement{}},
+		{Method: "POST", Path: "/settlements/{id}/confirm", Handler: settlementHandler.ConfirmSettlementHandler, Request: service.TransitionSettlementRequest{}, Response: repository.Settlement{}},
+		{Method: "POST", Path: "/settlements/{id}/dispute", Handler: settlementHandler.DisputeSettlementHandler, Request: service.TransitionSettlementRequest{}, Response: repository.Settlement{}},
+		{Method: "POST", Path: "/settlements/{id}/pay", Handler: stripeHandler.PaySettlementHandler, Response: service.SettlementPayment{}},
+		{Method: "PUT", Path: "/budgets", Handler: budgetHandler.SetTagBudgetHandler, Request: service.SetTagBudgetRequest{}},
+		{Method: "GET", Path: "/budgets/by-user/{email}", Handler: budgetHandler.GetBudgetStatusHandler, Response: []service.BudgetStatus{}},
+		{Method: "GET", Path: "/budgets/by-user-id/{id}", Handler: handler.ByUserID(services.User, budgetHandler.GetBudgetStatusHandler), Response: []service.BudgetStatus{}},
+		{Method: "POST", Path: "/goals", Handler: goalHandler.CreateGoalHandler, Request: service.CreateGoalRequest{}, Response: repository.Goal{}},
+		{Method: "GET", Path: "/goals/by-user/{email}", Handler: goalHandler.GetGoalProgressHandler, Response: []service.GoalProgress{}},
+		{Method: "GET", Path: "/goals/by-user-id/{id}", Handler: handler.ByUserID(services.User, goalHandler.GetGoalProgressHandler), Response: []service.GoalProgress{}},
+		{Method: "GET", Path: "/analytics/next-payer", Handler: analyticsHandler.NextPayerHandler, Middleware: opts.AnalyticsMiddleware, Response: service.NextPayerSuggestion{}},
+		{Method: "GET", Path: "/analytics/year-in-review/{email}", Handler: analyticsHandler.YearInReviewHandler, Middleware: opts.AnalyticsMiddleware, Response: service.YearInReview{}},
+		{Method: "GET", Path: "/analytics/year-in-review/by-user-id/{id}", Handler: handler.ByUserID(services.User, analyticsHandler.YearInReviewHandler), Middleware: opts.AnalyticsMiddleware, Response: service.YearInReview{}},
+		{Method: "GET", Path: "/analytics/heatmap/{email}", Handler: analyticsHandler.HeatmapHandler, Middleware: opts.AnalyticsMiddleware, Response: service.Heatmap{}},
+		{Method: "GET", Path: "/analytics/heatmap/by-user-id/{id}", Handler: handler.ByUserID(services.User, analyticsHandler.HeatmapHandler), Middleware: opts.AnalyticsMiddleware, Response: service.Heatmap{}},
+		{Method: "GET", Path: "/analytics/counterparties/{email}", Handler: analyticsHandler.CounterpartiesHandler, Middleware: opts.AnalyticsMiddleware, Response: []service.Counterparty{}},
+		{Method: "GET", Path: "/analytics/counterparties/by-user-id/{id}", Handler: handler.ByUserID(services.User, analyticsHandler.CounterpartiesHandler), Middleware: opts.AnalyticsMiddleware, Response: []service.Counterparty{}},
+		{Method: "GET", Path: "/analytics/forecast/{email}", Handler: analyticsHandler.ForecastHandler, Middleware: opts.AnalyticsMiddleware, Response: service.Forecast{}},
+		{Method: "GET", Path: "/analytics/forecast/by-user-id/{id}", Handler: handler.ByUserID(services.User, analyticsHandler.ForecastHandler), Middleware: opts.AnalyticsMiddleware, Response: service.Forecast{}},
+		{Method: "GET", Path: "/analytics/fairness/{id}", Handler: analyticsHandler.FairnessHandler, Middleware: opts.AnalyticsMiddleware, Response: service.FairnessReport{}},
+		{Method: "GET", Path: "/jobs/{id}", Handler: jobHandler.GetJobHandler, Response: repository.Job{}},
+		{Method: "POST", Path: "/imports", Handler: importHandler.CreateImportHandler, Request: service.CreateImportRequest{}, Response: repository.ImportSession{}},
+		{Method: "GET", Path: "/imports/{id}", Handler: importHandler.GetImportHandler, Response: repository.ImportSession{}},
+		{Method: "PUT", Path: "/imports/{id}/chunks/{seq}", Handler: importHandler.UploadChunkHandler, Request: handler.UploadChunkRequest{}, Response: repository.ImportSession{}},
+		{Method: "POST", Path: "/imports/{id}/commit", Handler: importHandler.CommitImportHandler, Request: service.CommitImportRequest{}, Response: repository.ImportSession{}},
+		{Method: "GET", Path: "/admin/audit", Handler: adminHandler.AuditLogsHandler, Middleware: opts.AdminMiddleware, Response: []repository.AuditLog{}},
+		{Method: "GET", Path: "/admin/queries", Handler: adminHandler.SlowestQueriesHandler, Middleware: opts.AdminMiddleware, Response: []repository.QueryStats{}},
+		{Method: "GET", Path: "/admin/ledger/check", Handler: ledgerHandler.CheckBalancesHandler, Middleware: opts.AdminMiddleware, Response: []repository.BalanceDrift{}},
+		{Method: "POST", Path: "/admin/ledger/rebuild", Handler: ledgerHandler.RebuildBalancesHandler, Middleware: opts.AdminMiddleware, Response: []repository.BalanceDrift{}},
+		{Method: "GET", Path: "/ui/expenses", Handler: uiHandler.ExpensesPageHandler},
+		{Method: "GET", Path: "/ui/balances", Handler: uiHandler.BalancesPageHandler},
+		{Method: "GET", Path: "/ui/new-expense", Handler: uiHandler.NewExpensePageHandler},
+		{Method: "POST", Path: "/ui/new-expense", Handler: uiHandler.CreateExpenseFormHandler},
+	}
+}
 
-	r.HandleFunc("/health", healthHandler).Methods("GET")
-	r.HandleFunc("/users", userHandler.CreateUserHandler).Methods("POST")
-	r.HandleFunc("/users/{id}", userHandler.GetUserHandler).Methods("GET")
-	r.HandleFunc("/users/by-email/{email}", userHandler.GetUserByEmailHandler).Methods("GET")
-	r.HandleFunc("/expenses", expenseHandler.CreateExpenseHandler).Methods("POST")
-	r.HandleFunc("/expenses/by-user/{email}", expenseHandler.GetExpensesForUserHandler).Methods("GET")
-	r.HandleFunc("/balances/by-user/{email}", expenseHandler.GetOutstandingBalancesHandler).Methods("GET")
-	r.HandleFunc("/balances/overall/by-user/{email}", expenseHandler.GetOverallOutstandingBalanceHandler).Methods("GET")
+// NewRouter builds the API router. The given middlewares wrap every route, outermost first.
+func NewRouter(services Services, opts Options, mws ...middleware.Middleware) *mux.Router {
+	// Match on the escaped path so an encoded "/" in an email can't split a path segment
+	r := mux.NewRouter().UseEncodedPath()
+	routes := Routes(services, opts)
+
+	// GET routes also answer HEAD; net/http drops the body
+	allowed := make(map[string][]string)
+	var paths []string
+	for _, route := range routes {
+		methods := []string{route.Method}
+		if route.Method == http.MethodGet {
+			methods = append(methods, http.MethodHead)
+		}
+		r.Handle(route.Path, middleware.Chain(route.Handler, route.Middleware...)).Methods(methods...)
+
+		if _, ok := allowed[route.Path]; !ok {
+			paths = append(paths, route.Path)
+		}
+		allowed[route.Path] = append(allowed[route.Path], methods...)
+	}
+
+	// Every path answers OPTIONS, and a 405 says which methods would have worked
+	for _, path := range paths {
+		allowed[path] = append(allowed[path], http.MethodOptions)
+		allow := strings.Join(allowed[path], ", ")
+		r.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Allow", allow)
+			w.WriteHeader(http.StatusNoContent)
+		}).Methods(http.MethodOptions)
+	}
+	r.MethodNotAllowedHandler = methodNotAllowedHandler(paths, allowed)
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response.Error(w, r, "Not found", http.StatusNotFound)
+	})
+
+	for _, mw := range mws {
+		r.Use(mux.MiddlewareFunc(mw))
+	}
 
 	return r
 }
+
+// methodNotAllowedHandler answers 405 with the Allow header of whichever path the request matched.
+func methodNotAllowedHandler(paths []string, allowed map[string][]string) http.Handler {
+	matchers := mux.NewRouter().UseEncodedPath()
+	for _, path := range paths {
+		matchers.NewRoute().Path(path).Name(path)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var match mux.RouteMatch
+		if matchers.Match(r, &match) {
+			w.Header().Set("Allow", strings.Join(allowed[match.Route.GetName()], ", "))
+		}
+		response.Error(w, r, "Method not allowed", http.StatusMethodNotAllowed)
+	})
+}
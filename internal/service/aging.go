@@ -0,0 +1,142 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// AgingBucket groups balances by how many days their oldest unsettled contribution has been open.
+type AgingBucket string
+
+const (
+	AgingCurrent AgingBucket = "0-30"
+	Aging31To60  AgingBucket = "31-60"
+	Aging61Plus  AgingBucket = "61+"
+)
+
+// agingBuckets lists the buckets in the order they are reported.
+var agingBuckets = []AgingBucket{AgingCurrent, Aging31To60, Aging61Plus}
+
+func agingBucket(days int) AgingBucket {
+	switch {
+	case days <= 30:
+		return AgingCurrent
+	case days <= 60:
+		return Aging31To60
+	default:
+		return Aging61Plus
+	}
+}
+
+// BalanceAge is one outstanding balance, dated by the oldest contribution to it that hasn't been
+// paid off. Amount is positive when the other user owes.
+type BalanceAge struct {
+	WithUserEmail string  `json:"with_user_email"`
+	WithUserName  string  `json:"with_user_name"`
+	Amount        float64 `json:"amount"`
+	// PaidAmount is what settlements have paid towards the balance since the pair was last even, so
+	// a balance of 35 left after paying 20 of 55 has a paid amount of 20.
+	PaidAmount float64 `json:"paid_amount"`
+	// OldestSource is the expense, loan or other posting the oldest unsettled part of the balance
+	// comes from.
+	OldestSource repository.LedgerSource `json:"oldest_source"`
+	OldestAt     time.Time               `json:"oldest_at"`
+	AgeDays      int                     `json:"age_days"`
+	Bucket       AgingBucket             `json:"bucket"`
+}
+
+// AgingTotal adds up the balances in one bucket, what the user is owed and what they owe apart.
+type AgingTotal struct {
+	Bucket   AgingBucket `json:"bucket"`
+	OwedToMe float64     `json:"owed_to_me"`
+	IOwe     float64     `json:"i_owe"`
+}
+
+// AgingReport is a user's outstanding balances by age, oldest first.
+type AgingReport struct {
+	UserEmail string       `json:"user_email"`
+	AsOf      time.Time    `json:"as_of"`
+	Balances  []BalanceAge `json:"balances"`
+	Totals    []AgingTotal `json:"totals"`
+}
+
+func (s *ledgerService) GetAgingReport(userEmail string) (*AgingReport, error) {
+	users, err := s.userService.GetUsersByEmails([]string{userEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", userEmail)
+	}
+	userID := users[0].ID
+	now := s.now()
+
+	entries, err := s.ledgerRepo.GetUnsettledEntries(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unsettled ledger entries for user %s: %w", userEmail, err)
+	}
+
+	byCounterparty := make(map[int][]repository.LedgerEntry)
+	var ids []int
+	for _, e := range entries {
+		if _, ok := byCounterparty[e.CounterpartyID]; !ok {
+			ids = append(ids, e.CounterpartyID)
+		}
+		byCounterparty[e.CounterpartyID] = append(byCounterparty[e.CounterpartyID], e)
+	}
+	others := make(map[int]*repository.User)
+	if len(ids) > 0 {
+		counterparties, err := s.userService.GetUsersByIDs(ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch counterparties for aging report: %w", err)
+		}
+		for _, u := range counterparties {
+			others[u.ID] = u
+		}
+	}
+
+	report := &AgingReport{UserEmail: users[0].Email, AsOf: now, Balances: []BalanceAge{}}
+	totals := make(map[AgingBucket]*AgingTotal, len(agingBuckets))
+	for _, bucket := range agingBuckets {
+		totals[bucket] = &AgingTotal{Bucket: bucket}
+	}
+	exp := util.DefaultExponent
+	for _, otherID := range ids {
+		open, paid := repository.UnsettledContributions(byCounterparty[otherID])
+		if len(open) == 0 {
+			continue
+		}
+		var units int64
+		for _, c := range open {
+			units += c.Units
+		}
+
+		oldest := open[0]
+		days := int(now.Sub(oldest.At) / (24 * time.Hour))
+		age := BalanceAge{
+			Amount:       util.FromMinorUnits(units, exp),
+			PaidAmount:   util.FromMinorUnits(paid, exp),
+			OldestSource: oldest.Source,
+			OldestAt:     oldest.At,
+			AgeDays:      days,
+			Bucket:       agingBucket(days),
+		}
+		if other, ok := others[otherID]; ok {
+			age.WithUserEmail, age.WithUserName = other.Email, other.Name
+		}
+		report.Balances = append(report.Balances, age)
+
+		if total := totals[age.Bucket]; units > 0 {
+			total.OwedToMe = util.FromMinorUnits(util.ToMinorUnits(total.OwedToMe, exp)+units, exp)
+		} else {
+			total.IOwe = util.FromMinorUnits(util.ToMinorUnits(total.IOwe, exp)-units, exp)
+		}
+	}
+
+	sort.SliceStable(report.Balances, func(i, j int) bool { return report.Balances[i].OldestAt.Before(report.Balances[j].OldestAt) })
+	for _, bucket := range agingBuckets {
+		report.Totals = append(report.Totals, *totals[bucket])
+	}
+	return report, nil
+}
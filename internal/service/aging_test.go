@@ -0,0 +1,57 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerService_GetAgingReport(t *testing.T) {
+	ledgerRepo := new(repomock.LedgerRepository)
+	userService := new(MockUserService)
+	s := NewLedgerService(ledgerRepo, userService).(*ledgerService)
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	daysAgo := func(d int) time.Time { return now.AddDate(0, 0, -d) }
+
+	userService.On("GetUsersByEmails", []string{"alice@example.com"}).Return([]*repository.User{{ID: 1, Email: "alice@example.com"}}, nil)
+	userService.On("GetUsersByIDs", []int{2, 3}).Return([]*repository.User{
+		{ID: 2, Name: "Bob", Email: "bob@example.com"},
+		{ID: 3, Name: "Carol", Email: "carol@example.com"},
+	}, nil)
+
+	// Bob's dinner from 90 days ago is paid off, leaving the trip from 45 days ago; Alice owes Carol
+	// for last week's groceries
+	ledgerRepo.On("GetUnsettledEntries", 1).Return([]repository.LedgerEntry{
+		{Source: repository.LedgerSource{Type: repository.LedgerExpense, ID: 1}, UserID: 1, CounterpartyID: 2, Amount: 25, CreatedAt: daysAgo(90)},
+		{Source: repository.LedgerSource{Type: repository.LedgerExpense, ID: 2}, UserID: 1, CounterpartyID: 2, Amount: 60, CreatedAt: daysAgo(45)},
+		{Source: repository.LedgerSource{Type: repository.LedgerExpense, ID: 3}, UserID: 1, CounterpartyID: 3, Amount: -12.5, CreatedAt: daysAgo(7)},
+		{Source: repository.LedgerSource{Type: repository.LedgerSettlement, ID: 1}, UserID: 1, CounterpartyID: 2, Amount: -25, CreatedAt: daysAgo(2)},
+	}, nil).Once()
+
+	report, err := s.GetAgingReport("alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, now, report.AsOf)
+	assert.Equal(t, []BalanceAge{
+		{WithUserEmail: "bob@example.com", WithUserName: "Bob", Amount: 60, PaidAmount: 25, OldestSource: repository.LedgerSource{Type: repository.LedgerExpense, ID: 2}, OldestAt: daysAgo(45), AgeDays: 45, Bucket: Aging31To60},
+		{WithUserEmail: "carol@example.com", WithUserName: "Carol", Amount: -12.5, OldestSource: repository.LedgerSource{Type: repository.LedgerExpense, ID: 3}, OldestAt: daysAgo(7), AgeDays: 7, Bucket: AgingCurrent},
+	}, report.Balances)
+	assert.Equal(t, []AgingTotal{
+		{Bucket: AgingCurrent, IOwe: 12.5},
+		{Bucket: Aging31To60, OwedToMe: 60},
+		{Bucket: Aging61Plus},
+	}, report.Totals)
+	ledgerRepo.AssertExpectations(t)
+}
+
+func TestAgingBucket(t *testing.T) {
+	assert.Equal(t, AgingCurrent, agingBucket(0))
+	assert.Equal(t, AgingCurrent, agingBucket(30))
+	assert.Equal(t, Aging31To60, agingBucket(31))
+	assert.Equal(t, Aging31To60, agingBucket(60))
+	assert.Equal(t, Aging61Plus, agingBucket(61))
+}
@@ -0,0 +1,764 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// PayerStanding is how much a user has fronted compared to what they consumed.
+type PayerStanding struct {
+	UserEmail string  `json:"user_email"`
+	UserName  string  `json:"user_name"`
+	Paid      float64 `json:"paid"`
+	Owed      float64 `json:"owed"`
+	Ratio     float64 `json:"ratio"` // Paid divided by owed, 1 means even; 0 when nothing is owed
+}
+
+type NextPayerSuggestion struct {
+	UserEmail string          `json:"user_email"`
+	UserName  string          `json:"user_name"`
+	Standings []PayerStanding `json:"standings"` // Most behind first
+}
+
+// YearInReview sums up a user's calendar year (UTC). Amounts in different currencies are added up
+// as they are, as in the balances.
+type YearInReview struct {
+	UserEmail      string           `json:"user_email"`
+	Year           int              `json:"year"`
+	ExpenseCount   int              `json:"expense_count"`
+	TotalFronted   float64          `json:"total_fronted"` // What the user paid
+	TotalOwed      float64          `json:"total_owed"`    // The user's own shares
+	BiggestExpense *ReviewExpense   `json:"biggest_expense,omitempty"`
+	TopCategory    *ReviewCategory  `json:"top_category,omitempty"`
+	TopCoSpender   *ReviewCoSpender `json:"top_co_spender,omitempty"`
+	MonthsRanked   []ReviewMonth    `json:"months_ranked"` // Highest owed first, only months with expenses
+}
+
+type ReviewExpense struct {
+	ExpenseID   int       `json:"expense_id"`
+	Description string    `json:"description"`
+	Tag         string    `json:"tag"`
+	TotalAmount float64   `json:"total_amount"`
+	Currency    string    `json:"currency"`
+	Date        time.Time `json:"date"`
+}
+
+type ReviewCategory struct {
+	Tag          string  `json:"tag"`
+	Owed         float64 `json:"owed"`
+	ExpenseCount int     `json:"expense_count"`
+}
+
+type ReviewCoSpender struct {
+	UserEmail      string `json:"user_email"`
+	UserName       string `json:"user_name"`
+	SharedExpenses int    `json:"shared_expenses"`
+}
+
+type ReviewMonth struct {
+	Month        string  `json:"month"` // YYYY-MM
+	Owed         float64 `json:"owed"`
+	ExpenseCount int     `json:"expense_count"`
+}
+
+// Counterparty is the lifetime record between a user and one other user.
+type Counterparty struct {
+	UserEmail       string  `json:"user_email"`
+	UserName        string  `json:"user_name"`
+	ExpenseCount    int     `json:"expense_count"`    // Expenses both took part in
+	TotalShared     float64 `json:"total_shared"`     // Sum of those expenses' totals
+	Balance         float64 `json:"balance"`          // Positive when they owe the user
+	SettlementCount int     `json:"settlement_count"` // Confirmed settlements between the two
+	// AverageSettleHours is how long confirmed settlements took from proposal to confirmation.
+	AverageSettleHours *float64 `json:"average_settle_hours,omitempty"`
+}
+
+// Heatmap has one entry for every day of a calendar year (UTC), for drawing a calendar heat map.
+type Heatmap struct {
+	UserEmail string       `json:"user_email"`
+	Year      int          `json:"year"`
+	Max       float64      `json:"max"` // Highest daily amount, to scale colours by
+	Days      []HeatmapDay `json:"days"`
+}
+
+type HeatmapDay struct {
+	Date         string  `json:"date"` // YYYY-MM-DD
+	Amount       float64 `json:"amount"`
+	ExpenseCount int     `json:"expense_count"`
+}
+
+// Forecast projects a user's shares over the coming calendar months (UTC) from the months before
+// the current one. Bills that came up in most of those months, like rent or utilities, are taken to
+// recur at their latest amount; everything else is projected at its monthly average by category.
+type Forecast struct {
+	UserEmail string          `json:"user_email"`
+	Recurring []RecurringBill `json:"recurring"` // Highest share first
+	Months    []ForecastMonth `json:"months"`
+}
+
+// RecurringBill is an expense, matched by tag and description, that keeps coming up every month.
+type RecurringBill struct {
+	Description string    `json:"description"`
+	Tag         string    `json:"tag"`
+	Share       float64   `json:"share"`       // The user's share in the latest month it came up
+	MonthsSeen  int       `json:"months_seen"` // Of the months looked back over
+	LastDate    time.Time `json:"last_date"`
+}
+
+type ForecastMonth struct {
+	Month      string             `json:"month"` // YYYY-MM
+	Total      float64            `json:"total"`
+	Categories []ForecastCategory `json:"categories"` // Highest first
+}
+
+// ForecastCategory is the projected share for one tag, empty for untagged expenses.
+type ForecastCategory struct {
+	Tag       string  `json:"tag"`
+	Recurring float64 `json:"recurring"` // From recurring bills
+	Other     float64 `json:"other"`     // Monthly average of the rest
+	Amount    float64 `json:"amount"`
+}
+
+// MaxForecastMonths is the furthest ahead a forecast reaches.
+const MaxForecastMonths = 12
+
+const (
+	// forecastHistoryMonths is how many whole months before the current one a forecast is based on.
+	forecastHistoryMonths = 6
+	// recurringMinMonths is in how many of them a bill has to come up to count as recurring.
+	recurringMinMonths = 3
+)
+
+// Fairness standings, from how a member's lifetime payments compare with their own shares.
+const (
+	FairnessOverPayer  = "over_payer"
+	FairnessEven       = "even"
+	FairnessUnderPayer = "under_payer"
+)
+
+// fairnessTolerance is how far, as a fraction of what they owed, a member may have paid over or
+// under their shares and still count as even.
+const fairnessTolerance = 0.1
+
+// FairnessReport compares what each member of an event paid with what they owed over its whole life,
+// showing who keeps fronting more than their share and who less. Amounts in different currencies
+// are not added up, so there is a part for each currency.
+type FairnessReport struct {
+	EventID    int                `json:"event_id"`
+	EventName  string             `json:"event_name"`
+	Currencies []FairnessCurrency `json:"currencies"`
+}
+
+type FairnessCurrency struct {
+	Currency  string           `json:"currency"`
+	TotalPaid float64          `json:"total_paid"`
+	MeanPaid  float64          `json:"mean_paid"` // TotalPaid shared evenly between the members
+	Members   []FairnessMember `json:"members"`   // Most paid over their shares first
+}
+
+type FairnessMember struct {
+	UserEmail string  `json:"user_email"`
+	UserName  string  `json:"user_name"`
+	Paid      float64 `json:"paid"`
+	Owed      float64 `json:"owed"`
+	Net       float64 `json:"net"`       // Paid less owed, positive when they fronted more than their shares
+	Deviation float64 `json:"deviation"` // Paid less the group's mean paid
+	Standing  string  `json:"standing"`  // FairnessOverPayer, FairnessEven or FairnessUnderPayer
+}
+
+type AnalyticsService interface {
+	// SuggestNextPayer picks who among the users should front the next shared expense. Only expenses
+	// shared exclusively within the group are considered, and the user who paid the least relative
+	// to what they owed is suggested.
+	SuggestNextPayer(userEmails []string) (*NextPayerSuggestion, error)
+	// YearInReview aggregates the expenses the user took part in during the year.
+	YearInReview(userEmail string, year int) (*YearInReview, error)
+	// Heatmap reports the user's share of expenses for each day of the year.
+	Heatmap(userEmail string, year int) (*Heatmap, error)
+	// Counterparties lists everyone the user has shared expenses, a balance or settlements with,
+	// most shared expenses first.
+	Counterparties(userEmail string) ([]Counterparty, error)
+	// Forecast projects the user's shares for each of the coming months, by category.
+	Forecast(userEmail string, months int) (*Forecast, error)
+	// Fairness compares each member's lifetime payments in the event with their shares.
+	Fairness(eventID int) (*FairnessReport, error)
+}
+
+type analyticsService struct {
+	expenseRepo    repository.ExpenseRepository
+	balanceRepo    repository.BalanceRepository
+	settlementRepo repository.SettlementRepository
+	eventRepo      repository.EventRepository
+	userService    UserService
+	currencies     util.Currencies
+	now            func() time.Time
+}
+
+func NewAnalyticsService(expenseRepo repository.ExpenseRepository, balanceRepo repository.BalanceRepository, settlementRepo repository.SettlementRepository, eventRepo repository.EventRepository, userService UserService, currencies util.Currencies) AnalyticsService {
+	return &analyticsService{expenseRepo: expenseRepo, balanceRepo: balanceRepo, settlementRepo: settlementRepo, eventRepo: eventRepo, userService: userService, currencies: currencies, now: time.Now}
+}
+
+func (s *analyticsService) SuggestNextPayer(userEmails []string) (*NextPayerSuggestion, error) {
+	users, err := s.userService.GetUsersByEmails(userEmails)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch users for next payer: %w", err)
+	}
+
+	// Keep the order the caller gave so ties are broken predictably
+	usersMap := make(map[string]*repository.User, len(users))
+	for _, u := range users {
+		usersMap[u.Email] = u
+	}
+	members := util.NewSet[int]()
+	ids := make([]int, 0, len(userEmails))
+	for _, email := range userEmails {
+		u, ok := usersMap[util.NormalizeEmail(email)]
+		if !ok {
+			return nil, fmt.Errorf("user with email %s not found", email)
+		}
+		members.Add(u.ID)
+		ids = append(ids, u.ID)
+	}
+
+	splits, err := s.expenseRepo.GetSplitsForExpensesInvolving(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expense history for next payer: %w", err)
+	}
+
+	// Drop expenses shared with anyone outside the group
+	outside := util.NewSet[int]()
+	for _, split := range splits {
+		if !members.IsMember(split.UserID) {
+			outside.Add(split.ExpenseID)
+		}
+	}
+
+	paid := make(map[int]float64, len(ids))
+	owed := make(map[int]float64, len(ids))
+	for _, split := range splits {
+		if outside.IsMember(split.ExpenseID) {
+			continue
+		}
+		paid[split.UserID] += split.AmountPaid
+		owed[split.UserID] += split.AmountOwed
+	}
+
+	// Rank by paid/owed; anyone who paid without owing anything is as far ahead as can be
+	standings := make([]PayerStanding, 0, len(ids))
+	rank := make(map[string]float64, len(ids))
+	for _, email := range userEmails {
+		u := usersMap[util.NormalizeEmail(email)]
+		standing := PayerStanding{
+			UserEmail: u.Email,
+			UserName:  u.Name,
+			Paid:      util.RoundToDefaultCurrency(paid[u.ID]),
+			Owed:      util.RoundToDefaultCurrency(owed[u.ID]),
+		}
+		switch {
+		case standing.Owed > 0:
+			standing.Ratio = util.RoundToTwoDecimalPlaces(standing.Paid / standing.Owed)
+			rank[email] = standing.Paid / standing.Owed
+		case standing.Paid > 0:
+			rank[email] = math.Inf(1)
+		}
+		standings = append(standings, standing)
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		ri, rj := rank[standings[i].UserEmail], rank[standings[j].UserEmail]
+		if ri != rj {
+			return ri < rj
+		}
+		return standings[i].Paid-standings[i].Owed < standings[j].Paid-standings[j].Owed
+	})
+
+	return &NextPayerSuggestion{
+		UserEmail: standings[0].UserEmail,
+		UserName:  standings[0].UserName,
+		Standings: standings,
+	}, nil
+}
+
+func (s *analyticsService) YearInReview(userEmail string, year int) (*YearInReview, error) {
+	users, err := s.userService.GetUsersByEmails([]string{userEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", userEmail)
+	}
+	user := users[0]
+
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	activity, err := s.expenseRepo.GetUserActivity(user.ID, from, from.AddDate(1, 0, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get activity for year in review: %w", err)
+	}
+
+	review := &YearInReview{UserEmail: user.Email, Year: year, ExpenseCount: len(activity), MonthsRanked: []ReviewMonth{}}
+	categories := make(map[string]*ReviewCategory)
+	months := make(map[string]*ReviewMonth)
+	shared := make(map[int]int)
+	for _, a := range activity {
+		review.TotalFronted += a.AmountPaid
+		review.TotalOwed += a.AmountOwed
+
+		if a.TotalAmount > 0 && (review.BiggestExpense == nil || a.TotalAmount > review.BiggestExpense.TotalAmount) {
+			review.BiggestExpense = &ReviewExpense{
+				ExpenseID:   a.ExpenseID,
+				Description: a.Description,
+				Tag:         a.Tag,
+				TotalAmount: a.TotalAmount,
+				Currency:    a.Currency,
+				Date:        a.CreatedAt,
+			}
+		}
+
+		if a.Tag != "" {
+			c, ok := categories[a.Tag]
+			if !ok {
+				c = &ReviewCategory{Tag: a.Tag}
+				categories[a.Tag] = c
+			}
+			c.Owed += a.AmountOwed
+			c.ExpenseCount++
+		}
+
+		month := a.CreatedAt.UTC().Format("2006-01")
+		m, ok := months[month]
+		if !ok {
+			m = &ReviewMonth{Month: month}
+			months[month] = m
+		}
+		m.Owed += a.AmountOwed
+		m.ExpenseCount++
+
+		for _, id := range a.CoParticipants {
+			shared[id]++
+		}
+	}
+	review.TotalFronted = util.RoundToDefaultCurrency(review.TotalFronted)
+	review.TotalOwed = util.RoundToDefaultCurrency(review.TotalOwed)
+
+	for _, c := range categories {
+		c.Owed = util.RoundToDefaultCurrency(c.Owed)
+		if t := review.TopCategory; t == nil || c.Owed > t.Owed || (c.Owed == t.Owed && c.Tag < t.Tag) {
+			review.TopCategory = c
+		}
+	}
+
+	for _, m := range months {
+		m.Owed = util.RoundToDefaultCurrency(m.Owed)
+		review.MonthsRanked = append(review.MonthsRanked, *m)
+	}
+	sort.Slice(review.MonthsRanked, func(i, j int) bool {
+		mi, mj := review.MonthsRanked[i], review.MonthsRanked[j]
+		if mi.Owed != mj.Owed {
+			return mi.Owed > mj.Owed
+		}
+		return mi.Month < mj.Month
+	})
+
+	// Ties go to the user who joined first
+	topID := 0
+	for id, n := range shared {
+		if n > shared[topID] || (n == shared[topID] && id < topID) {
+			topID = id
+		}
+	}
+	if topID != 0 {
+		coSpenders, err := s.userService.GetUsersByIDs([]int{topID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get co-spender for year in review: %w", err)
+		}
+		if len(coSpenders) == 0 {
+			return nil, fmt.Errorf("co-spender %d not found", topID)
+		}
+		review.TopCoSpender = &ReviewCoSpender{
+			UserEmail:      coSpenders[0].Email,
+			UserName:       coSpenders[0].Name,
+			SharedExpenses: shared[topID],
+		}
+	}
+
+	return review, nil
+}
+
+func (s *analyticsService) Counterparties(userEmail string) ([]Counterparty, error) {
+	users, err := s.userService.GetUsersByEmails([]string{userEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", userEmail)
+	}
+	userID := users[0].ID
+
+	var (
+		splits      []repository.ExpenseSplit
+		balances    []repository.Balance
+		settlements []repository.Settlement
+	)
+	err = fanOut(
+		func() (err error) {
+			if splits, err = s.expenseRepo.GetSplitsForExpensesInvolving([]int{userID}); err != nil {
+				return fmt.Errorf("failed to get expense history for counterparties: %w", err)
+			}
+			return nil
+		},
+		func() (err error) {
+			if balances, err = s.balanceRepo.GetBalancesByUserID(userID); err != nil {
+				return fmt.Errorf("failed to get balances for counterparties: %w", err)
+			}
+			return nil
+		},
+		func() (err error) {
+			if settlements, err = s.settlementRepo.GetSettlementsByUserID(userID); err != nil {
+				return fmt.Errorf("failed to get settlements for counterparties: %w", err)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*Counterparty)
+	var ids []int
+	counterparty := func(id int) *Counterparty {
+		c, ok := byID[id]
+		if !ok {
+			c = &Counterparty{}
+			byID[id] = c
+			ids = append(ids, id)
+		}
+		return c
+	}
+
+	// What was paid adds up to each expense's total
+	totals := make(map[int]float64)
+	for _, split := range splits {
+		totals[split.ExpenseID] += split.AmountPaid
+	}
+	for _, split := range splits {
+		if split.UserID != userID {
+			c := counterparty(split.UserID)
+			c.ExpenseCount++
+			c.TotalShared += totals[split.ExpenseID]
+		}
+	}
+
+	for _, b := range balances {
+		if b.User1ID == userID {
+			counterparty(b.User2ID).Balance += b.Balance
+		} else {
+			counterparty(b.User1ID).Balance -= b.Balance
+		}
+	}
+
+	settleTime := make(map[int]time.Duration)
+	for _, st := range settlements {
+		if st.Status != repository.SettlementConfirmed {
+			continue
+		}
+		otherID := st.PayeeID
+		if otherID == userID {
+			otherID = st.PayerID
+		}
+		counterparty(otherID).SettlementCount++
+		settleTime[otherID] += st.UpdatedAt.Sub(st.CreatedAt)
+	}
+
+	if len(ids) == 0 {
+		return []Counterparty{}, nil
+	}
+	others, err := getUsersByIDs(s.userService, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch counterparties: %w", err)
+	}
+
+	counterparties := make([]Counterparty, 0, len(others))
+	for _, u := range others {
+		c := byID[u.ID]
+		c.UserEmail = u.Email
+		c.UserName = u.Name
+		c.TotalShared = util.RoundToDefaultCurrency(c.TotalShared)
+		c.Balance = util.RoundToDefaultCurrency(c.Balance)
+		if c.SettlementCount > 0 {
+			hours := util.RoundToTwoDecimalPlaces(settleTime[u.ID].Hours() / float64(c.SettlementCount))
+			c.AverageSettleHours = &hours
+		}
+		counterparties = append(counterparties, *c)
+	}
+
+	sort.Slice(counterparties, func(i, j int) bool {
+		ci, cj := counterparties[i], counterparties[j]
+		if ci.ExpenseCount != cj.ExpenseCount {
+			return ci.ExpenseCount > cj.ExpenseCount
+		}
+		if ci.TotalShared != cj.TotalShared {
+			return ci.TotalShared > cj.TotalShared
+		}
+		return ci.UserEmail < cj.UserEmail
+	})
+
+	return counterparties, nil
+}
+
+func (s *analyticsService) Heatmap(userEmail string, year int) (*Heatmap, error) {
+	users, err := s.userService.GetUsersByEmails([]string{userEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", userEmail)
+	}
+	user := users[0]
+
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0)
+	spend, err := s.expenseRepo.GetDailySpend(user.ID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily spend for heatmap: %w", err)
+	}
+
+	byDate := make(map[string]repository.DailySpend, len(spend))
+	for _, d := range spend {
+		byDate[d.Date.Format("2006-01-02")] = d
+	}
+
+	heatmap := &Heatmap{UserEmail: user.Email, Year: year}
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		d := byDate[date]
+		amount := util.RoundToDefaultCurrency(d.Owed)
+		heatmap.Days = append(heatmap.Days, HeatmapDay{Date: date, Amount: amount, ExpenseCount: d.ExpenseCount})
+		heatmap.Max = math.Max(heatmap.Max, amount)
+	}
+
+	return heatmap, nil
+}
+
+func (s *analyticsService) Fairness(eventID int) (*FairnessReport, error) {
+	var (
+		event  *repository.Event
+		splits []repository.EventSplit
+	)
+	err := fanOut(
+		func() (err error) {
+			event, err = s.eventRepo.GetEvent(eventID)
+			return err
+		},
+		func() (err error) {
+			if splits, err = s.eventRepo.GetEventSplits(eventID); err != nil {
+				return fmt.Errorf("failed to get event splits for fairness: %w", err)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Summed in minor units so the totals come out exact
+	type position struct {
+		userID   int
+		currency string
+	}
+	var (
+		report     = &FairnessReport{EventID: event.ID, EventName: event.Name, Currencies: []FairnessCurrency{}}
+		paid, owed = make(map[position]int64), make(map[position]int64)
+		members    = make(map[string][]int)
+		userIDs    = util.NewSet[int]()
+	)
+	for _, sp := range splits {
+		exp := s.currencies.Exponent(sp.Currency)
+		p := position{userID: sp.UserID, currency: sp.Currency}
+		if _, seen := paid[p]; !seen {
+			members[sp.Currency] = append(members[sp.Currency], sp.UserID)
+		}
+		paid[p] += util.ToMinorUnits(sp.AmountPaid, exp)
+		owed[p] += util.ToMinorUnits(sp.AmountOwed, exp)
+		userIDs.Add(sp.UserID)
+	}
+	if len(members) == 0 {
+		return report, nil
+	}
+
+	users, err := getUsersByIDs(s.userService, userIDs.ToList())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event members for fairness: %w", err)
+	}
+	byID := make(map[int]*repository.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+
+	for currency, ids := range members {
+		exp := s.currencies.Exponent(currency)
+		var total int64
+		for _, id := range ids {
+			total += paid[position{id, currency}]
+		}
+		mean := float64(total) / float64(len(ids))
+
+		part := FairnessCurrency{
+			Currency:  currency,
+			TotalPaid: util.FromMinorUnits(total, exp),
+			MeanPaid:  util.FromMinorUnits(int64(math.Round(mean)), exp),
+			Members:   make([]FairnessMember, 0, len(ids)),
+		}
+		for _, id := range ids {
+			p, o := paid[position{id, currency}], owed[position{id, currency}]
+			member := FairnessMember{
+				Paid:      util.FromMinorUnits(p, exp),
+				Owed:      util.FromMinorUnits(o, exp),
+				Net:       util.FromMinorUnits(p-o, exp),
+				Deviation: util.FromMinorUnits(int64(math.Round(float64(p)-mean)), exp),
+				Standing:  fairnessStanding(p, o),
+			}
+			if u, ok := byID[id]; ok {
+				member.UserEmail, member.UserName = u.Email, u.Name
+			}
+			part.Members = append(part.Members, member)
+		}
+		sort.Slice(part.Members, func(i, j int) bool {
+			mi, mj := part.Members[i], part.Members[j]
+			if mi.Net != mj.Net {
+				return mi.Net > mj.Net
+			}
+			return mi.UserEmail < mj.UserEmail
+		})
+		report.Currencies = append(report.Currencies, part)
+	}
+	sort.Slice(report.Currencies, func(i, j int) bool { return report.Currencies[i].Currency < report.Currencies[j].Currency })
+
+	return report, nil
+}
+
+// fairnessStanding places a member who paid paid against shares of owed, both in minor units.
+func fairnessStanding(paid, owed int64) string {
+	tolerance := fairnessTolerance * math.Abs(float64(owed))
+	switch net := float64(paid - owed); {
+	case net > tolerance:
+		return FairnessOverPayer
+	case net < -tolerance:
+		return FairnessUnderPayer
+	default:
+		return FairnessEven
+	}
+}
+
+func (s *analyticsService) Forecast(userEmail string, months int) (*Forecast, error) {
+	users, err := s.userService.GetUsersByEmails([]string{userEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", userEmail)
+	}
+	user := users[0]
+
+	now := s.now().UTC()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	from := thisMonth.AddDate(0, -forecastHistoryMonths, 0)
+	activity, err := s.expenseRepo.GetUserActivity(user.ID, from, thisMonth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get activity for forecast: %w", err)
+	}
+
+	// Bills are matched by tag and description, whatever the letter case
+	type billKey struct{ tag, description string }
+	type bill struct {
+		RecurringBill
+		owedByMonth map[string]float64
+		expenseIDs  []int
+	}
+	bills := make(map[billKey]*bill)
+	var keys []billKey
+	for _, a := range activity {
+		if a.TotalAmount <= 0 { // Refunds only lower what is spent, they don't recur
+			continue
+		}
+		k := billKey{tag: strings.ToLower(a.Tag), description: strings.ToLower(a.Description)}
+		b, ok := bills[k]
+		if !ok {
+			b = &bill{owedByMonth: make(map[string]float64)}
+			bills[k] = b
+			keys = append(keys, k)
+		}
+		if !a.CreatedAt.Before(b.LastDate) {
+			b.Description, b.Tag, b.LastDate = a.Description, a.Tag, a.CreatedAt
+		}
+		b.owedByMonth[a.CreatedAt.UTC().Format("2006-01")] += a.AmountOwed
+		b.expenseIDs = append(b.expenseIDs, a.ExpenseID)
+	}
+
+	forecast := &Forecast{UserEmail: user.Email, Recurring: []RecurringBill{}, Months: []ForecastMonth{}}
+	recurring := make(map[string]float64)
+	recurringIDs := util.NewSet[int]()
+	for _, k := range keys {
+		b := bills[k]
+		if len(b.owedByMonth) < recurringMinMonths {
+			continue
+		}
+		b.MonthsSeen = len(b.owedByMonth)
+		b.Share = util.RoundToDefaultCurrency(b.owedByMonth[b.LastDate.UTC().Format("2006-01")])
+		forecast.Recurring = append(forecast.Recurring, b.RecurringBill)
+		recurring[b.Tag] += b.Share
+		for _, id := range b.expenseIDs {
+			recurringIDs.Add(id)
+		}
+	}
+	sort.Slice(forecast.Recurring, func(i, j int) bool {
+		ri, rj := forecast.Recurring[i], forecast.Recurring[j]
+		if ri.Share != rj.Share {
+			return ri.Share > rj.Share
+		}
+		return ri.Description < rj.Description
+	})
+
+	other := make(map[string]float64)
+	for _, a := range activity {
+		if !recurringIDs.IsMember(a.ExpenseID) {
+			other[a.Tag] += a.AmountOwed
+		}
+	}
+
+	// Every month is projected alike; only the labels differ
+	tags := util.NewSet[string]()
+	for tag := range recurring {
+		tags.Add(tag)
+	}
+	for tag := range other {
+		tags.Add(tag)
+	}
+	var categories []ForecastCategory
+	var total float64
+	for _, tag := range tags.ToList() {
+		c := ForecastCategory{
+			Tag:       tag,
+			Recurring: util.RoundToDefaultCurrency(recurring[tag]),
+			Other:     util.RoundToDefaultCurrency(other[tag] / forecastHistoryMonths),
+		}
+		c.Amount = util.RoundToDefaultCurrency(c.Recurring + c.Other)
+		if c.Amount == 0 {
+			continue
+		}
+		categories = append(categories, c)
+		total += c.Amount
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Amount != categories[j].Amount {
+			return categories[i].Amount > categories[j].Amount
+		}
+		return categories[i].Tag < categories[j].Tag
+	})
+	if categories == nil {
+		categories = []ForecastCategory{}
+	}
+
+	for i := 1; i <= months; i++ {
+		forecast.Months = append(forecast.Months, ForecastMonth{
+			Month:      thisMonth.AddDate(0, i, 0).Format("2006-01"),
+			Total:      util.RoundToDefaultCurrency(total),
+			Categories: categories,
+		})
+	}
+
+	return forecast, nil
+}
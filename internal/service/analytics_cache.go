@@ -0,0 +1,100 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheEntry is one remembered analytics result.
+type cacheEntry struct {
+	value     any
+	expiresAt time.Time
+}
+
+// cachedAnalyticsService remembers analytics results for a short time so repeated dashboard
+// refreshes don't rescan a user's or group's whole history. Entries are keyed by method and
+// arguments and are only dropped once they expire: a new expense, settlement or loan is not seen
+// until then. Errors are never cached.
+type cachedAnalyticsService struct {
+	AnalyticsService
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// NewCachedAnalyticsService wraps inner with a cache whose entries live for ttl. A non-positive ttl disables caching.
+func NewCachedAnalyticsService(inner AnalyticsService, ttl time.Duration) AnalyticsService {
+	if ttl <= 0 {
+		return inner
+	}
+	return &cachedAnalyticsService{AnalyticsService: inner, ttl: ttl, now: time.Now, entries: make(map[string]cacheEntry)}
+}
+
+// cached returns the result remembered under key, or loads and remembers it if there is none or it
+// has expired.
+func cached[T any](s *cachedAnalyticsService, key string, load func() (T, error)) (T, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	if entry, ok := s.entries[key]; ok && now.Before(entry.expiresAt) {
+		s.mu.Unlock()
+		return entry.value.(T), nil
+	}
+	s.mu.Unlock()
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+	s.entries[key] = cacheEntry{value: value, expiresAt: now.Add(s.ttl)}
+	return value, nil
+}
+
+func (s *cachedAnalyticsService) SuggestNextPayer(userEmails []string) (*NextPayerSuggestion, error) {
+	// The order of the emails breaks ties, so it is part of the key
+	return cached(s, "next-payer:"+strings.Join(userEmails, ","), func() (*NextPayerSuggestion, error) {
+		return s.AnalyticsService.SuggestNextPayer(userEmails)
+	})
+}
+
+func (s *cachedAnalyticsService) YearInReview(userEmail string, year int) (*YearInReview, error) {
+	return cached(s, fmt.Sprintf("year-in-review:%s:%d", userEmail, year), func() (*YearInReview, error) {
+		return s.AnalyticsService.YearInReview(userEmail, year)
+	})
+}
+
+func (s *cachedAnalyticsService) Heatmap(userEmail string, year int) (*Heatmap, error) {
+	return cached(s, fmt.Sprintf("heatmap:%s:%d", userEmail, year), func() (*Heatmap, error) {
+		return s.AnalyticsService.Heatmap(userEmail, year)
+	})
+}
+
+func (s *cachedAnalyticsService) Counterparties(userEmail string) ([]Counterparty, error) {
+	return cached(s, "counterparties:"+userEmail, func() ([]Counterparty, error) {
+		return s.AnalyticsService.Counterparties(userEmail)
+	})
+}
+
+func (s *cachedAnalyticsService) Forecast(userEmail string, months int) (*Forecast, error) {
+	return cached(s, fmt.Sprintf("forecast:%s:%d", userEmail, months), func() (*Forecast, error) {
+		return s.AnalyticsService.Forecast(userEmail, months)
+	})
+}
+
+func (s *cachedAnalyticsService) Fairness(eventID int) (*FairnessReport, error) {
+	return cached(s, fmt.Sprintf("fairness:%d", eventID), func() (*FairnessReport, error) {
+		return s.AnalyticsService.Fairness(eventID)
+	})
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAnalyticsService struct {
+	mock.Mock
+}
+
+func (m *MockAnalyticsService) SuggestNextPayer(userEmails []string) (*NextPayerSuggestion, error) {
+	args := m.Called(userEmails)
+	suggestion, _ := args.Get(0).(*NextPayerSuggestion)
+	return suggestion, args.Error(1)
+}
+
+func (m *MockAnalyticsService) YearInReview(userEmail string, year int) (*YearInReview, error) {
+	args := m.Called(userEmail, year)
+	review, _ := args.Get(0).(*YearInReview)
+	return review, args.Error(1)
+}
+
+func (m *MockAnalyticsService) Heatmap(userEmail string, year int) (*Heatmap, error) {
+	args := m.Called(userEmail, year)
+	heatmap, _ := args.Get(0).(*Heatmap)
+	return heatmap, args.Error(1)
+}
+
+func (m *MockAnalyticsService) Counterparties(userEmail string) ([]Counterparty, error) {
+	args := m.Called(userEmail)
+	counterparties, _ := args.Get(0).([]Counterparty)
+	return counterparties, args.Error(1)
+}
+
+func (m *MockAnalyticsService) Forecast(userEmail string, months int) (*Forecast, error) {
+	args := m.Called(userEmail, months)
+	forecast, _ := args.Get(0).(*Forecast)
+	return forecast, args.Error(1)
+}
+
+func (m *MockAnalyticsService) Fairness(eventID int) (*FairnessReport, error) {
+	args := m.Called(eventID)
+	report, _ := args.Get(0).(*FairnessReport)
+	return report, args.Error(1)
+}
+
+func TestCachedAnalyticsService_SuggestNextPayer(t *testing.T) {
+	inner := new(MockAnalyticsService)
+	cached := NewCachedAnalyticsService(inner, time.Minute).(*cachedAnalyticsService)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	cached.now = func() time.Time { return now }
+
+	group := []string{"alice@example.com", "bob@example.com"}
+	first := &NextPayerSuggestion{UserEmail: "bob@example.com"}
+	second := &NextPayerSuggestion{UserEmail: "alice@example.com"}
+
+	// Test case 1: Repeated queries within the TTL are served from the cache
+	{
+		inner.On("SuggestNextPayer", group).Return(first, nil).Once()
+		for i := 0; i < 3; i++ {
+			suggestion, err := cached.SuggestNextPayer(group)
+			assert.NoError(t, err)
+			assert.Same(t, first, suggestion)
+		}
+	}
+
+	// Test case 2: The entry is recomputed once it expires
+	{
+		now = now.Add(time.Minute)
+		inner.On("SuggestNextPayer", group).Return(second, nil).Once()
+		suggestion, err := cached.SuggestNextPayer(group)
+		assert.NoError(t, err)
+		assert.Same(t, second, suggestion)
+	}
+
+	// Test case 3: Errors are not cached
+	{
+		other := []string{"carol@example.com", "dave@example.com"}
+		inner.On("SuggestNextPayer", other).Return(nil, errors.New("db down")).Once()
+		inner.On("SuggestNextPayer", other).Return(first, nil).Once()
+		_, err := cached.SuggestNextPayer(other)
+		assert.Error(t, err)
+		suggestion, err := cached.SuggestNextPayer(other)
+		assert.NoError(t, err)
+		assert.Same(t, first, suggestion)
+	}
+
+	inner.AssertExpectations(t)
+
+	// Test case 4: A zero TTL disables caching altogether
+	assert.Same(t, AnalyticsService(inner), NewCachedAnalyticsService(inner, 0))
+}
+
+func TestCachedAnalyticsService_PerUserResults(t *testing.T) {
+	inner := new(MockAnalyticsService)
+	cached := NewCachedAnalyticsService(inner, time.Minute).(*cachedAnalyticsService)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	cached.now = func() time.Time { return now }
+
+	review2024 := &YearInReview{Year: 2024}
+	review2025 := &YearInReview{Year: 2025}
+	heatmap := &Heatmap{Year: 2024}
+	counterparties := []Counterparty{{UserEmail: "bob@example.com"}}
+	forecast := &Forecast{}
+	fairness := &FairnessReport{EventID: 3}
+	inner.On("YearInReview", "alice@example.com", 2024).Return(review2024, nil).Once()
+	inner.On("YearInReview", "alice@example.com", 2025).Return(review2025, nil).Once()
+	inner.On("Heatmap", "alice@example.com", 2024).Return(heatmap, nil).Once()
+	inner.On("Counterparties", "alice@example.com").Return(counterparties, nil).Once()
+	inner.On("Forecast", "alice@example.com", 3).Return(forecast, nil).Once()
+	inner.On("Fairness", 3).Return(fairness, nil).Once()
+
+	// Test case 1: Each result is computed once and then served from the cache, keyed by its arguments
+	for i := 0; i < 2; i++ {
+		review, err := cached.YearInReview("alice@example.com", 2024)
+		assert.NoError(t, err)
+		assert.Same(t, review2024, review)
+		review, err = cached.YearInReview("alice@example.com", 2025)
+		assert.NoError(t, err)
+		assert.Same(t, review2025, review)
+
+		gotHeatmap, err := cached.Heatmap("alice@example.com", 2024)
+		assert.NoError(t, err)
+		assert.Same(t, heatmap, gotHeatmap)
+
+		gotCounterparties, err := cached.Counterparties("alice@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, counterparties, gotCounterparties)
+
+		gotForecast, err := cached.Forecast("alice@example.com", 3)
+		assert.NoError(t, err)
+		assert.Same(t, forecast, gotForecast)
+
+		report, err := cached.Fairness(3)
+		assert.NoError(t, err)
+		assert.Same(t, fairness, report)
+	}
+	inner.AssertExpectations(t)
+
+	// Test case 2: The entry is recomputed once it expires
+	{
+		now = now.Add(time.Minute)
+		updated := &FairnessReport{EventID: 3, EventName: "Trip"}
+		inner.On("Fairness", 3).Return(updated, nil).Once()
+		report, err := cached.Fairness(3)
+		assert.NoError(t, err)
+		assert.Same(t, updated, report)
+	}
+}
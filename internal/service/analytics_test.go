@@ -0,0 +1,297 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAnalyticsService_SuggestNextPayer(t *testing.T) {
+	expenseRepo := new(repomock.ExpenseRepository)
+	userService := new(MockUserService)
+	analyticsService := NewAnalyticsService(expenseRepo, new(repomock.BalanceRepository), new(repomock.SettlementRepository), nil, userService, seededCurrencies)
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+	charlie := &repository.User{ID: 3, Name: "Charlie", Email: "charlie@example.com"}
+	dave := &repository.User{ID: 4, Name: "Dave", Email: "dave@example.com"}
+	emails := []string{alice.Email, bob.Email, charlie.Email}
+
+	splits := []repository.ExpenseSplit{
+		// Alice paid 90 for the three of them
+		{ExpenseID: 1, UserID: alice.ID, AmountPaid: 90, AmountOwed: 30},
+		{ExpenseID: 1, UserID: bob.ID, AmountOwed: 30},
+		{ExpenseID: 1, UserID: charlie.ID, AmountOwed: 30},
+		// Bob paid 30 for himself and Charlie
+		{ExpenseID: 2, UserID: bob.ID, AmountPaid: 30, AmountOwed: 15},
+		{ExpenseID: 2, UserID: charlie.ID, AmountOwed: 15},
+		// Charlie paid for Dave, who is not in the group, so it doesn't count
+		{ExpenseID: 3, UserID: charlie.ID, AmountPaid: 500, AmountOwed: 250},
+		{ExpenseID: 3, UserID: dave.ID, AmountOwed: 250},
+	}
+
+	userService.On("GetUsersByEmails", emails).Return([]*repository.User{charlie, alice, bob}, nil).Once()
+	expenseRepo.On("GetSplitsForExpensesInvolving", []int{alice.ID, bob.ID, charlie.ID}).Return(splits, nil).Once()
+
+	suggestion, err := analyticsService.SuggestNextPayer(emails)
+	assert.Nil(t, err)
+	assert.Equal(t, charlie.Email, suggestion.UserEmail)
+	assert.Equal(t, []PayerStanding{
+		{UserEmail: charlie.Email, UserName: "Charlie", Paid: 0, Owed: 45, Ratio: 0},
+		{UserEmail: bob.Email, UserName: "Bob", Paid: 30, Owed: 45, Ratio: 0.67},
+		{UserEmail: alice.Email, UserName: "Alice", Paid: 90, Owed: 30, Ratio: 3},
+	}, suggestion.Standings)
+	expenseRepo.AssertExpectations(t)
+	userService.AssertExpectations(t)
+}
+
+func TestAnalyticsService_YearInReview(t *testing.T) {
+	expenseRepo := new(repomock.ExpenseRepository)
+	userService := new(MockUserService)
+	analyticsService := NewAnalyticsService(expenseRepo, new(repomock.BalanceRepository), new(repomock.SettlementRepository), nil, userService, seededCurrencies)
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC) }
+
+	// Test case 1: Aggregates over the year
+	{
+		activity := []repository.ExpenseActivity{
+			{ExpenseID: 1, Description: "Groceries", Tag: "Food", TotalAmount: 90, Currency: "INR", CreatedAt: day(1, 5), AmountPaid: 90, AmountOwed: 30, CoParticipants: []int{2, 3}},
+			{ExpenseID: 2, Description: "Flights", Tag: "Travel", TotalAmount: 600, Currency: "INR", CreatedAt: day(3, 1), AmountOwed: 300, CoParticipants: []int{3}},
+			{ExpenseID: 3, Description: "Dinner", Tag: "Food", TotalAmount: 40, Currency: "INR", CreatedAt: day(3, 9), AmountPaid: 40, AmountOwed: 20, CoParticipants: []int{2}},
+			{ExpenseID: 4, Description: "Flights refund", Tag: "Travel", TotalAmount: -600, Currency: "INR", CreatedAt: day(4, 2), AmountOwed: -300, CoParticipants: []int{3}},
+			{ExpenseID: 5, Description: "Book", TotalAmount: 10, Currency: "INR", CreatedAt: day(4, 3), AmountPaid: 10, AmountOwed: 10},
+		}
+		userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+		expenseRepo.On("GetUserActivity", alice.ID, from, to).Return(activity, nil).Once()
+		// Bob and user 3 both shared three expenses; the lower ID wins
+		userService.On("GetUsersByIDs", []int{2}).Return([]*repository.User{bob}, nil).Once()
+		activity[4].CoParticipants = []int{2}
+
+		review, err := analyticsService.YearInReview(alice.Email, 2024)
+		assert.NoError(t, err)
+		assert.Equal(t, &YearInReview{
+			UserEmail:      alice.Email,
+			Year:           2024,
+			ExpenseCount:   5,
+			TotalFronted:   140,
+			TotalOwed:      60,
+			BiggestExpense: &ReviewExpense{ExpenseID: 2, Description: "Flights", Tag: "Travel", TotalAmount: 600, Currency: "INR", Date: day(3, 1)},
+			TopCategory:    &ReviewCategory{Tag: "Food", Owed: 50, ExpenseCount: 2},
+			TopCoSpender:   &ReviewCoSpender{UserEmail: bob.Email, UserName: bob.Name, SharedExpenses: 3},
+			MonthsRanked: []ReviewMonth{
+				{Month: "2024-03", Owed: 320, ExpenseCount: 2},
+				{Month: "2024-01", Owed: 30, ExpenseCount: 1},
+				{Month: "2024-04", Owed: -290, ExpenseCount: 2},
+			},
+		}, review)
+		expenseRepo.AssertExpectations(t)
+		userService.AssertExpectations(t)
+	}
+
+	// Test case 2: A quiet year
+	{
+		userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+		expenseRepo.On("GetUserActivity", alice.ID, from, to).Return([]repository.ExpenseActivity(nil), nil).Once()
+
+		review, err := analyticsService.YearInReview(alice.Email, 2024)
+		assert.NoError(t, err)
+		assert.Equal(t, &YearInReview{UserEmail: alice.Email, Year: 2024, MonthsRanked: []ReviewMonth{}}, review)
+	}
+}
+
+func TestAnalyticsService_Counterparties(t *testing.T) {
+	expenseRepo := new(repomock.ExpenseRepository)
+	balanceRepo := new(repomock.BalanceRepository)
+	settlementRepo := new(repomock.SettlementRepository)
+	userService := new(MockUserService)
+	analyticsService := NewAnalyticsService(expenseRepo, balanceRepo, settlementRepo, nil, userService, seededCurrencies)
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+	charlie := &repository.User{ID: 3, Name: "Charlie", Email: "charlie@example.com"}
+	dave := &repository.User{ID: 4, Name: "Dave", Email: "dave@example.com"}
+	proposed := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+	expenseRepo.On("GetSplitsForExpensesInvolving", []int{alice.ID}).Return([]repository.ExpenseSplit{
+		// Alice paid 90 for the three of them
+		{ExpenseID: 1, UserID: alice.ID, AmountPaid: 90, AmountOwed: 30},
+		{ExpenseID: 1, UserID: bob.ID, AmountOwed: 30},
+		{ExpenseID: 1, UserID: charlie.ID, AmountOwed: 30},
+		// Bob paid 20 for the two of them
+		{ExpenseID: 2, UserID: alice.ID, AmountOwed: 10},
+		{ExpenseID: 2, UserID: bob.ID, AmountPaid: 20, AmountOwed: 10},
+	}, nil).Once()
+	balanceRepo.On("GetBalancesByUserID", alice.ID).Return([]repository.Balance{
+		{User1ID: alice.ID, User2ID: bob.ID, Balance: 20},
+		{User1ID: alice.ID, User2ID: charlie.ID, Balance: 30},
+		// Dave lent Alice money
+		{User1ID: dave.ID, User2ID: alice.ID, Balance: 50},
+	}, nil).Once()
+	settlementRepo.On("GetSettlementsByUserID", alice.ID).Return([]repository.Settlement{
+		{PayerID: bob.ID, PayeeID: alice.ID, Status: repository.SettlementConfirmed, CreatedAt: proposed, UpdatedAt: proposed.Add(2 * time.Hour)},
+		{PayerID: bob.ID, PayeeID: alice.ID, Status: repository.SettlementConfirmed, CreatedAt: proposed, UpdatedAt: proposed.Add(4 * time.Hour)},
+		{PayerID: charlie.ID, PayeeID: alice.ID, Status: repository.SettlementProposed, CreatedAt: proposed, UpdatedAt: proposed},
+	}, nil).Once()
+	userService.On("GetUsersByIDs", []int{bob.ID, charlie.ID, dave.ID}).Return([]*repository.User{bob, charlie, dave}, nil).Once()
+
+	counterparties, err := analyticsService.Counterparties(alice.Email)
+	assert.NoError(t, err)
+	threeHours := 3.0
+	assert.Equal(t, []Counterparty{
+		{UserEmail: bob.Email, UserName: bob.Name, ExpenseCount: 2, TotalShared: 110, Balance: 20, SettlementCount: 2, AverageSettleHours: &threeHours},
+		{UserEmail: charlie.Email, UserName: charlie.Name, ExpenseCount: 1, TotalShared: 90, Balance: 30},
+		{UserEmail: dave.Email, UserName: dave.Name, Balance: -50},
+	}, counterparties)
+	expenseRepo.AssertExpectations(t)
+	balanceRepo.AssertExpectations(t)
+	settlementRepo.AssertExpectations(t)
+	userService.AssertExpectations(t)
+}
+
+func TestAnalyticsService_Heatmap(t *testing.T) {
+	expenseRepo := new(repomock.ExpenseRepository)
+	userService := new(MockUserService)
+	analyticsService := NewAnalyticsService(expenseRepo, new(repomock.BalanceRepository), new(repomock.SettlementRepository), nil, userService, seededCurrencies)
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+	expenseRepo.On("GetDailySpend", alice.ID, from, to).Return([]repository.DailySpend{
+		{Date: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Owed: 12.5, ExpenseCount: 2},
+		{Date: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), Owed: 40, ExpenseCount: 1},
+	}, nil).Once()
+
+	heatmap, err := analyticsService.Heatmap(alice.Email, 2024)
+	assert.NoError(t, err)
+	// 2024 is a leap year, and every day is there
+	assert.Len(t, heatmap.Days, 366)
+	assert.Equal(t, HeatmapDay{Date: "2024-01-01"}, heatmap.Days[0])
+	assert.Equal(t, HeatmapDay{Date: "2024-01-02", Amount: 12.5, ExpenseCount: 2}, heatmap.Days[1])
+	assert.Equal(t, HeatmapDay{Date: "2024-12-31", Amount: 40, ExpenseCount: 1}, heatmap.Days[365])
+	assert.Equal(t, 40.0, heatmap.Max)
+	expenseRepo.AssertExpectations(t)
+}
+
+func TestAnalyticsService_Fairness(t *testing.T) {
+	eventRepo := new(repomock.EventRepository)
+	userService := new(MockUserService)
+	analyticsService := NewAnalyticsService(new(repomock.ExpenseRepository), new(repomock.BalanceRepository), new(repomock.SettlementRepository), eventRepo, userService, seededCurrencies)
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+	carol := &repository.User{ID: 3, Name: "Carol", Email: "carol@example.com"}
+	dave := &repository.User{ID: 4, Name: "Dave", Email: "dave@example.com"}
+
+	// Test case 1: Members are placed by what they paid against their shares, in each currency
+	eventRepo.On("GetEvent", 5).Return(&repository.Event{ID: 5, Name: "Flat"}, nil).Once()
+	eventRepo.On("GetEventSplits", 5).Return([]repository.EventSplit{
+		{ExpenseID: 1, Currency: "INR", UserID: alice.ID, AmountPaid: 300, AmountOwed: 100},
+		{ExpenseID: 1, Currency: "INR", UserID: bob.ID, AmountOwed: 100},
+		{ExpenseID: 1, Currency: "INR", UserID: carol.ID, AmountOwed: 100},
+		{ExpenseID: 2, Currency: "INR", UserID: bob.ID, AmountPaid: 90, AmountOwed: 30},
+		{ExpenseID: 2, Currency: "INR", UserID: alice.ID, AmountOwed: 30},
+		{ExpenseID: 2, Currency: "INR", UserID: carol.ID, AmountOwed: 30},
+		{ExpenseID: 3, Currency: "INR", UserID: dave.ID, AmountPaid: 50, AmountOwed: 50},
+		{ExpenseID: 4, Currency: "EUR", UserID: carol.ID, AmountPaid: 20, AmountOwed: 10},
+		{ExpenseID: 4, Currency: "EUR", UserID: alice.ID, AmountOwed: 10},
+	}, nil).Once()
+	userService.On("GetUsersByIDs", mock.Anything).Return([]*repository.User{alice, bob, carol, dave}, nil).Once()
+
+	report, err := analyticsService.Fairness(5)
+	assert.NoError(t, err)
+	assert.Equal(t, "Flat", report.EventName)
+	assert.Equal(t, []FairnessCurrency{
+		{Currency: "EUR", TotalPaid: 20, MeanPaid: 10, Members: []FairnessMember{
+			{UserEmail: carol.Email, UserName: "Carol", Paid: 20, Owed: 10, Net: 10, Deviation: 10, Standing: FairnessOverPayer},
+			{UserEmail: alice.Email, UserName: "Alice", Paid: 0, Owed: 10, Net: -10, Deviation: -10, Standing: FairnessUnderPayer},
+		}},
+		{Currency: "INR", TotalPaid: 440, MeanPaid: 110, Members: []FairnessMember{
+			{UserEmail: alice.Email, UserName: "Alice", Paid: 300, Owed: 130, Net: 170, Deviation: 190, Standing: FairnessOverPayer},
+			{UserEmail: dave.Email, UserName: "Dave", Paid: 50, Owed: 50, Net: 0, Deviation: -60, Standing: FairnessEven},
+			{UserEmail: bob.Email, UserName: "Bob", Paid: 90, Owed: 130, Net: -40, Deviation: -20, Standing: FairnessUnderPayer},
+			{UserEmail: carol.Email, UserName: "Carol", Paid: 0, Owed: 130, Net: -130, Deviation: -110, Standing: FairnessUnderPayer},
+		}},
+	}, report.Currencies)
+
+	// Test case 2: Within the tolerance counts as even
+	assert.Equal(t, FairnessEven, fairnessStanding(10500, 10000))
+	assert.Equal(t, FairnessUnderPayer, fairnessStanding(8000, 10000))
+
+	// Test case 3: Unknown event; its splits are fetched alongside it
+	eventRepo.On("GetEvent", 6).Return(nil, repository.ErrEventNotFound).Once()
+	eventRepo.On("GetEventSplits", 6).Return([]repository.EventSplit{}, nil).Once()
+	_, err = analyticsService.Fairness(6)
+	assert.ErrorIs(t, err, repository.ErrEventNotFound)
+
+	eventRepo.AssertExpectations(t)
+	userService.AssertExpectations(t)
+}
+
+func TestAnalyticsService_Forecast(t *testing.T) {
+	expenseRepo := new(repomock.ExpenseRepository)
+	userService := new(MockUserService)
+	analyticsService := NewAnalyticsService(expenseRepo, new(repomock.BalanceRepository), new(repomock.SettlementRepository), nil, userService, seededCurrencies).(*analyticsService)
+	analyticsService.now = func() time.Time { return time.Date(2024, 7, 15, 9, 0, 0, 0, time.UTC) }
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC) }
+	var activity []repository.ExpenseActivity
+	add := func(id int, description, tag string, total, owed float64, date time.Time) {
+		activity = append(activity, repository.ExpenseActivity{ExpenseID: id, Description: description, Tag: tag, TotalAmount: total, AmountOwed: owed, CreatedAt: date})
+	}
+	// Rent every month, going up in April
+	for m := time.January; m <= time.June; m++ {
+		owed := 500.0
+		if m >= time.April {
+			owed = 550
+		}
+		add(int(m), "Rent", "Housing", 2*owed, owed, day(m, 1))
+	}
+	// Internet every other month, however it was typed
+	add(10, "Internet", "Utilities", 60, 30, day(time.February, 5))
+	add(11, "internet", "Utilities", 60, 30, day(time.April, 5))
+	add(12, "Internet", "Utilities", 60, 30, day(time.June, 5))
+	// One-offs, and a refund of one of them
+	add(20, "Dinner", "Food", 80, 40, day(time.January, 20))
+	add(21, "Lunch", "Food", 40, 20, day(time.March, 20))
+	add(22, "Dinner", "Food", -12, -6, day(time.March, 22))
+	add(23, "Cab", "", 24, 12, day(time.May, 3))
+
+	userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+	expenseRepo.On("GetUserActivity", alice.ID, day(time.January, 1), day(time.July, 1)).Return(activity, nil).Once()
+
+	forecast, err := analyticsService.Forecast(alice.Email, 2)
+	assert.NoError(t, err)
+
+	// Test case 1: Bills seen in most months recur at their latest share
+	assert.Equal(t, []RecurringBill{
+		{Description: "Rent", Tag: "Housing", Share: 550, MonthsSeen: 6, LastDate: day(time.June, 1)},
+		{Description: "Internet", Tag: "Utilities", Share: 30, MonthsSeen: 3, LastDate: day(time.June, 5)},
+	}, forecast.Recurring)
+
+	// Test case 2: The rest is averaged by category, over the coming months
+	categories := []ForecastCategory{
+		{Tag: "Housing", Recurring: 550, Amount: 550},
+		{Tag: "Utilities", Recurring: 30, Amount: 30},
+		{Tag: "Food", Other: 9, Amount: 9},
+		{Tag: "", Other: 2, Amount: 2},
+	}
+	assert.Equal(t, []ForecastMonth{
+		{Month: "2024-08", Total: 591, Categories: categories},
+		{Month: "2024-09", Total: 591, Categories: categories},
+	}, forecast.Months)
+
+	expenseRepo.AssertExpectations(t)
+	userService.AssertExpectations(t)
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+type AuditService interface {
+	RecordAuditLog(entry *repository.AuditLog) error
+	ListAuditLogs(filter repository.AuditLogFilter) ([]repository.AuditLog, error)
+}
+
+type auditService struct {
+	auditRepo repository.AuditRepository
+}
+
+func NewAuditService(auditRepo repository.AuditRepository) AuditService {
+	return &auditService{auditRepo: auditRepo}
+}
+
+func (s *auditService) RecordAuditLog(entry *repository.AuditLog) error {
+	return s.auditRepo.CreateAuditLog(entry)
+}
+
+func (s *auditService) ListAuditLogs(filter repository.AuditLogFilter) ([]repository.AuditLog, error) {
+	entries, err := s.auditRepo.ListAuditLogs(filter)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []repository.AuditLog{}
+	}
+	return entries, nil
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+type BalanceStrategyType string
+
+const (
+	// BalanceStrategySimple nets every participant against the creator, which is how expenses always
+	// moved balances.
+	BalanceStrategySimple BalanceStrategyType = "simple"
+	// BalanceStrategyHighestBalance settles the largest debts against the largest credits first, so an
+	// expense several people paid for touches as few pairs of users as it can.
+	BalanceStrategyHighestBalance BalanceStrategyType = "highest-balance"
+	// BalanceStrategyPairwiseNetting has every payer fund each participant's share in proportion to
+	// what they paid, netting what two users owe each other.
+	BalanceStrategyPairwiseNetting BalanceStrategyType = "pairwise-netting"
+)
+
+// BalanceStrategy turns an expense's splits into the balance changes it makes. It must give the same
+// updates for the same expense and splits, since undoing an expense recomputes and reverses them.
+// exp is the number of decimal places in the expense's currency.
+type BalanceStrategy interface {
+	CalculateBalanceUpdates(expense *repository.Expense, splits []repository.ExpenseSplit, exp int) []repository.BalanceUpdate
+}
+
+type simpleBalanceStrategy struct{}
+
+func (s *simpleBalanceStrategy) CalculateBalanceUpdates(expense *repository.Expense, splits []repository.ExpenseSplit, exp int) []repository.BalanceUpdate {
+	balanceUpdates := make([]repository.BalanceUpdate, 0)
+	for _, split := range splits {
+		if expense.CreatedBy != split.UserID {
+			// Update balance for each user involved in the split relative to the CreatedBy user
+			// The net amount represents how much the split.UserID owes the expense.CreatedBy user
+			// A positive net amount means split.UserID owes CreatedBy
+			// A negative net amount means CreatedBy owes split.UserID
+			netAmountOwedToCreator := split.AmountOwed - split.AmountPaid
+
+			if netAmountOwedToCreator != 0 {
+				balanceUpdates = append(balanceUpdates, repository.BalanceUpdate{
+					User1ID: expense.CreatedBy,
+					User2ID: split.UserID,
+					Amount:  netAmountOwedToCreator,
+				})
+			}
+		}
+	}
+	return balanceUpdates
+}
+
+type highestBalanceStrategy struct{}
+
+func (s *highestBalanceStrategy) CalculateBalanceUpdates(expense *repository.Expense, splits []repository.ExpenseSplit, exp int) []repository.BalanceUpdate {
+
+	// Work in minor units so matching a debt against a credit never leaves a fraction of a cent over
+	type position struct {
+		userID int
+		units  int64
+	}
+	var creditors, debtors []position
+	for userID, units := range netUnits(splits, exp) {
+		if units > 0 {
+			creditors = append(creditors, position{userID, units})
+		} else if units < 0 {
+			debtors = append(debtors, position{userID, -units})
+		}
+	}
+	// Largest first, then by user so equal balances always pair up the same way
+	byUnits := func(p []position) func(i, j int) bool {
+		return func(i, j int) bool {
+			if p[i].units != p[j].units {
+				return p[i].units > p[j].units
+			}
+			return p[i].userID < p[j].userID
+		}
+	}
+	sort.Slice(creditors, byUnits(creditors))
+	sort.Slice(debtors, byUnits(debtors))
+
+	balanceUpdates := make([]repository.BalanceUpdate, 0)
+	for c, d := 0, 0; c < len(creditors) && d < len(debtors); {
+		units := min(creditors[c].units, debtors[d].units)
+		balanceUpdates = append(balanceUpdates, repository.BalanceUpdate{
+			User1ID: creditors[c].userID,
+			User2ID: debtors[d].userID,
+			Amount:  util.FromMinorUnits(units, exp),
+		})
+		creditors[c].units -= units
+		debtors[d].units -= units
+		if creditors[c].units == 0 {
+			c++
+		}
+		if debtors[d].units == 0 {
+			d++
+		}
+	}
+	return balanceUpdates
+}
+
+// netUnits sums what each user paid less what they owe across splits, in minor units.
+func netUnits(splits []repository.ExpenseSplit, exp int) map[int]int64 {
+	net := make(map[int]int64, len(splits))
+	for _, split := range splits {
+		net[split.UserID] += util.ToMinorUnits(split.AmountPaid, exp) - util.ToMinorUnits(split.AmountOwed, exp)
+	}
+	return net
+}
+
+type pairwiseNettingStrategy struct{}
+
+func (s *pairwiseNettingStrategy) CalculateBalanceUpdates(expense *repository.Expense, splits []repository.ExpenseSplit, exp int) []repository.BalanceUpdate {
+
+	// cumOwed[i] and cumPaid[j] total the first i and j splits, in minor units
+	cumOwed := make([]int64, len(splits)+1)
+	cumPaid := make([]int64, len(splits)+1)
+	for i, split := range splits {
+		cumOwed[i+1] = cumOwed[i] + util.ToMinorUnits(split.AmountOwed, exp)
+		cumPaid[i+1] = cumPaid[i] + util.ToMinorUnits(split.AmountPaid, exp)
+	}
+	total := cumPaid[len(splits)]
+	if total == 0 {
+		return []repository.BalanceUpdate{}
+	}
+	// funded(i, j) is what the first j payers put towards the first i shares. Rounding the running
+	// totals rather than each part means every share is funded exactly, and every payer funds
+	// exactly what they paid.
+	funded := func(i, j int) int64 {
+		return int64(math.Round(float64(cumOwed[i]) * float64(cumPaid[j]) / float64(total)))
+	}
+
+	// owes[pair{a, b}] is what b owes a, kept with a < b; a negative amount is what a owes b
+	type pair struct{ user1, user2 int }
+	owes := make(map[pair]int64)
+	var order []pair
+	for i, debtor := range splits {
+		for j, payer := range splits {
+			units := funded(i+1, j+1) - funded(i, j+1) - funded(i+1, j) + funded(i, j)
+			if units == 0 || payer.UserID == debtor.UserID {
+				continue
+			}
+			p, sign := pair{payer.UserID, debtor.UserID}, int64(1)
+			if debtor.UserID < payer.UserID {
+				p, sign = pair{debtor.UserID, payer.UserID}, -1
+			}
+			if _, ok := owes[p]; !ok {
+				order = append(order, p)
+			}
+			owes[p] += sign * units
+		}
+	}
+
+	balanceUpdates := make([]repository.BalanceUpdate, 0, len(order))
+	for _, p := range order {
+		if owes[p] != 0 {
+			balanceUpdates = append(balanceUpdates, repository.BalanceUpdate{
+				User1ID: p.user1,
+				User2ID: p.user2,
+				Amount:  util.FromMinorUnits(owes[p], exp),
+			})
+		}
+	}
+	return balanceUpdates
+}
+
+// getBalanceStrategy resolves a strategy by name. An empty name is the simple strategy, which
+// expenses recorded before strategies could be chosen were created with.
+func getBalanceStrategy(name BalanceStrategyType) (BalanceStrategy, error) {
+	switch name {
+	case BalanceStrategySimple, "":
+		return &simpleBalanceStrategy{}, nil
+	case BalanceStrategyHighestBalance:
+		return &highestBalanceStrategy{}, nil
+	case BalanceStrategyPairwiseNetting:
+		return &pairwiseNettingStrategy{}, nil
+	default:
+		return nil, fmt.Errorf("invalid balance strategy: %s", name)
+	}
+}
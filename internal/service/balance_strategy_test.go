@@ -0,0 +1,125 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checkBalanceInvariants asserts that updates leave every participant up or down by exactly what
+// they paid less what they owe.
+func checkBalanceInvariants(t *testing.T, splits []repository.ExpenseSplit, updates []repository.BalanceUpdate) {
+	t.Helper()
+
+	expected := netUnits(splits, 2)
+	got := make(map[int]int64, len(expected))
+	for _, u := range updates {
+		got[u.User1ID] += util.ToMinorUnits(u.Amount, 2)
+		got[u.User2ID] -= util.ToMinorUnits(u.Amount, 2)
+	}
+	for userID, units := range expected {
+		assert.Equal(t, units, got[userID], "net balance of user %d", userID)
+	}
+}
+
+func TestBalanceStrategies(t *testing.T) {
+	// Alice (1) records 100 she paid 60 of and Bob (2) 40, shared equally with Charlie (3)
+	expense := &repository.Expense{CreatedBy: 1, TotalAmount: 100, Currency: "USD"}
+	splits := []repository.ExpenseSplit{
+		{UserID: 1, AmountPaid: 60, AmountOwed: 33.34},
+		{UserID: 2, AmountPaid: 40, AmountOwed: 33.33},
+		{UserID: 3, AmountOwed: 33.33},
+	}
+
+	// Test case 1: simple nets everyone against the creator, subtracting in floating point as it
+	// always has
+	updates := (&simpleBalanceStrategy{}).CalculateBalanceUpdates(expense, splits, 2)
+	require.Len(t, updates, 2)
+	assert.Equal(t, 2, updates[0].User2ID)
+	assert.InDelta(t, -6.67, updates[0].Amount, 1e-9)
+	assert.Equal(t, repository.BalanceUpdate{User1ID: 1, User2ID: 3, Amount: 33.33}, updates[1])
+	checkBalanceInvariants(t, splits, updates)
+
+	// Test case 2: highest-balance has the one debtor pay the creditors off directly
+	updates = (&highestBalanceStrategy{}).CalculateBalanceUpdates(expense, splits, 2)
+	assert.Equal(t, []repository.BalanceUpdate{
+		{User1ID: 1, User2ID: 3, Amount: 26.66},
+		{User1ID: 2, User2ID: 3, Amount: 6.67},
+	}, updates)
+	checkBalanceInvariants(t, splits, updates)
+
+	// Test case 3: pairwise-netting has Alice fund 60% and Bob 40% of every share, netted per pair
+	updates = (&pairwiseNettingStrategy{}).CalculateBalanceUpdates(expense, splits, 2)
+	assert.Equal(t, []repository.BalanceUpdate{
+		{User1ID: 1, User2ID: 2, Amount: 6.66},
+		{User1ID: 1, User2ID: 3, Amount: 20},
+		{User1ID: 2, User2ID: 3, Amount: 13.33},
+	}, updates)
+	checkBalanceInvariants(t, splits, updates)
+
+	// Test case 4: nothing paid by anyone moves no balance
+	updates = (&pairwiseNettingStrategy{}).CalculateBalanceUpdates(expense, []repository.ExpenseSplit{{UserID: 1}, {UserID: 2}}, 2)
+	assert.Empty(t, updates)
+}
+
+func TestBalanceStrategies_Deterministic(t *testing.T) {
+	// Equal credits and debts must pair up the same way every time, so an undo reverses them exactly
+	expense := &repository.Expense{CreatedBy: 1, TotalAmount: 40, Currency: "USD"}
+	splits := []repository.ExpenseSplit{
+		{UserID: 1, AmountPaid: 20, AmountOwed: 10},
+		{UserID: 2, AmountPaid: 20, AmountOwed: 10},
+		{UserID: 3, AmountOwed: 10},
+		{UserID: 4, AmountOwed: 10},
+	}
+
+	for _, name := range []BalanceStrategyType{BalanceStrategySimple, BalanceStrategyHighestBalance, BalanceStrategyPairwiseNetting} {
+		strategy, err := getBalanceStrategy(name)
+		require.NoError(t, err)
+		first := strategy.CalculateBalanceUpdates(expense, splits, 2)
+		checkBalanceInvariants(t, splits, first)
+		for i := 0; i < 20; i++ {
+			assert.Equal(t, first, strategy.CalculateBalanceUpdates(expense, splits, 2), "strategy %s", name)
+		}
+	}
+}
+
+func TestGetBalanceStrategy(t *testing.T) {
+	// Test case 1: expenses recorded before strategies existed use simple
+	strategy, err := getBalanceStrategy("")
+	require.NoError(t, err)
+	assert.IsType(t, &simpleBalanceStrategy{}, strategy)
+
+	// Test case 2: an unknown strategy is refused
+	_, err = getBalanceStrategy("round-robin")
+	assert.EqualError(t, err, "invalid balance strategy: round-robin")
+}
+
+func FuzzPairwiseNettingStrategy(f *testing.F) {
+	f.Add(uint32(6000), uint32(4000), uint32(0), uint8(1), uint8(1), uint8(1))
+	f.Add(uint32(1), uint32(2), uint32(3), uint8(7), uint8(0), uint8(3))
+
+	f.Fuzz(func(t *testing.T, paid1, paid2, paid3 uint32, w1, w2, w3 uint8) {
+		paid := []int64{int64(paid1 % 10_000_000), int64(paid2 % 10_000_000), int64(paid3 % 10_000_000)}
+		weights := []int64{int64(w1) + 1, int64(w2) + 1, int64(w3) + 1}
+		total := paid[0] + paid[1] + paid[2]
+
+		// Share the total by weight, giving the leftover cents to the first user
+		var owed [3]int64
+		assigned := int64(0)
+		for i := range owed {
+			owed[i] = total * weights[i] / (weights[0] + weights[1] + weights[2])
+			assigned += owed[i]
+		}
+		owed[0] += total - assigned
+
+		splits := make([]repository.ExpenseSplit, 3)
+		for i := range splits {
+			splits[i] = repository.ExpenseSplit{UserID: i + 1, AmountPaid: util.FromMinorUnits(paid[i], 2), AmountOwed: util.FromMinorUnits(owed[i], 2)}
+		}
+		expense := &repository.Expense{CreatedBy: 1, TotalAmount: util.FromMinorUnits(total, 2), Currency: "USD"}
+		checkBalanceInvariants(t, splits, (&pairwiseNettingStrategy{}).CalculateBalanceUpdates(expense, splits, 2))
+	})
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// ErrTagBudgetExceeded is returned, when budgets are enforced, for an expense that takes a participant over one.
+var ErrTagBudgetExceeded = errors.New("tag_budget_exceeded")
+
+type SetTagBudgetRequest struct {
+	UserEmail    string  `json:"user_email"`
+	Tag          string  `json:"tag"`
+	Currency     string  `json:"currency,omitempty"` // ISO 4217 code, defaults to INR
+	MonthlyLimit float64 `json:"monthly_limit"`      // Zero removes the budget
+}
+
+// BudgetStatus is where a user stands against one of their tag budgets this month.
+type BudgetStatus struct {
+	Tag          string  `json:"tag"`
+	Currency     string  `json:"currency"`
+	MonthlyLimit float64 `json:"monthly_limit"`
+	Spent        float64 `json:"spent"`
+	Remaining    float64 `json:"remaining"` // Negative once the budget is overrun
+}
+
+type BudgetService interface {
+	SetTagBudget(req SetTagBudgetRequest) error
+	// GetBudgetStatus reports the user's month-to-date spend against each of their budgets.
+	GetBudgetStatus(userEmail string) ([]BudgetStatus, error)
+	// CheckExpense warns about every participant the expense would take over a budget for its
+	// month, or, when budgets are enforced, fails with ErrTagBudgetExceeded.
+	CheckExpense(expense *repository.Expense, splits []repository.ExpenseSplit) ([]repository.BudgetWarning, error)
+}
+
+type budgetService struct {
+	budgetRepo  repository.BudgetRepository
+	userService UserService
+	currencies  util.Currencies
+	enforce     bool
+	now         func() time.Time
+}
+
+func NewBudgetService(budgetRepo repository.BudgetRepository, userService UserService, currencies util.Currencies, enforce bool) BudgetService {
+	return &budgetService{budgetRepo: budgetRepo, userService: userService, currencies: currencies, enforce: enforce, now: time.Now}
+}
+
+// monthStart is the start of the calendar month (UTC) budgets are counted over.
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (s *budgetService) SetTagBudget(req SetTagBudgetRequest) error {
+	if req.Currency == "" {
+		req.Currency = util.DefaultCurrency
+	}
+	// Sanitized like expense tags, so the two compare equal
+	req.Tag = util.SanitizeText(req.Tag)
+
+	users, err := s.userService.GetUsersByEmails([]string{req.UserEmail})
+	if err != nil || len(users) == 0 {
+		return fmt.Errorf("user with email %s not found", req.UserEmail)
+	}
+	userID := users[0].ID
+
+	if req.MonthlyLimit == 0 {
+		return s.budgetRepo.DeleteTagBudget(userID, req.Tag, req.Currency)
+	}
+	return s.budgetRepo.SetTagBudget(&repository.TagBudget{
+		UserID:       userID,
+		Tag:          req.Tag,
+		Currency:     req.Currency,
+		MonthlyLimit: req.MonthlyLimit,
+	})
+}
+
+func (s *budgetService) GetBudgetStatus(userEmail string) ([]BudgetStatus, error) {
+	users, err := s.userService.GetUsersByEmails([]string{userEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", userEmail)
+	}
+	userID := users[0].ID
+
+	budgets, err := s.budgetRepo.GetTagBudgets([]int{userID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budgets for user %s: %w", userEmail, err)
+	}
+
+	since := monthStart(s.now())
+	statuses := make([]BudgetStatus, 0, len(budgets))
+	for _, b := range budgets {
+		spent, err := s.budgetRepo.GetTagSpend(userID, b.Tag, b.Currency, since)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s spend for user %s: %w", b.Tag, userEmail, err)
+		}
+		exp := s.currencies.Exponent(b.Currency)
+		statuses = append(statuses, BudgetStatus{
+			Tag:          b.Tag,
+			Currency:     b.Currency,
+			MonthlyLimit: b.MonthlyLimit,
+			Spent:        util.RoundToCurrency(spent, exp),
+			Remaining:    util.RoundToCurrency(b.MonthlyLimit-spent, exp),
+		})
+	}
+
+	return statuses, nil
+}
+
+func (s *budgetService) CheckExpense(expense *repository.Expense, splits []repository.ExpenseSplit) ([]repository.BudgetWarning, error) {
+	owed := make(map[int]float64)
+	var userIDs []int
+	for _, split := range splits {
+		// Refunds only ever bring spend down
+		if split.AmountOwed > 0 {
+			owed[split.UserID] += split.AmountOwed
+			userIDs = append(userIDs, split.UserID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	budgets, err := s.budgetRepo.GetTagBudgets(userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag budgets: %w", err)
+	}
+
+	since := monthStart(s.now())
+	exp := s.currencies.Exponent(expense.Currency)
+	var warnings []repository.BudgetWarning
+	var overIDs []int
+	for _, b := range budgets {
+		if b.Tag != expense.Tag || b.Currency != expense.Currency {
+			continue
+		}
+		spent, err := s.budgetRepo.GetTagSpend(b.UserID, b.Tag, b.Currency, since)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s spend for user %d: %w", b.Tag, b.UserID, err)
+		}
+		if after := util.RoundToCurrency(spent+owed[b.UserID], exp); after > b.MonthlyLimit {
+			warnings = append(warnings, repository.BudgetWarning{
+				Tag:          b.Tag,
+				Currency:     b.Currency,
+				MonthlyLimit: b.MonthlyLimit,
+				SpentAfter:   after,
+			})
+			overIDs = append(overIDs, b.UserID)
+		}
+	}
+	if len(warnings) == 0 {
+		return nil, nil
+	}
+
+	users, err := s.userService.GetUsersByIDs(overIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users over budget: %w", err)
+	}
+	emails := make(map[int]string, len(users))
+	for _, u := range users {
+		emails[u.ID] = u.Email
+	}
+	for i := range warnings {
+		warnings[i].UserEmail = emails[overIDs[i]]
+	}
+
+	if s.enforce {
+		w := warnings[0]
+		return nil, fmt.Errorf("%w: %s would owe %.2f %s on %s this month, over the budget of %.2f", ErrTagBudgetExceeded, w.UserEmail, w.SpentAfter, w.Currency, w.Tag, w.MonthlyLimit)
+	}
+	return warnings, nil
+}
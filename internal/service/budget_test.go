@@ -0,0 +1,117 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBudgetService_CheckExpense(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	march := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	alice := &repository.User{ID: 1, Email: "alice@example.com"}
+
+	expense := &repository.Expense{Tag: "Eating Out", Currency: "USD", TotalAmount: 100}
+	splits := []repository.ExpenseSplit{
+		{UserID: 1, AmountPaid: 100, AmountOwed: 50},
+		{UserID: 2, AmountOwed: 50},
+	}
+	budgets := []repository.TagBudget{
+		{UserID: 1, Tag: "Eating Out", Currency: "USD", MonthlyLimit: 500},
+		{UserID: 1, Tag: "Eating Out", Currency: "EUR", MonthlyLimit: 10},
+		{UserID: 2, Tag: "Travel", Currency: "USD", MonthlyLimit: 10},
+	}
+
+	newService := func(repo *repomock.BudgetRepository, users *MockUserService, enforce bool) *budgetService {
+		s := NewBudgetService(repo, users, seededCurrencies, enforce).(*budgetService)
+		s.now = func() time.Time { return now }
+		return s
+	}
+
+	// Test case 1: Within budget, no warnings
+	{
+		repo, users := new(repomock.BudgetRepository), new(MockUserService)
+		repo.On("GetTagBudgets", []int{1, 2}).Return(budgets, nil).Once()
+		repo.On("GetTagSpend", 1, "Eating Out", "USD", march).Return(450.0, nil).Once()
+
+		warnings, err := newService(repo, users, false).CheckExpense(expense, splits)
+		assert.NoError(t, err)
+		assert.Empty(t, warnings)
+		repo.AssertExpectations(t)
+	}
+
+	// Test case 2: Over budget warns
+	{
+		repo, users := new(repomock.BudgetRepository), new(MockUserService)
+		repo.On("GetTagBudgets", []int{1, 2}).Return(budgets, nil).Once()
+		repo.On("GetTagSpend", 1, "Eating Out", "USD", march).Return(480.0, nil).Once()
+		users.On("GetUsersByIDs", []int{1}).Return([]*repository.User{alice}, nil).Once()
+
+		warnings, err := newService(repo, users, false).CheckExpense(expense, splits)
+		assert.NoError(t, err)
+		assert.Equal(t, []repository.BudgetWarning{
+			{UserEmail: "alice@example.com", Tag: "Eating Out", Currency: "USD", MonthlyLimit: 500, SpentAfter: 530},
+		}, warnings)
+		repo.AssertExpectations(t)
+		users.AssertExpectations(t)
+	}
+
+	// Test case 3: Over budget blocks when enforced
+	{
+		repo, users := new(repomock.BudgetRepository), new(MockUserService)
+		repo.On("GetTagBudgets", []int{1, 2}).Return(budgets, nil).Once()
+		repo.On("GetTagSpend", 1, "Eating Out", "USD", march).Return(480.0, nil).Once()
+		users.On("GetUsersByIDs", []int{1}).Return([]*repository.User{alice}, nil).Once()
+
+		_, err := newService(repo, users, true).CheckExpense(expense, splits)
+		assert.True(t, errors.Is(err, ErrTagBudgetExceeded))
+	}
+
+	// Test case 4: Refunds are never checked
+	{
+		repo, users := new(repomock.BudgetRepository), new(MockUserService)
+		warnings, err := newService(repo, users, true).CheckExpense(expense, []repository.ExpenseSplit{{UserID: 1, AmountOwed: -50}})
+		assert.NoError(t, err)
+		assert.Empty(t, warnings)
+		repo.AssertNotCalled(t, "GetTagBudgets", mock.Anything)
+	}
+}
+
+func TestBudgetService_GetBudgetStatus(t *testing.T) {
+	repo, users := new(repomock.BudgetRepository), new(MockUserService)
+	s := NewBudgetService(repo, users, seededCurrencies, false).(*budgetService)
+	s.now = func() time.Time { return time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC) }
+	march := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	users.On("GetUsersByEmails", []string{"alice@example.com"}).Return([]*repository.User{{ID: 1, Email: "alice@example.com"}}, nil).Once()
+	repo.On("GetTagBudgets", []int{1}).Return([]repository.TagBudget{{UserID: 1, Tag: "Food", Currency: "INR", MonthlyLimit: 100}}, nil).Once()
+	repo.On("GetTagSpend", 1, "Food", "INR", march).Return(120.5, nil).Once()
+
+	statuses, err := s.GetBudgetStatus("alice@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []BudgetStatus{{Tag: "Food", Currency: "INR", MonthlyLimit: 100, Spent: 120.5, Remaining: -20.5}}, statuses)
+	repo.AssertExpectations(t)
+}
+
+func TestBudgetService_SetTagBudget(t *testing.T) {
+	repo, users := new(repomock.BudgetRepository), new(MockUserService)
+	s := NewBudgetService(repo, users, seededCurrencies, false)
+	alice := []*repository.User{{ID: 1, Email: "alice@example.com"}}
+
+	// Test case 1: Currency defaults to INR
+	users.On("GetUsersByEmails", []string{"alice@example.com"}).Return(alice, nil).Twice()
+	repo.On("SetTagBudget", &repository.TagBudget{UserID: 1, Tag: "Food", Currency: "INR", MonthlyLimit: 100}).Return(nil).Once()
+	assert.NoError(t, s.SetTagBudget(SetTagBudgetRequest{UserEmail: "alice@example.com", Tag: "Food", MonthlyLimit: 100}))
+
+	// Test case 2: A zero limit removes the budget
+	repo.On("DeleteTagBudget", 1, "Food", "USD").Return(nil).Once()
+	assert.NoError(t, s.SetTagBudget(SetTagBudgetRequest{UserEmail: "alice@example.com", Tag: "Food", Currency: "USD"}))
+
+	repo.AssertExpectations(t)
+	users.AssertExpectations(t)
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// DigestJobType is the job that builds and sends one user's digest.
+const DigestJobType = "send_digest"
+
+// unsubscribePrefix scopes unsubscribe tokens, so no other signed token can stand in for one.
+const unsubscribePrefix = "unsubscribe-digest."
+
+// ErrInvalidDigestFrequency is returned for a frequency other than daily, weekly or never.
+var ErrInvalidDigestFrequency = errors.New("digest frequency must be daily, weekly or never")
+
+// ErrInvalidUnsubscribeToken is returned for an unsubscribe token that is malformed or wrongly signed.
+var ErrInvalidUnsubscribeToken = errors.New("unsubscribe link is not valid")
+
+// Digest is what happened around one user over one digest period.
+type Digest struct {
+	UserEmail          string                       `json:"user_email"`
+	UserName           string                       `json:"user_name"`
+	Since              time.Time                    `json:"since"`
+	Until              time.Time                    `json:"until"`
+	NewExpenses        []repository.UserExpenseView `json:"new_expenses"`
+	BalanceChanges     []UserBalanceView            `json:"balance_changes"`
+	PendingSettlements []SettlementView             `json:"pending_settlements"`
+	// DueLoans are the user's loans, lent or borrowed, due by the end of the period with something
+	// still owed on them.
+	DueLoans []LoanView `json:"due_loans"`
+}
+
+// Empty reports whether there is nothing worth emailing.
+func (d *Digest) Empty() bool {
+	return len(d.NewExpenses) == 0 && len(d.BalanceChanges) == 0 && len(d.PendingSettlements) == 0 && len(d.DueLoans) == 0
+}
+
+// DigestOptions configures digest delivery.
+type DigestOptions struct {
+	// BaseURL is the server's public address, used to build unsubscribe links.
+	BaseURL string
+	// LinkSecret signs unsubscribe links. Digests are not sent while it is empty.
+	LinkSecret string
+}
+
+// DigestService emails users a periodic summary of their expenses, balances, settlements and the
+// loans that have fallen due.
+type DigestService interface {
+	SetDigestFrequency(userID int, frequency repository.DigestFrequency) error
+	// Unsubscribe turns off digests for the user an unsubscribe token was made for.
+	Unsubscribe(token string) error
+	BuildDigest(userID int, since, until time.Time) (*Digest, error)
+	// ScheduleDue enqueues a digest job for every user whose digest is due and returns how many.
+	ScheduleDue() (int, error)
+	// Run calls ScheduleDue every interval until ctx is cancelled.
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type digestService struct {
+	prefRepo          repository.NotificationPreferenceRepository
+	userService       UserService
+	expenseService    ExpenseService
+	settlementService SettlementService
+	loanService       LoanService
+	jobService        JobService
+	notifier          Notifier
+	signer            *util.Signer
+	baseURL           string
+	now               func() time.Time
+}
+
+// digestJob is the payload of a DigestJobType job.
+type digestJob struct {
+	UserID int       `json:"user_id"`
+	Since  time.Time `json:"since"`
+	Until  time.Time `json:"until"`
+}
+
+// NewDigestService builds the digest service and registers its job with jobService.
+func NewDigestService(prefRepo repository.NotificationPreferenceRepository, userService UserService, expenseService ExpenseService, settlementService SettlementService, loanService LoanService, jobService JobService, notifier Notifier, opts DigestOptions) DigestService {
+	s := &digestService{
+		prefRepo:          prefRepo,
+		userService:       userService,
+		expenseService:    expenseService,
+		settlementService: settlementService,
+		loanService:       loanService,
+		jobService:        jobService,
+		notifier:          notifier,
+		signer:            util.NewSigner(opts.LinkSecret),
+		baseURL:           strings.TrimSuffix(opts.BaseURL, "/"),
+		now:               time.Now,
+	}
+	jobService.Register(DigestJobType, s.runDigestJob)
+	return s
+}
+
+func (s *digestService) SetDigestFrequency(userID int, frequency repository.DigestFrequency) error {
+	switch frequency {
+	case repository.DigestDaily, repository.DigestWeekly, repository.DigestNever:
+	default:
+		return fmt.Errorf("%w, got %q", ErrInvalidDigestFrequency, frequency)
+	}
+	if _, err := s.userService.GetUser(userID); err != nil {
+		return err
+	}
+	return s.prefRepo.SetDigestFrequency(userID, frequency)
+}
+
+func (s *digestService) Unsubscribe(token string) error {
+	// Anyone can sign with an empty key, so nothing verifies without one
+	if !s.signer.Enabled() {
+		return ErrInvalidUnsubscribeToken
+	}
+	payload, ok := s.signer.Verify(token)
+	idPart, scoped := strings.CutPrefix(payload, unsubscribePrefix)
+	userID, err := strconv.Atoi(idPart)
+	if !ok || !scoped || err != nil {
+		return ErrInvalidUnsubscribeToken
+	}
+	return s.prefRepo.SetDigestFrequency(userID, repository.DigestNever)
+}
+
+func (s *digestService) unsubscribeURL(userID int) string {
+	return s.baseURL + "/notifications/unsubscribe?token=" + url.QueryEscape(s.signer.Sign(unsubscribePrefix+strconv.Itoa(userID)))
+}
+
+func (s *digestService) BuildDigest(userID int, since, until time.Time) (*Digest, error) {
+	user, err := s.userService.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	digest := &Digest{
+		UserEmail:          user.Email,
+		UserName:           user.Name,
+		Since:              since,
+		Until:              until,
+		NewExpenses:        []repository.UserExpenseView{},
+		BalanceChanges:     []UserBalanceView{},
+		PendingSettlements: []SettlementView{},
+		DueLoans:           []LoanView{},
+	}
+	within := func(t time.Time) bool { return t.After(since) && !t.After(until) }
+
+	expenses, err := s.expenseService.GetExpensesForUser(user.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expenses for digest: %w", err)
+	}
+	for _, e := range expenses {
+		if within(e.Date) {
+			digest.NewExpenses = append(digest.NewExpenses, e)
+		}
+	}
+
+	balances, err := s.expenseService.GetOutstandingBalancesForUser(user.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances for digest: %w", err)
+	}
+	for _, b := range balances {
+		if within(b.LastUpdated) {
+			digest.BalanceChanges = append(digest.BalanceChanges, b)
+		}
+	}
+
+	settlements, err := s.settlementService.GetSettlementsForUser(user.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settlements for digest: %w", err)
+	}
+	for _, st := range settlements {
+		if st.Status == repository.SettlementProposed || st.Status == repository.SettlementSent {
+			digest.PendingSettlements = append(digest.PendingSettlements, st)
+		}
+	}
+
+	loans, err := s.loanService.GetLoansForUser(user.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loans for digest: %w", err)
+	}
+	// Due dates are in DateLayout, which sorts as the dates do
+	lastDay := until.Format(DateLayout)
+	for _, l := range loans {
+		if l.DueDate != "" && l.DueDate <= lastDay && l.Outstanding > 0 {
+			digest.DueLoans = append(digest.DueLoans, l)
+		}
+	}
+
+	return digest, nil
+}
+
+func (s *digestService) ScheduleDue() (int, error) {
+	// Without a key there is no unsubscribe link, and digests must always carry one
+	if !s.signer.Enabled() {
+		return 0, nil
+	}
+
+	now := s.now().Truncate(time.Second)
+	due, err := s.prefRepo.GetDueDigests(now)
+	if err != nil {
+		return 0, err
+	}
+
+	scheduled := 0
+	for _, d := range due {
+		claimed, err := s.prefRepo.ClaimDigest(d.UserID, d.LastDigestAt, now)
+		if err != nil {
+			return scheduled, err
+		}
+		if !claimed {
+			continue
+		}
+
+		since := now.Add(-d.Frequency.Period())
+		if d.LastDigestAt != nil {
+			since = *d.LastDigestAt
+		}
+		if _, err := s.jobService.Enqueue(DigestJobType, digestJob{UserID: d.UserID, Since: since, Until: now}); err != nil {
+			return scheduled, err
+		}
+		scheduled++
+	}
+	return scheduled, nil
+}
+
+func (s *digestService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.ScheduleDue(); err != nil {
+			log.Printf("digest scheduler: %v", err)
+		} else if n > 0 {
+			log.Printf("digest scheduler: queued %d digests", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *digestService) runDigestJob(ctx context.Context, payload json.RawMessage) error {
+	var job digestJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("invalid digest job payload: %w", err)
+	}
+
+	digest, err := s.BuildDigest(job.UserID, job.Since, job.Until)
+	if err != nil {
+		return err
+	}
+	if digest.Empty() {
+		return nil
+	}
+
+	unsubscribe := s.unsubscribeURL(job.UserID)
+	var body bytes.Buffer
+	if err := digestEmail.Execute(&body, struct {
+		*Digest
+		UnsubscribeURL string
+	}{digest, unsubscribe}); err != nil {
+		return fmt.Errorf("failed to render digest: %w", err)
+	}
+
+	return s.notifier.Notify(ctx, Notification{
+		UserID:         job.UserID,
+		Kind:           repository.KindDigest,
+		To:             digest.UserEmail,
+		Subject:        fmt.Sprintf("Your expenses since %s", digest.Since.Format("2 Jan")),
+		Body:           body.String(),
+		UnsubscribeURL: unsubscribe,
+	})
+}
+
+var digestEmail = template.Must(template.New("digest").Parse(`Hi {{.UserName}},
+
+Here is what happened between {{.Since.Format "2 Jan 2006"}} and {{.Until.Format "2 Jan 2006"}}.
+{{if .NewExpenses}}
+New expenses:
+{{range .NewExpenses}}  {{.Date.Format "2 Jan"}}  {{.Description}}: {{printf "%.2f" .TotalAmount}} {{.Currency}} (your share {{printf "%+.2f" .Share}})
+{{end}}{{end}}{{if .BalanceChanges}}
+Balances that changed:
+{{range .BalanceChanges}}  {{.WithUserName}}: {{printf "%+.2f" .Amount}}
+{{end}}{{end}}{{if .PendingSettlements}}
+Settlements waiting on someone:
+{{range .PendingSettlements}}  {{.Direction}} {{printf "%.2f" .Amount}} with {{.WithUserName}} ({{.Status}})
+{{end}}{{end}}{{if .DueLoans}}
+Loans due:
+{{range .DueLoans}}  {{.Direction}} {{printf "%.2f" .Amount}} with {{.WithUserName}}, due {{.DueDate}}: {{printf "%.2f" .Outstanding}} still owed
+{{end}}{{end}}
+To stop these emails, open {{.UnsubscribeURL}}
+`))
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testLinkSecret = "test-link-secret-0123456789abcdef"
+
+func TestDigestService_ScheduleDue(t *testing.T) {
+	prefRepo := new(repomock.NotificationPreferenceRepository)
+	jobRepo := new(repomock.JobRepository)
+	now := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)
+	jobs := NewJobService(jobRepo, JobOptions{MaxAttempts: 3})
+	jobs.(*jobService).now = func() time.Time { return now }
+	s := NewDigestService(prefRepo, nil, nil, nil, nil, jobs, NewLogNotifier(), DigestOptions{LinkSecret: testLinkSecret}).(*digestService)
+	s.now = func() time.Time { return now.Add(300 * time.Millisecond) }
+
+	lastWeek := now.Add(-8 * 24 * time.Hour)
+	prefRepo.On("GetDueDigests", now).Return([]repository.DueDigest{
+		{UserID: 1, Frequency: repository.DigestWeekly, LastDigestAt: &lastWeek},
+		{UserID: 2, Frequency: repository.DigestDaily},
+		{UserID: 3, Frequency: repository.DigestWeekly},
+	}, nil).Once()
+	prefRepo.On("ClaimDigest", 1, &lastWeek, now).Return(true, nil).Once()
+	prefRepo.On("ClaimDigest", 2, (*time.Time)(nil), now).Return(true, nil).Once()
+	// Another server got to user 3 first
+	prefRepo.On("ClaimDigest", 3, (*time.Time)(nil), now).Return(false, nil).Once()
+
+	var payloads []digestJob
+	jobRepo.On("CreateJob", mock.Anything).Run(func(args mock.Arguments) {
+		var p digestJob
+		assert.NoError(t, json.Unmarshal(args.Get(0).(*repository.Job).Payload, &p))
+		payloads = append(payloads, p)
+	}).Return(&repository.Job{}, nil).Twice()
+
+	// Test case 1: Digests pick up where the last one ended, or one period back for a first digest
+	n, err := s.ScheduleDue()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []digestJob{
+		{UserID: 1, Since: lastWeek, Until: now},
+		{UserID: 2, Since: now.Add(-24 * time.Hour), Until: now},
+	}, payloads)
+
+	// Test case 2: Without a link secret nothing is scheduled
+	s.signer = NewDigestService(prefRepo, nil, nil, nil, nil, jobs, NewLogNotifier(), DigestOptions{}).(*digestService).signer
+	n, err = s.ScheduleDue()
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+
+	prefRepo.AssertExpectations(t)
+	jobRepo.AssertExpectations(t)
+}
+
+func TestDigestService_Unsubscribe(t *testing.T) {
+	prefRepo := new(repomock.NotificationPreferenceRepository)
+	jobService := NewJobService(new(repomock.JobRepository), JobOptions{})
+	s := NewDigestService(prefRepo, nil, nil, nil, nil, jobService, NewLogNotifier(), DigestOptions{BaseURL: "https://split.example/", LinkSecret: testLinkSecret}).(*digestService)
+
+	link, err := url.Parse(s.unsubscribeURL(7))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://split.example/notifications/unsubscribe", strings.Split(link.String(), "?")[0])
+	token := link.Query().Get("token")
+
+	// Test case 1: The token turns digests off for its user
+	prefRepo.On("SetDigestFrequency", 7, repository.DigestNever).Return(nil).Once()
+	assert.NoError(t, s.Unsubscribe(token))
+
+	// Test case 2: Forged tokens and tokens signed for something else are refused
+	for _, bad := range []string{"", token + "x", "unsubscribe-digest.8." + strings.Split(token, ".")[2], s.signer.Sign("8")} {
+		assert.True(t, errors.Is(s.Unsubscribe(bad), ErrInvalidUnsubscribeToken), bad)
+	}
+
+	prefRepo.AssertExpectations(t)
+}
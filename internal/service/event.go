@@ -0,0 +1,351 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// ErrEventArchived is returned when adding an expense to an archived event.
+var ErrEventArchived = errors.New("event is archived")
+
+// ErrNotEventCreator is returned when someone other than its creator archives or unarchives an event.
+var ErrNotEventCreator = errors.New("only the event's creator can change it")
+
+// ErrEventHasExpenses is returned when changing the base currency of an event that already has
+// expenses, which were kept in the old one.
+var ErrEventHasExpenses = errors.New("event already has expenses")
+
+// ErrEventHasUnit is returned when giving a base currency to an event that counts its own unit.
+var ErrEventHasUnit = errors.New("event counts its own unit, not money")
+
+type CreateEventRequest struct {
+	Name           string `json:"name"`
+	CreatedByEmail string `json:"created_by_email"`
+	// BaseCurrency, when set, is the currency the event's expenses are converted to. Only the
+	// default currency is accepted, as balances are kept in it.
+	BaseCurrency string `json:"base_currency,omitempty"`
+	// Unit, when set, has the event count something other than money, such as chore points, in
+	// whole units that stay out of the balances. It can't be combined with a base currency.
+	Unit string `json:"unit,omitempty"`
+}
+
+type ArchiveEventRequest struct {
+	UserEmail string `json:"user_email"`
+}
+
+// SetAutoSettleRequest opts an event in to, or out of, having its month-end settle-up proposed as
+// settlements. UserEmail must be the event's creator.
+type SetAutoSettleRequest struct {
+	UserEmail string `json:"user_email"`
+	Enabled   bool   `json:"enabled"`
+}
+
+// SetBaseCurrencyRequest fixes an event's base currency, or clears it when BaseCurrency is empty.
+// UserEmail must be the event's creator.
+type SetBaseCurrencyRequest struct {
+	UserEmail    string `json:"user_email"`
+	BaseCurrency string `json:"base_currency"`
+}
+
+// EventSummary is an event's own ledger: what was spent, where each participant stands and the
+// fewest transfers that would square everyone up for this event alone.
+type EventSummary struct {
+	repository.Event
+	Totals       []EventTotal       `json:"totals"`
+	Participants []EventParticipant `json:"participants"`
+	SettleUp     []SettleUpTransfer `json:"settle_up"`
+}
+
+type EventTotal struct {
+	Currency     string  `json:"currency"`
+	TotalAmount  float64 `json:"total_amount"`
+	ExpenseCount int     `json:"expense_count"`
+}
+
+// EventParticipant is one user's position in one currency of an event. Net is positive when the
+// others owe them.
+type EventParticipant struct {
+	UserEmail string  `json:"user_email"`
+	UserName  string  `json:"user_name"`
+	Currency  string  `json:"currency"`
+	Paid      float64 `json:"paid"`
+	Owed      float64 `json:"owed"`
+	Net       float64 `json:"net"`
+}
+
+// SettleUpTransfer is a payment that would settle part of an event.
+type SettleUpTransfer struct {
+	FromEmail string  `json:"from_email"`
+	ToEmail   string  `json:"to_email"`
+	Currency  string  `json:"currency"`
+	Amount    float64 `json:"amount"`
+	// Payments are the ways the payee can be paid, when they have set any payment handles.
+	Payments []PaymentLink `json:"payments,omitempty"`
+}
+
+// EventService manages trips and occasions that collect expenses into their own sub-ledger.
+type EventService interface {
+	CreateEvent(req CreateEventRequest) (*repository.Event, error)
+	GetEventSummary(id int) (*EventSummary, error)
+	ArchiveEvent(id int, req ArchiveEventRequest) (*repository.Event, error)
+	UnarchiveEvent(id int, req ArchiveEventRequest) (*repository.Event, error)
+	// ListEvents returns the user's events, leaving out archived ones unless includeArchived is set.
+	ListEvents(userEmail string, includeArchived bool) ([]repository.Event, error)
+	SetAutoSettle(id int, req SetAutoSettleRequest) (*repository.Event, error)
+	// SetBaseCurrency changes the event's base currency, as long as it has no expenses yet and
+	// doesn't count a unit of its own.
+	SetBaseCurrency(id int, req SetBaseCurrencyRequest) (*repository.Event, error)
+}
+
+type eventService struct {
+	eventRepo    repository.EventRepository
+	userService  UserService
+	quotaService QuotaService
+	currencies   util.Currencies
+}
+
+// NewEventService builds the event service. quotaService may be nil, in which case the number of open
+// events is not limited.
+func NewEventService(eventRepo repository.EventRepository, userService UserService, quotaService QuotaService, currencies util.Currencies) EventService {
+	return &eventService{eventRepo: eventRepo, userService: userService, quotaService: quotaService, currencies: currencies}
+}
+
+func (s *eventService) CreateEvent(req CreateEventRequest) (*repository.Event, error) {
+	users, err := s.userService.GetUsersByEmails([]string{req.CreatedByEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", req.CreatedByEmail)
+	}
+	unit := util.SanitizeText(req.Unit)
+	if req.BaseCurrency != "" {
+		if unit != "" {
+			return nil, fmt.Errorf("%w: it can't also have base currency %s", ErrEventHasUnit, req.BaseCurrency)
+		}
+		if err := checkBalanceCurrency(req.BaseCurrency); err != nil {
+			return nil, err
+		}
+	}
+	if s.quotaService != nil {
+		if err := s.quotaService.CheckEvent(users[0].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.eventRepo.CreateEvent(&repository.Event{Name: util.SanitizeText(req.Name), CreatedBy: users[0].ID, BaseCurrency: req.BaseCurrency, Unit: unit})
+}
+
+func (s *eventService) ArchiveEvent(id int, req ArchiveEventRequest) (*repository.Event, error) {
+	if err := s.checkCreator(id, req.UserEmail); err != nil {
+		return nil, err
+	}
+	return s.eventRepo.ArchiveEvent(id)
+}
+
+func (s *eventService) UnarchiveEvent(id int, req ArchiveEventRequest) (*repository.Event, error) {
+	if err := s.checkCreator(id, req.UserEmail); err != nil {
+		return nil, err
+	}
+	// Reopening an event takes up a place in the creator's quota again
+	if s.quotaService != nil {
+		event, err := s.eventRepo.GetEvent(id)
+		if err != nil {
+			return nil, err
+		}
+		if event.ArchivedAt != nil {
+			if err := s.quotaService.CheckEvent(event.CreatedBy); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s.eventRepo.UnarchiveEvent(id)
+}
+
+func (s *eventService) SetAutoSettle(id int, req SetAutoSettleRequest) (*repository.Event, error) {
+	if err := s.checkCreator(id, req.UserEmail); err != nil {
+		return nil, err
+	}
+	return s.eventRepo.SetAutoSettle(id, req.Enabled)
+}
+
+func (s *eventService) SetBaseCurrency(id int, req SetBaseCurrencyRequest) (*repository.Event, error) {
+	if err := s.checkCreator(id, req.UserEmail); err != nil {
+		return nil, err
+	}
+	if req.BaseCurrency != "" {
+		event, err := s.eventRepo.GetEvent(id)
+		if err != nil {
+			return nil, err
+		}
+		if event.Unit != "" {
+			return nil, fmt.Errorf("%w: %s counts %s", ErrEventHasUnit, event.Name, event.Unit)
+		}
+		if err := checkBalanceCurrency(req.BaseCurrency); err != nil {
+			return nil, err
+		}
+	}
+	expenses, err := s.eventRepo.GetEventExpenses(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(expenses) > 0 {
+		return nil, ErrEventHasExpenses
+	}
+	return s.eventRepo.SetBaseCurrency(id, req.BaseCurrency)
+}
+
+// checkCreator makes sure the event exists and was created by the user.
+func (s *eventService) checkCreator(id int, userEmail string) error {
+	event, err := s.eventRepo.GetEvent(id)
+	if err != nil {
+		return err
+	}
+
+	users, err := s.userService.GetUsersByEmails([]string{userEmail})
+	if err != nil || len(users) == 0 {
+		return fmt.Errorf("user with email %s not found", userEmail)
+	}
+	if users[0].ID != event.CreatedBy {
+		return ErrNotEventCreator
+	}
+	return nil
+}
+
+func (s *eventService) ListEvents(userEmail string, includeArchived bool) ([]repository.Event, error) {
+	users, err := s.userService.GetUsersByEmails([]string{userEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", userEmail)
+	}
+	return s.eventRepo.ListEvents(users[0].ID, includeArchived)
+}
+
+func (s *eventService) GetEventSummary(id int) (*EventSummary, error) {
+	event, err := s.eventRepo.GetEvent(id)
+	if err != nil {
+		return nil, err
+	}
+	splits, err := s.eventRepo.GetEventSplits(id)
+	if err != nil {
+		return nil, err
+	}
+
+	type position struct {
+		userID   int
+		currency string
+	}
+	var (
+		summary    = &EventSummary{Event: *event, Totals: []EventTotal{}, Participants: []EventParticipant{}, SettleUp: []SettleUpTransfer{}}
+		totals     = make(map[string]*EventTotal)
+		paid, owed = make(map[position]int64), make(map[position]int64)
+		positions  []position
+		expenses   = util.NewSet[int]()
+		userIDs    = util.NewSet[int]()
+	)
+	for _, sp := range splits {
+		exp := s.currencies.Exponent(sp.Currency)
+		t, ok := totals[sp.Currency]
+		if !ok {
+			t = &EventTotal{Currency: sp.Currency}
+			totals[sp.Currency] = t
+		}
+		if !expenses.IsMember(sp.ExpenseID) {
+			expenses.Add(sp.ExpenseID)
+			t.ExpenseCount++
+		}
+		t.TotalAmount = util.RoundToCurrency(t.TotalAmount+sp.AmountPaid, exp)
+
+		p := position{userID: sp.UserID, currency: sp.Currency}
+		if _, seen := paid[p]; !seen {
+			positions = append(positions, p)
+		}
+		paid[p] += util.ToMinorUnits(sp.AmountPaid, exp)
+		owed[p] += util.ToMinorUnits(sp.AmountOwed, exp)
+		userIDs.Add(sp.UserID)
+	}
+	if len(positions) == 0 {
+		return summary, nil
+	}
+
+	users, err := s.userService.GetUsersByIDs(userIDs.ToList())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event participants: %w", err)
+	}
+	byID := make(map[int]*repository.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+
+	for _, t := range totals {
+		summary.Totals = append(summary.Totals, *t)
+	}
+	sort.Slice(summary.Totals, func(i, j int) bool { return summary.Totals[i].Currency < summary.Totals[j].Currency })
+
+	nets := make(map[string][]settleUpBalance)
+	for _, p := range positions {
+		exp := s.currencies.Exponent(p.currency)
+		u := byID[p.userID]
+		net := paid[p] - owed[p]
+		summary.Participants = append(summary.Participants, EventParticipant{
+			UserEmail: u.Email,
+			UserName:  u.Name,
+			Currency:  p.currency,
+			Paid:      util.FromMinorUnits(paid[p], exp),
+			Owed:      util.FromMinorUnits(owed[p], exp),
+			Net:       util.FromMinorUnits(net, exp),
+		})
+		nets[p.currency] = append(nets[p.currency], settleUpBalance{email: u.Email, units: net})
+	}
+	for _, t := range summary.Totals {
+		summary.SettleUp = append(summary.SettleUp, settleUp(t.Currency, s.currencies.Exponent(t.Currency), nets[t.Currency])...)
+	}
+
+	return summary, nil
+}
+
+// settleUpBalance is what a user is owed (positive) or owes (negative) in minor units.
+type settleUpBalance struct {
+	email string
+	units int64
+}
+
+// settleUp pairs the largest debtor with the largest creditor until everyone is square, which
+// needs at most one transfer fewer than there are users with a non-zero balance. Amounts are in
+// exp decimal places, the currency's minor unit.
+func settleUp(currency string, exp int, balances []settleUpBalance) []SettleUpTransfer {
+	var creditors, debtors []settleUpBalance
+	for _, b := range balances {
+		switch {
+		case b.units > 0:
+			creditors = append(creditors, b)
+		case b.units < 0:
+			debtors = append(debtors, settleUpBalance{email: b.email, units: -b.units})
+		}
+	}
+	largestFirst := func(bs []settleUpBalance) {
+		sort.Slice(bs, func(i, j int) bool {
+			if bs[i].units != bs[j].units {
+				return bs[i].units > bs[j].units
+			}
+			return bs[i].email < bs[j].email
+		})
+	}
+	largestFirst(creditors)
+	largestFirst(debtors)
+
+	var transfers []SettleUpTransfer
+	for c, d := 0, 0; c < len(creditors) && d < len(debtors); {
+		amount := min(creditors[c].units, debtors[d].units)
+		transfers = append(transfers, SettleUpTransfer{FromEmail: debtors[d].email, ToEmail: creditors[c].email, Currency: currency, Amount: util.FromMinorUnits(amount, exp)})
+		creditors[c].units -= amount
+		debtors[d].units -= amount
+		if creditors[c].units == 0 {
+			c++
+		}
+		if debtors[d].units == 0 {
+			d++
+		}
+	}
+	return transfers
+}
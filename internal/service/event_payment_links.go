@@ -0,0 +1,27 @@
+package service
+
+type paymentLinkingEventService struct {
+	EventService
+	paymentService PaymentService
+}
+
+// NewPaymentLinkingEventService wraps inner so that each transfer in an event's settle-up comes
+// with links to pay it through the payee's payment handles, unless the event counts its own unit.
+func NewPaymentLinkingEventService(inner EventService, paymentService PaymentService) EventService {
+	return &paymentLinkingEventService{EventService: inner, paymentService: paymentService}
+}
+
+func (s *paymentLinkingEventService) GetEventSummary(id int) (*EventSummary, error) {
+	summary, err := s.EventService.GetEventSummary(id)
+	if err != nil {
+		return nil, err
+	}
+	// Points and tokens aren't paid through a payment app
+	if summary.Unit != "" {
+		return summary, nil
+	}
+	if err := s.paymentService.LinkTransfers(summary.SettleUp, "Settle up: "+summary.Name); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventService_GetEventSummary(t *testing.T) {
+	eventRepo := new(repomock.EventRepository)
+	userService := new(MockUserService)
+	s := NewEventService(eventRepo, userService, nil, seededCurrencies)
+
+	alice := &repository.User{ID: 1, Email: "alice@example.com", Name: "Alice"}
+	bob := &repository.User{ID: 2, Email: "bob@example.com", Name: "Bob"}
+	event := &repository.Event{ID: 7, Name: "Goa trip", CreatedBy: alice.ID}
+
+	// Test case 1: Totals and settle-up are kept per currency
+	{
+		eventRepo.On("GetEvent", 7).Return(event, nil).Once()
+		eventRepo.On("GetEventSplits", 7).Return([]repository.EventSplit{
+			{ExpenseID: 1, Currency: "INR", UserID: 1, AmountPaid: 100, AmountOwed: 50},
+			{ExpenseID: 1, Currency: "INR", UserID: 2, AmountPaid: 0, AmountOwed: 50},
+			{ExpenseID: 2, Currency: "USD", UserID: 2, AmountPaid: 10, AmountOwed: 3.33},
+			{ExpenseID: 2, Currency: "USD", UserID: 1, AmountPaid: 0, AmountOwed: 6.67},
+		}, nil).Once()
+		userService.On("GetUsersByIDs", mock.Anything).Return([]*repository.User{alice, bob}, nil).Once()
+
+		summary, err := s.GetEventSummary(7)
+		assert.NoError(t, err)
+		assert.Equal(t, []EventTotal{{Currency: "INR", TotalAmount: 100, ExpenseCount: 1}, {Currency: "USD", TotalAmount: 10, ExpenseCount: 1}}, summary.Totals)
+		assert.Len(t, summary.Participants, 4)
+		assert.Equal(t, []SettleUpTransfer{
+			{FromEmail: bob.Email, ToEmail: alice.Email, Currency: "INR", Amount: 50},
+			{FromEmail: alice.Email, ToEmail: bob.Email, Currency: "USD", Amount: 6.67},
+		}, summary.SettleUp)
+	}
+
+	// Test case 2: An empty event has an empty ledger
+	{
+		eventRepo.On("GetEvent", 7).Return(event, nil).Once()
+		eventRepo.On("GetEventSplits", 7).Return([]repository.EventSplit{}, nil).Once()
+
+		summary, err := s.GetEventSummary(7)
+		assert.NoError(t, err)
+		assert.Empty(t, summary.Totals)
+		assert.Empty(t, summary.SettleUp)
+	}
+
+	eventRepo.AssertExpectations(t)
+	userService.AssertExpectations(t)
+}
+
+func TestSettleUp(t *testing.T) {
+	// Test case 1: Largest debtor pays largest creditor first
+	transfers := settleUp("INR", 2, []settleUpBalance{
+		{email: "a", units: 9000},
+		{email: "b", units: -3000},
+		{email: "c", units: -6000},
+		{email: "d", units: 0},
+	})
+	assert.Equal(t, []SettleUpTransfer{
+		{FromEmail: "c", ToEmail: "a", Currency: "INR", Amount: 60},
+		{FromEmail: "b", ToEmail: "a", Currency: "INR", Amount: 30},
+	}, transfers)
+
+	// Test case 2: A debt split across two creditors
+	transfers = settleUp("INR", 2, []settleUpBalance{
+		{email: "a", units: 500},
+		{email: "b", units: 250},
+		{email: "c", units: -750},
+	})
+	assert.Equal(t, []SettleUpTransfer{
+		{FromEmail: "c", ToEmail: "a", Currency: "INR", Amount: 5},
+		{FromEmail: "c", ToEmail: "b", Currency: "INR", Amount: 2.5},
+	}, transfers)
+
+	// Test case 3: Everyone square
+	assert.Empty(t, settleUp("INR", 2, []settleUpBalance{{email: "a"}, {email: "b"}}))
+}
+
+func TestEventService_ArchiveEvent(t *testing.T) {
+	eventRepo := new(repomock.EventRepository)
+	userService := new(MockUserService)
+	s := NewEventService(eventRepo, userService, nil, seededCurrencies)
+
+	alice := &repository.User{ID: 1, Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Email: "bob@example.com"}
+	event := &repository.Event{ID: 7, Name: "Goa trip", CreatedBy: alice.ID}
+
+	// Test case 1: Someone else cannot archive the event
+	eventRepo.On("GetEvent", 7).Return(event, nil).Once()
+	userService.On("GetUsersByEmails", []string{bob.Email}).Return([]*repository.User{bob}, nil).Once()
+	_, err := s.ArchiveEvent(7, ArchiveEventRequest{UserEmail: bob.Email})
+	assert.True(t, errors.Is(err, ErrNotEventCreator))
+
+	// Test case 2: The creator can
+	eventRepo.On("GetEvent", 7).Return(event, nil).Once()
+	userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+	eventRepo.On("ArchiveEvent", 7).Return(event, nil).Once()
+	_, err = s.ArchiveEvent(7, ArchiveEventRequest{UserEmail: alice.Email})
+	assert.NoError(t, err)
+
+	eventRepo.AssertExpectations(t)
+	userService.AssertExpectations(t)
+}
+
+func TestEventService_UnarchiveEvent(t *testing.T) {
+	eventRepo := new(repomock.EventRepository)
+	userService := new(MockUserService)
+	s := NewEventService(eventRepo, userService, nil, seededCurrencies)
+
+	alice := &repository.User{ID: 1, Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Email: "bob@example.com"}
+	event := &repository.Event{ID: 7, Name: "Goa trip", CreatedBy: alice.ID}
+
+	// Test case 1: Someone else cannot unarchive the event
+	eventRepo.On("GetEvent", 7).Return(event, nil).Once()
+	userService.On("GetUsersByEmails", []string{bob.Email}).Return([]*repository.User{bob}, nil).Once()
+	_, err := s.UnarchiveEvent(7, ArchiveEventRequest{UserEmail: bob.Email})
+	assert.ErrorIs(t, err, ErrNotEventCreator)
+
+	// Test case 2: The creator can
+	eventRepo.On("GetEvent", 7).Return(event, nil).Once()
+	userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+	eventRepo.On("UnarchiveEvent", 7).Return(event, nil).Once()
+	_, err = s.UnarchiveEvent(7, ArchiveEventRequest{UserEmail: alice.Email})
+	assert.NoError(t, err)
+
+	// Test case 3: Unknown events are reported as such
+	eventRepo.On("GetEvent", 8).Return(nil, repository.ErrEventNotFound).Once()
+	_, err = s.UnarchiveEvent(8, ArchiveEventRequest{UserEmail: alice.Email})
+	assert.ErrorIs(t, err, repository.ErrEventNotFound)
+
+	eventRepo.AssertExpectations(t)
+	userService.AssertExpectations(t)
+}
+
+func TestEventService_CreateEvent(t *testing.T) {
+	eventRepo := new(repomock.EventRepository)
+	userService := new(MockUserService)
+	s := NewEventService(eventRepo, userService, nil, seededCurrencies)
+
+	alice := &repository.User{ID: 1, Email: "alice@example.com"}
+	userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil)
+
+	// Test case 1: An event can count chore points instead of money
+	eventRepo.On("CreateEvent", &repository.Event{Name: "Flat", CreatedBy: alice.ID, Unit: "chore points"}).Return(&repository.Event{ID: 3, Unit: "chore points"}, nil).Once()
+	event, err := s.CreateEvent(CreateEventRequest{Name: "Flat", CreatedByEmail: alice.Email, Unit: " chore points "})
+	require.NoError(t, err)
+	assert.Equal(t, "chore points", event.Unit)
+
+	// Test case 2: But not with a base currency as well
+	_, err = s.CreateEvent(CreateEventRequest{Name: "Flat", CreatedByEmail: alice.Email, Unit: "chore points", BaseCurrency: "INR"})
+	assert.ErrorIs(t, err, ErrEventHasUnit)
+
+	eventRepo.AssertExpectations(t)
+}
+
+func TestEventService_SetBaseCurrency(t *testing.T) {
+	eventRepo := new(repomock.EventRepository)
+	userService := new(MockUserService)
+	s := NewEventService(eventRepo, userService, nil, seededCurrencies)
+
+	alice := &repository.User{ID: 1, Email: "alice@example.com"}
+	eventRepo.On("GetEvent", 7).Return(&repository.Event{ID: 7, Name: "Lisbon", CreatedBy: alice.ID}, nil)
+	userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil)
+	req := SetBaseCurrencyRequest{UserEmail: alice.Email, BaseCurrency: "INR"}
+
+	// Test case 1: Expenses already kept in another currency pin it
+	eventRepo.On("GetEventExpenses", 7).Return([]repository.EventExpense{{ID: 3, Currency: "USD"}}, nil).Once()
+	_, err := s.SetBaseCurrency(7, req)
+	assert.ErrorIs(t, err, ErrEventHasExpenses)
+
+	// Test case 2: An event without expenses can fix one
+	eventRepo.On("GetEventExpenses", 7).Return([]repository.EventExpense(nil), nil).Once()
+	eventRepo.On("SetBaseCurrency", 7, "INR").Return(&repository.Event{ID: 7, BaseCurrency: "INR"}, nil).Once()
+	event, err := s.SetBaseCurrency(7, req)
+	assert.NoError(t, err)
+	assert.Equal(t, "INR", event.BaseCurrency)
+
+	// Test case 3: Balances can't be kept in any other currency
+	_, err = s.SetBaseCurrency(7, SetBaseCurrencyRequest{UserEmail: alice.Email, BaseCurrency: "EUR"})
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+
+	// Test case 4: An event counting its own unit can't take a currency
+	eventRepo.On("GetEvent", 8).Return(&repository.Event{ID: 8, Name: "Flat", CreatedBy: alice.ID, Unit: "chore points"}, nil)
+	_, err = s.SetBaseCurrency(8, req)
+	assert.ErrorIs(t, err, ErrEventHasUnit)
+
+	eventRepo.AssertExpectations(t)
+}
@@ -1,7 +1,10 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"math"
 	"time"
 
 	"github.com/aadithya-md/split-expense/internal/repository"
@@ -15,6 +18,8 @@ const (
 	SplitMethodEqual      SplitMethodType = "equal"
 	SplitMethodPercentage SplitMethodType = "percentage"
 	SplitMethodManual     SplitMethodType = "manual"
+	SplitMethodDays       SplitMethodType = "days"
+	SplitMethodWeighted   SplitMethodType = "weighted"
 )
 
 type EqualSplitRequest struct {
@@ -37,54 +42,192 @@ type ManualSplitRequest struct {
 	AmountPaid float64 `json:"amount_paid,omitempty"`
 }
 
+// DaysSplitRequest prorates a trip cost by the days a participant was present, both dates inclusive.
+type DaysSplitRequest struct {
+	UserEmail  string  `json:"user_email"`
+	UserID     int     `json:"-"`          // Populated by service layer
+	JoinDate   string  `json:"join_date"`  // YYYY-MM-DD
+	LeaveDate  string  `json:"leave_date"` // YYYY-MM-DD
+	AmountPaid float64 `json:"amount_paid,omitempty"`
+}
+
+// WeightedSplitRequest shares the cost in proportion to the split weight stored on each participant.
+type WeightedSplitRequest struct {
+	UserEmail  string  `json:"user_email"`
+	UserID     int     `json:"-"` // Populated by service layer
+	Weight     float64 `json:"-"` // Populated by service layer from the user's stored split weight
+	AmountPaid float64 `json:"amount_paid,omitempty"`
+}
+
 type CreateExpenseRequest struct {
+	PublicID         string                   `json:"public_id,omitempty"` // ID the expense is to be known by, generated when left out
 	Description      string                   `json:"description"`
 	Tag              string                   `json:"tag"`
-	TotalAmount      float64                  `json:"total_amount"`
+	TotalAmount      float64                  `json:"total_amount"`             // Before tax and tip when either is given
+	TaxAmount        float64                  `json:"tax_amount,omitempty"`     // Shared in proportion to the pre-tax owed amounts
+	TipPercentage    float64                  `json:"tip_percentage,omitempty"` // Of the pre-tax total, shared like the tax
+	Currency         string                   `json:"currency,omitempty"`       // ISO 4217 code, defaults to INR
+	RefundOf         string                   `json:"refund_of,omitempty"`      // Public ID of the expense being partly or fully refunded
+	RefundOfID       *int                     `json:"-"`                        // Populated by service layer
+	PayeePartyID     *int                     `json:"payee_party_id,omitempty"` // Outside party, like a landlord, the money went to
+	Location         *repository.Location     `json:"location,omitempty"`
+	EventID          *int                     `json:"event_id,omitempty"` // Trip or occasion to file the expense under
 	CreatedByEmail   string                   `json:"created_by_email"`
 	CreatedByID      int                      `json:"-"`            // Populated by service layer
-	SplitMethod      SplitMethodType          `json:"split_method"` // "equal", "percentage", "manual"
+	SplitMethod      SplitMethodType          `json:"split_method"` // "equal", "percentage", "manual", "days", "weighted"
 	EqualSplits      []EqualSplitRequest      `json:"equal_splits,omitempty"`
 	PercentageSplits []PercentageSplitRequest `json:"percentage_splits,omitempty"`
 	ManualSplits     []ManualSplitRequest     `json:"manual_splits,omitempty"`
+	DaysSplits       []DaysSplitRequest       `json:"days_splits,omitempty"`
+	WeightedSplits   []WeightedSplitRequest   `json:"weighted_splits,omitempty"`
+	// Explain asks for the math behind the splits in the response, see repository.SplitExplanation.
+	Explain bool `json:"explain,omitempty"`
+	// PayerOnly records an expense the creator paid in full on behalf of the participants, like a
+	// parent paying for their kids. The creator is left out of the splits and owes nothing.
+	PayerOnly bool `json:"payer_only,omitempty"`
+	// AllowZeroAmounts accepts percentage and manual participants who neither owe nor paid anything,
+	// which are otherwise taken for a mistake.
+	AllowZeroAmounts bool `json:"allow_zero_amounts,omitempty"`
+	// BalanceStrategy picks how the splits move balances between the participants, "simple" when
+	// left out. See BalanceStrategyType.
+	BalanceStrategy BalanceStrategyType `json:"balance_strategy,omitempty"`
+}
+
+// ErrFractionalUnits is returned when an expense in an event that counts its own unit has an amount
+// that isn't a whole number of it.
+var ErrFractionalUnits = errors.New("amounts must be whole units")
+
+// ErrNotExpenseParticipant is returned when a user acts on an expense they are not part of.
+var ErrNotExpenseParticipant = errors.New("user is not allowed to act on this expense")
+
+type DisputeExpenseRequest struct {
+	UserEmail string `json:"user_email"`
+	Reason    string `json:"reason"`
+}
+
+type DismissDisputeRequest struct {
+	UserEmail string `json:"user_email"`
+}
+
+// ErrExpenseNotUndoable is returned when deleting an expense whose undo window has passed, or that has
+// already been refunded.
+var ErrExpenseNotUndoable = errors.New("expense can no longer be undone")
+
+type UndoExpenseRequest struct {
+	UserEmail string `json:"user_email"`
+}
+
+type UnlockExpenseRequest struct {
+	UserEmail string `json:"user_email"`
 }
 
 type ExpenseService interface {
 	CreateExpense(req CreateExpenseRequest) (*repository.Expense, error)
+	GetExpenseByPublicID(publicID string) (*repository.Expense, error)
+	// DisputeExpense flags an expense on behalf of one of its participants. While disputed, settlements
+	// between the creator and the other participants are refused.
+	DisputeExpense(id int, req DisputeExpenseRequest) (*repository.Expense, error)
+	// DismissExpenseDispute lets the creator of a disputed expense put it back into effect.
+	DismissExpenseDispute(id int, req DismissDisputeRequest) (*repository.Expense, error)
+	// UndoExpense lets the creator delete an expense within the undo window, as if it had never been added.
+	// Undoing an expense that a settlement had paid for proposes settlements that square its pairs again.
+	UndoExpense(id int, req UndoExpenseRequest) error
+	// UnlockExpense lets the creator of an expense locked by a confirmed settlement change it again.
+	UnlockExpense(id int, req UnlockExpenseRequest) (*repository.Expense, error)
 	GetExpensesForUser(userEmail string) ([]repository.UserExpenseView, error)
+	// GetExpensePageForUser returns limit of the user's expenses from offset, newest first, and how
+	// many expenses the user has in all. With runningBalance, each carries the RunningBalance
+	// WithRunningBalance would give it over the whole list.
+	GetExpensePageForUser(userEmail string, limit, offset int, runningBalance bool) ([]repository.UserExpenseView, int, error)
+	// GetNearbyExpenses lists the user's expenses located within radius meters of a point, nearest first.
+	GetNearbyExpenses(userEmail string, latitude, longitude, radius float64) ([]repository.NearbyExpense, error)
 	GetOutstandingBalancesForUser(userEmail string) ([]UserBalanceView, error)
 	GetOverallOutstandingBalance(userEmail string) (float64, error)
+	// GetBalanceList lists what others owe the user, or what the user owes others, largest first,
+	// limit of them from offset. A limit of 0 lists them all.
+	GetBalanceList(userEmail string, direction repository.DebtDirection, limit, offset int) (*BalanceList, error)
 }
 
 type UserBalanceView struct {
-	WithUserEmail string    `json:"with_user_email"`
-	WithUserName  string    `json:"with_user_name"`
-	Amount        float64   `json:"amount"`
-	LastUpdated   time.Time `json:"last_updated"`
+	WithUserEmail string  `json:"with_user_email"`
+	WithUserName  string  `json:"with_user_name"`
+	Amount        float64 `json:"amount"`
+	// PaidAmount is what settlements have paid towards the balance since the pair was last even, set
+	// on balances that have been settled in part.
+	PaidAmount  float64   `json:"paid_amount,omitempty"`
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+// BalanceList is one side of a user's balances: what others owe them, or what they owe others. The
+// amounts are positive either way. Count and Total cover the whole side, however many of its
+// balances are listed.
+type BalanceList struct {
+	Count    int
+	Total    float64
+	Balances []UserBalanceView
 }
 
 type expenseService struct {
-	expenseRepo repository.ExpenseRepository
-	userService UserService
-	balanceRepo repository.BalanceRepository
+	expenseRepo    repository.ExpenseRepository
+	userService    UserService
+	balanceRepo    repository.BalanceRepository
+	budgetService  BudgetService
+	quotaService   QuotaService
+	partyRepo      repository.PartyRepository
+	eventRepo      repository.EventRepository
+	settlementRepo repository.SettlementRepository
+	ledgerRepo     repository.LedgerRepository
+	rateService    RateService
+	tagService     TagService
+	ids            util.IDGenerator
+	currencies     util.Currencies
+	undoWindow     time.Duration
+	validation     ValidationPolicy
+	now            func() time.Time
 }
 
-func NewExpenseService(expenseRepo repository.ExpenseRepository, userService UserService, balanceRepo repository.BalanceRepository) ExpenseService {
-	return &expenseService{expenseRepo: expenseRepo, userService: userService, balanceRepo: balanceRepo}
+// NewExpenseService builds the expense service. budgetService and quotaService may be nil, in which case tag budgets
+// and quotas are not checked.
+// partyRepo and eventRepo may be nil, in which case expenses cannot name an outside payee or an event.
+// settlementRepo may be nil, in which case undoing a settled expense proposes no settlements.
+// ledgerRepo may be nil, in which case balances don't tell what has been paid towards them.
+// rateService may be nil, in which case an event's expenses must be in its base currency.
+// tagService may be nil, in which case expenses created without a tag get no suggested one.
+// ids may be nil, in which case public IDs are random UUIDs.
+// Amounts are rounded to the minor unit currencies gives their currency.
+// A zero undoWindow means expenses cannot be undone, and a zero validation policy is strict.
+func NewExpenseService(expenseRepo repository.ExpenseRepository, userService UserService, balanceRepo repository.BalanceRepository, budgetService BudgetService, quotaService QuotaService, partyRepo repository.PartyRepository, eventRepo repository.EventRepository, settlementRepo repository.SettlementRepository, ledgerRepo repository.LedgerRepository, rateService RateService, tagService TagService, ids util.IDGenerator, currencies util.Currencies, undoWindow time.Duration, validation ValidationPolicy) ExpenseService {
+	if ids == nil {
+		ids = util.UUIDGenerator{}
+	}
+	return &expenseService{expenseRepo: expenseRepo, userService: userService, balanceRepo: balanceRepo, budgetService: budgetService, quotaService: quotaService, partyRepo: partyRepo, eventRepo: eventRepo, settlementRepo: settlementRepo, ledgerRepo: ledgerRepo, rateService: rateService, tagService: tagService, ids: ids, currencies: currencies, undoWindow: undoWindow, validation: validation, now: time.Now}
 }
 
-func (s *expenseService) calculateExpenseSplits(req CreateExpenseRequest) ([]repository.ExpenseSplit, error) {
+// GrandTotal returns the amount actually paid: the total plus tax and tip, rounded to exp decimal
+// places, the currency's minor unit.
+func (r CreateExpenseRequest) GrandTotal(exp int) float64 {
+	return util.FromMinorUnits(util.ToMinorUnits(r.TotalAmount, exp)+taxAndTipUnits(r, exp), exp)
+}
+
+// calculateExpenseSplits splits the expense, in exp decimal places, and, when req.Explain is set,
+// explains how.
+func (s *expenseService) calculateExpenseSplits(req CreateExpenseRequest, exp int) ([]repository.ExpenseSplit, *repository.SplitExplanation, error) {
 	strategy, err := getSplitStrategy(req.SplitMethod)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	splits, err := strategy.CalculateSplits(req) // No longer passing usersMap
+	splits, err := strategy.CalculateSplits(req, exp) // No longer passing usersMap
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return splits, nil
+	if !req.Explain {
+		return applyTaxAndTip(req, splits, exp), nil, nil
+	}
+	preTax := append([]repository.ExpenseSplit(nil), splits...) // applyTaxAndTip updates splits in place
+	splits = applyTaxAndTip(req, splits, exp)
+	return splits, explainSplits(req, preTax, splits, exp), nil
 }
 
 // resolveUserEmailsToIDs gathers all unique emails from the request, fetches users in a batch,
@@ -107,6 +250,14 @@ func (s *expenseService) resolveUserEmailsToIDs(req *CreateExpenseRequest) error
 		for _, ms := range req.ManualSplits {
 			emailsToFetch.Add(ms.UserEmail)
 		}
+	case SplitMethodDays:
+		for _, ds := range req.DaysSplits {
+			emailsToFetch.Add(ds.UserEmail)
+		}
+	case SplitMethodWeighted:
+		for _, ws := range req.WeightedSplits {
+			emailsToFetch.Add(ws.UserEmail)
+		}
 	}
 
 	emailList := emailsToFetch.ToList()
@@ -124,7 +275,7 @@ func (s *expenseService) resolveUserEmailsToIDs(req *CreateExpenseRequest) error
 	}
 
 	// Populate CreatedByID
-	creator, ok := resolvedUsersMap[req.CreatedByEmail]
+	creator, ok := resolvedUsersMap[util.NormalizeEmail(req.CreatedByEmail)]
 	if !ok {
 		return fmt.Errorf("created_by user not found: %s", req.CreatedByEmail)
 	}
@@ -134,7 +285,7 @@ func (s *expenseService) resolveUserEmailsToIDs(req *CreateExpenseRequest) error
 	switch req.SplitMethod {
 	case SplitMethodEqual:
 		for i, es := range req.EqualSplits {
-			user, ok := resolvedUsersMap[es.UserEmail]
+			user, ok := resolvedUsersMap[util.NormalizeEmail(es.UserEmail)]
 			if !ok {
 				return fmt.Errorf("equal split participant not found: %s", es.UserEmail)
 			}
@@ -142,7 +293,7 @@ func (s *expenseService) resolveUserEmailsToIDs(req *CreateExpenseRequest) error
 		}
 	case SplitMethodPercentage:
 		for i, ps := range req.PercentageSplits {
-			user, ok := resolvedUsersMap[ps.UserEmail]
+			user, ok := resolvedUsersMap[util.NormalizeEmail(ps.UserEmail)]
 			if !ok {
 				return fmt.Errorf("percentage split participant not found: %s", ps.UserEmail)
 			}
@@ -150,37 +301,45 @@ func (s *expenseService) resolveUserEmailsToIDs(req *CreateExpenseRequest) error
 		}
 	case SplitMethodManual:
 		for i, ms := range req.ManualSplits {
-			user, ok := resolvedUsersMap[ms.UserEmail]
+			user, ok := resolvedUsersMap[util.NormalizeEmail(ms.UserEmail)]
 			if !ok {
 				return fmt.Errorf("manual split participant not found: %s", ms.UserEmail)
 			}
 			req.ManualSplits[i].UserID = user.ID
 		}
+	case SplitMethodDays:
+		for i, ds := range req.DaysSplits {
+			user, ok := resolvedUsersMap[util.NormalizeEmail(ds.UserEmail)]
+			if !ok {
+				return fmt.Errorf("days split participant not found: %s", ds.UserEmail)
+			}
+			req.DaysSplits[i].UserID = user.ID
+		}
+	case SplitMethodWeighted:
+		for i, ws := range req.WeightedSplits {
+			user, ok := resolvedUsersMap[util.NormalizeEmail(ws.UserEmail)]
+			if !ok {
+				return fmt.Errorf("weighted split participant not found: %s", ws.UserEmail)
+			}
+			req.WeightedSplits[i].UserID = user.ID
+			req.WeightedSplits[i].Weight = user.SplitWeight
+		}
 	}
 
 	return nil
 }
 
-func (s *expenseService) calculateBalanceUpdates(expense *repository.Expense, splits []repository.ExpenseSplit) []repository.BalanceUpdate {
-	balanceUpdates := make([]repository.BalanceUpdate, 0)
-	for _, split := range splits {
-		if expense.CreatedBy != split.UserID {
-			// Update balance for each user involved in the split relative to the CreatedBy user
-			// The net amount represents how much the split.UserID owes the expense.CreatedBy user
-			// A positive net amount means split.UserID owes CreatedBy
-			// A negative net amount means CreatedBy owes split.UserID
-			netAmountOwedToCreator := split.AmountOwed - split.AmountPaid
-
-			if netAmountOwedToCreator != 0 {
-				balanceUpdates = append(balanceUpdates, repository.BalanceUpdate{
-					User1ID: expense.CreatedBy,
-					User2ID: split.UserID,
-					Amount:  netAmountOwedToCreator,
-				})
-			}
-		}
+// calculateBalanceUpdates applies the balance strategy recorded on the expense to its splits. An
+// expense counted in an event's own unit moves no balance.
+func (s *expenseService) calculateBalanceUpdates(expense *repository.Expense, splits []repository.ExpenseSplit) ([]repository.BalanceUpdate, error) {
+	if expense.Currency == util.UnitCurrency {
+		return nil, nil
+	}
+	strategy, err := getBalanceStrategy(BalanceStrategyType(expense.BalanceStrategy))
+	if err != nil {
+		return nil, err
 	}
-	return balanceUpdates
+	return strategy.CalculateBalanceUpdates(expense, splits, s.currencies.Exponent(expense.Currency)), nil
 }
 
 func (s *expenseService) CreateExpense(req CreateExpenseRequest) (*repository.Expense, error) {
@@ -188,17 +347,94 @@ func (s *expenseService) CreateExpense(req CreateExpenseRequest) (*repository.Ex
 		return nil, err
 	}
 
+	if s.quotaService != nil {
+		if err := s.quotaService.CheckExpense(req.CreatedByID); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.RefundOf != "" {
+		if err := s.checkRefund(&req); err != nil {
+			return nil, err
+		}
+	}
+
+	var event *repository.Event
+	if req.EventID != nil {
+		var err error
+		if event, err = s.checkEvent(*req.EventID); err != nil {
+			return nil, err
+		}
+		if event.Unit != "" {
+			if err := checkWholeUnits(&req, event); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if req.Currency == "" {
+		req.Currency = util.DefaultCurrency
+	}
+	exp := s.currencies.Exponent(req.Currency)
+
+	if req.Location != nil {
+		location := *req.Location
+		location.PlaceName = util.SanitizeText(location.PlaceName)
+		req.Location = &location
+	}
+	if req.PublicID == "" {
+		req.PublicID = s.ids.NewID()
+	}
 	expense := &repository.Expense{
-		Description: req.Description,
-		Tag:         req.Tag,
-		TotalAmount: req.TotalAmount,
+		PublicID:    req.PublicID,
+		Description: util.SanitizeText(req.Description),
+		Tag:         util.SanitizeText(req.Tag),
+		TotalAmount: req.GrandTotal(exp),
+		Currency:    req.Currency,
 		CreatedBy:   req.CreatedByID, // Use the resolved ID
+		RefundOf:    req.RefundOfID,
+		Location:    req.Location,
+		EventID:     req.EventID,
+	}
+	// Recorded so undoing the expense reverses exactly the balances it moved
+	if req.BalanceStrategy == "" {
+		req.BalanceStrategy = BalanceStrategySimple
+	}
+	expense.BalanceStrategy = string(req.BalanceStrategy)
+
+	// The payee only labels where the money went; it takes no split, so balances are unaffected
+	if req.PayeePartyID != nil {
+		if s.partyRepo == nil {
+			return nil, fmt.Errorf("%w: %d", repository.ErrPartyNotFound, *req.PayeePartyID)
+		}
+		party, err := s.partyRepo.GetParty(*req.PayeePartyID)
+		if err != nil {
+			return nil, err
+		}
+		expense.PayeeParty = party
+	}
+
+	// Run before splitting, so what the creator's split absorbs is split like the rest
+	adjustment, err := s.validation.reconcileTotals(&req, exp)
+	if err != nil {
+		return nil, err
 	}
+	expense.Adjustment = adjustment
 
-	splits, err := s.calculateExpenseSplits(req) // No longer passing usersMap
+	splits, explanation, err := s.calculateExpenseSplits(req, exp)
 	if err != nil {
 		return nil, err
 	}
+	shares := len(splits)
+	if req.PayerOnly {
+		for _, split := range splits {
+			if split.UserID == req.CreatedByID {
+				return nil, fmt.Errorf("created_by user (%s) cannot be a participant of a payer-only expense", req.CreatedByEmail)
+			}
+		}
+		// The creator's split only records that they paid; owing nothing, they move no balance
+		splits = append(splits, repository.ExpenseSplit{UserID: req.CreatedByID, AmountPaid: expense.TotalAmount})
+	}
 
 	// The total amount paid across all splits should match the TotalAmount of the expense
 	var totalAmountPaidInSplits float64
@@ -206,21 +442,451 @@ func (s *expenseService) CreateExpense(req CreateExpenseRequest) (*repository.Ex
 		totalAmountPaidInSplits += split.AmountPaid
 	}
 
-	if util.RoundToTwoDecimalPlaces(totalAmountPaidInSplits) != util.RoundToTwoDecimalPlaces(req.TotalAmount) {
-		return nil, fmt.Errorf("total amount paid across all splits (%.2f) does not match total expense amount (%.2f)", totalAmountPaidInSplits, req.TotalAmount)
+	if util.RoundToCurrency(totalAmountPaidInSplits, exp) != expense.TotalAmount {
+		return nil, fmt.Errorf("total amount paid across all splits (%.2f) does not match total expense amount (%.2f)", totalAmountPaidInSplits, expense.TotalAmount)
+	}
+
+	if event != nil && event.BaseCurrency != "" && event.BaseCurrency != expense.Currency {
+		if err := s.convertToBaseCurrency(expense, splits, event.BaseCurrency); err != nil {
+			return nil, err
+		}
+	}
+	// A refund goes back in its expense's currency, undoing balances that are already there, and
+	// what an event counts in its own unit never reaches them
+	if req.RefundOf == "" && expense.Currency != util.UnitCurrency {
+		if err := checkBalanceCurrency(expense.Currency); err != nil {
+			return nil, err
+		}
+	}
+
+	// A refund is split like an expense and then reversed: what each participant got back
+	// counts as negative paid and their share of the refund as negative owed
+	if req.RefundOf != "" {
+		expense.RefundOfPublicID = req.RefundOf
+		expense.TotalAmount = -expense.TotalAmount
+		for i := range splits {
+			splits[i].AmountPaid = -splits[i].AmountPaid
+			splits[i].AmountOwed = -splits[i].AmountOwed
+		}
+	}
+
+	var budgetWarnings []repository.BudgetWarning
+	if s.budgetService != nil {
+		if budgetWarnings, err = s.budgetService.CheckExpense(expense, splits); err != nil {
+			return nil, err
+		}
 	}
 
 	// Calculate balance updates
-	balanceUpdates := s.calculateBalanceUpdates(expense, splits)
+	balanceUpdates, err := s.calculateBalanceUpdates(expense, splits)
+	if err != nil {
+		return nil, err
+	}
 
 	createdExpense, err := s.expenseRepo.CreateExpense(expense, splits, balanceUpdates)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create expense in service: %w", err)
 	}
+	createdExpense.BudgetWarnings = budgetWarnings
+	createdExpense.Splits = splits
+	for _, update := range balanceUpdates {
+		createdExpense.BalanceDeltas = append(createdExpense.BalanceDeltas, update.Delta())
+	}
+	if s.undoWindow > 0 {
+		until := createdExpense.CreatedAt.Add(s.undoWindow)
+		createdExpense.UndoUntil = &until
+	}
+	if explanation != nil {
+		explainBalanceDeltas(explanation, req, splits[:shares], createdExpense.BalanceDeltas, s.currencies.Exponent(explanation.Currency))
+		createdExpense.Explanation = explanation
+	}
+	if expense.Tag == "" && s.tagService != nil {
+		// Only a hint for the client to offer, so a failed lookup doesn't fail the expense
+		if suggestion, err := s.tagService.SuggestTag(expense.Description); err != nil {
+			log.Printf("Failed to suggest a tag for expense %d: %v", createdExpense.ID, err)
+		} else if suggestion != nil {
+			createdExpense.SuggestedTag = suggestion.Tag
+		}
+	}
 
 	return createdExpense, nil
 }
 
+// checkEvent makes sure the event exists and still takes expenses, and returns it.
+func (s *expenseService) checkEvent(id int) (*repository.Event, error) {
+	if s.eventRepo == nil {
+		return nil, fmt.Errorf("%w: %d", repository.ErrEventNotFound, id)
+	}
+	event, err := s.eventRepo.GetEvent(id)
+	if err != nil {
+		return nil, err
+	}
+	if event.ArchivedAt != nil {
+		return nil, fmt.Errorf("%w: %s", ErrEventArchived, event.Name)
+	}
+	return event, nil
+}
+
+// checkWholeUnits puts an expense in an event that counts its own unit in util.UnitCurrency, refusing
+// another currency, a fractional amount or tax and tip, which have no meaning for points.
+func checkWholeUnits(req *CreateExpenseRequest, event *repository.Event) error {
+	if req.Currency != "" && req.Currency != util.UnitCurrency {
+		return fmt.Errorf("%w: %s, %s counts %s", ErrUnsupportedCurrency, req.Currency, event.Name, event.Unit)
+	}
+	req.Currency = util.UnitCurrency
+	if req.TaxAmount != 0 || req.TipPercentage != 0 {
+		return fmt.Errorf("%w: %s has no tax or tip", ErrFractionalUnits, event.Unit)
+	}
+
+	amounts := []float64{req.TotalAmount}
+	for _, sp := range req.EqualSplits {
+		amounts = append(amounts, sp.AmountPaid)
+	}
+	for _, sp := range req.PercentageSplits {
+		amounts = append(amounts, sp.AmountPaid)
+	}
+	for _, sp := range req.ManualSplits {
+		amounts = append(amounts, sp.AmountPaid, sp.AmountOwed)
+	}
+	for _, sp := range req.DaysSplits {
+		amounts = append(amounts, sp.AmountPaid)
+	}
+	for _, sp := range req.WeightedSplits {
+		amounts = append(amounts, sp.AmountPaid)
+	}
+	for _, amount := range amounts {
+		if amount != math.Trunc(amount) {
+			return fmt.Errorf("%w: %g is not a whole number of %s", ErrFractionalUnits, amount, event.Unit)
+		}
+	}
+	return nil
+}
+
+// convertToBaseCurrency converts the expense and its splits to currency at today's rate, recording
+// what was entered. The total is converted and rounded once, then shared out in proportion to what
+// each participant paid and owed, so the splits still add up to it exactly.
+func (s *expenseService) convertToBaseCurrency(expense *repository.Expense, splits []repository.ExpenseSplit, currency string) error {
+	if s.rateService == nil {
+		return fmt.Errorf("%w from %s to %s", ErrRateUnavailable, expense.Currency, currency)
+	}
+	rate, err := s.rateService.Rate(expense.Currency, currency)
+	if err != nil {
+		return err
+	}
+
+	fromExp, toExp := s.currencies.Exponent(expense.Currency), s.currencies.Exponent(currency)
+	totalUnits := util.ToMinorUnits(expense.TotalAmount*rate, toExp)
+	paid, owed := make([]int64, len(splits)), make([]int64, len(splits))
+	for i, split := range splits {
+		paid[i] = util.ToMinorUnits(split.AmountPaid, fromExp)
+		owed[i] = util.ToMinorUnits(split.AmountOwed, fromExp)
+	}
+	paid, owed = scaleUnits(paid, totalUnits), scaleUnits(owed, totalUnits)
+	for i := range splits {
+		splits[i].AmountPaid = util.FromMinorUnits(paid[i], toExp)
+		splits[i].AmountOwed = util.FromMinorUnits(owed[i], toExp)
+	}
+
+	expense.Conversion = &repository.CurrencyConversion{OriginalCurrency: expense.Currency, OriginalAmount: expense.TotalAmount, Rate: rate}
+	expense.Currency = currency
+	expense.TotalAmount = util.FromMinorUnits(totalUnits, toExp)
+	return nil
+}
+
+// scaleUnits shares total out in proportion to units. Shares are rounded down and the leftover
+// units go to the first non-zero share, so nobody who had nothing ends up with something.
+func scaleUnits(units []int64, total int64) []int64 {
+	var sum int64
+	for _, u := range units {
+		sum += u
+	}
+	scaled := make([]int64, len(units))
+	if sum == 0 {
+		return scaled
+	}
+
+	var allocated int64
+	first := -1
+	for i, u := range units {
+		scaled[i] = int64(math.Floor(float64(total) * float64(u) / float64(sum)))
+		allocated += scaled[i]
+		if first < 0 && u != 0 {
+			first = i
+		}
+	}
+	scaled[first] += total - allocated
+	return scaled
+}
+
+// checkRefund makes sure the refunded expense exists, takes its currency and keeps the refunds within its total.
+func (s *expenseService) checkRefund(req *CreateExpenseRequest) error {
+	original, err := s.expenseRepo.GetExpenseByPublicID(req.RefundOf)
+	if err != nil {
+		return fmt.Errorf("failed to get refunded expense %s: %w", req.RefundOf, err)
+	}
+	req.RefundOfID = &original.ID
+	if original.RefundOf != nil {
+		return fmt.Errorf("expense %s is itself a refund and cannot be refunded", original.PublicID)
+	}
+
+	if req.Currency == "" {
+		req.Currency = original.Currency
+	}
+	if req.Currency != original.Currency {
+		return fmt.Errorf("refund currency %s does not match expense currency %s", req.Currency, original.Currency)
+	}
+
+	exp := s.currencies.Exponent(req.Currency)
+	if util.RoundToCurrency(req.TotalAmount, exp) != req.TotalAmount || util.RoundToCurrency(req.TaxAmount, exp) != req.TaxAmount {
+		return fmt.Errorf("refund amount has more decimal places than %s allows (%d)", req.Currency, exp)
+	}
+
+	refunded, err := s.expenseRepo.GetRefundedAmount(original.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get refunded amount for expense %d: %w", original.ID, err)
+	}
+	if left, amount := util.RoundToCurrency(original.TotalAmount-refunded, exp), req.GrandTotal(exp); amount > left {
+		return fmt.Errorf("refund of %.*f exceeds the %.*f left to refund on expense %s", exp, amount, exp, left, original.PublicID)
+	}
+
+	return nil
+}
+
+func (s *expenseService) GetExpenseByPublicID(publicID string) (*repository.Expense, error) {
+	return s.expenseRepo.GetExpenseByPublicID(publicID)
+}
+
+func (s *expenseService) DisputeExpense(id int, req DisputeExpenseRequest) (*repository.Expense, error) {
+	users, err := s.userService.GetUsersByEmails([]string{req.UserEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", req.UserEmail)
+	}
+
+	splits, err := s.expenseRepo.GetExpenseSplits(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get splits for expense %d: %w", id, err)
+	}
+
+	participant := false
+	for _, split := range splits {
+		participant = participant || split.UserID == users[0].ID
+	}
+	if !participant {
+		return nil, fmt.Errorf("%w: %s is not a participant of expense %d", ErrNotExpenseParticipant, req.UserEmail, id)
+	}
+
+	expense, err := s.expenseRepo.TransitionExpense(id, repository.ExpenseActive, repository.ExpenseDisputed, util.SanitizeText(req.Reason))
+	if err != nil {
+		return nil, fmt.Errorf("failed to dispute expense %d: %w", id, err)
+	}
+
+	return expense, nil
+}
+
+func (s *expenseService) DismissExpenseDispute(id int, req DismissDisputeRequest) (*repository.Expense, error) {
+	users, err := s.userService.GetUsersByEmails([]string{req.UserEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", req.UserEmail)
+	}
+
+	expense, err := s.expenseRepo.GetExpense(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expense %d: %w", id, err)
+	}
+	if expense.CreatedBy != users[0].ID {
+		return nil, fmt.Errorf("%w: only the creator can dismiss a dispute on expense %d", ErrNotExpenseParticipant, id)
+	}
+
+	expense, err = s.expenseRepo.TransitionExpense(id, repository.ExpenseDisputed, repository.ExpenseActive, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to dismiss dispute on expense %d: %w", id, err)
+	}
+
+	return expense, nil
+}
+
+func (s *expenseService) UndoExpense(id int, req UndoExpenseRequest) error {
+	users, err := s.userService.GetUsersByEmails([]string{req.UserEmail})
+	if err != nil || len(users) == 0 {
+		return fmt.Errorf("user with email %s not found", req.UserEmail)
+	}
+
+	expense, err := s.expenseRepo.GetExpense(id)
+	if err != nil {
+		return fmt.Errorf("failed to get expense %d: %w", id, err)
+	}
+	if expense.CreatedBy != users[0].ID {
+		return fmt.Errorf("%w: only the creator can undo expense %d", ErrNotExpenseParticipant, id)
+	}
+	if s.undoWindow <= 0 || s.now().After(expense.CreatedAt.Add(s.undoWindow)) {
+		return fmt.Errorf("%w: the undo window for expense %d has passed", ErrExpenseNotUndoable, id)
+	}
+
+	// Deleting a refunded expense would leave its refunds pointing at nothing
+	refunded, err := s.expenseRepo.GetRefundedAmount(id)
+	if err != nil {
+		return fmt.Errorf("failed to get refunded amount for expense %d: %w", id, err)
+	}
+	if refunded != 0 {
+		return fmt.Errorf("%w: expense %d has been refunded", ErrExpenseNotUndoable, id)
+	}
+
+	splits, err := s.expenseRepo.GetExpenseSplits(id)
+	if err != nil {
+		return fmt.Errorf("failed to get splits for expense %d: %w", id, err)
+	}
+	// Taking back exactly what creation added leaves the balances as if the expense never existed
+	balanceUpdates, err := s.calculateBalanceUpdates(expense, splits)
+	if err != nil {
+		return fmt.Errorf("failed to recalculate balances for expense %d: %w", id, err)
+	}
+	for i := range balanceUpdates {
+		balanceUpdates[i].Amount = -balanceUpdates[i].Amount
+	}
+
+	if err := s.expenseRepo.DeleteExpense(id, balanceUpdates); err != nil {
+		return fmt.Errorf("failed to undo expense %d: %w", id, err)
+	}
+
+	// The pairs had paid up with the expense counted; now they are off by it
+	if expense.SettledBy != nil && s.settlementRepo != nil {
+		if err := s.resettle(balanceUpdates); err != nil {
+			return fmt.Errorf("failed to propose settlements after undoing expense %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func (s *expenseService) UnlockExpense(id int, req UnlockExpenseRequest) (*repository.Expense, error) {
+	users, err := s.userService.GetUsersByEmails([]string{req.UserEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", req.UserEmail)
+	}
+
+	expense, err := s.expenseRepo.GetExpense(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expense %d: %w", id, err)
+	}
+	if expense.CreatedBy != users[0].ID {
+		return nil, fmt.Errorf("%w: only the creator can unlock expense %d", ErrNotExpenseParticipant, id)
+	}
+
+	expense, err = s.expenseRepo.UnlockExpense(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock expense %d: %w", id, err)
+	}
+	return expense, nil
+}
+
+// resettle proposes, all together, a settlement for whatever each pair in updates owes now. A pair
+// that is even, has a disputed expense, or already has an open settlement for the amount is left
+// alone.
+func (s *expenseService) resettle(updates []repository.BalanceUpdate) error {
+	var settlements []*repository.Settlement
+	for _, u := range updates {
+		balances, err := s.balanceRepo.GetBalancesByUserID(u.User1ID)
+		if err != nil {
+			return err
+		}
+		owed := pairOwed(balances, u.User2ID, u.User1ID)
+		payerID, payeeID := u.User2ID, u.User1ID
+		if owed < 0 {
+			payerID, payeeID, owed = u.User1ID, u.User2ID, -owed
+		}
+		if owed == 0 {
+			continue
+		}
+
+		disputed, err := s.expenseRepo.HasDisputedExpenseBetween(payerID, payeeID)
+		if err != nil {
+			return err
+		}
+		open, err := s.settlementRepo.GetSettlementsByUserID(payerID)
+		if err != nil {
+			return err
+		}
+		if disputed || hasOpenSettlement(open, payerID, payeeID, util.FromMinorUnits(owed, util.DefaultExponent)) {
+			continue
+		}
+		amount := util.FromMinorUnits(owed, util.DefaultExponent)
+		settlements = append(settlements, &repository.Settlement{PayerID: payerID, PayeeID: payeeID, Amount: amount, OutstandingAmount: &amount})
+	}
+
+	if len(settlements) == 0 {
+		return nil
+	}
+	_, err := s.settlementRepo.CreateSettlements(settlements)
+	return err
+}
+
+// runningBalanceExponent is the precision running balances are kept at: the widest minor unit any
+// currency has, as in the amount columns.
+const runningBalanceExponent = 3
+
+// WithRunningBalance sets each expense's RunningBalance: how the user's net position stood once it was
+// added, counting expenses only. Expenses counted in an event's own unit leave it as it was, as they do
+// the balances. Expenses are expected newest first, as the repository returns them.
+func WithRunningBalance(expenses []repository.UserExpenseView) []repository.UserExpenseView {
+	return withRunningBalanceFrom(expenses, 0)
+}
+
+// withRunningBalanceFrom is WithRunningBalance for a page of expenses, starting from the user's
+// share in the expenses older than it.
+func withRunningBalanceFrom(expenses []repository.UserExpenseView, older float64) []repository.UserExpenseView {
+	withBalance := make([]repository.UserExpenseView, len(expenses))
+	running := util.RoundToCurrency(older, runningBalanceExponent)
+	for i := len(expenses) - 1; i >= 0; i-- {
+		if expenses[i].Currency != util.UnitCurrency {
+			running = util.RoundToCurrency(running+expenses[i].Share, runningBalanceExponent)
+		}
+		balance := running
+		withBalance[i] = expenses[i]
+		withBalance[i].RunningBalance = &balance
+	}
+	return withBalance
+}
+
+// ExpenseMonth is one calendar month (UTC) of a user's expense history.
+type ExpenseMonth struct {
+	Month     string                       `json:"month"` // YYYY-MM
+	Subtotals []MonthSubtotal              `json:"subtotals"`
+	Expenses  []repository.UserExpenseView `json:"expenses"`
+}
+
+// MonthSubtotal sums a month's expenses in one currency.
+type MonthSubtotal struct {
+	Currency    string  `json:"currency"`
+	TotalAmount float64 `json:"total_amount"`
+	Share       float64 `json:"share"`
+}
+
+// GroupExpensesByMonth buckets expenses by the month they were made in, keeping their order, and
+// subtotals each bucket per currency, rounded to the minor unit currencies gives it. Expenses are
+// expected sorted by date, as the repository returns them.
+func GroupExpensesByMonth(expenses []repository.UserExpenseView, currencies util.Currencies) []ExpenseMonth {
+	months := []ExpenseMonth{}
+	for _, e := range expenses {
+		month := e.Date.UTC().Format("2006-01")
+		if len(months) == 0 || months[len(months)-1].Month != month {
+			months = append(months, ExpenseMonth{Month: month})
+		}
+		m := &months[len(months)-1]
+		m.Expenses = append(m.Expenses, e)
+
+		i := 0
+		for i < len(m.Subtotals) && m.Subtotals[i].Currency != e.Currency {
+			i++
+		}
+		if i == len(m.Subtotals) {
+			m.Subtotals = append(m.Subtotals, MonthSubtotal{Currency: e.Currency})
+		}
+		exp := currencies.Exponent(e.Currency)
+		m.Subtotals[i].TotalAmount = util.RoundToCurrency(m.Subtotals[i].TotalAmount+e.TotalAmount, exp)
+		m.Subtotals[i].Share = util.RoundToCurrency(m.Subtotals[i].Share+e.Share, exp)
+	}
+	return months
+}
+
 func (s *expenseService) GetExpensesForUser(userEmail string) ([]repository.UserExpenseView, error) {
 	users, err := s.userService.GetUsersByEmails([]string{userEmail})
 	if err != nil || len(users) == 0 {
@@ -236,6 +902,36 @@ func (s *expenseService) GetExpensesForUser(userEmail string) ([]repository.User
 	return expenses, nil
 }
 
+func (s *expenseService) GetExpensePageForUser(userEmail string, limit, offset int, runningBalance bool) ([]repository.UserExpenseView, int, error) {
+	users, err := s.userService.GetUsersByEmails([]string{userEmail})
+	if err != nil || len(users) == 0 {
+		return nil, 0, fmt.Errorf("user with email %s not found", userEmail)
+	}
+
+	page, err := s.expenseRepo.GetExpensePageByUserID(users[0].ID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get expenses for user %s: %w", userEmail, err)
+	}
+	expenses := page.Expenses
+	if runningBalance {
+		expenses = withRunningBalanceFrom(expenses, page.OlderShare)
+	}
+	return expenses, page.Count, nil
+}
+
+func (s *expenseService) GetNearbyExpenses(userEmail string, latitude, longitude, radius float64) ([]repository.NearbyExpense, error) {
+	users, err := s.userService.GetUsersByEmails([]string{userEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", userEmail)
+	}
+
+	expenses, err := s.expenseRepo.GetNearbyExpenses(users[0].ID, latitude, longitude, radius)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nearby expenses for user %s: %w", userEmail, err)
+	}
+	return expenses, nil
+}
+
 func (s *expenseService) GetOutstandingBalancesForUser(userEmail string) ([]UserBalanceView, error) {
 	users, err := s.userService.GetUsersByEmails([]string{userEmail})
 	if err != nil || len(users) == 0 {
@@ -244,25 +940,47 @@ func (s *expenseService) GetOutstandingBalancesForUser(userEmail string) ([]User
 
 	userID := users[0].ID
 
-	balances, err := s.balanceRepo.GetBalancesByUserID(userID)
+	var (
+		balances []repository.Balance
+		paid     map[int]int64
+	)
+	err = fanOut(
+		func() (err error) {
+			if balances, err = s.balanceRepo.GetBalancesByUserID(userID); err != nil {
+				return fmt.Errorf("failed to get balances for user %s: %w", userEmail, err)
+			}
+			return nil
+		},
+		func() (err error) {
+			if paid, err = s.paidTowardsBalances(userID); err != nil {
+				return fmt.Errorf("failed to get settled amounts for user %s: %w", userEmail, err)
+			}
+			return nil
+		},
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get balances for user %s: %w", userEmail, err)
+		return nil, err
 	}
 
 	var userBalances []UserBalanceView
 
-	// Collect all unique user IDs involved in the balances (excluding the current user)
+	// Collect all unique user IDs involved in the balances (excluding the current user),
+	// keeping the order in which they appear so the lookup is deterministic
 	otherUserIDsToFetch := util.NewSet[int]()
+	var otherUserIDs []int
 	for _, b := range balances {
+		otherID := b.User1ID
 		if b.User1ID == userID {
-			otherUserIDsToFetch.Add(b.User2ID)
-		} else {
-			otherUserIDsToFetch.Add(b.User1ID)
+			otherID = b.User2ID
+		}
+		if !otherUserIDsToFetch.IsMember(otherID) {
+			otherUserIDsToFetch.Add(otherID)
+			otherUserIDs = append(otherUserIDs, otherID)
 		}
 	}
 
-	// Fetch all other users in a single batch call
-	otherUsers, err := s.userService.GetUsersByIDs(otherUserIDsToFetch.ToList())
+	// Fetch all other users in as few batch calls as it takes
+	otherUsers, err := getUsersByIDs(s.userService, otherUserIDs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch other users for balances: %w", err)
 	}
@@ -297,7 +1015,8 @@ func (s *expenseService) GetOutstandingBalancesForUser(userEmail string) ([]User
 		userBalances = append(userBalances, UserBalanceView{
 			WithUserEmail: otherUserEmail,
 			WithUserName:  otherUserName,
-			Amount:        util.RoundToTwoDecimalPlaces(balanceAmount),
+			Amount:        util.RoundToDefaultCurrency(balanceAmount),
+			PaidAmount:    util.FromMinorUnits(paid[otherUserID], util.DefaultExponent),
 			LastUpdated:   b.LastUpdated,
 		})
 	}
@@ -305,6 +1024,27 @@ func (s *expenseService) GetOutstandingBalancesForUser(userEmail string) ([]User
 	return userBalances, nil
 }
 
+// paidTowardsBalances returns, by counterparty, what settlements have paid towards each of the
+// user's outstanding balances since the pair was last even, in minor units.
+func (s *expenseService) paidTowardsBalances(userID int) (map[int]int64, error) {
+	if s.ledgerRepo == nil {
+		return nil, nil
+	}
+	entries, err := s.ledgerRepo.GetUnsettledEntries(userID)
+	if err != nil {
+		return nil, err
+	}
+	byCounterparty := make(map[int][]repository.LedgerEntry)
+	for _, e := range entries {
+		byCounterparty[e.CounterpartyID] = append(byCounterparty[e.CounterpartyID], e)
+	}
+	paid := make(map[int]int64, len(byCounterparty))
+	for otherID, pairEntries := range byCounterparty {
+		_, paid[otherID] = repository.UnsettledContributions(pairEntries)
+	}
+	return paid, nil
+}
+
 func (s *expenseService) GetOverallOutstandingBalance(userEmail string) (float64, error) {
 	users, err := s.userService.GetUsersByEmails([]string{userEmail})
 	if err != nil || len(users) == 0 {
@@ -318,5 +1058,46 @@ func (s *expenseService) GetOverallOutstandingBalance(userEmail string) (float64
 		return 0, fmt.Errorf("failed to get overall balance for user %s: %w", userEmail, err)
 	}
 
-	return util.RoundToTwoDecimalPlaces(overallBalance), nil
+	return util.RoundToDefaultCurrency(overallBalance), nil
+}
+
+func (s *expenseService) GetBalanceList(userEmail string, direction repository.DebtDirection, limit, offset int) (*BalanceList, error) {
+	users, err := s.userService.GetUsersByEmails([]string{userEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", userEmail)
+	}
+
+	page, err := s.balanceRepo.GetDirectedBalances(users[0].ID, direction, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s balances for user %s: %w", direction, userEmail, err)
+	}
+
+	ids := make([]int, len(page.Balances))
+	for i, b := range page.Balances {
+		ids[i] = b.CounterpartyID
+	}
+	others, err := s.userService.GetUsersByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch other users for balances: %w", err)
+	}
+	othersMap := make(map[int]*repository.User, len(others))
+	for _, u := range others {
+		othersMap[u.ID] = u
+	}
+
+	list := &BalanceList{Count: page.Count, Total: util.RoundToDefaultCurrency(page.Total), Balances: make([]UserBalanceView, 0, len(page.Balances))}
+	for _, b := range page.Balances {
+		view := UserBalanceView{
+			WithUserEmail: fmt.Sprintf("unknown_user_%d", b.CounterpartyID),
+			WithUserName:  "Unknown",
+			Amount:        util.RoundToDefaultCurrency(b.Amount),
+			LastUpdated:   b.LastUpdated,
+		}
+		if u, ok := othersMap[b.CounterpartyID]; ok {
+			view.WithUserEmail, view.WithUserName = u.Email, u.Name
+		}
+		list.Balances = append(list.Balances, view)
+	}
+
+	return list, nil
 }
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// AnnounceExpenseJobType is the job that tells an expense's participants about it.
+const AnnounceExpenseJobType = "announce_expense"
+
+type announceExpenseJob struct {
+	ExpenseID int `json:"expense_id"`
+}
+
+// AnnounceDisputeJobType is the job that tells the creator and payers of an expense it is disputed.
+const AnnounceDisputeJobType = "announce_dispute"
+
+type announceDisputeJob struct {
+	ExpenseID  int    `json:"expense_id"`
+	DisputedBy string `json:"disputed_by"`
+}
+
+type announcingExpenseService struct {
+	ExpenseService
+	expenseRepo repository.ExpenseRepository
+	userService UserService
+	jobService  JobService
+	notifier    Notifier
+	delay       time.Duration
+}
+
+// NewAnnouncingExpenseService wraps inner so that the other participants of a new expense are notified
+// once delay has passed. The job is queued with the expense, and an expense undone in the meantime is
+// simply not announced, so nobody hears about an expense that was taken back. A dispute is announced
+// straight away to the expense's creator and to whoever paid for it, unless it is dismissed first.
+func NewAnnouncingExpenseService(inner ExpenseService, expenseRepo repository.ExpenseRepository, userService UserService, jobService JobService, notifier Notifier, delay time.Duration) ExpenseService {
+	s := &announcingExpenseService{
+		ExpenseService: inner,
+		expenseRepo:    expenseRepo,
+		userService:    userService,
+		jobService:     jobService,
+		notifier:       notifier,
+		delay:          delay,
+	}
+	jobService.Register(AnnounceExpenseJobType, s.runAnnounceJob)
+	jobService.Register(AnnounceDisputeJobType, s.runAnnounceDisputeJob)
+	return s
+}
+
+func (s *announcingExpenseService) CreateExpense(req CreateExpenseRequest) (*repository.Expense, error) {
+	expense, err := s.ExpenseService.CreateExpense(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// The expense is already stored, so a lost announcement is not worth failing the request over
+	if _, err := s.jobService.EnqueueAt(AnnounceExpenseJobType, announceExpenseJob{ExpenseID: expense.ID}, expense.CreatedAt.Add(s.delay)); err != nil {
+		log.Printf("failed to queue announcement of expense %d: %v", expense.ID, err)
+	}
+	return expense, nil
+}
+
+func (s *announcingExpenseService) runAnnounceJob(ctx context.Context, payload json.RawMessage) error {
+	var job announceExpenseJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("invalid announce job payload: %w", err)
+	}
+
+	expense, err := s.expenseRepo.GetExpense(job.ExpenseID)
+	if err != nil {
+		if errors.Is(err, repository.ErrExpenseNotFound) {
+			return nil // Undone before the window closed
+		}
+		return err
+	}
+	splits, err := s.expenseRepo.GetExpenseSplits(expense.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get splits for expense %d: %w", expense.ID, err)
+	}
+
+	ids := util.NewSet(expense.CreatedBy)
+	for _, split := range splits {
+		ids.Add(split.UserID)
+	}
+	users, err := s.userService.GetUsersByIDs(ids.ToList())
+	if err != nil {
+		return fmt.Errorf("failed to get participants of expense %d: %w", expense.ID, err)
+	}
+	byID := make(map[int]*repository.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+	creator := byID[expense.CreatedBy]
+	if creator == nil {
+		return fmt.Errorf("creator %d of expense %d not found", expense.CreatedBy, expense.ID)
+	}
+
+	for _, split := range splits {
+		user := byID[split.UserID]
+		if split.UserID == expense.CreatedBy || user == nil {
+			continue
+		}
+		err := s.notifier.Notify(ctx, Notification{
+			UserID:  user.ID,
+			Kind:    repository.KindNewExpense,
+			To:      user.Email,
+			Subject: fmt.Sprintf("%s added %q", creator.Name, expense.Description),
+			Body: fmt.Sprintf("Hi %s,\n\n%s added %q for %.2f %s. Your share is %.2f %s.\n",
+				user.Name, creator.Name, expense.Description, expense.TotalAmount, expense.Currency, split.AmountOwed, expense.Currency),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *announcingExpenseService) DisputeExpense(id int, req DisputeExpenseRequest) (*repository.Expense, error) {
+	expense, err := s.ExpenseService.DisputeExpense(id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// The dispute is already recorded, so a lost announcement is not worth failing the request over
+	if _, err := s.jobService.Enqueue(AnnounceDisputeJobType, announceDisputeJob{ExpenseID: expense.ID, DisputedBy: req.UserEmail}); err != nil {
+		log.Printf("failed to queue announcement of the dispute on expense %d: %v", expense.ID, err)
+	}
+	return expense, nil
+}
+
+func (s *announcingExpenseService) runAnnounceDisputeJob(ctx context.Context, payload json.RawMessage) error {
+	var job announceDisputeJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("invalid announce dispute job payload: %w", err)
+	}
+
+	expense, err := s.expenseRepo.GetExpense(job.ExpenseID)
+	if err != nil {
+		if errors.Is(err, repository.ErrExpenseNotFound) {
+			return nil
+		}
+		return err
+	}
+	if expense.Status != repository.ExpenseDisputed {
+		return nil // Dismissed before anyone heard of it
+	}
+	splits, err := s.expenseRepo.GetExpenseSplits(expense.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get splits for expense %d: %w", expense.ID, err)
+	}
+
+	// The creator, and whoever else paid, are the ones the dispute holds up
+	ids := util.NewSet(expense.CreatedBy)
+	for _, split := range splits {
+		if split.AmountPaid > 0 {
+			ids.Add(split.UserID)
+		}
+	}
+	users, err := s.userService.GetUsersByIDs(ids.ToList())
+	if err != nil {
+		return fmt.Errorf("failed to get payers of expense %d: %w", expense.ID, err)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	disputedBy := util.NormalizeEmail(job.DisputedBy)
+	for _, user := range users {
+		if util.NormalizeEmail(user.Email) == disputedBy {
+			continue
+		}
+		err := s.notifier.Notify(ctx, Notification{
+			UserID:  user.ID,
+			Kind:    repository.KindDispute,
+			To:      user.Email,
+			Subject: fmt.Sprintf("%s disputed %q", disputedBy, expense.Description),
+			Body: fmt.Sprintf("Hi %s,\n\n%s disputed %q for %.2f %s: %s\n\nSettlements with its participants are on hold until the creator dismisses the dispute.\n",
+				user.Name, disputedBy, expense.Description, expense.TotalAmount, expense.Currency, expense.DisputeReason),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockNotifier struct {
+	mock.Mock
+}
+
+func (m *MockNotifier) Notify(ctx context.Context, n Notification) error {
+	return m.Called(n).Error(0)
+}
+
+// createdExpenseService answers CreateExpense with a fixed expense.
+type createdExpenseService struct {
+	ExpenseService
+	expense *repository.Expense
+}
+
+func (s createdExpenseService) CreateExpense(CreateExpenseRequest) (*repository.Expense, error) {
+	return s.expense, nil
+}
+
+func (s createdExpenseService) DisputeExpense(int, DisputeExpenseRequest) (*repository.Expense, error) {
+	return s.expense, nil
+}
+
+func TestAnnouncingExpenseService(t *testing.T) {
+	expenseRepo := new(repomock.ExpenseRepository)
+	userService := new(MockUserService)
+	jobRepo := new(repomock.JobRepository)
+	notifier := new(MockNotifier)
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	expense := &repository.Expense{ID: 7, Description: "Dinner", TotalAmount: 30, Currency: "USD", CreatedBy: alice.ID, CreatedAt: createdAt}
+	payload, _ := json.Marshal(announceExpenseJob{ExpenseID: 7})
+	s := NewAnnouncingExpenseService(createdExpenseService{expense: expense}, expenseRepo, userService, NewJobService(jobRepo, JobOptions{MaxAttempts: 3}), notifier, time.Minute).(*announcingExpenseService)
+
+	// Test case 1: Creating an expense queues its announcement for when the undo window closes
+	{
+		jobRepo.On("CreateJob", mock.MatchedBy(func(j *repository.Job) bool {
+			return j.Type == AnnounceExpenseJobType && j.RunAt.Equal(createdAt.Add(time.Minute)) && string(j.Payload) == string(payload)
+		})).Return(&repository.Job{ID: 1}, nil).Once()
+
+		created, err := s.CreateExpense(CreateExpenseRequest{Description: "Dinner", CreatedByEmail: alice.Email})
+		assert.NoError(t, err)
+		assert.Equal(t, expense, created)
+		jobRepo.AssertExpectations(t)
+	}
+
+	// Test case 2: The other participants are told their share
+	{
+		expenseRepo.On("GetExpense", 7).Return(expense, nil).Once()
+		expenseRepo.On("GetExpenseSplits", 7).Return([]repository.ExpenseSplit{
+			{ExpenseID: 7, UserID: alice.ID, AmountPaid: 30, AmountOwed: 15},
+			{ExpenseID: 7, UserID: bob.ID, AmountOwed: 15},
+		}, nil).Once()
+		userService.On("GetUsersByIDs", mock.MatchedBy(func(ids []int) bool { return assert.ElementsMatch(t, []int{alice.ID, bob.ID}, ids) })).Return([]*repository.User{alice, bob}, nil).Once()
+		notifier.On("Notify", mock.MatchedBy(func(n Notification) bool {
+			return n.To == bob.Email && n.Subject == `Alice added "Dinner"`
+		})).Return(nil).Once()
+
+		assert.NoError(t, s.runAnnounceJob(context.Background(), payload))
+		notifier.AssertExpectations(t)
+	}
+
+	// Test case 3: An expense undone in the meantime is not announced
+	{
+		expenseRepo.On("GetExpense", 7).Return((*repository.Expense)(nil), repository.ErrExpenseNotFound).Once()
+
+		assert.NoError(t, s.runAnnounceJob(context.Background(), payload))
+		notifier.AssertNumberOfCalls(t, "Notify", 1)
+	}
+}
+
+func TestAnnouncingExpenseService_DisputeExpense(t *testing.T) {
+	expenseRepo := new(repomock.ExpenseRepository)
+	userService := new(MockUserService)
+	jobRepo := new(repomock.JobRepository)
+	notifier := new(MockNotifier)
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+	carol := &repository.User{ID: 3, Name: "Carol", Email: "carol@example.com"}
+	expense := &repository.Expense{ID: 7, Description: "Dinner", TotalAmount: 30, Currency: "USD", CreatedBy: alice.ID, Status: repository.ExpenseDisputed, DisputeReason: "I only had a drink"}
+	splits := []repository.ExpenseSplit{
+		{ExpenseID: 7, UserID: alice.ID, AmountOwed: 10},
+		{ExpenseID: 7, UserID: bob.ID, AmountPaid: 30, AmountOwed: 10},
+		{ExpenseID: 7, UserID: carol.ID, AmountOwed: 10},
+	}
+	payload, _ := json.Marshal(announceDisputeJob{ExpenseID: 7, DisputedBy: carol.Email})
+	s := NewAnnouncingExpenseService(createdExpenseService{expense: expense}, expenseRepo, userService, NewJobService(jobRepo, JobOptions{MaxAttempts: 3}), notifier, time.Minute).(*announcingExpenseService)
+
+	// Test case 1: Disputing an expense queues its announcement
+	{
+		jobRepo.On("CreateJob", mock.MatchedBy(func(j *repository.Job) bool {
+			return j.Type == AnnounceDisputeJobType && string(j.Payload) == string(payload)
+		})).Return(&repository.Job{ID: 1}, nil).Once()
+
+		disputed, err := s.DisputeExpense(7, DisputeExpenseRequest{UserEmail: carol.Email, Reason: "I only had a drink"})
+		assert.NoError(t, err)
+		assert.Equal(t, expense, disputed)
+		jobRepo.AssertExpectations(t)
+	}
+
+	// Test case 2: The creator and the payer hear about it, but not the participant who disputed it
+	{
+		expenseRepo.On("GetExpense", 7).Return(expense, nil).Once()
+		expenseRepo.On("GetExpenseSplits", 7).Return(splits, nil).Once()
+		userService.On("GetUsersByIDs", mock.MatchedBy(func(ids []int) bool { return assert.ElementsMatch(t, []int{alice.ID, bob.ID}, ids) })).Return([]*repository.User{bob, alice}, nil).Once()
+		for _, u := range []*repository.User{alice, bob} {
+			to := u.Email
+			notifier.On("Notify", mock.MatchedBy(func(n Notification) bool {
+				return n.To == to && n.Kind == repository.KindDispute && n.Subject == `carol@example.com disputed "Dinner"`
+			})).Return(nil).Once()
+		}
+
+		assert.NoError(t, s.runAnnounceDisputeJob(context.Background(), payload))
+		notifier.AssertExpectations(t)
+	}
+
+	// Test case 3: A dispute dismissed in the meantime is not announced
+	{
+		expenseRepo.On("GetExpense", 7).Return(&repository.Expense{ID: 7, CreatedBy: alice.ID, Status: repository.ExpenseActive}, nil).Once()
+
+		assert.NoError(t, s.runAnnounceDisputeJob(context.Background(), payload))
+		notifier.AssertNumberOfCalls(t, "Notify", 2)
+	}
+}
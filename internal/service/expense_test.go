@@ -1,52 +1,56 @@
 package service
 
 import (
-	"database/sql"
 	"errors"
+	"math"
+	"math/rand"
 	"testing"
 	"time"
 
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
 	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/repository/memory"
 	"github.com/aadithya-md/split-expense/internal/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
-type MockExpenseRepository struct {
-	mock.Mock
-}
-
-func (m *MockExpenseRepository) CreateExpense(expense *repository.Expense, splits []repository.ExpenseSplit, balanceUpdates []repository.BalanceUpdate) (*repository.Expense, error) {
-	args := m.Called(expense, splits, balanceUpdates)
-	return args.Get(0).(*repository.Expense), args.Error(1)
-}
-
-func (m *MockExpenseRepository) GetExpense(id int) (*repository.Expense, error) {
-	args := m.Called(id)
-	return args.Get(0).(*repository.Expense), args.Error(1)
-}
-
-func (m *MockExpenseRepository) GetExpensesByUserID(userID int) ([]repository.UserExpenseView, error) {
-	args := m.Called(userID)
-	return args.Get(0).([]repository.UserExpenseView), args.Error(1)
-}
+// seededCurrencies are the currencies the migrations seed, so amounts round as they do on a server.
+var seededCurrencies = func() util.Currencies {
+	exponents, err := memory.NewStore(memory.Options{}).Currencies.GetCurrencyExponents()
+	if err != nil {
+		panic(err)
+	}
+	return util.NewCurrencies(exponents)
+}()
 
-// This mock should be defined in a separate file if used by multiple tests.
-// For now, it's here for simplicity.
+// MockUserService stays hand-written: internal/mocks/servicemock imports this package, so the
+// package's own tests can't import it back.
 type MockUserService struct {
 	mock.Mock
 }
 
-func (m *MockUserService) CreateUser(name, email string) (*repository.User, error) {
-	args := m.Called(name, email)
+func (m *MockUserService) CreateUser(name, email, publicID string) (*repository.User, error) {
+	args := m.Called(name, email, publicID)
 	return args.Get(0).(*repository.User), args.Error(1)
 }
 
+func (m *MockUserService) GetOrCreateUser(name, email, publicID string) (*repository.User, bool, error) {
+	args := m.Called(name, email, publicID)
+	return args.Get(0).(*repository.User), args.Bool(1), args.Error(2)
+}
+
 func (m *MockUserService) GetUser(id int) (*repository.User, error) {
 	args := m.Called(id)
 	return args.Get(0).(*repository.User), args.Error(1)
 }
 
+func (m *MockUserService) GetUserByPublicID(publicID string) (*repository.User, error) {
+	args := m.Called(publicID)
+	return args.Get(0).(*repository.User), args.Error(1)
+}
+
 func (m *MockUserService) GetUsersByEmails(emails []string) ([]*repository.User, error) {
 	args := m.Called(emails)
 	return args.Get(0).([]*repository.User), args.Error(1)
@@ -57,30 +61,21 @@ func (m *MockUserService) GetUsersByIDs(ids []int) ([]*repository.User, error) {
 	return args.Get(0).([]*repository.User), args.Error(1)
 }
 
-type MockBalanceRepository struct {
-	mock.Mock
-}
-
-func (m *MockBalanceRepository) UpdateBalance(tx *sql.Tx, user1ID, user2ID int, amount float64) error {
-	args := m.Called(tx, user1ID, user2ID, amount)
-	return args.Error(0)
+func (m *MockUserService) GetLastModified(email string) (time.Time, error) {
+	args := m.Called(email)
+	return args.Get(0).(time.Time), args.Error(1)
 }
 
-func (m *MockBalanceRepository) GetBalancesByUserID(userID int) ([]repository.Balance, error) {
-	args := m.Called(userID)
-	return args.Get(0).([]repository.Balance), args.Error(1)
-}
-
-func (m *MockBalanceRepository) GetOverallBalanceByUserID(userID int) (float64, error) {
-	args := m.Called(userID)
-	return args.Get(0).(float64), args.Error(1)
+func (m *MockUserService) SetSplitWeight(id int, weight float64) (*repository.User, error) {
+	args := m.Called(id, weight)
+	return args.Get(0).(*repository.User), args.Error(1)
 }
 
 func TestExpenseService_CreateExpense(t *testing.T) {
-	expenseRepo := new(MockExpenseRepository)
+	expenseRepo := new(repomock.ExpenseRepository)
 	userService := new(MockUserService)
-	balanceRepo := new(MockBalanceRepository)
-	expenseService := NewExpenseService(expenseRepo, userService, balanceRepo)
+	balanceRepo := new(repomock.BalanceRepository)
+	expenseService := NewExpenseService(expenseRepo, userService, balanceRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, seededCurrencies, 0, ValidationPolicy{})
 
 	// Setup common users for all tests
 	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
@@ -97,24 +92,25 @@ func TestExpenseService_CreateExpense(t *testing.T) {
 		splits := make([]repository.ExpenseSplit, 0)
 		switch splitMethod {
 		case SplitMethodEqual:
-			amountPerUser := util.RoundToTwoDecimalPlaces(totalAmount / float64(len(req.EqualSplits)))
+			totalCents := util.ToMinorUnits(totalAmount, 2)
+			centsPerUser := totalCents / int64(len(req.EqualSplits))
 			for i, es := range req.EqualSplits {
-				owed := amountPerUser
+				owed := centsPerUser
 				if i == 0 {
-					owed = util.RoundToTwoDecimalPlaces(totalAmount - (amountPerUser * float64(len(req.EqualSplits)-1)))
+					owed = totalCents - centsPerUser*int64(len(req.EqualSplits)-1)
 				}
-				splits = append(splits, repository.ExpenseSplit{UserID: participants[es.UserEmail].ID, AmountOwed: owed, AmountPaid: util.RoundToTwoDecimalPlaces(es.AmountPaid)})
+				splits = append(splits, repository.ExpenseSplit{UserID: participants[es.UserEmail].ID, AmountOwed: util.FromMinorUnits(owed, 2), AmountPaid: util.RoundToTwoDecimalPlaces(es.AmountPaid)})
 			}
 		case SplitMethodPercentage:
-			var currentTotalOwed float64
+			totalCents := util.ToMinorUnits(totalAmount, 2)
+			var allocated int64
 			for _, ps := range req.PercentageSplits {
-				owed := util.RoundToTwoDecimalPlaces(totalAmount * (ps.Percentage / 100))
-				splits = append(splits, repository.ExpenseSplit{UserID: participants[ps.UserEmail].ID, AmountOwed: owed, AmountPaid: util.RoundToTwoDecimalPlaces(ps.AmountPaid)})
-				currentTotalOwed += owed
+				owed := int64(math.Floor(float64(totalCents)*ps.Percentage/100 + 1e-9))
+				splits = append(splits, repository.ExpenseSplit{UserID: participants[ps.UserEmail].ID, AmountOwed: util.FromMinorUnits(owed, 2), AmountPaid: util.RoundToTwoDecimalPlaces(ps.AmountPaid)})
+				allocated += owed
 			}
-			diff := util.RoundToTwoDecimalPlaces(totalAmount - currentTotalOwed)
-			if diff != 0 && len(splits) > 0 {
-				splits[0].AmountOwed = util.RoundToTwoDecimalPlaces(splits[0].AmountOwed + diff)
+			if diff := totalCents - allocated; diff != 0 && len(splits) > 0 {
+				splits[0].AmountOwed = util.FromMinorUnits(util.ToMinorUnits(splits[0].AmountOwed, 2)+diff, 2)
 			}
 		case SplitMethodManual:
 			for _, ms := range req.ManualSplits {
@@ -291,19 +287,237 @@ func TestExpenseService_CreateExpense(t *testing.T) {
 		userService.On("GetUsersByEmails", mock.AnythingOfType("[]string")).Return([]*repository.User{alice, bob}, nil).Once()
 
 		createdExpense, err := expenseService.CreateExpense(req)
-		assert.NotNil(t, err)
-		assert.Contains(t, err.Error(), "manual split amounts (90.00) must sum up to total amount (100.00)")
+		assert.ErrorIs(t, err, ErrAmountMismatch)
+		assert.Contains(t, err.Error(), "total amount owed across all splits (90.00) does not match total expense amount (100.00)")
 		assert.Nil(t, createdExpense)
 		expenseRepo.AssertNotCalled(t, "CreateExpense")
 		userService.AssertExpectations(t)
 	}
+
+	// Test case 8: Payer-only, the creator pays for the others and owes nothing
+	{
+		req := CreateExpenseRequest{
+			Description:    "Museum tickets",
+			TotalAmount:    90.00,
+			CreatedByEmail: "alice@example.com",
+			SplitMethod:    SplitMethodEqual,
+			EqualSplits:    []EqualSplitRequest{{UserEmail: "bob@example.com"}, {UserEmail: "charlie@example.com"}},
+			PayerOnly:      true,
+		}
+		userService.On("GetUsersByEmails", mock.AnythingOfType("[]string")).Return([]*repository.User{alice, bob, charlie}, nil).Once()
+
+		expectedSplits := []repository.ExpenseSplit{
+			{UserID: bob.ID, AmountOwed: 45},
+			{UserID: charlie.ID, AmountOwed: 45},
+			{UserID: alice.ID, AmountPaid: 90},
+		}
+		expectedUpdates := []repository.BalanceUpdate{
+			{User1ID: alice.ID, User2ID: bob.ID, Amount: 45},
+			{User1ID: alice.ID, User2ID: charlie.ID, Amount: 45},
+		}
+		expenseRepo.On("CreateExpense", mock.AnythingOfType("*repository.Expense"), expectedSplits, expectedUpdates).Return(&repository.Expense{ID: 4}, nil).Once()
+
+		_, err := expenseService.CreateExpense(req)
+		assert.NoError(t, err)
+		expenseRepo.AssertExpectations(t)
+	}
+
+	// Test case 9: Payer-only, the creator can't also be a participant
+	{
+		req := CreateExpenseRequest{
+			Description:    "Museum tickets",
+			TotalAmount:    90.00,
+			CreatedByEmail: "alice@example.com",
+			SplitMethod:    SplitMethodEqual,
+			EqualSplits:    []EqualSplitRequest{{UserEmail: "alice@example.com", AmountPaid: 90}, {UserEmail: "bob@example.com"}},
+			PayerOnly:      true,
+		}
+		userService.On("GetUsersByEmails", mock.AnythingOfType("[]string")).Return([]*repository.User{alice, bob}, nil).Once()
+
+		_, err := expenseService.CreateExpense(req)
+		assert.ErrorContains(t, err, "cannot be a participant of a payer-only expense")
+	}
+
+	// Test case 10: The chosen balance strategy moves the balances and is recorded on the expense
+	{
+		req := CreateExpenseRequest{
+			Description:     "Cabin",
+			TotalAmount:     90.00,
+			CreatedByEmail:  "alice@example.com",
+			SplitMethod:     SplitMethodEqual,
+			EqualSplits:     []EqualSplitRequest{{UserEmail: "alice@example.com", AmountPaid: 30}, {UserEmail: "bob@example.com", AmountPaid: 60}, {UserEmail: "charlie@example.com"}},
+			BalanceStrategy: BalanceStrategyHighestBalance,
+		}
+		userService.On("GetUsersByEmails", mock.AnythingOfType("[]string")).Return([]*repository.User{alice, bob, charlie}, nil).Once()
+
+		recorded := mock.MatchedBy(func(e *repository.Expense) bool { return e.BalanceStrategy == "highest-balance" })
+		expectedUpdates := []repository.BalanceUpdate{{User1ID: bob.ID, User2ID: charlie.ID, Amount: 30}}
+		expenseRepo.On("CreateExpense", recorded, mock.Anything, expectedUpdates).Return(&repository.Expense{ID: 5}, nil).Once()
+
+		_, err := expenseService.CreateExpense(req)
+		assert.NoError(t, err)
+		expenseRepo.AssertExpectations(t)
+	}
+
+	// Test case 11: An unknown balance strategy is refused before anything is stored
+	{
+		req := CreateExpenseRequest{
+			Description:     "Cabin",
+			TotalAmount:     90.00,
+			CreatedByEmail:  "alice@example.com",
+			SplitMethod:     SplitMethodEqual,
+			EqualSplits:     []EqualSplitRequest{{UserEmail: "alice@example.com", AmountPaid: 90}, {UserEmail: "bob@example.com"}},
+			BalanceStrategy: "round-robin",
+		}
+		userService.On("GetUsersByEmails", mock.AnythingOfType("[]string")).Return([]*repository.User{alice, bob}, nil).Once()
+
+		_, err := expenseService.CreateExpense(req)
+		assert.EqualError(t, err, "invalid balance strategy: round-robin")
+	}
+}
+
+func TestExpenseService_CreateExpense_BaseCurrency(t *testing.T) {
+	expenseRepo := new(repomock.ExpenseRepository)
+	eventRepo := new(repomock.EventRepository)
+	userService := new(MockUserService)
+	rates := NewStaticRateService(map[string]float64{"usd": 1, "inr": 1.1})
+	expenseService := NewExpenseService(expenseRepo, userService, new(repomock.BalanceRepository), nil, nil, nil, eventRepo, nil, nil, rates, nil, nil, seededCurrencies, 0, ValidationPolicy{})
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+	charlie := &repository.User{ID: 3, Name: "Charlie", Email: "charlie@example.com"}
+	eventID := 4
+	eventRepo.On("GetEvent", eventID).Return(&repository.Event{ID: eventID, Name: "Lisbon", BaseCurrency: "INR"}, nil)
+	userService.On("GetUsersByEmails", mock.AnythingOfType("[]string")).Return([]*repository.User{alice, bob, charlie}, nil)
+	dinner := func(currency string) CreateExpenseRequest {
+		return CreateExpenseRequest{
+			Description:    "Dinner",
+			TotalAmount:    100,
+			Currency:       currency,
+			CreatedByEmail: alice.Email,
+			SplitMethod:    SplitMethodEqual,
+			EqualSplits:    []EqualSplitRequest{{UserEmail: alice.Email, AmountPaid: 100}, {UserEmail: bob.Email}, {UserEmail: charlie.Email}},
+			EventID:        &eventID,
+		}
+	}
+
+	// Test case 1: Dollars are converted to the event's rupees, shares and all, keeping what was entered
+	{
+		var stored *repository.Expense
+		expenseRepo.On("CreateExpense", mock.Anything, []repository.ExpenseSplit{
+			{UserID: alice.ID, AmountPaid: 90.91, AmountOwed: 30.31},
+			{UserID: bob.ID, AmountOwed: 30.30},
+			{UserID: charlie.ID, AmountOwed: 30.30},
+		}, []repository.BalanceUpdate{
+			{User1ID: alice.ID, User2ID: bob.ID, Amount: 30.30},
+			{User1ID: alice.ID, User2ID: charlie.ID, Amount: 30.30},
+		}).Run(func(args mock.Arguments) {
+			stored = args.Get(0).(*repository.Expense)
+		}).Return(&repository.Expense{ID: 1}, nil).Once()
+
+		_, err := expenseService.CreateExpense(dinner("USD"))
+		require.NoError(t, err)
+		assert.Equal(t, "INR", stored.Currency)
+		assert.Equal(t, 90.91, stored.TotalAmount)
+		assert.Equal(t, &repository.CurrencyConversion{OriginalCurrency: "USD", OriginalAmount: 100, Rate: 0.90909091}, stored.Conversion)
+	}
+
+	// Test case 2: Already in the base currency, nothing is converted
+	{
+		expenseRepo.On("CreateExpense", mock.MatchedBy(func(e *repository.Expense) bool {
+			return e.Currency == "INR" && e.TotalAmount == 100 && e.Conversion == nil
+		}), mock.Anything, mock.Anything).Return(&repository.Expense{ID: 2}, nil).Once()
+
+		_, err := expenseService.CreateExpense(dinner("INR"))
+		require.NoError(t, err)
+	}
+
+	// Test case 3: A currency without a rate is refused
+	{
+		_, err := expenseService.CreateExpense(dinner("JPY"))
+		assert.ErrorIs(t, err, ErrRateUnavailable)
+	}
+
+	// Test case 4: Outside an event nothing converts it, and balances are only kept in rupees
+	{
+		req := dinner("USD")
+		req.EventID = nil
+		_, err := expenseService.CreateExpense(req)
+		assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+	}
+
+	expenseRepo.AssertExpectations(t)
+}
+
+func TestExpenseService_CreateExpense_EventUnit(t *testing.T) {
+	expenseRepo := new(repomock.ExpenseRepository)
+	eventRepo := new(repomock.EventRepository)
+	userService := new(MockUserService)
+	expenseService := NewExpenseService(expenseRepo, userService, new(repomock.BalanceRepository), nil, nil, nil, eventRepo, nil, nil, nil, nil, nil, seededCurrencies, 0, ValidationPolicy{})
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+	charlie := &repository.User{ID: 3, Name: "Charlie", Email: "charlie@example.com"}
+	eventID := 5
+	eventRepo.On("GetEvent", eventID).Return(&repository.Event{ID: eventID, Name: "Flat", Unit: "chore points"}, nil)
+	userService.On("GetUsersByEmails", mock.AnythingOfType("[]string")).Return([]*repository.User{alice, bob, charlie}, nil)
+	chores := func(points float64) CreateExpenseRequest {
+		return CreateExpenseRequest{
+			Description:    "Bins",
+			TotalAmount:    points,
+			CreatedByEmail: alice.Email,
+			SplitMethod:    SplitMethodEqual,
+			EqualSplits:    []EqualSplitRequest{{UserEmail: alice.Email, AmountPaid: points}, {UserEmail: bob.Email}, {UserEmail: charlie.Email}},
+			EventID:        &eventID,
+		}
+	}
+
+	// Test case 1: Points are split in whole points and move no balance
+	{
+		var stored *repository.Expense
+		expenseRepo.On("CreateExpense", mock.Anything, []repository.ExpenseSplit{
+			{UserID: alice.ID, AmountPaid: 10, AmountOwed: 4},
+			{UserID: bob.ID, AmountOwed: 3},
+			{UserID: charlie.ID, AmountOwed: 3},
+		}, []repository.BalanceUpdate(nil)).Run(func(args mock.Arguments) {
+			stored = args.Get(0).(*repository.Expense)
+		}).Return(&repository.Expense{ID: 1}, nil).Once()
+
+		_, err := expenseService.CreateExpense(chores(10))
+		require.NoError(t, err)
+		assert.Equal(t, util.UnitCurrency, stored.Currency)
+	}
+
+	// Test case 2: Half a point is refused rather than rounded
+	{
+		_, err := expenseService.CreateExpense(chores(2.5))
+		assert.ErrorIs(t, err, ErrFractionalUnits)
+	}
+
+	// Test case 3: So is money
+	{
+		req := chores(10)
+		req.Currency = "INR"
+		_, err := expenseService.CreateExpense(req)
+		assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+	}
+
+	expenseRepo.AssertExpectations(t)
+}
+
+func TestScaleUnits(t *testing.T) {
+	// Test case 1: Leftover units go to the first non-zero share
+	assert.Equal(t, []int64{0, 3031, 3030, 3030}, scaleUnits([]int64{0, 3334, 3333, 3333}, 9091))
+
+	// Test case 2: Nothing to scale
+	assert.Equal(t, []int64{0, 0}, scaleUnits([]int64{0, 0}, 500))
 }
 
 func TestExpenseService_GetExpensesForUser(t *testing.T) {
-	expenseRepo := new(MockExpenseRepository)
+	expenseRepo := new(repomock.ExpenseRepository)
 	userService := new(MockUserService)
-	balanceRepo := new(MockBalanceRepository)
-	expenseService := NewExpenseService(expenseRepo, userService, balanceRepo)
+	balanceRepo := new(repomock.BalanceRepository)
+	expenseService := NewExpenseService(expenseRepo, userService, balanceRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, seededCurrencies, 0, ValidationPolicy{})
 
 	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
 
@@ -326,11 +540,332 @@ func TestExpenseService_GetExpensesForUser(t *testing.T) {
 	}
 }
 
+func TestExpenseService_GetExpensePageForUser(t *testing.T) {
+	expenseRepo := new(repomock.ExpenseRepository)
+	userService := new(MockUserService)
+	expenseService := NewExpenseService(expenseRepo, userService, new(repomock.BalanceRepository), nil, nil, nil, nil, nil, nil, nil, nil, nil, seededCurrencies, 0, ValidationPolicy{})
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil)
+	page := &repository.UserExpensePage{
+		Expenses:   []repository.UserExpenseView{{ExpenseID: 3, Share: 0.2}, {ExpenseID: 2, Share: -30}},
+		Count:      3,
+		OlderShare: 0.1,
+	}
+	expenseRepo.On("GetExpensePageByUserID", alice.ID, 2, 0).Return(page, nil)
+
+	// Test case 1: The page and the count of the whole list
+	expenses, total, err := expenseService.GetExpensePageForUser(alice.Email, 2, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, page.Expenses, expenses)
+
+	// Test case 2: Running balances carry on from the expenses older than the page
+	expenses, _, err = expenseService.GetExpensePageForUser(alice.Email, 2, 0, true)
+	require.NoError(t, err)
+	if assert.Len(t, expenses, 2) {
+		assert.Equal(t, -29.7, *expenses[0].RunningBalance)
+		assert.Equal(t, -29.9, *expenses[1].RunningBalance)
+	}
+}
+
+func TestWithRunningBalance(t *testing.T) {
+	expenses := []repository.UserExpenseView{
+		{ExpenseID: 3, Share: 0.2},
+		{ExpenseID: 2, Share: -30},
+		{ExpenseID: 1, Share: 0.1},
+	}
+
+	withBalance := WithRunningBalance(expenses)
+
+	var balances []float64
+	for _, e := range withBalance {
+		balances = append(balances, *e.RunningBalance)
+	}
+	assert.Equal(t, []float64{-29.7, -29.9, 0.1}, balances)
+	assert.Nil(t, expenses[0].RunningBalance, "the input is left alone")
+}
+
+func TestGroupExpensesByMonth(t *testing.T) {
+	// Test case 1: Months keep the input order and subtotal per currency
+	{
+		expenses := []repository.UserExpenseView{
+			{ExpenseID: 4, Date: time.Date(2026, 5, 31, 23, 0, 0, 0, time.UTC), TotalAmount: 0.3, Currency: "USD", Share: 0.1},
+			{ExpenseID: 3, Date: time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC), TotalAmount: 0.2, Currency: "USD", Share: 0.2},
+			{ExpenseID: 2, Date: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), TotalAmount: 900, Currency: "JPY", Share: -300},
+			{ExpenseID: 1, Date: time.Date(2026, 4, 30, 0, 0, 0, 0, time.UTC), TotalAmount: 40, Currency: "INR", Share: 20},
+		}
+
+		months := GroupExpensesByMonth(expenses, seededCurrencies)
+		assert.Equal(t, []ExpenseMonth{
+			{
+				Month: "2026-05",
+				Subtotals: []MonthSubtotal{
+					{Currency: "USD", TotalAmount: 0.5, Share: 0.3},
+					{Currency: "JPY", TotalAmount: 900, Share: -300},
+				},
+				Expenses: expenses[:3],
+			},
+			{
+				Month:     "2026-04",
+				Subtotals: []MonthSubtotal{{Currency: "INR", TotalAmount: 40, Share: 20}},
+				Expenses:  expenses[3:],
+			},
+		}, months)
+	}
+
+	// Test case 2: No expenses encode as an empty list
+	{
+		assert.Equal(t, []ExpenseMonth{}, GroupExpensesByMonth(nil, seededCurrencies))
+	}
+}
+
+func TestExpenseService_DisputeExpense(t *testing.T) {
+	expenseRepo := new(repomock.ExpenseRepository)
+	userService := new(MockUserService)
+	expenseService := NewExpenseService(expenseRepo, userService, new(repomock.BalanceRepository), nil, nil, nil, nil, nil, nil, nil, nil, nil, seededCurrencies, 0, ValidationPolicy{})
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+	charlie := &repository.User{ID: 3, Name: "Charlie", Email: "charlie@example.com"}
+	splits := []repository.ExpenseSplit{{ExpenseID: 7, UserID: alice.ID}, {ExpenseID: 7, UserID: bob.ID}}
+
+	// Test case 1: A participant disputes the expense
+	{
+		disputed := &repository.Expense{ID: 7, CreatedBy: alice.ID, Status: repository.ExpenseDisputed, DisputeReason: "I wasn't there"}
+		userService.On("GetUsersByEmails", []string{bob.Email}).Return([]*repository.User{bob}, nil).Once()
+		expenseRepo.On("GetExpenseSplits", 7).Return(splits, nil).Once()
+		expenseRepo.On("TransitionExpense", 7, repository.ExpenseActive, repository.ExpenseDisputed, "I wasn't there").Return(disputed, nil).Once()
+
+		expense, err := expenseService.DisputeExpense(7, DisputeExpenseRequest{UserEmail: bob.Email, Reason: "I wasn't there"})
+		assert.Nil(t, err)
+		assert.Equal(t, disputed, expense)
+		expenseRepo.AssertExpectations(t)
+	}
+
+	// Test case 2: Outsiders can't dispute it
+	{
+		userService.On("GetUsersByEmails", []string{charlie.Email}).Return([]*repository.User{charlie}, nil).Once()
+		expenseRepo.On("GetExpenseSplits", 7).Return(splits, nil).Once()
+
+		expense, err := expenseService.DisputeExpense(7, DisputeExpenseRequest{UserEmail: charlie.Email, Reason: "No"})
+		assert.Nil(t, expense)
+		assert.ErrorIs(t, err, ErrNotExpenseParticipant)
+		expenseRepo.AssertNumberOfCalls(t, "TransitionExpense", 1)
+	}
+
+	// Test case 3: Only the creator can dismiss the dispute
+	{
+		disputed := &repository.Expense{ID: 7, CreatedBy: alice.ID, Status: repository.ExpenseDisputed}
+		userService.On("GetUsersByEmails", []string{bob.Email}).Return([]*repository.User{bob}, nil).Once()
+		expenseRepo.On("GetExpense", 7).Return(disputed, nil).Once()
+
+		expense, err := expenseService.DismissExpenseDispute(7, DismissDisputeRequest{UserEmail: bob.Email})
+		assert.Nil(t, expense)
+		assert.ErrorIs(t, err, ErrNotExpenseParticipant)
+
+		active := &repository.Expense{ID: 7, CreatedBy: alice.ID, Status: repository.ExpenseActive}
+		userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+		expenseRepo.On("GetExpense", 7).Return(disputed, nil).Once()
+		expenseRepo.On("TransitionExpense", 7, repository.ExpenseDisputed, repository.ExpenseActive, "").Return(active, nil).Once()
+
+		expense, err = expenseService.DismissExpenseDispute(7, DismissDisputeRequest{UserEmail: alice.Email})
+		assert.Nil(t, err)
+		assert.Equal(t, repository.ExpenseActive, expense.Status)
+		expenseRepo.AssertExpectations(t)
+	}
+}
+
+func TestExpenseService_UndoExpense(t *testing.T) {
+	expenseRepo := new(repomock.ExpenseRepository)
+	userService := new(MockUserService)
+	balanceRepo := new(repomock.BalanceRepository)
+	settlementRepo := new(repomock.SettlementRepository)
+	svc := NewExpenseService(expenseRepo, userService, balanceRepo, nil, nil, nil, nil, settlementRepo, nil, nil, nil, nil, seededCurrencies, time.Minute, ValidationPolicy{}).(*expenseService)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+	fresh := &repository.Expense{ID: 7, CreatedBy: alice.ID, TotalAmount: 30, CreatedAt: now.Add(-30 * time.Second)}
+	splits := []repository.ExpenseSplit{
+		{ExpenseID: 7, UserID: alice.ID, AmountPaid: 30, AmountOwed: 15},
+		{ExpenseID: 7, UserID: bob.ID, AmountPaid: 0, AmountOwed: 15},
+	}
+
+	// Test case 1: Only the creator can undo
+	{
+		userService.On("GetUsersByEmails", []string{bob.Email}).Return([]*repository.User{bob}, nil).Once()
+		expenseRepo.On("GetExpense", 7).Return(fresh, nil).Once()
+
+		err := svc.UndoExpense(7, UndoExpenseRequest{UserEmail: bob.Email})
+		assert.ErrorIs(t, err, ErrNotExpenseParticipant)
+	}
+
+	// Test case 2: Not once the window has passed
+	{
+		stale := &repository.Expense{ID: 8, CreatedBy: alice.ID, CreatedAt: now.Add(-2 * time.Minute)}
+		userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+		expenseRepo.On("GetExpense", 8).Return(stale, nil).Once()
+
+		err := svc.UndoExpense(8, UndoExpenseRequest{UserEmail: alice.Email})
+		assert.ErrorIs(t, err, ErrExpenseNotUndoable)
+	}
+
+	// Test case 3: Not once something has been refunded
+	{
+		userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+		expenseRepo.On("GetExpense", 7).Return(fresh, nil).Once()
+		expenseRepo.On("GetRefundedAmount", 7).Return(5.0, nil).Once()
+
+		err := svc.UndoExpense(7, UndoExpenseRequest{UserEmail: alice.Email})
+		assert.ErrorIs(t, err, ErrExpenseNotUndoable)
+	}
+
+	// Test case 4: The creator undoes it and the balance it added is taken back
+	{
+		userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+		expenseRepo.On("GetExpense", 7).Return(fresh, nil).Once()
+		expenseRepo.On("GetRefundedAmount", 7).Return(0.0, nil).Once()
+		expenseRepo.On("GetExpenseSplits", 7).Return(splits, nil).Once()
+		expenseRepo.On("DeleteExpense", 7, []repository.BalanceUpdate{{User1ID: alice.ID, User2ID: bob.ID, Amount: -15}}).Return(nil).Once()
+
+		err := svc.UndoExpense(7, UndoExpenseRequest{UserEmail: alice.Email})
+		assert.Nil(t, err)
+		expenseRepo.AssertExpectations(t)
+	}
+
+	// Test case 5: The balances are taken back with the strategy the expense was recorded with
+	{
+		charlie := &repository.User{ID: 3, Name: "Charlie", Email: "charlie@example.com"}
+		netted := &repository.Expense{ID: 9, CreatedBy: alice.ID, TotalAmount: 30, Currency: "USD", BalanceStrategy: string(BalanceStrategyHighestBalance), CreatedAt: now.Add(-30 * time.Second)}
+		userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+		expenseRepo.On("GetExpense", 9).Return(netted, nil).Once()
+		expenseRepo.On("GetRefundedAmount", 9).Return(0.0, nil).Once()
+		expenseRepo.On("GetExpenseSplits", 9).Return([]repository.ExpenseSplit{
+			{ExpenseID: 9, UserID: alice.ID, AmountPaid: 10, AmountOwed: 10},
+			{ExpenseID: 9, UserID: bob.ID, AmountPaid: 20, AmountOwed: 10},
+			{ExpenseID: 9, UserID: charlie.ID, AmountOwed: 10},
+		}, nil).Once()
+		// Charlie paid Bob back directly, rather than through Alice
+		expenseRepo.On("DeleteExpense", 9, []repository.BalanceUpdate{{User1ID: bob.ID, User2ID: charlie.ID, Amount: -10}}).Return(nil).Once()
+
+		err := svc.UndoExpense(9, UndoExpenseRequest{UserEmail: alice.Email})
+		assert.Nil(t, err)
+		expenseRepo.AssertExpectations(t)
+	}
+
+	// Test case 6: Locked by a confirmed settlement
+	{
+		userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+		expenseRepo.On("GetExpense", 7).Return(fresh, nil).Once()
+		expenseRepo.On("GetRefundedAmount", 7).Return(0.0, nil).Once()
+		expenseRepo.On("GetExpenseSplits", 7).Return(splits, nil).Once()
+		expenseRepo.On("DeleteExpense", 7, []repository.BalanceUpdate{{User1ID: alice.ID, User2ID: bob.ID, Amount: -15}}).Return(repository.ErrExpenseLocked).Once()
+
+		err := svc.UndoExpense(7, UndoExpenseRequest{UserEmail: alice.Email})
+		assert.ErrorIs(t, err, repository.ErrExpenseLocked)
+	}
+
+	// Test case 7: Once unlocked, undoing an expense Bob had paid for proposes paying him back
+	{
+		settledBy := 4
+		settled := &repository.Expense{ID: 10, CreatedBy: alice.ID, TotalAmount: 30, CreatedAt: now.Add(-30 * time.Second), SettledBy: &settledBy}
+		userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+		expenseRepo.On("GetExpense", 10).Return(settled, nil).Once()
+		expenseRepo.On("GetRefundedAmount", 10).Return(0.0, nil).Once()
+		expenseRepo.On("GetExpenseSplits", 10).Return(splits, nil).Once()
+		expenseRepo.On("DeleteExpense", 10, []repository.BalanceUpdate{{User1ID: alice.ID, User2ID: bob.ID, Amount: -15}}).Return(nil).Once()
+		// Bob's 15 paid Alice for a share that is gone, so Alice owes it back
+		balanceRepo.On("GetBalancesByUserID", alice.ID).Return([]repository.Balance{{User1ID: alice.ID, User2ID: bob.ID, Balance: -15}}, nil).Once()
+		expenseRepo.On("HasDisputedExpenseBetween", alice.ID, bob.ID).Return(false, nil).Once()
+		settlementRepo.On("GetSettlementsByUserID", alice.ID).Return([]repository.Settlement{}, nil).Once()
+		owed := 15.0
+		settlementRepo.On("CreateSettlements", []*repository.Settlement{{PayerID: alice.ID, PayeeID: bob.ID, Amount: 15, OutstandingAmount: &owed}}).Return([]*repository.Settlement{{ID: 5}}, nil).Once()
+
+		err := svc.UndoExpense(10, UndoExpenseRequest{UserEmail: alice.Email})
+		assert.Nil(t, err)
+		expenseRepo.AssertExpectations(t)
+		balanceRepo.AssertExpectations(t)
+		settlementRepo.AssertExpectations(t)
+	}
+
+	// Test case 8: Nothing is proposed for a pair that is even, or already has it proposed
+	{
+		settledBy := 4
+		settled := &repository.Expense{ID: 11, CreatedBy: alice.ID, TotalAmount: 30, CreatedAt: now.Add(-30 * time.Second), SettledBy: &settledBy}
+		for _, balance := range []float64{0, -15} {
+			userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+			expenseRepo.On("GetExpense", 11).Return(settled, nil).Once()
+			expenseRepo.On("GetRefundedAmount", 11).Return(0.0, nil).Once()
+			expenseRepo.On("GetExpenseSplits", 11).Return(splits, nil).Once()
+			expenseRepo.On("DeleteExpense", 11, []repository.BalanceUpdate{{User1ID: alice.ID, User2ID: bob.ID, Amount: -15}}).Return(nil).Once()
+			balanceRepo.On("GetBalancesByUserID", alice.ID).Return([]repository.Balance{{User1ID: alice.ID, User2ID: bob.ID, Balance: balance}}, nil).Once()
+			if balance != 0 {
+				expenseRepo.On("HasDisputedExpenseBetween", alice.ID, bob.ID).Return(false, nil).Once()
+				settlementRepo.On("GetSettlementsByUserID", alice.ID).Return([]repository.Settlement{
+					{PayerID: alice.ID, PayeeID: bob.ID, Amount: 15, Status: repository.SettlementProposed},
+				}, nil).Once()
+			}
+
+			assert.Nil(t, svc.UndoExpense(11, UndoExpenseRequest{UserEmail: alice.Email}))
+		}
+		settlementRepo.AssertNumberOfCalls(t, "CreateSettlements", 1) // The one in test case 7
+		balanceRepo.AssertExpectations(t)
+	}
+}
+
+func TestExpenseService_UnlockExpense(t *testing.T) {
+	expenseRepo := new(repomock.ExpenseRepository)
+	userService := new(MockUserService)
+	expenseService := NewExpenseService(expenseRepo, userService, new(repomock.BalanceRepository), nil, nil, nil, nil, nil, nil, nil, nil, nil, seededCurrencies, 0, ValidationPolicy{})
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+	lockedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	settledBy := 4
+	locked := &repository.Expense{ID: 7, CreatedBy: alice.ID, LockedAt: &lockedAt, SettledBy: &settledBy}
+
+	// Test case 1: Only the creator can unlock
+	{
+		userService.On("GetUsersByEmails", []string{bob.Email}).Return([]*repository.User{bob}, nil).Once()
+		expenseRepo.On("GetExpense", 7).Return(locked, nil).Once()
+
+		expense, err := expenseService.UnlockExpense(7, UnlockExpenseRequest{UserEmail: bob.Email})
+		assert.Nil(t, expense)
+		assert.ErrorIs(t, err, ErrNotExpenseParticipant)
+	}
+
+	// Test case 2: The creator unlocks it
+	{
+		unlocked := &repository.Expense{ID: 7, CreatedBy: alice.ID, SettledBy: &settledBy}
+		userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+		expenseRepo.On("GetExpense", 7).Return(locked, nil).Once()
+		expenseRepo.On("UnlockExpense", 7).Return(unlocked, nil).Once()
+
+		expense, err := expenseService.UnlockExpense(7, UnlockExpenseRequest{UserEmail: alice.Email})
+		assert.Nil(t, err)
+		assert.Nil(t, expense.LockedAt)
+	}
+
+	// Test case 3: It wasn't locked
+	{
+		userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+		expenseRepo.On("GetExpense", 8).Return(&repository.Expense{ID: 8, CreatedBy: alice.ID}, nil).Once()
+		expenseRepo.On("UnlockExpense", 8).Return(nil, repository.ErrExpenseNotLocked).Once()
+
+		_, err := expenseService.UnlockExpense(8, UnlockExpenseRequest{UserEmail: alice.Email})
+		assert.ErrorIs(t, err, repository.ErrExpenseNotLocked)
+	}
+
+	expenseRepo.AssertExpectations(t)
+}
+
 func TestExpenseService_GetOutstandingBalancesForUser(t *testing.T) {
-	expenseRepo := new(MockExpenseRepository)
+	expenseRepo := new(repomock.ExpenseRepository)
 	userService := new(MockUserService)
-	balanceRepo := new(MockBalanceRepository)
-	expenseService := NewExpenseService(expenseRepo, userService, balanceRepo)
+	balanceRepo := new(repomock.BalanceRepository)
+	expenseService := NewExpenseService(expenseRepo, userService, balanceRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, seededCurrencies, 0, ValidationPolicy{})
 
 	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
 	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
@@ -360,13 +895,67 @@ func TestExpenseService_GetOutstandingBalancesForUser(t *testing.T) {
 		userService.AssertExpectations(t)
 		balanceRepo.AssertExpectations(t)
 	}
+
+	// Test case: A balance settled in part says how much has been paid towards it
+	{
+		ledgerRepo := new(repomock.LedgerRepository)
+		expenseService := NewExpenseService(expenseRepo, userService, balanceRepo, nil, nil, nil, nil, nil, ledgerRepo, nil, nil, nil, seededCurrencies, 0, ValidationPolicy{})
+		now := time.Now()
+		userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+		balanceRepo.On("GetBalancesByUserID", alice.ID).Return([]repository.Balance{{User1ID: alice.ID, User2ID: bob.ID, Balance: 35, LastUpdated: now}}, nil).Once()
+		ledgerRepo.On("GetUnsettledEntries", alice.ID).Return([]repository.LedgerEntry{
+			{Source: repository.LedgerSource{Type: repository.LedgerExpense, ID: 1}, UserID: alice.ID, CounterpartyID: bob.ID, Amount: 55},
+			{Source: repository.LedgerSource{Type: repository.LedgerSettlement, ID: 1}, UserID: alice.ID, CounterpartyID: bob.ID, Amount: -20},
+		}, nil).Once()
+		userService.On("GetUsersByIDs", []int{bob.ID}).Return([]*repository.User{bob}, nil).Once()
+
+		balances, err := expenseService.GetOutstandingBalancesForUser(alice.Email)
+		assert.Nil(t, err)
+		assert.Equal(t, []UserBalanceView{{WithUserEmail: "bob@example.com", WithUserName: "Bob", Amount: 35, PaidAmount: 20, LastUpdated: now}}, balances)
+		ledgerRepo.AssertExpectations(t)
+	}
+}
+
+func TestExpenseService_GetBalanceList(t *testing.T) {
+	userService := new(MockUserService)
+	balanceRepo := new(repomock.BalanceRepository)
+	expenseService := NewExpenseService(new(repomock.ExpenseRepository), userService, balanceRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, seededCurrencies, 0, ValidationPolicy{})
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+	charlie := &repository.User{ID: 3, Name: "Charlie", Email: "charlie@example.com"}
+	now := time.Now()
+
+	// Test case 1: A page of what Alice is owed, with the count and total of all of it
+	userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+	balanceRepo.On("GetDirectedBalances", alice.ID, repository.OwedToUser, 2, 0).Return(&repository.DirectedBalances{
+		Balances: []repository.DirectedBalance{{CounterpartyID: charlie.ID, Amount: 40.004, LastUpdated: now}, {CounterpartyID: bob.ID, Amount: 15, LastUpdated: now}},
+		Count:    3,
+		Total:    60.004,
+	}, nil).Once()
+	userService.On("GetUsersByIDs", []int{charlie.ID, bob.ID}).Return([]*repository.User{bob, charlie}, nil).Once()
+
+	list, err := expenseService.GetBalanceList(alice.Email, repository.OwedToUser, 2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, &BalanceList{Count: 3, Total: 60, Balances: []UserBalanceView{
+		{WithUserEmail: "charlie@example.com", WithUserName: "Charlie", Amount: 40, LastUpdated: now},
+		{WithUserEmail: "bob@example.com", WithUserName: "Bob", Amount: 15, LastUpdated: now},
+	}}, list)
+
+	// Test case 2: Unknown user
+	userService.On("GetUsersByEmails", []string{"ghost@example.com"}).Return([]*repository.User{}, nil).Once()
+	_, err = expenseService.GetBalanceList("ghost@example.com", repository.OwedByUser, 0, 0)
+	assert.Error(t, err)
+
+	userService.AssertExpectations(t)
+	balanceRepo.AssertExpectations(t)
 }
 
 func TestExpenseService_GetOverallOutstandingBalance(t *testing.T) {
-	expenseRepo := new(MockExpenseRepository)
+	expenseRepo := new(repomock.ExpenseRepository)
 	userService := new(MockUserService)
-	balanceRepo := new(MockBalanceRepository)
-	expenseService := NewExpenseService(expenseRepo, userService, balanceRepo)
+	balanceRepo := new(repomock.BalanceRepository)
+	expenseService := NewExpenseService(expenseRepo, userService, balanceRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, seededCurrencies, 0, ValidationPolicy{})
 
 	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
 
@@ -413,3 +1002,128 @@ func TestExpenseService_GetOverallOutstandingBalance(t *testing.T) {
 		balanceRepo.AssertExpectations(t)
 	}
 }
+
+// ledgerExpenseRepository keeps splits and pairwise balances in memory, applying balance
+// updates with the same pair ordering as the SQL repository. Balances are kept in cents,
+// as the DECIMAL(10, 2) column would. Methods the test does not need are left to the nil embedded interface.
+type ledgerExpenseRepository struct {
+	repository.ExpenseRepository
+	splits   []repository.ExpenseSplit
+	balances map[[2]int]int64
+}
+
+func (r *ledgerExpenseRepository) CreateExpense(expense *repository.Expense, splits []repository.ExpenseSplit, balanceUpdates []repository.BalanceUpdate) (*repository.Expense, error) {
+	r.splits = append(r.splits, splits...)
+	for _, u := range balanceUpdates {
+		user1ID, user2ID, amount := repository.OrderedPair(u.User1ID, u.User2ID, u.Amount)
+		r.balances[[2]int{user1ID, user2ID}] += util.ToMinorUnits(amount, 2)
+	}
+	expense.CreatedAt = time.Now()
+	return expense, nil
+}
+
+// randomExpenseRequest builds a valid request over a random subset of users, with the paid
+// amounts spread randomly across the participants.
+func randomExpenseRequest(rng *rand.Rand, users []*repository.User) CreateExpenseRequest {
+	perm := rng.Perm(len(users))
+	participants := make([]*repository.User, 1+rng.Intn(len(users)))
+	for i := range participants {
+		participants[i] = users[perm[i]]
+	}
+
+	totalCents := int64(1 + rng.Intn(100000))
+	paid := make([]int64, len(participants))
+	for left := totalCents; left > 0; {
+		chunk := 1 + rng.Int63n(left)
+		paid[rng.Intn(len(paid))] += chunk
+		left -= chunk
+	}
+
+	req := CreateExpenseRequest{
+		Description:    "Random",
+		TotalAmount:    util.FromMinorUnits(totalCents, 2),
+		CreatedByEmail: participants[rng.Intn(len(participants))].Email,
+	}
+
+	switch rng.Intn(3) {
+	case 0:
+		req.SplitMethod = SplitMethodEqual
+		for i, p := range participants {
+			req.EqualSplits = append(req.EqualSplits, EqualSplitRequest{UserEmail: p.Email, AmountPaid: util.FromMinorUnits(paid[i], 2)})
+		}
+	case 1:
+		req.SplitMethod = SplitMethodPercentage
+		left := 10000
+		for i, p := range participants {
+			basisPoints := left
+			if i < len(participants)-1 {
+				basisPoints = rng.Intn(left + 1)
+			}
+			left -= basisPoints
+			req.PercentageSplits = append(req.PercentageSplits, PercentageSplitRequest{UserEmail: p.Email, Percentage: float64(basisPoints) / 100, AmountPaid: util.FromMinorUnits(paid[i], 2)})
+		}
+	default:
+		req.SplitMethod = SplitMethodManual
+		left := totalCents
+		for i, p := range participants {
+			owed := left
+			if i < len(participants)-1 {
+				owed = rng.Int63n(left + 1)
+			}
+			left -= owed
+			req.ManualSplits = append(req.ManualSplits, ManualSplitRequest{UserEmail: p.Email, AmountOwed: util.FromMinorUnits(owed, 2), AmountPaid: util.FromMinorUnits(paid[i], 2)})
+		}
+	}
+
+	return req
+}
+
+func TestExpenseService_BalanceConservation(t *testing.T) {
+	users := []*repository.User{
+		{ID: 1, Name: "Alice", Email: "alice@example.com"},
+		{ID: 2, Name: "Bob", Email: "bob@example.com"},
+		{ID: 3, Name: "Charlie", Email: "charlie@example.com"},
+		{ID: 4, Name: "Dave", Email: "dave@example.com"},
+		{ID: 5, Name: "Eve", Email: "eve@example.com"},
+	}
+
+	for seed := int64(1); seed <= 200; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		expenseRepo := &ledgerExpenseRepository{balances: make(map[[2]int]int64)}
+		userService := new(MockUserService)
+		userService.On("GetUsersByEmails", mock.AnythingOfType("[]string")).Return(users, nil)
+		expenseService := NewExpenseService(expenseRepo, userService, new(repomock.BalanceRepository), nil, nil, nil, nil, nil, nil, nil, nil, nil, seededCurrencies, 0, ValidationPolicy{})
+
+		for i := 0; i < 1+rng.Intn(20); i++ {
+			req := randomExpenseRequest(rng, users)
+			if _, err := expenseService.CreateExpense(req); err != nil {
+				t.Fatalf("seed %d: unexpected error creating %+v: %v", seed, req, err)
+			}
+		}
+
+		// Overall balance per user from the pairwise table, as GetOverallBalanceByUserID computes it
+		overall := make(map[int]int64)
+		var sum int64
+		for pair, cents := range expenseRepo.balances {
+			overall[pair[0]] += cents
+			overall[pair[1]] -= cents
+		}
+		for _, cents := range overall {
+			sum += cents
+		}
+		if sum != 0 {
+			t.Fatalf("seed %d: balances do not net to zero, off by %d cents", seed, sum)
+		}
+
+		// Each user's overall balance equals what they paid minus what they owed across all splits
+		fromSplits := make(map[int]int64)
+		for _, s := range expenseRepo.splits {
+			fromSplits[s.UserID] += util.ToMinorUnits(s.AmountPaid, 2) - util.ToMinorUnits(s.AmountOwed, 2)
+		}
+		for _, u := range users {
+			if overall[u.ID] != fromSplits[u.ID] {
+				t.Fatalf("seed %d: user %d balance %d cents does not match %d cents recomputed from splits", seed, u.ID, overall[u.ID], fromSplits[u.ID])
+			}
+		}
+	}
+}
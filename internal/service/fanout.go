@@ -0,0 +1,58 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxFanOut caps how many queries one aggregation runs at once, so a single request can't take
+// over the database's connection pool.
+const maxFanOut = 4
+
+// userLookupBatch is how many users one lookup asks for when fetching a long list of counterparties.
+const userLookupBatch = 100
+
+// fanOut runs independent queries concurrently, at most maxFanOut at a time, and returns the first
+// error any of them returns once they have all finished.
+func fanOut(queries ...func() error) error {
+	var g errgroup.Group
+	g.SetLimit(maxFanOut)
+	for _, query := range queries {
+		g.Go(query)
+	}
+	return g.Wait()
+}
+
+// getUsersByIDs looks up users in batches of userLookupBatch, fetched concurrently, so a user with
+// many counterparties doesn't wait on one long lookup. The users come back in no particular order.
+func getUsersByIDs(userService UserService, ids []int) ([]*repository.User, error) {
+	if len(ids) <= userLookupBatch {
+		return userService.GetUsersByIDs(ids)
+	}
+
+	var (
+		mu    sync.Mutex
+		users = make([]*repository.User, 0, len(ids))
+	)
+	var queries []func() error
+	for start := 0; start < len(ids); start += userLookupBatch {
+		batch := ids[start:min(start+userLookupBatch, len(ids))]
+		queries = append(queries, func() error {
+			found, err := userService.GetUsersByIDs(batch)
+			if err != nil {
+				return fmt.Errorf("failed to get %d users: %w", len(batch), err)
+			}
+			mu.Lock()
+			users = append(users, found...)
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := fanOut(queries...); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFanOut(t *testing.T) {
+	// Test case 1: Queries run concurrently, never more than maxFanOut at once
+	var running, peak atomic.Int32
+	queries := make([]func() error, 2*maxFanOut)
+	for i := range queries {
+		queries[i] = func() error {
+			n := running.Add(1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			time.Sleep(10 * time.Millisecond)
+			running.Add(-1)
+			return nil
+		}
+	}
+	require.NoError(t, fanOut(queries...))
+	assert.Greater(t, peak.Load(), int32(1))
+	assert.LessOrEqual(t, peak.Load(), int32(maxFanOut))
+
+	// Test case 2: A failing query fails the lot
+	errBoom := errors.New("boom")
+	assert.ErrorIs(t, fanOut(func() error { return nil }, func() error { return errBoom }), errBoom)
+}
+
+func TestGetUsersByIDs(t *testing.T) {
+	userService := new(MockUserService)
+
+	// Test case 1: A short list is one lookup
+	userService.On("GetUsersByIDs", []int{1, 2}).Return([]*repository.User{{ID: 1}, {ID: 2}}, nil).Once()
+	users, err := getUsersByIDs(userService, []int{1, 2})
+	require.NoError(t, err)
+	assert.Len(t, users, 2)
+
+	// Test case 2: A long list is split into batches, whose users are put together
+	ids := make([]int, userLookupBatch+1)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	userService.On("GetUsersByIDs", ids[:userLookupBatch]).Return([]*repository.User{{ID: 1}}, nil).Once()
+	userService.On("GetUsersByIDs", ids[userLookupBatch:]).Return([]*repository.User{{ID: userLookupBatch + 1}}, nil).Once()
+	users, err = getUsersByIDs(userService, ids)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*repository.User{{ID: 1}, {ID: userLookupBatch + 1}}, users)
+
+	// Test case 3: A failed batch fails the lookup
+	userService.On("GetUsersByIDs", ids[:userLookupBatch]).Return([]*repository.User(nil), errors.New("db down")).Once()
+	userService.On("GetUsersByIDs", mock.Anything).Return([]*repository.User{}, nil).Once()
+	_, err = getUsersByIDs(userService, ids)
+	assert.ErrorContains(t, err, "db down")
+
+	userService.AssertExpectations(t)
+}
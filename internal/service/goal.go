@@ -0,0 +1,174 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// ErrInvalidGoal is returned for a goal that can't be tracked, such as one whose deadline has passed.
+var ErrInvalidGoal = errors.New("invalid goal")
+
+type CreateGoalRequest struct {
+	UserEmail     string  `json:"user_email"`
+	TargetBalance float64 `json:"target_balance"` // Zero means settling up completely
+	Deadline      string  `json:"deadline"`       // YYYY-MM-DD
+}
+
+// GoalProgress is how far a user has come towards a goal. Progress is measured on the overall
+// balance, so confirmed settlements move it as soon as they land.
+type GoalProgress struct {
+	repository.Goal
+	CurrentBalance float64 `json:"current_balance"`
+	Progress       float64 `json:"progress"` // From 0 at the starting balance to 1 at the target
+	Achieved       bool    `json:"achieved"`
+	DaysLeft       int     `json:"days_left"`
+	// OnTrack tells whether progress keeps pace with the time elapsed since the goal was set.
+	OnTrack bool `json:"on_track"`
+	// Nudges splits what others still owe towards the target among the debtors, in proportion to their debts.
+	Nudges []GoalNudge `json:"nudges"`
+}
+
+type GoalNudge struct {
+	UserEmail string  `json:"user_email"`
+	UserName  string  `json:"user_name"`
+	Amount    float64 `json:"amount"`
+}
+
+type GoalService interface {
+	CreateGoal(req CreateGoalRequest) (*repository.Goal, error)
+	GetGoalProgress(userEmail string) ([]GoalProgress, error)
+}
+
+type goalService struct {
+	goalRepo    repository.GoalRepository
+	balanceRepo repository.BalanceRepository
+	userService UserService
+	now         func() time.Time
+}
+
+func NewGoalService(goalRepo repository.GoalRepository, balanceRepo repository.BalanceRepository, userService UserService) GoalService {
+	return &goalService{goalRepo: goalRepo, balanceRepo: balanceRepo, userService: userService, now: time.Now}
+}
+
+func (s *goalService) CreateGoal(req CreateGoalRequest) (*repository.Goal, error) {
+	deadline, err := time.Parse("2006-01-02", req.Deadline)
+	if err != nil {
+		return nil, fmt.Errorf("%w: deadline must be a YYYY-MM-DD date", ErrInvalidGoal)
+	}
+	if !deadline.After(s.now().UTC()) {
+		return nil, fmt.Errorf("%w: deadline must be in the future", ErrInvalidGoal)
+	}
+
+	users, err := s.userService.GetUsersByEmails([]string{req.UserEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", req.UserEmail)
+	}
+	userID := users[0].ID
+
+	current, err := s.balanceRepo.GetOverallBalanceByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance for goal: %w", err)
+	}
+
+	goal, err := s.goalRepo.CreateGoal(&repository.Goal{
+		UserID:          userID,
+		TargetBalance:   req.TargetBalance,
+		StartingBalance: util.RoundToDefaultCurrency(current),
+		Deadline:        deadline,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create goal in service: %w", err)
+	}
+	return goal, nil
+}
+
+func (s *goalService) GetGoalProgress(userEmail string) ([]GoalProgress, error) {
+	users, err := s.userService.GetUsersByEmails([]string{userEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", userEmail)
+	}
+	userID := users[0].ID
+
+	goals, err := s.goalRepo.GetGoalsByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goals for user %s: %w", userEmail, err)
+	}
+	if len(goals) == 0 {
+		return []GoalProgress{}, nil
+	}
+
+	balances, err := s.balanceRepo.GetBalancesByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances for user %s: %w", userEmail, err)
+	}
+
+	// What each other user owes this one, positive for debtors
+	var current, owedToUser float64
+	owedBy := make(map[int]float64)
+	var debtorIDs []int
+	for _, b := range balances {
+		otherID, amount := b.User2ID, b.Balance
+		if b.User2ID == userID {
+			otherID, amount = b.User1ID, -b.Balance
+		}
+		current += amount
+		if amount > 0 {
+			if _, ok := owedBy[otherID]; !ok {
+				debtorIDs = append(debtorIDs, otherID)
+			}
+			owedBy[otherID] += amount
+			owedToUser += amount
+		}
+	}
+	current = util.RoundToDefaultCurrency(current)
+
+	var debtors []*repository.User
+	if len(debtorIDs) > 0 {
+		if debtors, err = s.userService.GetUsersByIDs(debtorIDs); err != nil {
+			return nil, fmt.Errorf("failed to fetch debtors for goals: %w", err)
+		}
+	}
+
+	now := s.now().UTC()
+	progress := make([]GoalProgress, 0, len(goals))
+	for _, g := range goals {
+		p := GoalProgress{Goal: g, CurrentBalance: current, Nudges: []GoalNudge{}}
+
+		if span := g.StartingBalance - g.TargetBalance; span != 0 {
+			p.Progress = util.RoundToTwoDecimalPlaces(math.Min(1, math.Max(0, (g.StartingBalance-current)/span)))
+		} else if current == g.TargetBalance {
+			p.Progress = 1
+		}
+		p.Achieved = p.Progress == 1
+
+		if left := g.Deadline.Sub(now); left > 0 {
+			p.DaysLeft = int(math.Ceil(left.Hours() / 24))
+		}
+		elapsed := 1.0
+		if total := g.Deadline.Sub(g.CreatedAt); total > 0 {
+			elapsed = math.Min(1, now.Sub(g.CreatedAt).Seconds()/total.Seconds())
+		}
+		p.OnTrack = p.Achieved || p.Progress >= elapsed
+
+		// Only money owed to the user brings a balance above the target down
+		if gap := current - g.TargetBalance; !p.Achieved && gap > 0 && owedToUser > 0 {
+			gap = math.Min(gap, owedToUser)
+			for _, u := range debtors {
+				p.Nudges = append(p.Nudges, GoalNudge{
+					UserEmail: u.Email,
+					UserName:  u.Name,
+					Amount:    util.RoundToDefaultCurrency(gap * owedBy[u.ID] / owedToUser),
+				})
+			}
+		}
+
+		progress = append(progress, p)
+	}
+
+	return progress, nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoalService_CreateGoal(t *testing.T) {
+	goalRepo := new(repomock.GoalRepository)
+	balanceRepo := new(repomock.BalanceRepository)
+	userService := new(MockUserService)
+	s := NewGoalService(goalRepo, balanceRepo, userService).(*goalService)
+	s.now = func() time.Time { return time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC) }
+
+	alice := &repository.User{ID: 1, Email: "alice@example.com"}
+
+	// Test case 1: The current balance is recorded as the start
+	{
+		deadline := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+		userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+		balanceRepo.On("GetOverallBalanceByUserID", alice.ID).Return(120.0, nil).Once()
+		expected := &repository.Goal{UserID: alice.ID, StartingBalance: 120, Deadline: deadline}
+		goalRepo.On("CreateGoal", expected).Return(expected, nil).Once()
+
+		goal, err := s.CreateGoal(CreateGoalRequest{UserEmail: alice.Email, Deadline: "2026-12-01"})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, goal)
+	}
+
+	// Test case 2: Bad deadlines are rejected before anything is looked up
+	for _, deadline := range []string{"Dec 1", "2026-09-30", "2026-10-01"} {
+		_, err := s.CreateGoal(CreateGoalRequest{UserEmail: alice.Email, Deadline: deadline})
+		assert.True(t, errors.Is(err, ErrInvalidGoal), deadline)
+	}
+
+	goalRepo.AssertExpectations(t)
+	balanceRepo.AssertExpectations(t)
+	userService.AssertExpectations(t)
+}
+
+func TestGoalService_GetGoalProgress(t *testing.T) {
+	goalRepo := new(repomock.GoalRepository)
+	balanceRepo := new(repomock.BalanceRepository)
+	userService := new(MockUserService)
+	s := NewGoalService(goalRepo, balanceRepo, userService).(*goalService)
+
+	set := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return set.AddDate(0, 0, 15) }
+
+	alice := &repository.User{ID: 1, Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+	charlie := &repository.User{ID: 3, Name: "Charlie", Email: "charlie@example.com"}
+	dave := &repository.User{ID: 4, Name: "Dave", Email: "dave@example.com"}
+
+	goals := []repository.Goal{
+		// Halfway through the time, from 200 down to 0. Collecting 80 of the 90 owed gets there, as Alice owes Dave 10
+		{ID: 1, UserID: alice.ID, StartingBalance: 200, Deadline: set.AddDate(0, 0, 30), CreatedAt: set},
+		// Already reached
+		{ID: 2, UserID: alice.ID, TargetBalance: 100, StartingBalance: 200, Deadline: set.AddDate(0, 0, 30), CreatedAt: set},
+	}
+	userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+	goalRepo.On("GetGoalsByUserID", alice.ID).Return(goals, nil).Once()
+	balanceRepo.On("GetBalancesByUserID", alice.ID).Return([]repository.Balance{
+		{User1ID: alice.ID, User2ID: bob.ID, Balance: 60},
+		{User1ID: charlie.ID, User2ID: alice.ID, Balance: -30},
+		// Alice owes Dave, which doesn't make him a debtor
+		{User1ID: dave.ID, User2ID: alice.ID, Balance: 10},
+	}, nil).Once()
+	userService.On("GetUsersByIDs", []int{bob.ID, charlie.ID}).Return([]*repository.User{bob, charlie}, nil).Once()
+
+	progress, err := s.GetGoalProgress(alice.Email)
+	assert.NoError(t, err)
+	assert.Equal(t, []GoalProgress{
+		{
+			Goal:           goals[0],
+			CurrentBalance: 80,
+			Progress:       0.6,
+			DaysLeft:       15,
+			OnTrack:        true,
+			Nudges: []GoalNudge{
+				{UserEmail: bob.Email, UserName: bob.Name, Amount: 53.33},
+				{UserEmail: charlie.Email, UserName: charlie.Name, Amount: 26.67},
+			},
+		},
+		{Goal: goals[1], CurrentBalance: 80, Progress: 1, Achieved: true, DaysLeft: 15, OnTrack: true, Nudges: []GoalNudge{}},
+	}, progress)
+	goalRepo.AssertExpectations(t)
+	balanceRepo.AssertExpectations(t)
+	userService.AssertExpectations(t)
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+const (
+	HealthUp   = "up"
+	HealthDown = "down"
+)
+
+// Pinger checks that a dependency is reachable. *sql.DB satisfies it.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+type ComponentHealth struct {
+	Name      string         `json:"name"`
+	Status    string         `json:"status"`
+	LatencyMs float64        `json:"latency_ms"`
+	Error     string         `json:"error,omitempty"`
+	Details   map[string]int `json:"details,omitempty"`
+}
+
+// HealthReport is down as soon as any of its components is.
+type HealthReport struct {
+	Status     string            `json:"status"`
+	Components []ComponentHealth `json:"components"`
+}
+
+type HealthService interface {
+	Check(ctx context.Context) HealthReport
+}
+
+type healthService struct {
+	db      Pinger
+	jobRepo repository.JobRepository
+}
+
+func NewHealthService(db Pinger, jobRepo repository.JobRepository) HealthService {
+	return &healthService{db: db, jobRepo: jobRepo}
+}
+
+func (s *healthService) Check(ctx context.Context) HealthReport {
+	report := HealthReport{Status: HealthUp}
+	for _, c := range []ComponentHealth{s.checkDatabase(ctx), s.checkJobs()} {
+		if c.Status != HealthUp {
+			report.Status = HealthDown
+		}
+		report.Components = append(report.Components, c)
+	}
+	return report
+}
+
+func (s *healthService) checkDatabase(ctx context.Context) ComponentHealth {
+	c := ComponentHealth{Name: "database", Status: HealthUp}
+	start := time.Now()
+	err := s.db.PingContext(ctx)
+	c.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		c.Status = HealthDown
+		c.Error = err.Error()
+	}
+	return c
+}
+
+// checkJobs reports the queue depth. Dead jobs need someone to look at them but don't make the service unhealthy.
+func (s *healthService) checkJobs() ComponentHealth {
+	c := ComponentHealth{Name: "jobs", Status: HealthUp}
+	start := time.Now()
+	counts, err := s.jobRepo.CountJobsByStatus()
+	c.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		c.Status = HealthDown
+		c.Error = err.Error()
+		return c
+	}
+	c.Details = map[string]int{
+		"queued":  counts[repository.JobQueued],
+		"running": counts[repository.JobRunning],
+		"dead":    counts[repository.JobDead],
+	}
+	return c
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pingerFunc func(ctx context.Context) error
+
+func (f pingerFunc) PingContext(ctx context.Context) error { return f(ctx) }
+
+func TestHealthService_Check(t *testing.T) {
+	// Test case 1: Everything is up, dead jobs included
+	{
+		jobRepo := new(repomock.JobRepository)
+		jobRepo.On("CountJobsByStatus").Return(map[repository.JobStatus]int{repository.JobQueued: 4, repository.JobDead: 1, repository.JobSucceeded: 20}, nil).Once()
+		healthService := NewHealthService(pingerFunc(func(ctx context.Context) error { return nil }), jobRepo)
+
+		report := healthService.Check(context.Background())
+		assert.Equal(t, HealthUp, report.Status)
+		require.Len(t, report.Components, 2)
+		assert.Equal(t, "database", report.Components[0].Name)
+		assert.Equal(t, HealthUp, report.Components[0].Status)
+		assert.Equal(t, map[string]int{"queued": 4, "running": 0, "dead": 1}, report.Components[1].Details)
+	}
+
+	// Test case 2: The database is unreachable
+	{
+		jobRepo := new(repomock.JobRepository)
+		jobRepo.On("CountJobsByStatus").Return(nil, errors.New("connection refused")).Once()
+		healthService := NewHealthService(pingerFunc(func(ctx context.Context) error { return errors.New("connection refused") }), jobRepo)
+
+		report := healthService.Check(context.Background())
+		assert.Equal(t, HealthDown, report.Status)
+		assert.Equal(t, "connection refused", report.Components[0].Error)
+		assert.Equal(t, HealthDown, report.Components[1].Status)
+	}
+}
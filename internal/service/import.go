@@ -0,0 +1,341 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// ImportJobType is the job that imports a committed import session.
+const ImportJobType = "import_expenses"
+
+// MaxImportChunks bounds how many chunks an import may be sent in.
+const MaxImportChunks = 1000
+
+// importProgressEvery is how many rows the import job goes between reports on its job.
+const importProgressEvery = 100
+
+var (
+	// ErrImportNotOpen is returned for a chunk sent to, or a commit of, a session already committed.
+	ErrImportNotOpen = errors.New("import session is not open")
+	// ErrInvalidImport is returned for a commit or chunk that doesn't fit the session.
+	ErrInvalidImport = errors.New("invalid import")
+)
+
+type CreateImportRequest struct {
+	UserEmail string `json:"user_email"` // Recorded as the creator of every imported expense
+}
+
+type CommitImportRequest struct {
+	ChunkCount int `json:"chunk_count"` // Chunks 0 to chunk_count-1 make up the file
+}
+
+// ImportService imports expenses from a CSV file uploaded in chunks. The file's header names its
+// columns, in any order: description, amount, paid_by and split_between are required, currency and
+// tag are optional. Each row is an expense paid in full by paid_by and split equally between the
+// semicolon-separated emails of split_between, paid_by among them.
+type ImportService interface {
+	CreateImport(req CreateImportRequest) (*repository.ImportSession, error)
+	GetImport(id int) (*repository.ImportSession, error)
+	// UploadChunk stores chunk seq of an open session. Sending a chunk again replaces it, so a client
+	// that lost the response can simply retry.
+	UploadChunk(id, seq int, data []byte) (*repository.ImportSession, error)
+	// CommitImport queues the import of an open session whose chunks have all arrived, and returns the
+	// session with the job to poll for progress. Committing a failed session again resumes it after
+	// the rows already imported; committing one already committed just returns it.
+	CommitImport(id int, req CommitImportRequest) (*repository.ImportSession, error)
+}
+
+type importService struct {
+	importRepo     repository.ImportRepository
+	expenseService ExpenseService
+	userService    UserService
+	jobService     JobService
+	quotaService   QuotaService
+	currencies     util.Currencies
+}
+
+// importJob is the payload of an ImportJobType job.
+type importJob struct {
+	SessionID int `json:"session_id"`
+}
+
+// NewImportService builds the import service and registers its job with jobService. quotaService
+// may be nil, in which case uploads are not limited.
+func NewImportService(importRepo repository.ImportRepository, expenseService ExpenseService, userService UserService, jobService JobService, quotaService QuotaService, currencies util.Currencies) ImportService {
+	s := &importService{importRepo: importRepo, expenseService: expenseService, userService: userService, jobService: jobService, quotaService: quotaService, currencies: currencies}
+	jobService.Register(ImportJobType, s.runImportJob)
+	return s
+}
+
+func (s *importService) CreateImport(req CreateImportRequest) (*repository.ImportSession, error) {
+	users, err := s.userService.GetUsersByEmails([]string{req.UserEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", req.UserEmail)
+	}
+	return s.importRepo.CreateImportSession(&repository.ImportSession{CreatedBy: users[0].ID})
+}
+
+func (s *importService) GetImport(id int) (*repository.ImportSession, error) {
+	return s.importRepo.GetImportSession(id)
+}
+
+func (s *importService) UploadChunk(id, seq int, data []byte) (*repository.ImportSession, error) {
+	if seq < 0 || seq >= MaxImportChunks {
+		return nil, fmt.Errorf("%w: chunk numbers go from 0 to %d", ErrInvalidImport, MaxImportChunks-1)
+	}
+	session, err := s.importRepo.GetImportSession(id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != repository.ImportOpen {
+		return nil, ErrImportNotOpen
+	}
+	if s.quotaService != nil {
+		if err := s.quotaService.CheckUpload(session.CreatedBy, len(data)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.importRepo.PutImportChunk(id, seq, data); err != nil {
+		return nil, err
+	}
+	return s.importRepo.GetImportSession(id)
+}
+
+func (s *importService) CommitImport(id int, req CommitImportRequest) (*repository.ImportSession, error) {
+	session, err := s.importRepo.GetImportSession(id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch session.Status {
+	case repository.ImportCommitted:
+		if session.JobID != nil {
+			return session, nil
+		}
+		// Committed, but queueing its job failed; queue it now
+	case repository.ImportDone:
+		return nil, ErrImportNotOpen
+	case repository.ImportFailed:
+		if req.ChunkCount != session.ChunkCount {
+			return nil, fmt.Errorf("%w: the session was committed with %d chunks", ErrInvalidImport, session.ChunkCount)
+		}
+		fallthrough
+	case repository.ImportOpen:
+		if req.ChunkCount < 1 || req.ChunkCount > MaxImportChunks {
+			return nil, fmt.Errorf("%w: chunk_count must be between 1 and %d", ErrInvalidImport, MaxImportChunks)
+		}
+		if missing := missingChunks(session.ReceivedChunks, req.ChunkCount); len(missing) > 0 {
+			return nil, fmt.Errorf("%w: chunks %v have not been uploaded", ErrInvalidImport, missing)
+		}
+		committed, err := s.importRepo.CommitImportSession(id, req.ChunkCount)
+		if err != nil {
+			return nil, err
+		}
+		if !committed {
+			// Someone else committed it first
+			return s.importRepo.GetImportSession(id)
+		}
+	}
+
+	job, err := s.jobService.Enqueue(ImportJobType, importJob{SessionID: id})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.importRepo.SetImportJob(id, job.ID); err != nil {
+		return nil, err
+	}
+	return s.importRepo.GetImportSession(id)
+}
+
+// missingChunks lists the chunks below count not in received, which is sorted.
+func missingChunks(received []int, count int) []int {
+	var missing []int
+	for seq := 0; seq < count; seq++ {
+		if _, found := slices.BinarySearch(received, seq); !found {
+			missing = append(missing, seq)
+		}
+	}
+	return missing
+}
+
+// runImportJob imports the session's rows from where it last got to, so a job cut short by its
+// lease picks up after the last row it imported. A row that can't be imported fails the session
+// rather than the job, as retrying it would fail the same way.
+func (s *importService) runImportJob(ctx context.Context, payload json.RawMessage) error {
+	var job importJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("invalid import job payload: %w", err)
+	}
+
+	session, err := s.importRepo.GetImportSession(job.SessionID)
+	if err != nil {
+		return err
+	}
+	if session.Status != repository.ImportCommitted {
+		return nil
+	}
+	creator, err := s.userService.GetUser(session.CreatedBy)
+	if err != nil {
+		return fmt.Errorf("failed to get creator of import session %d: %w", session.ID, err)
+	}
+
+	data, err := s.importRepo.GetImportData(session.ID, session.ChunkCount)
+	if err != nil {
+		return err
+	}
+	reqs, err := parseImportCSV(data, creator.Email, s.currencies)
+	if err != nil {
+		return s.importRepo.FinishImportSession(session.ID, repository.ImportFailed, err.Error())
+	}
+
+	if err := ReportJobProgress(ctx, session.RowsImported, len(reqs)); err != nil {
+		return err
+	}
+	for i := session.RowsImported; i < len(reqs); i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := s.expenseService.CreateExpense(reqs[i]); err != nil {
+			if repository.IsQueryTimeout(err) {
+				return err
+			}
+			return s.importRepo.FinishImportSession(session.ID, repository.ImportFailed, fmt.Sprintf("row %d: %v", i+1, err))
+		}
+		if err := s.importRepo.SetImportProgress(session.ID, i+1); err != nil {
+			return err
+		}
+		if (i+1)%importProgressEvery == 0 {
+			if err := ReportJobProgress(ctx, i+1, len(reqs)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := ReportJobProgress(ctx, len(reqs), len(reqs)); err != nil {
+		return err
+	}
+	return s.importRepo.FinishImportSession(session.ID, repository.ImportDone, "")
+}
+
+// parseImportCSV reads an import file into the expenses it describes, created by createdBy. Rows
+// are numbered from 1 after the header in errors, and amounts may have no more decimal places than
+// currencies gives their currency.
+func parseImportCSV(data []byte, createdBy string, currencies util.Currencies) ([]CreateExpenseRequest, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("the file is empty")
+		}
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"description", "amount", "paid_by", "split_between"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("the header has no %s column", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var reqs []CreateExpenseRequest
+	for row := 1; ; row++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := importRow(record, field, createdBy, currencies)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row, err)
+		}
+		reqs = append(reqs, req)
+	}
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("the file has no rows")
+	}
+	return reqs, nil
+}
+
+func importRow(record []string, field func([]string, string) string, createdBy string, currencies util.Currencies) (CreateExpenseRequest, error) {
+	currency := strings.ToUpper(field(record, "currency"))
+	if currency == "" {
+		currency = util.DefaultCurrency
+	}
+	if err := checkBalanceCurrency(currency); err != nil {
+		return CreateExpenseRequest{}, err
+	}
+	amount, err := strconv.ParseFloat(field(record, "amount"), 64)
+	if err != nil || amount <= 0 {
+		return CreateExpenseRequest{}, fmt.Errorf("amount must be a positive number")
+	}
+	if exp := currencies.Exponent(currency); util.RoundToCurrency(amount, exp) != amount {
+		return CreateExpenseRequest{}, fmt.Errorf("amount has more decimal places than %s allows (%d)", currency, exp)
+	}
+
+	description := field(record, "description")
+	if description == "" {
+		return CreateExpenseRequest{}, fmt.Errorf("description is required")
+	}
+	paidBy := util.NormalizeEmail(field(record, "paid_by"))
+	if paidBy == "" {
+		return CreateExpenseRequest{}, fmt.Errorf("paid_by is required")
+	}
+
+	var splits []EqualSplitRequest
+	seen := util.NewSet[string]()
+	payerIncluded := false
+	for _, email := range strings.Split(field(record, "split_between"), ";") {
+		email = util.NormalizeEmail(email)
+		if email == "" {
+			continue
+		}
+		if seen.IsMember(email) {
+			return CreateExpenseRequest{}, fmt.Errorf("%s appears twice in split_between", email)
+		}
+		seen.Add(email)
+		split := EqualSplitRequest{UserEmail: email}
+		if email == paidBy {
+			split.AmountPaid = amount
+			payerIncluded = true
+		}
+		splits = append(splits, split)
+	}
+	if !payerIncluded {
+		return CreateExpenseRequest{}, fmt.Errorf("split_between must include paid_by")
+	}
+
+	return CreateExpenseRequest{
+		Description:    description,
+		Tag:            field(record, "tag"),
+		TotalAmount:    amount,
+		Currency:       currency,
+		CreatedByEmail: createdBy,
+		SplitMethod:    SplitMethodEqual,
+		EqualSplits:    splits,
+	}, nil
+}
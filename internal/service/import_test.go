@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingExpenseService records the expenses it is asked to create, refusing those described as
+// refused.
+type recordingExpenseService struct {
+	ExpenseService
+	created []CreateExpenseRequest
+}
+
+func (s *recordingExpenseService) CreateExpense(req CreateExpenseRequest) (*repository.Expense, error) {
+	if req.Description == "refused" {
+		return nil, errors.New("user with email zoe@example.com not found")
+	}
+	s.created = append(s.created, req)
+	return &repository.Expense{ID: len(s.created)}, nil
+}
+
+func TestParseImportCSV(t *testing.T) {
+	// Test case 1: Columns in any order, optional ones left out or blank
+	{
+		data := "Amount,description,split_between,paid_by,currency\n" +
+			"30.00,Dinner,alice@example.com; Bob@example.com,alice@example.com,\n" +
+			"\"1,500\",Ramen,bob@example.com;alice@example.com,bob@example.com,INR\n"
+		_, err := parseImportCSV([]byte(data), "carol@example.com", seededCurrencies)
+		assert.EqualError(t, err, "row 2: amount must be a positive number")
+
+		data = "Amount,description,split_between,paid_by,currency\n" +
+			"30.00,Dinner,alice@example.com; Bob@example.com,alice@example.com,\n" +
+			"1500,Ramen,bob@example.com;alice@example.com,bob@example.com,inr\n"
+		reqs, err := parseImportCSV([]byte(data), "carol@example.com", seededCurrencies)
+		require.NoError(t, err)
+		assert.Equal(t, []CreateExpenseRequest{
+			{Description: "Dinner", TotalAmount: 30, Currency: "INR", CreatedByEmail: "carol@example.com", SplitMethod: SplitMethodEqual,
+				EqualSplits: []EqualSplitRequest{{UserEmail: "alice@example.com", AmountPaid: 30}, {UserEmail: "bob@example.com"}}},
+			{Description: "Ramen", TotalAmount: 1500, Currency: "INR", CreatedByEmail: "carol@example.com", SplitMethod: SplitMethodEqual,
+				EqualSplits: []EqualSplitRequest{{UserEmail: "bob@example.com", AmountPaid: 1500}, {UserEmail: "alice@example.com"}}},
+		}, reqs)
+	}
+
+	// Test case 2: Refusals name the row at fault
+	for data, want := range map[string]string{
+		"":                             "the file is empty",
+		"description,amount,paid_by\n": "the header has no split_between column",
+		"description,amount,paid_by,split_between\n":                                                                       "the file has no rows",
+		"description,amount,paid_by,split_between\nTaxi,12.345,a@example.com,a@example.com\n":                              "row 1: amount has more decimal places than INR allows (2)",
+		"description,amount,paid_by,split_between\nTaxi,12,a@example.com,b@example.com\n":                                  "row 1: split_between must include paid_by",
+		"description,amount,paid_by,split_between,currency\nTaxi,1500,a@example.com,a@example.com,JPY\n":                   "row 1: currency not supported: JPY, balances are kept in INR",
+		"description,amount,paid_by,split_between\nTaxi,12,a@example.com,a@example.com;A@example.com\n":                    "row 1: a@example.com appears twice in split_between",
+		"description,amount,paid_by,split_between\nTaxi,12,a@example.com,a@example.com\n,12,a@example.com,a@example.com\n": "row 2: description is required",
+	} {
+		_, err := parseImportCSV([]byte(data), "carol@example.com", seededCurrencies)
+		assert.EqualError(t, err, want)
+	}
+}
+
+func TestImportService_CommitImport(t *testing.T) {
+	importRepo := new(repomock.ImportRepository)
+	jobRepo := new(repomock.JobRepository)
+	s := NewImportService(importRepo, nil, nil, NewJobService(jobRepo, JobOptions{MaxAttempts: 3}), nil, seededCurrencies)
+
+	// Test case 1: Every chunk must be in before committing
+	{
+		importRepo.On("GetImportSession", 1).Return(&repository.ImportSession{ID: 1, Status: repository.ImportOpen, ReceivedChunks: []int{0, 2}}, nil).Once()
+		_, err := s.CommitImport(1, CommitImportRequest{ChunkCount: 4})
+		assert.ErrorIs(t, err, ErrInvalidImport)
+		assert.ErrorContains(t, err, "chunks [1 3] have not been uploaded")
+	}
+
+	// Test case 2: Committing queues the job and records it on the session
+	{
+		jobID := int64(9)
+		importRepo.On("GetImportSession", 1).Return(&repository.ImportSession{ID: 1, Status: repository.ImportOpen, ReceivedChunks: []int{0, 1, 2}}, nil).Once()
+		importRepo.On("CommitImportSession", 1, 3).Return(true, nil).Once()
+		jobRepo.On("CreateJob", mock.MatchedBy(func(j *repository.Job) bool {
+			return j.Type == ImportJobType && string(j.Payload) == `{"session_id":1}`
+		})).Return(&repository.Job{ID: jobID}, nil).Once()
+		importRepo.On("SetImportJob", 1, jobID).Return(nil).Once()
+		importRepo.On("GetImportSession", 1).Return(&repository.ImportSession{ID: 1, Status: repository.ImportCommitted, ChunkCount: 3, JobID: &jobID}, nil).Once()
+
+		session, err := s.CommitImport(1, CommitImportRequest{ChunkCount: 3})
+		require.NoError(t, err)
+		assert.Equal(t, &jobID, session.JobID)
+	}
+
+	// Test case 3: Committing again, say after a lost response, changes nothing
+	{
+		jobID := int64(9)
+		committed := &repository.ImportSession{ID: 1, Status: repository.ImportCommitted, ChunkCount: 3, JobID: &jobID}
+		importRepo.On("GetImportSession", 1).Return(committed, nil).Once()
+		session, err := s.CommitImport(1, CommitImportRequest{ChunkCount: 3})
+		require.NoError(t, err)
+		assert.Equal(t, committed, session)
+	}
+
+	// Test case 4: A failed session resumes with the chunks it was committed with
+	{
+		importRepo.On("GetImportSession", 2).Return(&repository.ImportSession{ID: 2, Status: repository.ImportFailed, ChunkCount: 3, ReceivedChunks: []int{0, 1, 2}}, nil).Once()
+		_, err := s.CommitImport(2, CommitImportRequest{ChunkCount: 2})
+		assert.ErrorIs(t, err, ErrInvalidImport)
+	}
+
+	// Test case 5: Nothing can be sent to a session already imported
+	{
+		importRepo.On("GetImportSession", 3).Return(&repository.ImportSession{ID: 3, Status: repository.ImportDone}, nil).Twice()
+		_, err := s.CommitImport(3, CommitImportRequest{ChunkCount: 1})
+		assert.ErrorIs(t, err, ErrImportNotOpen)
+		_, err = s.UploadChunk(3, 0, []byte("x"))
+		assert.ErrorIs(t, err, ErrImportNotOpen)
+	}
+
+	importRepo.AssertExpectations(t)
+	jobRepo.AssertExpectations(t)
+}
+
+func TestImportService_RunImportJob(t *testing.T) {
+	carol := &repository.User{ID: 3, Name: "Carol", Email: "carol@example.com"}
+	data := "description,amount,paid_by,split_between\n" +
+		"Dinner,30,alice@example.com,alice@example.com;bob@example.com\n" +
+		"Taxi,12,bob@example.com,alice@example.com;bob@example.com\n" +
+		"refused,5,alice@example.com,alice@example.com;zoe@example.com\n" +
+		"Lunch,20,alice@example.com,alice@example.com;bob@example.com\n"
+	payload, _ := json.Marshal(importJob{SessionID: 1})
+
+	importRepo := new(repomock.ImportRepository)
+	userService := new(MockUserService)
+	expenses := &recordingExpenseService{}
+	s := NewImportService(importRepo, expenses, userService, NewJobService(new(repomock.JobRepository), JobOptions{}), nil, seededCurrencies).(*importService)
+	userService.On("GetUser", carol.ID).Return(carol, nil)
+	importRepo.On("GetImportData", 1, 2).Return([]byte(data), nil)
+
+	// Test case 1: A retried job carries on after the rows already imported, and stops the session at
+	// a row it can't import
+	importRepo.On("GetImportSession", 1).Return(&repository.ImportSession{ID: 1, CreatedBy: carol.ID, Status: repository.ImportCommitted, ChunkCount: 2, RowsImported: 1}, nil).Once()
+	importRepo.On("SetImportProgress", 1, 2).Return(nil).Once()
+	importRepo.On("FinishImportSession", 1, repository.ImportFailed, "row 3: user with email zoe@example.com not found").Return(nil).Once()
+
+	require.NoError(t, s.runImportJob(context.Background(), payload))
+	require.Len(t, expenses.created, 1)
+	assert.Equal(t, "Taxi", expenses.created[0].Description)
+	assert.Equal(t, "carol@example.com", expenses.created[0].CreatedByEmail)
+
+	// Test case 2: A job for a session no longer committed does nothing
+	importRepo.On("GetImportSession", 1).Return(&repository.ImportSession{ID: 1, Status: repository.ImportFailed}, nil).Once()
+	require.NoError(t, s.runImportJob(context.Background(), payload))
+	assert.Len(t, expenses.created, 1)
+
+	importRepo.AssertExpectations(t)
+}
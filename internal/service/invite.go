@@ -0,0 +1,292 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// invitePrefix scopes invite tokens, so no other signed token can stand in for one.
+const invitePrefix = "expense-invite."
+
+// inviteQRScale is the size in pixels of each module of an invite's QR code.
+const inviteQRScale = 8
+
+// ErrInvitesDisabled is returned when no key for signing invite links is configured.
+var ErrInvitesDisabled = errors.New("invite links are not enabled")
+
+// ErrInvalidInviteToken is returned for an invite token that is malformed or wrongly signed.
+var ErrInvalidInviteToken = errors.New("invite link is not valid")
+
+// ErrInviteExpired is returned for an invite link past its expiry.
+var ErrInviteExpired = errors.New("invite link has expired")
+
+type CreateExpenseInviteRequest struct {
+	UserEmail string `json:"user_email"`
+}
+
+type ClaimExpenseInviteRequest struct {
+	UserEmail string `json:"user_email"`
+}
+
+// CreatedExpenseInvite is a new invite link to an expense. URL is what its QR code holds.
+type CreatedExpenseInvite struct {
+	ExpenseID int       `json:"expense_id"`
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	QRCodeURL string    `json:"qr_code_url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ExpenseInvite is what someone who scans an invite sees before claiming. People are shown by name
+// only, since whoever holds the link need not be a participant.
+type ExpenseInvite struct {
+	ExpenseID   int           `json:"expense_id"`
+	Description string        `json:"description"`
+	TotalAmount float64       `json:"total_amount"`
+	Currency    string        `json:"currency"`
+	CreatedBy   string        `json:"created_by"`
+	EventName   string        `json:"event_name,omitempty"`
+	ExpiresAt   time.Time     `json:"expires_at"`
+	Shares      []InviteShare `json:"shares"`
+}
+
+type InviteShare struct {
+	Name       string  `json:"name"`
+	AmountOwed float64 `json:"amount_owed"`
+	Claimed    bool    `json:"claimed"`
+}
+
+// InviteClaim is the outcome of following an invite: a participant claims their share, and anyone
+// else joins the expense's event.
+type InviteClaim struct {
+	ExpenseID     int        `json:"expense_id"`
+	AmountOwed    *float64   `json:"amount_owed,omitempty"`
+	ClaimedAt     *time.Time `json:"claimed_at,omitempty"`
+	JoinedEventID *int       `json:"joined_event_id,omitempty"`
+}
+
+// InviteService hands out signed, expiring invite links to an expense, with QR codes to scan them,
+// and lets the people who follow them claim their share or join the expense's event.
+type InviteService interface {
+	CreateExpenseInvite(expenseID int, req CreateExpenseInviteRequest) (*CreatedExpenseInvite, error)
+	GetExpenseInvite(token string) (*ExpenseInvite, error)
+	// InviteQRCode renders the invite's link as a QR code PNG.
+	InviteQRCode(token string) ([]byte, error)
+	ClaimExpenseInvite(token string, req ClaimExpenseInviteRequest) (*InviteClaim, error)
+}
+
+type inviteService struct {
+	expenseRepo repository.ExpenseRepository
+	eventRepo   repository.EventRepository
+	userService UserService
+	signer      *util.Signer
+	ttl         time.Duration
+	baseURL     string
+	now         func() time.Time
+}
+
+// NewInviteService builds the invite service. Links last ttl and start with baseURL, this server's
+// public address. An empty secret disables invites.
+func NewInviteService(expenseRepo repository.ExpenseRepository, eventRepo repository.EventRepository, userService UserService, secret string, ttl time.Duration, baseURL string) InviteService {
+	return &inviteService{
+		expenseRepo: expenseRepo,
+		eventRepo:   eventRepo,
+		userService: userService,
+		signer:      util.NewSigner(secret),
+		ttl:         ttl,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		now:         time.Now,
+	}
+}
+
+func (s *inviteService) CreateExpenseInvite(expenseID int, req CreateExpenseInviteRequest) (*CreatedExpenseInvite, error) {
+	if !s.signer.Enabled() {
+		return nil, ErrInvitesDisabled
+	}
+
+	expense, err := s.expenseRepo.GetExpense(expenseID)
+	if err != nil {
+		return nil, err
+	}
+	splits, err := s.expenseRepo.GetExpenseSplits(expenseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get splits for expense %d: %w", expenseID, err)
+	}
+	users, err := s.userService.GetUsersByEmails([]string{req.UserEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", req.UserEmail)
+	}
+	participant := expense.CreatedBy == users[0].ID
+	for _, split := range splits {
+		participant = participant || split.UserID == users[0].ID
+	}
+	if !participant {
+		return nil, fmt.Errorf("%w: %s is not a participant of expense %d", ErrNotExpenseParticipant, req.UserEmail, expenseID)
+	}
+
+	// Whole seconds, so the expiry in the token matches the one reported
+	expiresAt := s.now().Add(s.ttl).Truncate(time.Second)
+	token := s.signer.Sign(invitePrefix + strconv.Itoa(expenseID) + "." + strconv.FormatInt(expiresAt.Unix(), 10))
+	return &CreatedExpenseInvite{
+		ExpenseID: expenseID,
+		Token:     token,
+		URL:       s.link(token),
+		QRCodeURL: s.link(token) + "/qr.png",
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (s *inviteService) GetExpenseInvite(token string) (*ExpenseInvite, error) {
+	expenseID, expiresAt, err := s.verify(token)
+	if err != nil {
+		return nil, err
+	}
+	expense, err := s.expenseRepo.GetExpense(expenseID)
+	if err != nil {
+		return nil, err
+	}
+	splits, err := s.expenseRepo.GetExpenseSplits(expenseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get splits for expense %d: %w", expenseID, err)
+	}
+	claims, err := s.expenseRepo.GetShareClaims(expenseID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := util.NewSet(expense.CreatedBy)
+	for _, split := range splits {
+		ids.Add(split.UserID)
+	}
+	users, err := s.userService.GetUsersByIDs(ids.ToList())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get participants of expense %d: %w", expenseID, err)
+	}
+	names := make(map[int]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.Name
+	}
+
+	invite := &ExpenseInvite{
+		ExpenseID:   expense.ID,
+		Description: expense.Description,
+		TotalAmount: expense.TotalAmount,
+		Currency:    expense.Currency,
+		CreatedBy:   names[expense.CreatedBy],
+		ExpiresAt:   expiresAt,
+		Shares:      make([]InviteShare, 0, len(splits)),
+	}
+	if expense.EventID != nil {
+		event, err := s.eventRepo.GetEvent(*expense.EventID)
+		if err != nil {
+			return nil, err
+		}
+		invite.EventName = event.Name
+	}
+	for _, split := range splits {
+		_, claimed := claims[split.UserID]
+		invite.Shares = append(invite.Shares, InviteShare{Name: names[split.UserID], AmountOwed: split.AmountOwed, Claimed: claimed})
+	}
+	return invite, nil
+}
+
+func (s *inviteService) InviteQRCode(token string) ([]byte, error) {
+	if _, _, err := s.verify(token); err != nil {
+		return nil, err
+	}
+	code, err := util.EncodeQR([]byte(s.link(token)))
+	if err != nil {
+		return nil, err
+	}
+	return code.PNG(inviteQRScale)
+}
+
+func (s *inviteService) ClaimExpenseInvite(token string, req ClaimExpenseInviteRequest) (*InviteClaim, error) {
+	expenseID, _, err := s.verify(token)
+	if err != nil {
+		return nil, err
+	}
+	expense, err := s.expenseRepo.GetExpense(expenseID)
+	if err != nil {
+		return nil, err
+	}
+	splits, err := s.expenseRepo.GetExpenseSplits(expenseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get splits for expense %d: %w", expenseID, err)
+	}
+	users, err := s.userService.GetUsersByEmails([]string{req.UserEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", req.UserEmail)
+	}
+	user := users[0]
+
+	claim := &InviteClaim{ExpenseID: expenseID}
+	for _, split := range splits {
+		if split.UserID != user.ID {
+			continue
+		}
+		claimedAt, err := s.expenseRepo.ClaimShare(expenseID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		owed := split.AmountOwed
+		claim.AmountOwed, claim.ClaimedAt = &owed, &claimedAt
+		return claim, nil
+	}
+
+	// Not in the split, so the most they can do is join the event the expense belongs to
+	if expense.EventID == nil {
+		return nil, fmt.Errorf("%w: %s is not a participant of expense %d", ErrNotExpenseParticipant, req.UserEmail, expenseID)
+	}
+	event, err := s.eventRepo.GetEvent(*expense.EventID)
+	if err != nil {
+		return nil, err
+	}
+	if event.ArchivedAt != nil {
+		return nil, fmt.Errorf("%w: %s", ErrEventArchived, event.Name)
+	}
+	if err := s.eventRepo.AddEventMember(event.ID, user.ID); err != nil {
+		return nil, err
+	}
+	claim.JoinedEventID = &event.ID
+	return claim, nil
+}
+
+func (s *inviteService) link(token string) string {
+	return s.baseURL + "/invites/" + token
+}
+
+// verify checks an invite token's signature and expiry, and returns the expense ID and expiry it carries.
+func (s *inviteService) verify(token string) (int, time.Time, error) {
+	if !s.signer.Enabled() {
+		return 0, time.Time{}, ErrInvitesDisabled
+	}
+	payload, ok := s.signer.Verify(token)
+	if !ok {
+		return 0, time.Time{}, ErrInvalidInviteToken
+	}
+	payload, scoped := strings.CutPrefix(payload, invitePrefix)
+	idPart, expiryPart, ok := strings.Cut(payload, ".")
+	if !scoped || !ok {
+		return 0, time.Time{}, ErrInvalidInviteToken
+	}
+	id, err := strconv.Atoi(idPart)
+	if err != nil {
+		return 0, time.Time{}, ErrInvalidInviteToken
+	}
+	expiry, err := strconv.ParseInt(expiryPart, 10, 64)
+	if err != nil {
+		return 0, time.Time{}, ErrInvalidInviteToken
+	}
+	expiresAt := time.Unix(expiry, 0)
+	if !s.now().Before(expiresAt) {
+		return 0, time.Time{}, ErrInviteExpired
+	}
+	return id, expiresAt, nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testInviteSecret = "test-secret-0123456789abcdef0123"
+
+func TestInviteService_Tokens(t *testing.T) {
+	expenseRepo := new(repomock.ExpenseRepository)
+	userService := new(MockUserService)
+	s := NewInviteService(expenseRepo, nil, userService, testInviteSecret, time.Hour, "https://split.example/").(*inviteService)
+	now := time.Unix(1_800_000_000, 0)
+	s.now = func() time.Time { return now }
+
+	alice := &repository.User{ID: 1, Email: "alice@example.com"}
+	eve := &repository.User{ID: 9, Email: "eve@example.com"}
+	expense := &repository.Expense{ID: 5, CreatedBy: alice.ID}
+	splits := []repository.ExpenseSplit{{ExpenseID: 5, UserID: alice.ID}, {ExpenseID: 5, UserID: 2}}
+
+	// Test case 1: Outsiders cannot hand out invites
+	expenseRepo.On("GetExpense", 5).Return(expense, nil)
+	expenseRepo.On("GetExpenseSplits", 5).Return(splits, nil)
+	userService.On("GetUsersByEmails", []string{eve.Email}).Return([]*repository.User{eve}, nil).Once()
+	_, err := s.CreateExpenseInvite(5, CreateExpenseInviteRequest{UserEmail: eve.Email})
+	assert.ErrorIs(t, err, ErrNotExpenseParticipant)
+
+	// Test case 2: Participants can, and the token round-trips until it expires
+	userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+	invite, err := s.CreateExpenseInvite(5, CreateExpenseInviteRequest{UserEmail: alice.Email})
+	require.NoError(t, err)
+	assert.Equal(t, "https://split.example/invites/"+invite.Token, invite.URL)
+	assert.Equal(t, invite.URL+"/qr.png", invite.QRCodeURL)
+	id, expiresAt, err := s.verify(invite.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, id)
+	assert.True(t, expiresAt.Equal(now.Add(time.Hour)))
+	s.now = func() time.Time { return now.Add(time.Hour) }
+	_, _, err = s.verify(invite.Token)
+	assert.ErrorIs(t, err, ErrInviteExpired)
+	s.now = func() time.Time { return now }
+
+	// Test case 3: Tampered tokens, and other signed tokens, are not invites
+	signer := util.NewSigner(testInviteSecret)
+	for _, bad := range []string{
+		strings.Replace(invite.Token, "invite.5.", "invite.6.", 1),
+		signer.Sign("5.1800003600"),
+		signer.Sign(unsubscribePrefix + "5"),
+		"garbage",
+	} {
+		_, _, err := s.verify(bad)
+		assert.True(t, errors.Is(err, ErrInvalidInviteToken), bad)
+	}
+
+	// Test case 4: The QR code holds the link
+	png, err := s.InviteQRCode(invite.Token)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(png), "\x89PNG"))
+
+	// Test case 5: Without a key, invites are off
+	_, err = NewInviteService(nil, nil, nil, "", time.Hour, "").CreateExpenseInvite(5, CreateExpenseInviteRequest{UserEmail: alice.Email})
+	assert.ErrorIs(t, err, ErrInvitesDisabled)
+
+	userService.AssertExpectations(t)
+}
+
+func TestInviteService_ClaimExpenseInvite(t *testing.T) {
+	expenseRepo := new(repomock.ExpenseRepository)
+	eventRepo := new(repomock.EventRepository)
+	userService := new(MockUserService)
+	s := NewInviteService(expenseRepo, eventRepo, userService, testInviteSecret, time.Hour, "").(*inviteService)
+
+	bob := &repository.User{ID: 2, Email: "bob@example.com"}
+	carol := &repository.User{ID: 3, Email: "carol@example.com"}
+	eventID := 7
+	expense := &repository.Expense{ID: 5, CreatedBy: 1, EventID: &eventID}
+	splits := []repository.ExpenseSplit{{ExpenseID: 5, UserID: 1, AmountOwed: 30}, {ExpenseID: 5, UserID: bob.ID, AmountOwed: 30}}
+	expenseRepo.On("GetExpense", 5).Return(expense, nil)
+	expenseRepo.On("GetExpenseSplits", 5).Return(splits, nil)
+	token := s.signer.Sign(invitePrefix + "5.1800003600")
+	s.now = func() time.Time { return time.Unix(1_800_000_000, 0) }
+
+	// Test case 1: A participant claims their share
+	claimedAt := time.Unix(1_800_000_000, 0)
+	userService.On("GetUsersByEmails", []string{bob.Email}).Return([]*repository.User{bob}, nil).Once()
+	expenseRepo.On("ClaimShare", 5, bob.ID).Return(claimedAt, nil).Once()
+	claim, err := s.ClaimExpenseInvite(token, ClaimExpenseInviteRequest{UserEmail: bob.Email})
+	require.NoError(t, err)
+	assert.Equal(t, 30.0, *claim.AmountOwed)
+	assert.Nil(t, claim.JoinedEventID)
+
+	// Test case 2: Anyone else joins the expense's event
+	userService.On("GetUsersByEmails", []string{carol.Email}).Return([]*repository.User{carol}, nil).Once()
+	eventRepo.On("GetEvent", eventID).Return(&repository.Event{ID: eventID}, nil).Once()
+	eventRepo.On("AddEventMember", eventID, carol.ID).Return(nil).Once()
+	claim, err = s.ClaimExpenseInvite(token, ClaimExpenseInviteRequest{UserEmail: carol.Email})
+	require.NoError(t, err)
+	assert.Nil(t, claim.AmountOwed)
+	assert.Equal(t, eventID, *claim.JoinedEventID)
+
+	// Test case 3: But not once it is archived
+	archivedAt := time.Unix(1_799_000_000, 0)
+	userService.On("GetUsersByEmails", []string{carol.Email}).Return([]*repository.User{carol}, nil).Once()
+	eventRepo.On("GetEvent", eventID).Return(&repository.Event{ID: eventID, ArchivedAt: &archivedAt}, nil).Once()
+	_, err = s.ClaimExpenseInvite(token, ClaimExpenseInviteRequest{UserEmail: carol.Email})
+	assert.ErrorIs(t, err, ErrEventArchived)
+
+	expenseRepo.AssertExpectations(t)
+	eventRepo.AssertExpectations(t)
+	userService.AssertExpectations(t)
+}
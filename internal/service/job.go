@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+// maxJobBackoff caps the delay between retries of a failing job.
+const maxJobBackoff = time.Hour
+
+var ErrUnknownJobType = errors.New("unknown job type")
+
+// JobFunc runs a single job. Returning an error schedules a retry until the job runs out of attempts.
+// The context is cancelled when the job's lease is over.
+type JobFunc func(ctx context.Context, payload json.RawMessage) error
+
+type jobProgressKey struct{}
+
+// ReportJobProgress records how far the job running with ctx has got, for clients polling it through
+// GET /jobs/{id}. Outside a job it does nothing.
+func ReportJobProgress(ctx context.Context, done, total int) error {
+	report, ok := ctx.Value(jobProgressKey{}).(func(done, total int) error)
+	if !ok {
+		return nil
+	}
+	return report(done, total)
+}
+
+// JobOptions tunes the job runner.
+type JobOptions struct {
+	MaxAttempts  int           // Runs before a job is moved to dead
+	PollInterval time.Duration // How long the runner waits when no job is due
+	Lease        time.Duration // How long a run may take before the job is handed out again
+	BaseBackoff  time.Duration // Delay before the first retry, doubled for every further one
+}
+
+type JobService interface {
+	// Register sets the function that runs jobs of the given type. It must be called before Run.
+	Register(jobType string, fn JobFunc)
+	Enqueue(jobType string, payload interface{}) (*repository.Job, error)
+	// EnqueueAt is Enqueue for a job that must not run before runAt.
+	EnqueueAt(jobType string, payload interface{}, runAt time.Time) (*repository.Job, error)
+	GetJob(id int64) (*repository.Job, error)
+	// Run processes due jobs one at a time until ctx is cancelled. Cancelling stops new jobs from
+	// being claimed but lets the one in flight finish, so Run may return some time after.
+	Run(ctx context.Context)
+}
+
+type jobService struct {
+	jobRepo repository.JobRepository
+	opts    JobOptions
+	now     func() time.Time
+
+	mu    sync.RWMutex
+	funcs map[string]JobFunc
+}
+
+func NewJobService(jobRepo repository.JobRepository, opts JobOptions) JobService {
+	return &jobService{jobRepo: jobRepo, opts: opts, now: time.Now, funcs: make(map[string]JobFunc)}
+}
+
+func (s *jobService) Register(jobType string, fn JobFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.funcs[jobType] = fn
+}
+
+func (s *jobService) jobFunc(jobType string) (JobFunc, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn, ok := s.funcs[jobType]
+	return fn, ok
+}
+
+func (s *jobService) Enqueue(jobType string, payload interface{}) (*repository.Job, error) {
+	return s.EnqueueAt(jobType, payload, s.now())
+}
+
+func (s *jobService) EnqueueAt(jobType string, payload interface{}, runAt time.Time) (*repository.Job, error) {
+	if _, ok := s.jobFunc(jobType); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownJobType, jobType)
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload for %s job: %w", jobType, err)
+	}
+
+	job, err := s.jobRepo.CreateJob(&repository.Job{Type: jobType, Payload: raw, MaxAttempts: s.opts.MaxAttempts, RunAt: runAt})
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue %s job: %w", jobType, err)
+	}
+	return job, nil
+}
+
+func (s *jobService) GetJob(id int64) (*repository.Job, error) {
+	return s.jobRepo.GetJob(id)
+}
+
+func (s *jobService) Run(ctx context.Context) {
+	for {
+		ran, err := s.runNext(ctx)
+		if err != nil {
+			log.Printf("job runner: %v", err)
+		}
+		if ran {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.opts.PollInterval):
+		}
+	}
+}
+
+// runNext claims and runs the next due job, reporting whether there was one.
+func (s *jobService) runNext(ctx context.Context) (bool, error) {
+	job, err := s.jobRepo.ClaimNextJob(s.now(), s.opts.Lease)
+	if err != nil {
+		return false, err
+	}
+	if job == nil {
+		return false, nil
+	}
+
+	if runErr := s.execute(ctx, job); runErr != nil {
+		var retryAt time.Time
+		if job.Attempts < job.MaxAttempts {
+			retryAt = s.now().Add(jobBackoff(s.opts.BaseBackoff, job.Attempts))
+		}
+		if err := s.jobRepo.FailJob(job.ID, runErr.Error(), retryAt); err != nil {
+			return true, err
+		}
+		if retryAt.IsZero() {
+			log.Printf("job %d (%s) moved to dead after %d attempts: %v", job.ID, job.Type, job.Attempts, runErr)
+		}
+		return true, nil
+	}
+
+	return true, s.jobRepo.CompleteJob(job.ID)
+}
+
+// execute runs the job's function within its lease, turning a panic into an ordinary failure.
+// Shutting down doesn't cut a job short; only the lease does.
+func (s *jobService) execute(ctx context.Context, job *repository.Job) (err error) {
+	fn, ok := s.jobFunc(job.Type)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownJobType, job.Type)
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.opts.Lease)
+	defer cancel()
+	ctx = context.WithValue(ctx, jobProgressKey{}, func(done, total int) error {
+		return s.jobRepo.SetJobProgress(job.ID, done, total)
+	})
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+	return fn(ctx, job.Payload)
+}
+
+// jobBackoff is the delay before retrying a job that has failed attempts times.
+func jobBackoff(base time.Duration, attempts int) time.Duration {
+	backoff := base
+	for i := 1; i < attempts && backoff < maxJobBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxJobBackoff {
+		return maxJobBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestJobService_Enqueue(t *testing.T) {
+	jobRepo := new(repomock.JobRepository)
+	jobService := NewJobService(jobRepo, JobOptions{MaxAttempts: 3}).(*jobService)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	jobService.now = func() time.Time { return now }
+	jobService.Register("export", func(ctx context.Context, payload json.RawMessage) error { return nil })
+
+	// Test case 1: Jobs without a registered function are refused up front
+	{
+		_, err := jobService.Enqueue("ocr", nil)
+		assert.ErrorIs(t, err, ErrUnknownJobType)
+	}
+
+	// Test case 2: The payload is stored as JSON
+	{
+		expected := &repository.Job{Type: "export", Payload: json.RawMessage(`{"user_email":"alice@example.com"}`), MaxAttempts: 3, RunAt: now}
+		jobRepo.On("CreateJob", expected).Return(&repository.Job{ID: 1, Type: "export", Status: repository.JobQueued}, nil).Once()
+
+		job, err := jobService.Enqueue("export", map[string]string{"user_email": "alice@example.com"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), job.ID)
+		jobRepo.AssertExpectations(t)
+	}
+}
+
+func TestJobService_RunNext(t *testing.T) {
+	jobRepo := new(repomock.JobRepository)
+	jobService := NewJobService(jobRepo, JobOptions{MaxAttempts: 3, Lease: time.Minute, BaseBackoff: 10 * time.Second}).(*jobService)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	jobService.now = func() time.Time { return now }
+
+	var runErr error
+	jobService.Register("notify", func(ctx context.Context, payload json.RawMessage) error {
+		return runErr
+	})
+	jobService.Register("explode", func(ctx context.Context, payload json.RawMessage) error {
+		panic("boom")
+	})
+
+	// Test case 1: Nothing due
+	{
+		jobRepo.On("ClaimNextJob", now, time.Minute).Return(nil, nil).Once()
+		ran, err := jobService.runNext(context.Background())
+		assert.NoError(t, err)
+		assert.False(t, ran)
+	}
+
+	// Test case 2: A successful run completes the job
+	{
+		jobRepo.On("ClaimNextJob", now, time.Minute).Return(&repository.Job{ID: 1, Type: "notify", Attempts: 1, MaxAttempts: 3}, nil).Once()
+		jobRepo.On("CompleteJob", int64(1)).Return(nil).Once()
+		ran, err := jobService.runNext(context.Background())
+		assert.NoError(t, err)
+		assert.True(t, ran)
+	}
+
+	// Test case 3: A failed second attempt is retried after twice the base backoff
+	{
+		runErr = errors.New("smtp unavailable")
+		jobRepo.On("ClaimNextJob", now, time.Minute).Return(&repository.Job{ID: 2, Type: "notify", Attempts: 2, MaxAttempts: 3}, nil).Once()
+		jobRepo.On("FailJob", int64(2), "smtp unavailable", now.Add(20*time.Second)).Return(nil).Once()
+		ran, err := jobService.runNext(context.Background())
+		assert.NoError(t, err)
+		assert.True(t, ran)
+	}
+
+	// Test case 4: The last attempt failing dead-letters the job
+	{
+		jobRepo.On("ClaimNextJob", now, time.Minute).Return(&repository.Job{ID: 3, Type: "notify", Attempts: 3, MaxAttempts: 3}, nil).Once()
+		jobRepo.On("FailJob", int64(3), "smtp unavailable", time.Time{}).Return(nil).Once()
+		_, err := jobService.runNext(context.Background())
+		assert.NoError(t, err)
+	}
+
+	// Test case 5: Panics and unknown types are ordinary failures
+	{
+		jobRepo.On("ClaimNextJob", now, time.Minute).Return(&repository.Job{ID: 4, Type: "explode", Attempts: 1, MaxAttempts: 3}, nil).Once()
+		jobRepo.On("FailJob", int64(4), "job panicked: boom", now.Add(10*time.Second)).Return(nil).Once()
+		_, err := jobService.runNext(context.Background())
+		assert.NoError(t, err)
+
+		jobRepo.On("ClaimNextJob", now, time.Minute).Return(&repository.Job{ID: 5, Type: "retired", Attempts: 1, MaxAttempts: 3}, nil).Once()
+		jobRepo.On("FailJob", int64(5), "unknown job type: retired", now.Add(10*time.Second)).Return(nil).Once()
+		_, err = jobService.runNext(context.Background())
+		assert.NoError(t, err)
+	}
+
+	// Test case 6: A job's progress is recorded against it
+	{
+		jobService.Register("import", func(ctx context.Context, payload json.RawMessage) error {
+			return ReportJobProgress(ctx, 100, 250)
+		})
+		jobRepo.On("ClaimNextJob", now, time.Minute).Return(&repository.Job{ID: 6, Type: "import", Attempts: 1, MaxAttempts: 3}, nil).Once()
+		jobRepo.On("SetJobProgress", int64(6), 100, 250).Return(nil).Once()
+		jobRepo.On("CompleteJob", int64(6)).Return(nil).Once()
+		_, err := jobService.runNext(context.Background())
+		assert.NoError(t, err)
+	}
+
+	jobRepo.AssertExpectations(t)
+}
+
+func TestJobBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, jobBackoff(10*time.Second, 1))
+	assert.Equal(t, 40*time.Second, jobBackoff(10*time.Second, 3))
+	assert.Equal(t, maxJobBackoff, jobBackoff(10*time.Second, 50))
+}
+
+func TestJobService_RunDrainsOnCancel(t *testing.T) {
+	jobRepo := new(repomock.JobRepository)
+	jobService := NewJobService(jobRepo, JobOptions{MaxAttempts: 3, Lease: time.Minute, PollInterval: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	jobService.Register("export", func(jobCtx context.Context, payload json.RawMessage) error {
+		// Shutdown begins while the job is running
+		cancel()
+		time.Sleep(10 * time.Millisecond)
+		return jobCtx.Err()
+	})
+
+	jobRepo.On("ClaimNextJob", mock.Anything, time.Minute).Return(&repository.Job{ID: 1, Type: "export", Attempts: 1, MaxAttempts: 3}, nil).Once()
+	jobRepo.On("CompleteJob", int64(1)).Return(nil).Once()
+
+	jobService.Run(ctx)
+
+	// The job finished normally and nothing further was claimed
+	jobRepo.AssertExpectations(t)
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// LedgerLine is one of a user's ledger entries. Amount is positive when it left the other user
+// owing more, and Balance is the user's overall balance once it was posted.
+type LedgerLine struct {
+	ID            int64                   `json:"id"`
+	Source        repository.LedgerSource `json:"source"`
+	WithUserEmail string                  `json:"with_user_email"`
+	WithUserName  string                  `json:"with_user_name"`
+	Amount        float64                 `json:"amount"`
+	Balance       float64                 `json:"balance"`
+	CreatedAt     time.Time               `json:"created_at"`
+}
+
+// LedgerStatement is a user's ledger up to AsOf, with the balances it adds up to at that time.
+type LedgerStatement struct {
+	UserEmail string            `json:"user_email"`
+	AsOf      time.Time         `json:"as_of"`
+	Entries   []LedgerLine      `json:"entries"`
+	Balances  []UserBalanceView `json:"balances"`
+	Overall   float64           `json:"overall"`
+}
+
+// LedgerService reads the double-entry ledger that balances are derived from.
+type LedgerService interface {
+	// GetStatement returns the user's ledger as it stood at asOf, or now when asOf is zero.
+	GetStatement(userEmail string, asOf time.Time) (*LedgerStatement, error)
+	// GetAgingReport buckets the user's outstanding balances by the age of the oldest part of each
+	// that hasn't been paid off.
+	GetAgingReport(userEmail string) (*AgingReport, error)
+	// CheckBalances lists the balances that no longer match the ledger.
+	CheckBalances() ([]repository.BalanceDrift, error)
+	// RebuildBalances brings the balances that drifted back in line with the ledger.
+	RebuildBalances() ([]repository.BalanceDrift, error)
+}
+
+type ledgerService struct {
+	ledgerRepo  repository.LedgerRepository
+	userService UserService
+	now         func() time.Time
+}
+
+func NewLedgerService(ledgerRepo repository.LedgerRepository, userService UserService) LedgerService {
+	return &ledgerService{ledgerRepo: ledgerRepo, userService: userService, now: time.Now}
+}
+
+func (s *ledgerService) GetStatement(userEmail string, asOf time.Time) (*LedgerStatement, error) {
+	users, err := s.userService.GetUsersByEmails([]string{userEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", userEmail)
+	}
+	userID := users[0].ID
+	if asOf.IsZero() {
+		asOf = s.now()
+	}
+
+	entries, err := s.ledgerRepo.GetEntries(userID, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger for user %s: %w", userEmail, err)
+	}
+	balances, err := s.ledgerRepo.GetBalancesAt(userID, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances for user %s at %s: %w", userEmail, asOf, err)
+	}
+
+	otherIDs := util.NewSet[int]()
+	var ids []int
+	for _, e := range entries {
+		if !otherIDs.IsMember(e.CounterpartyID) {
+			otherIDs.Add(e.CounterpartyID)
+			ids = append(ids, e.CounterpartyID)
+		}
+	}
+	others := make(map[int]*repository.User)
+	if len(ids) > 0 {
+		found, err := s.userService.GetUsersByIDs(ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch other users for ledger: %w", err)
+		}
+		for _, u := range found {
+			others[u.ID] = u
+		}
+	}
+	describe := func(id int) (string, string) {
+		if u, ok := others[id]; ok {
+			return u.Email, u.Name
+		}
+		return fmt.Sprintf("unknown_user_%d", id), "Unknown"
+	}
+
+	statement := &LedgerStatement{
+		UserEmail: users[0].Email,
+		AsOf:      asOf,
+		Entries:   make([]LedgerLine, 0, len(entries)),
+		Balances:  make([]UserBalanceView, 0, len(balances)),
+	}
+	var running float64
+	for _, e := range entries {
+		running += e.Amount
+		email, name := describe(e.CounterpartyID)
+		statement.Entries = append(statement.Entries, LedgerLine{
+			ID:            e.ID,
+			Source:        e.Source,
+			WithUserEmail: email,
+			WithUserName:  name,
+			Amount:        util.RoundToDefaultCurrency(e.Amount),
+			Balance:       util.RoundToDefaultCurrency(running),
+			CreatedAt:     e.CreatedAt,
+		})
+	}
+	for _, b := range balances {
+		otherID, amount := b.User2ID, b.Balance
+		if b.User2ID == userID {
+			otherID, amount = b.User1ID, -b.Balance
+		}
+		email, name := describe(otherID)
+		statement.Balances = append(statement.Balances, UserBalanceView{
+			WithUserEmail: email,
+			WithUserName:  name,
+			Amount:        util.RoundToDefaultCurrency(amount),
+			LastUpdated:   b.LastUpdated,
+		})
+	}
+	statement.Overall = util.RoundToDefaultCurrency(running)
+	return statement, nil
+}
+
+func (s *ledgerService) CheckBalances() ([]repository.BalanceDrift, error) {
+	drifts, err := s.ledgerRepo.CheckBalances()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check balances against the ledger: %w", err)
+	}
+	return drifts, nil
+}
+
+func (s *ledgerService) RebuildBalances() ([]repository.BalanceDrift, error) {
+	drifts, err := s.ledgerRepo.RebuildBalances()
+	if err != nil {
+		return nil, fmt.Errorf("failed to rebuild balances from the ledger: %w", err)
+	}
+	return drifts, nil
+}
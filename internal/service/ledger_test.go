@@ -0,0 +1,77 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerService_GetStatement(t *testing.T) {
+	ledgerRepo := new(repomock.LedgerRepository)
+	userService := new(MockUserService)
+	s := NewLedgerService(ledgerRepo, userService).(*ledgerService)
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	opened, settled := now.Add(-48*time.Hour), now.Add(-time.Hour)
+
+	userService.On("GetUsersByEmails", []string{"bob@example.com"}).Return([]*repository.User{{ID: 2, Email: "bob@example.com"}}, nil)
+	userService.On("GetUsersByIDs", []int{1, 3}).Return([]*repository.User{
+		{ID: 1, Name: "Alice", Email: "alice@example.com"},
+		{ID: 3, Name: "Carol", Email: "carol@example.com"},
+	}, nil)
+
+	// Test case 1: Without a time, the statement runs up to now with a running overall balance
+	ledgerRepo.On("GetEntries", 2, now).Return([]repository.LedgerEntry{
+		{ID: 1, Source: repository.LedgerSource{Type: repository.LedgerOpening}, UserID: 2, CounterpartyID: 1, Amount: -50, CreatedAt: opened},
+		{ID: 4, Source: repository.LedgerSource{Type: repository.LedgerExpense, ID: 7}, UserID: 2, CounterpartyID: 3, Amount: 12.5, CreatedAt: settled},
+	}, nil).Once()
+	ledgerRepo.On("GetBalancesAt", 2, now).Return([]repository.Balance{
+		{User1ID: 2, User2ID: 3, Balance: 12.5, LastUpdated: settled},
+		{User1ID: 1, User2ID: 2, Balance: 50, LastUpdated: opened},
+	}, nil).Once()
+	statement, err := s.GetStatement("bob@example.com", time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, now, statement.AsOf)
+	assert.Equal(t, []LedgerLine{
+		{ID: 1, Source: repository.LedgerSource{Type: repository.LedgerOpening}, WithUserEmail: "alice@example.com", WithUserName: "Alice", Amount: -50, Balance: -50, CreatedAt: opened},
+		{ID: 4, Source: repository.LedgerSource{Type: repository.LedgerExpense, ID: 7}, WithUserEmail: "carol@example.com", WithUserName: "Carol", Amount: 12.5, Balance: -37.5, CreatedAt: settled},
+	}, statement.Entries)
+	assert.Equal(t, []UserBalanceView{
+		{WithUserEmail: "carol@example.com", WithUserName: "Carol", Amount: 12.5, LastUpdated: settled},
+		{WithUserEmail: "alice@example.com", WithUserName: "Alice", Amount: -50, LastUpdated: opened},
+	}, statement.Balances)
+	assert.Equal(t, -37.5, statement.Overall)
+
+	// Test case 2: Before anything was posted the statement is empty
+	ledgerRepo.On("GetEntries", 2, opened.Add(-time.Hour)).Return([]repository.LedgerEntry(nil), nil).Once()
+	ledgerRepo.On("GetBalancesAt", 2, opened.Add(-time.Hour)).Return([]repository.Balance(nil), nil).Once()
+	statement, err = s.GetStatement("bob@example.com", opened.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, statement.Entries)
+	assert.Empty(t, statement.Balances)
+	assert.Equal(t, 0.0, statement.Overall)
+
+	ledgerRepo.AssertExpectations(t)
+}
+
+func TestLedgerService_RebuildBalances(t *testing.T) {
+	ledgerRepo := new(repomock.LedgerRepository)
+	s := NewLedgerService(ledgerRepo, nil)
+
+	drifts := []repository.BalanceDrift{{User1ID: 1, User2ID: 2, Materialized: 40, Ledger: 30}}
+	ledgerRepo.On("CheckBalances").Return(drifts, nil).Once()
+	ledgerRepo.On("RebuildBalances").Return(drifts, nil).Once()
+
+	found, err := s.CheckBalances()
+	require.NoError(t, err)
+	assert.Equal(t, drifts, found)
+	corrected, err := s.RebuildBalances()
+	require.NoError(t, err)
+	assert.Equal(t, drifts, corrected)
+
+	ledgerRepo.AssertExpectations(t)
+}
@@ -0,0 +1,330 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"text/template"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// DateLayout is the format of calendar dates in requests and responses, such as loan due dates.
+const DateLayout = "2006-01-02"
+
+// LoanReminderJobType is the job that reminds a borrower of a loan that has fallen due.
+const LoanReminderJobType = "send_loan_reminder"
+
+type CreateLoanRequest struct {
+	LenderEmail   string  `json:"lender_email"`
+	BorrowerEmail string  `json:"borrower_email"`
+	Amount        float64 `json:"amount"`
+	Description   string  `json:"description"`
+	DueDate       string  `json:"due_date,omitempty"` // Optional, YYYY-MM-DD
+}
+
+type LoanView struct {
+	ID            int       `json:"id"`
+	Direction     string    `json:"direction"` // "lent" or "borrowed", from the viewing user's side
+	WithUserEmail string    `json:"with_user_email"`
+	WithUserName  string    `json:"with_user_name"`
+	Amount        float64   `json:"amount"`
+	Outstanding   float64   `json:"outstanding"` // What is still owed, after what the pair has paid each other since
+	Description   string    `json:"description"`
+	DueDate       string    `json:"due_date,omitempty"`
+	Overdue       bool      `json:"overdue"` // Past the due date with something still owed
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type LoanService interface {
+	CreateLoan(req CreateLoanRequest) (*repository.Loan, error)
+	GetLoansForUser(userEmail string) ([]LoanView, error)
+	// ScheduleReminders enqueues a reminder for every loan that has fallen due since the last run and
+	// returns how many.
+	ScheduleReminders() (int, error)
+	// Run calls ScheduleReminders every interval until ctx is cancelled.
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type loanService struct {
+	loanRepo    repository.LoanRepository
+	ledgerRepo  repository.LedgerRepository
+	userService UserService
+	jobService  JobService
+	notifier    Notifier
+	now         func() time.Time
+}
+
+// loanReminderJob is the payload of a LoanReminderJobType job.
+type loanReminderJob struct {
+	LoanID int `json:"loan_id"`
+}
+
+// NewLoanService builds the loan service and registers its reminder job with jobService. Reminders go
+// out as KindReminder notifications, so a notifier that checks preferences drops them for users who
+// turned reminders off.
+func NewLoanService(loanRepo repository.LoanRepository, ledgerRepo repository.LedgerRepository, userService UserService, jobService JobService, notifier Notifier) LoanService {
+	s := &loanService{
+		loanRepo:    loanRepo,
+		ledgerRepo:  ledgerRepo,
+		userService: userService,
+		jobService:  jobService,
+		notifier:    notifier,
+		now:         time.Now,
+	}
+	jobService.Register(LoanReminderJobType, s.runLoanReminderJob)
+	return s
+}
+
+func (s *loanService) CreateLoan(req CreateLoanRequest) (*repository.Loan, error) {
+	users, err := s.userService.GetUsersByEmails([]string{req.LenderEmail, req.BorrowerEmail})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch users for loan: %w", err)
+	}
+
+	usersMap := make(map[string]*repository.User, len(users))
+	for _, u := range users {
+		usersMap[u.Email] = u
+	}
+
+	lender, ok := usersMap[util.NormalizeEmail(req.LenderEmail)]
+	if !ok {
+		return nil, fmt.Errorf("lender not found: %s", req.LenderEmail)
+	}
+	borrower, ok := usersMap[util.NormalizeEmail(req.BorrowerEmail)]
+	if !ok {
+		return nil, fmt.Errorf("borrower not found: %s", req.BorrowerEmail)
+	}
+
+	loan := &repository.Loan{
+		LenderID:    lender.ID,
+		BorrowerID:  borrower.ID,
+		Amount:      util.RoundToDefaultCurrency(req.Amount),
+		Description: util.SanitizeText(req.Description),
+	}
+
+	if req.DueDate != "" {
+		dueDate, err := time.Parse(DateLayout, req.DueDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid due date %q: %w", req.DueDate, err)
+		}
+		loan.DueDate = &dueDate
+	}
+
+	createdLoan, err := s.loanRepo.CreateLoan(loan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create loan in service: %w", err)
+	}
+
+	return createdLoan, nil
+}
+
+func (s *loanService) GetLoansForUser(userEmail string) ([]LoanView, error) {
+	users, err := s.userService.GetUsersByEmails([]string{userEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", userEmail)
+	}
+
+	userID := users[0].ID
+	loans, err := s.loanRepo.GetLoansByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loans for user %s: %w", userEmail, err)
+	}
+
+	// Fetch all counterparties in a single batch call
+	counterpartyIDs := util.NewSet[int]()
+	var ids []int
+	for _, l := range loans {
+		otherID := l.LenderID
+		if l.LenderID == userID {
+			otherID = l.BorrowerID
+		}
+		if !counterpartyIDs.IsMember(otherID) {
+			counterpartyIDs.Add(otherID)
+			ids = append(ids, otherID)
+		}
+	}
+
+	others, err := s.userService.GetUsersByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch counterparties for loans: %w", err)
+	}
+	othersMap := make(map[int]*repository.User, len(others))
+	for _, u := range others {
+		othersMap[u.ID] = u
+	}
+
+	outstanding, err := s.outstandingLoans(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	today := s.now().Truncate(24 * time.Hour)
+	views := make([]LoanView, 0, len(loans))
+	for _, l := range loans {
+		view := LoanView{
+			ID:          l.ID,
+			Direction:   "lent",
+			Amount:      l.Amount,
+			Outstanding: util.FromMinorUnits(outstanding[l.ID], util.DefaultExponent),
+			Description: l.Description,
+			CreatedAt:   l.CreatedAt,
+		}
+
+		otherID := l.BorrowerID
+		if l.BorrowerID == userID {
+			view.Direction = "borrowed"
+			otherID = l.LenderID
+		}
+		if other, ok := othersMap[otherID]; ok {
+			view.WithUserEmail = other.Email
+			view.WithUserName = other.Name
+		}
+
+		if l.DueDate != nil {
+			view.DueDate = l.DueDate.Format(DateLayout)
+			view.Overdue = l.DueDate.Before(today) && outstanding[l.ID] > 0
+		}
+
+		views = append(views, view)
+	}
+
+	return views, nil
+}
+
+// outstandingLoans returns what is still owed on each of the user's loans, in minor units, keyed by
+// loan ID. It is read from the ledger rather than the loans: a loan is paid off, oldest posting
+// first, by whatever runs the other way between the pair afterwards, settlements and expenses alike.
+func (s *loanService) outstandingLoans(userID int) (map[int]int64, error) {
+	entries, err := s.ledgerRepo.GetUnsettledEntries(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unsettled ledger entries for user %d: %w", userID, err)
+	}
+
+	byCounterparty := make(map[int][]repository.LedgerEntry)
+	for _, e := range entries {
+		byCounterparty[e.CounterpartyID] = append(byCounterparty[e.CounterpartyID], e)
+	}
+	outstanding := make(map[int]int64)
+	for _, pair := range byCounterparty {
+		open, _ := repository.UnsettledContributions(pair)
+		for _, c := range open {
+			if c.Source.Type != repository.LedgerLoan {
+				continue
+			}
+			if c.Units < 0 {
+				c.Units = -c.Units
+			}
+			outstanding[c.Source.ID] += c.Units
+		}
+	}
+	return outstanding, nil
+}
+
+// ScheduleReminders claims every loan due today or earlier whose borrower has not been reminded, so
+// a reminder is only ever queued once for each loan.
+func (s *loanService) ScheduleReminders() (int, error) {
+	now := s.now().UTC().Truncate(time.Second)
+	due, err := s.loanRepo.GetLoansDueForReminder(now.Truncate(24 * time.Hour))
+	if err != nil {
+		return 0, err
+	}
+
+	scheduled := 0
+	for _, l := range due {
+		claimed, err := s.loanRepo.ClaimReminder(l.ID, now)
+		if err != nil {
+			return scheduled, err
+		}
+		if !claimed {
+			continue
+		}
+		if _, err := s.jobService.Enqueue(LoanReminderJobType, loanReminderJob{LoanID: l.ID}); err != nil {
+			return scheduled, err
+		}
+		scheduled++
+	}
+	return scheduled, nil
+}
+
+func (s *loanService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.ScheduleReminders(); err != nil {
+			log.Printf("loan reminder scheduler: %v", err)
+		} else if n > 0 {
+			log.Printf("loan reminder scheduler: queued %d reminders", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runLoanReminderJob reminds the borrower of what is still owed on a loan that has fallen due. A
+// loan paid off by then needs no reminder.
+func (s *loanService) runLoanReminderJob(ctx context.Context, payload json.RawMessage) error {
+	var job loanReminderJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("invalid loan reminder job payload: %w", err)
+	}
+
+	loan, err := s.loanRepo.GetLoan(job.LoanID)
+	if err != nil {
+		return err
+	}
+	outstanding, err := s.outstandingLoans(loan.BorrowerID)
+	if err != nil {
+		return err
+	}
+	if outstanding[loan.ID] <= 0 {
+		return nil
+	}
+
+	users, err := s.userService.GetUsersByIDs([]int{loan.BorrowerID, loan.LenderID})
+	if err != nil {
+		return fmt.Errorf("failed to fetch users for loan reminder: %w", err)
+	}
+	byID := make(map[int]*repository.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+	borrower, lender := byID[loan.BorrowerID], byID[loan.LenderID]
+	if borrower == nil || lender == nil {
+		return fmt.Errorf("users for loan %d not found", loan.ID)
+	}
+
+	var body bytes.Buffer
+	if err := loanReminderEmail.Execute(&body, struct {
+		BorrowerName string
+		LenderName   string
+		Description  string
+		Amount       float64
+		Outstanding  float64
+		DueDate      string
+	}{borrower.Name, lender.Name, loan.Description, loan.Amount, util.FromMinorUnits(outstanding[loan.ID], util.DefaultExponent), loan.DueDate.Format(DateLayout)}); err != nil {
+		return fmt.Errorf("failed to render loan reminder: %w", err)
+	}
+
+	return s.notifier.Notify(ctx, Notification{
+		UserID:  borrower.ID,
+		Kind:    repository.KindReminder,
+		To:      borrower.Email,
+		Subject: fmt.Sprintf("Your loan from %s is due", lender.Name),
+		Body:    body.String(),
+	})
+}
+
+var loanReminderEmail = template.Must(template.New("loan-reminder").Parse(`Hi {{.BorrowerName}},
+
+The loan {{.LenderName}} gave you{{if .Description}} for {{.Description}}{{end}} was due on {{.DueDate}}.
+Of the {{printf "%.2f" .Amount}} lent, {{printf "%.2f" .Outstanding}} is still owed.
+`))
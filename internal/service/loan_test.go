@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoanService_CreateLoan(t *testing.T) {
+	loanRepo := new(repomock.LoanRepository)
+	userService := new(MockUserService)
+	loanService := NewLoanService(loanRepo, nil, userService, NewJobService(new(repomock.JobRepository), JobOptions{}), NewLogNotifier())
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+
+	// Test case 1: Successful loan with a due date
+	{
+		req := CreateLoanRequest{LenderEmail: alice.Email, BorrowerEmail: bob.Email, Amount: 100.004, Description: "Rent advance", DueDate: "2024-06-30"}
+		dueDate := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+		expectedLoan := &repository.Loan{LenderID: alice.ID, BorrowerID: bob.ID, Amount: 100.00, Description: "Rent advance", DueDate: &dueDate}
+
+		userService.On("GetUsersByEmails", []string{alice.Email, bob.Email}).Return([]*repository.User{alice, bob}, nil).Once()
+		loanRepo.On("CreateLoan", expectedLoan).Return(&repository.Loan{ID: 1, LenderID: alice.ID, BorrowerID: bob.ID, Amount: 100.00}, nil).Once()
+
+		loan, err := loanService.CreateLoan(req)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, loan.ID)
+		loanRepo.AssertExpectations(t)
+		userService.AssertExpectations(t)
+	}
+
+	// Test case 2: Borrower not found
+	{
+		req := CreateLoanRequest{LenderEmail: alice.Email, BorrowerEmail: "ghost@example.com", Amount: 10}
+		userService.On("GetUsersByEmails", []string{alice.Email, "ghost@example.com"}).Return([]*repository.User{}, errors.New("some users not found for emails: ghost@example.com")).Once()
+
+		loan, err := loanService.CreateLoan(req)
+		assert.Nil(t, loan)
+		assert.Contains(t, err.Error(), "some users not found for emails: ghost@example.com")
+		loanRepo.AssertNumberOfCalls(t, "CreateLoan", 1)
+	}
+}
+
+func TestLoanService_GetLoansForUser(t *testing.T) {
+	loanRepo := new(repomock.LoanRepository)
+	ledgerRepo := new(repomock.LedgerRepository)
+	userService := new(MockUserService)
+	loanService := NewLoanService(loanRepo, ledgerRepo, userService, NewJobService(new(repomock.JobRepository), JobOptions{}), NewLogNotifier()).(*loanService)
+	loanService.now = func() time.Time { return time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC) }
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+	charlie := &repository.User{ID: 3, Name: "Charlie", Email: "charlie@example.com"}
+	dana := &repository.User{ID: 4, Name: "Dana", Email: "dana@example.com"}
+
+	pastDue := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	futureDue := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	loans := []repository.Loan{
+		{ID: 3, LenderID: alice.ID, BorrowerID: dana.ID, Amount: 50, DueDate: &pastDue},
+		{ID: 2, LenderID: charlie.ID, BorrowerID: alice.ID, Amount: 40, DueDate: &futureDue},
+		{ID: 1, LenderID: alice.ID, BorrowerID: bob.ID, Amount: 100, DueDate: &pastDue},
+	}
+	// Bob has paid back 30 of his loan and Dana all of hers, so Alice and Dana are even and
+	// have no unsettled entries left
+	at := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	ledgerRepo.On("GetUnsettledEntries", alice.ID).Return([]repository.LedgerEntry{
+		{UserID: alice.ID, CounterpartyID: bob.ID, Source: repository.LedgerSource{Type: repository.LedgerLoan, ID: 1}, Amount: 100, CreatedAt: at},
+		{UserID: alice.ID, CounterpartyID: charlie.ID, Source: repository.LedgerSource{Type: repository.LedgerLoan, ID: 2}, Amount: -40, CreatedAt: at},
+		{UserID: alice.ID, CounterpartyID: bob.ID, Source: repository.LedgerSource{Type: repository.LedgerSettlement, ID: 7}, Amount: -30, CreatedAt: at.Add(time.Hour)},
+	}, nil).Once()
+
+	userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+	loanRepo.On("GetLoansByUserID", alice.ID).Return(loans, nil).Once()
+	userService.On("GetUsersByIDs", []int{dana.ID, charlie.ID, bob.ID}).Return([]*repository.User{dana, charlie, bob}, nil).Once()
+
+	// Test case 1: A loan is only overdue while something is still owed on it
+	views, err := loanService.GetLoansForUser(alice.Email)
+	assert.Nil(t, err)
+	assert.Equal(t, []LoanView{
+		{ID: 3, Direction: "lent", WithUserEmail: dana.Email, WithUserName: dana.Name, Amount: 50, Outstanding: 0, DueDate: "2024-05-01", Overdue: false},
+		{ID: 2, Direction: "borrowed", WithUserEmail: charlie.Email, WithUserName: charlie.Name, Amount: 40, Outstanding: 40, DueDate: "2024-07-01", Overdue: false},
+		{ID: 1, Direction: "lent", WithUserEmail: bob.Email, WithUserName: bob.Name, Amount: 100, Outstanding: 70, DueDate: "2024-05-01", Overdue: true},
+	}, views)
+	loanRepo.AssertExpectations(t)
+	ledgerRepo.AssertExpectations(t)
+	userService.AssertExpectations(t)
+}
+
+func TestLoanService_ScheduleReminders(t *testing.T) {
+	loanRepo := new(repomock.LoanRepository)
+	jobRepo := new(repomock.JobRepository)
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	s := NewLoanService(loanRepo, nil, nil, NewJobService(jobRepo, JobOptions{MaxAttempts: 3}), NewLogNotifier()).(*loanService)
+	s.now = func() time.Time { return now.Add(300 * time.Millisecond) }
+
+	loanRepo.On("GetLoansDueForReminder", time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)).Return([]repository.Loan{{ID: 1}, {ID: 2}}, nil).Once()
+	loanRepo.On("ClaimReminder", 1, now).Return(true, nil).Once()
+	// Another server got to loan 2 first
+	loanRepo.On("ClaimReminder", 2, now).Return(false, nil).Once()
+
+	var payload loanReminderJob
+	jobRepo.On("CreateJob", mock.Anything).Run(func(args mock.Arguments) {
+		assert.NoError(t, json.Unmarshal(args.Get(0).(*repository.Job).Payload, &payload))
+	}).Return(&repository.Job{}, nil).Once()
+
+	n, err := s.ScheduleReminders()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, loanReminderJob{LoanID: 1}, payload)
+
+	loanRepo.AssertExpectations(t)
+	jobRepo.AssertExpectations(t)
+}
+
+func TestLoanService_RunLoanReminderJob(t *testing.T) {
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+	due := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	loan := &repository.Loan{ID: 1, LenderID: alice.ID, BorrowerID: bob.ID, Amount: 100, Description: "Rent advance", DueDate: &due}
+	payload, _ := json.Marshal(loanReminderJob{LoanID: 1})
+	at := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	setup := func(entries []repository.LedgerEntry) (*loanService, *MockNotifier) {
+		loanRepo := new(repomock.LoanRepository)
+		ledgerRepo := new(repomock.LedgerRepository)
+		userService := new(MockUserService)
+		notifier := new(MockNotifier)
+		s := NewLoanService(loanRepo, ledgerRepo, userService, NewJobService(new(repomock.JobRepository), JobOptions{}), notifier).(*loanService)
+		loanRepo.On("GetLoan", 1).Return(loan, nil)
+		ledgerRepo.On("GetUnsettledEntries", bob.ID).Return(entries, nil)
+		userService.On("GetUsersByIDs", []int{bob.ID, alice.ID}).Return([]*repository.User{alice, bob}, nil)
+		notifier.On("Notify", mock.Anything).Return(nil)
+		return s, notifier
+	}
+
+	// Test case 1: The borrower is reminded of what is still owed
+	{
+		s, notifier := setup([]repository.LedgerEntry{
+			{UserID: bob.ID, CounterpartyID: alice.ID, Source: repository.LedgerSource{Type: repository.LedgerLoan, ID: 1}, Amount: -100, CreatedAt: at},
+			{UserID: bob.ID, CounterpartyID: alice.ID, Source: repository.LedgerSource{Type: repository.LedgerSettlement, ID: 7}, Amount: 40, CreatedAt: at.Add(time.Hour)},
+		})
+		require.NoError(t, s.runLoanReminderJob(context.Background(), payload))
+		notifier.AssertNumberOfCalls(t, "Notify", 1)
+		n := notifier.Calls[0].Arguments.Get(0).(Notification)
+		assert.Equal(t, bob.Email, n.To)
+		assert.Equal(t, repository.KindReminder, n.Kind)
+		assert.Equal(t, "Your loan from Alice is due", n.Subject)
+		assert.Contains(t, n.Body, "The loan Alice gave you for Rent advance was due on 2024-05-01.\n")
+		assert.Contains(t, n.Body, "Of the 100.00 lent, 60.00 is still owed.\n")
+	}
+
+	// Test case 2: A loan paid back by the time it falls due needs no reminder
+	{
+		s, notifier := setup(nil)
+		require.NoError(t, s.runLoanReminderJob(context.Background(), payload))
+		notifier.AssertNotCalled(t, "Notify", mock.Anything)
+	}
+}
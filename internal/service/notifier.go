@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+// Notification is a plain-text message for one user.
+type Notification struct {
+	UserID  int                         // Whose preferences apply; zero skips the check
+	Kind    repository.NotificationKind // What it is about, matched against the user's preferences
+	To      string
+	Subject string
+	Body    string
+	// UnsubscribeURL, when set, is offered to mail clients as a one-click unsubscribe.
+	UnsubscribeURL string
+}
+
+// Notifier delivers notifications to users.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// NewLogNotifier writes notifications to the log instead of sending them, for development and for
+// deployments without a mail server.
+func NewLogNotifier() Notifier {
+	return logNotifier{}
+}
+
+type logNotifier struct{}
+
+func (logNotifier) Notify(_ context.Context, n Notification) error {
+	log.Printf("notification to %s: %s\n%s", n.To, n.Subject, n.Body)
+	return nil
+}
+
+// NewPreferenceNotifier wraps the notifier for a channel so that notifications a user has turned off,
+// by kind or by channel, are dropped instead of sent. Every notifier the server uses is wrapped.
+func NewPreferenceNotifier(inner Notifier, channel repository.NotificationChannel, prefRepo repository.NotificationPreferenceRepository) Notifier {
+	return &preferenceNotifier{inner: inner, channel: channel, prefRepo: prefRepo}
+}
+
+type preferenceNotifier struct {
+	inner    Notifier
+	channel  repository.NotificationChannel
+	prefRepo repository.NotificationPreferenceRepository
+}
+
+func (p *preferenceNotifier) Notify(ctx context.Context, n Notification) error {
+	if n.UserID != 0 {
+		prefs, err := p.prefRepo.GetPreferences(n.UserID)
+		if err != nil {
+			return err
+		}
+		if !prefs.Allows(p.channel, n.Kind) {
+			return nil
+		}
+	}
+	return p.inner.Notify(ctx, n)
+}
+
+// SMTPConfig is what the SMTP notifier needs to hand mail to a relay.
+type SMTPConfig struct {
+	Addr     string // host:port
+	Username string // Empty sends without authenticating
+	Password string
+	From     string
+}
+
+// NewSMTPNotifier sends notifications as email through an SMTP relay.
+func NewSMTPNotifier(cfg SMTPConfig) Notifier {
+	return &smtpNotifier{cfg: cfg, send: smtp.SendMail, now: time.Now}
+}
+
+type smtpNotifier struct {
+	cfg  SMTPConfig
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+	now  func() time.Time
+}
+
+func (s *smtpNotifier) Notify(ctx context.Context, n Notification) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		host, _, err := net.SplitHostPort(s.cfg.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP address %q: %w", s.cfg.Addr, err)
+		}
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, host)
+	}
+
+	if err := s.send(s.cfg.Addr, auth, s.cfg.From, []string{n.To}, s.message(n)); err != nil {
+		return fmt.Errorf("failed to send email to %s: %w", n.To, err)
+	}
+	return nil
+}
+
+// message renders n as an RFC 5322 email. Header values come from our own templates and user
+// records, so line breaks are stripped rather than trusted.
+func (s *smtpNotifier) message(n Notification) []byte {
+	header := func(b *strings.Builder, name, value string) {
+		value = strings.NewReplacer("\r", "", "\n", " ").Replace(value)
+		fmt.Fprintf(b, "%s: %s\r\n", name, value)
+	}
+
+	var b strings.Builder
+	header(&b, "From", s.cfg.From)
+	header(&b, "To", n.To)
+	header(&b, "Subject", n.Subject)
+	header(&b, "Date", s.now().Format(time.RFC1123Z))
+	header(&b, "MIME-Version", "1.0")
+	header(&b, "Content-Type", "text/plain; charset=utf-8")
+	if n.UnsubscribeURL != "" {
+		header(&b, "List-Unsubscribe", "<"+n.UnsubscribeURL+">")
+		header(&b, "List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(n.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSMTPNotifier(t *testing.T) {
+	var (
+		gotAddr string
+		gotAuth smtp.Auth
+		gotTo   []string
+		gotMsg  string
+		sendErr error
+	)
+	n := NewSMTPNotifier(SMTPConfig{Addr: "mail.example:587", From: "noreply@split.example"}).(*smtpNotifier)
+	n.now = func() time.Time { return time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC) }
+	n.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotAuth, gotTo, gotMsg = addr, a, to, string(msg)
+		return sendErr
+	}
+
+	// Test case 1: Headers, one-click unsubscribe and CRLF line endings
+	err := n.Notify(context.Background(), Notification{
+		To:             "alice@example.com",
+		Subject:        "Your expenses\r\nBcc: eve@example.com",
+		Body:           "line one\nline two",
+		UnsubscribeURL: "https://split.example/notifications/unsubscribe?token=abc",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "mail.example:587", gotAddr)
+	assert.Nil(t, gotAuth)
+	assert.Equal(t, []string{"alice@example.com"}, gotTo)
+	assert.Contains(t, gotMsg, "Subject: Your expenses Bcc: eve@example.com\r\n")
+	assert.NotContains(t, gotMsg, "\r\nBcc:")
+	assert.Contains(t, gotMsg, "List-Unsubscribe: <https://split.example/notifications/unsubscribe?token=abc>\r\n")
+	assert.Contains(t, gotMsg, "List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+	assert.True(t, strings.HasSuffix(gotMsg, "\r\n\r\nline one\r\nline two"))
+
+	// Test case 2: Credentials turn on auth; relay errors are returned
+	n.cfg.Username, n.cfg.Password = "user", "pass"
+	sendErr = errors.New("relay refused")
+	err = n.Notify(context.Background(), Notification{To: "alice@example.com"})
+	assert.ErrorContains(t, err, "relay refused")
+	assert.NotNil(t, gotAuth)
+}
+
+func TestPreferenceNotifier(t *testing.T) {
+	prefRepo := new(repomock.NotificationPreferenceRepository)
+	inner := new(MockNotifier)
+	n := NewPreferenceNotifier(inner, repository.ChannelEmail, prefRepo)
+
+	quiet := repository.DefaultNotificationPreferences(2)
+	quiet.Events.NewExpense = false
+	noEmail := repository.DefaultNotificationPreferences(3)
+	noEmail.Channels.Email = false
+	prefRepo.On("GetPreferences", 1).Return(repository.DefaultNotificationPreferences(1), nil)
+	prefRepo.On("GetPreferences", 2).Return(quiet, nil)
+	prefRepo.On("GetPreferences", 3).Return(noEmail, nil)
+
+	// Test case 1: Sent when the user wants it
+	sent := Notification{UserID: 1, Kind: repository.KindNewExpense, To: "a@example.com"}
+	inner.On("Notify", sent).Return(nil).Once()
+	assert.NoError(t, n.Notify(context.Background(), sent))
+
+	// Test case 2: Dropped when the kind or the channel is off, but other kinds still go out
+	assert.NoError(t, n.Notify(context.Background(), Notification{UserID: 2, Kind: repository.KindNewExpense}))
+	assert.NoError(t, n.Notify(context.Background(), Notification{UserID: 3, Kind: repository.KindDigest}))
+	digest := Notification{UserID: 2, Kind: repository.KindDigest}
+	inner.On("Notify", digest).Return(nil).Once()
+	assert.NoError(t, n.Notify(context.Background(), digest))
+
+	// Test case 3: Notifications without a user are not checked
+	anon := Notification{To: "ops@example.com"}
+	inner.On("Notify", anon).Return(nil).Once()
+	assert.NoError(t, n.Notify(context.Background(), anon))
+
+	inner.AssertExpectations(t)
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+type CreatePartyRequest struct {
+	Name string `json:"name"`
+}
+
+// PartyService manages the outside parties, like landlords and vendors, that expenses can be paid to.
+type PartyService interface {
+	CreateParty(req CreatePartyRequest) (*repository.Party, error)
+	ListParties() ([]repository.Party, error)
+}
+
+type partyService struct {
+	partyRepo repository.PartyRepository
+}
+
+func NewPartyService(partyRepo repository.PartyRepository) PartyService {
+	return &partyService{partyRepo: partyRepo}
+}
+
+func (s *partyService) CreateParty(req CreatePartyRequest) (*repository.Party, error) {
+	return s.partyRepo.CreateParty(&repository.Party{Name: util.SanitizeText(req.Name)})
+}
+
+func (s *partyService) ListParties() ([]repository.Party, error) {
+	return s.partyRepo.ListParties()
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// Payment providers a transfer can be paid through, and a settlement recorded from.
+const (
+	ProviderUPI    = "upi"
+	ProviderPayPal = "paypal"
+	ProviderVenmo  = "venmo"
+)
+
+// paymentQRScale is the size in pixels of each module of a payment link's QR code.
+const paymentQRScale = 8
+
+// paymentLinkPrefixes are the only links PaymentQRCode renders, so it cannot be used to put
+// arbitrary content behind this server's name.
+var paymentLinkPrefixes = []string{"upi://pay?", "https://paypal.me/", "https://venmo.com/"}
+
+var (
+	upiIDPattern    = regexp.MustCompile(`^[a-zA-Z0-9._-]{2,256}@[a-zA-Z]{2,64}$`)
+	payPalMePattern = regexp.MustCompile(`^[a-zA-Z0-9]{1,20}$`)
+	venmoPattern    = regexp.MustCompile(`^[a-zA-Z0-9_-]{5,30}$`)
+)
+
+// ErrInvalidPaymentHandle is returned for a UPI ID, PayPal.me name or Venmo username that is malformed.
+var ErrInvalidPaymentHandle = errors.New("invalid payment handle")
+
+// ErrInvalidPaymentLink is returned when asked for the QR code of something that is not a payment link.
+var ErrInvalidPaymentLink = errors.New("not a payment link")
+
+// ErrInvalidPaymentCallback is returned for a payment callback missing what it takes to record the payment.
+var ErrInvalidPaymentCallback = errors.New("invalid payment callback")
+
+// PaymentLink is a ready-made way of paying a transfer: a deep link into the provider's app or
+// site, and a QR code of it to scan with a phone.
+type PaymentLink struct {
+	Provider  string `json:"provider"`
+	URL       string `json:"url"`
+	QRCodeURL string `json:"qr_code_url"`
+}
+
+// PaymentCallbackRequest reports that a transfer was paid through a provider. Reference is the
+// provider's ID for the payment, so the same payment reported twice is recorded once. It is sent
+// with an X-Payment-Signature header signed with the provider's callback secret, in the same
+// format as Stripe's webhook signatures.
+type PaymentCallbackRequest struct {
+	FromEmail string  `json:"from_email"`
+	ToEmail   string  `json:"to_email"`
+	Amount    float64 `json:"amount"`
+	Provider  string  `json:"provider"`
+	Reference string  `json:"reference"`
+}
+
+// PaymentService keeps where users can be paid, turns settle-up transfers into payment links and
+// records the payments made through them as settlements.
+type PaymentService interface {
+	GetPaymentHandles(userID int) (*repository.PaymentHandles, error)
+	SetPaymentHandles(userID int, handles repository.PaymentHandles) (*repository.PaymentHandles, error)
+	// LinkTransfers fills in the payment links of each transfer from its payee's handles. note is
+	// what the payment is described as in the provider's app.
+	LinkTransfers(transfers []SettleUpTransfer, note string) error
+	// PaymentQRCode renders a payment link as a QR code PNG.
+	PaymentQRCode(link string) ([]byte, error)
+	// RecordPayment verifies a PaymentCallbackRequest against the callback secret of the provider it
+	// names, records the payment as a confirmed settlement, and returns the settlement already
+	// recording it if it was reported before.
+	RecordPayment(payload []byte, signature string) (*repository.Settlement, error)
+}
+
+type paymentService struct {
+	handleRepo      repository.PaymentHandleRepository
+	settlementRepo  repository.SettlementRepository
+	userService     UserService
+	baseURL         string
+	callbackSecrets map[string]string
+	currencies      util.Currencies
+	now             func() time.Time
+}
+
+// NewPaymentService builds the payment service. baseURL is this server's public address, which
+// the QR codes of payment links are served from. callbackSecrets maps each provider to the secret
+// its callbacks are signed with; callbacks from a provider without one are refused.
+func NewPaymentService(handleRepo repository.PaymentHandleRepository, settlementRepo repository.SettlementRepository, userService UserService, baseURL string, callbackSecrets map[string]string, currencies util.Currencies) PaymentService {
+	return &paymentService{
+		handleRepo:      handleRepo,
+		settlementRepo:  settlementRepo,
+		userService:     userService,
+		baseURL:         strings.TrimSuffix(baseURL, "/"),
+		callbackSecrets: callbackSecrets,
+		currencies:      currencies,
+		now:             time.Now,
+	}
+}
+
+func (s *paymentService) GetPaymentHandles(userID int) (*repository.PaymentHandles, error) {
+	if _, err := s.userService.GetUser(userID); err != nil {
+		return nil, err
+	}
+	handles, err := s.handleRepo.GetPaymentHandles([]int{userID})
+	if err != nil {
+		return nil, err
+	}
+	h := handles[userID]
+	h.UserID = userID
+	return &h, nil
+}
+
+func (s *paymentService) SetPaymentHandles(userID int, h repository.PaymentHandles) (*repository.PaymentHandles, error) {
+	h.UPIID = strings.TrimSpace(h.UPIID)
+	h.PayPalMe = strings.TrimSpace(h.PayPalMe)
+	h.Venmo = strings.TrimPrefix(strings.TrimSpace(h.Venmo), "@")
+	if h.UPIID != "" && !upiIDPattern.MatchString(h.UPIID) {
+		return nil, fmt.Errorf("%w: UPI ID must look like name@bank, got %q", ErrInvalidPaymentHandle, h.UPIID)
+	}
+	if h.PayPalMe != "" && !payPalMePattern.MatchString(h.PayPalMe) {
+		return nil, fmt.Errorf("%w: PayPal.me name must be up to 20 letters and digits, got %q", ErrInvalidPaymentHandle, h.PayPalMe)
+	}
+	if h.Venmo != "" && !venmoPattern.MatchString(h.Venmo) {
+		return nil, fmt.Errorf("%w: Venmo username must be 5 to 30 letters, digits, dashes or underscores, got %q", ErrInvalidPaymentHandle, h.Venmo)
+	}
+	if _, err := s.userService.GetUser(userID); err != nil {
+		return nil, err
+	}
+
+	h.UserID = userID
+	if err := s.handleRepo.SetPaymentHandles(&h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func (s *paymentService) LinkTransfers(transfers []SettleUpTransfer, note string) error {
+	if len(transfers) == 0 {
+		return nil
+	}
+	emails := util.NewSet[string]()
+	for _, t := range transfers {
+		emails.Add(t.ToEmail)
+	}
+	payees, err := s.userService.GetUsersByEmails(emails.ToList())
+	if err != nil {
+		return fmt.Errorf("failed to fetch payees of transfers: %w", err)
+	}
+	ids := make([]int, 0, len(payees))
+	byEmail := make(map[string]*repository.User, len(payees))
+	for _, u := range payees {
+		ids = append(ids, u.ID)
+		byEmail[u.Email] = u
+	}
+	handles, err := s.handleRepo.GetPaymentHandles(ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range transfers {
+		payee, ok := byEmail[util.NormalizeEmail(transfers[i].ToEmail)]
+		if !ok {
+			continue
+		}
+		transfers[i].Payments = s.paymentLinks(transfers[i], payee.Name, handles[payee.ID], note)
+	}
+	return nil
+}
+
+// paymentLinks builds a link for each of the payee's handles whose provider takes the transfer's
+// currency: UPI only moves rupees and Venmo only dollars.
+func (s *paymentService) paymentLinks(t SettleUpTransfer, payeeName string, h repository.PaymentHandles, note string) []PaymentLink {
+	amount := strconv.FormatFloat(t.Amount, 'f', s.currencies.Exponent(t.Currency), 64)
+	var links []PaymentLink
+	if h.UPIID != "" && t.Currency == "INR" {
+		query := url.Values{"pa": {h.UPIID}, "pn": {payeeName}, "am": {amount}, "cu": {"INR"}, "tn": {note}}
+		links = append(links, s.paymentLink(ProviderUPI, "upi://pay?"+query.Encode()))
+	}
+	if h.PayPalMe != "" {
+		links = append(links, s.paymentLink(ProviderPayPal, "https://paypal.me/"+h.PayPalMe+"/"+amount+t.Currency))
+	}
+	if h.Venmo != "" && t.Currency == "USD" {
+		query := url.Values{"txn": {"pay"}, "amount": {amount}, "note": {note}}
+		links = append(links, s.paymentLink(ProviderVenmo, "https://venmo.com/"+h.Venmo+"?"+query.Encode()))
+	}
+	return links
+}
+
+func (s *paymentService) paymentLink(provider, link string) PaymentLink {
+	return PaymentLink{
+		Provider:  provider,
+		URL:       link,
+		QRCodeURL: s.baseURL + "/payments/qr.png?url=" + url.QueryEscape(link),
+	}
+}
+
+func (s *paymentService) PaymentQRCode(link string) ([]byte, error) {
+	known := false
+	for _, prefix := range paymentLinkPrefixes {
+		known = known || strings.HasPrefix(link, prefix)
+	}
+	if !known {
+		return nil, ErrInvalidPaymentLink
+	}
+	code, err := util.EncodeQR([]byte(link))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPaymentLink, err)
+	}
+	return code.PNG(paymentQRScale)
+}
+
+func (s *paymentService) RecordPayment(payload []byte, signature string) (*repository.Settlement, error) {
+	var req PaymentCallbackRequest
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPaymentCallback, err)
+	}
+	switch req.Provider {
+	case ProviderUPI, ProviderPayPal, ProviderVenmo:
+	default:
+		return nil, fmt.Errorf("%w: unknown provider %q", ErrInvalidPaymentCallback, req.Provider)
+	}
+	// Unsigned, anyone could settle anyone's debts
+	secret := s.callbackSecrets[req.Provider]
+	if secret == "" {
+		return nil, fmt.Errorf("%w: no callback secret is configured for %s", ErrInvalidWebhookSignature, req.Provider)
+	}
+	if err := verifySignedPayload(secret, payload, signature, s.now()); err != nil {
+		return nil, err
+	}
+
+	if req.FromEmail == "" || req.ToEmail == "" {
+		return nil, fmt.Errorf("%w: from_email and to_email are required", ErrInvalidPaymentCallback)
+	}
+	if req.Reference == "" {
+		return nil, fmt.Errorf("%w: reference is required", ErrInvalidPaymentCallback)
+	}
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidPaymentCallback)
+	}
+
+	existing, err := s.settlementRepo.GetSettlementByPaymentReference(req.Provider, req.Reference)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, repository.ErrSettlementNotFound) {
+		return nil, err
+	}
+
+	users, err := s.userService.GetUsersByEmails([]string{req.FromEmail, req.ToEmail})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch users for payment: %w", err)
+	}
+	usersMap := make(map[string]*repository.User, len(users))
+	for _, u := range users {
+		usersMap[u.Email] = u
+	}
+	payer, ok := usersMap[util.NormalizeEmail(req.FromEmail)]
+	if !ok {
+		return nil, fmt.Errorf("payer not found: %s", req.FromEmail)
+	}
+	payee, ok := usersMap[util.NormalizeEmail(req.ToEmail)]
+	if !ok {
+		return nil, fmt.Errorf("payee not found: %s", req.ToEmail)
+	}
+
+	// No dispute check, unlike a proposed settlement: the provider has signed for the money having
+	// moved, and the ledger should say so
+	settlement, err := s.settlementRepo.CreateSettlement(&repository.Settlement{
+		PayerID:          payer.ID,
+		PayeeID:          payee.ID,
+		Amount:           util.RoundToDefaultCurrency(req.Amount),
+		PaymentProvider:  req.Provider,
+		PaymentReference: req.Reference,
+	})
+	if errors.Is(err, repository.ErrDuplicatePaymentReference) {
+		// Reported twice at once, and the other report got there first
+		return s.settlementRepo.GetSettlementByPaymentReference(req.Provider, req.Reference)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to record %s payment %s: %w", req.Provider, req.Reference, err)
+	}
+
+	confirmed, err := s.settlementRepo.TransitionSettlement(settlement.ID, settlementTransitions[repository.SettlementConfirmed], repository.SettlementConfirmed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm settlement %d for %s payment %s: %w", settlement.ID, req.Provider, req.Reference, err)
+	}
+	return confirmed, nil
+}
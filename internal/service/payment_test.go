@@ -0,0 +1,143 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentService_SetPaymentHandles(t *testing.T) {
+	handleRepo := new(repomock.PaymentHandleRepository)
+	userService := new(MockUserService)
+	s := NewPaymentService(handleRepo, nil, userService, "http://split.example", nil, seededCurrencies)
+
+	// Test case 1: Saved for the user in the path, with the @ dropped from the Venmo username
+	userService.On("GetUser", 1).Return(&repository.User{ID: 1}, nil).Once()
+	handleRepo.On("SetPaymentHandles", &repository.PaymentHandles{UserID: 1, UPIID: "alice@okbank", Venmo: "alice-s"}).Return(nil).Once()
+	saved, err := s.SetPaymentHandles(1, repository.PaymentHandles{UserID: 99, UPIID: " alice@okbank ", Venmo: "@alice-s"})
+	assert.NoError(t, err)
+	assert.Equal(t, &repository.PaymentHandles{UserID: 1, UPIID: "alice@okbank", Venmo: "alice-s"}, saved)
+
+	// Test case 2: Malformed handles are refused before anything is stored
+	for _, h := range []repository.PaymentHandles{{UPIID: "alice"}, {PayPalMe: "alice/smith"}, {Venmo: "al"}} {
+		_, err = s.SetPaymentHandles(1, h)
+		assert.ErrorIs(t, err, ErrInvalidPaymentHandle)
+	}
+
+	handleRepo.AssertExpectations(t)
+	userService.AssertExpectations(t)
+}
+
+func TestPaymentService_LinkTransfers(t *testing.T) {
+	handleRepo := new(repomock.PaymentHandleRepository)
+	userService := new(MockUserService)
+	s := NewPaymentService(handleRepo, nil, userService, "http://split.example/", nil, seededCurrencies)
+
+	userService.On("GetUsersByEmails", mock.Anything).Return([]*repository.User{
+		{ID: 2, Name: "Bob Jones", Email: "bob@example.com"},
+		{ID: 3, Name: "Carol", Email: "carol@example.com"},
+	}, nil)
+	handleRepo.On("GetPaymentHandles", mock.Anything).Return(map[int]repository.PaymentHandles{
+		2: {UserID: 2, UPIID: "bob@okbank", PayPalMe: "bobjones", Venmo: "bob-jones"},
+	}, nil)
+
+	transfers := []SettleUpTransfer{
+		{FromEmail: "alice@example.com", ToEmail: "bob@example.com", Currency: "INR", Amount: 250.5},
+		{FromEmail: "alice@example.com", ToEmail: "bob@example.com", Currency: "USD", Amount: 10},
+		{FromEmail: "alice@example.com", ToEmail: "carol@example.com", Currency: "USD", Amount: 5},
+	}
+	require.NoError(t, s.LinkTransfers(transfers, "Settle up: Goa"))
+
+	// Test case 1: Rupees can go through UPI or PayPal, but not Venmo
+	assert.Equal(t, []PaymentLink{
+		{
+			Provider:  ProviderUPI,
+			URL:       "upi://pay?am=250.50&cu=INR&pa=bob%40okbank&pn=Bob+Jones&tn=Settle+up%3A+Goa",
+			QRCodeURL: "http://split.example/payments/qr.png?url=upi%3A%2F%2Fpay%3Fam%3D250.50%26cu%3DINR%26pa%3Dbob%2540okbank%26pn%3DBob%2BJones%26tn%3DSettle%2Bup%253A%2BGoa",
+		},
+		{
+			Provider:  ProviderPayPal,
+			URL:       "https://paypal.me/bobjones/250.50INR",
+			QRCodeURL: "http://split.example/payments/qr.png?url=https%3A%2F%2Fpaypal.me%2Fbobjones%2F250.50INR",
+		},
+	}, transfers[0].Payments)
+
+	// Test case 2: Dollars can go through PayPal or Venmo, but not UPI
+	require.Len(t, transfers[1].Payments, 2)
+	assert.Equal(t, ProviderPayPal, transfers[1].Payments[0].Provider)
+	assert.Equal(t, ProviderVenmo, transfers[1].Payments[1].Provider)
+	assert.Equal(t, "https://venmo.com/bob-jones?amount=10.00&note=Settle+up%3A+Goa&txn=pay", transfers[1].Payments[1].URL)
+
+	// Test case 3: A payee without handles gets no links
+	assert.Empty(t, transfers[2].Payments)
+}
+
+func TestPaymentService_PaymentQRCode(t *testing.T) {
+	s := NewPaymentService(nil, nil, nil, "http://split.example", nil, seededCurrencies)
+
+	// Test case 1: A payment link
+	png, err := s.PaymentQRCode("https://paypal.me/bobjones/10.00USD")
+	assert.NoError(t, err)
+	assert.Equal(t, "\x89PNG", string(png[:4]))
+
+	// Test case 2: Anything else is refused
+	_, err = s.PaymentQRCode("https://evil.example/phish")
+	assert.ErrorIs(t, err, ErrInvalidPaymentLink)
+}
+
+func TestPaymentService_RecordPayment(t *testing.T) {
+	settlementRepo := new(repomock.SettlementRepository)
+	userService := new(MockUserService)
+	s := NewPaymentService(nil, settlementRepo, userService, "http://split.example", map[string]string{ProviderUPI: testWebhookSecret}, seededCurrencies).(*paymentService)
+	now := time.Unix(1_700_000_000, 0)
+	s.now = func() time.Time { return now }
+	payload := `{"from_email":"alice@example.com","to_email":"bob@example.com","amount":12.345,"provider":"upi","reference":"UPI123"}`
+
+	// Test case 1: A new payment is recorded and confirmed straight away
+	settlementRepo.On("GetSettlementByPaymentReference", ProviderUPI, "UPI123").Return((*repository.Settlement)(nil), repository.ErrSettlementNotFound).Once()
+	userService.On("GetUsersByEmails", []string{"alice@example.com", "bob@example.com"}).Return([]*repository.User{
+		{ID: 1, Email: "alice@example.com"},
+		{ID: 2, Email: "bob@example.com"},
+	}, nil).Once()
+	settlementRepo.On("CreateSettlement", &repository.Settlement{PayerID: 1, PayeeID: 2, Amount: 12.35, PaymentProvider: ProviderUPI, PaymentReference: "UPI123"}).
+		Return(&repository.Settlement{ID: 7, PayerID: 1, PayeeID: 2, Amount: 12.35, Status: repository.SettlementProposed}, nil).Once()
+	settlementRepo.On("TransitionSettlement", 7, settlementTransitions[repository.SettlementConfirmed], repository.SettlementConfirmed).
+		Return(&repository.Settlement{ID: 7, Status: repository.SettlementConfirmed}, nil).Once()
+	settlement, err := s.RecordPayment([]byte(payload), signStripe(payload, now))
+	assert.NoError(t, err)
+	assert.Equal(t, repository.SettlementConfirmed, settlement.Status)
+
+	// Test case 2: Reported again, the payment is not recorded twice
+	settlementRepo.On("GetSettlementByPaymentReference", ProviderUPI, "UPI123").Return(&repository.Settlement{ID: 7, Status: repository.SettlementConfirmed}, nil).Once()
+	settlement, err = s.RecordPayment([]byte(payload), signStripe(payload, now))
+	assert.NoError(t, err)
+	assert.Equal(t, 7, settlement.ID)
+
+	// Test case 3: Unsigned, wrongly signed, stale, or from a provider without a secret
+	for _, signature := range []string{"", signStripe(`{"amount":1}`, now), signStripe(payload, now.Add(-time.Hour))} {
+		_, err = s.RecordPayment([]byte(payload), signature)
+		assert.ErrorIs(t, err, ErrInvalidWebhookSignature)
+	}
+	venmo := `{"from_email":"alice@example.com","to_email":"bob@example.com","amount":1,"provider":"venmo","reference":"V1"}`
+	_, err = s.RecordPayment([]byte(venmo), signStripe(venmo, now))
+	assert.ErrorIs(t, err, ErrInvalidWebhookSignature)
+
+	// Test case 4: Unknown provider, missing reference or malformed body
+	for _, body := range []string{
+		`{"from_email":"alice@example.com","to_email":"bob@example.com","amount":1,"provider":"cash","reference":"x"}`,
+		`{"from_email":"alice@example.com","to_email":"bob@example.com","amount":1,"provider":"upi"}`,
+		`{"from_email":"alice@example.com","provider":"upi","reference":"x","amount":1}`,
+		`{"provider":"upi",`,
+	} {
+		_, err = s.RecordPayment([]byte(body), signStripe(body, now))
+		assert.ErrorIs(t, err, ErrInvalidPaymentCallback, body)
+	}
+
+	settlementRepo.AssertExpectations(t)
+	userService.AssertExpectations(t)
+}
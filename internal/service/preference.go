@@ -0,0 +1,46 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+// PreferenceService reads and changes what users want to be notified about, and how.
+type PreferenceService interface {
+	GetPreferences(userID int) (*repository.NotificationPreferences, error)
+	SetPreferences(userID int, prefs repository.NotificationPreferences) (*repository.NotificationPreferences, error)
+}
+
+type preferenceService struct {
+	prefRepo    repository.NotificationPreferenceRepository
+	userService UserService
+}
+
+func NewPreferenceService(prefRepo repository.NotificationPreferenceRepository, userService UserService) PreferenceService {
+	return &preferenceService{prefRepo: prefRepo, userService: userService}
+}
+
+func (s *preferenceService) GetPreferences(userID int) (*repository.NotificationPreferences, error) {
+	if _, err := s.userService.GetUser(userID); err != nil {
+		return nil, err
+	}
+	return s.prefRepo.GetPreferences(userID)
+}
+
+func (s *preferenceService) SetPreferences(userID int, prefs repository.NotificationPreferences) (*repository.NotificationPreferences, error) {
+	switch prefs.DigestFrequency {
+	case repository.DigestDaily, repository.DigestWeekly, repository.DigestNever:
+	default:
+		return nil, fmt.Errorf("%w, got %q", ErrInvalidDigestFrequency, prefs.DigestFrequency)
+	}
+	if _, err := s.userService.GetUser(userID); err != nil {
+		return nil, err
+	}
+
+	prefs.UserID = userID
+	if err := s.prefRepo.SetPreferences(&prefs); err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
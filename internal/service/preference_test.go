@@ -0,0 +1,44 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPreferenceService_SetPreferences(t *testing.T) {
+	prefRepo := new(repomock.NotificationPreferenceRepository)
+	userService := new(MockUserService)
+	s := NewPreferenceService(prefRepo, userService)
+
+	prefs := *repository.DefaultNotificationPreferences(0)
+	prefs.Events.NewExpense = false
+
+	// Test case 1: Saved for the user in the path, whatever the body said
+	prefs.UserID = 99
+	userService.On("GetUser", 1).Return(&repository.User{ID: 1}, nil).Once()
+	prefRepo.On("SetPreferences", mock.MatchedBy(func(p *repository.NotificationPreferences) bool {
+		return p.UserID == 1 && !p.Events.NewExpense && p.Channels.Email
+	})).Return(nil).Once()
+	saved, err := s.SetPreferences(1, prefs)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, saved.UserID)
+
+	// Test case 2: Unknown digest frequency
+	prefs.DigestFrequency = "hourly"
+	_, err = s.SetPreferences(1, prefs)
+	assert.ErrorIs(t, err, ErrInvalidDigestFrequency)
+
+	// Test case 3: Unknown user
+	prefs.DigestFrequency = repository.DigestDaily
+	userService.On("GetUser", 2).Return((*repository.User)(nil), errors.New("user not found")).Once()
+	_, err = s.SetPreferences(2, prefs)
+	assert.ErrorContains(t, err, "user not found")
+
+	prefRepo.AssertExpectations(t)
+	userService.AssertExpectations(t)
+}
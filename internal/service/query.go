@@ -0,0 +1,56 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+// MaxSlowestQueries is the most statements the slowest queries report lists.
+const MaxSlowestQueries = 50
+
+type QueryService interface {
+	// SlowestQueries lists up to n recorded statements, the slowest on average first, explaining
+	// each SELECT among them afresh. A SELECT that can't be explained keeps its last plan, with the
+	// reason it failed.
+	SlowestQueries(n int) ([]repository.QueryStats, error)
+}
+
+type queryService struct {
+	queryStatsRepo repository.QueryStatsRepository
+	now            func() time.Time
+}
+
+// NewQueryService reports on the recorded statements. queryStatsRepo may be nil, in which case no
+// statements are recorded, as with the memory storage backend.
+func NewQueryService(queryStatsRepo repository.QueryStatsRepository) QueryService {
+	return &queryService{queryStatsRepo: queryStatsRepo, now: time.Now}
+}
+
+func (s *queryService) SlowestQueries(n int) ([]repository.QueryStats, error) {
+	if s.queryStatsRepo == nil {
+		return []repository.QueryStats{}, nil
+	}
+
+	stats, err := s.queryStatsRepo.SlowestQueries(n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the slowest queries: %w", err)
+	}
+
+	for i := range stats {
+		plan, err := s.queryStatsRepo.ExplainQuery(stats[i].Name)
+		if errors.Is(err, repository.ErrQueryNotExplained) {
+			continue
+		}
+		if err != nil {
+			stats[i].ExplainError = err.Error()
+			continue
+		}
+		at := s.now()
+		stats[i].Explain, stats[i].ExplainedAt, stats[i].ExplainError = plan, &at, ""
+	}
+
+	return stats, nil
+}
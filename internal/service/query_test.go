@@ -0,0 +1,47 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryService_SlowestQueries(t *testing.T) {
+	// Test case 1: Nothing is listed when queries aren't recorded
+	{
+		stats, err := NewQueryService(nil).SlowestQueries(10)
+		require.NoError(t, err)
+		assert.Empty(t, stats)
+	}
+
+	// Test case 2: Each SELECT is explained, and a failed EXPLAIN is reported alongside the statement
+	{
+		queryStatsRepo := new(repomock.QueryStatsRepository)
+		s := NewQueryService(queryStatsRepo).(*queryService)
+		now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+		s.now = func() time.Time { return now }
+
+		selectUsers, selectJobs, deleteJobs := "SELECT * FROM users WHERE id = ?", "SELECT * FROM jobs", "DELETE FROM jobs WHERE id = ?"
+		queryStatsRepo.On("SlowestQueries", 3).Return([]repository.QueryStats{{Name: selectUsers}, {Name: selectJobs}, {Name: deleteJobs}}, nil).Once()
+		plan := []map[string]any{{"table": "users", "type": "const", "key": "PRIMARY"}}
+		queryStatsRepo.On("ExplainQuery", selectUsers).Return(plan, nil).Once()
+		queryStatsRepo.On("ExplainQuery", selectJobs).Return([]map[string]any(nil), errors.New("connection refused")).Once()
+		queryStatsRepo.On("ExplainQuery", deleteJobs).Return([]map[string]any(nil), fmt.Errorf("%w: %s", repository.ErrQueryNotExplained, deleteJobs)).Once()
+
+		stats, err := s.SlowestQueries(3)
+		require.NoError(t, err)
+		require.Len(t, stats, 3)
+		assert.Equal(t, plan, stats[0].Explain)
+		assert.Equal(t, &now, stats[0].ExplainedAt)
+		assert.Equal(t, "connection refused", stats[1].ExplainError)
+		assert.Nil(t, stats[2].Explain)
+		assert.Empty(t, stats[2].ExplainError)
+		queryStatsRepo.AssertExpectations(t)
+	}
+}
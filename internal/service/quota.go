@@ -0,0 +1,154 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+// ErrQuotaExceeded matches every QuotaError.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Quota names, as reported in QuotaError.Quota.
+const (
+	QuotaExpensesPerDay = "expenses_per_day"
+	QuotaEvents         = "events"
+	QuotaUploadBytes    = "upload_bytes"
+)
+
+// Quotas bound what one user may create, to keep a free deployment within its resources. Zero
+// disables a quota. They are soft: usage is checked before a change rather than reserved, so
+// concurrent requests can overshoot by a little.
+type Quotas struct {
+	ExpensesPerDay int   // Expenses created per calendar day (UTC)
+	Events         int   // Unarchived events created
+	UploadBytes    int64 // Import file data stored
+}
+
+// QuotaError is returned for a change that would take a user over a quota.
+type QuotaError struct {
+	Quota string
+	Limit int64
+	// RetryAfter is how long until the quota frees up by itself, zero if it doesn't.
+	RetryAfter time.Duration
+}
+
+func (e *QuotaError) Error() string {
+	switch e.Quota {
+	case QuotaExpensesPerDay:
+		return fmt.Sprintf("quota exceeded: at most %d expenses may be created a day", e.Limit)
+	case QuotaEvents:
+		return fmt.Sprintf("quota exceeded: at most %d events may be open at once; archive one to make room", e.Limit)
+	default:
+		return fmt.Sprintf("quota exceeded: at most %d bytes of imports may be stored", e.Limit)
+	}
+}
+
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// QuotaUsage is where a user stands against each quota. A zero limit means no quota.
+type QuotaUsage struct {
+	ExpensesToday  int   `json:"expenses_today"`
+	ExpensesPerDay int   `json:"expenses_per_day"`
+	Events         int   `json:"events"`
+	MaxEvents      int   `json:"max_events"`
+	UploadBytes    int64 `json:"upload_bytes"`
+	MaxUploadBytes int64 `json:"max_upload_bytes"`
+}
+
+type QuotaService interface {
+	// CheckExpense fails with a QuotaError if the user has created all the expenses they may today.
+	CheckExpense(userID int) error
+	// CheckEvent fails with a QuotaError if the user has as many open events as they may.
+	CheckEvent(userID int) error
+	// CheckUpload fails with a QuotaError if storing size more bytes of imports would take the user
+	// over their quota.
+	CheckUpload(userID int, size int) error
+	GetUsage(userID int) (*QuotaUsage, error)
+}
+
+type quotaService struct {
+	quotaRepo repository.QuotaRepository
+	quotas    Quotas
+	now       func() time.Time
+}
+
+func NewQuotaService(quotaRepo repository.QuotaRepository, quotas Quotas) QuotaService {
+	return &quotaService{quotaRepo: quotaRepo, quotas: quotas, now: time.Now}
+}
+
+// dayStart is the start of the calendar day (UTC) the daily quota is counted over.
+func dayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (s *quotaService) CheckExpense(userID int) error {
+	if s.quotas.ExpensesPerDay <= 0 {
+		return nil
+	}
+	now := s.now()
+	count, err := s.quotaRepo.CountExpensesCreatedSince(userID, dayStart(now))
+	if err != nil {
+		return err
+	}
+	if count >= s.quotas.ExpensesPerDay {
+		return &QuotaError{Quota: QuotaExpensesPerDay, Limit: int64(s.quotas.ExpensesPerDay), RetryAfter: dayStart(now).AddDate(0, 0, 1).Sub(now)}
+	}
+	return nil
+}
+
+func (s *quotaService) CheckEvent(userID int) error {
+	if s.quotas.Events <= 0 {
+		return nil
+	}
+	count, err := s.quotaRepo.CountActiveEvents(userID)
+	if err != nil {
+		return err
+	}
+	if count >= s.quotas.Events {
+		return &QuotaError{Quota: QuotaEvents, Limit: int64(s.quotas.Events)}
+	}
+	return nil
+}
+
+func (s *quotaService) CheckUpload(userID int, size int) error {
+	if s.quotas.UploadBytes <= 0 {
+		return nil
+	}
+	used, err := s.quotaRepo.CountImportBytes(userID)
+	if err != nil {
+		return err
+	}
+	if used+int64(size) > s.quotas.UploadBytes {
+		return &QuotaError{Quota: QuotaUploadBytes, Limit: s.quotas.UploadBytes}
+	}
+	return nil
+}
+
+func (s *quotaService) GetUsage(userID int) (*QuotaUsage, error) {
+	expenses, err := s.quotaRepo.CountExpensesCreatedSince(userID, dayStart(s.now()))
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.quotaRepo.CountActiveEvents(userID)
+	if err != nil {
+		return nil, err
+	}
+	uploads, err := s.quotaRepo.CountImportBytes(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &QuotaUsage{
+		ExpensesToday:  expenses,
+		ExpensesPerDay: s.quotas.ExpensesPerDay,
+		Events:         events,
+		MaxEvents:      s.quotas.Events,
+		UploadBytes:    uploads,
+		MaxUploadBytes: s.quotas.UploadBytes,
+	}, nil
+}
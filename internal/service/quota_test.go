@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotaService_CheckExpense(t *testing.T) {
+	now := time.Date(2026, 3, 15, 18, 0, 0, 0, time.UTC)
+	today := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	newService := func(repo *repomock.QuotaRepository, quotas Quotas) *quotaService {
+		s := NewQuotaService(repo, quotas).(*quotaService)
+		s.now = func() time.Time { return now }
+		return s
+	}
+
+	// Test case 1: Under the quota
+	{
+		repo := new(repomock.QuotaRepository)
+		repo.On("CountExpensesCreatedSince", 1, today).Return(4, nil).Once()
+		assert.NoError(t, newService(repo, Quotas{ExpensesPerDay: 5}).CheckExpense(1))
+		repo.AssertExpectations(t)
+	}
+
+	// Test case 2: At the quota, retry at midnight UTC
+	{
+		repo := new(repomock.QuotaRepository)
+		repo.On("CountExpensesCreatedSince", 1, today).Return(5, nil).Once()
+		err := newService(repo, Quotas{ExpensesPerDay: 5}).CheckExpense(1)
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+		var quotaErr *QuotaError
+		assert.True(t, errors.As(err, &quotaErr))
+		assert.Equal(t, QuotaExpensesPerDay, quotaErr.Quota)
+		assert.Equal(t, 6*time.Hour, quotaErr.RetryAfter)
+		repo.AssertExpectations(t)
+	}
+
+	// Test case 3: Disabled quota is not counted
+	{
+		repo := new(repomock.QuotaRepository)
+		assert.NoError(t, newService(repo, Quotas{}).CheckExpense(1))
+		repo.AssertExpectations(t)
+	}
+}
+
+func TestQuotaService_CheckEventAndUpload(t *testing.T) {
+	repo := new(repomock.QuotaRepository)
+	s := NewQuotaService(repo, Quotas{Events: 2, UploadBytes: 100})
+
+	// Test case 1: Open events at the quota
+	repo.On("CountActiveEvents", 1).Return(2, nil).Once()
+	err := s.CheckEvent(1)
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	var quotaErr *QuotaError
+	assert.True(t, errors.As(err, &quotaErr))
+	assert.Equal(t, QuotaEvents, quotaErr.Quota)
+	assert.Zero(t, quotaErr.RetryAfter)
+
+	repo.On("CountActiveEvents", 2).Return(1, nil).Once()
+	assert.NoError(t, s.CheckEvent(2))
+
+	// Test case 2: An upload may fill the quota but not pass it
+	repo.On("CountImportBytes", 1).Return(int64(60), nil).Twice()
+	assert.NoError(t, s.CheckUpload(1, 40))
+	assert.ErrorIs(t, s.CheckUpload(1, 41), ErrQuotaExceeded)
+
+	// Test case 3: Repository errors pass through
+	repo.On("CountImportBytes", 3).Return(int64(0), errors.New("db down")).Once()
+	err = s.CheckUpload(3, 1)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrQuotaExceeded)
+
+	repo.AssertExpectations(t)
+}
+
+func TestQuotaService_GetUsage(t *testing.T) {
+	repo := new(repomock.QuotaRepository)
+	s := NewQuotaService(repo, Quotas{ExpensesPerDay: 50, UploadBytes: 1 << 20}).(*quotaService)
+	now := time.Date(2026, 3, 15, 18, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	repo.On("CountExpensesCreatedSince", 1, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)).Return(3, nil).Once()
+	repo.On("CountActiveEvents", 1).Return(2, nil).Once()
+	repo.On("CountImportBytes", 1).Return(int64(512), nil).Once()
+
+	usage, err := s.GetUsage(1)
+	assert.NoError(t, err)
+	assert.Equal(t, &QuotaUsage{ExpensesToday: 3, ExpensesPerDay: 50, Events: 2, UploadBytes: 512, MaxUploadBytes: 1 << 20}, usage)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// ErrRateUnavailable is returned when there is no exchange rate between two currencies.
+var ErrRateUnavailable = errors.New("no exchange rate available")
+
+// ErrUnsupportedCurrency is returned when an expense would move balances in a currency other than
+// util.DefaultCurrency. Balances, settlements and loans are kept per pair of users, not per
+// currency, so amounts in another currency would be added to them as they are.
+var ErrUnsupportedCurrency = errors.New("currency not supported")
+
+// checkBalanceCurrency refuses a currency that balances can't be kept in.
+func checkBalanceCurrency(currency string) error {
+	if currency != util.DefaultCurrency {
+		return fmt.Errorf("%w: %s, balances are kept in %s", ErrUnsupportedCurrency, currency, util.DefaultCurrency)
+	}
+	return nil
+}
+
+// rateDecimals is how many decimal places a rate is rounded to, as the exchange_rate column keeps.
+const rateDecimals = 8
+
+// RateService looks up exchange rates.
+type RateService interface {
+	// Rate returns how much one unit of from is worth in to.
+	Rate(from, to string) (float64, error)
+}
+
+type staticRateService struct {
+	values map[string]float64
+}
+
+// NewStaticRateService answers from fixed rates. values gives what one unit of each currency is
+// worth in a common reference currency, so any two listed currencies can be converted between.
+func NewStaticRateService(values map[string]float64) RateService {
+	upper := make(map[string]float64, len(values))
+	for code, v := range values {
+		upper[strings.ToUpper(code)] = v
+	}
+	return &staticRateService{values: upper}
+}
+
+func (s *staticRateService) Rate(from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	fromValue, toValue := s.values[from], s.values[to]
+	if fromValue <= 0 || toValue <= 0 {
+		return 0, fmt.Errorf("%w from %s to %s", ErrRateUnavailable, from, to)
+	}
+	scale := math.Pow10(rateDecimals)
+	return math.Round(fromValue/toValue*scale) / scale, nil
+}
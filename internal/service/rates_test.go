@@ -0,0 +1,28 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticRateService(t *testing.T) {
+	rates := NewStaticRateService(map[string]float64{"usd": 1, "EUR": 1.1, "INR": 0.012})
+
+	// Test case 1: Rates go through the reference currency, to eight decimal places
+	rate, err := rates.Rate("EUR", "INR")
+	assert.NoError(t, err)
+	assert.Equal(t, 91.66666667, rate)
+	rate, err = rates.Rate("USD", "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.90909091, rate)
+
+	// Test case 2: A currency is always worth itself, listed or not
+	rate, err = rates.Rate("JPY", "JPY")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, rate)
+
+	// Test case 3: Unlisted currencies can't be converted
+	_, err = rates.Rate("JPY", "USD")
+	assert.ErrorIs(t, err, ErrRateUnavailable)
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"text/template"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// SettleUpJobType is the job that sends one event's month-end settle-up.
+const SettleUpJobType = "send_settle_up"
+
+// SettleUpService runs the month-end settle-up: once a month, every unarchived event's suggested
+// transfers are emailed to the people in them, and proposed as settlements for events that opted in
+// with auto_settle.
+type SettleUpService interface {
+	// ScheduleDue enqueues a settle-up job for every event not yet settled up this month and returns
+	// how many.
+	ScheduleDue() (int, error)
+	// Run calls ScheduleDue every interval until ctx is cancelled.
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type settleUpService struct {
+	eventRepo      repository.EventRepository
+	eventService   EventService
+	settlementRepo repository.SettlementRepository
+	expenseRepo    repository.ExpenseRepository
+	userService    UserService
+	jobService     JobService
+	notifier       Notifier
+	now            func() time.Time
+}
+
+// settleUpJob is the payload of a SettleUpJobType job.
+type settleUpJob struct {
+	EventID int `json:"event_id"`
+	// Month is the month being settled up, as 2006-01.
+	Month string `json:"month"`
+}
+
+// NewSettleUpService builds the settle-up service and registers its job with jobService. The event
+// service's summaries are what is emailed, so one that links payments puts pay links in the email.
+func NewSettleUpService(eventRepo repository.EventRepository, eventService EventService, settlementRepo repository.SettlementRepository, expenseRepo repository.ExpenseRepository, userService UserService, jobService JobService, notifier Notifier) SettleUpService {
+	s := &settleUpService{
+		eventRepo:      eventRepo,
+		eventService:   eventService,
+		settlementRepo: settlementRepo,
+		expenseRepo:    expenseRepo,
+		userService:    userService,
+		jobService:     jobService,
+		notifier:       notifier,
+		now:            time.Now,
+	}
+	jobService.Register(SettleUpJobType, s.runSettleUpJob)
+	return s
+}
+
+// ScheduleDue settles up the month that has just ended, so it is due from midnight UTC on the first.
+// An event created during the current month waits for the next one.
+func (s *settleUpService) ScheduleDue() (int, error) {
+	now := s.now().UTC().Truncate(time.Second)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	due, err := s.eventRepo.GetEventsDueForSettleUp(monthStart)
+	if err != nil {
+		return 0, err
+	}
+
+	scheduled := 0
+	for _, e := range due {
+		claimed, err := s.eventRepo.ClaimSettleUp(e.ID, e.LastSettleUpAt, now)
+		if err != nil {
+			return scheduled, err
+		}
+		if !claimed {
+			continue
+		}
+
+		job := settleUpJob{EventID: e.ID, Month: monthStart.AddDate(0, -1, 0).Format("2006-01")}
+		if _, err := s.jobService.Enqueue(SettleUpJobType, job); err != nil {
+			return scheduled, err
+		}
+		scheduled++
+	}
+	return scheduled, nil
+}
+
+func (s *settleUpService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.ScheduleDue(); err != nil {
+			log.Printf("settle-up scheduler: %v", err)
+		} else if n > 0 {
+			log.Printf("settle-up scheduler: queued %d events", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// settleUpLine is one transfer as it reads in a participant's email.
+type settleUpLine struct {
+	SettleUpTransfer
+	Paying   bool
+	With     string
+	Proposed bool
+	Disputed bool
+	// Unit is what the event counts instead of money, if anything
+	Unit string
+}
+
+func (s *settleUpService) runSettleUpJob(ctx context.Context, payload json.RawMessage) error {
+	var job settleUpJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("invalid settle-up job payload: %w", err)
+	}
+
+	summary, err := s.eventService.GetEventSummary(job.EventID)
+	if err != nil {
+		return err
+	}
+	if len(summary.SettleUp) == 0 {
+		return nil
+	}
+
+	emails := util.NewSet[string]()
+	for _, t := range summary.SettleUp {
+		emails.Add(t.FromEmail)
+		emails.Add(t.ToEmail)
+	}
+	users, err := s.userService.GetUsersByEmails(emails.ToList())
+	if err != nil {
+		return fmt.Errorf("failed to get settle-up participants for event %d: %w", job.EventID, err)
+	}
+	byEmail := make(map[string]*repository.User, len(users))
+	for _, u := range users {
+		byEmail[u.Email] = u
+	}
+
+	lines := make([]settleUpLine, len(summary.SettleUp))
+	for i, t := range summary.SettleUp {
+		lines[i].SettleUpTransfer = t
+		lines[i].Unit = summary.Unit
+	}
+	// Settlements go in before any email, so the email can say what was proposed. Settlements move
+	// money, so an event counting its own unit only gets the email
+	if summary.AutoSettle && summary.Unit == "" {
+		if err := s.proposeSettlements(lines, byEmail); err != nil {
+			return fmt.Errorf("failed to propose settle-up for event %d: %w", job.EventID, err)
+		}
+	}
+
+	for _, u := range users {
+		var mine []settleUpLine
+		for _, l := range lines {
+			switch u.Email {
+			case l.FromEmail:
+				l.Paying, l.With = true, l.ToEmail
+			case l.ToEmail:
+				l.Paying, l.With = false, l.FromEmail
+			default:
+				continue
+			}
+			mine = append(mine, l)
+		}
+
+		var body bytes.Buffer
+		if err := settleUpEmail.Execute(&body, struct {
+			UserName  string
+			EventName string
+			Month     string
+			Lines     []settleUpLine
+		}{u.Name, summary.Name, job.Month, mine}); err != nil {
+			return fmt.Errorf("failed to render settle-up: %w", err)
+		}
+		if err := s.notifier.Notify(ctx, Notification{
+			UserID:  u.ID,
+			Kind:    repository.KindReminder,
+			To:      u.Email,
+			Subject: fmt.Sprintf("Settle up %s", summary.Name),
+			Body:    body.String(),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// proposeSettlements proposes a settlement for each line, all together, and marks the lines it
+// proposed. A pair with a disputed expense is left out, and so is a transfer that already has an
+// open settlement for the same amount, which keeps a retried job from proposing it twice.
+func (s *settleUpService) proposeSettlements(lines []settleUpLine, byEmail map[string]*repository.User) error {
+	var settlements []*repository.Settlement
+	var proposed []int
+	for i, l := range lines {
+		payer, payee := byEmail[l.FromEmail], byEmail[l.ToEmail]
+		if payer == nil || payee == nil {
+			continue
+		}
+
+		disputed, err := s.expenseRepo.HasDisputedExpenseBetween(payer.ID, payee.ID)
+		if err != nil {
+			return fmt.Errorf("failed to check disputed expenses for settle-up: %w", err)
+		}
+		if disputed {
+			lines[i].Disputed = true
+			continue
+		}
+
+		open, err := s.settlementRepo.GetSettlementsByUserID(payer.ID)
+		if err != nil {
+			return err
+		}
+		if hasOpenSettlement(open, payer.ID, payee.ID, l.Amount) {
+			lines[i].Proposed = true
+			continue
+		}
+
+		settlements = append(settlements, &repository.Settlement{PayerID: payer.ID, PayeeID: payee.ID, Amount: l.Amount})
+		proposed = append(proposed, i)
+	}
+
+	if len(settlements) > 0 {
+		if _, err := s.settlementRepo.CreateSettlements(settlements); err != nil {
+			return err
+		}
+	}
+	for _, i := range proposed {
+		lines[i].Proposed = true
+	}
+	return nil
+}
+
+// hasOpenSettlement reports whether settlements include one still under way from payer to payee
+// for amount.
+func hasOpenSettlement(settlements []repository.Settlement, payerID, payeeID int, amount float64) bool {
+	for _, st := range settlements {
+		if st.PayerID == payerID && st.PayeeID == payeeID && st.ViaUserID == nil &&
+			util.ToMinorUnits(st.Amount, util.DefaultExponent) == util.ToMinorUnits(amount, util.DefaultExponent) && containsSettlementStatus(openSettlementStatuses, st.Status) {
+			return true
+		}
+	}
+	return false
+}
+
+var settleUpEmail = template.Must(template.New("settle-up").Parse(`Hi {{.UserName}},
+
+{{.Month}} is over. To square up {{.EventName}}:
+
+{{range .Lines}}  {{if .Paying}}Pay {{.With}}{{else}}{{.With}} pays you{{end}} {{if .Unit}}{{printf "%.0f" .Amount}} {{.Unit}}{{else}}{{printf "%.2f" .Amount}} {{.Currency}}{{end}}{{if .Proposed}} (proposed as a settlement){{else if .Disputed}} (on hold while an expense between you is disputed){{end}}{{if .Paying}}{{range .Payments}}
+    {{.Provider}}: {{.URL}}{{end}}{{end}}
+{{end}}`))
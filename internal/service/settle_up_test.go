@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// summarizedEventService answers GetEventSummary with a fixed summary.
+type summarizedEventService struct {
+	EventService
+	summary *EventSummary
+}
+
+func (s summarizedEventService) GetEventSummary(int) (*EventSummary, error) {
+	return s.summary, nil
+}
+
+func TestSettleUpService_ScheduleDue(t *testing.T) {
+	eventRepo := new(repomock.EventRepository)
+	jobRepo := new(repomock.JobRepository)
+	now := time.Date(2026, 11, 1, 0, 30, 0, 0, time.UTC)
+	jobs := NewJobService(jobRepo, JobOptions{MaxAttempts: 3})
+	s := NewSettleUpService(eventRepo, nil, nil, nil, nil, jobs, NewLogNotifier()).(*settleUpService)
+	s.now = func() time.Time { return now }
+
+	lastMonth := time.Date(2026, 10, 1, 0, 10, 0, 0, time.UTC)
+	eventRepo.On("GetEventsDueForSettleUp", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)).Return([]repository.Event{
+		{ID: 1, LastSettleUpAt: &lastMonth},
+		{ID: 2},
+	}, nil).Once()
+	eventRepo.On("ClaimSettleUp", 1, &lastMonth, now).Return(true, nil).Once()
+	// Another server got to event 2 first
+	eventRepo.On("ClaimSettleUp", 2, (*time.Time)(nil), now).Return(false, nil).Once()
+
+	var payload settleUpJob
+	jobRepo.On("CreateJob", mock.Anything).Run(func(args mock.Arguments) {
+		assert.NoError(t, json.Unmarshal(args.Get(0).(*repository.Job).Payload, &payload))
+	}).Return(&repository.Job{}, nil).Once()
+
+	n, err := s.ScheduleDue()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, settleUpJob{EventID: 1, Month: "2026-10"}, payload)
+
+	eventRepo.AssertExpectations(t)
+	jobRepo.AssertExpectations(t)
+}
+
+func TestSettleUpService_RunSettleUpJob(t *testing.T) {
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+	carol := &repository.User{ID: 3, Name: "Carol", Email: "carol@example.com"}
+	summary := &EventSummary{
+		Event: repository.Event{ID: 5, Name: "Flat"},
+		SettleUp: []SettleUpTransfer{
+			{FromEmail: bob.Email, ToEmail: alice.Email, Currency: "INR", Amount: 300},
+			{FromEmail: carol.Email, ToEmail: alice.Email, Currency: "INR", Amount: 100},
+		},
+	}
+	payload, _ := json.Marshal(settleUpJob{EventID: 5, Month: "2026-10"})
+
+	setup := func() (*settleUpService, *repomock.SettlementRepository, *repomock.ExpenseRepository, *MockNotifier) {
+		settlementRepo := new(repomock.SettlementRepository)
+		expenseRepo := new(repomock.ExpenseRepository)
+		userService := new(MockUserService)
+		notifier := new(MockNotifier)
+		jobs := NewJobService(new(repomock.JobRepository), JobOptions{})
+		s := NewSettleUpService(nil, summarizedEventService{summary: summary}, settlementRepo, expenseRepo, userService, jobs, notifier).(*settleUpService)
+		userService.On("GetUsersByEmails", mock.Anything).Return([]*repository.User{alice, bob, carol}, nil)
+		notifier.On("Notify", mock.Anything).Return(nil)
+		return s, settlementRepo, expenseRepo, notifier
+	}
+	bodyFor := func(notifier *MockNotifier, email string) string {
+		for _, c := range notifier.Calls {
+			if n := c.Arguments.Get(0).(Notification); n.To == email {
+				assert.Equal(t, repository.KindReminder, n.Kind)
+				return n.Body
+			}
+		}
+		t.Fatalf("no settle-up email to %s", email)
+		return ""
+	}
+
+	// Test case 1: Everyone in a transfer hears about theirs; nothing is proposed without opting in
+	{
+		s, settlementRepo, _, notifier := setup()
+		require.NoError(t, s.runSettleUpJob(context.Background(), payload))
+		notifier.AssertNumberOfCalls(t, "Notify", 3)
+		assert.Contains(t, bodyFor(notifier, bob.Email), "Pay alice@example.com 300.00 INR\n")
+		assert.Contains(t, bodyFor(notifier, alice.Email), "carol@example.com pays you 100.00 INR\n")
+		settlementRepo.AssertNotCalled(t, "CreateSettlements", mock.Anything)
+	}
+
+	// Test case 2: Opted in, transfers are proposed together, except with a pair in dispute
+	summary.AutoSettle = true
+	{
+		s, settlementRepo, expenseRepo, notifier := setup()
+		expenseRepo.On("HasDisputedExpenseBetween", bob.ID, alice.ID).Return(false, nil).Once()
+		expenseRepo.On("HasDisputedExpenseBetween", carol.ID, alice.ID).Return(true, nil).Once()
+		settlementRepo.On("GetSettlementsByUserID", bob.ID).Return([]repository.Settlement{}, nil).Once()
+		settlementRepo.On("CreateSettlements", []*repository.Settlement{{PayerID: bob.ID, PayeeID: alice.ID, Amount: 300}}).Return([]*repository.Settlement{{ID: 9}}, nil).Once()
+
+		require.NoError(t, s.runSettleUpJob(context.Background(), payload))
+		assert.Contains(t, bodyFor(notifier, bob.Email), "300.00 INR (proposed as a settlement)")
+		assert.Contains(t, bodyFor(notifier, carol.Email), "100.00 INR (on hold while an expense between you is disputed)")
+		settlementRepo.AssertExpectations(t)
+		expenseRepo.AssertExpectations(t)
+	}
+
+	// Test case 3: A retried job doesn't propose a transfer that is already under way
+	{
+		s, settlementRepo, expenseRepo, _ := setup()
+		expenseRepo.On("HasDisputedExpenseBetween", mock.Anything, mock.Anything).Return(false, nil)
+		settlementRepo.On("GetSettlementsByUserID", bob.ID).Return([]repository.Settlement{
+			{ID: 9, PayerID: bob.ID, PayeeID: alice.ID, Amount: 300, Status: repository.SettlementProposed},
+		}, nil).Once()
+		settlementRepo.On("GetSettlementsByUserID", carol.ID).Return([]repository.Settlement{}, nil).Once()
+		settlementRepo.On("CreateSettlements", []*repository.Settlement{{PayerID: carol.ID, PayeeID: alice.ID, Amount: 100}}).Return([]*repository.Settlement{{ID: 10}}, nil).Once()
+
+		require.NoError(t, s.runSettleUpJob(context.Background(), payload))
+		settlementRepo.AssertExpectations(t)
+	}
+
+	// Test case 4: An event counting chore points only emails them, in whole points
+	summary.Unit = "chore points"
+	summary.SettleUp = []SettleUpTransfer{{FromEmail: bob.Email, ToEmail: alice.Email, Currency: "XXX", Amount: 3}}
+	{
+		s, settlementRepo, _, notifier := setup()
+		require.NoError(t, s.runSettleUpJob(context.Background(), payload))
+		assert.Contains(t, bodyFor(notifier, bob.Email), "Pay alice@example.com 3 chore points\n")
+		settlementRepo.AssertNotCalled(t, "CreateSettlements", mock.Anything)
+	}
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// settlementTransitions lists, for each target state, the states a settlement may move from.
+// Balances only change when a settlement reaches confirmed.
+var settlementTransitions = map[repository.SettlementStatus][]repository.SettlementStatus{
+	repository.SettlementSent:      {repository.SettlementProposed, repository.SettlementDisputed},
+	repository.SettlementConfirmed: {repository.SettlementProposed, repository.SettlementSent},
+	repository.SettlementDisputed:  {repository.SettlementProposed, repository.SettlementSent},
+}
+
+// ErrSettlementBlockedByDispute is returned when the two users share an expense that is still under dispute.
+var ErrSettlementBlockedByDispute = errors.New("settlement blocked by a disputed expense")
+
+// ErrSettlementExceedsBalance is returned when a proposed settlement pays more than is owed.
+var ErrSettlementExceedsBalance = errors.New("settlement exceeds the outstanding balance")
+
+// ErrNotSettlementParty is returned when a user moves a settlement in a way only the other party may.
+var ErrNotSettlementParty = errors.New("user is not allowed to act on this settlement")
+
+// TransitionSettlementRequest names the user moving a settlement along.
+type TransitionSettlementRequest struct {
+	UserEmail string `json:"user_email"`
+}
+
+type ProposeSettlementRequest struct {
+	PayerEmail string  `json:"payer_email"`
+	PayeeEmail string  `json:"payee_email"`
+	Amount     float64 `json:"amount"`
+	// OnBehalfOfEmail routes the settlement through another user: the payer pays the payee what
+	// that user owes them, and that user now owes the payer instead.
+	OnBehalfOfEmail string `json:"on_behalf_of_email,omitempty"`
+}
+
+type SettlementView struct {
+	ID int `json:"id"`
+	// Direction is "paying" or "receiving" from the viewing user's side, or "routed" for a settlement
+	// routed through them, where the user paying is the one it is with.
+	Direction     string `json:"direction"`
+	WithUserEmail string `json:"with_user_email"`
+	WithUserName  string `json:"with_user_name"`
+	// ViaUserEmail is set on a routed settlement to whoever it is routed through, or, when the
+	// viewing user is that one, to whoever is paid.
+	ViaUserEmail string  `json:"via_user_email,omitempty"`
+	Amount       float64 `json:"amount"`
+	// OutstandingAmount is what the payer owed when the settlement was proposed and RemainingAmount
+	// what they still owe once it is paid, so paying 20 of 55 leaves 35. Both are left out when no
+	// outstanding amount was recorded. The via amounts are the same for what the user a routed
+	// settlement goes through owed the payee.
+	OutstandingAmount    *float64                    `json:"outstanding_amount,omitempty"`
+	RemainingAmount      *float64                    `json:"remaining_amount,omitempty"`
+	ViaOutstandingAmount *float64                    `json:"via_outstanding_amount,omitempty"`
+	ViaRemainingAmount   *float64                    `json:"via_remaining_amount,omitempty"`
+	Status               repository.SettlementStatus `json:"status"`
+	CreatedAt            time.Time                   `json:"created_at"`
+	UpdatedAt            time.Time                   `json:"updated_at"`
+}
+
+type SettlementService interface {
+	ProposeSettlement(req ProposeSettlementRequest) (*repository.Settlement, error)
+	// TransitionSettlement moves a settlement on behalf of one of its parties: only the payer may mark
+	// it sent, and only the payee confirm or dispute it.
+	TransitionSettlement(id int, to repository.SettlementStatus, req TransitionSettlementRequest) (*repository.Settlement, error)
+	GetSettlementsForUser(userEmail string) ([]SettlementView, error)
+	// SimplifyDebts proposes the fewest transfers that settle all of a user's balances.
+	SimplifyDebts(userEmail string) (*DebtSimplification, error)
+	// ApplyDebtSimplification proposes a settlement for each transfer SimplifyDebts finds.
+	ApplyDebtSimplification(req ApplyDebtSimplificationRequest) ([]*repository.Settlement, error)
+}
+
+type settlementService struct {
+	settlementRepo repository.SettlementRepository
+	expenseRepo    repository.ExpenseRepository
+	balanceRepo    repository.BalanceRepository
+	userService    UserService
+}
+
+func NewSettlementService(settlementRepo repository.SettlementRepository, expenseRepo repository.ExpenseRepository, balanceRepo repository.BalanceRepository, userService UserService) SettlementService {
+	return &settlementService{settlementRepo: settlementRepo, expenseRepo: expenseRepo, balanceRepo: balanceRepo, userService: userService}
+}
+
+func (s *settlementService) ProposeSettlement(req ProposeSettlementRequest) (*repository.Settlement, error) {
+	emails := []string{req.PayerEmail, req.PayeeEmail}
+	if req.OnBehalfOfEmail != "" {
+		emails = append(emails, req.OnBehalfOfEmail)
+	}
+	users, err := s.userService.GetUsersByEmails(emails)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch users for settlement: %w", err)
+	}
+
+	usersMap := make(map[string]*repository.User, len(users))
+	for _, u := range users {
+		usersMap[u.Email] = u
+	}
+
+	payer, ok := usersMap[util.NormalizeEmail(req.PayerEmail)]
+	if !ok {
+		return nil, fmt.Errorf("payer not found: %s", req.PayerEmail)
+	}
+	payee, ok := usersMap[util.NormalizeEmail(req.PayeeEmail)]
+	if !ok {
+		return nil, fmt.Errorf("payee not found: %s", req.PayeeEmail)
+	}
+	settlement := &repository.Settlement{
+		PayerID: payer.ID,
+		PayeeID: payee.ID,
+		Amount:  util.RoundToDefaultCurrency(req.Amount),
+	}
+	if req.OnBehalfOfEmail != "" {
+		via, ok := usersMap[util.NormalizeEmail(req.OnBehalfOfEmail)]
+		if !ok {
+			return nil, fmt.Errorf("user paid on behalf of not found: %s", req.OnBehalfOfEmail)
+		}
+		settlement.ViaUserID = &via.ID
+	}
+
+	// A disputed expense has to be dismissed before the pair can settle up, and a routed settlement
+	// moves two pairs
+	emailsByID := make(map[int]string, len(users))
+	for _, u := range users {
+		emailsByID[u.ID] = u.Email
+	}
+	for _, u := range settlement.BalanceUpdates() {
+		disputed, err := s.expenseRepo.HasDisputedExpenseBetween(u.User1ID, u.User2ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check disputed expenses for settlement: %w", err)
+		}
+		if disputed {
+			return nil, fmt.Errorf("%w between %s and %s", ErrSettlementBlockedByDispute, emailsByID[u.User1ID], emailsByID[u.User2ID])
+		}
+	}
+
+	// The amount may pay only part of the balance but no more than all of it; what each leg was paying
+	// towards is kept with it
+	balances, err := s.balanceRepo.GetBalancesByUserID(payer.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances for settlement: %w", err)
+	}
+	if settlement.ViaUserID == nil {
+		settlement.OutstandingAmount, err = outstandingFor(balances, payer.ID, payee.ID, settlement.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("%w by %s to %s", err, payer.Email, payee.Email)
+		}
+	} else {
+		// Paying on the via user's behalf may leave them owing the payer, so only their debt to the
+		// payee caps the amount
+		if owed := pairOwed(balances, payer.ID, *settlement.ViaUserID); owed > 0 {
+			amount := util.FromMinorUnits(owed, util.DefaultExponent)
+			settlement.OutstandingAmount = &amount
+		}
+		viaBalances, err := s.balanceRepo.GetBalancesByUserID(*settlement.ViaUserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get balances for settlement: %w", err)
+		}
+		settlement.ViaOutstandingAmount, err = outstandingFor(viaBalances, *settlement.ViaUserID, payee.ID, settlement.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("%w by %s to %s", err, emailsByID[*settlement.ViaUserID], payee.Email)
+		}
+	}
+
+	settlement, err = s.settlementRepo.CreateSettlement(settlement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create settlement in service: %w", err)
+	}
+
+	return settlement, nil
+}
+
+// outstandingFor returns what the debtor owes the creditor by balances that include their pair,
+// refusing an amount that is more than that.
+func outstandingFor(balances []repository.Balance, debtorID, creditorID int, amount float64) (*float64, error) {
+	exp := util.DefaultExponent
+	owed := pairOwed(balances, debtorID, creditorID)
+	if util.ToMinorUnits(amount, exp) > owed {
+		return nil, fmt.Errorf("%w: only %.*f is owed", ErrSettlementExceedsBalance, exp, util.FromMinorUnits(max(0, owed), exp))
+	}
+	outstanding := util.FromMinorUnits(owed, exp)
+	return &outstanding, nil
+}
+
+// pairOwed returns, in minor units, what the debtor owes the creditor by balances that include their
+// pair, negative when the creditor owes the debtor.
+func pairOwed(balances []repository.Balance, debtorID, creditorID int) int64 {
+	// A positive balance is what User2 owes User1
+	for _, b := range balances {
+		switch {
+		case b.User1ID == creditorID && b.User2ID == debtorID:
+			return util.ToMinorUnits(b.Balance, util.DefaultExponent)
+		case b.User1ID == debtorID && b.User2ID == creditorID:
+			return -util.ToMinorUnits(b.Balance, util.DefaultExponent)
+		}
+	}
+	return 0
+}
+
+func (s *settlementService) TransitionSettlement(id int, to repository.SettlementStatus, req TransitionSettlementRequest) (*repository.Settlement, error) {
+	from, ok := settlementTransitions[to]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not a target state", repository.ErrInvalidSettlementTransition, to)
+	}
+
+	users, err := s.userService.GetUsersByEmails([]string{req.UserEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", req.UserEmail)
+	}
+	settlement, err := s.settlementRepo.GetSettlement(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settlement %d: %w", id, err)
+	}
+	// The payer saying the money went out is no proof it arrived; only the payee can say that
+	if to == repository.SettlementSent && users[0].ID != settlement.PayerID {
+		return nil, fmt.Errorf("%w: only the payer can mark settlement %d as sent", ErrNotSettlementParty, id)
+	}
+	if to != repository.SettlementSent && users[0].ID != settlement.PayeeID {
+		return nil, fmt.Errorf("%w: only the payee can mark settlement %d as %s", ErrNotSettlementParty, id, to)
+	}
+
+	settlement, err = s.settlementRepo.TransitionSettlement(id, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark settlement %d as %s: %w", id, to, err)
+	}
+
+	return settlement, nil
+}
+
+func (s *settlementService) GetSettlementsForUser(userEmail string) ([]SettlementView, error) {
+	users, err := s.userService.GetUsersByEmails([]string{userEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", userEmail)
+	}
+
+	userID := users[0].ID
+	settlements, err := s.settlementRepo.GetSettlementsByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settlements for user %s: %w", userEmail, err)
+	}
+
+	// Fetch all counterparties in a single batch call
+	counterpartyIDs := util.NewSet[int]()
+	var ids []int
+	for _, st := range settlements {
+		for _, otherID := range st.UserIDs() {
+			if otherID != userID && !counterpartyIDs.IsMember(otherID) {
+				counterpartyIDs.Add(otherID)
+				ids = append(ids, otherID)
+			}
+		}
+	}
+
+	others, err := s.userService.GetUsersByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch counterparties for settlements: %w", err)
+	}
+	othersMap := make(map[int]*repository.User, len(others))
+	for _, u := range others {
+		othersMap[u.ID] = u
+	}
+
+	views := make([]SettlementView, 0, len(settlements))
+	for _, st := range settlements {
+		view := SettlementView{
+			ID:                   st.ID,
+			Direction:            "paying",
+			Amount:               st.Amount,
+			OutstandingAmount:    st.OutstandingAmount,
+			RemainingAmount:      st.RemainingAmount(),
+			ViaOutstandingAmount: st.ViaOutstandingAmount,
+			ViaRemainingAmount:   st.ViaRemainingAmount(),
+			Status:               st.Status,
+			CreatedAt:            st.CreatedAt,
+			UpdatedAt:            st.UpdatedAt,
+		}
+
+		otherID, viaID := st.PayeeID, st.ViaUserID
+		switch {
+		case st.PayeeID == userID:
+			view.Direction = "receiving"
+			otherID = st.PayerID
+		case viaID != nil && *viaID == userID:
+			view.Direction = "routed"
+			otherID, viaID = st.PayerID, &st.PayeeID
+		}
+		if other, ok := othersMap[otherID]; ok {
+			view.WithUserEmail = other.Email
+			view.WithUserName = other.Name
+		}
+		if viaID != nil {
+			if via, ok := othersMap[*viaID]; ok {
+				view.ViaUserEmail = via.Email
+			}
+		}
+
+		views = append(views, view)
+	}
+
+	return views, nil
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSettlementService_ProposeSettlement(t *testing.T) {
+	settlementRepo := new(repomock.SettlementRepository)
+	expenseRepo := new(repomock.ExpenseRepository)
+	userService := new(MockUserService)
+	balanceRepo := new(repomock.BalanceRepository)
+	settlementService := NewSettlementService(settlementRepo, expenseRepo, balanceRepo, userService)
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+
+	// Test case 1: Successful proposal paying off what Bob owes
+	{
+		req := ProposeSettlementRequest{PayerEmail: bob.Email, PayeeEmail: alice.Email, Amount: 25.004}
+		owed := 25.0
+		expected := &repository.Settlement{PayerID: bob.ID, PayeeID: alice.ID, Amount: 25.00, OutstandingAmount: &owed}
+
+		userService.On("GetUsersByEmails", []string{bob.Email, alice.Email}).Return([]*repository.User{bob, alice}, nil).Once()
+		expenseRepo.On("HasDisputedExpenseBetween", bob.ID, alice.ID).Return(false, nil).Once()
+		balanceRepo.On("GetBalancesByUserID", bob.ID).Return([]repository.Balance{{User1ID: alice.ID, User2ID: bob.ID, Balance: 25}}, nil).Once()
+		settlementRepo.On("CreateSettlement", expected).Return(&repository.Settlement{ID: 1, PayerID: bob.ID, PayeeID: alice.ID, Amount: 25.00, Status: repository.SettlementProposed}, nil).Once()
+
+		settlement, err := settlementService.ProposeSettlement(req)
+		assert.Nil(t, err)
+		assert.Equal(t, repository.SettlementProposed, settlement.Status)
+		settlementRepo.AssertExpectations(t)
+		userService.AssertExpectations(t)
+	}
+
+	// Test case 2: Payee not found
+	{
+		req := ProposeSettlementRequest{PayerEmail: bob.Email, PayeeEmail: "ghost@example.com", Amount: 10}
+		userService.On("GetUsersByEmails", []string{bob.Email, "ghost@example.com"}).Return([]*repository.User{bob}, nil).Once()
+
+		settlement, err := settlementService.ProposeSettlement(req)
+		assert.Nil(t, settlement)
+		assert.Contains(t, err.Error(), "payee not found: ghost@example.com")
+		settlementRepo.AssertNumberOfCalls(t, "CreateSettlement", 1)
+	}
+
+	// Test case 3: A disputed expense between the pair blocks the settlement
+	{
+		req := ProposeSettlementRequest{PayerEmail: bob.Email, PayeeEmail: alice.Email, Amount: 10}
+		userService.On("GetUsersByEmails", []string{bob.Email, alice.Email}).Return([]*repository.User{bob, alice}, nil).Once()
+		expenseRepo.On("HasDisputedExpenseBetween", bob.ID, alice.ID).Return(true, nil).Once()
+
+		settlement, err := settlementService.ProposeSettlement(req)
+		assert.Nil(t, settlement)
+		assert.ErrorIs(t, err, ErrSettlementBlockedByDispute)
+		settlementRepo.AssertNumberOfCalls(t, "CreateSettlement", 1)
+		expenseRepo.AssertExpectations(t)
+	}
+
+	// Test case 4: Paying 20 of the 55 Bob owes leaves 35 outstanding
+	{
+		req := ProposeSettlementRequest{PayerEmail: bob.Email, PayeeEmail: alice.Email, Amount: 20}
+		owed := 55.0
+		expected := &repository.Settlement{PayerID: bob.ID, PayeeID: alice.ID, Amount: 20, OutstandingAmount: &owed}
+
+		userService.On("GetUsersByEmails", []string{bob.Email, alice.Email}).Return([]*repository.User{bob, alice}, nil).Once()
+		expenseRepo.On("HasDisputedExpenseBetween", bob.ID, alice.ID).Return(false, nil).Once()
+		balanceRepo.On("GetBalancesByUserID", bob.ID).Return([]repository.Balance{{User1ID: alice.ID, User2ID: bob.ID, Balance: 55}}, nil).Once()
+		settlementRepo.On("CreateSettlement", expected).Return(expected, nil).Once()
+
+		settlement, err := settlementService.ProposeSettlement(req)
+		assert.Nil(t, err)
+		if assert.NotNil(t, settlement.RemainingAmount()) {
+			assert.Equal(t, 35.0, *settlement.RemainingAmount())
+		}
+		balanceRepo.AssertExpectations(t)
+	}
+
+	// Test case 5: Carol pays Alice what Bob owes her, routed through Bob so he owes Carol instead
+	{
+		carol := &repository.User{ID: 3, Name: "Carol", Email: "carol@example.com"}
+		req := ProposeSettlementRequest{PayerEmail: carol.Email, PayeeEmail: alice.Email, OnBehalfOfEmail: bob.Email, Amount: 30}
+		owed := 45.0
+		expected := &repository.Settlement{PayerID: carol.ID, PayeeID: alice.ID, ViaUserID: &bob.ID, Amount: 30, ViaOutstandingAmount: &owed}
+
+		userService.On("GetUsersByEmails", []string{carol.Email, alice.Email, bob.Email}).Return([]*repository.User{alice, bob, carol}, nil).Once()
+		expenseRepo.On("HasDisputedExpenseBetween", carol.ID, bob.ID).Return(false, nil).Once()
+		expenseRepo.On("HasDisputedExpenseBetween", bob.ID, alice.ID).Return(false, nil).Once()
+		balanceRepo.On("GetBalancesByUserID", carol.ID).Return([]repository.Balance{}, nil).Once()
+		balanceRepo.On("GetBalancesByUserID", bob.ID).Return([]repository.Balance{{User1ID: alice.ID, User2ID: bob.ID, Balance: 45}}, nil).Once()
+		settlementRepo.On("CreateSettlement", expected).Return(expected, nil).Once()
+
+		settlement, err := settlementService.ProposeSettlement(req)
+		assert.Nil(t, err)
+		assert.Equal(t, []repository.BalanceUpdate{
+			{User1ID: carol.ID, User2ID: bob.ID, Amount: 30},
+			{User1ID: bob.ID, User2ID: alice.ID, Amount: 30},
+		}, settlement.BalanceUpdates())
+		assert.Nil(t, settlement.RemainingAmount())
+		if assert.NotNil(t, settlement.ViaRemainingAmount()) {
+			assert.Equal(t, 15.0, *settlement.ViaRemainingAmount())
+		}
+		expenseRepo.AssertExpectations(t)
+		balanceRepo.AssertExpectations(t)
+	}
+
+	// Test case 6: A dispute on either leg blocks a routed settlement
+	{
+		carol := &repository.User{ID: 3, Name: "Carol", Email: "carol@example.com"}
+		req := ProposeSettlementRequest{PayerEmail: carol.Email, PayeeEmail: alice.Email, OnBehalfOfEmail: bob.Email, Amount: 30}
+
+		userService.On("GetUsersByEmails", []string{carol.Email, alice.Email, bob.Email}).Return([]*repository.User{alice, bob, carol}, nil).Once()
+		expenseRepo.On("HasDisputedExpenseBetween", carol.ID, bob.ID).Return(false, nil).Once()
+		expenseRepo.On("HasDisputedExpenseBetween", bob.ID, alice.ID).Return(true, nil).Once()
+
+		settlement, err := settlementService.ProposeSettlement(req)
+		assert.Nil(t, settlement)
+		assert.ErrorIs(t, err, ErrSettlementBlockedByDispute)
+		assert.Contains(t, err.Error(), "between bob@example.com and alice@example.com")
+		settlementRepo.AssertNumberOfCalls(t, "CreateSettlement", 3)
+	}
+
+	// Test case 7: Paying more than Bob owes is refused
+	{
+		req := ProposeSettlementRequest{PayerEmail: bob.Email, PayeeEmail: alice.Email, Amount: 60}
+		userService.On("GetUsersByEmails", []string{bob.Email, alice.Email}).Return([]*repository.User{bob, alice}, nil).Once()
+		expenseRepo.On("HasDisputedExpenseBetween", bob.ID, alice.ID).Return(false, nil).Once()
+		balanceRepo.On("GetBalancesByUserID", bob.ID).Return([]repository.Balance{{User1ID: alice.ID, User2ID: bob.ID, Balance: 55}}, nil).Once()
+
+		settlement, err := settlementService.ProposeSettlement(req)
+		assert.Nil(t, settlement)
+		assert.ErrorIs(t, err, ErrSettlementExceedsBalance)
+		assert.Contains(t, err.Error(), "only 55.00 is owed by bob@example.com to alice@example.com")
+		settlementRepo.AssertNumberOfCalls(t, "CreateSettlement", 3)
+	}
+
+	// Test case 8: Paying on Bob's behalf more than he owes Alice is refused, even though Carol owes Bob
+	{
+		carol := &repository.User{ID: 3, Name: "Carol", Email: "carol@example.com"}
+		req := ProposeSettlementRequest{PayerEmail: carol.Email, PayeeEmail: alice.Email, OnBehalfOfEmail: bob.Email, Amount: 30}
+
+		userService.On("GetUsersByEmails", []string{carol.Email, alice.Email, bob.Email}).Return([]*repository.User{alice, bob, carol}, nil).Once()
+		expenseRepo.On("HasDisputedExpenseBetween", carol.ID, bob.ID).Return(false, nil).Once()
+		expenseRepo.On("HasDisputedExpenseBetween", bob.ID, alice.ID).Return(false, nil).Once()
+		balanceRepo.On("GetBalancesByUserID", carol.ID).Return([]repository.Balance{{User1ID: bob.ID, User2ID: carol.ID, Balance: 100}}, nil).Once()
+		balanceRepo.On("GetBalancesByUserID", bob.ID).Return([]repository.Balance{{User1ID: alice.ID, User2ID: bob.ID, Balance: 10}}, nil).Once()
+
+		settlement, err := settlementService.ProposeSettlement(req)
+		assert.Nil(t, settlement)
+		assert.ErrorIs(t, err, ErrSettlementExceedsBalance)
+		assert.Contains(t, err.Error(), "only 10.00 is owed by bob@example.com to alice@example.com")
+		settlementRepo.AssertNumberOfCalls(t, "CreateSettlement", 3)
+	}
+}
+
+func TestSettlementService_TransitionSettlement(t *testing.T) {
+	settlementRepo := new(repomock.SettlementRepository)
+	userService := new(MockUserService)
+	settlementService := NewSettlementService(settlementRepo, new(repomock.ExpenseRepository), new(repomock.BalanceRepository), userService)
+
+	alice := &repository.User{ID: 1, Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Email: "bob@example.com"}
+	userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil)
+	userService.On("GetUsersByEmails", []string{bob.Email}).Return([]*repository.User{bob}, nil)
+	// Bob pays Alice
+	settlementRepo.On("GetSettlement", 1).Return(&repository.Settlement{ID: 1, PayerID: bob.ID, PayeeID: alice.ID}, nil)
+
+	// Test case 1: The payee confirming passes the allowed source states to the repository
+	{
+		from := []repository.SettlementStatus{repository.SettlementProposed, repository.SettlementSent}
+		settlementRepo.On("TransitionSettlement", 1, from, repository.SettlementConfirmed).Return(&repository.Settlement{ID: 1, Status: repository.SettlementConfirmed}, nil).Once()
+
+		settlement, err := settlementService.TransitionSettlement(1, repository.SettlementConfirmed, TransitionSettlementRequest{UserEmail: alice.Email})
+		assert.Nil(t, err)
+		assert.Equal(t, repository.SettlementConfirmed, settlement.Status)
+		settlementRepo.AssertExpectations(t)
+	}
+
+	// Test case 2: Proposed is never a target state
+	{
+		settlement, err := settlementService.TransitionSettlement(1, repository.SettlementProposed, TransitionSettlementRequest{UserEmail: alice.Email})
+		assert.Nil(t, settlement)
+		assert.ErrorIs(t, err, repository.ErrInvalidSettlementTransition)
+		settlementRepo.AssertNumberOfCalls(t, "TransitionSettlement", 1)
+	}
+
+	// Test case 3: Repository rejection is passed through
+	{
+		settlementRepo.On("GetSettlement", 2).Return(&repository.Settlement{ID: 2, PayerID: bob.ID, PayeeID: alice.ID}, nil).Once()
+		settlementRepo.On("TransitionSettlement", 2, mock.Anything, repository.SettlementDisputed).Return((*repository.Settlement)(nil), repository.ErrInvalidSettlementTransition).Once()
+
+		settlement, err := settlementService.TransitionSettlement(2, repository.SettlementDisputed, TransitionSettlementRequest{UserEmail: alice.Email})
+		assert.Nil(t, settlement)
+		assert.ErrorIs(t, err, repository.ErrInvalidSettlementTransition)
+		settlementRepo.AssertExpectations(t)
+	}
+
+	// Test case 4: The payer can't confirm or dispute what they paid, and the payee can't mark it sent
+	{
+		for _, to := range []repository.SettlementStatus{repository.SettlementConfirmed, repository.SettlementDisputed} {
+			_, err := settlementService.TransitionSettlement(1, to, TransitionSettlementRequest{UserEmail: bob.Email})
+			assert.ErrorIs(t, err, ErrNotSettlementParty, to)
+		}
+		_, err := settlementService.TransitionSettlement(1, repository.SettlementSent, TransitionSettlementRequest{UserEmail: alice.Email})
+		assert.ErrorIs(t, err, ErrNotSettlementParty)
+		settlementRepo.AssertNumberOfCalls(t, "TransitionSettlement", 2)
+	}
+
+	// Test case 5: The payer marks it sent
+	{
+		from := []repository.SettlementStatus{repository.SettlementProposed, repository.SettlementDisputed}
+		settlementRepo.On("TransitionSettlement", 1, from, repository.SettlementSent).Return(&repository.Settlement{ID: 1, Status: repository.SettlementSent}, nil).Once()
+
+		settlement, err := settlementService.TransitionSettlement(1, repository.SettlementSent, TransitionSettlementRequest{UserEmail: bob.Email})
+		assert.Nil(t, err)
+		assert.Equal(t, repository.SettlementSent, settlement.Status)
+		settlementRepo.AssertExpectations(t)
+	}
+}
+
+func TestSettlementService_GetSettlementsForUser(t *testing.T) {
+	settlementRepo := new(repomock.SettlementRepository)
+	userService := new(MockUserService)
+	settlementService := NewSettlementService(settlementRepo, new(repomock.ExpenseRepository), new(repomock.BalanceRepository), userService)
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+
+	settlements := []repository.Settlement{
+		{ID: 2, PayerID: alice.ID, PayeeID: bob.ID, Amount: 5, Status: repository.SettlementSent},
+		{ID: 1, PayerID: bob.ID, PayeeID: alice.ID, Amount: 20, Status: repository.SettlementConfirmed},
+	}
+
+	userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+	settlementRepo.On("GetSettlementsByUserID", alice.ID).Return(settlements, nil).Once()
+	userService.On("GetUsersByIDs", []int{bob.ID}).Return([]*repository.User{bob}, nil).Once()
+
+	views, err := settlementService.GetSettlementsForUser(alice.Email)
+	assert.Nil(t, err)
+	assert.Equal(t, []SettlementView{
+		{ID: 2, Direction: "paying", WithUserEmail: bob.Email, WithUserName: bob.Name, Amount: 5, Status: repository.SettlementSent},
+		{ID: 1, Direction: "receiving", WithUserEmail: bob.Email, WithUserName: bob.Name, Amount: 20, Status: repository.SettlementConfirmed},
+	}, views)
+	settlementRepo.AssertExpectations(t)
+	userService.AssertExpectations(t)
+}
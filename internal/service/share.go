@@ -0,0 +1,240 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// ErrSharingDisabled is returned when no key for signing share links is configured.
+var ErrSharingDisabled = errors.New("share links are not enabled")
+
+// ErrInvalidShareToken is returned for a share token that is malformed or wrongly signed.
+var ErrInvalidShareToken = errors.New("share link is not valid")
+
+// ErrShareLinkGone is returned for a share link that has expired or been revoked.
+var ErrShareLinkGone = errors.New("share link has expired or been revoked")
+
+// ErrInvalidShareTTL is returned when a requested link lifetime is unparseable or out of range.
+var ErrInvalidShareTTL = errors.New("invalid share link lifetime")
+
+type CreateShareLinkRequest struct {
+	UserEmail string `json:"user_email"`
+	// ExpiresIn is a Go duration such as "72h". Empty uses the configured default.
+	ExpiresIn string `json:"expires_in,omitempty"`
+}
+
+type RevokeShareLinkRequest struct {
+	UserEmail string `json:"user_email"`
+}
+
+// CreatedShareLink is a new link along with its token. The token is only ever handed out here.
+type CreatedShareLink struct {
+	repository.ShareLink
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+// SharedLedger is the read-only view of an event behind a share link. People are shown by name
+// only, since whoever holds the link need not be a user.
+type SharedLedger struct {
+	Name      string                    `json:"name"`
+	Archived  bool                      `json:"archived"`
+	ExpiresAt time.Time                 `json:"expires_at"`
+	Totals    []EventTotal              `json:"totals"`
+	Balances  []SharedBalance           `json:"balances"`
+	SettleUp  []SharedTransfer          `json:"settle_up"`
+	Expenses  []repository.EventExpense `json:"expenses"`
+}
+
+type SharedBalance struct {
+	Name     string  `json:"name"`
+	Currency string  `json:"currency"`
+	Net      float64 `json:"net"`
+}
+
+type SharedTransfer struct {
+	From     string  `json:"from"`
+	To       string  `json:"to"`
+	Currency string  `json:"currency"`
+	Amount   float64 `json:"amount"`
+}
+
+// ShareService hands out signed, expiring links to an event's ledger and resolves them.
+type ShareService interface {
+	CreateShareLink(eventID int, req CreateShareLinkRequest) (*CreatedShareLink, error)
+	RevokeShareLink(id int, req RevokeShareLinkRequest) (*repository.ShareLink, error)
+	GetSharedLedger(token string) (*SharedLedger, error)
+}
+
+type shareService struct {
+	shareRepo    repository.ShareLinkRepository
+	eventRepo    repository.EventRepository
+	eventService EventService
+	userService  UserService
+	signer       *util.Signer
+	defaultTTL   time.Duration
+	maxTTL       time.Duration
+	now          func() time.Time
+}
+
+// NewShareService builds the share link service. An empty secret disables share links.
+func NewShareService(shareRepo repository.ShareLinkRepository, eventRepo repository.EventRepository, eventService EventService, userService UserService, secret string, defaultTTL, maxTTL time.Duration) ShareService {
+	return &shareService{
+		shareRepo:    shareRepo,
+		eventRepo:    eventRepo,
+		eventService: eventService,
+		userService:  userService,
+		signer:       util.NewSigner(secret),
+		defaultTTL:   defaultTTL,
+		maxTTL:       maxTTL,
+		now:          time.Now,
+	}
+}
+
+func (s *shareService) CreateShareLink(eventID int, req CreateShareLinkRequest) (*CreatedShareLink, error) {
+	if !s.signer.Enabled() {
+		return nil, ErrSharingDisabled
+	}
+
+	ttl := s.defaultTTL
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || d <= 0 || d > s.maxTTL {
+			return nil, fmt.Errorf("%w: expires_in must be a positive duration up to %s", ErrInvalidShareTTL, s.maxTTL)
+		}
+		ttl = d
+	}
+
+	event, err := s.eventRepo.GetEvent(eventID)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.userService.GetUsersByEmails([]string{req.UserEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", req.UserEmail)
+	}
+	if users[0].ID != event.CreatedBy {
+		return nil, ErrNotEventCreator
+	}
+
+	// Whole seconds, so the expiry in the token matches the one stored
+	expiresAt := s.now().Add(ttl).Truncate(time.Second)
+	link, err := s.shareRepo.CreateShareLink(&repository.ShareLink{EventID: eventID, CreatedBy: users[0].ID, ExpiresAt: expiresAt})
+	if err != nil {
+		return nil, err
+	}
+
+	token := s.sign(link.ID, link.ExpiresAt)
+	return &CreatedShareLink{ShareLink: *link, Token: token, URL: "/share/" + token}, nil
+}
+
+func (s *shareService) RevokeShareLink(id int, req RevokeShareLinkRequest) (*repository.ShareLink, error) {
+	link, err := s.shareRepo.GetShareLink(id)
+	if err != nil {
+		return nil, err
+	}
+	event, err := s.eventRepo.GetEvent(link.EventID)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.userService.GetUsersByEmails([]string{req.UserEmail})
+	if err != nil || len(users) == 0 {
+		return nil, fmt.Errorf("user with email %s not found", req.UserEmail)
+	}
+	if users[0].ID != event.CreatedBy {
+		return nil, ErrNotEventCreator
+	}
+
+	return s.shareRepo.RevokeShareLink(id)
+}
+
+func (s *shareService) GetSharedLedger(token string) (*SharedLedger, error) {
+	if !s.signer.Enabled() {
+		return nil, ErrSharingDisabled
+	}
+
+	id, expiresAt, err := s.verify(token)
+	if err != nil {
+		return nil, err
+	}
+	// The signature vouches for the expiry, so an expired link costs no lookup
+	if !s.now().Before(expiresAt) {
+		return nil, ErrShareLinkGone
+	}
+
+	link, err := s.shareRepo.GetShareLink(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrShareLinkNotFound) {
+			return nil, ErrInvalidShareToken
+		}
+		return nil, err
+	}
+	if link.RevokedAt != nil {
+		return nil, ErrShareLinkGone
+	}
+
+	summary, err := s.eventService.GetEventSummary(link.EventID)
+	if err != nil {
+		return nil, err
+	}
+	expenses, err := s.eventRepo.GetEventExpenses(link.EventID)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger := &SharedLedger{
+		Name:      summary.Name,
+		Archived:  summary.ArchivedAt != nil,
+		ExpiresAt: link.ExpiresAt,
+		Totals:    summary.Totals,
+		Balances:  make([]SharedBalance, 0, len(summary.Participants)),
+		SettleUp:  make([]SharedTransfer, 0, len(summary.SettleUp)),
+		Expenses:  expenses,
+	}
+	if ledger.Expenses == nil {
+		ledger.Expenses = []repository.EventExpense{}
+	}
+	names := make(map[string]string, len(summary.Participants))
+	for _, p := range summary.Participants {
+		names[p.UserEmail] = p.UserName
+		ledger.Balances = append(ledger.Balances, SharedBalance{Name: p.UserName, Currency: p.Currency, Net: p.Net})
+	}
+	for _, t := range summary.SettleUp {
+		ledger.SettleUp = append(ledger.SettleUp, SharedTransfer{From: names[t.FromEmail], To: names[t.ToEmail], Currency: t.Currency, Amount: t.Amount})
+	}
+
+	return ledger, nil
+}
+
+// sign makes the token <id>.<expiry unix seconds>.<signature>.
+func (s *shareService) sign(id int, expiresAt time.Time) string {
+	return s.signer.Sign(strconv.Itoa(id) + "." + strconv.FormatInt(expiresAt.Unix(), 10))
+}
+
+// verify checks a token's signature and returns the link ID and expiry it carries.
+func (s *shareService) verify(token string) (int, time.Time, error) {
+	payload, ok := s.signer.Verify(token)
+	if !ok {
+		return 0, time.Time{}, ErrInvalidShareToken
+	}
+
+	idPart, expiryPart, ok := strings.Cut(payload, ".")
+	if !ok {
+		return 0, time.Time{}, ErrInvalidShareToken
+	}
+	id, err := strconv.Atoi(idPart)
+	if err != nil {
+		return 0, time.Time{}, ErrInvalidShareToken
+	}
+	expiry, err := strconv.ParseInt(expiryPart, 10, 64)
+	if err != nil {
+		return 0, time.Time{}, ErrInvalidShareToken
+	}
+	return id, time.Unix(expiry, 0), nil
+}
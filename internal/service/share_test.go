@@ -0,0 +1,62 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShareService_Tokens(t *testing.T) {
+	s := NewShareService(nil, nil, nil, nil, "test-secret-0123456789abcdef0123", time.Hour, 2*time.Hour).(*shareService)
+	expiresAt := time.Unix(1_800_000_000, 0)
+
+	// Test case 1: A signed token round-trips
+	token := s.sign(42, expiresAt)
+	id, exp, err := s.verify(token)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, id)
+	assert.True(t, exp.Equal(expiresAt))
+
+	// Test case 2: Changing the ID, the expiry or the key breaks the signature
+	sig := token[strings.LastIndex(token, "."):]
+	other := NewShareService(nil, nil, nil, nil, "another-secret-0123456789abcdef0", time.Hour, 2*time.Hour).(*shareService)
+	for _, bad := range []string{
+		"43" + token[2:],
+		"42." + strconv.FormatInt(expiresAt.Add(time.Hour).Unix(), 10) + sig,
+		other.sign(42, expiresAt),
+		"garbage",
+		"",
+	} {
+		_, _, err := s.verify(bad)
+		assert.True(t, errors.Is(err, ErrInvalidShareToken), bad)
+	}
+}
+
+func TestShareService_GetSharedLedger(t *testing.T) {
+	shareRepo := new(repomock.ShareLinkRepository)
+	s := NewShareService(shareRepo, nil, nil, nil, "test-secret-0123456789abcdef0123", time.Hour, 2*time.Hour).(*shareService)
+	now := time.Unix(1_800_000_000, 0)
+	s.now = func() time.Time { return now }
+
+	// Test case 1: An expired link is refused without a lookup
+	_, err := s.GetSharedLedger(s.sign(1, now))
+	assert.True(t, errors.Is(err, ErrShareLinkGone))
+
+	// Test case 2: A revoked link is refused
+	revokedAt := now.Add(-time.Minute)
+	shareRepo.On("GetShareLink", 2).Return(&repository.ShareLink{ID: 2, EventID: 7, ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt}, nil).Once()
+	_, err = s.GetSharedLedger(s.sign(2, now.Add(time.Hour)))
+	assert.True(t, errors.Is(err, ErrShareLinkGone))
+
+	// Test case 3: Without a key, links are off
+	_, err = NewShareService(nil, nil, nil, nil, "", time.Hour, 2*time.Hour).GetSharedLedger("1.2.3")
+	assert.True(t, errors.Is(err, ErrSharingDisabled))
+
+	shareRepo.AssertExpectations(t)
+}
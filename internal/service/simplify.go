@@ -0,0 +1,239 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// SimplifiedTransfer is one transfer proposed to settle a user's balances. A transfer between two of
+// the user's counterparties is routed through the user, paying down what the payer owes them and
+// what they owe the payee at once.
+type SimplifiedTransfer struct {
+	PayerEmail   string  `json:"payer_email"`
+	PayeeEmail   string  `json:"payee_email"`
+	ViaUserEmail string  `json:"via_user_email,omitempty"`
+	Amount       float64 `json:"amount"`
+}
+
+// DebtSimplification proposes the fewest transfers it can find that settle everything a user owes
+// and is owed, across all their counterparties.
+type DebtSimplification struct {
+	UserEmail string               `json:"user_email"`
+	Transfers []SimplifiedTransfer `json:"transfers"`
+	// TransfersBefore is how many transfers settling each balance with the user directly would take.
+	TransfersBefore int `json:"transfers_before"`
+	// BlockedByDispute lists counterparties left out because an expense with them is disputed.
+	BlockedByDispute []string `json:"blocked_by_dispute,omitempty"`
+}
+
+type ApplyDebtSimplificationRequest struct {
+	UserEmail string `json:"user_email"`
+}
+
+// openSettlementStatuses are the states of a settlement that is under way but not yet in the balances.
+var openSettlementStatuses = []repository.SettlementStatus{repository.SettlementProposed, repository.SettlementSent, repository.SettlementProcessing}
+
+func (s *settlementService) SimplifyDebts(userEmail string) (*DebtSimplification, error) {
+	simplification, _, err := s.planDebtSimplification(userEmail)
+	return simplification, err
+}
+
+func (s *settlementService) ApplyDebtSimplification(req ApplyDebtSimplificationRequest) ([]*repository.Settlement, error) {
+	_, settlements, err := s.planDebtSimplification(req.UserEmail)
+	if err != nil {
+		return nil, err
+	}
+	if len(settlements) == 0 {
+		return []*repository.Settlement{}, nil
+	}
+	created, err := s.settlementRepo.CreateSettlements(settlements)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create settlements for %s: %w", req.UserEmail, err)
+	}
+	return created, nil
+}
+
+// planDebtSimplification works out the transfers that settle the user's balances, and the settlements
+// that would propose them. Settlements already under way count as paid, so applying a plan twice
+// proposes nothing the second time.
+func (s *settlementService) planDebtSimplification(userEmail string) (*DebtSimplification, []*repository.Settlement, error) {
+	users, err := s.userService.GetUsersByEmails([]string{userEmail})
+	if err != nil || len(users) == 0 {
+		return nil, nil, fmt.Errorf("user with email %s not found", userEmail)
+	}
+	user := users[0]
+
+	owed, err := s.owedToUser(user.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get balances for user %s: %w", userEmail, err)
+	}
+
+	ids := make([]int, 0, len(owed))
+	for otherID := range owed {
+		ids = append(ids, otherID)
+	}
+	sort.Ints(ids)
+	others, err := s.userService.GetUsersByIDs(ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch counterparties for balances: %w", err)
+	}
+	emails := map[int]string{user.ID: user.Email}
+	for _, u := range others {
+		emails[u.ID] = u.Email
+	}
+
+	simplification := &DebtSimplification{UserEmail: user.Email, Transfers: []SimplifiedTransfer{}}
+	var debtors, creditors []debtPosition
+	for _, otherID := range ids {
+		// A disputed expense blocks settling with that user, so their balance is left as it is
+		disputed, err := s.expenseRepo.HasDisputedExpenseBetween(user.ID, otherID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to check disputed expenses for simplification: %w", err)
+		}
+		if disputed {
+			simplification.BlockedByDispute = append(simplification.BlockedByDispute, emails[otherID])
+			continue
+		}
+		simplification.TransfersBefore++
+		if units := owed[otherID]; units > 0 {
+			debtors = append(debtors, debtPosition{otherID, units})
+		} else {
+			creditors = append(creditors, debtPosition{otherID, -units})
+		}
+	}
+
+	var settlements []*repository.Settlement
+	for _, t := range simplifyDebts(user.ID, debtors, creditors) {
+		settlement := &repository.Settlement{PayerID: t.payerID, PayeeID: t.payeeID, Amount: util.FromMinorUnits(t.units, util.DefaultExponent)}
+		transfer := SimplifiedTransfer{PayerEmail: emails[t.payerID], PayeeEmail: emails[t.payeeID], Amount: settlement.Amount}
+		if t.payerID != user.ID && t.payeeID != user.ID {
+			settlement.ViaUserID = &user.ID
+			transfer.ViaUserEmail = user.Email
+		}
+		settlements = append(settlements, settlement)
+		simplification.Transfers = append(simplification.Transfers, transfer)
+	}
+	return simplification, settlements, nil
+}
+
+// owedToUser maps each counterparty to what they owe the user in minor units, negative for what the user
+// owes them, net of settlements already under way. Settled pairs are left out.
+func (s *settlementService) owedToUser(userID int) (map[int]int64, error) {
+	owed := make(map[int]int64)
+	apply := func(u repository.BalanceUpdate) {
+		// A positive amount is what User2 owes User1
+		switch userID {
+		case u.User1ID:
+			owed[u.User2ID] += util.ToMinorUnits(u.Amount, util.DefaultExponent)
+		case u.User2ID:
+			owed[u.User1ID] -= util.ToMinorUnits(u.Amount, util.DefaultExponent)
+		}
+	}
+
+	balances, err := s.balanceRepo.GetBalancesByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range balances {
+		apply(repository.BalanceUpdate{User1ID: b.User1ID, User2ID: b.User2ID, Amount: b.Balance})
+	}
+
+	settlements, err := s.settlementRepo.GetSettlementsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range settlements {
+		if containsSettlementStatus(openSettlementStatuses, st.Status) {
+			for _, u := range st.BalanceUpdates() {
+				apply(u)
+			}
+		}
+	}
+
+	for otherID, units := range owed {
+		if units == 0 {
+			delete(owed, otherID)
+		}
+	}
+	return owed, nil
+}
+
+func containsSettlementStatus(statuses []repository.SettlementStatus, status repository.SettlementStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// debtPosition is what one counterparty owes the user, or is owed by them, in minor units.
+type debtPosition struct {
+	userID int
+	units  int64
+}
+
+type debtTransfer struct {
+	payerID, payeeID int
+	units            int64
+}
+
+// simplifyDebts pairs the user's debtors with their creditors, so a debtor pays a creditor straight
+// away instead of both settling with the user. Debts that exactly cancel a credit are paired first,
+// since each such pair saves a whole transfer, then the largest remaining debts meet the largest
+// credits. Whatever is left over is settled with the user directly.
+func simplifyDebts(userID int, debtors, creditors []debtPosition) []debtTransfer {
+	// Largest first, then by user so equal amounts always pair up the same way
+	byUnits := func(p []debtPosition) {
+		sort.Slice(p, func(i, j int) bool {
+			if p[i].units != p[j].units {
+				return p[i].units > p[j].units
+			}
+			return p[i].userID < p[j].userID
+		})
+	}
+	byUnits(debtors)
+	byUnits(creditors)
+
+	var transfers []debtTransfer
+	for d := range debtors {
+		for c := range creditors {
+			if creditors[c].units != 0 && creditors[c].units == debtors[d].units {
+				transfers = append(transfers, debtTransfer{debtors[d].userID, creditors[c].userID, debtors[d].units})
+				debtors[d].units, creditors[c].units = 0, 0
+				break
+			}
+		}
+	}
+
+	for d, c := 0, 0; ; {
+		for d < len(debtors) && debtors[d].units == 0 {
+			d++
+		}
+		for c < len(creditors) && creditors[c].units == 0 {
+			c++
+		}
+		if d == len(debtors) || c == len(creditors) {
+			break
+		}
+		units := min(debtors[d].units, creditors[c].units)
+		transfers = append(transfers, debtTransfer{debtors[d].userID, creditors[c].userID, units})
+		debtors[d].units -= units
+		creditors[c].units -= units
+	}
+
+	for _, p := range debtors {
+		if p.units != 0 {
+			transfers = append(transfers, debtTransfer{p.userID, userID, p.units})
+		}
+	}
+	for _, p := range creditors {
+		if p.units != 0 {
+			transfers = append(transfers, debtTransfer{userID, p.userID, p.units})
+		}
+	}
+	return transfers
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimplifyDebts(t *testing.T) {
+	// Test case 1: A debt that cancels a credit exactly is paired first, saving a transfer over
+	// matching the largest amounts
+	transfers := simplifyDebts(1,
+		[]debtPosition{{2, 5000}, {3, 3000}},
+		[]debtPosition{{4, 3000}, {5, 4000}},
+	)
+	assert.Equal(t, []debtTransfer{
+		{payerID: 3, payeeID: 4, units: 3000},
+		{payerID: 2, payeeID: 5, units: 4000},
+		{payerID: 2, payeeID: 1, units: 1000},
+	}, transfers)
+
+	// Test case 2: What the user owes beyond what they are owed they pay themselves
+	transfers = simplifyDebts(1, []debtPosition{{2, 1000}}, []debtPosition{{3, 2500}})
+	assert.Equal(t, []debtTransfer{
+		{payerID: 2, payeeID: 3, units: 1000},
+		{payerID: 1, payeeID: 3, units: 1500},
+	}, transfers)
+
+	// Test case 3: Nothing to settle
+	assert.Empty(t, simplifyDebts(1, nil, nil))
+}
+
+func TestSettlementService_SimplifyDebts(t *testing.T) {
+	settlementRepo := new(repomock.SettlementRepository)
+	expenseRepo := new(repomock.ExpenseRepository)
+	balanceRepo := new(repomock.BalanceRepository)
+	userService := new(MockUserService)
+	settlementService := NewSettlementService(settlementRepo, expenseRepo, balanceRepo, userService)
+
+	alice := &repository.User{ID: 1, Name: "Alice", Email: "alice@example.com"}
+	bob := &repository.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+	carol := &repository.User{ID: 3, Name: "Carol", Email: "carol@example.com"}
+	dave := &repository.User{ID: 4, Name: "Dave", Email: "dave@example.com"}
+
+	// Bob owes Alice 30, of which 10 is on its way, Alice owes Carol 30 and Dave owes Alice 10 for
+	// an expense he disputes
+	setup := func() {
+		userService.On("GetUsersByEmails", []string{alice.Email}).Return([]*repository.User{alice}, nil).Once()
+		userService.On("GetUsersByIDs", []int{bob.ID, carol.ID, dave.ID}).Return([]*repository.User{bob, carol, dave}, nil).Once()
+		balanceRepo.On("GetBalancesByUserID", alice.ID).Return([]repository.Balance{
+			{User1ID: alice.ID, User2ID: bob.ID, Balance: 30},
+			{User1ID: alice.ID, User2ID: carol.ID, Balance: -30},
+			{User1ID: alice.ID, User2ID: dave.ID, Balance: 10},
+		}, nil).Once()
+		settlementRepo.On("GetSettlementsByUserID", alice.ID).Return([]repository.Settlement{
+			{ID: 7, PayerID: bob.ID, PayeeID: alice.ID, Amount: 10, Status: repository.SettlementSent},
+			{ID: 6, PayerID: bob.ID, PayeeID: alice.ID, Amount: 30, Status: repository.SettlementDisputed},
+		}, nil).Once()
+		expenseRepo.On("HasDisputedExpenseBetween", alice.ID, bob.ID).Return(false, nil).Once()
+		expenseRepo.On("HasDisputedExpenseBetween", alice.ID, carol.ID).Return(false, nil).Once()
+		expenseRepo.On("HasDisputedExpenseBetween", alice.ID, dave.ID).Return(true, nil).Once()
+	}
+
+	// Test case 1: Bob pays Carol what he still owes on Alice's behalf and Alice pays Carol the rest
+	{
+		setup()
+		simplification, err := settlementService.SimplifyDebts(alice.Email)
+		require.NoError(t, err)
+		assert.Equal(t, &DebtSimplification{
+			UserEmail: alice.Email,
+			Transfers: []SimplifiedTransfer{
+				{PayerEmail: bob.Email, PayeeEmail: carol.Email, ViaUserEmail: alice.Email, Amount: 20},
+				{PayerEmail: alice.Email, PayeeEmail: carol.Email, Amount: 10},
+			},
+			TransfersBefore:  2,
+			BlockedByDispute: []string{dave.Email},
+		}, simplification)
+		settlementRepo.AssertNotCalled(t, "CreateSettlements")
+	}
+
+	// Test case 2: Applying it proposes both transfers together
+	{
+		setup()
+		via := alice.ID
+		expected := []*repository.Settlement{
+			{PayerID: bob.ID, PayeeID: carol.ID, ViaUserID: &via, Amount: 20},
+			{PayerID: alice.ID, PayeeID: carol.ID, Amount: 10},
+		}
+		settlementRepo.On("CreateSettlements", expected).Return(expected, nil).Once()
+
+		settlements, err := settlementService.ApplyDebtSimplification(ApplyDebtSimplificationRequest{UserEmail: alice.Email})
+		require.NoError(t, err)
+		assert.Len(t, settlements, 2)
+		settlementRepo.AssertExpectations(t)
+		expenseRepo.AssertExpectations(t)
+	}
+
+	// Test case 3: Unknown user
+	{
+		userService.On("GetUsersByEmails", []string{"ghost@example.com"}).Return([]*repository.User{}, nil).Once()
+		_, err := settlementService.SimplifyDebts("ghost@example.com")
+		assert.EqualError(t, err, "user with email ghost@example.com not found")
+	}
+}
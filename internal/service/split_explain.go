@@ -0,0 +1,135 @@
+package service
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// splitBasis returns the participants of req in order, with what each one's share is in proportion to.
+// For manual splits the basis is the amount entered.
+func splitBasis(req CreateExpenseRequest) ([]string, []float64) {
+	var emails []string
+	var basis []float64
+	switch req.SplitMethod {
+	case SplitMethodEqual:
+		for _, es := range req.EqualSplits {
+			emails, basis = append(emails, es.UserEmail), append(basis, 1)
+		}
+	case SplitMethodPercentage:
+		for _, ps := range req.PercentageSplits {
+			emails, basis = append(emails, ps.UserEmail), append(basis, ps.Percentage)
+		}
+	case SplitMethodManual:
+		for _, ms := range req.ManualSplits {
+			emails, basis = append(emails, ms.UserEmail), append(basis, ms.AmountOwed)
+		}
+	case SplitMethodDays:
+		for _, ds := range req.DaysSplits {
+			days, _ := StayDays(ds.JoinDate, ds.LeaveDate) // Already validated by the strategy
+			emails, basis = append(emails, ds.UserEmail), append(basis, float64(days))
+		}
+	case SplitMethodWeighted:
+		for _, ws := range req.WeightedSplits {
+			emails, basis = append(emails, ws.UserEmail), append(basis, ws.Weight)
+		}
+	}
+	return emails, basis
+}
+
+// explainSplits describes how req was divided into preTax, the strategy's splits, and final, the
+// same splits with tax and tip added. Strategies keep the request's order of participants, so index
+// i is the same person throughout.
+func explainSplits(req CreateExpenseRequest, preTax, final []repository.ExpenseSplit, exp int) *repository.SplitExplanation {
+	format := func(amount float64) string { return fmt.Sprintf("%.*f", exp, amount) }
+	emails, basis := splitBasis(req)
+	totalUnits := util.ToMinorUnits(req.TotalAmount, exp)
+	var totalBasis float64
+	for _, b := range basis {
+		totalBasis += b
+	}
+
+	e := &repository.SplitExplanation{
+		Method:    string(req.SplitMethod),
+		Currency:  req.Currency,
+		MinorUnit: util.FromMinorUnits(1, exp),
+		Subtotal:  util.FromMinorUnits(totalUnits, exp),
+		TaxAndTip: util.FromMinorUnits(taxAndTipUnits(req, exp), exp),
+		Shares:    make([]repository.ShareExplanation, 0, len(final)),
+	}
+
+	for i := range final {
+		preTaxUnits := util.ToMinorUnits(preTax[i].AmountOwed, exp)
+		raw, floorUnits := basis[i], preTaxUnits
+		if req.SplitMethod != SplitMethodManual {
+			// The same rounding down the strategies do, epsilon included
+			rawUnits := float64(totalUnits) * basis[i] / totalBasis
+			raw = math.Round(rawUnits/math.Pow10(exp)*1e6) / 1e6
+			floorUnits = int64(math.Floor(rawUnits + 1e-9))
+		}
+		remainder := preTaxUnits - floorUnits
+		if remainder != 0 {
+			e.RemainderUnits += remainder
+			e.RemainderTo = emails[i]
+		}
+		e.Shares = append(e.Shares, repository.ShareExplanation{
+			UserEmail:   emails[i],
+			Basis:       basis[i],
+			RawAmount:   raw,
+			RoundedDown: util.FromMinorUnits(floorUnits, exp),
+			Remainder:   util.FromMinorUnits(remainder, exp),
+			TaxAndTip:   util.FromMinorUnits(util.ToMinorUnits(final[i].AmountOwed, exp)-preTaxUnits, exp),
+			AmountOwed:  final[i].AmountOwed,
+			AmountPaid:  final[i].AmountPaid,
+		})
+	}
+
+	switch req.SplitMethod {
+	case SplitMethodManual:
+		e.Steps = append(e.Steps, "Each amount owed was entered by hand, so nothing needed dividing.")
+	case SplitMethodEqual:
+		e.Steps = append(e.Steps, fmt.Sprintf("%s %s split equally %d ways is %g each.", format(e.Subtotal), e.Currency, len(final), e.Shares[0].RawAmount))
+	default:
+		e.Steps = append(e.Steps, fmt.Sprintf("%s %s is split in proportion to each participant's %s, out of %g in all.", format(e.Subtotal), e.Currency, basisName(req.SplitMethod), totalBasis))
+	}
+	if e.RemainderUnits != 0 {
+		e.Steps = append(e.Steps, fmt.Sprintf("Each share is rounded down to %s, leaving %s over, which goes to %s, the first participant.",
+			format(e.MinorUnit), format(util.FromMinorUnits(e.RemainderUnits, exp)), e.RemainderTo))
+	} else if req.SplitMethod != SplitMethodManual {
+		e.Steps = append(e.Steps, fmt.Sprintf("Every share comes out in whole multiples of %s, so nothing was left over.", format(e.MinorUnit)))
+	}
+	if e.TaxAndTip != 0 {
+		e.Steps = append(e.Steps, fmt.Sprintf("Tax and tip of %s are shared in proportion to those shares, rounded the same way.", format(e.TaxAndTip)))
+	}
+	return e
+}
+
+func basisName(method SplitMethodType) string {
+	switch method {
+	case SplitMethodPercentage:
+		return "percentage"
+	case SplitMethodDays:
+		return "days present"
+	default:
+		return "split weight"
+	}
+}
+
+// explainBalanceDeltas adds the balance changes an expense made to its explanation. splits are the
+// expense's splits, in the explanation's order.
+func explainBalanceDeltas(e *repository.SplitExplanation, req CreateExpenseRequest, splits []repository.ExpenseSplit, deltas []repository.BalanceDelta, exp int) {
+	emails := map[int]string{req.CreatedByID: req.CreatedByEmail}
+	for i, split := range splits {
+		emails[split.UserID] = e.Shares[i].UserEmail
+	}
+
+	e.BalanceDeltas = deltas
+	if e.BalanceDeltas == nil {
+		e.BalanceDeltas = []repository.BalanceDelta{}
+	}
+	for _, d := range deltas {
+		e.Steps = append(e.Steps, fmt.Sprintf("%s now owes %s %.*f %s more.", emails[d.FromUserID], emails[d.ToUserID], exp, d.Amount, e.Currency))
+	}
+}
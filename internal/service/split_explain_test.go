@@ -0,0 +1,57 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainSplits(t *testing.T) {
+	// Test case 1: 100 three ways leaves a cent, which the first participant takes
+	{
+		req := CreateExpenseRequest{
+			TotalAmount: 100,
+			Currency:    "USD",
+			SplitMethod: SplitMethodEqual,
+			EqualSplits: []EqualSplitRequest{{UserEmail: "alice@example.com", UserID: 1, AmountPaid: 100}, {UserEmail: "bob@example.com", UserID: 2}, {UserEmail: "carol@example.com", UserID: 3}},
+		}
+		splits, _ := (&equalSplitStrategy{}).CalculateSplits(req, 2)
+		e := explainSplits(req, append([]repository.ExpenseSplit(nil), splits...), splits, 2)
+
+		assert.Equal(t, 0.01, e.MinorUnit)
+		assert.Equal(t, int64(1), e.RemainderUnits)
+		assert.Equal(t, "alice@example.com", e.RemainderTo)
+		assert.Equal(t, repository.ShareExplanation{UserEmail: "alice@example.com", Basis: 1, RawAmount: 33.333333, RoundedDown: 33.33, Remainder: 0.01, AmountOwed: 33.34, AmountPaid: 100}, e.Shares[0])
+		assert.Equal(t, repository.ShareExplanation{UserEmail: "bob@example.com", Basis: 1, RawAmount: 33.333333, RoundedDown: 33.33, AmountOwed: 33.33}, e.Shares[1])
+		assert.Equal(t, []string{
+			"100.00 USD split equally 3 ways is 33.333333 each.",
+			"Each share is rounded down to 0.01, leaving 0.01 over, which goes to alice@example.com, the first participant.",
+		}, e.Steps)
+
+		explainBalanceDeltas(e, CreateExpenseRequest{CreatedByID: 1, CreatedByEmail: "alice@example.com"}, splits, []repository.BalanceDelta{{FromUserID: 2, ToUserID: 1, Amount: 33.33}}, 2)
+		assert.Equal(t, "bob@example.com now owes alice@example.com 33.33 USD more.", e.Steps[2])
+	}
+
+	// Test case 2: Tax is shown apart from the proportional shares
+	{
+		req := CreateExpenseRequest{
+			TotalAmount:      90,
+			TaxAmount:        9,
+			Currency:         "USD",
+			SplitMethod:      SplitMethodPercentage,
+			PercentageSplits: []PercentageSplitRequest{{UserEmail: "alice@example.com", Percentage: 200.0 / 3}, {UserEmail: "bob@example.com", Percentage: 100.0 / 3}},
+		}
+		splits, _ := (&percentageSplitStrategy{}).CalculateSplits(req, 2)
+		preTax := append([]repository.ExpenseSplit(nil), splits...)
+		e := explainSplits(req, preTax, applyTaxAndTip(req, splits, 2), 2)
+
+		assert.Equal(t, 9.0, e.TaxAndTip)
+		assert.Equal(t, 60.0, e.Shares[0].RoundedDown)
+		assert.Equal(t, 6.0, e.Shares[0].TaxAndTip)
+		assert.Equal(t, 66.0, e.Shares[0].AmountOwed)
+		assert.Equal(t, 3.0, e.Shares[1].TaxAndTip)
+		assert.Zero(t, e.RemainderUnits)
+		assert.Contains(t, e.Steps[0], "in proportion to each participant's percentage")
+	}
+}
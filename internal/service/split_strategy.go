@@ -2,44 +2,45 @@ package service
 
 import (
 	"fmt"
+	"math"
+	"time"
 
 	"github.com/aadithya-md/split-expense/internal/repository"
 	"github.com/aadithya-md/split-expense/internal/util"
 )
 
+// SplitStrategy divides an expense among its participants, rounding to exp decimal places, the
+// minor unit of the request's currency.
 type SplitStrategy interface {
-	CalculateSplits(req CreateExpenseRequest) ([]repository.ExpenseSplit, error) // Removed usersMap
+	CalculateSplits(req CreateExpenseRequest, exp int) ([]repository.ExpenseSplit, error) // Removed usersMap
 }
 
 type equalSplitStrategy struct{}
 
-func (s *equalSplitStrategy) CalculateSplits(req CreateExpenseRequest) ([]repository.ExpenseSplit, error) {
+func (s *equalSplitStrategy) CalculateSplits(req CreateExpenseRequest, exp int) ([]repository.ExpenseSplit, error) {
 	if len(req.EqualSplits) == 0 {
 		return nil, fmt.Errorf("equal split requires participants")
 	}
 
-	amountPerUser := util.RoundToTwoDecimalPlaces(req.TotalAmount / float64(len(req.EqualSplits)))
+	// Work in whole minor units (cents, or yen for JPY): every participant's share is rounded
+	// down and the leftover units go to the first user, so shares can never go negative.
+	totalUnits := util.ToMinorUnits(req.TotalAmount, exp)
+	unitsPerUser := totalUnits / int64(len(req.EqualSplits))
+	remainder := totalUnits - unitsPerUser*int64(len(req.EqualSplits))
 
 	splits := make([]repository.ExpenseSplit, 0, len(req.EqualSplits))
-	var currentTotalOwed float64
 
 	for i, es := range req.EqualSplits {
 		// UserID is now populated by resolveUserEmailsToIDs
-		splitOwed := amountPerUser
+		splitOwed := unitsPerUser
 		if i == 0 { // Distribute rounding error to the first user
-			splitOwed = util.RoundToTwoDecimalPlaces(req.TotalAmount - (amountPerUser * float64(len(req.EqualSplits)-1)))
+			splitOwed += remainder
 		}
 		splits = append(splits, repository.ExpenseSplit{
 			UserID:     es.UserID, // Use pre-populated UserID
-			AmountPaid: util.RoundToTwoDecimalPlaces(es.AmountPaid),
-			AmountOwed: splitOwed,
+			AmountPaid: util.RoundToCurrency(es.AmountPaid, exp),
+			AmountOwed: util.FromMinorUnits(splitOwed, exp),
 		})
-		currentTotalOwed += splitOwed
-	}
-
-	// Final check to ensure total owed matches total amount after rounding adjustments
-	if util.RoundToTwoDecimalPlaces(currentTotalOwed) != util.RoundToTwoDecimalPlaces(req.TotalAmount) {
-		return nil, fmt.Errorf("rounding error: sum of equal split amounts (%.2f) does not match total amount (%.2f)", currentTotalOwed, req.TotalAmount)
 	}
 
 	return splits, nil
@@ -47,37 +48,41 @@ func (s *equalSplitStrategy) CalculateSplits(req CreateExpenseRequest) ([]reposi
 
 type percentageSplitStrategy struct{}
 
-func (s *percentageSplitStrategy) CalculateSplits(req CreateExpenseRequest) ([]repository.ExpenseSplit, error) {
+func (s *percentageSplitStrategy) CalculateSplits(req CreateExpenseRequest, exp int) ([]repository.ExpenseSplit, error) {
 	if len(req.PercentageSplits) == 0 {
 		return nil, fmt.Errorf("percentage split requires percentages")
 	}
 
 	var totalPercentage float64
 	for _, ps := range req.PercentageSplits {
+		if ps.Percentage < 0 {
+			return nil, fmt.Errorf("percentage for %s cannot be negative", ps.UserEmail)
+		}
 		totalPercentage += ps.Percentage
 	}
-	if totalPercentage != 100 {
+	if util.RoundToTwoDecimalPlaces(totalPercentage) != 100 {
 		return nil, fmt.Errorf("percentage split total must be 100%%")
 	}
 
+	totalUnits := util.ToMinorUnits(req.TotalAmount, exp)
 	splits := make([]repository.ExpenseSplit, 0, len(req.PercentageSplits))
-	var currentTotalOwed float64
+	var allocatedUnits int64
 
 	for _, ps := range req.PercentageSplits {
 		// UserID is now populated by resolveUserEmailsToIDs
-		splitOwed := util.RoundToTwoDecimalPlaces(req.TotalAmount * (ps.Percentage / 100))
+		// Round each share down to the minor unit; the epsilon absorbs float noise such as 6.9999999
+		splitOwed := int64(math.Floor(float64(totalUnits)*ps.Percentage/100 + 1e-9))
 		splits = append(splits, repository.ExpenseSplit{
 			UserID:     ps.UserID, // Use pre-populated UserID
-			AmountPaid: util.RoundToTwoDecimalPlaces(ps.AmountPaid),
-			AmountOwed: splitOwed,
+			AmountPaid: util.RoundToCurrency(ps.AmountPaid, exp),
+			AmountOwed: util.FromMinorUnits(splitOwed, exp),
 		})
-		currentTotalOwed += splitOwed
+		allocatedUnits += splitOwed
 	}
 
-	// Adjust for rounding errors
-	diff := util.RoundToTwoDecimalPlaces(req.TotalAmount - currentTotalOwed)
-	if diff != 0 && len(splits) > 0 {
-		splits[0].AmountOwed = util.RoundToTwoDecimalPlaces(splits[0].AmountOwed + diff)
+	// Adjust for rounding errors; shares were rounded down so the difference is never negative
+	if diff := totalUnits - allocatedUnits; diff != 0 && len(splits) > 0 {
+		splits[0].AmountOwed = util.FromMinorUnits(util.ToMinorUnits(splits[0].AmountOwed, exp)+diff, exp)
 	}
 
 	return splits, nil
@@ -85,7 +90,7 @@ func (s *percentageSplitStrategy) CalculateSplits(req CreateExpenseRequest) ([]r
 
 type manualSplitStrategy struct{}
 
-func (s *manualSplitStrategy) CalculateSplits(req CreateExpenseRequest) ([]repository.ExpenseSplit, error) {
+func (s *manualSplitStrategy) CalculateSplits(req CreateExpenseRequest, exp int) ([]repository.ExpenseSplit, error) {
 	if len(req.ManualSplits) == 0 {
 		return nil, fmt.Errorf("manual split requires manual amounts")
 	}
@@ -93,23 +98,160 @@ func (s *manualSplitStrategy) CalculateSplits(req CreateExpenseRequest) ([]repos
 	var totalOwed float64
 	splits := make([]repository.ExpenseSplit, 0, len(req.ManualSplits))
 	for _, ms := range req.ManualSplits {
+		if ms.AmountOwed < 0 {
+			return nil, fmt.Errorf("amount owed by %s cannot be negative", ms.UserEmail)
+		}
 		// UserID is now populated by resolveUserEmailsToIDs
-		splitOwed := util.RoundToTwoDecimalPlaces(ms.AmountOwed)
+		splitOwed := util.RoundToCurrency(ms.AmountOwed, exp)
 		splits = append(splits, repository.ExpenseSplit{
 			UserID:     ms.UserID, // Use pre-populated UserID
-			AmountPaid: util.RoundToTwoDecimalPlaces(ms.AmountPaid),
+			AmountPaid: util.RoundToCurrency(ms.AmountPaid, exp),
 			AmountOwed: splitOwed,
 		})
 		totalOwed += splitOwed
 	}
 
-	if util.RoundToTwoDecimalPlaces(totalOwed) != util.RoundToTwoDecimalPlaces(req.TotalAmount) {
+	if util.RoundToCurrency(totalOwed, exp) != util.RoundToCurrency(req.TotalAmount, exp) {
 		return nil, fmt.Errorf("manual split amounts (%.2f) must sum up to total amount (%.2f)", totalOwed, req.TotalAmount)
 	}
 
 	return splits, nil
 }
 
+type daysSplitStrategy struct{}
+
+// StayDays returns the number of days between the join and leave dates, counting both ends.
+func StayDays(joinDate, leaveDate string) (int64, error) {
+	join, err := time.Parse(DateLayout, joinDate)
+	if err != nil {
+		return 0, fmt.Errorf("invalid join date %q: %w", joinDate, err)
+	}
+	leave, err := time.Parse(DateLayout, leaveDate)
+	if err != nil {
+		return 0, fmt.Errorf("invalid leave date %q: %w", leaveDate, err)
+	}
+	if leave.Before(join) {
+		return 0, fmt.Errorf("leave date %s is before join date %s", leaveDate, joinDate)
+	}
+	return int64(leave.Sub(join).Hours()/24) + 1, nil
+}
+
+func (s *daysSplitStrategy) CalculateSplits(req CreateExpenseRequest, exp int) ([]repository.ExpenseSplit, error) {
+	if len(req.DaysSplits) == 0 {
+		return nil, fmt.Errorf("days split requires participants with join and leave dates")
+	}
+
+	days := make([]int64, len(req.DaysSplits))
+	var totalDays int64
+	for i, ds := range req.DaysSplits {
+		d, err := StayDays(ds.JoinDate, ds.LeaveDate)
+		if err != nil {
+			return nil, fmt.Errorf("days split for %s: %w", ds.UserEmail, err)
+		}
+		days[i] = d
+		totalDays += d
+	}
+
+	totalUnits := util.ToMinorUnits(req.TotalAmount, exp)
+	splits := make([]repository.ExpenseSplit, 0, len(req.DaysSplits))
+	var allocatedUnits int64
+
+	for i, ds := range req.DaysSplits {
+		// Integer division rounds each share down to the minor unit
+		splitOwed := totalUnits * days[i] / totalDays
+		splits = append(splits, repository.ExpenseSplit{
+			UserID:     ds.UserID,
+			AmountPaid: util.RoundToCurrency(ds.AmountPaid, exp),
+			AmountOwed: util.FromMinorUnits(splitOwed, exp),
+		})
+		allocatedUnits += splitOwed
+	}
+
+	// Leftover units go to the first user, as with the other proportional strategies
+	if diff := totalUnits - allocatedUnits; diff != 0 {
+		splits[0].AmountOwed = util.FromMinorUnits(util.ToMinorUnits(splits[0].AmountOwed, exp)+diff, exp)
+	}
+
+	return splits, nil
+}
+
+type weightedSplitStrategy struct{}
+
+func (s *weightedSplitStrategy) CalculateSplits(req CreateExpenseRequest, exp int) ([]repository.ExpenseSplit, error) {
+	if len(req.WeightedSplits) == 0 {
+		return nil, fmt.Errorf("weighted split requires participants")
+	}
+
+	var totalWeight float64
+	for _, ws := range req.WeightedSplits {
+		if ws.Weight <= 0 {
+			return nil, fmt.Errorf("split weight for %s must be positive", ws.UserEmail)
+		}
+		totalWeight += ws.Weight
+	}
+
+	totalUnits := util.ToMinorUnits(req.TotalAmount, exp)
+	splits := make([]repository.ExpenseSplit, 0, len(req.WeightedSplits))
+	var allocatedUnits int64
+
+	for _, ws := range req.WeightedSplits {
+		// Round each share down to the minor unit; the epsilon absorbs float noise such as 6.9999999
+		splitOwed := int64(math.Floor(float64(totalUnits)*ws.Weight/totalWeight + 1e-9))
+		splits = append(splits, repository.ExpenseSplit{
+			UserID:     ws.UserID,
+			AmountPaid: util.RoundToCurrency(ws.AmountPaid, exp),
+			AmountOwed: util.FromMinorUnits(splitOwed, exp),
+		})
+		allocatedUnits += splitOwed
+	}
+
+	// Shares were rounded down so the leftover units are never negative; they go to the first user
+	if diff := totalUnits - allocatedUnits; diff != 0 {
+		splits[0].AmountOwed = util.FromMinorUnits(util.ToMinorUnits(splits[0].AmountOwed, exp)+diff, exp)
+	}
+
+	return splits, nil
+}
+
+// taxAndTipUnits returns the tax plus the tip on the pre-tax total, in minor units.
+func taxAndTipUnits(req CreateExpenseRequest, exp int) int64 {
+	tip := int64(math.Round(float64(util.ToMinorUnits(req.TotalAmount, exp)) * req.TipPercentage / 100))
+	return util.ToMinorUnits(req.TaxAmount, exp) + tip
+}
+
+// applyTaxAndTip adds the tax and tip to the owed amounts computed by a strategy, in proportion
+// to each participant's pre-tax share. Rounding follows the strategies: shares are rounded down
+// and the leftover units go to the first participant.
+func applyTaxAndTip(req CreateExpenseRequest, splits []repository.ExpenseSplit, exp int) []repository.ExpenseSplit {
+	extraUnits := taxAndTipUnits(req, exp)
+	if extraUnits == 0 || len(splits) == 0 {
+		return splits
+	}
+
+	owedUnits := make([]int64, len(splits))
+	var totalOwedUnits int64
+	for i, split := range splits {
+		owedUnits[i] = util.ToMinorUnits(split.AmountOwed, exp)
+		totalOwedUnits += owedUnits[i]
+	}
+	if totalOwedUnits == 0 {
+		return splits
+	}
+
+	var allocatedUnits int64
+	for i := range splits {
+		share := extraUnits * owedUnits[i] / totalOwedUnits
+		owedUnits[i] += share
+		allocatedUnits += share
+	}
+	owedUnits[0] += extraUnits - allocatedUnits
+
+	for i := range splits {
+		splits[i].AmountOwed = util.FromMinorUnits(owedUnits[i], exp)
+	}
+	return splits
+}
+
 func getSplitStrategy(method SplitMethodType) (SplitStrategy, error) {
 	switch method {
 	case SplitMethodEqual:
@@ -118,6 +260,10 @@ func getSplitStrategy(method SplitMethodType) (SplitStrategy, error) {
 		return &percentageSplitStrategy{}, nil
 	case SplitMethodManual:
 		return &manualSplitStrategy{}, nil
+	case SplitMethodDays:
+		return &daysSplitStrategy{}, nil
+	case SplitMethodWeighted:
+		return &weightedSplitStrategy{}, nil
 	default:
 		return nil, fmt.Errorf("invalid split method: %s", method)
 	}
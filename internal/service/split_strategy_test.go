@@ -0,0 +1,293 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// fuzzAmount maps arbitrary fuzzer input onto a positive amount with at most two decimal places.
+func fuzzAmount(cents uint32) float64 {
+	return util.FromMinorUnits(int64(cents%100_000_000)+1, 2)
+}
+
+// checkSplitInvariants asserts the properties every strategy must uphold.
+func checkSplitInvariants(t *testing.T, totalAmount float64, participants int, splits []repository.ExpenseSplit) {
+	t.Helper()
+
+	if len(splits) != participants {
+		t.Fatalf("expected %d splits, got %d", participants, len(splits))
+	}
+
+	var owedCents int64
+	for i, s := range splits {
+		if s.AmountOwed < 0 {
+			t.Fatalf("split %d has negative amount owed %v (total %v)", i, s.AmountOwed, totalAmount)
+		}
+		if util.RoundToTwoDecimalPlaces(s.AmountOwed) != s.AmountOwed {
+			t.Fatalf("split %d amount owed %v is not rounded to the cent", i, s.AmountOwed)
+		}
+		owedCents += util.ToMinorUnits(s.AmountOwed, 2)
+	}
+
+	if owedCents != util.ToMinorUnits(totalAmount, 2) {
+		t.Fatalf("owed amounts sum to %v, expected %v", util.FromMinorUnits(owedCents, 2), totalAmount)
+	}
+}
+
+func FuzzEqualSplitStrategy(f *testing.F) {
+	f.Add(uint32(3000), uint8(3))
+	f.Add(uint32(14), uint8(10))
+	f.Add(uint32(0), uint8(1))
+	f.Add(uint32(9999), uint8(7))
+
+	f.Fuzz(func(t *testing.T, cents uint32, n uint8) {
+		participants := int(n%50) + 1
+		req := CreateExpenseRequest{TotalAmount: fuzzAmount(cents)}
+		for i := 0; i < participants; i++ {
+			req.EqualSplits = append(req.EqualSplits, EqualSplitRequest{UserID: i + 1})
+		}
+
+		splits, err := (&equalSplitStrategy{}).CalculateSplits(req, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		checkSplitInvariants(t, req.TotalAmount, participants, splits)
+
+		// Nobody but the first user carries more than the rounded-down share
+		for _, s := range splits[1:] {
+			if s.AmountOwed > splits[0].AmountOwed {
+				t.Fatalf("split %v exceeds first user's share %v", s.AmountOwed, splits[0].AmountOwed)
+			}
+		}
+	})
+}
+
+func FuzzPercentageSplitStrategy(f *testing.F) {
+	f.Add(uint32(10000), uint16(5000), uint16(3000))
+	f.Add(uint32(4), uint16(0), uint16(5000))
+	f.Add(uint32(100), uint16(3333), uint16(3333))
+	f.Add(uint32(1), uint16(10000), uint16(0))
+
+	f.Fuzz(func(t *testing.T, cents uint32, a, b uint16) {
+		// Build three percentages with two decimals that always sum to exactly 100
+		first := int(a % 10001)
+		second := int(b) % (10001 - first)
+		third := 10000 - first - second
+
+		req := CreateExpenseRequest{TotalAmount: fuzzAmount(cents)}
+		for i, basisPoints := range []int{first, second, third} {
+			req.PercentageSplits = append(req.PercentageSplits, PercentageSplitRequest{UserID: i + 1, Percentage: float64(basisPoints) / 100})
+		}
+
+		splits, err := (&percentageSplitStrategy{}).CalculateSplits(req, 2)
+		if err != nil {
+			t.Fatalf("unexpected error for %v%%/%v%%/%v%%: %v", first, second, third, err)
+		}
+		checkSplitInvariants(t, req.TotalAmount, 3, splits)
+
+		// Every share is within a cent of its exact value, except the first which absorbs the remainder
+		for i, s := range splits[1:] {
+			exact := req.TotalAmount * req.PercentageSplits[i+1].Percentage / 100
+			if math.Abs(s.AmountOwed-exact) >= 0.01+1e-9 {
+				t.Fatalf("split %d owes %v, expected about %v", i+1, s.AmountOwed, exact)
+			}
+		}
+	})
+}
+
+func FuzzManualSplitStrategy(f *testing.F) {
+	f.Add(uint32(1000), uint32(2000), uint32(3000))
+	f.Add(uint32(0), uint32(0), uint32(1))
+	f.Add(uint32(33), uint32(33), uint32(34))
+
+	f.Fuzz(func(t *testing.T, a, b, c uint32) {
+		owed := []float64{util.FromMinorUnits(int64(a%10_000_000), 2), util.FromMinorUnits(int64(b%10_000_000), 2), util.FromMinorUnits(int64(c%10_000_000), 2)}
+		var totalCents int64
+		req := CreateExpenseRequest{}
+		for i, o := range owed {
+			req.ManualSplits = append(req.ManualSplits, ManualSplitRequest{UserID: i + 1, AmountOwed: o})
+			totalCents += util.ToMinorUnits(o, 2)
+		}
+		req.TotalAmount = util.FromMinorUnits(totalCents, 2)
+
+		splits, err := (&manualSplitStrategy{}).CalculateSplits(req, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		checkSplitInvariants(t, req.TotalAmount, 3, splits)
+
+		// Rounding is idempotent: feeding the result back in yields the same splits
+		for i := range req.ManualSplits {
+			req.ManualSplits[i].AmountOwed = splits[i].AmountOwed
+		}
+		again, err := (&manualSplitStrategy{}).CalculateSplits(req, 2)
+		if err != nil {
+			t.Fatalf("unexpected error on second pass: %v", err)
+		}
+		for i := range splits {
+			if splits[i] != again[i] {
+				t.Fatalf("split %d changed on second pass: %+v != %+v", i, splits[i], again[i])
+			}
+		}
+	})
+}
+
+func TestDaysSplitStrategy(t *testing.T) {
+	// A 10-day villa: Alice stays the whole trip, Bob the last 5 days, Charlie a single night
+	req := CreateExpenseRequest{
+		TotalAmount: 1000,
+		DaysSplits: []DaysSplitRequest{
+			{UserID: 1, JoinDate: "2026-07-01", LeaveDate: "2026-07-10", AmountPaid: 1000},
+			{UserID: 2, JoinDate: "2026-07-06", LeaveDate: "2026-07-10"},
+			{UserID: 3, JoinDate: "2026-07-04", LeaveDate: "2026-07-04"},
+		},
+	}
+
+	splits, err := (&daysSplitStrategy{}).CalculateSplits(req, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkSplitInvariants(t, req.TotalAmount, 3, splits)
+
+	// 10 + 5 + 1 = 16 days at 62.50 a day
+	expected := []float64{625, 312.5, 62.5}
+	for i, s := range splits {
+		if s.AmountOwed != expected[i] {
+			t.Fatalf("split %d owes %v, expected %v", i, s.AmountOwed, expected[i])
+		}
+	}
+
+	req.DaysSplits[1].LeaveDate = "2026-07-05"
+	if _, err := (&daysSplitStrategy{}).CalculateSplits(req, 2); err == nil {
+		t.Fatalf("expected an error when the leave date is before the join date")
+	}
+}
+
+func FuzzDaysSplitStrategy(f *testing.F) {
+	f.Add(uint32(100000), uint8(10), uint8(5), uint8(1))
+	f.Add(uint32(1), uint8(0), uint8(0), uint8(0))
+	f.Add(uint32(9999), uint8(2), uint8(200), uint8(30))
+
+	f.Fuzz(func(t *testing.T, cents uint32, a, b, c uint8) {
+		start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		req := CreateExpenseRequest{TotalAmount: fuzzAmount(cents)}
+		for i, extraDays := range []uint8{a, b, c} {
+			req.DaysSplits = append(req.DaysSplits, DaysSplitRequest{
+				UserID:    i + 1,
+				JoinDate:  start.Format(DateLayout),
+				LeaveDate: start.AddDate(0, 0, int(extraDays)).Format(DateLayout),
+			})
+		}
+
+		splits, err := (&daysSplitStrategy{}).CalculateSplits(req, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		checkSplitInvariants(t, req.TotalAmount, 3, splits)
+
+		// Staying longer never costs less, apart from the first user who absorbs the remainder
+		if (b > c && splits[1].AmountOwed < splits[2].AmountOwed) || (b < c && splits[1].AmountOwed > splits[2].AmountOwed) {
+			t.Fatalf("shares %v and %v are not ordered by days stayed (%d, %d)", splits[1].AmountOwed, splits[2].AmountOwed, b, c)
+		}
+	})
+}
+
+func FuzzWeightedSplitStrategy(f *testing.F) {
+	f.Add(uint32(300000), uint16(300), uint16(200), uint16(100))
+	f.Add(uint32(1), uint16(1), uint16(1), uint16(1))
+	f.Add(uint32(1000), uint16(3), uint16(3), uint16(3))
+
+	f.Fuzz(func(t *testing.T, cents uint32, a, b, c uint16) {
+		req := CreateExpenseRequest{TotalAmount: fuzzAmount(cents)}
+		for i, w := range []uint16{a, b, c} {
+			// Weights with up to two decimals, never zero
+			req.WeightedSplits = append(req.WeightedSplits, WeightedSplitRequest{UserID: i + 1, Weight: float64(int(w)+1) / 100})
+		}
+
+		splits, err := (&weightedSplitStrategy{}).CalculateSplits(req, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		checkSplitInvariants(t, req.TotalAmount, 3, splits)
+
+		var totalWeight float64
+		for _, ws := range req.WeightedSplits {
+			totalWeight += ws.Weight
+		}
+		for i, s := range splits[1:] {
+			exact := req.TotalAmount * req.WeightedSplits[i+1].Weight / totalWeight
+			if math.Abs(s.AmountOwed-exact) >= 0.01+1e-9 {
+				t.Fatalf("split %d owes %v, expected about %v", i+1, s.AmountOwed, exact)
+			}
+		}
+	})
+}
+
+func TestSplitStrategies_CurrencyMinorUnits(t *testing.T) {
+	// Yen has no minor unit: 1000 yen three ways is 334/333/333, not 333.34/333.33/333.33
+	req := CreateExpenseRequest{
+		TotalAmount: 1000,
+		Currency:    "JPY",
+		EqualSplits: []EqualSplitRequest{{UserID: 1}, {UserID: 2}, {UserID: 3}},
+	}
+	splits, err := (&equalSplitStrategy{}).CalculateSplits(req, seededCurrencies.Exponent(req.Currency))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, expected := range []float64{334, 333, 333} {
+		if splits[i].AmountOwed != expected {
+			t.Fatalf("JPY split %d owes %v, expected %v", i, splits[i].AmountOwed, expected)
+		}
+	}
+
+	// Kuwaiti dinar has three decimals: 10 KWD three ways keeps the fils
+	req = CreateExpenseRequest{
+		TotalAmount:      10,
+		Currency:         "KWD",
+		PercentageSplits: []PercentageSplitRequest{{UserID: 1, Percentage: 50}, {UserID: 2, Percentage: 33.33}, {UserID: 3, Percentage: 16.67}},
+	}
+	splits, err = (&percentageSplitStrategy{}).CalculateSplits(req, seededCurrencies.Exponent(req.Currency))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, expected := range []float64{5, 3.333, 1.667} {
+		if splits[i].AmountOwed != expected {
+			t.Fatalf("KWD split %d owes %v, expected %v", i, splits[i].AmountOwed, expected)
+		}
+	}
+}
+
+func TestApplyTaxAndTip(t *testing.T) {
+	// A 90 bill with 9 tax and a 10% tip: Alice ordered 60 worth, Bob 30
+	req := CreateExpenseRequest{
+		TotalAmount:   90,
+		TaxAmount:     9,
+		TipPercentage: 10,
+		ManualSplits:  []ManualSplitRequest{{UserID: 1, AmountOwed: 60, AmountPaid: 108}, {UserID: 2, AmountOwed: 30}},
+	}
+
+	splits, err := (&manualSplitStrategy{}).CalculateSplits(req, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	splits = applyTaxAndTip(req, splits, 2)
+	checkSplitInvariants(t, req.GrandTotal(2), 2, splits)
+
+	// Tax and tip come to 18, shared two to one
+	if splits[0].AmountOwed != 72 || splits[1].AmountOwed != 36 {
+		t.Fatalf("expected 72 and 36, got %v and %v", splits[0].AmountOwed, splits[1].AmountOwed)
+	}
+
+	// Uneven extras leave the odd cent with the first participant
+	req = CreateExpenseRequest{TotalAmount: 10, TaxAmount: 0.01, EqualSplits: []EqualSplitRequest{{UserID: 1}, {UserID: 2}}}
+	splits, _ = (&equalSplitStrategy{}).CalculateSplits(req, 2)
+	splits = applyTaxAndTip(req, splits, 2)
+	checkSplitInvariants(t, req.GrandTotal(2), 2, splits)
+	if splits[0].AmountOwed != 5.01 || splits[1].AmountOwed != 5 {
+		t.Fatalf("expected 5.01 and 5, got %v and %v", splits[0].AmountOwed, splits[1].AmountOwed)
+	}
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// ProviderStripe marks settlements paid in-app through Stripe.
+const ProviderStripe = "stripe"
+
+// stripeWebhookTolerance is how old a webhook's signed timestamp may be, which stops a captured
+// webhook from being replayed later.
+const stripeWebhookTolerance = 5 * time.Minute
+
+// ErrPaymentsDisabled is returned when no Stripe key is configured.
+var ErrPaymentsDisabled = errors.New("in-app payments are not enabled")
+
+// ErrPaymentProvider is returned when Stripe fails or refuses a request.
+var ErrPaymentProvider = errors.New("payment provider request failed")
+
+// ErrInvalidWebhookSignature is returned for a webhook that is unsigned, wrongly signed or too old.
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// StripeOptions configures the Stripe integration. An empty SecretKey disables it.
+type StripeOptions struct {
+	SecretKey     string
+	WebhookSecret string
+	APIBase       string
+	// Currency is what payments are taken in, since settlements carry no currency.
+	Currency string
+}
+
+// SettlementPayment is an in-app payment of a settlement. The client finishes the payment with
+// ClientSecret, and the settlement stays processing until Stripe reports the outcome.
+type SettlementPayment struct {
+	SettlementID    int                         `json:"settlement_id"`
+	Provider        string                      `json:"provider"`
+	PaymentIntentID string                      `json:"payment_intent_id"`
+	ClientSecret    string                      `json:"client_secret"`
+	Amount          float64                     `json:"amount"`
+	Currency        string                      `json:"currency"`
+	Status          repository.SettlementStatus `json:"status"`
+}
+
+// StripeService pays settlements in-app. A paid settlement moves proposed → processing when the
+// payment starts, then to confirmed, which applies it to the balance, only once Stripe reports the
+// payment succeeded. A canceled payment moves it back to proposed.
+type StripeService interface {
+	// PaySettlement starts paying a proposed settlement, or returns the payment already under way
+	// for a processing one.
+	PaySettlement(id int) (*SettlementPayment, error)
+	// HandleWebhook verifies and applies an event Stripe sent to the webhook.
+	HandleWebhook(payload []byte, signature string) error
+}
+
+type stripeService struct {
+	settlementRepo repository.SettlementRepository
+	opts           StripeOptions
+	currencies     util.Currencies
+	client         *http.Client
+	now            func() time.Time
+}
+
+func NewStripeService(settlementRepo repository.SettlementRepository, opts StripeOptions, currencies util.Currencies) StripeService {
+	opts.APIBase = strings.TrimSuffix(opts.APIBase, "/")
+	opts.Currency = strings.ToUpper(opts.Currency)
+	return &stripeService{
+		settlementRepo: settlementRepo,
+		opts:           opts,
+		currencies:     currencies,
+		client:         &http.Client{Timeout: 10 * time.Second},
+		now:            time.Now,
+	}
+}
+
+// stripePaymentIntent is the part of a Stripe PaymentIntent this service reads.
+type stripePaymentIntent struct {
+	ID           string `json:"id"`
+	ClientSecret string `json:"client_secret"`
+	Status       string `json:"status"`
+}
+
+func (s *stripeService) PaySettlement(id int) (*SettlementPayment, error) {
+	if s.opts.SecretKey == "" {
+		return nil, ErrPaymentsDisabled
+	}
+
+	settlement, err := s.settlementRepo.GetSettlement(id)
+	if err != nil {
+		return nil, err
+	}
+	if settlement.Status == repository.SettlementProcessing && settlement.PaymentProvider == ProviderStripe {
+		// Resume the payment under way, unless it was canceled behind our back
+		intent, err := s.getPaymentIntent(settlement.PaymentReference)
+		if err != nil {
+			return nil, err
+		}
+		if intent.Status != "canceled" {
+			return s.payment(settlement, intent), nil
+		}
+		if settlement, err = s.settlementRepo.TransitionSettlement(id, []repository.SettlementStatus{repository.SettlementProcessing}, repository.SettlementProposed); err != nil {
+			return nil, err
+		}
+	}
+
+	// Moving to processing first keeps two payments from starting at once, and keeps the
+	// settlement from being confirmed by hand while it is being paid
+	settlement, err = s.settlementRepo.TransitionSettlement(id, []repository.SettlementStatus{repository.SettlementProposed}, repository.SettlementProcessing)
+	if err != nil {
+		return nil, err
+	}
+	intent, err := s.createPaymentIntent(settlement)
+	if err == nil {
+		err = s.settlementRepo.SetSettlementPayment(id, ProviderStripe, intent.ID)
+	}
+	if err != nil {
+		if _, revertErr := s.settlementRepo.TransitionSettlement(id, []repository.SettlementStatus{repository.SettlementProcessing}, repository.SettlementProposed); revertErr != nil {
+			log.Printf("failed to move settlement %d back to proposed after a failed payment: %v", id, revertErr)
+		}
+		return nil, err
+	}
+	settlement.PaymentProvider, settlement.PaymentReference = ProviderStripe, intent.ID
+	return s.payment(settlement, intent), nil
+}
+
+func (s *stripeService) payment(settlement *repository.Settlement, intent *stripePaymentIntent) *SettlementPayment {
+	return &SettlementPayment{
+		SettlementID:    settlement.ID,
+		Provider:        ProviderStripe,
+		PaymentIntentID: intent.ID,
+		ClientSecret:    intent.ClientSecret,
+		Amount:          settlement.Amount,
+		Currency:        s.opts.Currency,
+		Status:          settlement.Status,
+	}
+}
+
+func (s *stripeService) createPaymentIntent(settlement *repository.Settlement) (*stripePaymentIntent, error) {
+	form := url.Values{
+		"amount":                             {strconv.FormatInt(util.ToMinorUnits(settlement.Amount, s.currencies.Exponent(s.opts.Currency)), 10)},
+		"currency":                           {strings.ToLower(s.opts.Currency)},
+		"automatic_payment_methods[enabled]": {"true"},
+		"metadata[settlement_id]":            {strconv.Itoa(settlement.ID)},
+		"metadata[payer_id]":                 {strconv.Itoa(settlement.PayerID)},
+		"metadata[payee_id]":                 {strconv.Itoa(settlement.PayeeID)},
+	}
+	req, err := http.NewRequest("POST", s.opts.APIBase+"/v1/payment_intents", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// Each attempt at paying gets its own key, so a retried request can't create a second intent
+	req.Header.Set("Idempotency-Key", fmt.Sprintf("settlement-%d-%d", settlement.ID, settlement.UpdatedAt.UnixNano()))
+	return s.do(req)
+}
+
+func (s *stripeService) getPaymentIntent(id string) (*stripePaymentIntent, error) {
+	req, err := http.NewRequest("GET", s.opts.APIBase+"/v1/payment_intents/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.do(req)
+}
+
+func (s *stripeService) do(req *http.Request) (*stripePaymentIntent, error) {
+	req.SetBasicAuth(s.opts.SecretKey, "")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPaymentProvider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&body)
+		return nil, fmt.Errorf("%w: %s %s returned %d: %s", ErrPaymentProvider, req.Method, req.URL.Path, resp.StatusCode, body.Error.Message)
+	}
+	var intent stripePaymentIntent
+	if err := json.NewDecoder(resp.Body).Decode(&intent); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode payment intent: %w", ErrPaymentProvider, err)
+	}
+	return &intent, nil
+}
+
+// stripeEvent is the part of a Stripe webhook event this service reads.
+type stripeEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object stripePaymentIntent `json:"object"`
+	} `json:"data"`
+}
+
+func (s *stripeService) HandleWebhook(payload []byte, signature string) error {
+	if s.opts.SecretKey == "" {
+		return ErrPaymentsDisabled
+	}
+	if err := s.verifySignature(payload, signature); err != nil {
+		return err
+	}
+
+	var event stripeEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("failed to decode stripe event: %w", err)
+	}
+	var to repository.SettlementStatus
+	switch event.Type {
+	case "payment_intent.succeeded":
+		to = repository.SettlementConfirmed
+	case "payment_intent.canceled":
+		to = repository.SettlementProposed
+	default:
+		// Failed attempts can be retried on the same intent, so only its final outcome matters
+		return nil
+	}
+
+	settlement, err := s.settlementRepo.GetSettlementByPaymentReference(ProviderStripe, event.Data.Object.ID)
+	if errors.Is(err, repository.ErrSettlementNotFound) {
+		log.Printf("ignoring stripe event %s for unknown payment intent %s", event.ID, event.Data.Object.ID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if settlement.Status == to {
+		// Stripe delivers at least once
+		return nil
+	}
+	_, err = s.settlementRepo.TransitionSettlement(settlement.ID, []repository.SettlementStatus{repository.SettlementProcessing}, to)
+	if errors.Is(err, repository.ErrInvalidSettlementTransition) {
+		// Failing would only make Stripe send it again, and it would never apply
+		log.Printf("ignoring stripe event %s for settlement %d: %v", event.ID, settlement.ID, err)
+		return nil
+	}
+	return err
+}
+
+// verifySignature checks a Stripe-Signature header against the webhook secret.
+func (s *stripeService) verifySignature(payload []byte, header string) error {
+	return verifySignedPayload(s.opts.WebhookSecret, payload, header, s.now())
+}
+
+// verifySignedPayload checks a signature header in Stripe's format: a timestamp and one or more
+// HMAC-SHA256 signatures of "<timestamp>.<payload>" under secret. A timestamp more than
+// stripeWebhookTolerance from now is refused, so a captured payload can't be replayed later.
+func verifySignedPayload(secret string, payload []byte, header string, now time.Time) error {
+	var (
+		timestamp  string
+		signatures []string
+	)
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidWebhookSignature
+	}
+	if age := now.Sub(time.Unix(sent, 0)); age > stripeWebhookTolerance || age < -stripeWebhookTolerance {
+		return fmt.Errorf("%w: timestamp is %s off", ErrInvalidWebhookSignature, age.Round(time.Second))
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+	for _, sig := range signatures {
+		if got, err := hex.DecodeString(sig); err == nil && hmac.Equal(got, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidWebhookSignature
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWebhookSecret = "whsec_test"
+
+// signStripe builds the Stripe-Signature header Stripe would send with payload at the given time.
+func signStripe(payload string, at time.Time) string {
+	ts := fmt.Sprint(at.Unix())
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write([]byte(ts + "." + payload))
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestStripeService_PaySettlement(t *testing.T) {
+	var form map[string]string
+	stripe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, _, _ := r.BasicAuth(); user != "sk_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "POST /v1/payment_intents":
+			r.ParseForm()
+			form = map[string]string{"amount": r.PostForm.Get("amount"), "currency": r.PostForm.Get("currency"), "settlement": r.PostForm.Get("metadata[settlement_id]")}
+			if r.PostForm.Get("metadata[settlement_id]") == "3" {
+				w.WriteHeader(http.StatusPaymentRequired)
+				fmt.Fprint(w, `{"error":{"message":"card declined"}}`)
+				return
+			}
+			fmt.Fprint(w, `{"id":"pi_1","client_secret":"pi_1_secret","status":"requires_payment_method"}`)
+		case "GET /v1/payment_intents/pi_1":
+			fmt.Fprint(w, `{"id":"pi_1","client_secret":"pi_1_secret","status":"requires_payment_method"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer stripe.Close()
+
+	settlementRepo := new(repomock.SettlementRepository)
+	s := NewStripeService(settlementRepo, StripeOptions{SecretKey: "sk_test", WebhookSecret: testWebhookSecret, APIBase: stripe.URL, Currency: "inr"}, seededCurrencies)
+	processing := []repository.SettlementStatus{repository.SettlementProcessing}
+	proposed := []repository.SettlementStatus{repository.SettlementProposed}
+
+	// Test case 1: A proposed settlement starts processing with a new payment intent
+	settlementRepo.On("GetSettlement", 1).Return(&repository.Settlement{ID: 1, Amount: 12.5, Status: repository.SettlementProposed}, nil).Once()
+	settlementRepo.On("TransitionSettlement", 1, proposed, repository.SettlementProcessing).Return(&repository.Settlement{ID: 1, Amount: 12.5, Status: repository.SettlementProcessing}, nil).Once()
+	settlementRepo.On("SetSettlementPayment", 1, ProviderStripe, "pi_1").Return(nil).Once()
+	payment, err := s.PaySettlement(1)
+	require.NoError(t, err)
+	assert.Equal(t, &SettlementPayment{SettlementID: 1, Provider: ProviderStripe, PaymentIntentID: "pi_1", ClientSecret: "pi_1_secret", Amount: 12.5, Currency: "INR", Status: repository.SettlementProcessing}, payment)
+	assert.Equal(t, map[string]string{"amount": "1250", "currency": "inr", "settlement": "1"}, form)
+
+	// Test case 2: Asking again resumes the same payment
+	settlementRepo.On("GetSettlement", 1).Return(&repository.Settlement{ID: 1, Amount: 12.5, Status: repository.SettlementProcessing, PaymentProvider: ProviderStripe, PaymentReference: "pi_1"}, nil).Once()
+	payment, err = s.PaySettlement(1)
+	require.NoError(t, err)
+	assert.Equal(t, "pi_1_secret", payment.ClientSecret)
+
+	// Test case 3: A settlement Stripe refuses goes back to proposed
+	settlementRepo.On("GetSettlement", 3).Return(&repository.Settlement{ID: 3, Amount: 5, Status: repository.SettlementProposed}, nil).Once()
+	settlementRepo.On("TransitionSettlement", 3, proposed, repository.SettlementProcessing).Return(&repository.Settlement{ID: 3, Amount: 5, Status: repository.SettlementProcessing}, nil).Once()
+	settlementRepo.On("TransitionSettlement", 3, processing, repository.SettlementProposed).Return(&repository.Settlement{ID: 3, Status: repository.SettlementProposed}, nil).Once()
+	_, err = s.PaySettlement(3)
+	assert.ErrorIs(t, err, ErrPaymentProvider)
+	assert.ErrorContains(t, err, "card declined")
+
+	// Test case 4: Without a key, nothing is paid
+	_, err = NewStripeService(settlementRepo, StripeOptions{}, seededCurrencies).PaySettlement(1)
+	assert.ErrorIs(t, err, ErrPaymentsDisabled)
+
+	settlementRepo.AssertExpectations(t)
+}
+
+func TestStripeService_HandleWebhook(t *testing.T) {
+	settlementRepo := new(repomock.SettlementRepository)
+	s := NewStripeService(settlementRepo, StripeOptions{SecretKey: "sk_test", WebhookSecret: testWebhookSecret}, seededCurrencies).(*stripeService)
+	now := time.Unix(1_700_000_000, 0)
+	s.now = func() time.Time { return now }
+	processing := []repository.SettlementStatus{repository.SettlementProcessing}
+	succeeded := `{"id":"evt_1","type":"payment_intent.succeeded","data":{"object":{"id":"pi_1","status":"succeeded"}}}`
+
+	// Test case 1: A successful payment confirms the settlement
+	settlementRepo.On("GetSettlementByPaymentReference", ProviderStripe, "pi_1").Return(&repository.Settlement{ID: 1, Status: repository.SettlementProcessing}, nil).Once()
+	settlementRepo.On("TransitionSettlement", 1, processing, repository.SettlementConfirmed).Return(&repository.Settlement{ID: 1, Status: repository.SettlementConfirmed}, nil).Once()
+	assert.NoError(t, s.HandleWebhook([]byte(succeeded), signStripe(succeeded, now)))
+
+	// Test case 2: Delivered again, it is acknowledged without applying twice
+	settlementRepo.On("GetSettlementByPaymentReference", ProviderStripe, "pi_1").Return(&repository.Settlement{ID: 1, Status: repository.SettlementConfirmed}, nil).Once()
+	assert.NoError(t, s.HandleWebhook([]byte(succeeded), signStripe(succeeded, now)))
+
+	// Test case 3: A canceled payment puts the settlement back to proposed
+	canceled := `{"id":"evt_2","type":"payment_intent.canceled","data":{"object":{"id":"pi_2","status":"canceled"}}}`
+	settlementRepo.On("GetSettlementByPaymentReference", ProviderStripe, "pi_2").Return(&repository.Settlement{ID: 2, Status: repository.SettlementProcessing}, nil).Once()
+	settlementRepo.On("TransitionSettlement", 2, processing, repository.SettlementProposed).Return(&repository.Settlement{ID: 2, Status: repository.SettlementProposed}, nil).Once()
+	assert.NoError(t, s.HandleWebhook([]byte(canceled), signStripe(canceled, now)))
+
+	// Test case 4: Other events and unknown intents are acknowledged and ignored
+	failed := `{"id":"evt_3","type":"payment_intent.payment_failed","data":{"object":{"id":"pi_1"}}}`
+	assert.NoError(t, s.HandleWebhook([]byte(failed), signStripe(failed, now)))
+	other := `{"id":"evt_4","type":"payment_intent.succeeded","data":{"object":{"id":"pi_other"}}}`
+	settlementRepo.On("GetSettlementByPaymentReference", ProviderStripe, "pi_other").Return((*repository.Settlement)(nil), repository.ErrSettlementNotFound).Once()
+	assert.NoError(t, s.HandleWebhook([]byte(other), signStripe(other, now)))
+
+	// Test case 5: Bad, missing and stale signatures are refused
+	assert.ErrorIs(t, s.HandleWebhook([]byte(succeeded), signStripe(canceled, now)), ErrInvalidWebhookSignature)
+	assert.ErrorIs(t, s.HandleWebhook([]byte(succeeded), ""), ErrInvalidWebhookSignature)
+	assert.ErrorIs(t, s.HandleWebhook([]byte(succeeded), signStripe(succeeded, now.Add(-time.Hour))), ErrInvalidWebhookSignature)
+
+	settlementRepo.AssertExpectations(t)
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// ErrInvalidTagKeyword is returned when a tag rule's keyword has no letters or digits to match on.
+var ErrInvalidTagKeyword = errors.New("keyword must contain a letter or digit")
+
+// MaxTagKeywordLength is the most characters a tag rule's keyword may hold once normalized by
+// TagKeyword, as the keyword column allows.
+const MaxTagKeywordLength = 100
+
+// Where a tag suggestion came from.
+const (
+	TagSourceRule  = "rule"
+	TagSourceModel = "model"
+)
+
+// minClassifierConfidence is how sure a TagClassifier must be for its tag to be suggested.
+const minClassifierConfidence = 0.5
+
+// TagClassifier is a pluggable model, such as a hosted text classifier, that suggests a tag from
+// an expense's description. It is only asked when no keyword rule matches.
+type TagClassifier interface {
+	// ClassifyTag returns the tag it suggests and how sure it is of it, from 0 to 1. An empty tag
+	// means it has no suggestion.
+	ClassifyTag(description string) (tag string, confidence float64, err error)
+}
+
+// TagSuggestion is a tag suggested for a description. Keyword is the rule's keyword when a rule
+// matched; rules are always sure of themselves, with a confidence of 1.
+type TagSuggestion struct {
+	Tag        string  `json:"tag"`
+	Source     string  `json:"source"`
+	Keyword    string  `json:"keyword,omitempty"`
+	Confidence float64 `json:"confidence"`
+}
+
+type CreateTagRuleRequest struct {
+	Keyword string `json:"keyword"`
+	Tag     string `json:"tag"`
+}
+
+// TagService suggests tags for expenses from their descriptions and manages the keyword rules it
+// suggests them by.
+type TagService interface {
+	// SuggestTag returns the tag suggested for description, or nil when nothing matches.
+	SuggestTag(description string) (*TagSuggestion, error)
+	CreateTagRule(req CreateTagRuleRequest) (*repository.TagRule, error)
+	ListTagRules() ([]repository.TagRule, error)
+	DeleteTagRule(id int) error
+}
+
+type tagService struct {
+	tagRuleRepo repository.TagRuleRepository
+	classifier  TagClassifier
+}
+
+// NewTagService builds the tag service. classifier may be nil, in which case only the keyword rules
+// suggest tags.
+func NewTagService(tagRuleRepo repository.TagRuleRepository, classifier TagClassifier) TagService {
+	return &tagService{tagRuleRepo: tagRuleRepo, classifier: classifier}
+}
+
+// TagKeyword returns the form a keyword is stored and matched in: its words, lowercased and joined
+// by single spaces, with punctuation dropped. It is empty when s has no letters or digits.
+func TagKeyword(s string) string {
+	return strings.Join(tagWords(s), " ")
+}
+
+// tagWords splits sanitized, lowercased text into runs of letters and digits.
+func tagWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(util.SanitizeText(s)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+func (s *tagService) SuggestTag(description string) (*TagSuggestion, error) {
+	rules, err := s.tagRuleRepo.ListTagRules()
+	if err != nil {
+		return nil, err
+	}
+
+	// Matching on padded words keeps "bar" from matching "barber", and lets keywords span words
+	text := " " + TagKeyword(description) + " "
+	var best *repository.TagRule
+	for i, rule := range rules {
+		if !strings.Contains(text, " "+rule.Keyword+" ") {
+			continue
+		}
+		// The most specific rule wins, so "uber eats" beats "uber"
+		if best == nil || utf8.RuneCountInString(rule.Keyword) > utf8.RuneCountInString(best.Keyword) ||
+			(utf8.RuneCountInString(rule.Keyword) == utf8.RuneCountInString(best.Keyword) && rule.ID < best.ID) {
+			best = &rules[i]
+		}
+	}
+	if best != nil {
+		return &TagSuggestion{Tag: best.Tag, Source: TagSourceRule, Keyword: best.Keyword, Confidence: 1}, nil
+	}
+
+	if s.classifier == nil || strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	tag, confidence, err := s.classifier.ClassifyTag(util.SanitizeText(description))
+	if err != nil {
+		// The model is a nice-to-have; the rules already had their say
+		log.Printf("Tag classifier failed: %v", err)
+		return nil, nil
+	}
+	tag = util.SanitizeText(tag)
+	if tag == "" || confidence < minClassifierConfidence {
+		return nil, nil
+	}
+	return &TagSuggestion{Tag: tag, Source: TagSourceModel, Confidence: confidence}, nil
+}
+
+func (s *tagService) CreateTagRule(req CreateTagRuleRequest) (*repository.TagRule, error) {
+	keyword := TagKeyword(req.Keyword)
+	if keyword == "" {
+		return nil, ErrInvalidTagKeyword
+	}
+	return s.tagRuleRepo.CreateTagRule(&repository.TagRule{Keyword: keyword, Tag: util.SanitizeText(req.Tag)})
+}
+
+func (s *tagService) ListTagRules() ([]repository.TagRule, error) {
+	return s.tagRuleRepo.ListTagRules()
+}
+
+func (s *tagService) DeleteTagRule(id int) error {
+	return s.tagRuleRepo.DeleteTagRule(id)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTagClassifier struct {
+	mock.Mock
+}
+
+func (m *MockTagClassifier) ClassifyTag(description string) (string, float64, error) {
+	args := m.Called(description)
+	return args.String(0), args.Get(1).(float64), args.Error(2)
+}
+
+func TestTagKeyword(t *testing.T) {
+	assert.Equal(t, "uber eats", TagKeyword("  Uber-Eats! "))
+	assert.Equal(t, "café", TagKeyword("Café"))
+	assert.Equal(t, "", TagKeyword("?!"))
+}
+
+func TestTagService_SuggestTag(t *testing.T) {
+	tagRuleRepo := new(repomock.TagRuleRepository)
+	classifier := new(MockTagClassifier)
+	tagService := NewTagService(tagRuleRepo, classifier)
+
+	tagRuleRepo.On("ListTagRules").Return([]repository.TagRule{
+		{ID: 3, Keyword: "bar", Tag: "Drinks"},
+		{ID: 1, Keyword: "uber", Tag: "Transport"},
+		{ID: 2, Keyword: "uber eats", Tag: "Food"},
+		{ID: 4, Keyword: "pub", Tag: "Going out"},
+	}, nil)
+
+	// Test case 1: Keywords match whole words, ignoring case and punctuation
+	suggestion, err := tagService.SuggestTag("Drinks at the BAR, then home")
+	require.NoError(t, err)
+	assert.Equal(t, &TagSuggestion{Tag: "Drinks", Source: TagSourceRule, Keyword: "bar", Confidence: 1}, suggestion)
+
+	// Test case 2: The longest keyword wins, then the oldest rule
+	suggestion, err = tagService.SuggestTag("uber eats: pizza")
+	require.NoError(t, err)
+	assert.Equal(t, "Food", suggestion.Tag)
+	suggestion, err = tagService.SuggestTag("pub crawl, bar hopping")
+	require.NoError(t, err)
+	assert.Equal(t, "Drinks", suggestion.Tag)
+
+	// Test case 3: The classifier is asked when no rule matches, and only trusted when it is sure
+	classifier.On("ClassifyTag", "Barber").Return("Personal care", 0.8, nil).Once()
+	suggestion, err = tagService.SuggestTag("Barber")
+	require.NoError(t, err)
+	assert.Equal(t, &TagSuggestion{Tag: "Personal care", Source: TagSourceModel, Confidence: 0.8}, suggestion)
+	classifier.On("ClassifyTag", "Gift").Return("Shopping", 0.3, nil).Once()
+	suggestion, err = tagService.SuggestTag("Gift")
+	require.NoError(t, err)
+	assert.Nil(t, suggestion)
+
+	// Test case 4: A failing classifier means no suggestion, not an error
+	classifier.On("ClassifyTag", "Rent").Return("", 0.0, errors.New("model unavailable")).Once()
+	suggestion, err = tagService.SuggestTag("Rent")
+	require.NoError(t, err)
+	assert.Nil(t, suggestion)
+
+	classifier.AssertExpectations(t)
+
+	// Test case 5: Without a classifier only the rules are used
+	suggestion, err = NewTagService(tagRuleRepo, nil).SuggestTag("Barber")
+	require.NoError(t, err)
+	assert.Nil(t, suggestion)
+}
+
+func TestTagService_CreateTagRule(t *testing.T) {
+	tagRuleRepo := new(repomock.TagRuleRepository)
+	tagService := NewTagService(tagRuleRepo, nil)
+
+	// Test case 1: The keyword is stored normalized and the tag sanitized
+	tagRuleRepo.On("CreateTagRule", &repository.TagRule{Keyword: "uber eats", Tag: "Food"}).Return(&repository.TagRule{ID: 1, Keyword: "uber eats", Tag: "Food"}, nil).Once()
+	rule, err := tagService.CreateTagRule(CreateTagRuleRequest{Keyword: " Uber  Eats ", Tag: "Food\n"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, rule.ID)
+
+	// Test case 2: Keywords without letters or digits would match nothing
+	_, err = tagService.CreateTagRule(CreateTagRuleRequest{Keyword: "--", Tag: "Food"})
+	assert.ErrorIs(t, err, ErrInvalidTagKeyword)
+
+	tagRuleRepo.AssertExpectations(t)
+}
@@ -2,29 +2,50 @@ package service
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
 )
 
 type UserService interface {
-	CreateUser(name, email string) (*repository.User, error)
+	// CreateUser registers a user. publicID is the ID they are to be known by in the API, or empty to
+	// generate one.
+	CreateUser(name, email, publicID string) (*repository.User, error)
+	// GetOrCreateUser returns the user registered with email, registering them under name and
+	// publicID first if there is none. created reports whether they were registered by this call.
+	GetOrCreateUser(name, email, publicID string) (user *repository.User, created bool, err error)
 	GetUser(id int) (*repository.User, error)
+	GetUserByPublicID(publicID string) (*repository.User, error)
 	GetUsersByEmails(emails []string) ([]*repository.User, error)
 	GetUsersByIDs(ids []int) ([]*repository.User, error)
+	// SetSplitWeight stores the factor the user's share is scaled by in weighted splits.
+	SetSplitWeight(id int, weight float64) (*repository.User, error)
+	// GetLastModified returns when an expense or balance involving the user last changed.
+	GetLastModified(email string) (time.Time, error)
 }
 
 type userService struct {
 	repo repository.UserRepository
+	ids  util.IDGenerator
 }
 
-func NewUserService(repo repository.UserRepository) UserService {
-	return &userService{repo: repo}
+// NewUserService builds the user service. ids may be nil, in which case public IDs are random UUIDs.
+func NewUserService(repo repository.UserRepository, ids util.IDGenerator) UserService {
+	if ids == nil {
+		ids = util.UUIDGenerator{}
+	}
+	return &userService{repo: repo, ids: ids}
 }
 
-func (s *userService) CreateUser(name, email string) (*repository.User, error) {
+func (s *userService) CreateUser(name, email, publicID string) (*repository.User, error) {
+	if publicID == "" {
+		publicID = s.ids.NewID()
+	}
 	user := &repository.User{
-		Name:  name,
-		Email: email,
+		PublicID: publicID,
+		Name:     util.SanitizeText(name),
+		Email:    util.NormalizeEmail(email),
 	}
 
 	createdUser, err := s.repo.CreateUser(user)
@@ -35,6 +56,22 @@ func (s *userService) CreateUser(name, email string) (*repository.User, error) {
 	return createdUser, nil
 }
 
+func (s *userService) GetOrCreateUser(name, email, publicID string) (*repository.User, bool, error) {
+	if publicID == "" {
+		publicID = s.ids.NewID()
+	}
+	user, created, err := s.repo.GetOrCreateUser(&repository.User{
+		PublicID: publicID,
+		Name:     util.SanitizeText(name),
+		Email:    util.NormalizeEmail(email),
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get or create user in service: %w", err)
+	}
+
+	return user, created, nil
+}
+
 func (s *userService) GetUser(id int) (*repository.User, error) {
 	user, err := s.repo.GetUser(id)
 	if err != nil {
@@ -43,8 +80,28 @@ func (s *userService) GetUser(id int) (*repository.User, error) {
 	return user, nil
 }
 
+func (s *userService) GetUserByPublicID(publicID string) (*repository.User, error) {
+	user, err := s.repo.GetUserByPublicID(publicID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user in service: %w", err)
+	}
+	return user, nil
+}
+
+// GetUsersByEmails looks the users up by their normalized emails, so callers should key any result
+// map by util.NormalizeEmail too.
 func (s *userService) GetUsersByEmails(emails []string) ([]*repository.User, error) {
-	users, err := s.repo.GetUsersByEmails(emails)
+	seen := util.NewSet[string]()
+	normalized := make([]string, 0, len(emails))
+	for _, email := range emails {
+		email = util.NormalizeEmail(email)
+		if !seen.IsMember(email) {
+			seen.Add(email)
+			normalized = append(normalized, email)
+		}
+	}
+
+	users, err := s.repo.GetUsersByEmails(normalized)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get users by emails in service: %w", err)
 	}
@@ -58,3 +115,27 @@ func (s *userService) GetUsersByIDs(ids []int) ([]*repository.User, error) {
 	}
 	return users, nil
 }
+
+func (s *userService) SetSplitWeight(id int, weight float64) (*repository.User, error) {
+	if weight <= 0 {
+		return nil, fmt.Errorf("split weight must be positive")
+	}
+
+	user, err := s.repo.UpdateSplitWeight(id, weight)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set split weight in service: %w", err)
+	}
+	return user, nil
+}
+
+func (s *userService) GetLastModified(email string) (time.Time, error) {
+	users, err := s.GetUsersByEmails([]string{email})
+	if err != nil {
+		return time.Time{}, err
+	}
+	lastModified, err := s.repo.GetLastModified(users[0].ID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get last modified time in service: %w", err)
+	}
+	return lastModified, nil
+}
@@ -4,66 +4,51 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/aadithya-md/split-expense/internal/mocks/repomock"
 	"github.com/aadithya-md/split-expense/internal/repository"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
 )
 
-type MockUserRepository struct {
-	mock.Mock
-}
-
-func (m *MockUserRepository) CreateUser(user *repository.User) (*repository.User, error) {
-	args := m.Called(user)
-	return args.Get(0).(*repository.User), args.Error(1)
-}
-
-func (m *MockUserRepository) GetUser(id int) (*repository.User, error) {
-	args := m.Called(id)
-	return args.Get(0).(*repository.User), args.Error(1)
-}
-
-func (m *MockUserRepository) GetUsersByEmails(emails []string) ([]*repository.User, error) {
-	args := m.Called(emails)
-	return args.Get(0).([]*repository.User), args.Error(1)
-}
-
-func (m *MockUserRepository) GetUserByEmail(email string) (*repository.User, error) {
-	args := m.Called(email)
-	return args.Get(0).(*repository.User), args.Error(1)
-}
+// fixedIDs generates the same public ID every time.
+type fixedIDs string
 
-func (m *MockUserRepository) GetUsersByIDs(ids []int) ([]*repository.User, error) {
-	args := m.Called(ids)
-	return args.Get(0).([]*repository.User), args.Error(1)
-}
+func (f fixedIDs) NewID() string { return string(f) }
 
 func TestUserService_CreateUser(t *testing.T) {
-	mockRepo := new(MockUserRepository)
-	userService := NewUserService(mockRepo)
+	mockRepo := new(repomock.UserRepository)
+	userService := NewUserService(mockRepo, fixedIDs("01HZX3Q8K2M4N6P8R0T2V4W6Y8"))
 
-	// Test case 1: Successful user creation
-	expectedUser := &repository.User{ID: 1, Name: "Test User", Email: "test@example.com"}
-	mockRepo.On("CreateUser", &repository.User{Name: "Test User", Email: "test@example.com"}).Return(expectedUser, nil).Once()
+	// Test case 1: Successful user creation, with a generated public ID
+	expectedUser := &repository.User{ID: 1, PublicID: "01HZX3Q8K2M4N6P8R0T2V4W6Y8", Name: "Test User", Email: "test@example.com"}
+	mockRepo.On("CreateUser", &repository.User{PublicID: "01HZX3Q8K2M4N6P8R0T2V4W6Y8", Name: "Test User", Email: "test@example.com"}).Return(expectedUser, nil).Once()
 
-	createdUser, err := userService.CreateUser("Test User", "test@example.com")
+	createdUser, err := userService.CreateUser("Test User", "test@example.com", "")
 	assert.Nil(t, err)
 	assert.Equal(t, expectedUser, createdUser)
 	mockRepo.AssertExpectations(t)
 
 	// Test case 2: Error from repository
-	mockRepo.On("CreateUser", &repository.User{Name: "Error User", Email: "error@example.com"}).Return((*repository.User)(nil), fmt.Errorf("repo error")).Once()
+	mockRepo.On("CreateUser", &repository.User{PublicID: "01HZX3Q8K2M4N6P8R0T2V4W6Y8", Name: "Error User", Email: "error@example.com"}).Return((*repository.User)(nil), fmt.Errorf("repo error")).Once()
 
-	createdUser, err = userService.CreateUser("Error User", "error@example.com")
+	createdUser, err = userService.CreateUser("Error User", "error@example.com", "")
 	assert.NotNil(t, err)
 	assert.Contains(t, err.Error(), "repo error")
 	assert.Nil(t, createdUser)
 	mockRepo.AssertExpectations(t)
+
+	// Test case 3: A public ID made by the client is kept
+	mine := "9b2e4f0c-3d1a-4c8e-a7f2-5e6d8c9b0a1f"
+	mockRepo.On("CreateUser", &repository.User{PublicID: mine, Name: "Test User", Email: "test@example.com"}).Return(&repository.User{ID: 2, PublicID: mine}, nil).Once()
+
+	createdUser, err = userService.CreateUser("Test User", "test@example.com", mine)
+	assert.Nil(t, err)
+	assert.Equal(t, mine, createdUser.PublicID)
+	mockRepo.AssertExpectations(t)
 }
 
 func TestUserService_GetUser(t *testing.T) {
-	mockRepo := new(MockUserRepository)
-	userService := NewUserService(mockRepo)
+	mockRepo := new(repomock.UserRepository)
+	userService := NewUserService(mockRepo, nil)
 
 	// Test case 1: Successful retrieval
 	expectedUser := &repository.User{ID: 1, Name: "Test User", Email: "test@example.com"}
@@ -94,8 +79,8 @@ func TestUserService_GetUser(t *testing.T) {
 }
 
 func TestUserService_GetUserByEmail(t *testing.T) {
-	mockRepo := new(MockUserRepository)
-	userService := NewUserService(mockRepo)
+	mockRepo := new(repomock.UserRepository)
+	userService := NewUserService(mockRepo, nil)
 
 	// Test case 1: Successful retrieval by email
 	expectedUser := &repository.User{ID: 1, Name: "Test User", Email: "test@example.com"}
@@ -126,3 +111,23 @@ func TestUserService_GetUserByEmail(t *testing.T) {
 	assert.Empty(t, users)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestUserService_SetSplitWeight(t *testing.T) {
+	mockRepo := new(repomock.UserRepository)
+	userService := NewUserService(mockRepo, nil)
+
+	// Test case 1: Successful update
+	expectedUser := &repository.User{ID: 1, Name: "Test User", Email: "test@example.com", SplitWeight: 2.5}
+	mockRepo.On("UpdateSplitWeight", 1, 2.5).Return(expectedUser, nil).Once()
+
+	user, err := userService.SetSplitWeight(1, 2.5)
+	assert.Nil(t, err)
+	assert.Equal(t, expectedUser, user)
+	mockRepo.AssertExpectations(t)
+
+	// Test case 2: Non-positive weights are rejected before reaching the repository
+	user, err = userService.SetSplitWeight(1, 0)
+	assert.Nil(t, user)
+	assert.Contains(t, err.Error(), "split weight must be positive")
+	mockRepo.AssertNumberOfCalls(t, "UpdateSplitWeight", 1)
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/util"
+)
+
+// ErrAmountMismatch is returned when what the splits of a new expense say was paid or owed doesn't
+// add up to its total, and the validation policy doesn't absorb the difference.
+var ErrAmountMismatch = errors.New("split amounts do not add up to the total")
+
+// Validation modes, see ValidationPolicy.
+const (
+	ValidationStrict  = "strict"
+	ValidationLenient = "lenient"
+)
+
+// StrictTolerance is the largest difference, in the expense's currency, that strict validation lets
+// through. Lenient validation always allows at least as much.
+const StrictTolerance = 0.01
+
+// ValidationPolicy decides what happens when the amounts paid, or the amounts owed of a manual
+// split, don't add up to a new expense's total. Either way a difference that is let through is
+// absorbed into the creator's own split and recorded on the expense, so the splits always add up;
+// an expense whose creator has no split to absorb it is rejected. Strict, the default, lets through
+// up to StrictTolerance, a rounding error of a cent or so, and rejects anything more. Lenient lets
+// through up to MaxAdjustment.
+type ValidationPolicy struct {
+	Mode          string
+	MaxAdjustment float64
+}
+
+// reconcileTotals checks the amounts in req against its total, changing the creator's split in req
+// when the policy absorbs a difference, in exp decimal places. It returns what was changed, or nil
+// if nothing was.
+func (p ValidationPolicy) reconcileTotals(req *CreateExpenseRequest, exp int) (*repository.ExpenseAdjustment, error) {
+	// A currency with no cents tolerates nothing: its minor unit is already more than StrictTolerance
+	maxUnits := util.ToMinorUnits(StrictTolerance, exp)
+	if p.Mode == ValidationLenient {
+		maxUnits = max(maxUnits, util.ToMinorUnits(p.MaxAdjustment, exp))
+	}
+
+	var adjustment repository.ExpenseAdjustment
+	// The creator of a payer-only expense pays it all and the participants pay nothing
+	if !req.PayerOnly {
+		paid, creator := paidAmounts(req)
+		sum, change, ok := absorb(paid, creator, req.GrandTotal(exp), exp, maxUnits)
+		if !ok {
+			return nil, fmt.Errorf("%w: total amount paid across all splits (%.2f) does not match total expense amount (%.2f)", ErrAmountMismatch, sum, req.GrandTotal(exp))
+		}
+		adjustment.AmountPaid = change
+	}
+	if req.SplitMethod == SplitMethodManual {
+		owed, creator := owedAmounts(req)
+		sum, change, ok := absorb(owed, creator, req.TotalAmount, exp, maxUnits)
+		if !ok {
+			return nil, fmt.Errorf("%w: total amount owed across all splits (%.2f) does not match total expense amount (%.2f)", ErrAmountMismatch, sum, req.TotalAmount)
+		}
+		adjustment.AmountOwed = change
+	}
+
+	if adjustment == (repository.ExpenseAdjustment{}) {
+		return nil, nil
+	}
+	return &adjustment, nil
+}
+
+// absorb makes amounts add up to total by changing the one at index creator, as long as they are
+// off by at most maxUnits and it doesn't go negative. It returns what the amounts added up to, the
+// change made and whether they add up now. creator is -1 when the creator has no amount to change.
+func absorb(amounts []*float64, creator int, total float64, exp int, maxUnits int64) (sum, change float64, ok bool) {
+	var sumUnits int64
+	for _, a := range amounts {
+		sumUnits += util.ToMinorUnits(*a, exp)
+	}
+	sum = util.FromMinorUnits(sumUnits, exp)
+	diff := util.ToMinorUnits(total, exp) - sumUnits
+	if diff == 0 {
+		return sum, 0, true
+	}
+	if creator < 0 || diff > maxUnits || -diff > maxUnits {
+		return sum, 0, false
+	}
+	adjusted := util.ToMinorUnits(*amounts[creator], exp) + diff
+	if adjusted < 0 {
+		return sum, 0, false
+	}
+	*amounts[creator] = util.FromMinorUnits(adjusted, exp)
+	return sum, util.FromMinorUnits(diff, exp), true
+}
+
+// paidAmounts points at the amount paid of each participant of req's split method, and returns the
+// index of the creator's, or -1 if they are not a participant.
+func paidAmounts(req *CreateExpenseRequest) ([]*float64, int) {
+	var paid []*float64
+	creator := -1
+	add := func(userID int, amount *float64) {
+		if userID == req.CreatedByID {
+			creator = len(paid)
+		}
+		paid = append(paid, amount)
+	}
+	switch req.SplitMethod {
+	case SplitMethodEqual:
+		for i := range req.EqualSplits {
+			add(req.EqualSplits[i].UserID, &req.EqualSplits[i].AmountPaid)
+		}
+	case SplitMethodPercentage:
+		for i := range req.PercentageSplits {
+			add(req.PercentageSplits[i].UserID, &req.PercentageSplits[i].AmountPaid)
+		}
+	case SplitMethodManual:
+		for i := range req.ManualSplits {
+			add(req.ManualSplits[i].UserID, &req.ManualSplits[i].AmountPaid)
+		}
+	case SplitMethodDays:
+		for i := range req.DaysSplits {
+			add(req.DaysSplits[i].UserID, &req.DaysSplits[i].AmountPaid)
+		}
+	case SplitMethodWeighted:
+		for i := range req.WeightedSplits {
+			add(req.WeightedSplits[i].UserID, &req.WeightedSplits[i].AmountPaid)
+		}
+	}
+	return paid, creator
+}
+
+// owedAmounts is paidAmounts for the amounts owed of a manual split.
+func owedAmounts(req *CreateExpenseRequest) ([]*float64, int) {
+	var owed []*float64
+	creator := -1
+	for i := range req.ManualSplits {
+		if req.ManualSplits[i].UserID == req.CreatedByID {
+			creator = len(owed)
+		}
+		owed = append(owed, &req.ManualSplits[i].AmountOwed)
+	}
+	return owed, creator
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationPolicy_ReconcileTotals(t *testing.T) {
+	strict := ValidationPolicy{Mode: ValidationStrict, MaxAdjustment: 0.05}
+	lenient := ValidationPolicy{Mode: ValidationLenient, MaxAdjustment: 0.05}
+	equal := func(alicePaid, bobPaid float64) CreateExpenseRequest {
+		return CreateExpenseRequest{
+			TotalAmount: 100, Currency: "INR", CreatedByID: 1, SplitMethod: SplitMethodEqual,
+			EqualSplits: []EqualSplitRequest{{UserID: 2, AmountPaid: bobPaid}, {UserID: 1, AmountPaid: alicePaid}},
+		}
+	}
+
+	// Test case 1: Amounts that add up pass either way, untouched
+	for _, policy := range []ValidationPolicy{strict, lenient} {
+		req := equal(60, 40)
+		adjustment, err := policy.reconcileTotals(&req, 2)
+		require.NoError(t, err)
+		assert.Nil(t, adjustment)
+		assert.Equal(t, 60.0, req.EqualSplits[1].AmountPaid)
+	}
+
+	// Test case 2: Strict lets a cent through in the creator's amount paid, but no more
+	req := equal(59.99, 40)
+	adjustment, err := strict.reconcileTotals(&req, 2)
+	require.NoError(t, err)
+	assert.Equal(t, &repository.ExpenseAdjustment{AmountPaid: 0.01}, adjustment)
+	assert.Equal(t, 60.0, req.EqualSplits[1].AmountPaid)
+
+	req = equal(59.98, 40)
+	_, err = strict.reconcileTotals(&req, 2)
+	assert.ErrorIs(t, err, ErrAmountMismatch)
+	assert.ErrorContains(t, err, "total amount paid across all splits (99.98) does not match total expense amount (100.00)")
+
+	// Test case 3: Lenient makes the creator's amount paid up a larger difference
+	adjustment, err = lenient.reconcileTotals(&req, 2)
+	require.NoError(t, err)
+	assert.Equal(t, &repository.ExpenseAdjustment{AmountPaid: 0.02}, adjustment)
+	assert.Equal(t, 60.0, req.EqualSplits[1].AmountPaid)
+	assert.Equal(t, 40.0, req.EqualSplits[0].AmountPaid)
+
+	req = equal(60.03, 40)
+	adjustment, err = lenient.reconcileTotals(&req, 2)
+	require.NoError(t, err)
+	assert.Equal(t, &repository.ExpenseAdjustment{AmountPaid: -0.03}, adjustment)
+
+	// Test case 4: Lenient still refuses larger differences
+	req = equal(59.9, 40)
+	_, err = lenient.reconcileTotals(&req, 2)
+	assert.ErrorIs(t, err, ErrAmountMismatch)
+	assert.Equal(t, 59.9, req.EqualSplits[1].AmountPaid)
+
+	// Test case 5: Manual amounts owed are made up in the creator's share too
+	manual := CreateExpenseRequest{
+		TotalAmount: 100, Currency: "INR", CreatedByID: 1, SplitMethod: SplitMethodManual,
+		ManualSplits: []ManualSplitRequest{
+			{UserID: 1, AmountOwed: 33.32, AmountPaid: 100},
+			{UserID: 2, AmountOwed: 33.33},
+			{UserID: 3, AmountOwed: 33.33},
+		},
+	}
+	_, err = strict.reconcileTotals(&manual, 2)
+	assert.ErrorContains(t, err, "total amount owed across all splits (99.98) does not match total expense amount (100.00)")
+	adjustment, err = lenient.reconcileTotals(&manual, 2)
+	require.NoError(t, err)
+	assert.Equal(t, &repository.ExpenseAdjustment{AmountOwed: 0.02}, adjustment)
+	assert.Equal(t, 33.34, manual.ManualSplits[0].AmountOwed)
+
+	// Test case 6: Nothing can be taken off an amount that would go negative
+	req = equal(0, 100.02)
+	_, err = lenient.reconcileTotals(&req, 2)
+	assert.ErrorIs(t, err, ErrAmountMismatch)
+
+	// Test case 7: The creator of a payer-only expense has no share to absorb what is owed
+	manual.PayerOnly = true
+	manual.ManualSplits = []ManualSplitRequest{{UserID: 2, AmountOwed: 50}, {UserID: 3, AmountOwed: 49.99}}
+	_, err = lenient.reconcileTotals(&manual, 2)
+	assert.ErrorIs(t, err, ErrAmountMismatch)
+
+	// Test case 8: Strict's cent is in the expense's currency, so it is nothing in yen and ten fils
+	// in dinar
+	yen := CreateExpenseRequest{
+		TotalAmount: 1000, Currency: "JPY", CreatedByID: 1, SplitMethod: SplitMethodEqual,
+		EqualSplits: []EqualSplitRequest{{UserID: 1, AmountPaid: 999}, {UserID: 2}},
+	}
+	_, err = strict.reconcileTotals(&yen, 0)
+	assert.ErrorIs(t, err, ErrAmountMismatch)
+	dinar := CreateExpenseRequest{
+		TotalAmount: 10, Currency: "KWD", CreatedByID: 1, SplitMethod: SplitMethodEqual,
+		EqualSplits: []EqualSplitRequest{{UserID: 1, AmountPaid: 9.99}, {UserID: 2}},
+	}
+	adjustment, err = strict.reconcileTotals(&dinar, 3)
+	require.NoError(t, err)
+	assert.Equal(t, &repository.ExpenseAdjustment{AmountPaid: 0.01}, adjustment)
+	dinar.EqualSplits[0].AmountPaid = 9.989
+	_, err = strict.reconcileTotals(&dinar, 3)
+	assert.ErrorIs(t, err, ErrAmountMismatch)
+}
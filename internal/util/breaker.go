@@ -0,0 +1,109 @@
+package util
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of calling a dependency whose breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // Calls go through
+	BreakerOpen     BreakerState = "open"      // Calls fail fast with ErrCircuitOpen
+	BreakerHalfOpen BreakerState = "half_open" // One trial call decides whether to close again
+)
+
+// BreakerStats is a snapshot of a breaker for health and metrics reporting.
+type BreakerStats struct {
+	Name                string       `json:"name"`
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	Trips               int          `json:"trips"`    // Times the breaker has opened
+	Rejected            int          `json:"rejected"` // Calls refused while open
+}
+
+// CircuitBreaker stops calling a failing third party for a while, so a slow or down dependency
+// costs callers an immediate error rather than a goroutine stuck on a timeout.
+// It opens after Threshold consecutive failures and lets one trial call through after Cooldown.
+type CircuitBreaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	trial    bool // A half-open trial call is in flight
+	trips    int
+	rejected int
+}
+
+func NewCircuitBreaker(name string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{name: name, threshold: threshold, cooldown: cooldown, now: time.Now, state: BreakerClosed}
+}
+
+// Call runs fn unless the breaker is open. Errors from fn count as failures; callers should make
+// fn return nil for outcomes that say nothing about the dependency's health, like a 404.
+func (b *CircuitBreaker) Call(fn func() error) error {
+	if err := b.before(); err != nil {
+		return err
+	}
+	err := fn()
+	b.after(err == nil)
+	return err
+}
+
+func (b *CircuitBreaker) before() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		b.state = BreakerHalfOpen
+	}
+	switch {
+	case b.state == BreakerOpen, b.state == BreakerHalfOpen && b.trial:
+		b.rejected++
+		return ErrCircuitOpen
+	case b.state == BreakerHalfOpen:
+		b.trial = true
+	}
+	return nil
+}
+
+func (b *CircuitBreaker) after(ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	if ok {
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+		b.trips++
+	}
+}
+
+func (b *CircuitBreaker) Stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.state
+	if state == BreakerOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		state = BreakerHalfOpen
+	}
+	return BreakerStats{Name: b.name, State: state, ConsecutiveFailures: b.failures, Trips: b.trips, Rejected: b.rejected}
+}
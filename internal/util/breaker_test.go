@@ -0,0 +1,58 @@
+package util
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker("rates", 2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	down := errors.New("connection refused")
+	fail := func() error { return down }
+	succeed := func() error { return nil }
+
+	// Test case 1: Failures below the threshold pass through
+	assert.ErrorIs(t, b.Call(fail), down)
+	assert.Equal(t, BreakerClosed, b.Stats().State)
+
+	// Test case 2: Reaching the threshold opens the breaker and later calls fail fast
+	assert.ErrorIs(t, b.Call(fail), down)
+	called := false
+	assert.ErrorIs(t, b.Call(func() error { called = true; return nil }), ErrCircuitOpen)
+	assert.False(t, called)
+	assert.Equal(t, BreakerStats{Name: "rates", State: BreakerOpen, ConsecutiveFailures: 2, Trips: 1, Rejected: 1}, b.Stats())
+
+	// Test case 3: After the cooldown a failed trial opens it again
+	now = now.Add(time.Minute)
+	assert.Equal(t, BreakerHalfOpen, b.Stats().State)
+	assert.ErrorIs(t, b.Call(fail), down)
+	assert.Equal(t, BreakerOpen, b.Stats().State)
+	assert.Equal(t, 2, b.Stats().Trips)
+
+	// Test case 4: A successful trial closes it
+	now = now.Add(time.Minute)
+	assert.NoError(t, b.Call(succeed))
+	assert.Equal(t, BreakerClosed, b.Stats().State)
+	assert.Equal(t, 0, b.Stats().ConsecutiveFailures)
+}
+
+func TestCircuitBreaker_SingleTrial(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker("ocr", 1, time.Second)
+	b.now = func() time.Time { return now }
+	assert.Error(t, b.Call(func() error { return errors.New("timeout") }))
+	now = now.Add(time.Second)
+
+	// Only the first caller after the cooldown gets through while the trial is running
+	assert.NoError(t, b.Call(func() error {
+		assert.ErrorIs(t, b.Call(func() error { return nil }), ErrCircuitOpen)
+		return nil
+	}))
+	assert.Equal(t, BreakerClosed, b.Stats().State)
+}
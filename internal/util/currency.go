@@ -0,0 +1,60 @@
+package util
+
+import "math"
+
+// DefaultCurrency is assumed for amounts recorded without a currency code. Balances, settlements
+// and loans are all kept in it.
+const DefaultCurrency = "INR"
+
+// UnitCurrency is ISO 4217's code for no currency. Expenses in an event that counts its own unit,
+// such as chore points, are kept in it, in whole units, and never reach the balances.
+const UnitCurrency = "XXX"
+
+// DefaultExponent is the number of decimal places in DefaultCurrency's minor unit, the one
+// balances, settlements and loans are rounded to.
+const DefaultExponent = 2
+
+// Currencies tells how many decimal places each currency's minor unit has, as the currencies table
+// lists them. Every currency it doesn't list has two, as does every currency for the zero value.
+// It can't be changed once built, so one value can be shared by everything that rounds amounts.
+type Currencies struct {
+	exponents map[string]int
+}
+
+// NewCurrencies returns the currencies whose minor units exponents lists, as loaded from the
+// currencies table.
+func NewCurrencies(exponents map[string]int) Currencies {
+	c := Currencies{exponents: make(map[string]int, len(exponents))}
+	for currency, exp := range exponents {
+		c.exponents[currency] = exp
+	}
+	return c
+}
+
+// Exponent returns the number of decimal places in the currency's minor unit.
+func (c Currencies) Exponent(currency string) int {
+	if exp, ok := c.exponents[currency]; ok {
+		return exp
+	}
+	return 2
+}
+
+// ToMinorUnits converts an amount to a whole number of minor units, rounding to the nearest unit.
+func ToMinorUnits(f float64, exponent int) int64 {
+	return int64(math.Round(f * math.Pow10(exponent)))
+}
+
+// FromMinorUnits converts a whole number of minor units back to an amount.
+func FromMinorUnits(u int64, exponent int) float64 {
+	return float64(u) / math.Pow10(exponent)
+}
+
+// RoundToCurrency rounds an amount to the currency's minor unit.
+func RoundToCurrency(f float64, exponent int) float64 {
+	return FromMinorUnits(ToMinorUnits(f, exponent), exponent)
+}
+
+// RoundToDefaultCurrency rounds an amount to DefaultCurrency's minor unit.
+func RoundToDefaultCurrency(f float64) float64 {
+	return RoundToCurrency(f, DefaultExponent)
+}
@@ -0,0 +1,39 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurrencies_Exponent(t *testing.T) {
+	loaded := map[string]int{"JPY": 0, "KWD": 3}
+	currencies := NewCurrencies(loaded)
+
+	// Test case 1: Loaded currencies keep their minor unit
+	assert.Equal(t, 0, currencies.Exponent("JPY"))
+	assert.Equal(t, 3, currencies.Exponent("KWD"))
+
+	// Test case 2: Everything else has two decimal places, and everything does without any loaded
+	assert.Equal(t, 2, currencies.Exponent("INR"))
+	assert.Equal(t, 2, currencies.Exponent(""))
+	assert.Equal(t, 2, Currencies{}.Exponent("JPY"))
+
+	// Test case 3: Changing the map it was built from changes nothing
+	loaded["JPY"] = 2
+	assert.Equal(t, 0, currencies.Exponent("JPY"))
+}
+
+func TestMinorUnits(t *testing.T) {
+	assert.Equal(t, int64(1235), ToMinorUnits(1234.5, 0))
+	assert.Equal(t, int64(12345), ToMinorUnits(123.45, 2))
+	assert.Equal(t, int64(1234), ToMinorUnits(1.234, 3))
+
+	assert.Equal(t, 1235.0, FromMinorUnits(1235, 0))
+	assert.Equal(t, 1.234, FromMinorUnits(1234, 3))
+
+	assert.Equal(t, 33.33, RoundToCurrency(33.333333, 2))
+	assert.Equal(t, 33.333, RoundToCurrency(33.333333, 3))
+	assert.Equal(t, 33.0, RoundToCurrency(33.333333, 0))
+	assert.Equal(t, 33.33, RoundToDefaultCurrency(33.333333))
+}
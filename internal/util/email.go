@@ -0,0 +1,9 @@
+package util
+
+import "strings"
+
+// NormalizeEmail returns the canonical form emails are stored and looked up in. Addresses are
+// compared case-insensitively, so Alice@Example.com and alice@example.com are the same user.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
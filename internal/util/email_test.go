@@ -0,0 +1,13 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	assert.Equal(t, "alice@example.com", NormalizeEmail("  Alice@Example.COM\n"))
+	assert.Equal(t, "bob+trips@example.com", NormalizeEmail("bob+trips@example.com"))
+	assert.Equal(t, "", NormalizeEmail("   "))
+}
@@ -0,0 +1,87 @@
+package util
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// ID formats public IDs can be generated in.
+const (
+	IDFormatUUID = "uuid"
+	IDFormatULID = "ulid"
+)
+
+// IDGenerator makes the public IDs users and expenses are known by outside the database. They are
+// random, so unlike the integer keys they can't be guessed or counted through.
+type IDGenerator interface {
+	NewID() string
+}
+
+// NewIDGenerator returns the generator for format, IDFormatUUID or IDFormatULID.
+func NewIDGenerator(format string) (IDGenerator, error) {
+	switch format {
+	case IDFormatUUID:
+		return UUIDGenerator{}, nil
+	case IDFormatULID:
+		return ULIDGenerator{Now: time.Now}, nil
+	default:
+		return nil, fmt.Errorf("unsupported ID format %q, use %s or %s", format, IDFormatUUID, IDFormatULID)
+	}
+}
+
+// UUIDGenerator makes random (version 4) UUIDs, like "9b2e4f0c-3d1a-4c8e-a7f2-5e6d8c9b0a1f".
+type UUIDGenerator struct{}
+
+func (UUIDGenerator) NewID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	h := hex.EncodeToString(b[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// ULIDGenerator makes ULIDs, like "01HZX3Q8K2M4N6P8R0T2V4W6Y8". They start with the time they were
+// made, to the millisecond, so they sort in the order they were made.
+type ULIDGenerator struct {
+	Now func() time.Time
+}
+
+// crockford is the base 32 alphabet of ULIDs, which leaves out I, L, O and U.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+func (g ULIDGenerator) NewID() string {
+	var b [16]byte
+	ms := uint64(g.Now().UnixMilli())
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+	rand.Read(b[6:])
+
+	// 128 bits in 26 characters of 5 bits, the first holding only the top 3
+	var out [26]byte
+	var acc uint64
+	bits, n := 2, 0 // Pad the front so the bits split evenly
+	for _, c := range b {
+		acc = acc<<8 | uint64(c)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			out[n] = crockford[(acc>>bits)&0x1f]
+			n++
+		}
+	}
+	return string(out[:])
+}
+
+var publicIDPattern = regexp.MustCompile(`^([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|[0-7][0-9A-HJKMNP-TV-Z]{25})$`)
+
+// ValidPublicID reports whether id is a UUID in lowercase or a ULID in uppercase, the forms either
+// generator makes, so a client can make its own ID in whichever format the server is set to.
+func ValidPublicID(id string) bool {
+	return publicIDPattern.MatchString(id)
+}
@@ -0,0 +1,49 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUUIDGenerator(t *testing.T) {
+	a, b := UUIDGenerator{}.NewID(), UUIDGenerator{}.NewID()
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, a)
+	assert.NotEqual(t, a, b)
+	assert.True(t, ValidPublicID(a))
+}
+
+func TestULIDGenerator(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	g := ULIDGenerator{Now: func() time.Time { return now }}
+
+	// Test case 1: The time is in the first ten characters
+	id := g.NewID()
+	assert.Len(t, id, 26)
+	assert.Equal(t, "01KKRNW4G0", id[:10])
+	assert.True(t, ValidPublicID(id))
+
+	// Test case 2: Later IDs sort after earlier ones
+	now = now.Add(time.Millisecond)
+	assert.Greater(t, g.NewID(), id)
+}
+
+func TestNewIDGenerator(t *testing.T) {
+	g, err := NewIDGenerator(IDFormatULID)
+	require.NoError(t, err)
+	assert.Len(t, g.NewID(), 26)
+
+	_, err = NewIDGenerator("serial")
+	assert.Error(t, err)
+}
+
+func TestValidPublicID(t *testing.T) {
+	assert.True(t, ValidPublicID("9b2e4f0c-3d1a-4c8e-a7f2-5e6d8c9b0a1f"))
+	assert.True(t, ValidPublicID("01HZX3Q8K2M4N6P8R0T2V4W6Y8"))
+	assert.False(t, ValidPublicID("9B2E4F0C-3D1A-4C8E-A7F2-5E6D8C9B0A1F"))
+	assert.False(t, ValidPublicID("81HZX3Q8K2M4N6P8R0T2V4W6Y8")) // Past the largest ULID
+	assert.False(t, ValidPublicID("01HZX3Q8K2M4N6P8R0T2V4W6YU"))
+	assert.False(t, ValidPublicID("42"))
+}
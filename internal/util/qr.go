@@ -0,0 +1,490 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// ErrQRTooLong is returned for data that does not fit the largest QR code EncodeQR makes.
+var ErrQRTooLong = errors.New("data is too long for a QR code")
+
+// QRCode is a QR code's grid of modules, true for dark, indexed [row][column].
+type QRCode struct {
+	Version int
+	Modules [][]bool
+}
+
+// Size is the number of modules along each side, not counting the quiet zone.
+func (q *QRCode) Size() int {
+	return len(q.Modules)
+}
+
+// qrVersion is the block layout of one QR version at error correction level M, which can lose
+// about 15% of the code to damage and still scan.
+type qrVersion struct {
+	codewords   int // Data and error correction codewords in all
+	eccPerBlock int
+	blocks      int
+	alignment   []int // Centres of the alignment patterns, along both axes
+}
+
+var qrVersions = []qrVersion{
+	1:  {26, 10, 1, nil},
+	2:  {44, 16, 1, []int{6, 18}},
+	3:  {70, 26, 1, []int{6, 22}},
+	4:  {100, 18, 2, []int{6, 26}},
+	5:  {134, 24, 2, []int{6, 30}},
+	6:  {172, 16, 4, []int{6, 34}},
+	7:  {196, 18, 4, []int{6, 22, 38}},
+	8:  {242, 22, 4, []int{6, 24, 42}},
+	9:  {292, 22, 5, []int{6, 26, 46}},
+	10: {346, 26, 5, []int{6, 28, 50}},
+}
+
+func (v qrVersion) dataCodewords() int {
+	return v.codewords - v.eccPerBlock*v.blocks
+}
+
+// EncodeQR encodes data in byte mode at error correction level M, in the smallest version that
+// holds it. Versions up to 10 are supported, which is 213 bytes, plenty for a link.
+func EncodeQR(data []byte) (*QRCode, error) {
+	version := 0
+	for v := 1; v < len(qrVersions); v++ {
+		if qrDataBits(v, len(data)) <= qrVersions[v].dataCodewords()*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrQRTooLong
+	}
+
+	q := newQRBuilder(version)
+	q.drawFunctionPatterns()
+	q.drawCodewords(qrInterleave(qrVersions[version], qrDataCodewords(version, data)))
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormatBits(mask)
+		if p := q.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		q.applyMask(mask) // Masking twice undoes it
+	}
+	q.applyMask(best)
+	q.drawFormatBits(best)
+
+	return &QRCode{Version: version, Modules: q.modules}, nil
+}
+
+// PNG renders the code with scale pixels per module and the four-module quiet zone scanners need.
+func (q *QRCode) PNG(scale int) ([]byte, error) {
+	const quiet = 4
+	side := (q.Size() + 2*quiet) * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+	for y, row := range q.Modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex((x+quiet)*scale+dx, (y+quiet)*scale+dy, 1)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// qrDataBits is the length of n bytes in byte mode: the mode, the count and the bytes.
+func qrDataBits(version, n int) int {
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	return 4 + countBits + 8*n
+}
+
+// qrDataCodewords lays data out in byte mode and pads it to the version's data capacity.
+func qrDataCodewords(version int, data []byte) []byte {
+	capacity := qrVersions[version].dataCodewords()
+	var bits qrBitBuffer
+	bits.append(0b0100, 4)
+	if version >= 10 {
+		bits.append(len(data), 16)
+	} else {
+		bits.append(len(data), 8)
+	}
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	// Terminator, then zeros up to a whole byte
+	bits.append(0, min(4, capacity*8-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+
+	codewords := make([]byte, len(bits)/8, capacity)
+	for i, bit := range bits {
+		if bit {
+			codewords[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	for pad := byte(0xEC); len(codewords) < capacity; pad ^= 0xEC ^ 0x11 {
+		codewords = append(codewords, pad)
+	}
+	return codewords
+}
+
+type qrBitBuffer []bool
+
+func (b *qrBitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 == 1)
+	}
+}
+
+// qrInterleave splits the data into blocks, adds each block's error correction, and interleaves
+// the blocks codeword by codeword, data first. Later blocks are one data codeword longer when the
+// data doesn't divide evenly.
+func qrInterleave(v qrVersion, data []byte) []byte {
+	shortBlocks := v.blocks - v.codewords%v.blocks
+	shortLen := v.codewords/v.blocks - v.eccPerBlock
+	divisor := qrRSDivisor(v.eccPerBlock)
+
+	var dataBlocks, eccBlocks [][]byte
+	for i, k := 0, 0; i < v.blocks; i++ {
+		n := shortLen
+		if i >= shortBlocks {
+			n++
+		}
+		block := data[k : k+n]
+		k += n
+		dataBlocks = append(dataBlocks, block)
+		eccBlocks = append(eccBlocks, qrRSRemainder(block, divisor))
+	}
+
+	result := make([]byte, 0, v.codewords)
+	for i := 0; i <= shortLen; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < v.eccPerBlock; i++ {
+		for _, block := range eccBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+// qrRSDivisor is the Reed-Solomon generator polynomial of the given degree, highest term first
+// and without its leading 1.
+func qrRSDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = qrGFMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = qrGFMultiply(root, 0x02)
+	}
+	return result
+}
+
+func qrRSRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= qrGFMultiply(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// qrGFMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func qrGFMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+type qrBuilder struct {
+	version    int
+	size       int
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+func newQRBuilder(version int) *qrBuilder {
+	size := 17 + 4*version
+	q := &qrBuilder{version: version, size: size}
+	for i := 0; i < size; i++ {
+		q.modules = append(q.modules, make([]bool, size))
+		q.isFunction = append(q.isFunction, make([]bool, size))
+	}
+	return q
+}
+
+func (q *qrBuilder) setFunction(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.isFunction[y][x] = true
+}
+
+func (q *qrBuilder) drawFunctionPatterns() {
+	for i := 0; i < q.size; i++ {
+		q.setFunction(6, i, i%2 == 0)
+		q.setFunction(i, 6, i%2 == 0)
+	}
+	q.drawFinder(3, 3)
+	q.drawFinder(q.size-4, 3)
+	q.drawFinder(3, q.size-4)
+
+	align := qrVersions[q.version].alignment
+	last := len(align) - 1
+	for i := range align {
+		for j := range align {
+			// Skip the three corners the finders occupy
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			q.drawAlignment(align[i], align[j])
+		}
+	}
+
+	q.drawFormatBits(0) // Reserves the area; redrawn once the mask is chosen
+	q.drawVersion()
+}
+
+// drawFinder draws a finder pattern and its light separator around the centre x, y.
+func (q *qrBuilder) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= q.size || yy < 0 || yy >= q.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			q.setFunction(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (q *qrBuilder) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			q.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// qrFormatBits is the 15-bit format information for level M and the mask: the level and mask,
+// a BCH code over them, and the fixed XOR pattern.
+func qrFormatBits(mask int) int {
+	const levelM = 0b00
+	data := levelM<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+func (q *qrBuilder) drawFormatBits(mask int) {
+	bits := qrFormatBits(mask)
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	// Around the top-left finder
+	for i := 0; i <= 5; i++ {
+		q.setFunction(8, i, bit(i))
+	}
+	q.setFunction(8, 7, bit(6))
+	q.setFunction(8, 8, bit(7))
+	q.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.setFunction(14-i, 8, bit(i))
+	}
+
+	// Split between the other two finders
+	for i := 0; i < 8; i++ {
+		q.setFunction(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.setFunction(8, q.size-15+i, bit(i))
+	}
+	q.setFunction(8, q.size-8, true) // Always dark
+}
+
+// qrVersionBits is the 18-bit version information versions 7 and up carry: the version and a BCH
+// code over it.
+func qrVersionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	return version<<12 | rem
+}
+
+func (q *qrBuilder) drawVersion() {
+	if q.version < 7 {
+		return
+	}
+	bits := qrVersionBits(q.version)
+	for i := 0; i < 18; i++ {
+		dark := bits>>i&1 == 1
+		a, b := q.size-11+i%3, i/3
+		q.setFunction(a, b, dark)
+		q.setFunction(b, a, dark)
+	}
+}
+
+// drawCodewords places the codewords in two-module columns zigzagging up and down from the
+// bottom right, skipping the vertical timing pattern and every function module.
+func (q *qrBuilder) drawCodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < q.size; vert++ {
+			y := vert
+			if upward {
+				y = q.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if q.isFunction[y][x] || i >= len(data)*8 {
+					continue
+				}
+				q.modules[y][x] = data[i/8]>>(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask flips the data modules the mask pattern selects. Applying the same mask again undoes it.
+func (q *qrBuilder) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.isFunction[y][x] {
+				continue
+			}
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the code is to scan, by the standard's four rules: long runs of one
+// colour, 2x2 blocks of one colour, patterns that look like finders, and an uneven balance of
+// dark and light.
+func (q *qrBuilder) penalty() int {
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return q.modules[x][y]
+		}
+		return q.modules[y][x]
+	}
+	finderLike := [][]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+
+	result := 0
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < q.size; y++ {
+			run := 1
+			for x := 1; x <= q.size; x++ {
+				if x < q.size && at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					result += 3 + run - 5
+				}
+				run = 1
+			}
+			for x := 0; x+len(finderLike[0]) <= q.size; x++ {
+				for _, pattern := range finderLike {
+					match := true
+					for i, dark := range pattern {
+						if at(x+i, y, transpose) != dark {
+							match = false
+							break
+						}
+					}
+					if match {
+						result += 40
+					}
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 < q.size && y+1 < q.size {
+				c := q.modules[y][x]
+				if c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+					result += 3
+				}
+			}
+		}
+	}
+	total := q.size * q.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return result + k*10
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,129 @@
+package util
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQRReedSolomon(t *testing.T) {
+	// The worked example from the standard: "HELLO WORLD" as 1-M
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	ecc := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	assert.Equal(t, ecc, qrRSRemainder(data, qrRSDivisor(10)))
+}
+
+func TestQRFormatAndVersionBits(t *testing.T) {
+	// Test case 1: The standard's table of format information for level M
+	for mask, want := range []int{
+		0b101010000010010, 0b101000100100101, 0b101111001111100, 0b101101101001011,
+		0b100010111111001, 0b100000011001110, 0b100111110010111, 0b100101010100000,
+	} {
+		assert.Equal(t, want, qrFormatBits(mask), "mask %d", mask)
+	}
+
+	// Test case 2: And of version information
+	assert.Equal(t, 0b000111110010010100, qrVersionBits(7))
+	assert.Equal(t, 0b001010010011010011, qrVersionBits(10))
+}
+
+func TestQRDataCodewords(t *testing.T) {
+	// Byte mode, a count of 2, "hi", the terminator, then alternating pad bytes
+	got := qrDataCodewords(1, []byte("hi"))
+	assert.Equal(t, []byte{0x40, 0x26, 0x86, 0x90, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}, got)
+}
+
+func TestEncodeQR(t *testing.T) {
+	// Test case 1: The smallest version that fits is chosen
+	for _, tc := range []struct {
+		n, version int
+	}{{14, 1}, {15, 2}, {106, 6}, {107, 7}, {213, 10}} {
+		code, err := EncodeQR(bytes.Repeat([]byte("a"), tc.n))
+		require.NoError(t, err)
+		assert.Equal(t, tc.version, code.Version, "%d bytes", tc.n)
+		assert.Equal(t, 17+4*tc.version, code.Size())
+	}
+
+	// Test case 2: Too long
+	_, err := EncodeQR(bytes.Repeat([]byte("a"), 214))
+	assert.ErrorIs(t, err, ErrQRTooLong)
+
+	// Test case 3: Reading the modules back gives the codewords, under the mask the format names
+	link := []byte("http://localhost:8080/invites/expense-invite:12.1760000000.c2lnbmF0dXJlIG9mIHRoaXMgdG9rZW4")
+	code, err := EncodeQR(link)
+	require.NoError(t, err)
+	q := newQRBuilder(code.Version)
+	q.drawFunctionPatterns()
+	var format int
+	for i := 0; i <= 5; i++ {
+		if code.Modules[i][8] {
+			format |= 1 << i
+		}
+	}
+	for i, xy := range [][2]int{{8, 7}, {8, 8}, {7, 8}} {
+		if code.Modules[xy[1]][xy[0]] {
+			format |= 1 << (6 + i)
+		}
+	}
+	for i := 9; i < 15; i++ {
+		if code.Modules[8][14-i] {
+			format |= 1 << i
+		}
+	}
+	mask := -1
+	for m := 0; m < 8; m++ {
+		if qrFormatBits(m) == format {
+			mask = m
+		}
+	}
+	require.GreaterOrEqual(t, mask, 0, "format bits %015b", format)
+	for i := 0; i < 8; i++ {
+		assert.Equal(t, format>>i&1 == 1, code.Modules[8][code.Size()-1-i], "second copy of format bit %d", i)
+	}
+
+	q.modules = code.Modules
+	q.applyMask(mask)
+	var read []byte
+	var cur byte
+	bits := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.size; vert++ {
+			y := vert
+			if (right+1)&2 == 0 {
+				y = q.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				if q.isFunction[y][right-j] {
+					continue
+				}
+				cur = cur<<1 | map[bool]byte{false: 0, true: 1}[q.modules[y][right-j]]
+				if bits++; bits%8 == 0 {
+					read = append(read, cur)
+				}
+			}
+		}
+	}
+	v := qrVersions[code.Version]
+	require.GreaterOrEqual(t, len(read), v.codewords)
+	assert.Equal(t, qrInterleave(v, qrDataCodewords(code.Version, link)), read[:v.codewords])
+
+	// Test case 4: The PNG has the quiet zone and the finder in the right place
+	out, err := code.PNG(3)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(out))
+	require.NoError(t, err)
+	side := (code.Size() + 8) * 3
+	assert.Equal(t, side, img.Bounds().Dx())
+	dark := func(x, y int) bool { r, _, _, _ := img.At(x, y).RGBA(); return r == 0 }
+	assert.False(t, dark(0, 0))
+	assert.True(t, dark(4*3, 4*3))
+	assert.False(t, dark(5*3, 5*3))
+	assert.True(t, strings.HasPrefix(string(out), "\x89PNG"))
+}
@@ -33,7 +33,8 @@ func (s *Set[T]) Remove(item T) {
 	delete(*s, item)
 }
 
-// RoundToTwoDecimalPlaces rounds a float64 to two decimal places.
+// RoundToTwoDecimalPlaces rounds a float64 to two decimal places, for ratios and percentages.
+// Amounts are rounded to their currency's minor unit with RoundToCurrency.
 func RoundToTwoDecimalPlaces(f float64) float64 {
 	return math.Round(f*100) / 100
 }
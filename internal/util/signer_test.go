@@ -0,0 +1,29 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigner(t *testing.T) {
+	s := NewSigner("test-key")
+
+	// Test case 1: A token round-trips, dots in the payload included
+	payload, ok := s.Verify(s.Sign("42.1800000000"))
+	assert.True(t, ok)
+	assert.Equal(t, "42.1800000000", payload)
+
+	// Test case 2: Another payload, another key or no signature fails
+	token := s.Sign("42")
+	_, ok = s.Verify("43" + token[2:])
+	assert.False(t, ok)
+	_, ok = s.Verify(NewSigner("other-key").Sign("42"))
+	assert.False(t, ok)
+	for _, bad := range []string{"", "42", "42.", "42.!!"} {
+		_, ok = s.Verify(bad)
+		assert.False(t, ok, bad)
+	}
+
+	assert.False(t, NewSigner("").Enabled())
+}
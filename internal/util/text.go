@@ -0,0 +1,46 @@
+package util
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxTextLength is the most characters any free text, like a description, name or dispute reason,
+// may hold once sanitized. The columns it is stored in are VARCHAR(255), which count characters.
+const MaxTextLength = 255
+
+// SanitizeText returns the form free text is stored in. It is NFC-normalized, so an accented letter
+// counts as one character however it was typed; line breaks and tabs become spaces; other control
+// characters, invalid UTF-8 and the bidi embeddings, overrides and isolates that could reorder the
+// text around it are dropped; and surrounding space is trimmed. Emoji, right-to-left scripts and
+// the joiners, variation selectors and direction marks they rely on are kept.
+func SanitizeText(s string) string {
+	s = norm.NFC.String(strings.ToValidUTF8(s, ""))
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			b.WriteRune(' ')
+		case unicode.IsControl(r) || isBidiControl(r):
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// TextLength is the number of characters s holds once sanitized, which is what length limits count.
+func TextLength(s string) int {
+	return utf8.RuneCountInString(SanitizeText(s))
+}
+
+// isBidiControl reports whether r is an explicit bidi embedding, override or isolate. Left
+// unbalanced they carry on reversing whatever is displayed after the text. The plain left-to-right
+// and right-to-left marks are not among them.
+func isBidiControl(r rune) bool {
+	return (r >= '\u202A' && r <= '\u202E') || (r >= '\u2066' && r <= '\u2069')
+}
@@ -0,0 +1,26 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeText(t *testing.T) {
+	// Emoji, including ZWJ sequences and skin tones, pass through
+	assert.Equal(t, "Pizza 🍕 with 👩‍👩‍👧 and 👍🏽", SanitizeText("  Pizza 🍕 with 👩‍👩‍👧 and 👍🏽 "))
+	// Right-to-left text and its direction marks are kept
+	assert.Equal(t, "عشاء\u200f في المطعم", SanitizeText("عشاء\u200f في المطعم"))
+	// Decomposed accents are composed
+	assert.Equal(t, "Caf\u00e9", SanitizeText("Cafe\u0301"))
+	// Control characters go, line breaks and tabs become spaces
+	assert.Equal(t, "Rent  for may", SanitizeText("Rent\r\nfor\x00\x1b\tmay"))
+	// Bidi overrides and invalid UTF-8 are dropped
+	assert.Equal(t, "invoice.pdf", SanitizeText("invoice\u202e.pdf\u2069\xff"))
+	assert.Equal(t, "", SanitizeText(" \x07\n "))
+}
+
+func TestTextLength(t *testing.T) {
+	assert.Equal(t, 4, TextLength("Cafe\u0301"))
+	assert.Equal(t, 1, TextLength(" 🍕\x00 "))
+}
@@ -0,0 +1,95 @@
+const status = document.getElementById("status");
+
+// api calls the JSON API and returns the data of its response envelope, or throws with the
+// envelope's error message. Bare payloads and plain-text errors from a server with
+// LEGACY_RESPONSES on are understood too.
+async function api(method, path, body) {
+  const res = await fetch(path, {
+    method,
+    headers: body ? { "Content-Type": "application/json" } : {},
+    body: body ? JSON.stringify(body) : undefined,
+  });
+  const text = await res.text();
+  let payload = null;
+  try {
+    payload = text ? JSON.parse(text) : null;
+  } catch {
+    // A legacy plain-text error
+  }
+  const enveloped = payload !== null && typeof payload === "object" && "data" in payload && "meta" in payload;
+  if (!res.ok) {
+    throw new Error(enveloped && payload.error ? payload.error.message : text.trim());
+  }
+  return enveloped ? payload.data : payload;
+}
+
+function fill(table, headers, rows) {
+  table.innerHTML = "";
+  const head = table.insertRow();
+  headers.forEach((h) => {
+    const th = document.createElement("th");
+    th.textContent = h;
+    head.appendChild(th);
+  });
+  rows.forEach((cells) => {
+    const row = table.insertRow();
+    cells.forEach((c) => (row.insertCell().textContent = c));
+  });
+}
+
+document.getElementById("user-form").addEventListener("submit", async (e) => {
+  e.preventDefault();
+  const f = new FormData(e.target);
+  try {
+    const user = await api("POST", "/users", { name: f.get("name"), email: f.get("email") });
+    status.textContent = `Created user ${user.name} (${user.id})`;
+    e.target.reset();
+  } catch (err) {
+    status.textContent = err.message;
+  }
+});
+
+document.getElementById("expense-form").addEventListener("submit", async (e) => {
+  e.preventDefault();
+  const f = new FormData(e.target);
+  const total = parseFloat(f.get("total_amount"));
+  const payer = f.get("created_by_email");
+  const emails = f.get("participants").split(",").map((s) => s.trim()).filter(Boolean);
+  if (!emails.includes(payer)) {
+    emails.unshift(payer);
+  }
+  try {
+    const expense = await api("POST", "/expenses", {
+      description: f.get("description"),
+      tag: f.get("tag"),
+      total_amount: total,
+      created_by_email: payer,
+      split_method: "equal",
+      equal_splits: emails.map((email) => ({ user_email: email, amount_paid: email === payer ? total : 0 })),
+    });
+    status.textContent = `Created expense ${expense.id}`;
+    e.target.reset();
+  } catch (err) {
+    status.textContent = err.message;
+  }
+});
+
+document.getElementById("lookup-form").addEventListener("submit", async (e) => {
+  e.preventDefault();
+  const email = encodeURIComponent(new FormData(e.target).get("email"));
+  try {
+    const overall = await api("GET", `/balances/overall/by-user/${email}`);
+    document.getElementById("overall").textContent = overall.overall_balance.toFixed(2);
+
+    const balances = (await api("GET", `/balances/by-user/${email}`)) || [];
+    fill(document.getElementById("balances"), ["With", "Amount"],
+      balances.map((b) => [`${b.with_user_name} <${b.with_user_email}>`, b.amount.toFixed(2)]));
+
+    const expenses = (await api("GET", `/expenses/by-user/${email}`)) || [];
+    fill(document.getElementById("expenses"), ["Date", "Description", "Tag", "Total", "Share"],
+      expenses.map((x) => [new Date(x.date).toLocaleDateString(), x.description, x.tag, x.total_amount.toFixed(2), x.share.toFixed(2)]));
+    status.textContent = "";
+  } catch (err) {
+    status.textContent = err.message;
+  }
+});
@@ -0,0 +1,59 @@
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// root is the bundled UI, with index.html at its top.
+var root = func() fs.FS {
+	root, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		// The embedded directory is fixed at compile time, so this can't happen at runtime.
+		panic(err)
+	}
+	return root
+}()
+
+// bundled reports whether the request's path names a file of the UI.
+func bundled(r *http.Request) bool {
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if name == "" {
+		return false
+	}
+	info, err := fs.Stat(root, name)
+	return err == nil && !info.IsDir()
+}
+
+// Serves reports whether the UI should answer the request: it asks for a bundled file, or it is a
+// page load, one that accepts HTML, which gets index.html so client-side routes survive a reload.
+// Anything else, such as an API call to a path that doesn't exist, is left to the API to refuse.
+func Serves(r *http.Request) bool {
+	return r.URL.Path == "/" || bundled(r) || strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// Handler serves the embedded web UI. Paths that don't match a bundled file fall back to
+// index.html, so it belongs behind Serves wherever it shares paths with an API.
+func Handler() http.Handler {
+	fileServer := http.FileServer(http.FS(root))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if bundled(r) {
+			fileServer.ServeHTTP(w, r)
+			return
+		}
+
+		index, err := fs.ReadFile(root, "index.html")
+		if err != nil {
+			http.Error(w, "index.html not found", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(index)
+	})
+}
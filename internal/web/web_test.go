@@ -0,0 +1,62 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	h := Handler()
+
+	// Test case 1: Root serves index.html
+	{
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Header().Get("Content-Type"), "text/html")
+		assert.Contains(t, rr.Body.String(), "<title>Split Expense</title>")
+	}
+
+	// Test case 2: Bundled assets are served as files
+	{
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest("GET", "/app.js", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), "async function api")
+	}
+
+	// Test case 3: Unknown paths fall back to index.html
+	{
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest("GET", "/some/client/route", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), "<title>Split Expense</title>")
+	}
+}
+
+func TestServes(t *testing.T) {
+	request := func(path, accept string) *http.Request {
+		r := httptest.NewRequest("GET", path, nil)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		return r
+	}
+
+	// Test case 1: The root and bundled files, whatever the request accepts
+	assert.True(t, Serves(request("/", "")))
+	assert.True(t, Serves(request("/app.js", "*/*")))
+
+	// Test case 2: A page load of a client-side route
+	assert.True(t, Serves(request("/some/client/route", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")))
+
+	// Test case 3: An API call to a path that doesn't exist is not the UI's
+	assert.False(t, Serves(request("/expenses/by-usr/alice@example.com", "application/json")))
+	assert.False(t, Serves(request("/expenses/by-usr/alice@example.com", "")))
+}
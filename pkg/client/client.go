@@ -0,0 +1,199 @@
+// Package client is a typed Go client for the split-expense API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aadithya-md/split-expense/internal/response"
+)
+
+// Options configures a Client. Only BaseURL is required.
+type Options struct {
+	// BaseURL is where the API is served, including any prefix it is mounted under.
+	BaseURL string
+	// HTTPClient sends the requests. Defaults to a client with a 10 second timeout.
+	HTTPClient *http.Client
+	// Username and Password are sent as basic auth when set, as the admin routes and proxies in
+	// front of the API may require.
+	Username string
+	Password string
+	// MaxRetries is how many times a failed request is retried. Defaults to 2; negative disables
+	// retries.
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry, doubling after each. Defaults to 200ms.
+	RetryBackoff time.Duration
+}
+
+// Error is a response the API refused or failed, with the message it sent.
+type Error struct {
+	StatusCode int
+	Message    string
+	// Code and Field say why a request body was refused, such as "unknown_field" and the field's
+	// name. They are empty for other failures.
+	Code  string
+	Field string
+	// RequestID identifies the request in the server's logs. It is empty when the failure came from
+	// something in front of the API, like a proxy.
+	RequestID string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("split-expense API returned %d: %s", e.StatusCode, e.Message)
+}
+
+// Client calls the split-expense API. It is safe for concurrent use.
+type Client struct {
+	opts Options
+}
+
+func New(opts Options) *Client {
+	opts.BaseURL = strings.TrimSuffix(opts.BaseURL, "/")
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = 2
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = 200 * time.Millisecond
+	}
+	return &Client{opts: opts}
+}
+
+// CreateUser registers a user. An email that is already registered fails with a 409 Error.
+func (c *Client) CreateUser(ctx context.Context, req CreateUserRequest) (*User, error) {
+	var user User
+	if err := c.do(ctx, "POST", "/users", req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetOrCreateUser returns the user registered with req's email, registering them first if there is
+// none. An existing user is returned as stored, keeping their name.
+func (c *Client) GetOrCreateUser(ctx context.Context, req CreateUserRequest) (*User, error) {
+	var user User
+	if err := c.do(ctx, "POST", "/users?mode=upsert", req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreateExpense records an expense and returns it with its splits and balance changes.
+func (c *Client) CreateExpense(ctx context.Context, req CreateExpenseRequest) (*Expense, error) {
+	var expense Expense
+	if err := c.do(ctx, "POST", "/expenses", req, &expense); err != nil {
+		return nil, err
+	}
+	return &expense, nil
+}
+
+// GetBalances returns what the user owes and is owed, one entry per other user. A positive amount is
+// owed to the user.
+func (c *Client) GetBalances(ctx context.Context, email string) ([]UserBalanceView, error) {
+	var balances []UserBalanceView
+	if err := c.do(ctx, "GET", "/balances/by-user/"+url.PathEscape(email), nil, &balances); err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// Settle proposes a settlement from payer to payee. It is applied to their balance once the payee
+// confirms it.
+func (c *Client) Settle(ctx context.Context, req ProposeSettlementRequest) (*Settlement, error) {
+	var settlement Settlement
+	if err := c.do(ctx, "POST", "/settlements", req, &settlement); err != nil {
+		return nil, err
+	}
+	return &settlement, nil
+}
+
+// do sends the request, retrying what is safe to retry, and decodes a successful response into out.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	backoff := c.opts.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := c.send(ctx, method, path, payload, out)
+		if err == nil || attempt >= c.opts.MaxRetries || !retryable(method, err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, path string, payload []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.opts.BaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.opts.Username != "" || c.opts.Password != "" {
+		req.SetBasicAuth(c.opts.Username, c.opts.Password)
+	}
+
+	resp, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		var env response.Envelope
+		if json.Unmarshal(body, &env) == nil && env.Error != nil {
+			return &Error{StatusCode: resp.StatusCode, Message: env.Error.Message, Code: env.Error.Code, Field: env.Error.Field, RequestID: env.Meta.RequestID}
+		}
+		// Not from the API itself, so whatever was sent is the best explanation there is
+		return &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+	env := response.Envelope{Data: out}
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return fmt.Errorf("failed to decode response from %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// retryable tells whether a failed request can be sent again. Reads are retried after any transport
+// error or a response saying the server was busy or unavailable. Writes are only retried after a 429,
+// which the API sends before doing anything, since a write that failed any other way may have been
+// applied.
+func retryable(method string, err error) bool {
+	var apiErr *Error
+	isAPIError := errors.As(err, &apiErr)
+	if method != "GET" {
+		return isAPIError && apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	if !isAPIError {
+		// Transport errors, but not the caller giving up
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
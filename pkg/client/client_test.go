@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aadithya-md/split-expense/pkg/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_AgainstServer(t *testing.T) {
+	cfg, err := server.DefaultConfig()
+	require.NoError(t, err)
+	srv, err := server.New(cfg, nil)
+	require.NoError(t, err)
+	api := httptest.NewServer(srv)
+	defer api.Close()
+
+	c := New(Options{BaseURL: api.URL})
+	ctx := context.Background()
+
+	// Test case 1: Users, an expense, the balance it leaves and a settlement of it
+	alice, err := c.CreateUser(ctx, CreateUserRequest{Name: "Alice", Email: "alice@client.example"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice@client.example", alice.Email)
+	_, err = c.CreateUser(ctx, CreateUserRequest{Name: "Bob", Email: "bob@client.example"})
+	require.NoError(t, err)
+
+	expense, err := c.CreateExpense(ctx, CreateExpenseRequest{
+		Description:    "Dinner",
+		TotalAmount:    100,
+		CreatedByEmail: "alice@client.example",
+		SplitMethod:    SplitMethodEqual,
+		EqualSplits: []EqualSplitRequest{
+			{UserEmail: "alice@client.example", AmountPaid: 100},
+			{UserEmail: "bob@client.example"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Len(t, expense.Splits, 2)
+
+	balances, err := c.GetBalances(ctx, "alice@client.example")
+	require.NoError(t, err)
+	require.Len(t, balances, 1)
+	assert.Equal(t, 50.0, balances[0].Amount)
+
+	settlement, err := c.Settle(ctx, ProposeSettlementRequest{PayerEmail: "bob@client.example", PayeeEmail: "alice@client.example", Amount: 50})
+	require.NoError(t, err)
+	assert.Equal(t, 50.0, settlement.Amount)
+
+	// Test case 2: Refusals come back as an Error with the status and message
+	_, err = c.CreateUser(ctx, CreateUserRequest{Name: "Alice", Email: "alice@client.example"})
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+	assert.Contains(t, apiErr.Message, "already registered")
+	assert.NotEmpty(t, apiErr.RequestID)
+
+	// Test case 3: Upserting a registered email returns the user as stored
+	again, err := c.GetOrCreateUser(ctx, CreateUserRequest{Name: "Alice Again", Email: "Alice@Client.example"})
+	require.NoError(t, err)
+	assert.Equal(t, alice, again)
+	carol, err := c.GetOrCreateUser(ctx, CreateUserRequest{Name: "Carol", Email: "carol@client.example"})
+	require.NoError(t, err)
+	assert.Equal(t, "Carol", carol.Name)
+}
+
+func TestClient_Retries(t *testing.T) {
+	var calls atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "admin" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if calls.Add(1) == 1 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		if r.Method == "POST" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"data":[],"meta":{"api_version":"1"},"error":null}`))
+	}))
+	defer api.Close()
+	c := New(Options{BaseURL: api.URL, Username: "admin", Password: "secret", RetryBackoff: time.Millisecond})
+
+	// Test case 1: A read that found the server unavailable is sent again
+	balances, err := c.GetBalances(context.Background(), "a@client.example")
+	require.NoError(t, err)
+	assert.Empty(t, balances)
+	assert.Equal(t, int32(2), calls.Load())
+
+	// Test case 2: A write that may have been applied is not
+	_, err = c.Settle(context.Background(), ProposeSettlementRequest{PayerEmail: "a@client.example", PayeeEmail: "b@client.example", Amount: 1})
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// Test case 3: A canceled context stops the request
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.GetBalances(ctx, "a@client.example")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(3), calls.Load())
+}
@@ -0,0 +1,33 @@
+package client
+
+import (
+	"github.com/aadithya-md/split-expense/internal/handler"
+	"github.com/aadithya-md/split-expense/internal/repository"
+	"github.com/aadithya-md/split-expense/internal/service"
+)
+
+// The request and response types are the ones the handlers decode and encode, so the client
+// can't drift from the API.
+type (
+	CreateUserRequest        = handler.CreateUserRequest
+	User                     = repository.User
+	CreateExpenseRequest     = service.CreateExpenseRequest
+	SplitMethodType          = service.SplitMethodType
+	EqualSplitRequest        = service.EqualSplitRequest
+	PercentageSplitRequest   = service.PercentageSplitRequest
+	ManualSplitRequest       = service.ManualSplitRequest
+	DaysSplitRequest         = service.DaysSplitRequest
+	WeightedSplitRequest     = service.WeightedSplitRequest
+	Expense                  = repository.Expense
+	UserBalanceView          = service.UserBalanceView
+	ProposeSettlementRequest = service.ProposeSettlementRequest
+	Settlement               = repository.Settlement
+)
+
+const (
+	SplitMethodEqual      = service.SplitMethodEqual
+	SplitMethodPercentage = service.SplitMethodPercentage
+	SplitMethodManual     = service.SplitMethodManual
+	SplitMethodDays       = service.SplitMethodDays
+	SplitMethodWeighted   = service.SplitMethodWeighted
+)
@@ -0,0 +1,77 @@
+// Package server embeds the split-expense API in another Go program. Mount a Server under your own
+// router, strip the prefix it is mounted at, and run its background work alongside:
+//
+//	srv, err := server.New(cfg, db)
+//	...
+//	mux.Handle("/split/", http.StripPrefix("/split", srv))
+//	go srv.Run(ctx)
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/aadithya-md/split-expense/internal/app"
+	"github.com/aadithya-md/split-expense/internal/config"
+	"github.com/aadithya-md/split-expense/internal/repository"
+)
+
+// Config is the service's configuration, laid out like config/default.yaml.
+type Config = config.Config
+
+// LoadConfig reads ./config/default.yaml and the APP_ENV profile, like the standalone server.
+func LoadConfig() (*Config, error) {
+	return config.LoadConfig()
+}
+
+// DefaultConfig returns the configuration the standalone server runs with when it has no config file.
+func DefaultConfig() (*Config, error) {
+	return config.Defaults()
+}
+
+// Server is the split-expense API. It serves every route the standalone server does, from the root.
+type Server struct {
+	app *app.App
+}
+
+// New wires the API on db, which must already hold the schema from db/migrations and stays owned by
+// the caller. With a nil db, everything is kept in memory instead and lost when the program exits.
+func New(cfg *Config, db *sql.DB) (*Server, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	var (
+		a   *app.App
+		err error
+	)
+	if db == nil {
+		a, err = app.NewInMemory(cfg)
+	} else {
+		if err := repository.VerifySchema(db); err != nil {
+			return nil, err
+		}
+		a, err = app.NewWithDB(cfg, db)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Server{app: a}, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.app.Router.ServeHTTP(w, r)
+}
+
+// Run does the background work the API relies on, such as sending notifications and queueing
+// digests, loan reminders and month-end settle-ups, until ctx is done. It returns once the job in flight has finished.
+func (s *Server) Run(ctx context.Context) {
+	go s.app.DigestService.Run(ctx, s.app.Config.Notifications.DigestCheckInterval)
+	go s.app.LoanService.Run(ctx, s.app.Config.Notifications.ReminderCheckInterval)
+	if s.app.Config.SettleUp.Enabled {
+		go s.app.SettleUpService.Run(ctx, s.app.Config.SettleUp.CheckInterval)
+	}
+	s.app.JobService.Run(ctx)
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_MountedUnderPrefix(t *testing.T) {
+	cfg, err := DefaultConfig()
+	require.NoError(t, err)
+	srv, err := New(cfg, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		srv.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("/split/", http.StripPrefix("/split", srv))
+	host := httptest.NewServer(mux)
+	defer host.Close()
+
+	// Test case 1: The API answers under the prefix it is mounted at
+	resp, err := http.Post(host.URL+"/split/users", "application/json", strings.NewReader(`{"name":"Alice","email":"alice@embed.example"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = http.Get(host.URL + "/split/users/by-email/alice@embed.example")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var user struct {
+		Data struct {
+			Name string `json:"name"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&user))
+	assert.Equal(t, "Alice", user.Data.Name)
+
+	// Test case 2: An invalid config is refused up front
+	cfg.Storage.Backend = "postgres"
+	_, err = New(cfg, nil)
+	assert.ErrorContains(t, err, "STORAGE.BACKEND")
+}
+
+func TestServer_WebUIFallback(t *testing.T) {
+	cfg, err := DefaultConfig()
+	require.NoError(t, err)
+	cfg.Frontend.Enabled = true
+	srv, err := New(cfg, nil)
+	require.NoError(t, err)
+	host := httptest.NewServer(srv)
+	defer host.Close()
+
+	get := func(path, accept string) (*http.Response, string) {
+		req, err := http.NewRequest("GET", host.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", accept)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	// Test case 1: A page load of a client-side route gets the UI
+	resp, body := get("/some/client/route", "text/html")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "<title>Split Expense</title>")
+
+	// Test case 2: An API call to a path that doesn't exist is a JSON 404, not the UI
+	resp, body = get("/loans/by-usr/alice@example.com", "application/json")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "application/json")
+	assert.NotContains(t, body, "<title>")
+
+	// Test case 3: A GET of a path that only takes POST is still refused as such
+	resp, _ = get("/loans", "application/json")
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+// webUIState is what testdata/webui.js reports the page showing after each step.
+type webUIState struct {
+	Status   string     `json:"status"`
+	Overall  string     `json:"overall"`
+	Balances [][]string `json:"balances"`
+	Expenses [][]string `json:"expenses"`
+}
+
+func TestServer_WebUI(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+	cfg, err := DefaultConfig()
+	require.NoError(t, err)
+	cfg.Frontend.Enabled = true
+	srv, err := New(cfg, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		srv.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	host := httptest.NewServer(srv)
+	defer host.Close()
+
+	out, err := exec.Command(node, "testdata/webui.js", host.URL).Output()
+	require.NoError(t, err, string(out))
+	var steps []webUIState
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		var step webUIState
+		require.NoError(t, json.Unmarshal([]byte(line), &step), line)
+		steps = append(steps, step)
+	}
+	require.Len(t, steps, 5)
+
+	// Test case 1: Created users and expenses are read out of the response envelope, by public ID
+	assert.Regexp(t, `^Created user Alice \([0-9a-f-]{36}\)$`, steps[0].Status)
+	assert.Regexp(t, `^Created user Bob \([0-9a-f-]{36}\)$`, steps[1].Status)
+	assert.Regexp(t, `^Created expense [0-9a-f-]{36}$`, steps[2].Status)
+
+	// Test case 2: The lookup fills the balance and the tables from enveloped data
+	assert.Empty(t, steps[3].Status)
+	assert.Equal(t, "-15.00", steps[3].Overall)
+	assert.Equal(t, [][]string{{"Alice <alice@ui.example>", "-15.00"}}, steps[3].Balances)
+	require.Len(t, steps[3].Expenses, 1)
+	assert.Equal(t, []string{"Pizza", "food", "30.00", "-15.00"}, steps[3].Expenses[0][1:])
+
+	// Test case 3: An API error shows the envelope's message, not the raw JSON
+	assert.Contains(t, steps[4].Status, "email is already registered")
+	assert.False(t, strings.HasPrefix(steps[4].Status, "{"), steps[4].Status)
+}
@@ -0,0 +1,94 @@
+// Drives the web UI's app.js against a running server, with just enough of a DOM for its forms
+// and tables. Usage: node webui.js <server URL>. After each step it prints what the page shows as
+// one line of JSON.
+const base = process.argv[2];
+const serverFetch = globalThis.fetch;
+globalThis.fetch = (path, options) => serverFetch(base + path, options);
+
+function table() {
+  return {
+    rows: [],
+    set innerHTML(_) {
+      this.rows = [];
+    },
+    insertRow() {
+      const cells = [];
+      this.rows.push(cells);
+      return {
+        appendChild: (cell) => cells.push(cell),
+        insertCell: () => {
+          const cell = { textContent: "" };
+          cells.push(cell);
+          return cell;
+        },
+      };
+    },
+  };
+}
+
+function form() {
+  return {
+    values: {},
+    addEventListener(type, listener) {
+      this.listener = listener;
+    },
+    reset() {
+      this.values = {};
+    },
+  };
+}
+
+const elements = {
+  status: { textContent: "" },
+  overall: { textContent: "" },
+  balances: table(),
+  expenses: table(),
+  "user-form": form(),
+  "expense-form": form(),
+  "lookup-form": form(),
+};
+globalThis.document = {
+  getElementById: (id) => elements[id],
+  createElement: () => ({ textContent: "" }),
+};
+globalThis.FormData = class {
+  constructor(form) {
+    this.form = form;
+  }
+  get(name) {
+    return this.form.values[name] ?? null;
+  }
+};
+
+async function submit(id, values) {
+  const form = elements[id];
+  form.values = values;
+  await form.listener({ preventDefault() {}, target: form });
+  const rows = (t) => t.rows.slice(1).map((cells) => cells.map((c) => c.textContent));
+  console.log(JSON.stringify({
+    status: elements.status.textContent,
+    overall: elements.overall.textContent,
+    balances: rows(elements.balances),
+    expenses: rows(elements.expenses),
+  }));
+}
+
+(async () => {
+  const res = await serverFetch(base + "/app.js");
+  new Function(await res.text())();
+
+  await submit("user-form", { name: "Alice", email: "alice@ui.example" });
+  await submit("user-form", { name: "Bob", email: "bob@ui.example" });
+  await submit("expense-form", {
+    description: "Pizza",
+    tag: "food",
+    total_amount: "30",
+    created_by_email: "alice@ui.example",
+    participants: "bob@ui.example",
+  });
+  await submit("lookup-form", { email: "bob@ui.example" });
+  await submit("user-form", { name: "Alice", email: "alice@ui.example" });
+})().catch((err) => {
+  console.error(err);
+  process.exit(1);
+});